@@ -29,6 +29,15 @@ func main() {
 	}
 
 	a := app.App{}
+
+	// `migrate` manages the database schema instead of starting the server
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := a.Migrate(os.Args[2:]); err != nil {
+			log.Fatalf("Migration failed: %s\n", err)
+		}
+		return
+	}
+
 	a.Initialize()
 	a.Run()
 }
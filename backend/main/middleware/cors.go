@@ -2,24 +2,89 @@ package middleware
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/DapperCollectives/CAST/backend/main/shared"
 )
 
+// TenantHeader names the tenant of a request, by slug or id, for frontends
+// that don't have a hostname of their own.
+const TenantHeader = "X-Tenant-ID"
+
+// headers browser clients may send and read across origins
+const (
+	corsAllowHeaders  = "Authorization, Content-Type, If-Match, If-None-Match, X-API-Key, " + RequestIDHeader + ", " + TenantHeader
+	corsExposeHeaders = "ETag, Last-Modified, Link, Retry-After, " + RequestIDHeader
+	corsMaxAge        = "600"
+)
+
+// corsPolicy is the set of origins allowed on a group of routes.
+type corsPolicy struct {
+	any     bool
+	origins map[string]bool
+}
+
+func newCorsPolicy(list string) corsPolicy {
+	p := corsPolicy{origins: make(map[string]bool)}
+	for _, origin := range strings.Fields(list) {
+		if origin == "*" {
+			p.any = true
+		}
+		p.origins[strings.TrimSuffix(origin, "/")] = true
+	}
+	return p
+}
+
+func (p corsPolicy) allows(origin string) bool {
+	return p.any || p.origins[origin]
+}
+
+// UseCors answers cross-origin requests with the policy of the route: the
+// admin origins for /admin, any origin for embeds, and the public origins
+// for the rest. Preflights are answered here without reaching the handler.
 func UseCors(c shared.Config) func(http.Handler) http.Handler {
+	public := newCorsPolicy(c.Cors_origins)
+	admin := newCorsPolicy(c.Cors_admin_origins)
+	open := corsPolicy{any: true}
+	// the flag predates the origin lists, and opened every route
+	if c.Features["useCorsMiddleware"] && c.Cors_origins == "" && c.Cors_admin_origins == "" {
+		public, admin = open, open
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if c.Features["useCorsMiddleware"] {
-				w.Header().Add("Access-Control-Allow-Origin", "*")
-				w.Header().Add("Access-Control-Allow-Headers", "*")
-
-				// handle preflight
-				if r.Method == "OPTIONS" {
-					w.WriteHeader(http.StatusOK)
-					return
+			policy := public
+			switch {
+			case strings.HasPrefix(r.URL.Path, "/admin/"):
+				policy = admin
+			case strings.HasPrefix(r.URL.Path, "/embed/"), r.URL.Path == "/oembed":
+				policy = open
+			}
+
+			origin := r.Header.Get("Origin")
+			if !policy.any {
+				w.Header().Add("Vary", "Origin")
+			}
+			allowed := policy.any || (origin != "" && policy.allows(origin))
+			if allowed {
+				if policy.any {
+					w.Header().Set("Access-Control-Allow-Origin", "*")
+				} else {
+					w.Header().Set("Access-Control-Allow-Origin", origin)
+				}
+				w.Header().Set("Access-Control-Expose-Headers", corsExposeHeaders)
+			}
+
+			// handle preflight, leaving out the allow headers for origins
+			// that are not allowed so the browser stops there
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				if allowed {
+					w.Header().Set("Access-Control-Allow-Headers", corsAllowHeaders)
+					w.Header().Set("Access-Control-Max-Age", corsMaxAge)
 				}
+				w.WriteHeader(http.StatusNoContent)
+				return
 			}
-			// Call the next handler, which can be another middleware in the chain, or the final handler.
 			next.ServeHTTP(w, r)
 		})
 	}
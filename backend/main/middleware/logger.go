@@ -10,7 +10,7 @@ import (
 func Logger(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if flag.Lookup("test.v") == nil {
-			log.Info().Msgf("%s %s", r.Method, r.RequestURI)
+			log.Ctx(r.Context()).Info().Msgf("%s %s", r.Method, r.RequestURI)
 		}
 		next.ServeHTTP(w, r)
 	})
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+)
+
+// ContentSecurityPolicy suits an API that serves data rather than pages;
+// handlers that serve a page set their own.
+const ContentSecurityPolicy = "default-src 'none'; frame-ancestors 'none'"
+
+// SecurityHeaders sets the headers that keep browsers from sniffing, framing
+// or leaking responses, and HSTS when HSTS_MAX_AGE is set.
+func SecurityHeaders(c shared.Config) func(http.Handler) http.Handler {
+	hsts := ""
+	if c.Hsts_max_age > 0 {
+		hsts = "max-age=" + strconv.Itoa(c.Hsts_max_age) + "; includeSubDomains"
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("X-Content-Type-Options", "nosniff")
+			h.Set("X-Frame-Options", "DENY")
+			h.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+			h.Set("Content-Security-Policy", ContentSecurityPolicy)
+			if hsts != "" {
+				h.Set("Strict-Transport-Security", hsts)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
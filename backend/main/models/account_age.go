@@ -0,0 +1,35 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// AccountAge requires the accounts of voters to have existed on Flow for a
+// while, so fresh wallets can't be made to vote many times. The account
+// must have existed at the block, and for the minimum age, in seconds,
+// before the proposal started. nil means no requirement.
+type AccountAge struct {
+	Account_created_before_block *uint64 `json:"accountCreatedBeforeBlock,omitempty"`
+	Min_account_age              *int    `json:"minAccountAge,omitempty"`
+}
+
+func (a AccountAge) IsZero() bool {
+	return a.Account_created_before_block == nil && seconds(a.Min_account_age) == 0
+}
+
+func (a AccountAge) Validate() error {
+	if seconds(a.Min_account_age) < 0 {
+		return errors.New("Minimum account age cannot be negative.")
+	}
+	return nil
+}
+
+// CreatedBy is the time voting accounts must have been created by to vote
+// on a proposal starting at start, and whether there is such a time.
+func (a AccountAge) CreatedBy(start time.Time) (time.Time, bool) {
+	if seconds(a.Min_account_age) <= 0 {
+		return time.Time{}, false
+	}
+	return start.Add(-seconds(a.Min_account_age)), true
+}
@@ -0,0 +1,214 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	AchievementFirstVote      = "firstVote"
+	AchievementEarlyVote      = "earlyVote"
+	AchievementStreak         = "streak"
+	AchievementWinningVote    = "winningVote"
+	AchievementProposalAuthor = "proposalAuthor"
+)
+
+// ACHIEVEMENT_POINTS are the points awarded for each achievement type.
+var ACHIEVEMENT_POINTS = map[string]int{
+	AchievementFirstVote:      1,
+	AchievementEarlyVote:      1,
+	AchievementStreak:         1,
+	AchievementWinningVote:    1,
+	AchievementProposalAuthor: 2,
+}
+
+// PROPOSAL_AUTHOR_MILESTONES are the authored proposal counts that earn an achievement.
+var PROPOSAL_AUTHOR_MILESTONES = []int{1, 5, 10, 25, 50, 100}
+
+// number of closed proposals processed per achievements run
+const achievementsBatchSize = 50
+
+type Achievement struct {
+	ID               int        `json:"id"`
+	Addr             string     `json:"addr"`
+	Achievement_type string     `json:"achievementType"`
+	Community_id     int        `json:"communityId"`
+	Proposals        []int64    `json:"proposals"`
+	Details          string     `json:"details"`
+	Created_at       *time.Time `json:"createdAt,omitempty"`
+	Updated_at       *time.Time `json:"updatedAt,omitempty"`
+	Points           int        `json:"points"`
+}
+
+func GetAchievementsForAddress(
+	db *s.Database,
+	addr string,
+	communityId int,
+	achievementType string,
+	pageParams shared.PageParams,
+) ([]*Achievement, int, error) {
+	var achievements []*Achievement
+	err := pgxscan.Select(db.Context, db.Conn, &achievements,
+		`
+		SELECT * FROM user_achievements
+		WHERE addr = $1
+		AND ($2 = 0 OR community_id = $2)
+		AND ($3 = '' OR achievement_type::text = $3)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4 OFFSET $5
+		`, addr, communityId, achievementType, pageParams.Count, pageParams.Start)
+
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, 0, err
+	} else if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return []*Achievement{}, 0, nil
+	}
+
+	var totalRecords int
+	countSql := `
+		SELECT COUNT(*) FROM user_achievements
+		WHERE addr = $1
+		AND ($2 = 0 OR community_id = $2)
+		AND ($3 = '' OR achievement_type::text = $3)
+	`
+	_ = db.Conn.QueryRow(db.Context, countSql, addr, communityId, achievementType).Scan(&totalRecords)
+
+	return achievements, totalRecords, nil
+}
+
+// GetProposalsPendingAchievements returns closed proposals whose achievements
+// have not been computed yet, oldest first. Proposals only count once the
+// close worker has finalized them.
+func GetProposalsPendingAchievements(db *s.Database) ([]*Proposal, error) {
+	var proposals []*Proposal
+	sql := fmt.Sprintf(`
+		SELECT *, %s FROM proposals
+		WHERE achievements_done = 'false' AND status = 'closed'
+		ORDER BY end_time ASC
+		LIMIT $1
+	`, computedStatusSQL)
+	err := pgxscan.Select(db.Context, db.Conn, &proposals, sql, achievementsBatchSize)
+
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	return proposals, nil
+}
+
+// AddProposalAchievements awards the achievements earned on a closed proposal
+// and marks the proposal as done, all in one transaction. Achievements are
+// keyed by their details, so running it again for the same proposal does not
+// award anything twice.
+func AddProposalAchievements(db *s.Database, p *Proposal, votes []*VoteWithBalance, results ProposalResults) error {
+	return db.WithTx(func(tx *s.Database) error {
+		winningChoice := getWinningChoice(results)
+		proposals := []int64{int64(p.ID)}
+
+		for _, v := range votes {
+			if err := addFirstVoteAchievement(tx, v.Addr, p.Community_id); err != nil {
+				return err
+			}
+
+			if v.IsEarly {
+				details := fmt.Sprintf("%s:%d:%s", AchievementEarlyVote, p.ID, v.Addr)
+				if err := addAchievement(tx, v.Addr, AchievementEarlyVote, p.Community_id, proposals, details); err != nil {
+					return err
+				}
+			}
+
+			if v.Choice == winningChoice {
+				details := fmt.Sprintf("%s:%d:%s", AchievementWinningVote, p.ID, v.Addr)
+				if err := addAchievement(tx, v.Addr, AchievementWinningVote, p.Community_id, proposals, details); err != nil {
+					return err
+				}
+			}
+
+			if err := addStreakAchievements(tx, v.Addr, p.Community_id); err != nil {
+				return err
+			}
+		}
+
+		if err := addProposalAuthorAchievements(tx, p.Creator_addr, p.Community_id); err != nil {
+			return err
+		}
+
+		return AddWinningVoteAchievement(tx, votes, results)
+	})
+}
+
+func addFirstVoteAchievement(db *s.Database, addr string, communityId int) error {
+	details := fmt.Sprintf("%s:%d:%s", AchievementFirstVote, communityId, addr)
+	_, err := db.Conn.Exec(db.Context,
+		`
+		INSERT INTO user_achievements(addr, achievement_type, community_id, proposals, details, points)
+		SELECT v.addr, $3::achievement_types, p.community_id, ARRAY[v.proposal_id::bigint], $4, $5
+		FROM votes v
+		JOIN proposals p ON p.id = v.proposal_id
+		WHERE v.addr = $1 AND p.community_id = $2
+		ORDER BY v.created_at ASC
+		LIMIT 1
+		ON CONFLICT (details) DO NOTHING
+		`, addr, communityId, AchievementFirstVote, details, ACHIEVEMENT_POINTS[AchievementFirstVote])
+	return err
+}
+
+// addStreakAchievements stores one achievement per voting streak, keyed by the
+// proposal the streak started on so a growing streak is updated in place.
+func addStreakAchievements(db *s.Database, addr string, communityId int) error {
+	streaks, err := getVotingStreaks(db, addr, communityId)
+	if err != nil {
+		return err
+	}
+
+	for _, streak := range streaks {
+		proposals := make([]int64, len(streak))
+		for i, id := range streak {
+			proposals[i] = int64(id)
+		}
+		details := fmt.Sprintf("%s:%d:%s:%d", AchievementStreak, communityId, addr, streak[0])
+		if err := addAchievement(db, addr, AchievementStreak, communityId, proposals, details); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addProposalAuthorAchievements(db *s.Database, addr string, communityId int) error {
+	var authored int
+	err := db.Conn.QueryRow(db.Context,
+		`
+		SELECT COUNT(*) FROM proposals
+		WHERE creator_addr = $1 AND community_id = $2
+		AND status NOT IN ('cancelled', 'pending_review', 'rejected')
+		`, addr, communityId).Scan(&authored)
+	if err != nil {
+		return err
+	}
+
+	for _, milestone := range PROPOSAL_AUTHOR_MILESTONES {
+		if authored < milestone {
+			break
+		}
+		details := fmt.Sprintf("%s:%d:%s:%d", AchievementProposalAuthor, communityId, addr, milestone)
+		if err := addAchievement(db, addr, AchievementProposalAuthor, communityId, nil, details); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addAchievement(db *s.Database, addr, achievementType string, communityId int, proposals []int64, details string) error {
+	_, err := db.Conn.Exec(db.Context,
+		`
+		INSERT INTO user_achievements(addr, achievement_type, community_id, proposals, details, points)
+		VALUES($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (details) DO UPDATE
+		SET proposals = EXCLUDED.proposals, updated_at = now()
+		`, addr, achievementType, communityId, proposals, details, ACHIEVEMENT_POINTS[achievementType])
+	return err
+}
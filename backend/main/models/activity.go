@@ -0,0 +1,95 @@
+package models
+
+import (
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	ActivityVote        = "vote"
+	ActivityProposal    = "proposal"
+	ActivityJoin        = "join"
+	ActivityAchievement = "achievement"
+)
+
+var ACTIVITY_TYPES = []string{ActivityVote, ActivityProposal, ActivityJoin, ActivityAchievement}
+
+// Activity is something an address did in a community. Detail is the
+// choice voted for, the name of the proposal authored or the type of
+// achievement earned.
+type Activity struct {
+	Type         string     `json:"type"`
+	Community_id int        `json:"communityId"`
+	Proposal_id  *int       `json:"proposalId,omitempty"`
+	Detail       *string    `json:"detail,omitempty"`
+	Created_at   *time.Time `json:"createdAt"`
+}
+
+// activitySQL gathers everything $1 did, in the communities of tenant $2,
+// of the types in $3 (all of them when NULL).
+const activitySQL = `
+	FROM (
+		SELECT 'vote' AS type, p.community_id, v.proposal_id, v.choice::text AS detail, v.created_at
+		FROM votes v JOIN proposals p ON p.id = v.proposal_id
+		WHERE v.addr = $1 AND v.is_cancelled IS NOT TRUE
+		UNION ALL
+		SELECT 'proposal', p.community_id, p.id, p.name::text, p.created_at
+		FROM proposals p
+		WHERE p.creator_addr = $1 AND p.status NOT IN ('pending_review', 'rejected')
+		UNION ALL
+		SELECT 'join', cu.community_id, NULL, NULL, cu.created_at
+		FROM community_users cu
+		WHERE cu.addr = $1 AND cu.user_type = 'member'
+		UNION ALL
+		SELECT 'achievement', a.community_id, NULL, a.achievement_type::text, a.created_at at time zone 'utc'
+		FROM user_achievements a
+		WHERE a.addr = $1
+	) AS activity
+	WHERE community_id IN (SELECT id FROM communities WHERE tenant_id = $2)
+	AND ($3::text[] IS NULL OR type = ANY($3))
+`
+
+// GetActivityForAddress lists what the address did, latest first. Proposals
+// under review or rejected are left out.
+func GetActivityForAddress(
+	db *s.Database,
+	tenantId int,
+	addr string,
+	types []string,
+	pageParams s.PageParams,
+) ([]*Activity, int, error) {
+	if len(types) == 0 {
+		types = nil
+	}
+
+	var activity []*Activity
+	err := pgxscan.Select(db.Context, db.Conn, &activity,
+		`SELECT *`+activitySQL+`
+		ORDER BY created_at DESC NULLS LAST, type, community_id, proposal_id
+		LIMIT $4 OFFSET $5
+		`, addr, tenantId, types, pageParams.Count, pageParams.Start)
+
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, 0, err
+	} else if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return []*Activity{}, 0, nil
+	}
+
+	var totalRecords int
+	countSql := `SELECT COUNT(*)` + activitySQL
+	_ = db.Conn.QueryRow(db.Context, countSql, addr, tenantId, types).Scan(&totalRecords)
+
+	return activity, totalRecords, nil
+}
+
+func EnsureValidActivityType(t string) bool {
+	for _, activityType := range ACTIVITY_TYPES {
+		if t == activityType {
+			return true
+		}
+	}
+	return false
+}
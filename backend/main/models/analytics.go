@@ -0,0 +1,486 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	// number of proposals whose stats are refreshed per analytics run
+	proposalStatsBatchSize = 50
+	// number of earlier proposals compared for voter overlap
+	voterOverlapProposals = 5
+	// number of largest voters counted in the top voters share
+	topVotersCount = 10
+)
+
+type CommunityPeriodStats struct {
+	Community_id       int       `json:"-"`
+	Period             time.Time `json:"period"`
+	Proposals          int       `json:"proposals"`
+	Votes              int       `json:"votes"`
+	Unique_voters      int       `json:"uniqueVoters"`
+	New_members        int       `json:"newMembers"`
+	Members            int       `json:"members"`
+	Participation_rate float64   `json:"participationRate"`
+	Updated_at         time.Time `json:"-"`
+}
+
+type ProposalStats struct {
+	Proposal_id   int        `json:"proposalId"`
+	Community_id  int        `json:"-"`
+	Strategy      *string    `json:"strategy,omitempty"`
+	Unique_voters int        `json:"uniqueVoters"`
+	Total_weight  float64    `json:"totalWeight"`
+	Gini          float64    `json:"gini"`
+	Is_final      bool       `json:"isFinal"`
+	End_time      *time.Time `json:"endTime,omitempty"`
+	Updated_at    *time.Time `json:"updatedAt,omitempty"`
+	Name          string     `json:"name"`
+}
+
+type StrategyUsage struct {
+	Strategy  string `json:"strategy"`
+	Proposals int    `json:"proposals"`
+}
+
+type CommunityAnalytics struct {
+	Community_id   int                     `json:"communityId"`
+	Participation  []*CommunityPeriodStats `json:"participation"`
+	Proposals      []*ProposalStats        `json:"proposals"`
+	Gini           float64                 `json:"gini"`
+	Strategy_usage []*StrategyUsage        `json:"strategyUsage"`
+	Updated_at     *time.Time              `json:"updatedAt,omitempty"`
+}
+
+type ProposalHourStats struct {
+	Hour              time.Time `json:"hour"`
+	Votes             int       `json:"votes"`
+	Weight            float64   `json:"weight"`
+	Cumulative_votes  int       `json:"cumulativeVotes"`
+	Cumulative_weight float64   `json:"cumulativeWeight"`
+}
+
+type VoterOverlap struct {
+	Proposal_id  int     `json:"proposalId"`
+	Name         string  `json:"name"`
+	Shared       int     `json:"sharedVoters"`
+	Overlap_rate float64 `json:"overlapRate"`
+}
+
+type WeightConcentration struct {
+	Gini             float64 `json:"gini"`
+	Top_voters_share float64 `json:"topVotersShare"`
+	// smallest number of voters holding more than half of the weight
+	Nakamoto int `json:"nakamoto"`
+}
+
+type ProposalAnalytics struct {
+	Proposal_id   int                  `json:"proposalId"`
+	Unique_voters int                  `json:"uniqueVoters"`
+	Total_weight  float64              `json:"totalWeight"`
+	Hourly        []*ProposalHourStats `json:"hourly"`
+	Overlap       []*VoterOverlap      `json:"overlap"`
+	Concentration WeightConcentration  `json:"concentration"`
+}
+
+type DryRunTallyPayload struct {
+	Strategy     Strategy   `json:"strategy"`
+	Addresses    []string   `json:"addresses,omitempty"`
+	List_id      *int       `json:"listId,omitempty"`
+	Block_height *uint64    `json:"blockHeight,omitempty"`
+	Voucher      *s.Voucher `json:"voucher,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
+type DryRunWeight struct {
+	Addr   string  `json:"addr"`
+	Weight float64 `json:"weight"`
+	Capped bool    `json:"capped,omitempty"`
+	Error  string  `json:"error,omitempty"`
+}
+
+// DryRunTally describes the weights a strategy would give a set of addresses.
+type DryRunTally struct {
+	Strategy      string              `json:"strategy"`
+	Block_height  uint64              `json:"blockHeight"`
+	Addresses     int                 `json:"addresses"`
+	Eligible      int                 `json:"eligible"`
+	Capped        int                 `json:"capped"`
+	Failed        int                 `json:"failed"`
+	Total_weight  float64             `json:"totalWeight"`
+	Min_weight    float64             `json:"minWeight"`
+	Max_weight    float64             `json:"maxWeight"`
+	Mean_weight   float64             `json:"meanWeight"`
+	Median_weight float64             `json:"medianWeight"`
+	Concentration WeightConcentration `json:"concentration"`
+	Weights       []DryRunWeight      `json:"weights"`
+}
+
+// BuildDryRunTally summarises the estimated weights. Addresses below the
+// strategy threshold, or whose weight could not be computed, are not eligible.
+func BuildDryRunTally(strategy Strategy, blockHeight uint64, weights []DryRunWeight) DryRunTally {
+	tally := DryRunTally{
+		Strategy:     *strategy.Name,
+		Block_height: blockHeight,
+		Addresses:    len(weights),
+		Weights:      weights,
+	}
+
+	eligible := []float64{}
+	for _, w := range weights {
+		if w.Error != "" {
+			tally.Failed++
+			continue
+		}
+		if w.Weight <= 0 || (strategy.Contract.Threshold != nil && w.Weight < *strategy.Contract.Threshold) {
+			continue
+		}
+		if w.Capped {
+			tally.Capped++
+		}
+		eligible = append(eligible, w.Weight)
+		tally.Total_weight += w.Weight
+	}
+
+	tally.Eligible = len(eligible)
+	if tally.Eligible == 0 {
+		return tally
+	}
+
+	sort.Float64s(eligible)
+	tally.Min_weight = eligible[0]
+	tally.Max_weight = eligible[len(eligible)-1]
+	tally.Mean_weight = tally.Total_weight / float64(len(eligible))
+	if mid := len(eligible) / 2; len(eligible)%2 == 0 {
+		tally.Median_weight = (eligible[mid-1] + eligible[mid]) / 2
+	} else {
+		tally.Median_weight = eligible[mid]
+	}
+	tally.Concentration = weightConcentration(eligible)
+
+	return tally
+}
+
+// BuildProposalAnalytics aggregates the weighted votes of a proposal into an
+// hourly histogram, with running totals, and its weight concentration.
+func BuildProposalAnalytics(p *Proposal, votes []*VoteWithBalance) ProposalAnalytics {
+	analytics := ProposalAnalytics{
+		Proposal_id: p.ID,
+		Hourly:      []*ProposalHourStats{},
+		Overlap:     []*VoterOverlap{},
+	}
+
+	counted := []*VoteWithBalance{}
+	for _, v := range votes {
+		if !v.IsCancelled {
+			counted = append(counted, v)
+		}
+	}
+	sort.Slice(counted, func(i, j int) bool {
+		return counted[i].Created_at.Before(counted[j].Created_at)
+	})
+
+	weights := []float64{}
+	var bucket *ProposalHourStats
+	for _, v := range counted {
+		weight := 0.0
+		if v.Weight != nil {
+			weight = *v.Weight
+		}
+		weights = append(weights, weight)
+		analytics.Total_weight += weight
+
+		hour := v.Created_at.UTC().Truncate(time.Hour)
+		if bucket == nil || !bucket.Hour.Equal(hour) {
+			bucket = &ProposalHourStats{Hour: hour}
+			analytics.Hourly = append(analytics.Hourly, bucket)
+		}
+		bucket.Votes++
+		bucket.Weight += weight
+		bucket.Cumulative_votes = len(weights)
+		bucket.Cumulative_weight = analytics.Total_weight
+	}
+
+	analytics.Unique_voters = len(counted)
+	analytics.Concentration = weightConcentration(weights)
+	return analytics
+}
+
+// GetVoterOverlap compares the voters of the proposal with those of the
+// community's previous proposals.
+func GetVoterOverlap(db *s.Database, p *Proposal) ([]*VoterOverlap, error) {
+	overlap := []*VoterOverlap{}
+	err := pgxscan.Select(db.Context, db.Conn, &overlap,
+		`
+		WITH voters AS (
+			SELECT DISTINCT addr FROM votes
+			WHERE proposal_id = $1 AND is_cancelled != 'true'
+		),
+		previous AS (
+			SELECT id, name FROM proposals
+			WHERE community_id = $2 AND start_time < $3 AND id != $1
+			AND status NOT IN ('cancelled', 'pending_review', 'rejected')
+			ORDER BY start_time DESC
+			LIMIT $4
+		)
+		SELECT
+			pp.id AS proposal_id,
+			pp.name,
+			COUNT(DISTINCT v.addr) AS shared,
+			CASE WHEN (SELECT COUNT(*) FROM voters) > 0
+				THEN COUNT(DISTINCT v.addr)::float / (SELECT COUNT(*) FROM voters)
+				ELSE 0
+			END AS overlap_rate
+		FROM previous pp
+		LEFT JOIN votes v ON v.proposal_id = pp.id
+			AND v.is_cancelled != 'true'
+			AND v.addr IN (SELECT addr FROM voters)
+		GROUP BY pp.id, pp.name
+		ORDER BY pp.id DESC
+		`, p.ID, p.Community_id, p.Start_time, voterOverlapProposals)
+
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	return overlap, nil
+}
+
+// GetCommunityAnalytics reads the pre-aggregated stats of the community for
+// the last number of months and proposals.
+func GetCommunityAnalytics(db *s.Database, communityId, months, proposals int) (CommunityAnalytics, error) {
+	analytics := CommunityAnalytics{
+		Community_id:   communityId,
+		Participation:  []*CommunityPeriodStats{},
+		Proposals:      []*ProposalStats{},
+		Strategy_usage: []*StrategyUsage{},
+	}
+
+	err := pgxscan.Select(db.Context, db.Conn, &analytics.Participation,
+		`
+		SELECT * FROM (
+			SELECT * FROM community_stats
+			WHERE community_id = $1
+			ORDER BY period DESC
+			LIMIT $2
+		) AS recent ORDER BY period ASC
+		`, communityId, months)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return analytics, err
+	}
+
+	err = pgxscan.Select(db.Context, db.Conn, &analytics.Proposals,
+		`
+		SELECT ps.*, p.name FROM proposal_stats ps
+		JOIN proposals p ON p.id = ps.proposal_id
+		WHERE ps.community_id = $1
+		ORDER BY ps.end_time DESC
+		LIMIT $2
+		`, communityId, proposals)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return analytics, err
+	}
+
+	err = pgxscan.Select(db.Context, db.Conn, &analytics.Strategy_usage,
+		`
+		SELECT COALESCE(strategy, '') AS strategy, COUNT(*) AS proposals
+		FROM proposal_stats
+		WHERE community_id = $1
+		GROUP BY strategy
+		ORDER BY proposals DESC
+		`, communityId)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return analytics, err
+	}
+
+	err = db.Conn.QueryRow(db.Context,
+		`
+		SELECT COALESCE(AVG(gini) FILTER (WHERE is_final AND unique_voters > 0), 0), MAX(updated_at)
+		FROM proposal_stats
+		WHERE community_id = $1
+		`, communityId).Scan(&analytics.Gini, &analytics.Updated_at)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return analytics, err
+	}
+
+	return analytics, nil
+}
+
+// GetProposalsPendingStats returns published proposals whose stats are missing
+// or were computed before the proposal closed.
+func GetProposalsPendingStats(db *s.Database) ([]*Proposal, error) {
+	var proposals []*Proposal
+	sql := fmt.Sprintf(`
+		SELECT *, %s FROM proposals
+		WHERE (status = 'published' OR status = 'closed')
+		AND start_time < (now() at time zone 'utc')
+		AND NOT EXISTS (
+			SELECT 1 FROM proposal_stats ps
+			WHERE ps.proposal_id = proposals.id AND ps.is_final = 'true'
+		)
+		ORDER BY end_time ASC
+		LIMIT $1
+	`, computedStatusSQL)
+	err := pgxscan.Select(db.Context, db.Conn, &proposals, sql, proposalStatsBatchSize)
+
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	return proposals, nil
+}
+
+// UpsertProposalStats stores the voter count and vote weight distribution of
+// the proposal. Stats of a closed proposal are final and no longer refreshed.
+func UpsertProposalStats(db *s.Database, p *Proposal, votes []*VoteWithBalance) error {
+	weights := []float64{}
+	voters := map[string]bool{}
+	total := 0.0
+	for _, v := range votes {
+		if v.IsCancelled {
+			continue
+		}
+		voters[v.Addr] = true
+		if v.Weight != nil {
+			weights = append(weights, *v.Weight)
+			total += *v.Weight
+		}
+	}
+
+	isFinal := p.Computed_status != nil && *p.Computed_status == "closed"
+	_, err := db.Conn.Exec(db.Context,
+		`
+		INSERT INTO proposal_stats(proposal_id, community_id, strategy, unique_voters, total_weight, gini, is_final, end_time)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (proposal_id) DO UPDATE
+		SET unique_voters = EXCLUDED.unique_voters,
+			total_weight = EXCLUDED.total_weight,
+			gini = EXCLUDED.gini,
+			is_final = EXCLUDED.is_final,
+			end_time = EXCLUDED.end_time,
+			updated_at = (now() at time zone 'utc')
+		`, p.ID, p.Community_id, p.Strategy, len(voters), total, giniCoefficient(weights), isFinal, p.End_time)
+	return err
+}
+
+// RefreshCommunityStats recomputes the monthly activity of every community,
+// from the month it was created until the current one.
+func RefreshCommunityStats(db *s.Database) error {
+	_, err := db.Conn.Exec(db.Context,
+		`
+		WITH periods AS (
+			SELECT c.id AS community_id, generate_series(
+				date_trunc('month', COALESCE(c.created_at, now() at time zone 'utc')),
+				date_trunc('month', now() at time zone 'utc'),
+				interval '1 month'
+			)::date AS period
+			FROM communities c
+		),
+		members AS (
+			SELECT community_id, addr, MIN(COALESCE(created_at, '-infinity'::timestamp)) AS joined_at
+			FROM community_users
+			GROUP BY community_id, addr
+		),
+		activity AS (
+			SELECT
+				pr.community_id,
+				pr.period,
+				(
+					SELECT COUNT(*) FROM proposals p
+					WHERE p.community_id = pr.community_id
+					AND date_trunc('month', p.start_time)::date = pr.period
+					AND p.status NOT IN ('cancelled', 'pending_review', 'rejected')
+				) AS proposals,
+				COUNT(v.id) AS votes,
+				COUNT(DISTINCT v.addr) AS unique_voters,
+				(
+					SELECT COUNT(*) FROM members m
+					WHERE m.community_id = pr.community_id
+					AND date_trunc('month', m.joined_at)::date = pr.period
+				) AS new_members,
+				(
+					SELECT COUNT(*) FROM members m
+					WHERE m.community_id = pr.community_id
+					AND m.joined_at < pr.period + interval '1 month'
+				) AS members
+			FROM periods pr
+			LEFT JOIN proposals p ON p.community_id = pr.community_id
+			LEFT JOIN votes v ON v.proposal_id = p.id
+				AND v.is_cancelled != 'true'
+				AND date_trunc('month', v.created_at)::date = pr.period
+			GROUP BY pr.community_id, pr.period
+		)
+		INSERT INTO community_stats(
+			community_id, period, proposals, votes, unique_voters, new_members, members, participation_rate
+		)
+		SELECT
+			community_id, period, proposals, votes, unique_voters, new_members, members,
+			CASE WHEN members > 0 THEN unique_voters::float / members ELSE 0 END
+		FROM activity
+		ON CONFLICT (community_id, period) DO UPDATE
+		SET proposals = EXCLUDED.proposals,
+			votes = EXCLUDED.votes,
+			unique_voters = EXCLUDED.unique_voters,
+			new_members = EXCLUDED.new_members,
+			members = EXCLUDED.members,
+			participation_rate = EXCLUDED.participation_rate,
+			updated_at = (now() at time zone 'utc')
+		`)
+	return err
+}
+
+func weightConcentration(weights []float64) WeightConcentration {
+	c := WeightConcentration{Gini: giniCoefficient(weights)}
+
+	sorted := make([]float64, len(weights))
+	copy(sorted, weights)
+	sort.Sort(sort.Reverse(sort.Float64Slice(sorted)))
+
+	total := 0.0
+	for _, w := range sorted {
+		total += w
+	}
+	if total == 0 {
+		return c
+	}
+
+	running := 0.0
+	for i, w := range sorted {
+		running += w
+		if i == topVotersCount-1 || (i == len(sorted)-1 && len(sorted) < topVotersCount) {
+			c.Top_voters_share = running / total
+		}
+		if c.Nakamoto == 0 && running > total/2 {
+			c.Nakamoto = i + 1
+		}
+	}
+	return c
+}
+
+// giniCoefficient measures how unequal the weights are, from 0 when every
+// weight is the same to 1 when a single weight holds everything.
+func giniCoefficient(weights []float64) float64 {
+	n := len(weights)
+	if n == 0 {
+		return 0
+	}
+
+	sorted := make([]float64, n)
+	copy(sorted, weights)
+	sort.Float64s(sorted)
+
+	var sum, weighted float64
+	for i, w := range sorted {
+		sum += w
+		weighted += float64(2*(i+1)-n-1) * w
+	}
+	if sum == 0 {
+		return 0
+	}
+	return weighted / (float64(n) * sum)
+}
@@ -0,0 +1,140 @@
+package models
+
+import (
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+const ApiKeyTokenAudience = "api-key"
+
+// ApiKey lets a partner read one community's public data without a wallet.
+// The key itself is a signed token naming the row, so revoking the row
+// revokes the key.
+type ApiKey struct {
+	ID            int        `json:"id"`
+	Community_id  int        `json:"communityId"`
+	Name          string     `json:"name"`
+	Rate_limit    *int       `json:"rateLimit,omitempty"`
+	Request_count int64      `json:"requestCount"`
+	Last_used_at  *time.Time `json:"lastUsedAt,omitempty"`
+	Created_by    string     `json:"createdBy"`
+	Revoked_at    *time.Time `json:"revokedAt,omitempty"`
+	Created_at    *time.Time `json:"createdAt,omitempty"`
+}
+
+type ApiKeyClaims struct {
+	shared.TokenClaims
+	Api_key_id   int `json:"apiKeyId"`
+	Community_id int `json:"communityId"`
+}
+
+type ApiKeyWithToken struct {
+	ApiKey
+	Token string `json:"token"`
+}
+
+// ApiKeyUsage is the number of requests a key made on one day.
+type ApiKeyUsage struct {
+	Day      time.Time `json:"day"`
+	Requests int64     `json:"requests"`
+}
+
+type CreateApiKeyPayload struct {
+	Community_id int    `json:"communityId" validate:"required"`
+	Name         string `json:"name"        validate:"required,max=100"`
+	Rate_limit   *int   `json:"rateLimit,omitempty" validate:"omitempty,gt=0"`
+
+	s.TimestampSignaturePayload
+}
+
+func GetApiKeys(db *s.Database, communityId int, pageParams shared.PageParams) ([]*ApiKey, int, error) {
+	var keys []*ApiKey
+	err := pgxscan.Select(db.Context, db.Conn, &keys,
+		`
+		SELECT * FROM api_keys
+		WHERE $1 = 0 OR community_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+		`, communityId, pageParams.Count, pageParams.Start)
+
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, 0, err
+	} else if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return []*ApiKey{}, 0, nil
+	}
+
+	var totalRecords int
+	countSql := `SELECT COUNT(*) FROM api_keys WHERE $1 = 0 OR community_id = $1`
+	_ = db.Conn.QueryRow(db.Context, countSql, communityId).Scan(&totalRecords)
+
+	return keys, totalRecords, nil
+}
+
+func (k *ApiKey) GetApiKeyById(db *s.Database) error {
+	return pgxscan.Get(db.Context, db.Conn, k,
+		`SELECT * FROM api_keys WHERE id = $1`,
+		k.ID)
+}
+
+func (k *ApiKey) CreateApiKey(db *s.Database) error {
+	return db.Conn.QueryRow(db.Context,
+		`
+		INSERT INTO api_keys(community_id, name, rate_limit, created_by)
+		VALUES($1, $2, $3, $4)
+		RETURNING id, created_at
+		`, k.Community_id, k.Name, k.Rate_limit, k.Created_by).
+		Scan(&k.ID, &k.Created_at)
+}
+
+func (k *ApiKey) RevokeApiKey(db *s.Database) error {
+	return db.Conn.QueryRow(db.Context,
+		`
+		UPDATE api_keys SET revoked_at = (now() at time zone 'utc')
+		WHERE id = $1 AND revoked_at IS NULL
+		RETURNING revoked_at
+		`, k.ID).
+		Scan(&k.Revoked_at)
+}
+
+// RecordUse counts a request against the key, in total and for the day.
+// A pgx.ErrNoRows error means the key is revoked.
+func (k *ApiKey) RecordUse(db *s.Database) error {
+	return db.Conn.QueryRow(db.Context,
+		`
+		WITH used AS (
+			UPDATE api_keys
+			SET request_count = request_count + 1, last_used_at = (now() at time zone 'utc')
+			WHERE id = $1 AND revoked_at IS NULL
+			RETURNING id, request_count, last_used_at
+		), daily AS (
+			INSERT INTO api_key_usage(api_key_id, day, requests)
+			SELECT id, (now() at time zone 'utc')::date, 1 FROM used
+			ON CONFLICT (api_key_id, day) DO UPDATE SET requests = api_key_usage.requests + 1
+		)
+		SELECT request_count, last_used_at FROM used
+		`, k.ID).
+		Scan(&k.Request_count, &k.Last_used_at)
+}
+
+// GetApiKeyUsage lists the requests made with a key per day, newest first,
+// for the last days days.
+func GetApiKeyUsage(db *s.Database, apiKeyId int, days int) ([]ApiKeyUsage, error) {
+	var usage []ApiKeyUsage
+	err := pgxscan.Select(db.Context, db.Conn, &usage,
+		`
+		SELECT day, requests FROM api_key_usage
+		WHERE api_key_id = $1 AND day > (now() at time zone 'utc')::date - $2::int
+		ORDER BY day DESC
+		`, apiKeyId, days)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	if usage == nil {
+		usage = []ApiKeyUsage{}
+	}
+	return usage, nil
+}
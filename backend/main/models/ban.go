@@ -0,0 +1,89 @@
+package models
+
+import (
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+type CommunityBan struct {
+	ID           int        `json:"id"`
+	Community_id int        `json:"communityId"`
+	Addr         string     `json:"addr"`
+	Reason       *string    `json:"reason,omitempty"`
+	Banned_by    string     `json:"bannedBy"`
+	Created_at   *time.Time `json:"createdAt,omitempty"`
+}
+
+type CommunityBanPayload struct {
+	Addr    string          `json:"addr"    validate:"required"`
+	Reason  *string         `json:"reason,omitempty"`
+	Voucher *shared.Voucher `json:"voucher,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
+func GetBansForCommunity(db *s.Database, communityId int, pageParams shared.PageParams) ([]*CommunityBan, int, error) {
+	var bans []*CommunityBan
+	err := pgxscan.Select(db.Context, db.Conn, &bans,
+		`
+		SELECT * FROM community_bans
+		WHERE community_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+		`, communityId, pageParams.Count, pageParams.Start)
+
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, 0, err
+	} else if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return []*CommunityBan{}, 0, nil
+	}
+
+	var totalRecords int
+	countSql := `SELECT COUNT(*) FROM community_bans WHERE community_id = $1`
+	_ = db.Conn.QueryRow(db.Context, countSql, communityId).Scan(&totalRecords)
+
+	return bans, totalRecords, nil
+}
+
+func GetAllBansForCommunity(db *s.Database, communityId int) ([]*CommunityBan, error) {
+	var bans []*CommunityBan
+	err := pgxscan.Select(db.Context, db.Conn, &bans,
+		`SELECT * FROM community_bans WHERE community_id = $1 ORDER BY created_at ASC`,
+		communityId)
+
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	return bans, nil
+}
+
+// IsAddressBanned reports whether the address is banned from the community.
+func IsAddressBanned(db *s.Database, communityId int, addr string) (bool, error) {
+	var banned bool
+	err := db.Conn.QueryRow(db.Context,
+		`SELECT EXISTS(SELECT 1 FROM community_bans WHERE community_id = $1 AND addr = $2)`,
+		communityId, addr).Scan(&banned)
+	return banned, err
+}
+
+func (b *CommunityBan) CreateBan(db *s.Database) error {
+	return db.Conn.QueryRow(db.Context,
+		`
+		INSERT INTO community_bans(community_id, addr, reason, banned_by)
+		VALUES($1, $2, $3, $4)
+		ON CONFLICT (community_id, addr) DO UPDATE SET reason = EXCLUDED.reason, banned_by = EXCLUDED.banned_by
+		RETURNING id, created_at
+		`, b.Community_id, b.Addr, b.Reason, b.Banned_by).
+		Scan(&b.ID, &b.Created_at)
+}
+
+func (b *CommunityBan) RemoveBan(db *s.Database) error {
+	_, err := db.Conn.Exec(db.Context,
+		`DELETE FROM community_bans WHERE community_id = $1 AND addr = $2`,
+		b.Community_id, b.Addr)
+	return err
+}
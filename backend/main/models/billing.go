@@ -0,0 +1,217 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+// Communities without a plan of their own, or whose plan expired, are on
+// the free plan.
+const FreePlan = "free"
+
+// What plans limit.
+const (
+	PlanMembers          = "members"
+	PlanActiveProposals  = "active proposals"
+	PlanCustomStrategies = "custom strategies"
+)
+
+// Entitlement webhooks of the payment processor. An update grants a plan,
+// or renews or changes it, and a revocation puts the community back on the
+// free plan.
+const (
+	EntitlementUpdated = "entitlement.updated"
+	EntitlementRevoked = "entitlement.revoked"
+)
+
+// Plan is a tier communities are on, with the most of each thing it allows.
+// A nil limit is no limit.
+type Plan struct {
+	Name                  string     `json:"name"`
+	Display_name          string     `json:"displayName"`
+	Max_members           *int       `json:"maxMembers,omitempty"`
+	Max_active_proposals  *int       `json:"maxActiveProposals,omitempty"`
+	Max_custom_strategies *int       `json:"maxCustomStrategies,omitempty"`
+	Created_at            *time.Time `json:"createdAt,omitempty"`
+}
+
+// CommunityPlan is the plan the payment processor last granted a
+// community, as of the event at Event_at.
+type CommunityPlan struct {
+	Community_id int        `json:"communityId"`
+	Plan         string     `json:"plan"`
+	Expires_at   *time.Time `json:"expiresAt,omitempty"`
+	Customer_id  *string    `json:"customerId,omitempty"`
+	Event_at     time.Time  `json:"eventAt"`
+	Updated_at   *time.Time `json:"updatedAt,omitempty"`
+}
+
+type PlanUsage struct {
+	Members           int `json:"members"`
+	Active_proposals  int `json:"activeProposals"`
+	Custom_strategies int `json:"customStrategies"`
+}
+
+// CommunityBilling is the plan a community is on, and how much of it the
+// community uses.
+type CommunityBilling struct {
+	Community_id int        `json:"communityId"`
+	Plan         Plan       `json:"plan"`
+	Expires_at   *time.Time `json:"expiresAt,omitempty"`
+	Usage        PlanUsage  `json:"usage"`
+	Enforced     bool       `json:"enforced"`
+	Checkout_url *string    `json:"checkoutUrl,omitempty"`
+}
+
+type EntitlementData struct {
+	Community_id int        `json:"communityId" validate:"required"`
+	Plan         string     `json:"plan"`
+	Expires_at   *time.Time `json:"expiresAt,omitempty"`
+	Customer_id  *string    `json:"customerId,omitempty"`
+}
+
+// EntitlementEvent is a webhook of the payment processor. Its ID makes
+// redelivery harmless, and events older than the one last applied to the
+// community are ignored, as processors don't keep them in order.
+type EntitlementEvent struct {
+	ID         string          `json:"id"        validate:"required,max=255"`
+	Type       string          `json:"type"      validate:"required"`
+	Created_at time.Time       `json:"createdAt" validate:"required"`
+	Data       EntitlementData `json:"data"`
+}
+
+var ErrUnknownPlan = errors.New("Plan not found.")
+
+// Limit is the most of what the plan allows, nil for no limit.
+func (p Plan) Limit(limit string) *int {
+	switch limit {
+	case PlanMembers:
+		return p.Max_members
+	case PlanActiveProposals:
+		return p.Max_active_proposals
+	case PlanCustomStrategies:
+		return p.Max_custom_strategies
+	}
+	return nil
+}
+
+// Used is how much of what a plan limits the community uses.
+func (u PlanUsage) Used(limit string) int {
+	switch limit {
+	case PlanMembers:
+		return u.Members
+	case PlanActiveProposals:
+		return u.Active_proposals
+	case PlanCustomStrategies:
+		return u.Custom_strategies
+	}
+	return 0
+}
+
+// CountCustomStrategies counts the custom script strategies of a list.
+func CountCustomStrategies(strategies *[]Strategy) int {
+	if strategies == nil {
+		return 0
+	}
+	count := 0
+	for _, s := range *strategies {
+		if s.Name != nil && *s.Name == "custom-script" {
+			count++
+		}
+	}
+	return count
+}
+
+func GetPlans(db *s.Database) ([]*Plan, error) {
+	var plans []*Plan
+	err := pgxscan.Select(db.Context, db.Conn, &plans,
+		`SELECT * FROM plans ORDER BY max_members ASC NULLS LAST, name ASC`)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	if plans == nil {
+		plans = []*Plan{}
+	}
+	return plans, nil
+}
+
+func GetPlan(db *s.Database, name string) (Plan, error) {
+	var plan Plan
+	err := pgxscan.Get(db.Context, db.Conn, &plan, `SELECT * FROM plans WHERE name = $1`, name)
+	if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return Plan{}, ErrUnknownPlan
+	}
+	return plan, err
+}
+
+// GetCommunityPlan returns the plan a community is on and when it expires,
+// the free plan when it has none or its plan expired.
+func GetCommunityPlan(db *s.Database, communityId int) (Plan, *time.Time, error) {
+	var cp CommunityPlan
+	err := pgxscan.Get(db.Context, db.Conn, &cp,
+		`SELECT * FROM community_plans WHERE community_id = $1`,
+		communityId)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return Plan{}, nil, err
+	}
+	if err != nil || (cp.Expires_at != nil && cp.Expires_at.Before(time.Now().UTC())) {
+		plan, err := GetPlan(db, FreePlan)
+		return plan, nil, err
+	}
+
+	plan, err := GetPlan(db, cp.Plan)
+	return plan, cp.Expires_at, err
+}
+
+// GetPlanUsage counts the distinct members of a community, and its
+// proposals in review, upcoming or active. The custom strategies are
+// counted from the community.
+func GetPlanUsage(db *s.Database, c Community) (PlanUsage, error) {
+	usage := PlanUsage{Custom_strategies: CountCustomStrategies(c.Strategies)}
+	err := db.Conn.QueryRow(db.Context,
+		`
+		SELECT
+			(SELECT COUNT(DISTINCT addr) FROM community_users WHERE community_id = $1),
+			(SELECT COUNT(*) FROM proposals WHERE community_id = $1 AND `+openProposalsFilter+`)
+		`, c.ID).Scan(&usage.Members, &usage.Active_proposals)
+	return usage, err
+}
+
+// ApplyEntitlement records a webhook and applies it to the community's
+// plan. It reports false for webhooks it has seen before.
+func ApplyEntitlement(db *s.Database, e EntitlementEvent) (bool, error) {
+	applied := false
+	err := db.WithTx(func(tx *s.Database) error {
+		tag, err := tx.Conn.Exec(tx.Context,
+			`
+			INSERT INTO billing_events(id, event_type, community_id) VALUES($1, $2, $3)
+			ON CONFLICT (id) DO NOTHING
+			`, e.ID, e.Type, e.Data.Community_id)
+		if err != nil || tag.RowsAffected() == 0 {
+			return err
+		}
+		applied = true
+
+		plan := e.Data.Plan
+		expiresAt := e.Data.Expires_at
+		if e.Type == EntitlementRevoked {
+			plan, expiresAt = FreePlan, nil
+		}
+		_, err = tx.Conn.Exec(tx.Context,
+			`
+			INSERT INTO community_plans(community_id, plan, expires_at, customer_id, event_at)
+			VALUES($1, $2, $3, $4, $5)
+			ON CONFLICT (community_id) DO UPDATE
+			SET plan = $2, expires_at = $3,
+				customer_id = COALESCE($4, community_plans.customer_id),
+				event_at = $5, updated_at = (now() at time zone 'utc')
+			WHERE community_plans.event_at <= $5
+			`, e.Data.Community_id, plan, expiresAt, e.Data.Customer_id, e.Created_at.UTC())
+		return err
+	})
+	return applied, err
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+var ErrBrandingNotFound = errors.New("No community is served from this domain.")
+
+// CommunityBranding is how a white-label frontend themes a community.
+// Logos are keyed by variant, theme colors by role and social links by
+// network.
+type CommunityBranding struct {
+	Community_id  int               `json:"communityId"`
+	Custom_domain *string           `json:"customDomain,omitempty"`
+	Logos         map[string]string `json:"logos"`
+	Theme         map[string]string `json:"theme"`
+	Social_links  map[string]string `json:"socialLinks"`
+	Updated_at    *time.Time        `json:"updatedAt,omitempty"`
+}
+
+// CommunityBrandingPayload changes the settings it sets, replacing each one
+// whole. An empty customDomain removes the domain.
+type CommunityBrandingPayload struct {
+	s.TimestampSignaturePayload
+	Voucher       *s.Voucher         `json:"voucher,omitempty"`
+	Custom_domain *string            `json:"customDomain,omitempty" validate:"omitempty,hostname_rfc1123,max=253"`
+	Logos         *map[string]string `json:"logos,omitempty"        validate:"omitempty,dive,keys,oneof=light dark icon favicon,endkeys,url"`
+	Theme         *map[string]string `json:"theme,omitempty"        validate:"omitempty,dive,keys,oneof=primary secondary accent background text,endkeys,hexcolor"`
+	Social_links  *map[string]string `json:"socialLinks,omitempty"  validate:"omitempty,dive,keys,oneof=website twitter discord github instagram telegram medium youtube,endkeys,url"`
+}
+
+// GetCommunityBranding reads the branding of the community, which is empty
+// until its admins set some.
+func GetCommunityBranding(db *s.Database, communityId int) (CommunityBranding, error) {
+	b := CommunityBranding{
+		Community_id: communityId,
+		Logos:        map[string]string{},
+		Theme:        map[string]string{},
+		Social_links: map[string]string{},
+	}
+	err := pgxscan.Get(db.Context, db.Conn, &b,
+		`SELECT * FROM community_branding WHERE community_id = $1`, communityId)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return CommunityBranding{}, err
+	}
+	return b, nil
+}
+
+// GetCommunityBrandingByDomain finds the branding of the community served
+// from the domain.
+func GetCommunityBrandingByDomain(db *s.Database, domain string) (CommunityBranding, error) {
+	var b CommunityBranding
+	err := pgxscan.Get(db.Context, db.Conn, &b,
+		`SELECT * FROM community_branding WHERE custom_domain = $1`, strings.ToLower(domain))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return CommunityBranding{}, ErrBrandingNotFound
+	}
+	return b, err
+}
+
+func (b *CommunityBranding) Upsert(db *s.Database) error {
+	return db.Conn.QueryRow(db.Context, `
+		INSERT INTO community_branding(community_id, custom_domain, logos, theme, social_links)
+		VALUES($1, $2, $3, $4, $5)
+		ON CONFLICT (community_id) DO UPDATE
+		SET custom_domain = EXCLUDED.custom_domain, logos = EXCLUDED.logos, theme = EXCLUDED.theme,
+			social_links = EXCLUDED.social_links, updated_at = (now() at time zone 'utc')
+		RETURNING updated_at
+	`, b.Community_id, b.Custom_domain, b.Logos, b.Theme, b.Social_links).Scan(&b.Updated_at)
+}
+
+// WithCommunityDefaults fills the logo and social links the branding leaves
+// out with those of the community profile.
+func (b CommunityBranding) WithCommunityDefaults(c Community) CommunityBranding {
+	logos := map[string]string{}
+	if c.Logo != nil && *c.Logo != "" {
+		logos["light"] = *c.Logo
+	}
+	for k, v := range b.Logos {
+		logos[k] = v
+	}
+
+	links := map[string]string{}
+	for network, url := range map[string]*string{
+		"website":   c.Website_url,
+		"twitter":   c.Twitter_url,
+		"discord":   c.Discord_url,
+		"github":    c.Github_url,
+		"instagram": c.Instagram_url,
+	} {
+		if url != nil && *url != "" {
+			links[network] = *url
+		}
+	}
+	for k, v := range b.Social_links {
+		links[k] = v
+	}
+
+	b.Logos = logos
+	b.Social_links = links
+	return b
+}
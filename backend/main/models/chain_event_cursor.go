@@ -0,0 +1,36 @@
+package models
+
+import (
+	"errors"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/jackc/pgx/v4"
+)
+
+// GetChainEventCursor returns the last block height the events of the
+// type were ingested up to, or nil before they ever were.
+func GetChainEventCursor(db *s.Database, eventType string) (*uint64, error) {
+	var height uint64
+	err := db.Conn.QueryRow(db.Context,
+		`SELECT block_height FROM chain_event_cursors WHERE event_type = $1`,
+		eventType).Scan(&height)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &height, nil
+}
+
+func SetChainEventCursor(db *s.Database, eventType string, height uint64) error {
+	_, err := db.Conn.Exec(db.Context,
+		`
+		INSERT INTO chain_event_cursors(event_type, block_height)
+		VALUES($1, $2)
+		ON CONFLICT (event_type) DO UPDATE
+		SET block_height = EXCLUDED.block_height,
+			updated_at = (now() at time zone 'utc')
+		`, eventType, height)
+	return err
+}
@@ -5,7 +5,9 @@ package models
 /////////////////
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
 	"time"
 
 	"github.com/DapperCollectives/CAST/backend/main/shared"
@@ -15,6 +17,10 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// ErrStaleVersion is returned by updates made against a version of a
+// community or proposal that has since been changed.
+var ErrStaleVersion = errors.New("record was changed by another update")
+
 type Community struct {
 	ID                       int         `json:"id,omitempty"`
 	Name                     string      `json:"name,omitempty"`
@@ -39,6 +45,10 @@ type Community struct {
 
 	Total *int `json:"total,omitempty"` // for search only
 
+	Trending_score *float64 `json:"trendingScore,omitempty"` // for trending sort only
+
+	Tokens []s.TokenMetadata `json:"tokens,omitempty"` // of its strategies, when fetched by id
+
 	Contract_name *string `json:"contractName,omitempty"`
 	Contract_addr *string `json:"contractAddr,omitempty"`
 	Contract_type *string `json:"contractType,omitempty"`
@@ -51,6 +61,54 @@ type Community struct {
 	Voucher              *shared.Voucher         `json:"voucher,omitempty"`
 	Created_at           *time.Time              `json:"createdAt,omitempty"`
 	Cid                  *string                 `json:"cid,omitempty"`
+
+	Is_archived  bool       `json:"isArchived"`
+	Archived_at  *time.Time `json:"archivedAt,omitempty"`
+	Delete_after *time.Time `json:"deleteAfter,omitempty"`
+
+	// suspended communities were archived by a platform admin, and only a
+	// platform admin can restore them
+	Suspended_at      *time.Time `json:"suspendedAt,omitempty"`
+	Suspension_reason *string    `json:"suspensionReason,omitempty"`
+
+	// set by platform admins, pinned communities lead the homepage and
+	// featured ones follow in homepage order
+	Homepage_pinned bool `json:"homepagePinned"`
+	Homepage_order  *int `json:"homepageOrder,omitempty"`
+
+	Parent_id  *int `json:"parentId,omitempty"`
+	Is_private bool `json:"isPrivate"`
+
+	// the tenant whose frontend lists the community, see Tenant
+	Tenant_id int `json:"tenantId"`
+
+	Require_proposal_review bool `json:"requireProposalReview"`
+
+	// admins who must approve sensitive actions, see PendingAction
+	Admin_approvals_required int `json:"adminApprovalsRequired"`
+
+	// the latest of the community's StrategyVersions
+	Strategies_version int `json:"strategiesVersion"`
+
+	// voters must be verified with one of these identity providers
+	Required_verifications []string `json:"requiredVerifications,omitempty"`
+	AccountAge
+	PowerDecay
+	AuthorParticipation
+	DuplicateCheck
+	ModerationSettings
+	ProposalCooldown
+	ProposalDeposit
+
+	ProposalWindow
+	// time zone the community shows proposal times in, times are stored in UTC
+	Timezone *string `json:"timezone,omitempty"`
+
+	Imported_at *time.Time `json:"importedAt,omitempty"`
+
+	// bumped on every update, see ErrStaleVersion
+	Version    int        `json:"version"`
+	Updated_at *time.Time `json:"updatedAt,omitempty"`
 }
 
 type CreateCommunityRequestPayload struct {
@@ -77,7 +135,20 @@ type UpdateCommunityRequestPayload struct {
 	Proposal_validation      *string         `json:"proposalValidation,omitempty"`
 	Proposal_threshold       *string         `json:"proposalThreshold,omitempty"`
 	Only_authors_to_submit   *bool           `json:"onlyAuthorsToSubmit,omitempty"`
+	Is_private               *bool           `json:"isPrivate,omitempty"`
+	Require_proposal_review  *bool           `json:"requireProposalReview,omitempty"`
 	Voucher                  *shared.Voucher `json:"voucher,omitempty"`
+	Timezone                 *string         `json:"timezone,omitempty"`
+	Required_verifications   *[]string       `json:"requiredVerifications,omitempty"`
+
+	ProposalWindow
+	AccountAge
+	PowerDecay
+	AuthorParticipation
+	DuplicateCheck
+	ModerationSettings
+	ProposalCooldown
+	ProposalDeposit
 
 	//TODO dup fields in Community struct, make sub struct for both to use
 	Contract_name *string  `json:"contractName,omitempty"`
@@ -89,9 +160,40 @@ type UpdateCommunityRequestPayload struct {
 	s.TimestampSignaturePayload
 }
 
+// UpdatesOnlyStrategies reports whether the payload changes nothing but the
+// community voting strategies.
+func (p *UpdateCommunityRequestPayload) UpdatesOnlyStrategies() bool {
+	if p.Strategies == nil && p.Strategy == nil {
+		return false
+	}
+	rest := *p
+	rest.Strategies = nil
+	rest.Strategy = nil
+	rest.Voucher = nil
+	rest.TimestampSignaturePayload = s.TimestampSignaturePayload{}
+	return reflect.DeepEqual(rest, UpdateCommunityRequestPayload{})
+}
+
+type ArchiveCommunityRequestPayload struct {
+	Voucher *shared.Voucher `json:"voucher,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
+type CommunityHierarchy struct {
+	Community   *Community   `json:"community"`
+	Ancestors   []*Community `json:"ancestors"`
+	Descendants []*Community `json:"descendants"`
+}
+
 type Strategy struct {
 	Name            *string `json:"name,omitempty"`
 	shared.Contract `json:"contract,omitempty"`
+
+	// voting with the strategy needs verifying with one of these identity
+	// providers, as well as with those the community requires
+	Required_verifications []string `json:"requiredVerifications,omitempty"`
+	AccountAge
 }
 
 type CommunityType struct {
@@ -101,7 +203,7 @@ type CommunityType struct {
 }
 
 const HOMEPAGE_SQL = `
-		SELECT * FROM communities WHERE (discord_url IS NOT NULL
+		SELECT * FROM communities WHERE is_archived = 'false' AND tenant_id = $3 AND ((discord_url IS NOT NULL
 		AND twitter_url IS NOT NULL
   	AND id IN (
     	SELECT community_id
@@ -115,14 +217,17 @@ const HOMEPAGE_SQL = `
     	GROUP BY community_id
     	HAVING COUNT(*) >= 2
   	))
-		OR is_featured = 'true'
+		OR is_featured = 'true' OR homepage_pinned = 'true')
+		ORDER BY homepage_pinned DESC, homepage_order ASC NULLS LAST, id ASC
 		LIMIT $1 OFFSET $2
 `
 const DEFAULT_SEARCH_SQL = `
 	SELECT id, name, body, logo, category
 		FROM communities
     WHERE is_featured = 'true'
+		AND is_archived = 'false'
 		AND category IS NOT NULL
+		AND tenant_id = $3
 `
 const INSERT_COMMUNITY_SQL = `
 	INSERT INTO communities(
@@ -149,10 +254,36 @@ const INSERT_COMMUNITY_SQL = `
 		contract_type, 
 		public_path, 
 		only_authors_to_submit, 
-		voucher)
+		voucher,
+		parent_id,
+		is_private,
+		require_proposal_review,
+		min_proposal_duration,
+		max_proposal_duration,
+		min_proposal_lead_time,
+		proposal_time_zones,
+		timezone,
+		required_verifications,
+		account_created_before_block,
+		min_account_age,
+		decay_window,
+		decay_rate,
+		author_min_votes,
+		author_vote_window,
+		duplicate_proposals,
+		duplicate_threshold,
+		moderation_hold_score,
+		proposal_cooldown_limit,
+		proposal_cooldown_window,
+		deposit_amount,
+		deposit_escrow_addr,
+		deposit_slash_addr,
+		tenant_id)
 	VALUES(
 		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, 
-		$14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24
+		$14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27,
+		$28, $29, $30, $31, $32, $33, $34, $35, $36, $37, $38, $39, $40,
+		$41, $42, $43, $44, $45, $46, $47, $48
 	)
 	RETURNING id, created_at
 `
@@ -177,27 +308,56 @@ const UPDATE_COMMUNITY_SQL = `
 	contract_addr = COALESCE($17, contract_addr),
 	contract_type = COALESCE($18, contract_type),
 	public_path = COALESCE($19, public_path),
-	only_authors_to_submit = COALESCE($20, only_authors_to_submit)
-	WHERE id = $21
+	only_authors_to_submit = COALESCE($20, only_authors_to_submit),
+	is_private = COALESCE($21, is_private),
+	require_proposal_review = COALESCE($22, require_proposal_review),
+	min_proposal_duration = COALESCE($23, min_proposal_duration),
+	max_proposal_duration = COALESCE($24, max_proposal_duration),
+	min_proposal_lead_time = COALESCE($25, min_proposal_lead_time),
+	proposal_time_zones = COALESCE($26, proposal_time_zones),
+	timezone = COALESCE($27, timezone),
+	required_verifications = COALESCE($28, required_verifications),
+	account_created_before_block = COALESCE($29, account_created_before_block),
+	min_account_age = COALESCE($30, min_account_age),
+	decay_window = COALESCE($31, decay_window),
+	decay_rate = COALESCE($32, decay_rate),
+	author_min_votes = COALESCE($33, author_min_votes),
+	author_vote_window = COALESCE($34, author_vote_window),
+	duplicate_proposals = COALESCE($35, duplicate_proposals),
+	duplicate_threshold = COALESCE($36, duplicate_threshold),
+	moderation_hold_score = COALESCE($37, moderation_hold_score),
+	proposal_cooldown_limit = COALESCE($38, proposal_cooldown_limit),
+	proposal_cooldown_window = COALESCE($39, proposal_cooldown_window),
+	deposit_amount = COALESCE($40, deposit_amount),
+	deposit_escrow_addr = COALESCE($41, deposit_escrow_addr),
+	deposit_slash_addr = COALESCE($42, deposit_slash_addr),
+	version = version + 1, updated_at = (now() at time zone 'utc')
+	WHERE id = $43 AND version = $44
 `
 const SEARCH_COMMUNITIES_SQL = `
 	SELECT id, name, body, logo, category, SIMILARITY(name, $1) as score	
 	FROM communities 
 	WHERE SIMILARITY(name, $1) > 0.1
+		AND is_archived = 'false'
 		AND category IS NOT NULL
+		AND tenant_id = $4
 `
 const COUNT_CATEGORIES_DEFAULT_SQL = `
 	SELECT category, COUNT(*) as category_count
 	FROM communities 
 	WHERE is_featured = 'true'
+		AND is_archived = 'false'
 		AND category IS NOT NULL
+		AND tenant_id = $1
 	GROUP BY category
 `
 const COUNT_CATEGORIES_SEARCH_SQL = `
 	SELECT category, COUNT(*) as category_count
 	FROM communities 
 	WHERE SIMILARITY(name, $1) > 0.1
+		AND is_archived = 'false'
 		AND category IS NOT NULL
+		AND tenant_id = $2
 	GROUP BY category
 `
 
@@ -220,13 +380,34 @@ func (c *Community) GetCommunity(db *s.Database) error {
 		c.ID)
 }
 
-func GetCommunities(db *s.Database, pageParams shared.PageParams) ([]*Community, int, error) {
+func GetCommunitiesByIds(db *s.Database, ids []int) ([]*Community, error) {
+	communities := []*Community{}
+	err := pgxscan.Select(db.Context, db.Conn, &communities,
+		`SELECT * FROM communities WHERE id = ANY($1)`, ids)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	return communities, nil
+}
+
+// CommunitySortColumns are the fields communities can be sorted by, besides
+// trending.
+var CommunitySortColumns = shared.SortColumns{
+	"id":         "id",
+	"name":       "name",
+	"category":   "category",
+	"created_at": "created_at",
+}
+
+func GetCommunities(db *s.Database, tenantId int, pageParams shared.PageParams) ([]*Community, int, error) {
 	var communities []*Community
 	err := pgxscan.Select(db.Context, db.Conn, &communities,
 		`
 		SELECT * FROM communities
+		WHERE is_archived = 'false' AND tenant_id = $3`+
+			CommunitySortColumns.OrderBy(pageParams.Sort, "")+`
 		LIMIT $1 OFFSET $2
-		`, pageParams.Count, pageParams.Start)
+		`, pageParams.Count, pageParams.Start, tenantId)
 
 	// If we get pgx.ErrNoRows, just return an empty array
 	// and obfuscate error
@@ -238,8 +419,8 @@ func GetCommunities(db *s.Database, pageParams shared.PageParams) ([]*Community,
 
 	// Get total number of communities
 	var totalRecords int
-	countSql := `SELECT COUNT(*) FROM communities`
-	_ = db.Conn.QueryRow(db.Context, countSql).Scan(&totalRecords)
+	countSql := `SELECT COUNT(*) FROM communities WHERE is_archived = 'false' AND tenant_id = $1`
+	_ = db.Conn.QueryRow(db.Context, countSql, tenantId).Scan(&totalRecords)
 
 	return communities, totalRecords, nil
 }
@@ -252,6 +433,7 @@ func (c *Community) GetCommunityByProposalId(db *s.Database, proposalId int) err
 
 func GetDefaultCommunities(
 	db *s.Database,
+	tenantId int,
 	params shared.PageParams,
 	filters []string,
 	isSearch bool,
@@ -260,7 +442,7 @@ func GetDefaultCommunities(
 
 	if !isSearch {
 		var totalRecords int
-		countSql := `SELECT COUNT(*) FROM communities `
+		countSql := `SELECT COUNT(*) FROM communities WHERE is_archived = 'false' AND tenant_id = $1`
 
 		sql = HOMEPAGE_SQL
 		var communities []*Community
@@ -272,6 +454,7 @@ func GetDefaultCommunities(
 			sql,
 			params.Count,
 			params.Start,
+			tenantId,
 		)
 
 		// If we get pgx.ErrNoRows, just return an empty array
@@ -282,7 +465,7 @@ func GetDefaultCommunities(
 			return []*Community{}, 0, nil
 		}
 
-		db.Conn.QueryRow(db.Context, countSql).Scan(&totalRecords)
+		db.Conn.QueryRow(db.Context, countSql, tenantId).Scan(&totalRecords)
 		return communities, totalRecords, nil
 	} else {
 		sql, err := addFiltersToSql(DEFAULT_SEARCH_SQL, "", filters)
@@ -297,6 +480,7 @@ func GetDefaultCommunities(
 			sql,
 			params.Count,
 			params.Start,
+			tenantId,
 		)
 		if err != nil {
 			return nil, 0, err
@@ -319,15 +503,15 @@ func GetDefaultCommunities(
 
 			fmt.Printf("count sql: %s \n", countSql)
 			var totalRecords int
-			db.Conn.QueryRow(db.Context, countSql).Scan(&totalRecords)
+			db.Conn.QueryRow(db.Context, countSql, tenantId).Scan(&totalRecords)
 
 			return communities, totalRecords, nil
 		} else {
 			countSql := `SELECT COUNT(*) FROM communities 
-			WHERE is_featured = 'true' AND category IS NOT NULL`
+			WHERE is_featured = 'true' AND is_archived = 'false' AND category IS NOT NULL AND tenant_id = $1`
 
 			var totalRecords int
-			db.Conn.QueryRow(db.Context, countSql).Scan(&totalRecords)
+			db.Conn.QueryRow(db.Context, countSql, tenantId).Scan(&totalRecords)
 
 			return communities, totalRecords, nil
 		}
@@ -335,6 +519,9 @@ func GetDefaultCommunities(
 }
 
 func (c *Community) CreateCommunity(db *s.Database) error {
+	if c.Tenant_id == 0 {
+		c.Tenant_id = DefaultTenantID
+	}
 
 	err := db.Conn.QueryRow(db.Context,
 		INSERT_COMMUNITY_SQL,
@@ -361,13 +548,60 @@ func (c *Community) CreateCommunity(db *s.Database) error {
 		c.Contract_type,
 		c.Public_path,
 		c.Only_authors_to_submit,
-		c.Voucher).
+		c.Voucher,
+		c.Parent_id,
+		c.Is_private,
+		c.Require_proposal_review,
+		c.Min_proposal_duration,
+		c.Max_proposal_duration,
+		c.Min_proposal_lead_time,
+		c.Proposal_time_zones,
+		c.Timezone,
+		c.Required_verifications,
+		c.Account_created_before_block,
+		c.Min_account_age,
+		c.Decay_window,
+		c.Decay_rate,
+		c.Author_min_votes,
+		c.Author_vote_window,
+		c.Duplicate_proposals,
+		c.Duplicate_threshold,
+		c.Moderation_hold_score,
+		c.Proposal_cooldown_limit,
+		c.Proposal_cooldown_window,
+		c.Deposit_amount,
+		c.Deposit_escrow_addr,
+		c.Deposit_slash_addr,
+		c.Tenant_id).
 		Scan(&c.ID, &c.Created_at)
-	return err
+	if err != nil {
+		return err
+	}
+
+	return c.recordStrategyVersion(db, &c.Creator_addr)
 }
 
+// UpdateCommunity applies the update only if the community is still at
+// c.Version, returning ErrStaleVersion otherwise.
 func (c *Community) UpdateCommunity(db *s.Database, p *UpdateCommunityRequestPayload) error {
-	_, err := db.Conn.Exec(
+	return db.WithTx(func(tx *s.Database) error {
+		if err := c.updateCommunity(tx, p); err != nil {
+			return err
+		}
+		if p.Strategies == nil {
+			return nil
+		}
+
+		var createdBy *string
+		if p.Signing_addr != "" {
+			createdBy = &p.Signing_addr
+		}
+		return c.recordStrategyVersion(tx, createdBy)
+	})
+}
+
+func (c *Community) updateCommunity(db *s.Database, p *UpdateCommunityRequestPayload) error {
+	tag, err := db.Conn.Exec(
 		db.Context,
 		UPDATE_COMMUNITY_SQL,
 		p.Name,
@@ -390,21 +624,225 @@ func (c *Community) UpdateCommunity(db *s.Database, p *UpdateCommunityRequestPay
 		p.Contract_type,
 		p.Public_path,
 		p.Only_authors_to_submit,
+		p.Is_private,
+		p.Require_proposal_review,
+		p.Min_proposal_duration,
+		p.Max_proposal_duration,
+		p.Min_proposal_lead_time,
+		p.Proposal_time_zones,
+		p.Timezone,
+		p.Required_verifications,
+		p.Account_created_before_block,
+		p.Min_account_age,
+		p.Decay_window,
+		p.Decay_rate,
+		p.Author_min_votes,
+		p.Author_vote_window,
+		p.Duplicate_proposals,
+		p.Duplicate_threshold,
+		p.Moderation_hold_score,
+		p.Proposal_cooldown_limit,
+		p.Proposal_cooldown_window,
+		p.Deposit_amount,
+		p.Deposit_escrow_addr,
+		p.Deposit_slash_addr,
 		c.ID,
+		c.Version,
 	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStaleVersion
+	}
 
-	return err
+	c.Version++
+	return nil
+}
+
+func (c *Community) ArchiveCommunity(db *s.Database) error {
+	return db.Conn.QueryRow(db.Context, `
+		UPDATE communities
+		SET is_archived = 'true', archived_at = (now() at time zone 'utc'), version = version + 1, updated_at = (now() at time zone 'utc')
+		WHERE id = $1
+		RETURNING is_archived, archived_at, version
+	`, c.ID).Scan(&c.Is_archived, &c.Archived_at, &c.Version)
+}
+
+func (c *Community) SetAdminApprovalsRequired(db *s.Database, required int) error {
+	return db.Conn.QueryRow(db.Context, `
+		UPDATE communities
+		SET admin_approvals_required = $2, version = version + 1, updated_at = (now() at time zone 'utc')
+		WHERE id = $1
+		RETURNING admin_approvals_required, version
+	`, c.ID, required).Scan(&c.Admin_approvals_required, &c.Version)
+}
+
+// Unarchiving a community also cancels any scheduled hard delete.
+func (c *Community) UnarchiveCommunity(db *s.Database) error {
+	err := db.Conn.QueryRow(db.Context, `
+		UPDATE communities
+		SET is_archived = 'false', archived_at = NULL, delete_after = NULL, version = version + 1, updated_at = (now() at time zone 'utc')
+		WHERE id = $1
+		RETURNING version
+	`, c.ID).Scan(&c.Version)
+	if err != nil {
+		return err
+	}
+
+	c.Is_archived = false
+	c.Archived_at = nil
+	c.Delete_after = nil
+	return nil
+}
+
+func (c *Community) ScheduleDelete(db *s.Database, gracePeriod time.Duration) error {
+	deleteAfter := time.Now().UTC().Add(gracePeriod)
+	_, err := db.Conn.Exec(db.Context, `
+		UPDATE communities SET delete_after = $1, updated_at = (now() at time zone 'utc') WHERE id = $2
+	`, deleteAfter, c.ID)
+	if err != nil {
+		return err
+	}
+
+	c.Delete_after = &deleteAfter
+	return nil
+}
+
+// HardDeleteCommunity permanently removes a community. Everything keyed to
+// it or its proposals goes with it by cascade, except the records that
+// don't reference it, which are removed first. Billing events are kept as
+// the ledger of processed webhooks, only detached from the community.
+func (c *Community) HardDeleteCommunity(db *s.Database) error {
+	statements := []string{
+		`DELETE FROM nfts WHERE proposal_id IN (SELECT id FROM proposals WHERE community_id = $1)`,
+		`DELETE FROM ipfs_pins WHERE
+			(record_type = 'community' AND record_id = $1)
+			OR (record_type IN ('proposal', 'proposal_results')
+				AND record_id IN (SELECT id FROM proposals WHERE community_id = $1))
+			OR (record_type = 'list' AND record_id IN (SELECT id FROM lists WHERE community_id = $1))`,
+		`UPDATE billing_events SET community_id = NULL WHERE community_id = $1`,
+		`DELETE FROM communities WHERE id = $1`,
+	}
+
+	return db.WithTx(func(tx *s.Database) error {
+		for _, sql := range statements {
+			if _, err := tx.Conn.Exec(tx.Context, sql, c.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetCommunitiesDueForDelete returns the archived communities whose grace
+// period before deletion has passed.
+func GetCommunitiesDueForDelete(db *s.Database) ([]*Community, error) {
+	var communities []*Community
+	err := pgxscan.Select(db.Context, db.Conn, &communities,
+		`
+		SELECT * FROM communities
+		WHERE is_archived = 'true' AND delete_after <= (now() at time zone 'utc')
+		ORDER BY delete_after ASC
+		`)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	return communities, nil
 }
 
 func (c *Community) CanUpdateCommunity(db *s.Database, addr string) error {
-	// Check if address has admin role
-	admin := CommunityUser{Addr: addr, Community_id: c.ID, User_type: "admin"}
-	if err := admin.GetCommunityUser(db); err != nil {
+	// Check if address has admin role, either directly or via a parent community
+	if err := EnsureRoleForCommunity(db, addr, c.ID, "admin"); err != nil {
 		return fmt.Errorf("address %s does not have permission to update community with ID %d", addr, c.ID)
 	}
 	return nil
 }
 
+func GetChildCommunities(db *s.Database, parentId int, pageParams shared.PageParams) ([]*Community, int, error) {
+	var communities []*Community
+	err := pgxscan.Select(db.Context, db.Conn, &communities,
+		`
+		SELECT * FROM communities
+		WHERE parent_id = $1 AND is_archived = 'false'
+		ORDER BY created_at ASC
+		LIMIT $2 OFFSET $3
+		`, parentId, pageParams.Count, pageParams.Start)
+
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, 0, err
+	} else if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return []*Community{}, 0, nil
+	}
+
+	var totalRecords int
+	countSql := `SELECT COUNT(*) FROM communities WHERE parent_id = $1 AND is_archived = 'false'`
+	_ = db.Conn.QueryRow(db.Context, countSql, parentId).Scan(&totalRecords)
+
+	return communities, totalRecords, nil
+}
+
+// GetAncestorCommunityIds returns the ids of every parent above the
+// community, nearest parent first.
+func GetAncestorCommunityIds(db *s.Database, communityId int) ([]int, error) {
+	var ids []int
+	err := pgxscan.Select(db.Context, db.Conn, &ids,
+		`
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id, 0 AS depth FROM communities WHERE id = $1
+			UNION
+			SELECT c.id, c.parent_id, a.depth + 1 FROM communities c
+			JOIN ancestors a ON c.id = a.parent_id
+		)
+		SELECT id FROM ancestors WHERE id != $1 ORDER BY depth ASC
+		`, communityId)
+
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func GetCommunityHierarchy(db *s.Database, c *Community) (CommunityHierarchy, error) {
+	hierarchy := CommunityHierarchy{Community: c, Ancestors: []*Community{}, Descendants: []*Community{}}
+
+	err := pgxscan.Select(db.Context, db.Conn, &hierarchy.Ancestors,
+		`
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id, 0 AS depth FROM communities WHERE id = $1
+			UNION
+			SELECT c.id, c.parent_id, a.depth + 1 FROM communities c
+			JOIN ancestors a ON c.id = a.parent_id
+		)
+		SELECT c.* FROM communities c
+		JOIN ancestors a ON a.id = c.id
+		WHERE c.id != $1
+		ORDER BY a.depth ASC
+		`, c.ID)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return hierarchy, err
+	}
+
+	err = pgxscan.Select(db.Context, db.Conn, &hierarchy.Descendants,
+		`
+		WITH RECURSIVE descendants AS (
+			SELECT id, 0 AS depth FROM communities WHERE id = $1
+			UNION
+			SELECT c.id, d.depth + 1 FROM communities c
+			JOIN descendants d ON c.parent_id = d.id
+		)
+		SELECT c.* FROM communities c
+		JOIN descendants d ON d.id = c.id
+		WHERE c.id != $1 AND c.is_archived = 'false'
+		ORDER BY d.depth ASC, c.id ASC
+		`, c.ID)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return hierarchy, err
+	}
+
+	return hierarchy, nil
+}
+
 func (c *Community) GetStrategy(name string) (Strategy, error) {
 	for _, s := range *c.Strategies {
 		if *s.Name == name {
@@ -416,6 +854,7 @@ func (c *Community) GetStrategy(name string) (Strategy, error) {
 
 func SearchForCommunity(
 	db *s.Database,
+	tenantId int,
 	query string,
 	filters []string,
 	params shared.PageParams,
@@ -434,6 +873,7 @@ func SearchForCommunity(
 		query,
 		params.Count,
 		params.Start,
+		tenantId,
 	)
 
 	if err != nil {
@@ -455,14 +895,14 @@ func SearchForCommunity(
 			return nil, 0, err
 		}
 		var totalRecords int
-		db.Conn.QueryRow(db.Context, countSql, query).Scan(&totalRecords)
+		db.Conn.QueryRow(db.Context, countSql, query, tenantId).Scan(&totalRecords)
 
 		return communities, totalRecords, nil
 	} else {
 		countSql := `SELECT COUNT(*) FROM communities 
-									WHERE SIMILARITY(name, $1) > 0.1`
+									WHERE SIMILARITY(name, $1) > 0.1 AND is_archived = 'false' AND tenant_id = $2`
 		var totalRecords int
-		db.Conn.QueryRow(db.Context, countSql, query).Scan(&totalRecords)
+		db.Conn.QueryRow(db.Context, countSql, query, tenantId).Scan(&totalRecords)
 
 		return communities, totalRecords, nil
 	}
@@ -508,7 +948,9 @@ func generateSearchFilterCountSql(filters []string) (string, error) {
 		var sql string = `
 				SELECT COUNT(*) FROM communities
         WHERE SIMILARITY(name, $1) > 0.1
+        AND is_archived = 'false'
         AND category IS NOT NULL
+        AND tenant_id = $2
 				AND category IN (`
 		for i, filter := range filters {
 			if i == len(filters)-1 {
@@ -530,6 +972,8 @@ func generateDefaultFilterCountSql(filters []string) (string, error) {
 				SELECT COUNT(*) FROM communities
         WHERE category IS NOT NULL
 				AND is_featured = true
+				AND is_archived = 'false'
+				AND tenant_id = $1
 				AND category IN (`
 		for i, filter := range filters {
 			if i == len(filters)-1 {
@@ -544,7 +988,7 @@ func generateDefaultFilterCountSql(filters []string) (string, error) {
 		return "", fmt.Errorf("No filters provided")
 	}
 }
-func GetCategoryCount(db *s.Database, search string) (map[string]int, error) {
+func GetCategoryCount(db *s.Database, tenantId int, search string) (map[string]int, error) {
 	var rows pgx.Rows
 	var err error
 
@@ -552,12 +996,14 @@ func GetCategoryCount(db *s.Database, search string) (map[string]int, error) {
 		rows, err = db.Conn.Query(
 			db.Context,
 			COUNT_CATEGORIES_DEFAULT_SQL,
+			tenantId,
 		)
 	} else {
 		rows, err = db.Conn.Query(
 			db.Context,
 			COUNT_CATEGORIES_SEARCH_SQL,
 			search,
+			tenantId,
 		)
 	}
 
@@ -605,3 +1051,9 @@ func addFiltersToSql(query, search string, filters []string) (string, error) {
 
 	return sql, nil
 }
+
+// SetCid stores the CID the community was pinned under.
+func (c *Community) SetCid(db *s.Database, cid string) error {
+	_, err := db.Conn.Exec(db.Context, `UPDATE communities SET cid = $2, updated_at = (now() at time zone 'utc') WHERE id = $1`, c.ID, cid)
+	return err
+}
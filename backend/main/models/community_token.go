@@ -0,0 +1,148 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+// CommunityToken is a fungible token registered by a community, after its
+// contract and paths were checked on chain. Strategies weigh votes with
+// registered tokens, or with tokens known to the network.
+type CommunityToken struct {
+	ID            int       `json:"id"`
+	Community_id  int       `json:"communityId"`
+	Contract_addr string    `json:"contractAddr" validate:"required,len=18,hexadecimal"`
+	Contract_name string    `json:"contractName" validate:"required,max=64"`
+	Vault_path    string    `json:"vaultPath"    validate:"required,max=128"`
+	Receiver_path string    `json:"receiverPath" validate:"required,max=128"`
+	Balance_path  string    `json:"balancePath"  validate:"required,max=128"`
+	Decimals      int       `json:"decimals"     validate:"min=0,max=8"`
+	Symbol        string    `json:"symbol"       validate:"max=16"`
+	Name          string    `json:"name"         validate:"max=64"`
+	Logo_uri      string    `json:"logoURI"      validate:"omitempty,url,max=512"`
+	Created_by    string    `json:"createdBy"`
+	Created_at    time.Time `json:"createdAt"`
+}
+
+type CommunityTokenPayload struct {
+	CommunityToken
+	Voucher *s.Voucher `json:"voucher,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
+// names and paths are Cadence identifiers, token names are also written
+// into the scripts validating them
+var cadenceIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateIdentifiers checks the contract name and paths are identifiers.
+func (t *CommunityToken) ValidateIdentifiers() error {
+	for _, id := range []string{t.Contract_name, t.Vault_path, t.Receiver_path, t.Balance_path} {
+		if !cadenceIdentifier.MatchString(id) {
+			return errors.New("Contract names and paths must be Cadence identifiers.")
+		}
+	}
+	return nil
+}
+
+// Contract is the token as strategies refer to it.
+func (t *CommunityToken) Contract() s.Contract {
+	return s.Contract{Name: &t.Contract_name, Addr: &t.Contract_addr, Public_path: &t.Balance_path}
+}
+
+// Metadata describes the token as the token list does, its contract name
+// standing in for a symbol it was registered without.
+func (t *CommunityToken) Metadata() s.TokenMetadata {
+	symbol, name := t.Symbol, t.Name
+	if symbol == "" {
+		symbol = t.Contract_name
+	}
+	if name == "" {
+		name = t.Contract_name
+	}
+	return s.TokenMetadata{
+		Symbol:        symbol,
+		Name:          name,
+		Decimals:      t.Decimals,
+		Logo_uri:      t.Logo_uri,
+		Contract_name: t.Contract_name,
+		Contract_addr: t.Contract_addr,
+		Paths: s.TokenPaths{
+			Vault:    "/storage/" + t.Vault_path,
+			Receiver: "/public/" + t.Receiver_path,
+			Balance:  "/public/" + t.Balance_path,
+		},
+		Source: s.TokenSourceCommunity,
+	}
+}
+
+func GetTokensForCommunity(db *s.Database, communityId int) ([]*CommunityToken, error) {
+	tokens := []*CommunityToken{}
+	err := pgxscan.Select(db.Context, db.Conn, &tokens,
+		`SELECT * FROM community_tokens WHERE community_id = $1 ORDER BY id ASC`,
+		communityId)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (t *CommunityToken) GetCommunityTokenById(db *s.Database) error {
+	return pgxscan.Get(db.Context, db.Conn, t,
+		`SELECT * FROM community_tokens WHERE id = $1`,
+		t.ID)
+}
+
+// GetCommunityTokenByContract returns the community's registration of the
+// token, or nil when it did not register it.
+func GetCommunityTokenByContract(db *s.Database, communityId int, name, addr string) (*CommunityToken, error) {
+	var t CommunityToken
+	err := pgxscan.Get(db.Context, db.Conn, &t,
+		`SELECT * FROM community_tokens WHERE community_id = $1 AND contract_name = $2 AND contract_addr = $3`,
+		communityId, name, addr)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// IsRegisteredToken tells whether the community registered the token.
+func IsRegisteredToken(db *s.Database, communityId int, contract s.Contract) (bool, error) {
+	if contract.Name == nil || contract.Addr == nil || contract.Public_path == nil {
+		return false, nil
+	}
+	var registered bool
+	err := db.Conn.QueryRow(db.Context,
+		`
+		SELECT EXISTS (
+			SELECT 1 FROM community_tokens
+			WHERE community_id = $1 AND contract_addr = $2 AND contract_name = $3 AND balance_path = $4
+		)
+		`, communityId, *contract.Addr, *contract.Name, *contract.Public_path).Scan(&registered)
+	return registered, err
+}
+
+func (t *CommunityToken) CreateCommunityToken(db *s.Database) error {
+	return db.Conn.QueryRow(db.Context,
+		`
+		INSERT INTO community_tokens(community_id, contract_addr, contract_name, vault_path,
+			receiver_path, balance_path, decimals, symbol, name, logo_uri, created_by)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at
+		`, t.Community_id, t.Contract_addr, t.Contract_name, t.Vault_path,
+		t.Receiver_path, t.Balance_path, t.Decimals, t.Symbol, t.Name, t.Logo_uri, t.Created_by).
+		Scan(&t.ID, &t.Created_at)
+}
+
+func (t *CommunityToken) DeleteCommunityToken(db *s.Database) error {
+	_, err := db.Conn.Exec(db.Context, `DELETE FROM community_tokens WHERE id = $1`, t.ID)
+	return err
+}
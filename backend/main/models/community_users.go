@@ -3,6 +3,7 @@ package models
 import (
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/DapperCollectives/CAST/backend/main/shared"
 	s "github.com/DapperCollectives/CAST/backend/main/shared"
@@ -12,17 +13,21 @@ import (
 )
 
 type CommunityUser struct {
-	Community_id int    `json:"communityId" validate:"required"`
-	Addr         string `json:"addr" validate:"required"`
-	User_type    string `json:"userType" validate:"required"`
+	Community_id int        `json:"communityId" validate:"required"`
+	Addr         string     `json:"addr" validate:"required"`
+	User_type    string     `json:"userType" validate:"required"`
+	Created_at   *time.Time `json:"createdAt,omitempty"`
+	Reputation   *float64   `json:"reputation,omitempty"`
 }
 
 type CommunityUserType struct {
-	Community_id int    `json:"communityId" validate:"required"`
-	Addr         string `json:"addr" validate:"required"`
-	Is_admin     bool   `json:"isAdmin" validate:"required"`
-	Is_author    bool   `json:"isAuthor" validate:"required"`
-	Is_member    bool   `json:"isMember" validate:"required"`
+	Community_id int      `json:"communityId" validate:"required"`
+	Addr         string   `json:"addr" validate:"required"`
+	Is_admin     bool     `json:"isAdmin" validate:"required"`
+	Is_author    bool     `json:"isAuthor" validate:"required"`
+	Is_member    bool     `json:"isMember" validate:"required"`
+	Reputation   *float64 `json:"reputation,omitempty"`
+	Verified_by  []string `json:"verifiedBy,omitempty"`
 }
 
 type UserTypes []string
@@ -51,14 +56,35 @@ type UserAchievements = []struct {
 }
 
 type LeaderboardUser struct {
-	Addr  string `json:"addr" validate:"required"`
-	Score int    `json:"score,omitempty"`
-	Index int    `json:"index,omitempty"`
+	Addr    string          `json:"addr" validate:"required"`
+	Score   int             `json:"score,omitempty"`
+	Index   int             `json:"index,omitempty"`
+	Profile *ProfileSummary `json:"profile,omitempty"`
 }
 
 type LeaderboardPayload struct {
-	Users       []LeaderboardUser `json:"users"`
-	CurrentUser LeaderboardUser   `json:"currentUser"`
+	Users        []LeaderboardUser `json:"users"`
+	CurrentUser  LeaderboardUser   `json:"currentUser"`
+	Window       string            `json:"window,omitempty"`
+	Metric       string            `json:"metric,omitempty"`
+	Refreshed_at *time.Time        `json:"refreshedAt,omitempty"`
+}
+
+// UserSortColumns are the fields the users of a community can be sorted by.
+var UserSortColumns = shared.SortColumns{
+	"addr":       "addr",
+	"is_admin":   "is_admin",
+	"is_author":  "is_author",
+	"is_member":  "is_member",
+	"reputation": "reputation",
+}
+
+// UserTypeSortColumns are the fields the users of a community with a role
+// can be sorted by.
+var UserTypeSortColumns = shared.SortColumns{
+	"addr":       "cu.addr",
+	"created_at": "cu.created_at",
+	"reputation": "reputation",
 }
 
 func GetUsersForCommunity(db *s.Database, communityId int, pageParams shared.PageParams) ([]CommunityUserType, int, error) {
@@ -76,10 +102,14 @@ func GetUsersForCommunity(db *s.Database, communityId int, pageParams shared.Pag
 					(EXISTS (SELECT community_users.addr FROM community_users WHERE community_users.addr = temp_user_addrs.addr AND community_users.user_type = 'member')) 
 				THEN '1' else '0' end)::boolean AS is_member,
 				temp_user_addrs.addr AS addr,
-				$1 as community_id
+				$1 as community_id,
+				COALESCE((SELECT score FROM reputation_scores
+					WHERE reputation_scores.community_id = $1 AND reputation_scores.addr = temp_user_addrs.addr), 0) AS reputation,
+				`+verifiedProviders("temp_user_addrs.addr")+` AS verified_by
 		FROM 
 				(SELECT addr FROM community_users WHERE community_id = $1 group BY community_users.addr) 
-		AS temp_user_addrs 
+		AS temp_user_addrs`+
+			UserSortColumns.OrderBy(pageParams.Sort, "")+`
 		LIMIT $2 OFFSET $3
 		`, communityId, pageParams.Count, pageParams.Start)
 
@@ -105,7 +135,10 @@ func GetUsersForCommunityByType(
 	var users = []CommunityUser{}
 	err := pgxscan.Select(db.Context, db.Conn, &users,
 		`
-		SELECT * FROM community_users WHERE community_id = $1 AND user_type = $2
+		SELECT cu.*, COALESCE(r.score, 0) AS reputation FROM community_users cu
+		LEFT JOIN reputation_scores r ON r.community_id = cu.community_id AND r.addr = cu.addr
+		WHERE cu.community_id = $1 AND cu.user_type = $2`+
+			UserTypeSortColumns.OrderBy(pageParams.Sort, "")+`
 		LIMIT $3 OFFSET $4
 		`, communityId, user_type, pageParams.Count, pageParams.Start)
 
@@ -216,22 +249,36 @@ func (u *CommunityUser) Remove(db *s.Database) error {
 	return err
 }
 
+func CountCommunityAdmins(db *s.Database, communityId int) (int, error) {
+	var count int
+	err := db.Conn.QueryRow(db.Context,
+		`SELECT COUNT(*) FROM community_users WHERE community_id = $1 AND user_type = 'admin'`,
+		communityId).Scan(&count)
+	return count, err
+}
+
+// PurgeMembers removes every role of the addresses in the community.
+func PurgeMembers(db *s.Database, communityId int, addrs []string) error {
+	_, err := db.Conn.Exec(db.Context,
+		`DELETE FROM community_users WHERE community_id = $1 AND addr = ANY($2)`,
+		communityId, addrs)
+	return err
+}
+
 func GrantAdminRolesToAddress(db *s.Database, communityId int, addr string) error {
-	userTypes := UserTypes{"admin", "author", "member"}
-	for _, role := range userTypes {
-		userRole := CommunityUser{Addr: addr, Community_id: communityId, User_type: role}
-		if err := userRole.GetCommunityUser(db); err != nil {
-			if err := userRole.CreateCommunityUser(db); err != nil {
-				log.Error().Err(err).Msgf("Database error creating role %s for Address: %s and Communuity Id: %d.", role, addr, communityId)
-				return err
-			}
-		}
-	}
-	return nil
+	return db.WithTx(func(tx *s.Database) error {
+		return grantRolesToAddress(tx, communityId, addr, UserTypes{"admin", "author", "member"})
+	})
 }
 
 func GrantAuthorRolesToAddress(db *s.Database, communityId int, addr string) error {
-	userTypes := UserTypes{"author", "member"}
+	return db.WithTx(func(tx *s.Database) error {
+		return grantRolesToAddress(tx, communityId, addr, UserTypes{"author", "member"})
+	})
+}
+
+// grantRolesToAddress creates the roles the address does not hold yet.
+func grantRolesToAddress(db *s.Database, communityId int, addr string, userTypes UserTypes) error {
 	for _, role := range userTypes {
 		userRole := CommunityUser{Addr: addr, Community_id: communityId, User_type: role}
 		if err := userRole.GetCommunityUser(db); err != nil {
@@ -256,19 +303,40 @@ func (u *CommunityUser) CreateCommunityUser(db *s.Database) error {
 }
 
 func GrantRolesToCommunityCreator(db *s.Database, addr string, communityId int) error {
-	for _, userType := range USER_TYPES {
-		communityUser := CommunityUser{Addr: addr, Community_id: communityId, User_type: userType}
-		if err := communityUser.CreateCommunityUser(db); err != nil {
-			return err
+	return db.WithTx(func(tx *s.Database) error {
+		for _, userType := range USER_TYPES {
+			communityUser := CommunityUser{Addr: addr, Community_id: communityId, User_type: userType}
+			if err := communityUser.CreateCommunityUser(tx); err != nil {
+				return err
+			}
+			log.Debug().Msgf("granted addr %s role %s for community %d", addr, userType, communityId)
 		}
-		log.Debug().Msgf("granted addr %s role %s for community %d", addr, userType, communityId)
-	}
-	return nil
+		return nil
+	})
 }
 
+// EnsureRoleForCommunity checks that the address holds the role in the
+// community. Admins of a parent community are treated as admins of its
+// children.
 func EnsureRoleForCommunity(db *s.Database, addr string, communityId int, userType string) error {
 	user := CommunityUser{Addr: addr, Community_id: communityId, User_type: userType}
-	return user.GetCommunityUser(db)
+	err := user.GetCommunityUser(db)
+	if err == nil || userType != "admin" {
+		return err
+	}
+
+	ancestorIds, ancestorErr := GetAncestorCommunityIds(db, communityId)
+	if ancestorErr != nil {
+		return ancestorErr
+	}
+	for _, id := range ancestorIds {
+		parentAdmin := CommunityUser{Addr: addr, Community_id: id, User_type: "admin"}
+		if parentAdmin.GetCommunityUser(db) == nil {
+			return nil
+		}
+	}
+
+	return err
 }
 
 func EnsureValidRole(userType string) bool {
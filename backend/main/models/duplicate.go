@@ -0,0 +1,107 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+// What a community does with a new proposal that looks like a recent one.
+const (
+	DuplicatesOff   = "off"
+	DuplicatesWarn  = "warn"
+	DuplicatesBlock = "block"
+)
+
+const (
+	DefaultDuplicateThreshold = 0.6
+	// how far back proposals are compared, and how many matches are listed
+	duplicateWindow     = 90 * 24 * time.Hour
+	maxDuplicateMatches = 5
+	// bodies are compared on their start, which is enough to tell them apart
+	duplicateBodyPrefix = 2000
+)
+
+// DuplicateProposal is a recent proposal of the community a new one closely
+// matches, with the similarity of their names and bodies from 0 to 1.
+type DuplicateProposal struct {
+	ID         int       `json:"id"`
+	Name       string    `json:"name"`
+	Status     string    `json:"status"`
+	Created_at time.Time `json:"createdAt"`
+	Similarity float64   `json:"similarity"`
+}
+
+// DuplicateCheck compares new proposals of a community with its recent ones,
+// to warn authors or block them. nil means off.
+type DuplicateCheck struct {
+	Duplicate_proposals *string  `json:"duplicateProposals,omitempty"`
+	Duplicate_threshold *float64 `json:"duplicateThreshold,omitempty"`
+}
+
+func (d DuplicateCheck) IsZero() bool {
+	return d.Duplicate_proposals == nil || *d.Duplicate_proposals == DuplicatesOff
+}
+
+func (d DuplicateCheck) Blocks() bool {
+	return d.Duplicate_proposals != nil && *d.Duplicate_proposals == DuplicatesBlock
+}
+
+func (d DuplicateCheck) Validate() error {
+	if d.Duplicate_proposals != nil {
+		switch *d.Duplicate_proposals {
+		case DuplicatesOff, DuplicatesWarn, DuplicatesBlock:
+		default:
+			return fmt.Errorf("Duplicate proposals must be off, warn or block, not %q.", *d.Duplicate_proposals)
+		}
+	}
+	if d.Duplicate_threshold != nil && (*d.Duplicate_threshold <= 0 || *d.Duplicate_threshold > 1) {
+		return errors.New("Duplicate threshold must be above 0 and at most 1.")
+	}
+	return nil
+}
+
+func (d DuplicateCheck) Threshold() float64 {
+	if d.Duplicate_threshold != nil {
+		return *d.Duplicate_threshold
+	}
+	return DefaultDuplicateThreshold
+}
+
+// FindDuplicateProposals lists the community's proposals of the last 90
+// days whose name and body, weighed equally, are at least threshold similar
+// to those given, most similar first. Without a body only names are
+// compared. Rejected and cancelled proposals and excludeId are left out.
+func FindDuplicateProposals(
+	db *s.Database,
+	communityId int,
+	name, body string,
+	threshold float64,
+	excludeId int,
+) ([]*DuplicateProposal, error) {
+	var matches []*DuplicateProposal
+	err := pgxscan.Select(db.Context, db.Conn, &matches,
+		`
+		SELECT id, name, status, created_at, similarity FROM (
+			SELECT id, name, COALESCE(status::text, '') AS status, created_at,
+				CASE WHEN $4 = '' THEN SIMILARITY(name, $2)
+					ELSE (SIMILARITY(name, $2) + SIMILARITY(LEFT(COALESCE(body, ''), $3), LEFT($4, $3))) / 2
+				END AS similarity
+			FROM proposals
+			WHERE community_id = $1 AND id <> $5 AND created_at >= $6
+				AND (status IS NULL OR status NOT IN ('rejected', 'cancelled'))
+		) p
+		WHERE similarity >= $7
+		ORDER BY similarity DESC, id DESC
+		LIMIT $8
+		`, communityId, name, duplicateBodyPrefix, body, excludeId,
+		time.Now().UTC().Add(-duplicateWindow), threshold, maxDuplicateMatches)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	return matches, nil
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+// EarlyCloseDecisive is the rule closing proposals whose leading choice can
+// no longer be overtaken.
+const EarlyCloseDecisive = "decisive-outcome"
+
+// EarlyClose records what closed a proposal before its end time.
+type EarlyClose struct {
+	Rule             string    `json:"rule"`
+	Leading_choice   string    `json:"leadingChoice"`
+	Lead             float64   `json:"lead"`
+	Remaining_weight float64   `json:"remainingWeight"`
+	Scheduled_end    time.Time `json:"scheduledEnd"`
+	Triggered_at     time.Time `json:"triggeredAt"`
+}
+
+// DecisiveLead returns the trigger of the decisive outcome rule when the
+// leading choice leads every other, and the vetoes, by more than the weight
+// still to be cast, the quorum is met and no veto is possible. It returns
+// nil while the outcome can change.
+func (r ProposalResults) DecisiveLead(p Proposal, remaining float64) *EarlyClose {
+	outcome := r.DecideOutcome(p)
+	if !outcome.Quorum_met || outcome.Vetoed {
+		return nil
+	}
+	if t := outcome.Veto_threshold; t != nil &&
+		(outcome.Veto_weight+remaining)/(outcome.Outcome_weight+remaining)*100 >= *t {
+		return nil
+	}
+
+	leading, first := "", 0.0
+	for _, choice := range p.Choices {
+		weight := r.Results_float[choice.Choice_text]
+		if choice.Choice_type == "" && (leading == "" || weight > first) {
+			leading, first = choice.Choice_text, weight
+		}
+	}
+	second := outcome.Veto_weight
+	for _, choice := range p.Choices {
+		weight := r.Results_float[choice.Choice_text]
+		if choice.Choice_type == "" && choice.Choice_text != leading && weight > second {
+			second = weight
+		}
+	}
+
+	lead := first - second
+	if leading == "" || lead <= remaining {
+		return nil
+	}
+	return &EarlyClose{
+		Rule:             EarlyCloseDecisive,
+		Leading_choice:   leading,
+		Lead:             lead,
+		Remaining_weight: remaining,
+		Scheduled_end:    p.End_time,
+		Triggered_at:     time.Now().UTC(),
+	}
+}
+
+// GetProposalsForEarlyClose returns open proposals that allow closing early,
+// soonest ending first.
+func GetProposalsForEarlyClose(db *s.Database) ([]*Proposal, error) {
+	var proposals []*Proposal
+	err := pgxscan.Select(db.Context, db.Conn, &proposals,
+		fmt.Sprintf(`
+		SELECT *, %s FROM proposals
+		WHERE status = 'published' AND allow_early_close
+		AND start_time <= (now() at time zone 'utc') AND end_time > (now() at time zone 'utc')
+		ORDER BY end_time ASC
+		LIMIT $1
+		`, computedStatusSQL), proposalCloseBatchSize)
+
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	return proposals, nil
+}
+
+// CountVotesFrom counts the votes cast on the proposal by the addresses.
+func CountVotesFrom(db *s.Database, proposalId int, addrs []string) (int, error) {
+	var count int
+	err := db.Conn.QueryRow(db.Context,
+		`SELECT COUNT(DISTINCT addr) FROM votes WHERE proposal_id = $1 AND addr = ANY($2)`,
+		proposalId, addrs).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+)
+
+const OEmbedVersion = "1.0"
+
+// ProposalEmbed is what a widget on another site shows of a proposal: only
+// public fields, with its live results.
+type ProposalEmbed struct {
+	ID             int             `json:"id"`
+	Name           string          `json:"name"`
+	Community      CommunityEmbed  `json:"community"`
+	Status         string          `json:"status"`
+	Voting_status  string          `json:"votingStatus"`
+	Start_time     time.Time       `json:"startTime"`
+	End_time       time.Time       `json:"endTime"`
+	Results        []*ChoiceResult `json:"results"`
+	Total_votes    int             `json:"totalVotes"`
+	Winning_choice *string         `json:"winningChoice,omitempty"`
+	Url            string          `json:"url"`
+	Image          string          `json:"image"`
+	Updated_at     *time.Time      `json:"-"`
+}
+
+type CommunityEmbed struct {
+	ID   int     `json:"id"`
+	Name string  `json:"name"`
+	Logo *string `json:"logo,omitempty"`
+}
+
+// OEmbed is an oEmbed rich response, see https://oembed.com.
+type OEmbed struct {
+	Version          string `json:"version"`
+	Type             string `json:"type"`
+	Provider_name    string `json:"provider_name"`
+	Provider_url     string `json:"provider_url"`
+	Title            string `json:"title"`
+	Author_name      string `json:"author_name"`
+	Author_url       string `json:"author_url"`
+	Html             string `json:"html"`
+	Width            int    `json:"width"`
+	Height           int    `json:"height"`
+	Cache_age        int    `json:"cache_age"`
+	Thumbnail_url    string `json:"thumbnail_url,omitempty"`
+	Thumbnail_width  int    `json:"thumbnail_width,omitempty"`
+	Thumbnail_height int    `json:"thumbnail_height,omitempty"`
+}
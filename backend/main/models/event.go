@@ -0,0 +1,95 @@
+package models
+
+import (
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	EventProposalCreated = "proposal_created"
+	EventProposalClosed  = "proposal_closed"
+	EventRoleGranted     = "role_granted"
+	EventRoleRemoved     = "role_removed"
+	EventVoteMilestone   = "vote_milestone"
+)
+
+// VOTE_MILESTONES are the proposal vote counts announced in the feed.
+var VOTE_MILESTONES = []int{10, 50, 100, 500, 1000, 5000, 10000}
+
+type CommunityEvent struct {
+	ID           int                    `json:"id"`
+	Community_id int                    `json:"communityId"`
+	Event_type   string                 `json:"eventType"`
+	Actor_addr   *string                `json:"actorAddr,omitempty"`
+	Proposal_id  *int                   `json:"proposalId,omitempty"`
+	Data         map[string]interface{} `json:"data"`
+	Milestone    *int                   `json:"milestone,omitempty"`
+	Created_at   *time.Time             `json:"createdAt,omitempty"`
+}
+
+func GetFeedForCommunity(db *s.Database, communityId int, pageParams shared.PageParams) ([]*CommunityEvent, int, error) {
+	var events []*CommunityEvent
+	err := pgxscan.Select(db.Context, db.Conn, &events,
+		`
+		SELECT * FROM community_events
+		WHERE community_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+		`, communityId, pageParams.Count, pageParams.Start)
+
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, 0, err
+	} else if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return []*CommunityEvent{}, 0, nil
+	}
+
+	var totalRecords int
+	countSql := `SELECT COUNT(*) FROM community_events WHERE community_id = $1`
+	_ = db.Conn.QueryRow(db.Context, countSql, communityId).Scan(&totalRecords)
+
+	return events, totalRecords, nil
+}
+
+// CreateEvent stores the event. Events that were already recorded for a
+// proposal (closing, milestones) are ignored.
+func (e *CommunityEvent) CreateEvent(db *s.Database) error {
+	if e.Data == nil {
+		e.Data = map[string]interface{}{}
+	}
+	err := db.Conn.QueryRow(db.Context,
+		`
+		INSERT INTO community_events(community_id, event_type, actor_addr, proposal_id, data, milestone)
+		VALUES($1, $2, $3, $4, $5, $6)
+		ON CONFLICT DO NOTHING
+		RETURNING id, created_at
+		`, e.Community_id, e.Event_type, e.Actor_addr, e.Proposal_id, e.Data, e.Milestone).
+		Scan(&e.ID, &e.Created_at)
+
+	if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return nil
+	}
+	return err
+}
+
+// HighestVoteMilestone returns the largest milestone reached by the vote count, if any.
+func HighestVoteMilestone(totalVotes int) (int, bool) {
+	milestone := 0
+	for _, m := range VOTE_MILESTONES {
+		if totalVotes >= m {
+			milestone = m
+		}
+	}
+	return milestone, milestone > 0
+}
+
+func GetVoteCountForProposal(db *s.Database, proposalId int) (int, error) {
+	var count int
+	err := db.Conn.QueryRow(db.Context,
+		`SELECT COUNT(*) FROM votes WHERE proposal_id = $1`,
+		proposalId).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+)
+
+const maxExecutionCadenceLength = 64 * 1024
+
+// ExecutionPayload is a Cadence transaction, with its arguments encoded as
+// JSON-Cadence values, to be executed when the proposal passes with the
+// choice.
+type ExecutionPayload struct {
+	Proposal_id int             `json:"-"`
+	Choice      string          `json:"choice"    validate:"required"`
+	Cadence     string          `json:"cadence"   validate:"required"`
+	Arguments   json.RawMessage `json:"arguments"`
+	Created_at  *time.Time      `json:"createdAt,omitempty"`
+}
+
+// ExecutionRecord is what signers or automation need to execute a passed
+// proposal's payload, along with proof of the result it was passed by.
+type ExecutionRecord struct {
+	Proposal_id   int                `json:"proposalId"`
+	Community_id  int                `json:"communityId"`
+	Choice        string             `json:"choice"`
+	Cadence       string             `json:"cadence"`
+	Arguments     json.RawMessage    `json:"arguments"`
+	Results       map[string]int     `json:"results"`
+	Results_float map[string]float64 `json:"resultsFloat"`
+	Proposal_cid  string             `json:"proposalCid"`
+	Results_cid   string             `json:"resultsCid"`
+	Block_height  *uint64            `json:"blockHeight"`
+	Closed_at     *time.Time         `json:"closedAt"`
+	Issued_at     time.Time          `json:"issuedAt"`
+}
+
+// Validate checks the payload is a transaction triggered by one of the
+// proposal's choices, with arguments that are JSON-Cadence values.
+func (e *ExecutionPayload) Validate(choices []s.Choice) error {
+	found := false
+	for _, choice := range choices {
+		if choice.Choice_text == e.Choice && choice.Choice_type == "" {
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("Execution payload choice %s is not a choice of the proposal that can win.", e.Choice)
+	}
+
+	if len(e.Cadence) > maxExecutionCadenceLength {
+		return fmt.Errorf("Execution payload cadence cannot be longer than %d bytes.", maxExecutionCadenceLength)
+	}
+	if !strings.Contains(e.Cadence, "transaction") {
+		return errors.New("Execution payload cadence must be a transaction.")
+	}
+
+	if len(e.Arguments) == 0 {
+		e.Arguments = json.RawMessage("[]")
+	}
+	var arguments []map[string]interface{}
+	if err := json.Unmarshal(e.Arguments, &arguments); err != nil {
+		return errors.New("Execution payload arguments must be a list of JSON-Cadence values.")
+	}
+	for i, argument := range arguments {
+		if _, ok := argument["type"].(string); !ok {
+			return fmt.Errorf("Execution payload argument %d has no type.", i)
+		}
+		if _, ok := argument["value"]; !ok {
+			return fmt.Errorf("Execution payload argument %d has no value.", i)
+		}
+	}
+	return nil
+}
+
+// GetExecutionPayload returns the proposal's execution payload, or nil
+// when it has none.
+func GetExecutionPayload(db *s.Database, proposalId int) (*ExecutionPayload, error) {
+	payloads := []*ExecutionPayload{}
+	err := pgxscan.Select(db.Context, db.Conn, &payloads,
+		`SELECT * FROM proposal_execution_payloads WHERE proposal_id = $1`,
+		proposalId)
+	if err != nil || len(payloads) == 0 {
+		return nil, err
+	}
+	return payloads[0], nil
+}
+
+func (e *ExecutionPayload) CreateExecutionPayload(db *s.Database) error {
+	return db.Conn.QueryRow(db.Context,
+		`
+		INSERT INTO proposal_execution_payloads(proposal_id, choice, cadence, arguments)
+		VALUES($1, $2, $3, $4)
+		RETURNING created_at
+		`, e.Proposal_id, e.Choice, e.Cadence, string(e.Arguments)).
+		Scan(&e.Created_at)
+}
+
+// PassedWith tells whether the choice won the proposal, as decided by its
+// outcome.
+func (r *ProposalResults) PassedWith(p Proposal, choice string) bool {
+	outcome := r.DecideOutcome(p)
+	return outcome.Winning_choice != nil && *outcome.Winning_choice == choice
+}
@@ -0,0 +1,321 @@
+package models
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	ExportPending   = "pending"
+	ExportRunning   = "running"
+	ExportCompleted = "completed"
+	ExportFailed    = "failed"
+)
+
+// version of the archive layout, checked when importing
+const CommunityArchiveVersion = 1
+
+// exports left running longer than this are assumed lost and picked up again
+const staleExportAfter = time.Hour
+
+// columns of community_exports without the archive itself
+const exportColumns = `id, community_id, requested_by, status, error, size, created_at, started_at, completed_at`
+
+type CommunityExport struct {
+	ID           int        `json:"id"`
+	Community_id int        `json:"communityId"`
+	Requested_by string     `json:"requestedBy"`
+	Status       string     `json:"status"`
+	Error        *string    `json:"error,omitempty"`
+	Size         int        `json:"size"`
+	Created_at   *time.Time `json:"createdAt,omitempty"`
+	Started_at   *time.Time `json:"startedAt,omitempty"`
+	Completed_at *time.Time `json:"completedAt,omitempty"`
+}
+
+type CreateExportPayload struct {
+	Voucher *s.Voucher `json:"voucher,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
+type ExportClaims struct {
+	shared.TokenClaims
+	Export_id    int `json:"exportId"`
+	Community_id int `json:"communityId"`
+}
+
+type ExportWithToken struct {
+	CommunityExport
+	Token string `json:"token"`
+}
+
+// CommunityArchive is the complete content of a community export.
+type CommunityArchive struct {
+	Version     int               `json:"version"`
+	Exported_at time.Time         `json:"exportedAt"`
+	Community   Community         `json:"community"`
+	Members     []CommunityUser   `json:"members"`
+	Lists       []List            `json:"lists"`
+	Proposals   []*Proposal       `json:"proposals"`
+	Votes       []*Vote           `json:"votes"`
+	Results     []ProposalResults `json:"results"`
+}
+
+func (e *CommunityExport) CreateExport(db *s.Database) error {
+	return pgxscan.Get(db.Context, db.Conn, e,
+		`
+		INSERT INTO community_exports(community_id, requested_by)
+		VALUES($1, $2)
+		RETURNING `+exportColumns,
+		e.Community_id, e.Requested_by)
+}
+
+func (e *CommunityExport) GetExportById(db *s.Database) error {
+	return pgxscan.Get(db.Context, db.Conn, e,
+		`SELECT `+exportColumns+` FROM community_exports WHERE id = $1`,
+		e.ID)
+}
+
+func GetExportArchive(db *s.Database, id int) ([]byte, error) {
+	var archive []byte
+	err := db.Conn.QueryRow(db.Context,
+		`SELECT archive FROM community_exports WHERE id = $1 AND status = 'completed'`,
+		id).Scan(&archive)
+	return archive, err
+}
+
+// ClaimPendingExport marks the oldest pending export as running and returns
+// it, or nil when there is nothing to do.
+func ClaimPendingExport(db *s.Database) (*CommunityExport, error) {
+	var e CommunityExport
+	err := pgxscan.Get(db.Context, db.Conn, &e,
+		`
+		UPDATE community_exports
+		SET status = 'running', started_at = (now() at time zone 'utc')
+		WHERE id = (
+			SELECT id FROM community_exports
+			WHERE status = 'pending'
+			OR (status = 'running' AND started_at < (now() at time zone 'utc') - make_interval(secs => $1))
+			ORDER BY id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+exportColumns,
+		staleExportAfter.Seconds())
+
+	if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (e *CommunityExport) CompleteExport(db *s.Database, archive []byte) error {
+	return pgxscan.Get(db.Context, db.Conn, e,
+		`
+		UPDATE community_exports
+		SET status = 'completed', archive = $2, size = $3, error = NULL,
+			completed_at = (now() at time zone 'utc')
+		WHERE id = $1
+		RETURNING `+exportColumns,
+		e.ID, archive, len(archive))
+}
+
+func (e *CommunityExport) FailExport(db *s.Database, cause error) error {
+	return pgxscan.Get(db.Context, db.Conn, e,
+		`
+		UPDATE community_exports
+		SET status = 'failed', error = $2, completed_at = (now() at time zone 'utc')
+		WHERE id = $1
+		RETURNING `+exportColumns,
+		e.ID, cause.Error())
+}
+
+// GetCommunityArchive reads everything stored for the community except the
+// proposal results, which depend on the voting strategies.
+func GetCommunityArchive(db *s.Database, communityId int) (CommunityArchive, error) {
+	archive := CommunityArchive{
+		Version:     CommunityArchiveVersion,
+		Exported_at: time.Now().UTC(),
+		Community:   Community{ID: communityId},
+		Members:     []CommunityUser{},
+		Lists:       []List{},
+		Proposals:   []*Proposal{},
+		Votes:       []*Vote{},
+		Results:     []ProposalResults{},
+	}
+
+	if err := archive.Community.GetCommunity(db); err != nil {
+		return archive, err
+	}
+
+	err := pgxscan.Select(db.Context, db.Conn, &archive.Members,
+		`SELECT * FROM community_users WHERE community_id = $1 ORDER BY addr, user_type`,
+		communityId)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return archive, err
+	}
+
+	lists, err := GetListsForCommunity(db, communityId)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return archive, err
+	}
+	if lists != nil {
+		archive.Lists = lists
+	}
+
+	err = pgxscan.Select(db.Context, db.Conn, &archive.Proposals,
+		fmt.Sprintf(`SELECT *, %s FROM proposals WHERE community_id = $1 ORDER BY id`, computedStatusSQL),
+		communityId)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return archive, err
+	}
+
+	err = pgxscan.Select(db.Context, db.Conn, &archive.Votes,
+		`
+		SELECT v.* FROM votes v
+		JOIN proposals p ON p.id = v.proposal_id
+		WHERE p.community_id = $1
+		ORDER BY v.proposal_id, v.id
+		`, communityId)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return archive, err
+	}
+
+	return archive, nil
+}
+
+// WriteArchive packs the archive into a zip file holding the full JSON
+// document and a CSV file per record type.
+func WriteArchive(archive CommunityArchive) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+
+	f, err := zw.Create("community.json")
+	if err != nil {
+		return nil, err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(archive); err != nil {
+		return nil, err
+	}
+
+	members := [][]string{{"addr", "userType", "createdAt"}}
+	for _, m := range archive.Members {
+		members = append(members, []string{m.Addr, m.User_type, formatTime(m.Created_at)})
+	}
+
+	lists := [][]string{{"listId", "listType", "addr"}}
+	for _, l := range archive.Lists {
+		listType := ""
+		if l.List_type != nil {
+			listType = *l.List_type
+		}
+		for _, addr := range l.Addresses {
+			lists = append(lists, []string{strconv.Itoa(l.ID), listType, addr})
+		}
+	}
+
+	proposals := [][]string{{"id", "name", "status", "strategy", "creatorAddr", "startTime", "endTime", "choices", "cid"}}
+	for _, p := range archive.Proposals {
+		choices := []string{}
+		for _, c := range p.Choices {
+			choices = append(choices, c.Choice_text)
+		}
+		proposals = append(proposals, []string{
+			strconv.Itoa(p.ID),
+			p.Name,
+			stringOrEmpty(p.Computed_status),
+			stringOrEmpty(p.Strategy),
+			p.Creator_addr,
+			p.Start_time.Format(time.RFC3339),
+			p.End_time.Format(time.RFC3339),
+			strings.Join(choices, "|"),
+			stringOrEmpty(p.Cid),
+		})
+	}
+
+	votes := [][]string{{"id", "proposalId", "addr", "choice", "createdAt", "cid", "isCancelled"}}
+	for _, v := range archive.Votes {
+		votes = append(votes, []string{
+			strconv.Itoa(v.ID),
+			strconv.Itoa(v.Proposal_id),
+			v.Addr,
+			v.Choice,
+			v.Created_at.Format(time.RFC3339),
+			stringOrEmpty(v.Cid),
+			strconv.FormatBool(v.IsCancelled),
+		})
+	}
+
+	results := [][]string{{"proposalId", "choice", "votes", "weight"}}
+	for _, r := range archive.Results {
+		choices := make([]string, 0, len(r.Results))
+		for c := range r.Results {
+			choices = append(choices, c)
+		}
+		sort.Strings(choices)
+		for _, c := range choices {
+			results = append(results, []string{
+				strconv.Itoa(r.Proposal_id),
+				c,
+				strconv.Itoa(r.Results[c]),
+				strconv.FormatFloat(r.Results_float[c], 'f', -1, 64),
+			})
+		}
+	}
+
+	files := []struct {
+		name    string
+		records [][]string
+	}{
+		{"members.csv", members},
+		{"lists.csv", lists},
+		{"proposals.csv", proposals},
+		{"votes.csv", votes},
+		{"results.csv", results},
+	}
+	for _, file := range files {
+		f, err := zw.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		if err := csv.NewWriter(f).WriteAll(file.records); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,190 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+// Capabilities that can be turned on per community before they are
+// released to everyone.
+const (
+	FlagComments       = "comments"
+	FlagShieldedVoting = "shielded-voting"
+	FlagDelegation     = "delegation"
+	FlagOnchainVoting  = "onchain-voting"
+)
+
+var ErrFeatureDisabled = errors.New("This feature is not enabled for the community.")
+
+type FeatureFlag struct {
+	Name            string    `json:"name"`
+	Description     *string   `json:"description,omitempty"`
+	Rollout_percent int       `json:"rolloutPercent"`
+	Updated_at      time.Time `json:"updatedAt"`
+
+	// communities the flag is explicitly turned on or off for
+	Communities map[int]bool `json:"communities" db:"-"`
+}
+
+type FeatureRolloutPayload struct {
+	s.TimestampSignaturePayload
+	Rollout_percent *int `json:"rolloutPercent" validate:"required,min=0,max=100"`
+}
+
+type CommunityFeaturePayload struct {
+	s.TimestampSignaturePayload
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+// RolloutBucket places a community in one of 100 buckets for the flag. A
+// flag rolled out to n percent is on for the communities in buckets below
+// n, so raising the percentage only ever adds communities.
+func RolloutBucket(flag string, communityId int) int {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s:%d", flag, communityId)
+	return int(h.Sum32() % 100)
+}
+
+// Enabled tells whether the flag is on for the community, its own setting
+// winning over the rollout.
+func (f *FeatureFlag) Enabled(communityId int) bool {
+	if enabled, ok := f.Communities[communityId]; ok {
+		return enabled
+	}
+	return RolloutBucket(f.Name, communityId) < f.Rollout_percent
+}
+
+func GetFeatureFlags(db *s.Database) ([]*FeatureFlag, error) {
+	var flags []*FeatureFlag
+	err := pgxscan.Select(db.Context, db.Conn, &flags,
+		`SELECT name, description, rollout_percent, updated_at FROM feature_flags ORDER BY name`)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+
+	var overrides []struct {
+		Community_id int
+		Flag         string
+		Enabled      bool
+	}
+	err = pgxscan.Select(db.Context, db.Conn, &overrides,
+		`SELECT community_id, flag, enabled FROM community_feature_flags`)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+
+	byName := make(map[string]*FeatureFlag, len(flags))
+	for _, f := range flags {
+		f.Communities = map[int]bool{}
+		byName[f.Name] = f
+	}
+	for _, o := range overrides {
+		if f := byName[o.Flag]; f != nil {
+			f.Communities[o.Community_id] = o.Enabled
+		}
+	}
+	return flags, nil
+}
+
+func GetFeatureFlag(db *s.Database, name string) (*FeatureFlag, error) {
+	var f FeatureFlag
+	err := pgxscan.Get(db.Context, db.Conn, &f,
+		`SELECT name, description, rollout_percent, updated_at FROM feature_flags WHERE name = $1`, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var overrides []struct {
+		Community_id int
+		Enabled      bool
+	}
+	err = pgxscan.Select(db.Context, db.Conn, &overrides,
+		`SELECT community_id, enabled FROM community_feature_flags WHERE flag = $1`, name)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+
+	f.Communities = make(map[int]bool, len(overrides))
+	for _, o := range overrides {
+		f.Communities[o.Community_id] = o.Enabled
+	}
+	return &f, nil
+}
+
+// IsFeatureEnabled tells whether the flag is on for the community. Unknown
+// flags are off.
+func IsFeatureEnabled(db *s.Database, name string, communityId int) (bool, error) {
+	var rollout int
+	var enabled *bool
+	err := db.Conn.QueryRow(db.Context, `
+		SELECT f.rollout_percent, cf.enabled
+		FROM feature_flags f
+		LEFT JOIN community_feature_flags cf ON cf.flag = f.name AND cf.community_id = $2
+		WHERE f.name = $1
+	`, name, communityId).Scan(&rollout, &enabled)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if enabled != nil {
+		return *enabled, nil
+	}
+	return RolloutBucket(name, communityId) < rollout, nil
+}
+
+// GetCommunityFeatures returns every flag and whether it is on for the
+// community.
+func GetCommunityFeatures(db *s.Database, communityId int) (map[string]bool, error) {
+	flags, err := GetFeatureFlags(db)
+	if err != nil {
+		return nil, err
+	}
+
+	features := make(map[string]bool, len(flags))
+	for _, f := range flags {
+		features[f.Name] = f.Enabled(communityId)
+	}
+	return features, nil
+}
+
+func (f *FeatureFlag) SetRollout(db *s.Database, percent int) error {
+	return db.Conn.QueryRow(db.Context, `
+		UPDATE feature_flags SET rollout_percent = $2, updated_at = now()
+		WHERE name = $1
+		RETURNING rollout_percent, updated_at
+	`, f.Name, percent).Scan(&f.Rollout_percent, &f.Updated_at)
+}
+
+func (f *FeatureFlag) SetForCommunity(db *s.Database, communityId int, enabled bool) error {
+	_, err := db.Conn.Exec(db.Context, `
+		INSERT INTO community_feature_flags(community_id, flag, enabled)
+		VALUES($1, $2, $3)
+		ON CONFLICT (community_id, flag) DO UPDATE SET enabled = EXCLUDED.enabled, updated_at = now()
+	`, communityId, f.Name, enabled)
+	if err != nil {
+		return err
+	}
+
+	f.Communities[communityId] = enabled
+	return nil
+}
+
+// ClearForCommunity returns the community to the flag's rollout.
+func (f *FeatureFlag) ClearForCommunity(db *s.Database, communityId int) error {
+	_, err := db.Conn.Exec(db.Context,
+		`DELETE FROM community_feature_flags WHERE community_id = $1 AND flag = $2`, communityId, f.Name)
+	if err != nil {
+		return err
+	}
+
+	delete(f.Communities, communityId)
+	return nil
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+type Follow struct {
+	ID            int        `json:"id"`
+	Follower_addr string     `json:"followerAddr"`
+	Community_id  *int       `json:"communityId,omitempty"`
+	Followed_addr *string    `json:"followedAddr,omitempty"`
+	Created_at    *time.Time `json:"createdAt,omitempty"`
+}
+
+type FollowPayload struct {
+	Community_id  *int            `json:"communityId,omitempty"`
+	Followed_addr *string         `json:"followedAddr,omitempty"`
+	Voucher       *shared.Voucher `json:"voucher,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
+// Validate checks that exactly one of a community or an address is followed.
+func (f *Follow) Validate() error {
+	if (f.Community_id == nil) == (f.Followed_addr == nil) {
+		return errors.New("follow either a community or an address")
+	}
+	if f.Followed_addr != nil && *f.Followed_addr == f.Follower_addr {
+		return errors.New("an address cannot follow itself")
+	}
+	return nil
+}
+
+func GetFollowsForAddress(db *s.Database, addr string, pageParams shared.PageParams) ([]*Follow, int, error) {
+	var follows []*Follow
+	err := pgxscan.Select(db.Context, db.Conn, &follows,
+		`
+		SELECT * FROM follows
+		WHERE follower_addr = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+		`, addr, pageParams.Count, pageParams.Start)
+
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, 0, err
+	} else if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return []*Follow{}, 0, nil
+	}
+
+	var totalRecords int
+	countSql := `SELECT COUNT(*) FROM follows WHERE follower_addr = $1`
+	_ = db.Conn.QueryRow(db.Context, countSql, addr).Scan(&totalRecords)
+
+	return follows, totalRecords, nil
+}
+
+func (f *Follow) CreateFollow(db *s.Database) error {
+	err := db.Conn.QueryRow(db.Context,
+		`
+		INSERT INTO follows(follower_addr, community_id, followed_addr)
+		VALUES($1, $2, $3)
+		ON CONFLICT DO NOTHING
+		RETURNING id, created_at
+		`, f.Follower_addr, f.Community_id, f.Followed_addr).
+		Scan(&f.ID, &f.Created_at)
+
+	// already following
+	if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return nil
+	}
+	return err
+}
+
+func (f *Follow) RemoveFollow(db *s.Database) error {
+	_, err := db.Conn.Exec(db.Context,
+		`
+		DELETE FROM follows
+		WHERE follower_addr = $1
+			AND community_id IS NOT DISTINCT FROM $2
+			AND followed_addr IS NOT DISTINCT FROM $3
+		`, f.Follower_addr, f.Community_id, f.Followed_addr)
+	return err
+}
+
+// GetFeedForAddress merges the activity of every community and address
+// followed by addr.
+func GetFeedForAddress(db *s.Database, addr string, pageParams shared.PageParams) ([]*CommunityEvent, int, error) {
+	const followedEventsSql = `
+		FROM community_events e
+		WHERE e.community_id IN (
+				SELECT community_id FROM follows WHERE follower_addr = $1 AND community_id IS NOT NULL
+			)
+			OR e.actor_addr IN (
+				SELECT followed_addr FROM follows WHERE follower_addr = $1 AND followed_addr IS NOT NULL
+			)
+	`
+
+	var events []*CommunityEvent
+	err := pgxscan.Select(db.Context, db.Conn, &events,
+		`SELECT e.* `+followedEventsSql+` ORDER BY e.created_at DESC, e.id DESC LIMIT $2 OFFSET $3`,
+		addr, pageParams.Count, pageParams.Start)
+
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, 0, err
+	} else if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return []*CommunityEvent{}, 0, nil
+	}
+
+	var totalRecords int
+	_ = db.Conn.QueryRow(db.Context, `SELECT COUNT(*) `+followedEventsSql, addr).Scan(&totalRecords)
+
+	return events, totalRecords, nil
+}
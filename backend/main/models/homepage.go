@@ -0,0 +1,155 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+// Kinds of homepage sections, each filled by its own query.
+const (
+	SectionFeatured = "featured"
+	SectionTrending = "trending"
+	SectionNew      = "new"
+	SectionCategory = "category"
+)
+
+type HomepageSection struct {
+	ID            int       `json:"id"`
+	Title         string    `json:"title"`
+	Kind          string    `json:"kind"`
+	Category      *string   `json:"category,omitempty"`
+	Display_order int       `json:"displayOrder"`
+	Size          int       `json:"size"`
+	Tenant_id     int       `json:"tenantId"`
+	Created_at    time.Time `json:"createdAt"`
+
+	Communities []*Community `json:"communities" db:"-"`
+}
+
+// HomepageSectionPayload creates a section, or changes the fields it sets.
+type HomepageSectionPayload struct {
+	s.TimestampSignaturePayload
+	Title         *string `json:"title,omitempty"         validate:"omitempty,min=1,max=128"`
+	Kind          *string `json:"kind,omitempty"          validate:"omitempty,oneof=featured trending new category"`
+	Category      *string `json:"category,omitempty"      validate:"omitempty,max=256"`
+	Display_order *int    `json:"displayOrder,omitempty"`
+	Size          *int    `json:"size,omitempty"          validate:"omitempty,min=1,max=50"`
+}
+
+// HomepageOrderPayload lists featured communities in the order the
+// homepage should show them.
+type HomepageOrderPayload struct {
+	s.TimestampSignaturePayload
+	Community_ids []int `json:"communityIds" validate:"required,min=1,max=100"`
+}
+
+const homepageCommunityOrder = `ORDER BY homepage_pinned DESC, homepage_order ASC NULLS LAST, id ASC`
+
+func GetHomepageSections(db *s.Database, tenantId int) ([]*HomepageSection, error) {
+	var sections []*HomepageSection
+	err := pgxscan.Select(db.Context, db.Conn, &sections,
+		`SELECT * FROM homepage_sections WHERE tenant_id = $1 ORDER BY display_order, id`, tenantId)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	} else if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return []*HomepageSection{}, nil
+	}
+	return sections, nil
+}
+
+func (h *HomepageSection) GetHomepageSection(db *s.Database) error {
+	return pgxscan.Get(db.Context, db.Conn, h, `SELECT * FROM homepage_sections WHERE id = $1`, h.ID)
+}
+
+// LoadCommunities fills the section with up to Size public, active
+// communities of its tenant.
+func (h *HomepageSection) LoadCommunities(db *s.Database) error {
+	const active = `is_archived = 'false' AND is_private = 'false' AND tenant_id = $2`
+
+	var sql string
+	args := []interface{}{h.Size, h.Tenant_id}
+	switch h.Kind {
+	case SectionFeatured:
+		sql = `SELECT * FROM communities
+			WHERE ` + active + ` AND (is_featured = 'true' OR homepage_pinned = 'true')
+			` + homepageCommunityOrder + ` LIMIT $1`
+	case SectionTrending:
+		sql = `SELECT c.*, t.score AS trending_score FROM communities c
+			JOIN community_trending_scores t ON t.community_id = c.id
+			WHERE ` + active + `
+			ORDER BY t.score DESC, c.id ASC LIMIT $1`
+	case SectionNew:
+		sql = `SELECT * FROM communities WHERE ` + active + ` ORDER BY created_at DESC, id DESC LIMIT $1`
+	case SectionCategory:
+		sql = `SELECT * FROM communities
+			WHERE ` + active + ` AND category = $3
+			` + homepageCommunityOrder + ` LIMIT $1`
+		args = append(args, h.Category)
+	default:
+		return fmt.Errorf("unknown homepage section kind %q", h.Kind)
+	}
+
+	h.Communities = []*Community{}
+	err := pgxscan.Select(db.Context, db.Conn, &h.Communities, sql, args...)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return err
+	}
+	return nil
+}
+
+func (h *HomepageSection) CreateHomepageSection(db *s.Database) error {
+	if h.Tenant_id == 0 {
+		h.Tenant_id = DefaultTenantID
+	}
+	return db.Conn.QueryRow(db.Context, `
+		INSERT INTO homepage_sections(title, kind, category, display_order, size, tenant_id)
+		VALUES($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, h.Title, h.Kind, h.Category, h.Display_order, h.Size, h.Tenant_id).Scan(&h.ID, &h.Created_at)
+}
+
+func (h *HomepageSection) UpdateHomepageSection(db *s.Database) error {
+	_, err := db.Conn.Exec(db.Context, `
+		UPDATE homepage_sections
+		SET title = $2, kind = $3, category = $4, display_order = $5, size = $6
+		WHERE id = $1
+	`, h.ID, h.Title, h.Kind, h.Category, h.Display_order, h.Size)
+	return err
+}
+
+func (h *HomepageSection) DeleteHomepageSection(db *s.Database) error {
+	_, err := db.Conn.Exec(db.Context, `DELETE FROM homepage_sections WHERE id = $1`, h.ID)
+	return err
+}
+
+func (c *Community) SetHomepagePinned(db *s.Database, pinned bool) error {
+	return db.Conn.QueryRow(db.Context, `
+		UPDATE communities SET homepage_pinned = $2, version = version + 1, updated_at = (now() at time zone 'utc')
+		WHERE id = $1
+		RETURNING homepage_pinned, version
+	`, c.ID, pinned).Scan(&c.Homepage_pinned, &c.Version)
+}
+
+// SetHomepageOrder orders the communities of the tenant as listed, ahead
+// of those with no order. Communities of other tenants are left alone.
+func SetHomepageOrder(db *s.Database, tenantId int, communityIds []int) error {
+	return db.WithTx(func(tx *s.Database) error {
+		_, err := tx.Conn.Exec(tx.Context,
+			`UPDATE communities SET homepage_order = NULL, updated_at = (now() at time zone 'utc') WHERE homepage_order IS NOT NULL AND tenant_id = $1`, tenantId)
+		if err != nil {
+			return err
+		}
+		for i, id := range communityIds {
+			_, err := tx.Conn.Exec(tx.Context,
+				`UPDATE communities SET homepage_order = $2, updated_at = (now() at time zone 'utc') WHERE id = $1 AND tenant_id = $3`, id, i, tenantId)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
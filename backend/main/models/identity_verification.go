@@ -0,0 +1,158 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	VerificationVerified   = "verified"
+	VerificationUnverified = "unverified"
+	VerificationRevoked    = "revoked"
+)
+
+// VerificationTTL is how long a check with a provider holds, after which
+// the address has to verify again. Attestations last until revoked unless
+// they say otherwise.
+const VerificationTTL = 90 * 24 * time.Hour
+
+var ErrIdentityInUse = errors.New("This identity already verifies another address.")
+
+// AddressVerification is the latest check of an address with an identity
+// provider. External_id is the account the provider knows, the address
+// itself for BrightID and an Ethereum address for Gitcoin Passport.
+type AddressVerification struct {
+	Addr        string     `json:"addr"`
+	Provider    string     `json:"provider"`
+	External_id string     `json:"externalId"`
+	Status      string     `json:"status"`
+	Score       *float64   `json:"score,omitempty"`
+	Verified_by *string    `json:"verifiedBy,omitempty"`
+	Verified_at *time.Time `json:"verifiedAt,omitempty"`
+	Expires_at  *time.Time `json:"expiresAt,omitempty"`
+	Updated_at  *time.Time `json:"updatedAt,omitempty"`
+}
+
+type VerifyAddressPayload struct {
+	Provider    string     `json:"provider"             validate:"required"`
+	External_id *string    `json:"externalId,omitempty" validate:"omitempty,eth_addr"`
+	Voucher     *s.Voucher `json:"voucher,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
+type AttestAddressPayload struct {
+	External_id *string    `json:"externalId,omitempty" validate:"omitempty,max=128"`
+	Expires_at  *time.Time `json:"expiresAt,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
+// verifiedProvidersSQL lists the providers the address in column %s is
+// currently verified with.
+const verifiedProvidersSQL = `ARRAY(
+	SELECT provider FROM address_verifications av
+	WHERE av.addr = %s AND av.status = 'verified'
+	AND (av.expires_at IS NULL OR av.expires_at > (now() at time zone 'utc'))
+	ORDER BY provider)`
+
+func verifiedProviders(addrColumn string) string {
+	return fmt.Sprintf(verifiedProvidersSQL, addrColumn)
+}
+
+func GetVerificationsForAddress(db *s.Database, addr string) ([]*AddressVerification, error) {
+	verifications := []*AddressVerification{}
+	err := pgxscan.Select(db.Context, db.Conn, &verifications,
+		`SELECT * FROM address_verifications WHERE addr = $1 ORDER BY provider`, addr)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	return verifications, nil
+}
+
+// GetVerifiedProviders lists the providers the address is verified with
+// and whose check hasn't expired.
+func GetVerifiedProviders(db *s.Database, addr string) ([]string, error) {
+	var providers []string
+	err := db.Conn.QueryRow(db.Context, `SELECT `+verifiedProviders("$1"), addr).Scan(&providers)
+	return providers, err
+}
+
+// Upsert records the check, replacing the previous one with the same
+// provider. It returns ErrIdentityInUse when another address was verified
+// with the same external account.
+func (v *AddressVerification) Upsert(db *s.Database) error {
+	err := db.Conn.QueryRow(db.Context, `
+		INSERT INTO address_verifications(addr, provider, external_id, status, score,
+			verified_by, verified_at, expires_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (addr, provider) DO UPDATE
+		SET external_id = EXCLUDED.external_id, status = EXCLUDED.status, score = EXCLUDED.score,
+			verified_by = EXCLUDED.verified_by, verified_at = EXCLUDED.verified_at,
+			expires_at = EXCLUDED.expires_at, updated_at = (now() at time zone 'utc')
+		RETURNING updated_at
+	`, v.Addr, v.Provider, v.External_id, v.Status, v.Score,
+		v.Verified_by, v.Verified_at, v.Expires_at).Scan(&v.Updated_at)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrIdentityInUse
+	}
+	return err
+}
+
+// RevokeVerification marks the check of the address with the provider as
+// revoked, returning pgx.ErrNoRows when there is none.
+func RevokeVerification(db *s.Database, addr, provider string) (AddressVerification, error) {
+	var v AddressVerification
+	err := pgxscan.Get(db.Context, db.Conn, &v, `
+		UPDATE address_verifications
+		SET status = 'revoked', updated_at = (now() at time zone 'utc')
+		WHERE addr = $1 AND provider = $2
+		RETURNING *
+	`, addr, provider)
+	return v, err
+}
+
+// ValidateIdentityProviders checks every provider is one CAST knows.
+func ValidateIdentityProviders(providers []string) error {
+	for _, p := range providers {
+		if !EnsureValidIdentityProvider(p) {
+			return fmt.Errorf("Unknown identity provider %q, expected one of %s.",
+				p, strings.Join(s.IDENTITY_PROVIDERS, ", "))
+		}
+	}
+	return nil
+}
+
+func EnsureValidIdentityProvider(provider string) bool {
+	for _, p := range s.IDENTITY_PROVIDERS {
+		if provider == p {
+			return true
+		}
+	}
+	return false
+}
+
+// MeetsVerificationRequirement reports whether the verified providers
+// include one of the required ones, which nothing required always does.
+func MeetsVerificationRequirement(verified, required []string) bool {
+	if len(required) == 0 {
+		return true
+	}
+	for _, r := range required {
+		for _, v := range verified {
+			if r == v {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,236 @@
+package models
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/ipfs/go-cid"
+)
+
+type ImportCommunityPayload struct {
+	Voucher *s.Voucher `json:"voucher,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
+// ReadArchive decodes an exported archive, either the zip file produced by a
+// community export or its community.json document.
+func ReadArchive(data []byte) (CommunityArchive, error) {
+	var archive CommunityArchive
+
+	document := data
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return archive, errors.New("Archive must be a zip file or a JSON document.")
+		}
+		document = nil
+		for _, f := range zr.File {
+			if f.Name != "community.json" {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return archive, err
+			}
+			document, err = io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				return archive, err
+			}
+		}
+		if document == nil {
+			return archive, errors.New("Archive does not contain community.json.")
+		}
+	}
+
+	if err := json.Unmarshal(document, &archive); err != nil {
+		return archive, errors.New("Invalid archive document.")
+	}
+	if archive.Version != CommunityArchiveVersion {
+		return archive, fmt.Errorf("Unsupported archive version %d.", archive.Version)
+	}
+
+	return archive, nil
+}
+
+// ValidateArchiveCids ensures every IPFS reference in the archive is a well
+// formed CID.
+func ValidateArchiveCids(archive CommunityArchive) error {
+	check := func(kind string, id int, c *string) error {
+		if c == nil || *c == "" {
+			return nil
+		}
+		if _, err := cid.Decode(*c); err != nil {
+			return fmt.Errorf("Invalid cid for %s %d.", kind, id)
+		}
+		return nil
+	}
+
+	if err := check("community", archive.Community.ID, archive.Community.Cid); err != nil {
+		return err
+	}
+	for _, l := range archive.Lists {
+		if err := check("list", l.ID, l.Cid); err != nil {
+			return err
+		}
+	}
+	for _, p := range archive.Proposals {
+		if err := check("proposal", p.ID, p.Cid); err != nil {
+			return err
+		}
+	}
+	for _, v := range archive.Votes {
+		if err := check("vote", v.ID, v.Cid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateArchivedVoteMessage checks the signed vote message refers to the
+// archived proposal and choice. Unlike ValidateVoteMessage, the timestamp is
+// not required to be recent.
+func ValidateArchivedVoteMessage(v *Vote, p *Proposal) error {
+	vars := strings.Split(v.Message, ":")
+	if len(vars) != 3 || vars[0] != strconv.Itoa(p.ID) {
+		return fmt.Errorf("Vote %d message does not match proposal %d.", v.ID, p.ID)
+	}
+	choice, err := hex.DecodeString(vars[1])
+	if err != nil || string(choice) != v.Choice {
+		return fmt.Errorf("Vote %d message does not match its choice.", v.ID)
+	}
+	return nil
+}
+
+// ImportCommunityArchive re-creates the archived community. Proposals are
+// closed and keep their archived votes and results as read-only history. The
+// import runs in a single transaction, so a failure leaves nothing behind.
+func ImportCommunityArchive(db *s.Database, archive CommunityArchive) (Community, error) {
+	c := archive.Community
+	c.ID = 0
+	c.Parent_id = nil
+	c.Is_archived = false
+	c.Archived_at = nil
+	c.Delete_after = nil
+
+	err := db.WithTx(func(tx *s.Database) error {
+		if err := c.CreateCommunity(tx); err != nil {
+			return err
+		}
+		return importCommunityRecords(tx, c.ID, archive)
+	})
+	if err != nil {
+		return Community{}, err
+	}
+
+	if err := c.GetCommunity(db); err != nil {
+		return Community{}, err
+	}
+	return c, nil
+}
+
+func importCommunityRecords(tx *s.Database, communityId int, archive CommunityArchive) error {
+	if _, err := tx.Conn.Exec(tx.Context,
+		`UPDATE communities SET imported_at = (now() at time zone 'utc'), updated_at = (now() at time zone 'utc') WHERE id = $1`,
+		communityId); err != nil {
+		return err
+	}
+
+	for _, m := range archive.Members {
+		if _, err := tx.Conn.Exec(tx.Context,
+			`
+			INSERT INTO community_users(community_id, addr, user_type, created_at)
+			VALUES($1, $2, $3, COALESCE($4, (now() at time zone 'utc')))
+			`, communityId, m.Addr, m.User_type, m.Created_at); err != nil {
+			return err
+		}
+	}
+
+	for _, l := range archive.Lists {
+		if _, err := tx.Conn.Exec(tx.Context,
+			`
+			WITH l AS (
+				INSERT INTO lists(community_id, addresses, list_type, cid, rule, name)
+				VALUES($1, $2, $3, $4, $5, $6)
+				RETURNING id, version, addresses, cid, created_at
+			)
+			INSERT INTO list_versions(list_id, version, addresses, cid, created_at)
+			SELECT id, version, COALESCE(addresses, '{}'), cid, created_at FROM l
+			`,
+			communityId, l.Addresses, l.List_type, l.Cid, l.Rule, l.Name); err != nil {
+			return err
+		}
+	}
+
+	proposalIds := map[int]int{}
+	for _, p := range archive.Proposals {
+		status := "closed"
+		if p.Status != nil && *p.Status != ProposalPublished && *p.Status != "closed" {
+			status = *p.Status
+		}
+
+		p.NormalizeTimes()
+
+		var id int
+		err := tx.Conn.QueryRow(tx.Context,
+			`
+			INSERT INTO proposals(community_id, name, choices, strategy, min_balance, max_weight,
+				creator_addr, start_time, end_time, status, body, block_height, cid,
+				composite_signatures, voucher, tags, result, achievements_done, imported_from_id)
+			VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15,
+				COALESCE($16::text[], '{}'), $17, 'true', $18)
+			RETURNING id
+			`,
+			communityId, p.Name, p.Choices, p.Strategy, p.Min_balance, p.Max_weight,
+			p.Creator_addr, p.Start_time, p.End_time, status, p.Body, p.Block_height, p.Cid,
+			p.Composite_signatures, p.Voucher, p.Tags, p.Result, p.ID,
+		).Scan(&id)
+		if err != nil {
+			return err
+		}
+		proposalIds[p.ID] = id
+	}
+
+	for _, v := range archive.Votes {
+		proposalId, ok := proposalIds[v.Proposal_id]
+		if !ok {
+			return fmt.Errorf("Vote %d references unknown proposal %d.", v.ID, v.Proposal_id)
+		}
+		if _, err := tx.Conn.Exec(tx.Context,
+			`
+			INSERT INTO votes(proposal_id, addr, choice, composite_signatures, cid, message,
+				voucher, is_cancelled, is_early, is_winning, created_at, source, tx_id, event_index)
+			VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, COALESCE(NULLIF($12, ''), 'offchain'), $13, $14)
+			`,
+			proposalId, v.Addr, v.Choice, v.Composite_signatures, v.Cid, v.Message,
+			v.Voucher, v.IsCancelled, v.IsEarly, v.IsWinning, v.Created_at, v.Source, v.Tx_id, v.Event_index); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range archive.Results {
+		proposalId, ok := proposalIds[r.Proposal_id]
+		if !ok {
+			return fmt.Errorf("Results reference unknown proposal %d.", r.Proposal_id)
+		}
+		if _, err := tx.Conn.Exec(tx.Context,
+			`
+			INSERT INTO proposal_results(proposal_id, results, results_float, cid, updated_at)
+			VALUES($1, $2, $3, $4, $5)
+			`,
+			proposalId, r.Results, r.Results_float, r.Cid, r.Updated_at); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package models
+
+import (
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+type Invite struct {
+	ID           int        `json:"id"`
+	Community_id int        `json:"communityId"`
+	User_type    string     `json:"userType"`
+	Addr         *string    `json:"addr,omitempty"`
+	Max_uses     int        `json:"maxUses"`
+	Uses         int        `json:"uses"`
+	Created_by   string     `json:"createdBy"`
+	Expires_at   time.Time  `json:"expiresAt"`
+	Revoked_at   *time.Time `json:"revokedAt,omitempty"`
+	Created_at   *time.Time `json:"createdAt,omitempty"`
+}
+
+// InviteClaims are embedded in the signed invite token handed out as a link.
+type InviteClaims struct {
+	shared.TokenClaims
+	Invite_id    int    `json:"inviteId"`
+	Community_id int    `json:"communityId"`
+	User_type    string `json:"userType"`
+}
+
+type InviteWithToken struct {
+	Invite
+	Token string `json:"token"`
+}
+
+type CreateInvitePayload struct {
+	User_type        string          `json:"userType"          validate:"required,oneof=member author admin"`
+	Addr             *string         `json:"addr,omitempty"`
+	Max_uses         *int            `json:"maxUses,omitempty" validate:"omitempty,gte=0"`
+	Expires_in_hours int             `json:"expiresInHours"    validate:"gte=0,lte=720"`
+	Voucher          *shared.Voucher `json:"voucher,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
+type RedeemInvitePayload struct {
+	Token   string          `json:"token"   validate:"required"`
+	Addr    string          `json:"addr"    validate:"required"`
+	Voucher *shared.Voucher `json:"voucher,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
+type RevokeInvitePayload struct {
+	Voucher *shared.Voucher `json:"voucher,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
+func GetInvitesForCommunity(
+	db *s.Database,
+	communityId int,
+	pageParams shared.PageParams,
+) ([]*Invite, int, error) {
+	var invites []*Invite
+	err := pgxscan.Select(db.Context, db.Conn, &invites,
+		`
+		SELECT * FROM community_invites
+		WHERE community_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+		`, communityId, pageParams.Count, pageParams.Start)
+
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, 0, err
+	} else if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return []*Invite{}, 0, nil
+	}
+
+	var totalRecords int
+	countSql := `SELECT COUNT(*) FROM community_invites WHERE community_id = $1`
+	_ = db.Conn.QueryRow(db.Context, countSql, communityId).Scan(&totalRecords)
+
+	return invites, totalRecords, nil
+}
+
+func (i *Invite) GetInviteById(db *s.Database) error {
+	return pgxscan.Get(db.Context, db.Conn, i,
+		`SELECT * FROM community_invites WHERE id = $1`,
+		i.ID)
+}
+
+func (i *Invite) CreateInvite(db *s.Database) error {
+	return db.Conn.QueryRow(db.Context,
+		`
+		INSERT INTO community_invites(community_id, user_type, addr, max_uses, created_by, expires_at)
+		VALUES($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+		`, i.Community_id, i.User_type, i.Addr, i.Max_uses, i.Created_by, i.Expires_at).
+		Scan(&i.ID, &i.Created_at)
+}
+
+func (i *Invite) RevokeInvite(db *s.Database) error {
+	return db.Conn.QueryRow(db.Context,
+		`
+		UPDATE community_invites SET revoked_at = (now() at time zone 'utc')
+		WHERE id = $1 AND revoked_at IS NULL
+		RETURNING revoked_at
+		`, i.ID).
+		Scan(&i.Revoked_at)
+}
+
+// ConsumeInvite atomically increments the use count of a live invite.
+// A pgx.ErrNoRows error means the invite is revoked, expired or used up.
+func (i *Invite) ConsumeInvite(db *s.Database) error {
+	return db.Conn.QueryRow(db.Context,
+		`
+		UPDATE community_invites SET uses = uses + 1
+		WHERE id = $1
+			AND revoked_at IS NULL
+			AND expires_at > (now() at time zone 'utc')
+			AND (max_uses = 0 OR uses < max_uses)
+		RETURNING uses
+		`, i.ID).
+		Scan(&i.Uses)
+}
+
+func (i *Invite) IsUsable() bool {
+	if i.Revoked_at != nil || time.Now().UTC().After(i.Expires_at) {
+		return false
+	}
+	return i.Max_uses == 0 || i.Uses < i.Max_uses
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+type JoinRequest struct {
+	ID                   int                     `json:"id"`
+	Community_id         int                     `json:"communityId"`
+	Addr                 string                  `json:"addr"                validate:"required"`
+	Message              *string                 `json:"message,omitempty"`
+	Status               string                  `json:"status"`
+	Composite_signatures *[]s.CompositeSignature `json:"compositeSignatures,omitempty"`
+	Reviewer_addr        *string                 `json:"reviewerAddr,omitempty"`
+	Reason               *string                 `json:"reason,omitempty"`
+	Created_at           *time.Time              `json:"createdAt,omitempty"`
+	Reviewed_at          *time.Time              `json:"reviewedAt,omitempty"`
+}
+
+type JoinRequestPayload struct {
+	Addr    string          `json:"addr"    validate:"required"`
+	Message *string         `json:"message,omitempty"`
+	Voucher *shared.Voucher `json:"voucher,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
+type ReviewJoinRequestPayload struct {
+	Reason  *string         `json:"reason,omitempty"`
+	Voucher *shared.Voucher `json:"voucher,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
+const (
+	JoinRequestPending  = "pending"
+	JoinRequestApproved = "approved"
+	JoinRequestDenied   = "denied"
+)
+
+func GetJoinRequestsForCommunity(
+	db *s.Database,
+	communityId int,
+	status string,
+	pageParams shared.PageParams,
+) ([]*JoinRequest, int, error) {
+	var requests []*JoinRequest
+	err := pgxscan.Select(db.Context, db.Conn, &requests,
+		`
+		SELECT * FROM community_join_requests
+		WHERE community_id = $1 AND ($2 = '' OR status::text = $2)
+		ORDER BY created_at ASC
+		LIMIT $3 OFFSET $4
+		`, communityId, status, pageParams.Count, pageParams.Start)
+
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, 0, err
+	} else if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return []*JoinRequest{}, 0, nil
+	}
+
+	var totalRecords int
+	countSql := `SELECT COUNT(*) FROM community_join_requests WHERE community_id = $1 AND ($2 = '' OR status::text = $2)`
+	_ = db.Conn.QueryRow(db.Context, countSql, communityId, status).Scan(&totalRecords)
+
+	return requests, totalRecords, nil
+}
+
+func (jr *JoinRequest) GetJoinRequestById(db *s.Database) error {
+	return pgxscan.Get(db.Context, db.Conn, jr,
+		`SELECT * FROM community_join_requests WHERE id = $1`,
+		jr.ID)
+}
+
+func (jr *JoinRequest) GetPendingJoinRequest(db *s.Database) error {
+	return pgxscan.Get(db.Context, db.Conn, jr,
+		`
+		SELECT * FROM community_join_requests
+		WHERE community_id = $1 AND addr = $2 AND status = 'pending'
+		`, jr.Community_id, jr.Addr)
+}
+
+func (jr *JoinRequest) CreateJoinRequest(db *s.Database) error {
+	return db.Conn.QueryRow(db.Context,
+		`
+		INSERT INTO community_join_requests(community_id, addr, message, composite_signatures)
+		VALUES($1, $2, $3, $4)
+		RETURNING id, status, created_at
+		`, jr.Community_id, jr.Addr, jr.Message, jr.Composite_signatures).
+		Scan(&jr.ID, &jr.Status, &jr.Created_at)
+}
+
+func (jr *JoinRequest) ReviewJoinRequest(db *s.Database, status, reviewer string, reason *string) error {
+	return db.Conn.QueryRow(db.Context,
+		`
+		UPDATE community_join_requests
+		SET status = $1, reviewer_addr = $2, reason = $3, reviewed_at = (now() at time zone 'utc')
+		WHERE id = $4 AND status = 'pending'
+		RETURNING status, reviewer_addr, reason, reviewed_at
+		`, status, reviewer, reason, jr.ID).
+		Scan(&jr.Status, &jr.Reviewer_addr, &jr.Reason, &jr.Reviewed_at)
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	LeaderboardWindowAll   = "all"
+	LeaderboardMetricScore = "score"
+)
+
+var LEADERBOARD_WINDOWS = []string{"30d", "90d", LeaderboardWindowAll}
+
+// LEADERBOARD_METRICS maps each metric to its leaderboard_stats column.
+var LEADERBOARD_METRICS = map[string]string{
+	LeaderboardMetricScore: "score",
+	"votes":                "votes_cast",
+	"proposals":            "proposals_authored",
+	"points":               "achievement_points",
+}
+
+func IsValidLeaderboardWindow(window string) bool {
+	for _, w := range LEADERBOARD_WINDOWS {
+		if w == window {
+			return true
+		}
+	}
+	return false
+}
+
+func IsValidLeaderboardMetric(metric string) bool {
+	_, ok := LEADERBOARD_METRICS[metric]
+	return ok
+}
+
+// GetCommunityLeaderboardStats ranks the community by a metric over a time
+// window, read from the leaderboard_stats aggregates. As with the all-time
+// leaderboard, pageParams.Start is a page index.
+func GetCommunityLeaderboardStats(
+	db *s.Database,
+	communityId int,
+	addr string,
+	window string,
+	metric string,
+	pageParams shared.PageParams,
+) (LeaderboardPayload, int, error) {
+	var payload = LeaderboardPayload{Users: []LeaderboardUser{}}
+
+	ranked := fmt.Sprintf(`
+		SELECT
+			addr,
+			%[1]s AS score,
+			ROW_NUMBER() OVER (ORDER BY %[1]s DESC, addr ASC) AS index,
+			refreshed_at
+		FROM leaderboard_stats
+		WHERE community_id = $1 AND time_window = $2 AND %[1]s > 0
+	`, LEADERBOARD_METRICS[metric])
+
+	var rows []struct {
+		LeaderboardUser
+		Refreshed_at time.Time
+	}
+	err := pgxscan.Select(db.Context, db.Conn, &rows,
+		ranked+` ORDER BY index LIMIT $3 OFFSET $4`,
+		communityId, window, pageParams.Count, pageParams.Start*pageParams.Count)
+
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return payload, 0, err
+	}
+
+	for _, row := range rows {
+		payload.Users = append(payload.Users, row.LeaderboardUser)
+		payload.Refreshed_at = &row.Refreshed_at
+	}
+
+	if addr != "" {
+		err = pgxscan.Get(db.Context, db.Conn, &payload.CurrentUser,
+			`SELECT addr, score, index FROM (`+ranked+`) AS ranked WHERE addr = $3`,
+			communityId, window, addr)
+		if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+			return payload, 0, err
+		}
+	}
+
+	var totalUsers int
+	countSql := fmt.Sprintf(`
+		SELECT COUNT(*) FROM leaderboard_stats
+		WHERE community_id = $1 AND time_window = $2 AND %s > 0
+	`, LEADERBOARD_METRICS[metric])
+	_ = db.Conn.QueryRow(db.Context, countSql, communityId, window).Scan(&totalUsers)
+
+	return payload, totalUsers, nil
+}
+
+func RefreshLeaderboardStats(db *s.Database) error {
+	_, err := db.Conn.Exec(db.Context, `REFRESH MATERIALIZED VIEW CONCURRENTLY leaderboard_stats`)
+	return err
+}
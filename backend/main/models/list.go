@@ -1,20 +1,62 @@
 package models
 
 import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
 	"sort"
+	"strings"
 	"time"
 
 	s "github.com/DapperCollectives/CAST/backend/main/shared"
 	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
 )
 
+const (
+	ListRuleFTBalance    = "ft-balance"
+	ListRuleNFTOwnership = "nft-ownership"
+)
+
+const (
+	ListUnion        = "union"
+	ListIntersection = "intersection"
+	ListDifference   = "difference"
+)
+
+var ErrNotOnAllowlist = errors.New("Address is not on the list of allowed voters.")
+
 type List struct {
-	ID           int        `json:"id"`
-	Community_id int        `json:"communityId"`
-	Addresses    []string   `json:"addresses,omitempty" validate:"required"`
-	List_type    *string    `json:"listType,omitempty"`
-	Cid          *string    `json:"cid,omitempty"`
-	Created_at   *time.Time `json:"createdAt,omitempty"`
+	ID              int        `json:"id"`
+	Community_id    int        `json:"communityId"`
+	Addresses       []string   `json:"addresses,omitempty" validate:"required"`
+	List_type       *string    `json:"listType,omitempty"`
+	Cid             *string    `json:"cid,omitempty"`
+	Created_at      *time.Time `json:"createdAt,omitempty"`
+	Rule            *ListRule  `json:"rule,omitempty"`
+	Materialized_at *time.Time `json:"materializedAt,omitempty"`
+	Version         int        `json:"version"`
+	Name            *string    `json:"name,omitempty"`
+}
+
+// ListVersion is the content of a list after one of its modifications.
+// Versions are immutable so past eligibility can be reproduced.
+type ListVersion struct {
+	List_id    int       `json:"listId"`
+	Version    int       `json:"version"`
+	Addresses  []string  `json:"addresses"`
+	Cid        *string   `json:"cid,omitempty"`
+	Created_at time.Time `json:"createdAt"`
+}
+
+// ListRule defines a dynamic list. Its addresses are computed periodically
+// from chain data instead of being edited directly.
+type ListRule struct {
+	Type       string     `json:"type"`
+	Contract   s.Contract `json:"contract"`
+	Min_amount float64    `json:"minAmount,omitempty"`
 }
 
 type ListPayload struct {
@@ -22,12 +64,121 @@ type ListPayload struct {
 	s.TimestampSignaturePayload
 }
 
+type ListSetOperationPayload struct {
+	Operation     string     `json:"operation" validate:"required,oneof=union intersection difference"`
+	Left_list_id  int        `json:"leftListId" validate:"required"`
+	Right_list_id int        `json:"rightListId" validate:"required"`
+	Name          *string    `json:"name,omitempty"`
+	List_type     *string    `json:"listType,omitempty"`
+	Voucher       *s.Voucher `json:"voucher,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
 type ListUpdatePayload struct {
 	ID        int      `json:"id"`
 	Addresses []string `json:"addresses,omitempty" validate:"required"`
 	s.TimestampSignaturePayload
 }
 
+// ListCSVRow is a row of an uploaded list file that was not added.
+type ListCSVRow struct {
+	Line  int    `json:"line"`
+	Value string `json:"value"`
+}
+
+// ListCSVReport summarises a list file upload.
+type ListCSVReport struct {
+	Added      []string     `json:"added"`
+	Malformed  []ListCSVRow `json:"malformed"`
+	Duplicates []ListCSVRow `json:"duplicates"`
+}
+
+var flowAddressRegexp = regexp.MustCompile(`^0x[0-9a-f]{1,16}$`)
+
+// ParseListCSV reads addresses from the first column of a CSV file, or from
+// its "addr"/"address" column when the file has a header. Addresses are
+// normalised to lower case with a 0x prefix. Malformed values, repeats within
+// the file and addresses already in existing are reported instead of added.
+func ParseListCSV(r io.Reader, existing []string) (ListCSVReport, error) {
+	report := ListCSVReport{
+		Added:      []string{},
+		Malformed:  []ListCSVRow{},
+		Duplicates: []ListCSVRow{},
+	}
+	seen := map[string]bool{}
+	for _, addr := range existing {
+		seen[strings.ToLower(addr)] = true
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	column := 0
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return report, errors.New("Invalid CSV file.")
+		}
+
+		if line == 1 {
+			header := false
+			for i, field := range record {
+				name := strings.ToLower(strings.TrimSpace(field))
+				if name == "addr" || name == "address" {
+					column = i
+					header = true
+				}
+			}
+			if header {
+				continue
+			}
+		}
+
+		if column >= len(record) || strings.TrimSpace(record[column]) == "" {
+			continue
+		}
+
+		value := strings.TrimSpace(record[column])
+		addr := strings.ToLower(value)
+		if !strings.HasPrefix(addr, "0x") {
+			addr = "0x" + addr
+		}
+
+		if !flowAddressRegexp.MatchString(addr) {
+			report.Malformed = append(report.Malformed, ListCSVRow{Line: line, Value: value})
+		} else if seen[addr] {
+			report.Duplicates = append(report.Duplicates, ListCSVRow{Line: line, Value: value})
+		} else {
+			seen[addr] = true
+			report.Added = append(report.Added, addr)
+		}
+	}
+
+	return report, nil
+}
+
+func (r *ListRule) Validate() error {
+	if r.Type != ListRuleFTBalance && r.Type != ListRuleNFTOwnership {
+		return fmt.Errorf("Invalid list rule type %q.", r.Type)
+	}
+	if r.Contract.Name == nil || r.Contract.Addr == nil || r.Contract.Public_path == nil {
+		return errors.New("List rule contract requires a name, address and public path.")
+	}
+	if r.Min_amount < 0 {
+		return errors.New("List rule minimum amount cannot be negative.")
+	}
+	return nil
+}
+
+// IsDynamic reports whether the list addresses are computed from a rule.
+func (l *List) IsDynamic() bool {
+	return l.Rule != nil
+}
+
 func GetListsForCommunity(db *s.Database, communityId int) ([]List, error) {
 	lists := []List{}
 	err := pgxscan.Select(db.Context, db.Conn, &lists,
@@ -51,28 +202,213 @@ func (l *List) GetListById(db *s.Database) error {
 	`, l.ID)
 }
 
+func GetDynamicLists(db *s.Database) ([]List, error) {
+	lists := []List{}
+	err := pgxscan.Select(db.Context, db.Conn, &lists,
+		`SELECT * FROM lists WHERE rule IS NOT NULL ORDER BY id`)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	return lists, nil
+}
+
+// GetListCandidates returns the addresses a dynamic list rule is evaluated
+// against: the community members and everyone who voted on its proposals.
+func GetListCandidates(db *s.Database, communityId int) ([]string, error) {
+	var addrs []string
+	err := pgxscan.Select(db.Context, db.Conn, &addrs,
+		`
+		SELECT addr FROM community_users WHERE community_id = $1
+		UNION
+		SELECT v.addr FROM votes v
+		JOIN proposals p ON p.id = v.proposal_id
+		WHERE p.community_id = $1
+		ORDER BY addr
+		`, communityId)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	return addrs, nil
+}
+
 func (l *List) CreateList(db *s.Database) error {
 	err := db.Conn.QueryRow(db.Context,
 		`
-		INSERT INTO lists(community_id, addresses, list_type, cid)
-		VALUES($1, $2, $3, $4)
-		RETURNING id, created_at
-	`, l.Community_id, l.Addresses, l.List_type, l.Cid).Scan(&l.ID, &l.Created_at)
+		WITH l AS (
+			INSERT INTO lists(community_id, addresses, list_type, cid, rule, name)
+			VALUES($1, $2, $3, $4, $5, $6)
+			RETURNING id, version, addresses, cid, created_at
+		), v AS (
+			INSERT INTO list_versions(list_id, version, addresses, cid, created_at)
+			SELECT id, version, COALESCE(addresses, '{}'), cid, created_at FROM l
+		)
+		SELECT id, version, created_at FROM l
+	`, l.Community_id, l.Addresses, l.List_type, l.Cid, l.Rule, l.Name).Scan(&l.ID, &l.Version, &l.Created_at)
 
 	return err // will be nil unless something went wrong
 }
 
+// UpdateList saves the list contents as a new version.
 func (l *List) UpdateList(db *s.Database) error {
-	_, err := db.Conn.Exec(db.Context,
+	err := db.Conn.QueryRow(db.Context,
 		`
-		UPDATE lists
-		SET addresses = $1
-		WHERE id = $2
-	`, l.Addresses, l.ID)
+		WITH l AS (
+			UPDATE lists
+			SET addresses = $1, cid = $2, version = version + 1
+			WHERE id = $3
+			RETURNING id, version, addresses, cid
+		), v AS (
+			INSERT INTO list_versions(list_id, version, addresses, cid)
+			SELECT id, version, COALESCE(addresses, '{}'), cid FROM l
+		)
+		SELECT version FROM l
+	`, l.Addresses, l.Cid, l.ID).Scan(&l.Version)
 
 	return err // will be nil unless something went wrong
 }
 
+// MarkMaterialized records that a dynamic list was recomputed.
+func (l *List) MarkMaterialized(db *s.Database) error {
+	return db.Conn.QueryRow(db.Context,
+		`
+		UPDATE lists
+		SET materialized_at = (now() at time zone 'utc')
+		WHERE id = $1
+		RETURNING materialized_at
+	`, l.ID).Scan(&l.Materialized_at)
+}
+
+func GetListVersions(db *s.Database, listId int, pageParams s.PageParams) ([]*ListVersion, int, error) {
+	var versions []*ListVersion
+	err := pgxscan.Select(db.Context, db.Conn, &versions,
+		`
+		SELECT * FROM list_versions
+		WHERE list_id = $1
+		ORDER BY version DESC
+		LIMIT $2 OFFSET $3
+		`, listId, pageParams.Count, pageParams.Start)
+
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, 0, err
+	} else if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return []*ListVersion{}, 0, nil
+	}
+
+	var totalRecords int
+	countSql := `SELECT COUNT(*) FROM list_versions WHERE list_id = $1`
+	_ = db.Conn.QueryRow(db.Context, countSql, listId).Scan(&totalRecords)
+
+	return versions, totalRecords, nil
+}
+
+func GetListVersion(db *s.Database, listId, version int) (ListVersion, error) {
+	var v ListVersion
+	err := pgxscan.Get(db.Context, db.Conn, &v,
+		`SELECT * FROM list_versions WHERE list_id = $1 AND version = $2`,
+		listId, version)
+	return v, err
+}
+
+// GetListVersionAt returns the version of the list in effect at time t. A
+// list that did not exist yet resolves to an empty version 0.
+func GetListVersionAt(db *s.Database, listId int, t time.Time) (ListVersion, error) {
+	var v ListVersion
+	err := pgxscan.Get(db.Context, db.Conn, &v,
+		`
+		SELECT * FROM list_versions
+		WHERE list_id = $1 AND created_at <= $2
+		ORDER BY version DESC
+		LIMIT 1
+		`, listId, t)
+	if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return ListVersion{List_id: listId, Addresses: []string{}}, nil
+	}
+	return v, err
+}
+
+// GetListVersionForProposal resolves the list for a proposal: the version it
+// is bound to, or otherwise the version in effect when the proposal started.
+func GetListVersionForProposal(db *s.Database, listId int, p *Proposal) (ListVersion, error) {
+	if version, ok := p.List_versions[listId]; ok {
+		return GetListVersion(db, listId, version)
+	}
+	return GetListVersionAt(db, listId, p.Start_time)
+}
+
+// GetListVersionsForProposal resolves every list of the proposal community.
+func GetListVersionsForProposal(db *s.Database, p *Proposal) ([]ListVersion, error) {
+	lists, err := GetListsForCommunity(db, p.Community_id)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+
+	versions := []ListVersion{}
+	for _, l := range lists {
+		v, err := GetListVersionForProposal(db, l.ID, p)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+// EnsureListVersionsForCommunity checks every bound list belongs to the
+// community and has the requested version.
+func EnsureListVersionsForCommunity(db *s.Database, communityId int, versions map[int]int) error {
+	for listId, version := range versions {
+		var exists bool
+		err := db.Conn.QueryRow(db.Context,
+			`
+			SELECT EXISTS(
+				SELECT 1 FROM list_versions v
+				JOIN lists l ON l.id = v.list_id
+				WHERE v.list_id = $1 AND v.version = $2 AND l.community_id = $3
+			)
+			`, listId, version, communityId).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("List %d has no version %d in community %d.", listId, version, communityId)
+		}
+	}
+	return nil
+}
+
+// CombineLists applies a set operation to the addresses of two lists. The
+// result keeps the order in which addresses first appear.
+func CombineLists(operation string, left, right []string) ([]string, error) {
+	inRight := map[string]bool{}
+	for _, addr := range right {
+		inRight[addr] = true
+	}
+
+	var candidates []string
+	switch operation {
+	case ListUnion:
+		candidates = append(append(candidates, left...), right...)
+	case ListIntersection, ListDifference:
+		for _, addr := range left {
+			if inRight[addr] == (operation == ListIntersection) {
+				candidates = append(candidates, addr)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("Invalid list operation %q.", operation)
+	}
+
+	result := []string{}
+	seen := map[string]bool{}
+	for _, addr := range candidates {
+		if !seen[addr] {
+			seen[addr] = true
+			result = append(result, addr)
+		}
+	}
+	return result, nil
+}
+
 func (l *List) AddAddresses(addresses []string) {
 	// Put addresses into map to speed up lookup time
 	addrMap := map[string]bool{}
@@ -100,3 +436,16 @@ func (l *List) RemoveAddresses(toRemove []string) {
 		}
 	}
 }
+
+// SetCid stores the CID a version of the list was pinned under.
+func (l *List) SetCid(db *s.Database, cid string) error {
+	if _, err := db.Conn.Exec(db.Context,
+		`UPDATE lists SET cid = $2 WHERE id = $1 AND version = $3`,
+		l.ID, cid, l.Version); err != nil {
+		return err
+	}
+	_, err := db.Conn.Exec(db.Context,
+		`UPDATE list_versions SET cid = $2 WHERE list_id = $1 AND version = $3`,
+		l.ID, cid, l.Version)
+	return err
+}
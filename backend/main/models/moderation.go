@@ -0,0 +1,178 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+// What was moderated, and what was done with it.
+const (
+	ModerationProposal = "proposal"
+
+	ModerationAllow = "allow"
+	ModerationHold  = "hold"
+)
+
+const DefaultModerationHoldScore = 0.8
+
+// ModerationRule is a keyword or phrase a community flags. Every rule found
+// in new content adds its weight to the content's score.
+type ModerationRule struct {
+	ID           int        `json:"id"`
+	Community_id int        `json:"communityId"`
+	Pattern      string     `json:"pattern"`
+	Weight       float64    `json:"weight"`
+	Created_by   string     `json:"createdBy"`
+	Created_at   *time.Time `json:"createdAt,omitempty"`
+}
+
+type ModerationRulePayload struct {
+	Pattern string          `json:"pattern" validate:"required,max=100"`
+	Weight  float64         `json:"weight"  validate:"gt=0,lte=1"`
+	Voucher *shared.Voucher `json:"voucher,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
+// ModerationDecision is the log entry of content that was scored, and
+// whether it was held for review because of it.
+type ModerationDecision struct {
+	ID           int        `json:"id"`
+	Community_id int        `json:"communityId"`
+	Content_type string     `json:"contentType"`
+	Content_id   int        `json:"contentId"`
+	Author_addr  string     `json:"authorAddr"`
+	Score        float64    `json:"score"`
+	Labels       []string   `json:"labels"`
+	Action       string     `json:"action"`
+	Created_at   *time.Time `json:"createdAt,omitempty"`
+}
+
+// ModerationSettings is the score at which a community holds new content
+// for review. nil is DefaultModerationHoldScore.
+type ModerationSettings struct {
+	Moderation_hold_score *float64 `json:"moderationHoldScore,omitempty"`
+}
+
+func (m ModerationSettings) Validate() error {
+	if m.Moderation_hold_score != nil && (*m.Moderation_hold_score <= 0 || *m.Moderation_hold_score > 1) {
+		return errors.New("Moderation hold score must be above 0 and at most 1.")
+	}
+	return nil
+}
+
+func (m ModerationSettings) HoldScore() float64 {
+	if m.Moderation_hold_score != nil {
+		return *m.Moderation_hold_score
+	}
+	return DefaultModerationHoldScore
+}
+
+// KeywordRules scores content with a community's rules, matched as whole
+// words regardless of case.
+type KeywordRules []*ModerationRule
+
+func (rules KeywordRules) Score(content s.ModerationContent) (s.ModerationScore, error) {
+	text := content.Title + "\n" + content.Body
+	score := s.ModerationScore{}
+	for _, r := range rules {
+		re, err := regexp.Compile(`(?i)(^|\W)` + regexp.QuoteMeta(r.Pattern) + `($|\W)`)
+		if err != nil {
+			return s.ModerationScore{}, err
+		}
+		if re.MatchString(text) {
+			score.Score += r.Weight
+			score.Labels = append(score.Labels, "keyword:"+strings.ToLower(r.Pattern))
+		}
+	}
+	score.Score = math.Min(score.Score, 1)
+	return score, nil
+}
+
+func GetModerationRules(db *s.Database, communityId int) ([]*ModerationRule, error) {
+	var rules []*ModerationRule
+	err := pgxscan.Select(db.Context, db.Conn, &rules,
+		`SELECT * FROM moderation_rules WHERE community_id = $1 ORDER BY pattern ASC`,
+		communityId)
+
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	if rules == nil {
+		rules = []*ModerationRule{}
+	}
+	return rules, nil
+}
+
+func (r *ModerationRule) GetModerationRuleById(db *s.Database) error {
+	return pgxscan.Get(db.Context, db.Conn, r,
+		`SELECT * FROM moderation_rules WHERE id = $1`,
+		r.ID)
+}
+
+func (r *ModerationRule) CreateModerationRule(db *s.Database) error {
+	return db.Conn.QueryRow(db.Context,
+		`
+		INSERT INTO moderation_rules(community_id, pattern, weight, created_by)
+		VALUES($1, $2, $3, $4)
+		RETURNING id, created_at
+		`, r.Community_id, r.Pattern, r.Weight, r.Created_by).
+		Scan(&r.ID, &r.Created_at)
+}
+
+func (r *ModerationRule) DeleteModerationRule(db *s.Database) error {
+	_, err := db.Conn.Exec(db.Context, `DELETE FROM moderation_rules WHERE id = $1`, r.ID)
+	return err
+}
+
+func (d *ModerationDecision) CreateModerationDecision(db *s.Database) error {
+	if d.Labels == nil {
+		d.Labels = []string{}
+	}
+	return db.Conn.QueryRow(db.Context,
+		`
+		INSERT INTO moderation_decisions(community_id, content_type, content_id, author_addr, score, labels, action)
+		VALUES($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+		`, d.Community_id, d.Content_type, d.Content_id, d.Author_addr, d.Score, d.Labels, d.Action).
+		Scan(&d.ID, &d.Created_at)
+}
+
+// GetModerationDecisions lists a community's decisions, newest first, those
+// with the given action only unless it is empty.
+func GetModerationDecisions(
+	db *s.Database,
+	communityId int,
+	action string,
+	pageParams shared.PageParams,
+) ([]*ModerationDecision, int, error) {
+	var decisions []*ModerationDecision
+	err := pgxscan.Select(db.Context, db.Conn, &decisions,
+		`
+		SELECT * FROM moderation_decisions
+		WHERE community_id = $1 AND ($2 = '' OR action = $2)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3 OFFSET $4
+		`, communityId, action, pageParams.Count, pageParams.Start)
+
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, 0, err
+	}
+	if decisions == nil {
+		decisions = []*ModerationDecision{}
+	}
+
+	var totalRecords int
+	countSql := `SELECT COUNT(*) FROM moderation_decisions WHERE community_id = $1 AND ($2 = '' OR action = $2)`
+	_ = db.Conn.QueryRow(db.Context, countSql, communityId, action).Scan(&totalRecords)
+
+	return decisions, totalRecords, nil
+}
@@ -0,0 +1,228 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	NotificationProposalOpened   = "proposal_opened"
+	NotificationProposalClosed   = "proposal_closed"
+	NotificationProposalVotes    = "proposal_votes"
+	NotificationProposalReviewed = "proposal_reviewed"
+	NotificationJoinRequest      = "join_request_reviewed"
+	NotificationJoinRequestNew   = "join_request_pending"
+	NotificationUsageWarning     = "usage_warning"
+)
+
+var NOTIFICATION_TYPES = []string{
+	NotificationProposalOpened,
+	NotificationProposalClosed,
+	NotificationProposalVotes,
+	NotificationProposalReviewed,
+	NotificationJoinRequest,
+	NotificationJoinRequestNew,
+	NotificationUsageWarning,
+}
+
+type Notification struct {
+	ID                int                    `json:"id"`
+	Addr              string                 `json:"addr"`
+	Notification_type string                 `json:"notificationType"`
+	Community_id      *int                   `json:"communityId,omitempty"`
+	Proposal_id       *int                   `json:"proposalId,omitempty"`
+	Event_id          *int                   `json:"eventId,omitempty"`
+	Data              map[string]interface{} `json:"data"`
+	Read_at           *time.Time             `json:"readAt,omitempty"`
+	Created_at        *time.Time             `json:"createdAt,omitempty"`
+}
+
+type NotificationPreference struct {
+	Addr              string `json:"-"`
+	Notification_type string `json:"notificationType" validate:"required"`
+	Enabled           bool   `json:"enabled"`
+}
+
+type MarkNotificationsReadPayload struct {
+	Ids     []int           `json:"ids,omitempty"`
+	Voucher *shared.Voucher `json:"voucher,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
+type NotificationPreferencesPayload struct {
+	Preferences []NotificationPreference `json:"preferences" validate:"required,dive"`
+	Voucher     *shared.Voucher          `json:"voucher,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
+func EnsureValidNotificationType(notificationType string) error {
+	for _, t := range NOTIFICATION_TYPES {
+		if t == notificationType {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown notification type %s", notificationType)
+}
+
+func GetNotificationsForAddress(
+	db *s.Database,
+	addr string,
+	unreadOnly bool,
+	pageParams shared.PageParams,
+) ([]*Notification, int, error) {
+	var notifications []*Notification
+	err := pgxscan.Select(db.Context, db.Conn, &notifications,
+		`
+		SELECT * FROM notifications
+		WHERE addr = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3 OFFSET $4
+		`, addr, unreadOnly, pageParams.Count, pageParams.Start)
+
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, 0, err
+	} else if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return []*Notification{}, 0, nil
+	}
+
+	var totalRecords int
+	countSql := `SELECT COUNT(*) FROM notifications WHERE addr = $1 AND (NOT $2 OR read_at IS NULL)`
+	_ = db.Conn.QueryRow(db.Context, countSql, addr, unreadOnly).Scan(&totalRecords)
+
+	return notifications, totalRecords, nil
+}
+
+// MarkNotificationsRead marks the given notifications, or the whole inbox
+// when no ids are given, as read.
+func MarkNotificationsRead(db *s.Database, addr string, ids []int) (int64, error) {
+	if len(ids) == 0 {
+		ids = nil
+	}
+	tag, err := db.Conn.Exec(db.Context,
+		`
+		UPDATE notifications SET read_at = (now() at time zone 'utc')
+		WHERE addr = $1 AND read_at IS NULL AND ($2::int[] IS NULL OR id = ANY($2))
+		`, addr, ids)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (n *Notification) CreateNotification(db *s.Database) error {
+	if n.Data == nil {
+		n.Data = map[string]interface{}{}
+	}
+	err := db.Conn.QueryRow(db.Context,
+		`
+		INSERT INTO notifications(addr, notification_type, community_id, proposal_id, event_id, data)
+		SELECT $1, $2, $3, $4, $5, $6
+		WHERE NOT EXISTS (
+			SELECT 1 FROM notification_preferences
+			WHERE addr = $1 AND notification_type = $2 AND enabled = 'false'
+		)
+		RETURNING id, created_at
+		`, n.Addr, n.Notification_type, n.Community_id, n.Proposal_id, n.Event_id, n.Data).
+		Scan(&n.ID, &n.Created_at)
+
+	// the recipient opted out of this notification type
+	if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return nil
+	}
+	return err
+}
+
+// NotifyCommunityAudience fans an event out to the inbox of every member and
+// follower of the community, and every follower of the actor, except the
+// actor itself. Notification preferences are honoured. It returns how many
+// notifications were sent.
+func NotifyCommunityAudience(db *s.Database, e CommunityEvent, notificationType string) (int64, error) {
+	actor := ""
+	if e.Actor_addr != nil {
+		actor = *e.Actor_addr
+	}
+	tag, err := db.Conn.Exec(db.Context,
+		`
+		INSERT INTO notifications(addr, notification_type, community_id, proposal_id, event_id, data)
+		SELECT audience.addr, $2, $1, $3, $4, $5::jsonb FROM (
+			SELECT addr FROM community_users WHERE community_id = $1
+			UNION
+			SELECT follower_addr FROM follows WHERE community_id = $1
+			UNION
+			SELECT follower_addr FROM follows WHERE followed_addr = $6
+		) AS audience
+		WHERE audience.addr <> $6
+			AND NOT EXISTS (
+				SELECT 1 FROM notification_preferences np
+				WHERE np.addr = audience.addr AND np.notification_type = $2 AND np.enabled = 'false'
+			)
+		`, e.Community_id, notificationType, e.Proposal_id, e.ID, e.Data, actor)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// NotifyCommunityAdmins sends a notification to every admin of the
+// community. Notification preferences are honoured. It returns how many
+// notifications were sent.
+func NotifyCommunityAdmins(db *s.Database, communityId int, notificationType string, data map[string]interface{}) (int64, error) {
+	tag, err := db.Conn.Exec(db.Context,
+		`
+		INSERT INTO notifications(addr, notification_type, community_id, data)
+		SELECT DISTINCT cu.addr, $2, $1, $3::jsonb FROM community_users cu
+		WHERE cu.community_id = $1 AND cu.user_type = 'admin'
+			AND NOT EXISTS (
+				SELECT 1 FROM notification_preferences np
+				WHERE np.addr = cu.addr AND np.notification_type = $2 AND np.enabled = 'false'
+			)
+		`, communityId, notificationType, data)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func GetNotificationPreferences(db *s.Database, addr string) ([]NotificationPreference, error) {
+	var stored []NotificationPreference
+	err := pgxscan.Select(db.Context, db.Conn, &stored,
+		`SELECT * FROM notification_preferences WHERE addr = $1`,
+		addr)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+
+	enabled := make(map[string]bool)
+	for _, p := range stored {
+		enabled[p.Notification_type] = p.Enabled
+	}
+
+	// every notification type is enabled unless the address opted out
+	preferences := []NotificationPreference{}
+	for _, t := range NOTIFICATION_TYPES {
+		on, ok := enabled[t]
+		preferences = append(preferences, NotificationPreference{
+			Addr:              addr,
+			Notification_type: t,
+			Enabled:           !ok || on,
+		})
+	}
+	return preferences, nil
+}
+
+func (p *NotificationPreference) SavePreference(db *s.Database) error {
+	_, err := db.Conn.Exec(db.Context,
+		`
+		INSERT INTO notification_preferences(addr, notification_type, enabled)
+		VALUES($1, $2, $3)
+		ON CONFLICT (addr, notification_type) DO UPDATE SET enabled = EXCLUDED.enabled
+		`, p.Addr, p.Notification_type, p.Enabled)
+	return err
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+)
+
+// Participation counts the votes of an address on the latest proposals of
+// a community ended by some time, at most Window of them.
+type Participation struct {
+	Window int `json:"window"`
+	Ended  int `json:"ended"`
+	Voted  int `json:"voted"`
+}
+
+// GetRecentParticipation counts the community's latest proposals ended by
+// before, leaving out proposal excludeId, and those addr voted on.
+func GetRecentParticipation(
+	db *s.Database,
+	communityId int,
+	addr string,
+	before time.Time,
+	window int,
+	excludeId int,
+) (Participation, error) {
+	participation := Participation{Window: window}
+	err := db.Conn.QueryRow(db.Context, `
+		SELECT COUNT(*), COUNT(v.id) FROM (
+			SELECT id FROM proposals
+			WHERE community_id = $1 AND id <> $2 AND status IN ('published', 'closed') AND end_time <= $3
+			ORDER BY end_time DESC
+			LIMIT $4
+		) recent
+		LEFT JOIN votes v ON v.proposal_id = recent.id AND v.addr = $5 AND v.is_cancelled IS NOT TRUE
+	`, communityId, excludeId, before, window, addr).Scan(&participation.Ended, &participation.Voted)
+	return participation, err
+}
+
+// AuthorParticipation requires proposal authors to have voted on at least
+// Author_min_votes of the community's Author_vote_window latest ended
+// proposals. nil means no requirement.
+type AuthorParticipation struct {
+	Author_min_votes   *int `json:"authorMinVotes,omitempty"`
+	Author_vote_window *int `json:"authorVoteWindow,omitempty"`
+}
+
+func (a AuthorParticipation) IsZero() bool {
+	return a.Author_min_votes == nil || *a.Author_min_votes == 0
+}
+
+func (a AuthorParticipation) Validate() error {
+	if a.Author_min_votes != nil && *a.Author_min_votes < 0 {
+		return errors.New("Minimum author votes cannot be negative.")
+	}
+	if a.Author_vote_window != nil && *a.Author_vote_window < 0 {
+		return errors.New("Author vote window cannot be negative.")
+	}
+	if a.Author_min_votes != nil && *a.Author_min_votes > a.window() {
+		return errors.New("Minimum author votes cannot exceed the author vote window.")
+	}
+	return nil
+}
+
+// RequiredVotes is how many of the recent proposals the author must have
+// voted on. Communities that ended fewer proposals than required only
+// require a vote on each of them.
+func (a AuthorParticipation) RequiredVotes(p Participation) int {
+	if a.IsZero() {
+		return 0
+	}
+	if p.Ended < *a.Author_min_votes {
+		return p.Ended
+	}
+	return *a.Author_min_votes
+}
+
+// the window defaults to the minimum votes, so they must all be in a row
+func (a AuthorParticipation) window() int {
+	if a.Author_vote_window != nil && *a.Author_vote_window > 0 {
+		return *a.Author_vote_window
+	}
+	if a.Author_min_votes != nil {
+		return *a.Author_min_votes
+	}
+	return 0
+}
+
+// CheckAuthor reads the participation of the author in the community, up
+// to now, and whether it meets the requirement.
+func (a AuthorParticipation) CheckAuthor(db *s.Database, communityId int, addr string) (Participation, bool, error) {
+	participation, err := GetRecentParticipation(db, communityId, addr, time.Now().UTC(), a.window(), 0)
+	if err != nil {
+		return participation, false, err
+	}
+	return participation, participation.Voted >= a.RequiredVotes(participation), nil
+}
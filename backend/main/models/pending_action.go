@@ -0,0 +1,179 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+// Sensitive community actions. Communities that require more than one
+// admin approval only apply them once enough admins co-signed them.
+const (
+	ActionUpdateStrategies     = "update_strategies"
+	ActionPurgeMembers         = "purge_members"
+	ActionArchive              = "archive"
+	ActionSetApprovalsRequired = "set_approvals_required"
+)
+
+const (
+	ActionPending   = "pending"
+	ActionApplied   = "applied"
+	ActionCancelled = "cancelled"
+)
+
+// PendingAction is a sensitive action proposed by one admin, applied once
+// the community's required number of admins approved it.
+type PendingAction struct {
+	ID           int             `json:"id"`
+	Community_id int             `json:"communityId"`
+	Action       string          `json:"action"`
+	Payload      json.RawMessage `json:"payload"`
+	Proposed_by  string          `json:"proposedBy"`
+	Status       string          `json:"status"`
+	Created_at   time.Time       `json:"createdAt"`
+	Expires_at   time.Time       `json:"expiresAt"`
+	Resolved_at  *time.Time      `json:"resolvedAt,omitempty"`
+	Approvals    []string        `json:"approvals" db:"-"`
+}
+
+type PendingActionPayload struct {
+	Action  string          `json:"action"  validate:"required,oneof=update_strategies purge_members archive set_approvals_required"`
+	Payload json.RawMessage `json:"payload"`
+	Voucher *s.Voucher      `json:"voucher,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
+// PendingActionApproval is signed by admins approving or cancelling an action.
+type PendingActionApproval struct {
+	Voucher *s.Voucher `json:"voucher,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
+// The payloads of each action.
+type UpdateStrategiesAction struct {
+	Strategies []Strategy `json:"strategies" validate:"required,min=1"`
+	Strategy   *string    `json:"strategy,omitempty"`
+}
+
+type PurgeMembersAction struct {
+	Addresses []string `json:"addresses" validate:"required,min=1,max=100"`
+}
+
+type SetApprovalsRequiredAction struct {
+	Required int `json:"required" validate:"required,min=1"`
+}
+
+var ErrActionNotPending = errors.New("action is no longer pending")
+
+func GetPendingActionsForCommunity(db *s.Database, communityId int, status string) ([]*PendingAction, error) {
+	actions := []*PendingAction{}
+	err := pgxscan.Select(db.Context, db.Conn, &actions,
+		`
+		SELECT * FROM community_pending_actions
+		WHERE community_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC
+		`, communityId, status)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+
+	for _, a := range actions {
+		if err := a.getApprovals(db); err != nil {
+			return nil, err
+		}
+	}
+	return actions, nil
+}
+
+func (a *PendingAction) GetPendingAction(db *s.Database) error {
+	if err := pgxscan.Get(db.Context, db.Conn, a,
+		`SELECT * FROM community_pending_actions WHERE id = $1`,
+		a.ID); err != nil {
+		return err
+	}
+	return a.getApprovals(db)
+}
+
+func (a *PendingAction) getApprovals(db *s.Database) error {
+	a.Approvals = []string{}
+	return pgxscan.Select(db.Context, db.Conn, &a.Approvals,
+		`SELECT addr FROM community_action_approvals WHERE action_id = $1 ORDER BY created_at`,
+		a.ID)
+}
+
+// CreatePendingAction records the action along with the approval of the
+// admin proposing it.
+func (a *PendingAction) CreatePendingAction(db *s.Database) error {
+	return db.WithTx(func(tx *s.Database) error {
+		if err := tx.Conn.QueryRow(tx.Context,
+			`
+			INSERT INTO community_pending_actions(community_id, action, payload, proposed_by, expires_at)
+			VALUES($1, $2, $3, $4, $5)
+			RETURNING id, status, created_at
+			`, a.Community_id, a.Action, string(a.Payload), a.Proposed_by, a.Expires_at).
+			Scan(&a.ID, &a.Status, &a.Created_at); err != nil {
+			return err
+		}
+		a.Approvals = []string{}
+		return a.Approve(tx, a.Proposed_by)
+	})
+}
+
+// Approve adds the admin's approval to a pending action.
+func (a *PendingAction) Approve(db *s.Database, addr string) error {
+	if a.Status != ActionPending || !a.Expires_at.After(time.Now().UTC()) {
+		return ErrActionNotPending
+	}
+	tag, err := db.Conn.Exec(db.Context,
+		`
+		INSERT INTO community_action_approvals(action_id, addr)
+		VALUES($1, $2)
+		ON CONFLICT DO NOTHING
+		`, a.ID, addr)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s already approved action %d", addr, a.ID)
+	}
+	a.Approvals = append(a.Approvals, addr)
+	return nil
+}
+
+// CountAdminApprovals counts the approvals of those who are still admins
+// of the community.
+func (a *PendingAction) CountAdminApprovals(db *s.Database) (int, error) {
+	var count int
+	err := db.Conn.QueryRow(db.Context,
+		`
+		SELECT COUNT(*) FROM community_action_approvals a
+		JOIN community_users u ON u.addr = a.addr AND u.community_id = $2 AND u.user_type = 'admin'
+		WHERE a.action_id = $1
+		`, a.ID, a.Community_id).Scan(&count)
+	return count, err
+}
+
+// Resolve marks a pending action as applied or cancelled.
+func (a *PendingAction) Resolve(db *s.Database, status string) error {
+	tag, err := db.Conn.Exec(db.Context,
+		`
+		UPDATE community_pending_actions
+		SET status = $2, resolved_at = (now() at time zone 'utc')
+		WHERE id = $1 AND status = 'pending'
+		`, a.ID, status)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrActionNotPending
+	}
+	a.Status = status
+	return nil
+}
@@ -0,0 +1,211 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	PermCreateProposal    = "create_proposal"
+	PermCancelProposal    = "cancel_proposal"
+	PermManageLists       = "manage_lists"
+	PermManageMembers     = "manage_members"
+	PermManageStrategies  = "manage_strategies"
+	PermModerateProposals = "moderate_proposals"
+)
+
+var PERMISSIONS = []string{
+	PermCreateProposal,
+	PermCancelProposal,
+	PermManageLists,
+	PermManageMembers,
+	PermManageStrategies,
+	PermModerateProposals,
+}
+
+// DEFAULT_ROLE_PERMISSIONS maps the built-in user types onto the permission
+// matrix. Custom roles add to these, they never take permissions away.
+var DEFAULT_ROLE_PERMISSIONS = map[string][]string{
+	"admin":  PERMISSIONS,
+	"author": {PermCreateProposal, PermCancelProposal},
+	"member": {},
+}
+
+type CommunityRole struct {
+	ID           int        `json:"id"`
+	Community_id int        `json:"communityId"`
+	Name         string     `json:"name"        validate:"required"`
+	Permissions  []string   `json:"permissions"`
+	Created_at   *time.Time `json:"createdAt,omitempty"`
+}
+
+type CommunityRolePayload struct {
+	Name        string          `json:"name"        validate:"required,max=64"`
+	Permissions []string        `json:"permissions" validate:"required"`
+	Voucher     *shared.Voucher `json:"voucher,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
+type CommunityRoleMemberPayload struct {
+	Addr    string          `json:"addr"    validate:"required"`
+	Voucher *shared.Voucher `json:"voucher,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
+type CommunityRoleDeletePayload struct {
+	Voucher *shared.Voucher `json:"voucher,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
+func EnsureValidPermissions(permissions []string) error {
+	for _, p := range permissions {
+		valid := false
+		for _, known := range PERMISSIONS {
+			if p == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("unknown permission %s", p)
+		}
+	}
+	return nil
+}
+
+func GetRolesForCommunity(db *s.Database, communityId int) ([]*CommunityRole, error) {
+	var roles []*CommunityRole
+	err := pgxscan.Select(db.Context, db.Conn, &roles,
+		`SELECT * FROM community_roles WHERE community_id = $1 ORDER BY name ASC`,
+		communityId)
+
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	} else if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return []*CommunityRole{}, nil
+	}
+	return roles, nil
+}
+
+func (r *CommunityRole) GetRoleById(db *s.Database) error {
+	return pgxscan.Get(db.Context, db.Conn, r,
+		`SELECT * FROM community_roles WHERE id = $1`,
+		r.ID)
+}
+
+func (r *CommunityRole) CreateRole(db *s.Database) error {
+	return db.Conn.QueryRow(db.Context,
+		`
+		INSERT INTO community_roles(community_id, name, permissions)
+		VALUES($1, $2, $3)
+		RETURNING id, created_at
+		`, r.Community_id, r.Name, r.Permissions).
+		Scan(&r.ID, &r.Created_at)
+}
+
+func (r *CommunityRole) UpdateRole(db *s.Database) error {
+	_, err := db.Conn.Exec(db.Context,
+		`UPDATE community_roles SET name = $1, permissions = $2 WHERE id = $3`,
+		r.Name, r.Permissions, r.ID)
+	return err
+}
+
+func (r *CommunityRole) DeleteRole(db *s.Database) error {
+	_, err := db.Conn.Exec(db.Context, `DELETE FROM community_roles WHERE id = $1`, r.ID)
+	return err
+}
+
+func (r *CommunityRole) GetRoleMembers(db *s.Database) ([]string, error) {
+	var addrs []string
+	err := pgxscan.Select(db.Context, db.Conn, &addrs,
+		`SELECT addr FROM community_role_members WHERE role_id = $1 ORDER BY addr ASC`,
+		r.ID)
+
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	if addrs == nil {
+		addrs = []string{}
+	}
+	return addrs, nil
+}
+
+func (r *CommunityRole) AssignRole(db *s.Database, addr string) error {
+	_, err := db.Conn.Exec(db.Context,
+		`INSERT INTO community_role_members(role_id, addr) VALUES($1, $2) ON CONFLICT DO NOTHING`,
+		r.ID, addr)
+	return err
+}
+
+func (r *CommunityRole) UnassignRole(db *s.Database, addr string) error {
+	_, err := db.Conn.Exec(db.Context,
+		`DELETE FROM community_role_members WHERE role_id = $1 AND addr = $2`,
+		r.ID, addr)
+	return err
+}
+
+// GetPermissionsForAddress resolves the effective permissions of an address
+// in a community from its built-in user types and any custom roles.
+func GetPermissionsForAddress(db *s.Database, addr string, communityId int) ([]string, error) {
+	granted := map[string]bool{}
+
+	userRoles, err := GetAllRolesForUserInCommunity(db, addr, communityId)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range userRoles {
+		for _, p := range DEFAULT_ROLE_PERMISSIONS[u.User_type] {
+			granted[p] = true
+		}
+	}
+
+	// admins of a parent community are admins here too
+	if !granted[PermManageMembers] && EnsureRoleForCommunity(db, addr, communityId, "admin") == nil {
+		for _, p := range DEFAULT_ROLE_PERMISSIONS["admin"] {
+			granted[p] = true
+		}
+	}
+
+	var custom []string
+	err = pgxscan.Select(db.Context, db.Conn, &custom,
+		`
+		SELECT DISTINCT unnest(r.permissions) FROM community_roles r
+		JOIN community_role_members m ON m.role_id = r.id
+		WHERE r.community_id = $1 AND m.addr = $2
+		`, communityId, addr)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	for _, p := range custom {
+		granted[p] = true
+	}
+
+	permissions := []string{}
+	for _, p := range PERMISSIONS {
+		if granted[p] {
+			permissions = append(permissions, p)
+		}
+	}
+	return permissions, nil
+}
+
+func EnsurePermissionForCommunity(db *s.Database, addr string, communityId int, permission string) error {
+	permissions, err := GetPermissionsForAddress(db, addr, communityId)
+	if err != nil {
+		return err
+	}
+	for _, p := range permissions {
+		if p == permission {
+			return nil
+		}
+	}
+	return fmt.Errorf("account %s does not have permission %s in community %d", addr, permission, communityId)
+}
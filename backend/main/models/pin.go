@@ -0,0 +1,186 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+// Records pinned to IPFS.
+const (
+	PinCommunity       = "community"
+	PinProposal        = "proposal"
+	PinProposalResults = "proposal_results"
+	PinList            = "list"
+)
+
+const (
+	PinPending = "pending"
+	PinPinned  = "pinned"
+	PinFailed  = "failed"
+)
+
+// Pin tracks pinning a record to IPFS. Records are queued for pinning after
+// they are written and pinned by a background job, which retries failures
+// with a growing delay.
+type Pin struct {
+	Record_type     string     `json:"recordType"`
+	Record_id       int        `json:"recordId"`
+	Status          string     `json:"status"`
+	Attempts        int        `json:"attempts"`
+	Last_error      *string    `json:"lastError,omitempty"`
+	Cid             *string    `json:"cid,omitempty"`
+	Requested_at    time.Time  `json:"requestedAt"`
+	Next_attempt_at time.Time  `json:"nextAttemptAt"`
+	Pinned_at       *time.Time `json:"pinnedAt,omitempty"`
+}
+
+// UnpinnedRecord is a record whose CID is missing or whose latest content
+// has not been pinned.
+type UnpinnedRecord struct {
+	Record_type  string     `json:"recordType"`
+	Record_id    int        `json:"recordId"`
+	Cid          *string    `json:"cid,omitempty"`
+	Status       *string    `json:"status,omitempty"`
+	Attempts     *int       `json:"attempts,omitempty"`
+	Last_error   *string    `json:"lastError,omitempty"`
+	Requested_at *time.Time `json:"requestedAt,omitempty"`
+}
+
+type PinReconcilePayload struct {
+	s.TimestampSignaturePayload
+	Requeue bool `json:"requeue"`
+}
+
+// QueuePin schedules a record to be pinned, replacing any earlier request
+// for it.
+func QueuePin(db *s.Database, recordType string, recordId int) error {
+	_, err := db.Conn.Exec(db.Context,
+		`
+		INSERT INTO ipfs_pins(record_type, record_id)
+		VALUES($1, $2)
+		ON CONFLICT (record_type, record_id) DO UPDATE
+		SET status = 'pending', attempts = 0, last_error = NULL,
+			requested_at = now(), next_attempt_at = now()
+		`, recordType, recordId)
+	return err
+}
+
+func GetPendingPins(db *s.Database, maxAttempts, limit int) ([]*Pin, error) {
+	var pins []*Pin
+	err := pgxscan.Select(db.Context, db.Conn, &pins,
+		`
+		SELECT * FROM ipfs_pins
+		WHERE status <> 'pinned' AND attempts < $1 AND next_attempt_at <= now()
+		ORDER BY next_attempt_at
+		LIMIT $2
+		`, maxAttempts, limit)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	return pins, nil
+}
+
+func GetPin(db *s.Database, recordType string, recordId int) (*Pin, error) {
+	var pin Pin
+	err := pgxscan.Get(db.Context, db.Conn, &pin,
+		`SELECT * FROM ipfs_pins WHERE record_type = $1 AND record_id = $2`,
+		recordType, recordId)
+	return &pin, err
+}
+
+// MarkPinned records a successful pin, unless the record was queued again
+// since the pin began.
+func (p *Pin) MarkPinned(db *s.Database, cid string) error {
+	_, err := db.Conn.Exec(db.Context,
+		`
+		UPDATE ipfs_pins
+		SET status = 'pinned', cid = $3, last_error = NULL, pinned_at = now()
+		WHERE record_type = $1 AND record_id = $2 AND requested_at = $4
+		`, p.Record_type, p.Record_id, cid, p.Requested_at)
+	return err
+}
+
+// MarkFailed records a failed attempt and delays the next one, doubling the
+// delay with every attempt.
+func (p *Pin) MarkFailed(db *s.Database, pinErr error) error {
+	_, err := db.Conn.Exec(db.Context,
+		`
+		UPDATE ipfs_pins
+		SET status = 'failed', attempts = attempts + 1, last_error = $3,
+			next_attempt_at = now() + (power(2, attempts) * interval '1 minute')
+		WHERE record_type = $1 AND record_id = $2 AND requested_at = $4
+		`, p.Record_type, p.Record_id, pinErr.Error(), p.Requested_at)
+	return err
+}
+
+// Defer puts the next attempt off until the given time, without counting
+// an attempt.
+func (p *Pin) Defer(db *s.Database, until time.Time) error {
+	_, err := db.Conn.Exec(db.Context,
+		`
+		UPDATE ipfs_pins SET next_attempt_at = $3
+		WHERE record_type = $1 AND record_id = $2 AND requested_at = $4
+		`, p.Record_type, p.Record_id, until, p.Requested_at)
+	return err
+}
+
+// CommunityId returns the community the pinned record belongs to.
+func (p *Pin) CommunityId(db *s.Database) (int, error) {
+	var sql string
+	switch p.Record_type {
+	case PinCommunity:
+		return p.Record_id, nil
+	case PinProposal, PinProposalResults:
+		sql = `SELECT community_id FROM proposals WHERE id = $1`
+	case PinList:
+		sql = `SELECT community_id FROM lists WHERE id = $1`
+	default:
+		return 0, fmt.Errorf("unknown pin record type %s", p.Record_type)
+	}
+	var communityId int
+	err := db.Conn.QueryRow(db.Context, sql, p.Record_id).Scan(&communityId)
+	return communityId, err
+}
+
+// unpinnedRecordsSQL lists the records of one table whose CID is missing or
+// whose pin is outstanding.
+func unpinnedRecordsSQL(recordType, table, idColumn, where string) string {
+	return fmt.Sprintf(`
+		SELECT '%[1]s' AS record_type, r.%[3]s AS record_id, r.cid,
+			p.status, p.attempts, p.last_error, p.requested_at
+		FROM %[2]s r
+		LEFT JOIN ipfs_pins p ON p.record_type = '%[1]s' AND p.record_id = r.%[3]s
+		WHERE (r.cid IS NULL OR p.status <> 'pinned') %[4]s
+	`, recordType, table, idColumn, where)
+}
+
+// GetUnpinnedRecords lists communities, proposals, lists and final results
+// whose CID is missing or whose latest content is not pinned yet.
+func GetUnpinnedRecords(db *s.Database, pageParams s.PageParams) ([]*UnpinnedRecord, int, error) {
+	sql := unpinnedRecordsSQL(PinCommunity, "communities", "id", "") +
+		" UNION ALL " + unpinnedRecordsSQL(PinProposal, "proposals", "id", "") +
+		" UNION ALL " + unpinnedRecordsSQL(PinList, "lists", "id", "") +
+		" UNION ALL " + unpinnedRecordsSQL(PinProposalResults, "proposal_results", "proposal_id",
+		"AND EXISTS (SELECT 1 FROM proposals WHERE id = r.proposal_id AND status = 'closed')")
+
+	var records []*UnpinnedRecord
+	err := pgxscan.Select(db.Context, db.Conn, &records,
+		`SELECT * FROM (`+sql+`) u ORDER BY record_type, record_id LIMIT $1 OFFSET $2`,
+		pageParams.Count, pageParams.Start)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, 0, err
+	}
+
+	var totalRecords int
+	if err := db.Conn.QueryRow(db.Context,
+		`SELECT COUNT(*) FROM (`+sql+`) u`,
+	).Scan(&totalRecords); err != nil {
+		return nil, 0, err
+	}
+
+	return records, totalRecords, nil
+}
@@ -0,0 +1,280 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+// Filters for listing communities to platform admins.
+const (
+	CommunityFilterFeatured  = "featured"
+	CommunityFilterArchived  = "archived"
+	CommunityFilterSuspended = "suspended"
+)
+
+var ErrAddressBlocked = errors.New("Address is blocked from creating communities.")
+
+// job failures are kept this long, a job that fails on every tick would
+// otherwise grow the table without bound
+const jobFailureRetention = 30 * 24 * time.Hour
+
+type SuspendCommunityPayload struct {
+	s.TimestampSignaturePayload
+	Reason string `json:"reason" validate:"required,max=1000"`
+}
+
+// AddressList names the table of a platform address list.
+type AddressList string
+
+const (
+	PlatformAdmins    AddressList = "platform_admins"
+	PlatformBlocklist AddressList = "platform_blocklist"
+)
+
+type AddressListPayload struct {
+	s.TimestampSignaturePayload
+	Addresses []string `json:"addresses" validate:"required,min=1,max=500,dive,required"`
+	Reason    *string  `json:"reason,omitempty"`
+}
+
+// ListedAddress is an entry of a platform address list. Admins seeded from
+// ADMIN_ADDRS were added by no one.
+type ListedAddress struct {
+	Addr       string    `json:"addr"`
+	Reason     *string   `json:"reason,omitempty"`
+	Added_by   *string   `json:"addedBy,omitempty"`
+	Created_at time.Time `json:"createdAt"`
+}
+
+// FailedJob is a failed run of a background job, or a failed community
+// export. Failed pins are reported by the pin reconciliation instead.
+type FailedJob struct {
+	Job          string    `json:"job"`
+	Record_id    *int      `json:"recordId,omitempty"`
+	Community_id *int      `json:"communityId,omitempty"`
+	Error        string    `json:"error"`
+	Failed_at    time.Time `json:"failedAt"`
+}
+
+type PlatformStats struct {
+	Communities           int `json:"communities"`
+	Archived_communities  int `json:"archivedCommunities"`
+	Suspended_communities int `json:"suspendedCommunities"`
+	Proposals             int `json:"proposals"`
+	Open_proposals        int `json:"openProposals"`
+	Votes                 int `json:"votes"`
+	Voters                int `json:"voters"`
+	Members               int `json:"members"`
+	Blocked_addresses     int `json:"blockedAddresses"`
+	Failed_jobs_24h       int `json:"failedJobs24h"`
+}
+
+// GetCommunitiesForAdmin lists every community, archived and suspended
+// ones included, optionally narrowed by one of the community filters.
+func GetCommunitiesForAdmin(db *s.Database, filter string, pageParams s.PageParams) ([]*Community, int, error) {
+	where := `WHERE ($1 = ''
+		OR ($1 = 'featured' AND is_featured = 'true')
+		OR ($1 = 'archived' AND is_archived = 'true')
+		OR ($1 = 'suspended' AND suspended_at IS NOT NULL))`
+
+	var communities []*Community
+	err := pgxscan.Select(db.Context, db.Conn, &communities,
+		`SELECT * FROM communities `+where+` ORDER BY id DESC LIMIT $2 OFFSET $3`,
+		filter, pageParams.Count, pageParams.Start)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, 0, err
+	} else if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return []*Community{}, 0, nil
+	}
+
+	var totalRecords int
+	countSql := `SELECT COUNT(*) FROM communities ` + where
+	_ = db.Conn.QueryRow(db.Context, countSql, filter).Scan(&totalRecords)
+
+	return communities, totalRecords, nil
+}
+
+func (c *Community) SetFeatured(db *s.Database, featured bool) error {
+	return db.Conn.QueryRow(db.Context, `
+		UPDATE communities SET is_featured = $2, version = version + 1, updated_at = (now() at time zone 'utc')
+		WHERE id = $1
+		RETURNING is_featured, version
+	`, c.ID, featured).Scan(&c.Is_featured, &c.Version)
+}
+
+// SuspendCommunity archives the community, which hides it and stops new
+// proposals and votes, and records why.
+func (c *Community) SuspendCommunity(db *s.Database, reason string) error {
+	return db.Conn.QueryRow(db.Context, `
+		UPDATE communities
+		SET is_archived = 'true', archived_at = COALESCE(archived_at, (now() at time zone 'utc')),
+			suspended_at = (now() at time zone 'utc'), suspension_reason = $2, version = version + 1, updated_at = (now() at time zone 'utc')
+		WHERE id = $1
+		RETURNING is_archived, archived_at, suspended_at, suspension_reason, version
+	`, c.ID, reason).Scan(&c.Is_archived, &c.Archived_at, &c.Suspended_at, &c.Suspension_reason, &c.Version)
+}
+
+// UnsuspendCommunity restores a suspended community to active.
+func (c *Community) UnsuspendCommunity(db *s.Database) error {
+	if err := c.UnarchiveCommunity(db); err != nil {
+		return err
+	}
+	_, err := db.Conn.Exec(db.Context, `
+		UPDATE communities SET suspended_at = NULL, suspension_reason = NULL, updated_at = (now() at time zone 'utc') WHERE id = $1
+	`, c.ID)
+	if err != nil {
+		return err
+	}
+
+	c.Suspended_at = nil
+	c.Suspension_reason = nil
+	return nil
+}
+
+func GetListedAddresses(db *s.Database, list AddressList, pageParams s.PageParams) ([]*ListedAddress, int, error) {
+	var listed []*ListedAddress
+	err := pgxscan.Select(db.Context, db.Conn, &listed,
+		`SELECT * FROM `+string(list)+` ORDER BY created_at DESC LIMIT $1 OFFSET $2`,
+		pageParams.Count, pageParams.Start)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, 0, err
+	} else if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return []*ListedAddress{}, 0, nil
+	}
+
+	var totalRecords int
+	_ = db.Conn.QueryRow(db.Context, `SELECT COUNT(*) FROM `+string(list)).Scan(&totalRecords)
+
+	return listed, totalRecords, nil
+}
+
+func GetAddressList(db *s.Database, list AddressList) ([]string, error) {
+	addresses := []string{}
+	err := pgxscan.Select(db.Context, db.Conn, &addresses, `SELECT addr FROM `+string(list)+` ORDER BY addr`)
+	return addresses, err
+}
+
+// AddListedAddresses adds the addresses to the list, updating the reason
+// of those already on it.
+func AddListedAddresses(db *s.Database, list AddressList, addresses []string, reason *string, addedBy string) error {
+	return db.WithTx(func(tx *s.Database) error {
+		for _, addr := range addresses {
+			_, err := tx.Conn.Exec(tx.Context, `
+				INSERT INTO `+string(list)+`(addr, reason, added_by)
+				VALUES($1, $2, $3)
+				ON CONFLICT (addr) DO UPDATE SET reason = EXCLUDED.reason
+			`, addr, reason, addedBy)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func RemoveListedAddresses(db *s.Database, list AddressList, addresses []string) (int64, error) {
+	tag, err := db.Conn.Exec(db.Context, `DELETE FROM `+string(list)+` WHERE addr = ANY($1)`, addresses)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// SeedPlatformAdmins adds the addresses as platform admins when there are
+// none yet, so that a fresh install has someone to manage the list.
+func SeedPlatformAdmins(db *s.Database, addresses []string) error {
+	return db.WithTx(func(tx *s.Database) error {
+		var count int
+		if err := tx.Conn.QueryRow(tx.Context, `SELECT COUNT(*) FROM platform_admins`).Scan(&count); err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		for _, addr := range addresses {
+			_, err := tx.Conn.Exec(tx.Context,
+				`INSERT INTO platform_admins(addr) VALUES($1) ON CONFLICT (addr) DO NOTHING`, addr)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RecordJobFailure keeps the error of a failed background job run, and
+// forgets failures past their retention.
+func RecordJobFailure(db *s.Database, job string, jobErr error) error {
+	_, err := db.Conn.Exec(db.Context,
+		`INSERT INTO job_failures(job, error) VALUES($1, $2)`, job, jobErr.Error())
+	if err != nil {
+		return err
+	}
+	_, err = db.Conn.Exec(db.Context,
+		`DELETE FROM job_failures WHERE failed_at < $1`, time.Now().Add(-jobFailureRetention))
+	return err
+}
+
+// failedJobsSQL unions failed job runs with failed exports.
+const failedJobsSQL = `
+	SELECT * FROM (
+		SELECT job, NULL::INT AS record_id, NULL::INT AS community_id, error, failed_at
+		FROM job_failures
+		UNION ALL
+		SELECT 'exports', id, community_id, COALESCE(error, ''), completed_at AT TIME ZONE 'utc'
+		FROM community_exports WHERE status = 'failed'
+	) f
+	WHERE $1 = '' OR job = $1
+`
+
+func GetFailedJobs(db *s.Database, job string, pageParams s.PageParams) ([]*FailedJob, int, error) {
+	var failures []*FailedJob
+	err := pgxscan.Select(db.Context, db.Conn, &failures,
+		failedJobsSQL+` ORDER BY failed_at DESC LIMIT $2 OFFSET $3`,
+		job, pageParams.Count, pageParams.Start)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, 0, err
+	} else if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return []*FailedJob{}, 0, nil
+	}
+
+	var totalRecords int
+	_ = db.Conn.QueryRow(db.Context, `SELECT COUNT(*) FROM (`+failedJobsSQL+`) c`, job).Scan(&totalRecords)
+
+	return failures, totalRecords, nil
+}
+
+func GetPlatformStats(db *s.Database) (PlatformStats, error) {
+	var stats PlatformStats
+	err := db.Conn.QueryRow(db.Context, `
+		SELECT
+			(SELECT COUNT(*) FROM communities),
+			(SELECT COUNT(*) FROM communities WHERE is_archived = 'true'),
+			(SELECT COUNT(*) FROM communities WHERE suspended_at IS NOT NULL),
+			(SELECT COUNT(*) FROM proposals),
+			(SELECT COUNT(*) FROM proposals
+				WHERE status = 'published'
+				AND start_time <= (now() at time zone 'utc') AND end_time > (now() at time zone 'utc')),
+			(SELECT COUNT(*) FROM votes),
+			(SELECT COUNT(DISTINCT addr) FROM votes),
+			(SELECT COUNT(DISTINCT addr) FROM community_users),
+			(SELECT COUNT(*) FROM platform_blocklist),
+			(SELECT COUNT(*) FROM job_failures WHERE failed_at > now() - interval '24 hours')
+	`).Scan(
+		&stats.Communities,
+		&stats.Archived_communities,
+		&stats.Suspended_communities,
+		&stats.Proposals,
+		&stats.Open_proposals,
+		&stats.Votes,
+		&stats.Voters,
+		&stats.Members,
+		&stats.Blocked_addresses,
+		&stats.Failed_jobs_24h,
+	)
+	return stats, err
+}
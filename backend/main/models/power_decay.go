@@ -0,0 +1,57 @@
+package models
+
+import (
+	"errors"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+)
+
+// PowerDecay lowers the vote weight of members who voted on none of the
+// community's Decay_window latest proposals ended before a proposal
+// started, by the Decay_rate share of it. nil means no decay.
+type PowerDecay struct {
+	Decay_window *int     `json:"decayWindow,omitempty"`
+	Decay_rate   *float64 `json:"decayRate,omitempty"`
+}
+
+func (d PowerDecay) IsZero() bool {
+	return d.Decay_window == nil || *d.Decay_window == 0 || d.Decay_rate == nil || *d.Decay_rate == 0
+}
+
+func (d PowerDecay) Validate() error {
+	if d.Decay_window != nil && *d.Decay_window < 0 {
+		return errors.New("Decay window cannot be negative.")
+	}
+	if d.Decay_rate != nil && (*d.Decay_rate < 0 || *d.Decay_rate > 1) {
+		return errors.New("Decay rate must be between 0 and 1.")
+	}
+	return nil
+}
+
+// WeightMultiplier is what the weight of addr voting on the proposal is
+// multiplied by. Members are only judged once the community has ended as
+// many proposals as the window.
+func (d PowerDecay) WeightMultiplier(db *s.Database, p Proposal, addr string) (float64, error) {
+	if d.IsZero() {
+		return 1, nil
+	}
+
+	participation, err := GetRecentParticipation(db, p.Community_id, addr, p.Start_time, *d.Decay_window, p.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	if participation.Ended < *d.Decay_window || participation.Voted > 0 {
+		return 1, nil
+	}
+	return 1 - *d.Decay_rate, nil
+}
+
+// ModifyWeight applies the vote's weight multiplier to the weight the
+// strategy gives it.
+func (v *Vote) ModifyWeight(weight float64) float64 {
+	if v.Weight_multiplier == nil {
+		return weight
+	}
+	return weight * *v.Weight_multiplier
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+)
+
+// LinkPreview is the Open Graph and Twitter card data shown when a link to
+// a community or proposal is shared.
+type LinkPreview struct {
+	Type         string          `json:"type"`
+	Title        string          `json:"title"`
+	Description  string          `json:"description"`
+	Url          string          `json:"url"`
+	Image        *string         `json:"image,omitempty"`
+	Site_name    string          `json:"siteName"`
+	Twitter_card string          `json:"twitterCard"`
+	Results      []*ChoiceResult `json:"results,omitempty"`
+	Total_votes  *int            `json:"totalVotes,omitempty"`
+	End_time     *time.Time      `json:"endTime,omitempty"`
+}
+
+type ChoiceResult struct {
+	Choice  string  `json:"choice"`
+	Type    string  `json:"type,omitempty"`
+	Votes   float64 `json:"votes"`
+	Percent float64 `json:"percent"`
+}
+
+// Breakdown lists the results of each choice in the order the proposal
+// offers them.
+func (r *ProposalResults) Breakdown(choices []s.Choice) []*ChoiceResult {
+	var total float64
+	for _, choice := range choices {
+		total += r.Results_float[choice.Choice_text]
+	}
+
+	breakdown := make([]*ChoiceResult, 0, len(choices))
+	for _, choice := range choices {
+		result := ChoiceResult{Choice: choice.Choice_text, Type: choice.Choice_type, Votes: r.Results_float[choice.Choice_text]}
+		if total > 0 {
+			result.Percent = result.Votes / total * 100
+		}
+		breakdown = append(breakdown, &result)
+	}
+	return breakdown
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+// UserProfile is keyed by address. Avatar holds the IPFS cid returned by
+// the /upload endpoint.
+type UserProfile struct {
+	Addr         string            `json:"addr"`
+	Display_name *string           `json:"displayName,omitempty"`
+	Avatar       *string           `json:"avatar,omitempty"`
+	Bio          *string           `json:"bio,omitempty"`
+	Socials      map[string]string `json:"socials"`
+	Created_at   *time.Time        `json:"createdAt,omitempty"`
+	Updated_at   *time.Time        `json:"updatedAt,omitempty"`
+
+	Stats *UserStats `json:"stats,omitempty" db:"-"`
+
+	// identity providers the address is verified with
+	Verified_by []string `json:"verifiedBy" db:"-"`
+}
+
+type UserStats struct {
+	Communities_joined int `json:"communitiesJoined"`
+	Proposals_authored int `json:"proposalsAuthored"`
+	Votes_cast         int `json:"votesCast"`
+}
+
+// ProfileSummary is the slice of a profile embedded in vote and
+// leaderboard responses.
+type ProfileSummary struct {
+	Addr         string  `json:"-"`
+	Display_name *string `json:"displayName,omitempty"`
+	Avatar       *string `json:"avatar,omitempty"`
+}
+
+type UpdateProfilePayload struct {
+	Display_name *string            `json:"displayName,omitempty" validate:"omitempty,max=64"`
+	Avatar       *string            `json:"avatar,omitempty"      validate:"omitempty,max=256"`
+	Bio          *string            `json:"bio,omitempty"         validate:"omitempty,max=2000"`
+	Socials      *map[string]string `json:"socials,omitempty"`
+	Voucher      *shared.Voucher    `json:"voucher,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
+// GetProfile loads the profile for the address. Addresses without a saved
+// profile get an empty one.
+func (p *UserProfile) GetProfile(db *s.Database) error {
+	err := pgxscan.Get(db.Context, db.Conn, p,
+		`SELECT * FROM user_profiles WHERE addr = $1`,
+		p.Addr)
+	if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		p.Socials = map[string]string{}
+		return nil
+	}
+	return err
+}
+
+func (p *UserProfile) GetStats(db *s.Database) error {
+	stats := UserStats{}
+	err := db.Conn.QueryRow(db.Context,
+		`
+		SELECT
+			(SELECT COUNT(DISTINCT community_id) FROM community_users WHERE addr = $1),
+			(SELECT COUNT(*) FROM proposals WHERE creator_addr = $1),
+			(SELECT COUNT(*) FROM votes WHERE addr = $1)
+		`, p.Addr).
+		Scan(&stats.Communities_joined, &stats.Proposals_authored, &stats.Votes_cast)
+	if err != nil {
+		return err
+	}
+	p.Stats = &stats
+	return nil
+}
+
+func (p *UserProfile) UpdateProfile(db *s.Database, payload *UpdateProfilePayload) error {
+	return db.Conn.QueryRow(db.Context,
+		`
+		INSERT INTO user_profiles(addr, display_name, avatar, bio, socials)
+		VALUES($1, $2, $3, $4, COALESCE($5::jsonb, '{}'))
+		ON CONFLICT (addr) DO UPDATE SET
+			display_name = COALESCE($2, user_profiles.display_name),
+			avatar = COALESCE($3, user_profiles.avatar),
+			bio = COALESCE($4, user_profiles.bio),
+			socials = COALESCE($5::jsonb, user_profiles.socials),
+			updated_at = (now() at time zone 'utc')
+		RETURNING display_name, avatar, bio, socials, created_at, updated_at
+		`, p.Addr, payload.Display_name, payload.Avatar, payload.Bio, payload.Socials).
+		Scan(&p.Display_name, &p.Avatar, &p.Bio, &p.Socials, &p.Created_at, &p.Updated_at)
+}
+
+// GetProfileSummaries returns the saved profile summaries of the addresses,
+// keyed by address.
+func GetProfileSummaries(db *s.Database, addrs []string) (map[string]*ProfileSummary, error) {
+	summaries := make(map[string]*ProfileSummary)
+	if len(addrs) == 0 {
+		return summaries, nil
+	}
+
+	var rows []*ProfileSummary
+	err := pgxscan.Select(db.Context, db.Conn, &rows,
+		`SELECT addr, display_name, avatar FROM user_profiles WHERE addr = ANY($1)`,
+		addrs)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+
+	for _, r := range rows {
+		summaries[r.Addr] = r
+	}
+	return summaries, nil
+}
@@ -5,6 +5,7 @@ package models
 ///////////////
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"os"
@@ -23,12 +24,16 @@ type Proposal struct {
 	Community_id         int                     `json:"communityId"`
 	Choices              []s.Choice              `json:"choices" validate:"required"`
 	Strategy             *string                 `json:"strategy,omitempty"`
+	Strategy_config      *Strategy               `json:"strategyConfig,omitempty"`
+	Strategy_version     *int                    `json:"strategyVersion,omitempty"`
 	Max_weight           *float64                `json:"maxWeight,omitempty"`
 	Min_balance          *float64                `json:"minBalance,omitempty"`
 	Creator_addr         string                  `json:"creatorAddr" validate:"required"`
 	Start_time           time.Time               `json:"startTime" validate:"required"`
 	Result               *string                 `json:"result,omitempty"`
 	End_time             time.Time               `json:"endTime" validate:"required"`
+	Start_block          *uint64                 `json:"startBlock,omitempty"`
+	End_block            *uint64                 `json:"endBlock,omitempty"`
 	Created_at           *time.Time              `json:"createdAt,omitempty"`
 	Cid                  *string                 `json:"cid,omitempty"`
 	Status               *string                 `json:"status,omitempty"`
@@ -38,8 +43,63 @@ type Proposal struct {
 	Timestamp            string                  `json:"timestamp" validate:"required"`
 	Composite_signatures *[]s.CompositeSignature `json:"compositeSignatures"`
 	Computed_status      *string                 `json:"computedStatus,omitempty"`
+	Trending_score       *float64                `json:"trendingScore,omitempty"`
 	Voucher              *shared.Voucher         `json:"voucher,omitempty"`
 	Achievements_done    bool                    `json:"achievementsDone"`
+	Review_reason        *string                 `json:"reviewReason,omitempty"`
+	Reviewed_by          *string                 `json:"reviewedBy,omitempty"`
+	Reviewed_at          *time.Time              `json:"reviewedAt,omitempty"`
+	Tags                 []string                `json:"tags"`
+	Imported_from_id     *int                    `json:"importedFromId,omitempty"`
+	List_versions        map[int]int             `json:"listVersions,omitempty"`
+	Closed_at            *time.Time              `json:"closedAt,omitempty"`
+	Version              int                     `json:"version"`
+	Updated_at           *time.Time              `json:"updatedAt,omitempty"`
+	Attachments          []*ProposalAttachment   `json:"attachments,omitempty" validate:"omitempty,dive"`
+	Snapshot_treasury    bool                    `json:"snapshotTreasury,omitempty"`
+	Treasury_snapshot    []*TreasuryBalance      `json:"treasurySnapshot,omitempty"`
+	Execution_payload    *ExecutionPayload       `json:"executionPayload,omitempty"`
+	Allow_split_votes    bool                    `json:"allowSplitVotes,omitempty"`
+	Allow_early_close    bool                    `json:"allowEarlyClose,omitempty"`
+	Quorum               *float64                `json:"quorum,omitempty"        validate:"omitempty,gt=0"`
+	Veto_threshold       *float64                `json:"vetoThreshold,omitempty" validate:"omitempty,gt=0,lte=100"`
+	// recent proposals this one looks like, when the community warns of them
+	Duplicates []*DuplicateProposal `json:"duplicates,omitempty"`
+	// the author's deposit, see ProposalDeposit
+	Deposit_tx_id            *string  `json:"depositTxId,omitempty"`
+	Deposit_amount           *float64 `json:"depositAmount,omitempty"`
+	Deposit_status           *string  `json:"depositStatus,omitempty"`
+	Deposit_settlement_tx_id *string  `json:"depositSettlementTxId,omitempty"`
+}
+
+type ReviewProposalRequestPayload struct {
+	Reason  *string    `json:"reason,omitempty"`
+	Voucher *s.Voucher `json:"voucher,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
+const (
+	ProposalPendingReview = "pending_review"
+	ProposalRejected      = "rejected"
+	ProposalPublished     = "published"
+	ProposalClosed        = "closed"
+)
+
+// number of ended proposals closed per run of the close worker
+const proposalCloseBatchSize = 50
+
+// ProposalBatchPayload lists the proposals to fetch in one request.
+type ProposalBatchPayload struct {
+	Ids []int `json:"ids" validate:"required,min=1,max=100"`
+}
+
+// ProposalWithCommunity is a proposal along with its community and the
+// strategy it is voted with, for listing proposals across communities.
+type ProposalWithCommunity struct {
+	*Proposal
+	Community     *Community `json:"community"`
+	Strategy_info *Strategy  `json:"strategyInfo,omitempty"`
 }
 
 type UpdateProposalRequestPayload struct {
@@ -56,44 +116,132 @@ var computedStatusSQL = `
 		WHEN status = 'published' AND end_time < (now() at time zone 'utc') THEN 'closed'
 		WHEN status = 'cancelled' THEN 'cancelled'
 		WHEN status = 'closed' THEN 'closed'
+		WHEN status = 'pending_review' THEN 'pending_review'
+		WHEN status = 'rejected' THEN 'rejected'
 	END as computed_status
 	`
 
+// ProposalSortColumns are the fields proposals can be sorted by.
+var ProposalSortColumns = shared.SortColumns{
+	"id":         "id",
+	"name":       "name",
+	"created_at": "created_at",
+	"start_time": "start_time",
+	"end_time":   "end_time",
+}
+
+// proposalStatusSQL selects proposals by the status they are listed under.
+var proposalStatusSQL = map[string]string{
+	"pending":        `status = 'published' AND start_time > (now() at time zone 'utc')`,
+	"active":         `status = 'published' AND start_time < (now() at time zone 'utc') AND end_time > (now() at time zone 'utc')`,
+	"closed":         `status = 'published' AND end_time < (now() at time zone 'utc')`,
+	"cancelled":      `status = 'cancelled'`,
+	"terminated":     `(status = 'cancelled' OR (status = 'published' AND end_time < (now() at time zone 'utc')))`,
+	"inprogress":     `status = 'published' AND end_time > (now() at time zone 'utc')`,
+	"pending_review": `status = 'pending_review'`,
+	"rejected":       `status = 'rejected'`,
+}
+
+// ProposalFilters narrow down the proposals of a community. Proposals match
+// any of the statuses, every tag and the rest of the filters that are set;
+// ranges include their start and exclude their end.
+type ProposalFilters struct {
+	Statuses       []string
+	Tags           []string
+	Strategy       *string
+	Creator_addr   *string
+	Created_after  *time.Time
+	Created_before *time.Time
+	Start_after    *time.Time
+	Start_before   *time.Time
+	End_after      *time.Time
+	End_before     *time.Time
+}
+
+// IncludesWithheld reports whether the filters ask for proposals held for
+// review or rejected.
+func (f ProposalFilters) IncludesWithheld() bool {
+	for _, status := range f.Statuses {
+		if status == ProposalPendingReview || status == ProposalRejected {
+			return true
+		}
+	}
+	return false
+}
+
+func (f ProposalFilters) Validate() error {
+	for _, status := range f.Statuses {
+		if _, ok := proposalStatusSQL[status]; !ok {
+			return fmt.Errorf("Unknown proposal status %q.", status)
+		}
+	}
+	for _, r := range [][2]*time.Time{
+		{f.Created_after, f.Created_before},
+		{f.Start_after, f.Start_before},
+		{f.End_after, f.End_before},
+	} {
+		if r[0] != nil && r[1] != nil && !r[0].Before(*r[1]) {
+			return errors.New("Date ranges must end after they start.")
+		}
+	}
+	return nil
+}
+
 func GetProposalsForCommunity(
 	db *s.Database,
 	communityId int,
-	status string,
+	filters ProposalFilters,
 	params shared.PageParams,
 ) ([]*Proposal, int, error) {
 	var proposals []*Proposal
 	var err error
 
-	// Get Proposals
-	sql := fmt.Sprintf(`SELECT *, %s FROM proposals WHERE community_id = $3`, computedStatusSQL)
-	statusFilter := ""
+	// Get Proposals, including those the community co-hosts
+	whereSql := ` (community_id = $1 OR id IN (SELECT proposal_id FROM proposal_cohosts WHERE community_id = $1))`
 
 	// Generate SQL based on computed status
-	// status: { pending | active | closed | cancelled }
-	switch status {
-	case "pending":
-		statusFilter = ` AND status = 'published' AND start_time > (now() at time zone 'utc')`
-	case "active":
-		statusFilter = ` AND status = 'published' AND start_time < (now() at time zone 'utc') AND end_time > (now() at time zone 'utc')`
-	case "closed":
-		statusFilter = ` AND status = 'published' AND end_time < (now() at time zone 'utc')`
-	case "cancelled":
-		statusFilter = ` AND status = 'cancelled'`
-	case "terminated":
-		statusFilter = ` AND (status = 'cancelled' OR (status = 'published' AND end_time < (now() at time zone 'utc')))`
-	case "inprogress":
-		statusFilter = ` AND status = 'published' AND end_time > (now() at time zone 'utc')`
+	// status: { pending | active | closed | cancelled | ... }
+	if len(filters.Statuses) == 0 {
+		whereSql += ` AND status NOT IN ('pending_review', 'rejected')`
+	} else {
+		statuses := make([]string, len(filters.Statuses))
+		for i, status := range filters.Statuses {
+			statuses[i] = "(" + proposalStatusSQL[status] + ")"
+		}
+		whereSql += ` AND (` + strings.Join(statuses, " OR ") + `)`
 	}
 
-	orderBySql := fmt.Sprintf(` ORDER BY created_at %s`, params.Order)
-	limitOffsetSql := ` LIMIT $1 OFFSET $2`
-	sql = sql + statusFilter + orderBySql + limitOffsetSql
+	// proposals must carry every requested tag
+	tags := filters.Tags
+	if len(tags) == 0 {
+		tags = nil
+	}
+
+	whereSql += `
+		AND ($2::text[] IS NULL OR tags @> $2)
+		AND ($3::text IS NULL OR strategy = $3)
+		AND ($4::text IS NULL OR creator_addr = $4)
+		AND ($5::timestamp IS NULL OR created_at >= $5) AND ($6::timestamp IS NULL OR created_at < $6)
+		AND ($7::timestamp IS NULL OR start_time >= $7) AND ($8::timestamp IS NULL OR start_time < $8)
+		AND ($9::timestamp IS NULL OR end_time >= $9) AND ($10::timestamp IS NULL OR end_time < $10)`
+	args := []interface{}{
+		communityId,
+		tags,
+		filters.Strategy,
+		filters.Creator_addr,
+		filters.Created_after,
+		filters.Created_before,
+		filters.Start_after,
+		filters.Start_before,
+		filters.End_after,
+		filters.End_before,
+	}
+
+	orderBySql := ProposalSortColumns.OrderBy(params.Sort, fmt.Sprintf(` ORDER BY created_at %s`, params.Order))
+	limitOffsetSql := ` LIMIT $11 OFFSET $12`
+	sql := fmt.Sprintf(`SELECT *, %s FROM proposals WHERE`, computedStatusSQL) + whereSql + orderBySql + limitOffsetSql
 
-	err = pgxscan.Select(db.Context, db.Conn, &proposals, sql, params.Count, params.Start, communityId)
+	err = pgxscan.Select(db.Context, db.Conn, &proposals, sql, append(args, params.Count, params.Start)...)
 
 	// If we get pgx.ErrNoRows, just return an empty array
 	// and obfuscate error
@@ -105,8 +253,8 @@ func GetProposalsForCommunity(
 
 	// Get total number of proposals
 	var totalRecords int
-	countSql := `SELECT COUNT(*) FROM proposals WHERE community_id = $1` + statusFilter
-	_ = db.Conn.QueryRow(db.Context, countSql, communityId).Scan(&totalRecords)
+	countSql := `SELECT COUNT(*) FROM proposals WHERE` + whereSql
+	_ = db.Conn.QueryRow(db.Context, countSql, args...).Scan(&totalRecords)
 
 	return proposals, totalRecords, nil
 }
@@ -121,7 +269,31 @@ func (p *Proposal) GetProposalById(db *s.Database) error {
 	return pgxscan.Get(db.Context, db.Conn, p, sql, p.ID)
 }
 
+// GetProposalsByIds returns those of the proposals that exist, in no
+// particular order.
+func GetProposalsByIds(db *s.Database, ids []int) ([]*Proposal, error) {
+	proposals := []*Proposal{}
+	sql := fmt.Sprintf(`
+	SELECT p.*, %s, count(v.id) as total_votes from proposals as p
+	left join votes as v on v.proposal_id = p.id
+	WHERE p.id = ANY($1)
+	GROUP BY p.id`, computedStatusSQL)
+	err := pgxscan.Select(db.Context, db.Conn, &proposals, sql, ids)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	return proposals, nil
+}
+
 func (p *Proposal) CreateProposal(db *s.Database) error {
+	p.NormalizeTimes()
+
+	// unbound proposals resolve lists by their start time
+	var listVersions interface{}
+	if len(p.List_versions) > 0 {
+		listVersions = p.List_versions
+	}
+
 	err := db.Conn.QueryRow(db.Context,
 		`
 	INSERT INTO proposals(community_id, 
@@ -138,9 +310,23 @@ func (p *Proposal) CreateProposal(db *s.Database) error {
 	block_height, 
 	cid, 
 	composite_signatures,
-	voucher
+	voucher,
+	tags,
+	list_versions,
+	strategy_config,
+	strategy_version,
+	start_block,
+	end_block,
+	allow_split_votes,
+	allow_early_close,
+	quorum,
+	veto_threshold,
+	deposit_tx_id,
+	deposit_amount,
+	deposit_status
 	)
-	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, COALESCE($16::text[], '{}'), $17, $18, $19,
+		$20, $21, $22, $23, $24, $25, $26, $27, $28)
 	RETURNING id, created_at
 	`,
 		p.Community_id,
@@ -158,21 +344,39 @@ func (p *Proposal) CreateProposal(db *s.Database) error {
 		p.Cid,
 		p.Composite_signatures,
 		p.Voucher,
+		p.Tags,
+		listVersions,
+		p.Strategy_config,
+		p.Strategy_version,
+		p.Start_block,
+		p.End_block,
+		p.Allow_split_votes,
+		p.Allow_early_close,
+		p.Quorum,
+		p.Veto_threshold,
+		p.Deposit_tx_id,
+		p.Deposit_amount,
+		p.Deposit_status,
 	).Scan(&p.ID, &p.Created_at)
 
 	return err
 }
 
+// UpdateProposal applies the status change only if the proposal is still at
+// p.Version, returning ErrStaleVersion otherwise.
 func (p *Proposal) UpdateProposal(db *s.Database) error {
-	_, err := db.Conn.Exec(db.Context, `
+	tag, err := db.Conn.Exec(db.Context, `
 		UPDATE proposals
-		SET status = $1
-		WHERE id = $2
-	`, p.Status, p.ID)
+		SET status = $1, version = version + 1, updated_at = (now() at time zone 'utc')
+		WHERE id = $2 AND version = $3
+	`, p.Status, p.ID, p.Version)
 
 	if err != nil {
 		return err
 	}
+	if tag.RowsAffected() == 0 {
+		return ErrStaleVersion
+	}
 
 	if *p.Status == "cancelled" {
 		err := handleCancelledProposal(db, p.ID)
@@ -185,9 +389,166 @@ func (p *Proposal) UpdateProposal(db *s.Database) error {
 	return err
 }
 
+// GetProposalsWithBlockWindows returns the proposals in review or published
+// that open or close at a block height.
+func GetProposalsWithBlockWindows(db *s.Database) ([]*Proposal, error) {
+	var proposals []*Proposal
+	err := pgxscan.Select(db.Context, db.Conn, &proposals,
+		fmt.Sprintf(`
+		SELECT *, %s FROM proposals
+		WHERE status IN ('pending_review', 'published')
+		AND (start_block IS NOT NULL OR end_block IS NOT NULL)
+		`, computedStatusSQL))
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	return proposals, nil
+}
+
+// UpdateProposalWindow sets the times a proposal with a block window is
+// estimated to open and close at.
+func (p *Proposal) UpdateProposalWindow(db *s.Database) error {
+	_, err := db.Conn.Exec(db.Context,
+		`
+		UPDATE proposals
+		SET start_time = $2, end_time = $3, updated_at = (now() at time zone 'utc')
+		WHERE id = $1 AND status IN ('pending_review', 'published')
+		`, p.ID, p.Start_time, p.End_time)
+	return err
+}
+
+// GetProposalsPendingClose returns published proposals past their end time,
+// oldest first.
+func GetProposalsPendingClose(db *s.Database) ([]*Proposal, error) {
+	var proposals []*Proposal
+	err := pgxscan.Select(db.Context, db.Conn, &proposals,
+		fmt.Sprintf(`
+		SELECT *, %s FROM proposals
+		WHERE status = 'published' AND end_time <= (now() at time zone 'utc')
+		ORDER BY end_time ASC
+		LIMIT $1
+		`, computedStatusSQL), proposalCloseBatchSize)
+
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	return proposals, nil
+}
+
+// CloseProposal moves an ended proposal to closed and stores its final
+// results in the same transaction. It reports false when the proposal was
+// already closed, so the close side effects only ever run once.
+func (p *Proposal) CloseProposal(db *s.Database, results ProposalResults) (bool, error) {
+	return p.closeProposal(db, results, `
+		UPDATE proposals
+		SET status = 'closed', closed_at = (now() at time zone 'utc'), version = version + 1, updated_at = (now() at time zone 'utc')
+		WHERE id = $1 AND status = 'published' AND end_time <= (now() at time zone 'utc')
+		`)
+}
+
+// CloseProposalEarly closes a proposal that allows it before its end time,
+// which becomes the time it closed, recording the trigger with the results.
+func (p *Proposal) CloseProposalEarly(db *s.Database, results ProposalResults, early EarlyClose) (bool, error) {
+	results.Early_close = &early
+	return p.closeProposal(db, results, `
+		UPDATE proposals
+		SET status = 'closed', closed_at = (now() at time zone 'utc'), end_time = (now() at time zone 'utc'),
+			version = version + 1, updated_at = (now() at time zone 'utc')
+		WHERE id = $1 AND status = 'published' AND allow_early_close AND end_time > (now() at time zone 'utc')
+		`)
+}
+
+func (p *Proposal) closeProposal(db *s.Database, results ProposalResults, closeSql string) (bool, error) {
+	closed := false
+	err := db.WithTx(func(tx *s.Database) error {
+		tag, err := tx.Conn.Exec(tx.Context, closeSql, p.ID)
+		if err != nil || tag.RowsAffected() == 0 {
+			return err
+		}
+
+		if _, err := tx.Conn.Exec(tx.Context,
+			`
+			INSERT INTO proposal_results(proposal_id, results, results_float, cid, votes_counted, early_close)
+			VALUES($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (proposal_id) DO UPDATE
+			SET results = EXCLUDED.results, results_float = EXCLUDED.results_float,
+				cid = EXCLUDED.cid, votes_counted = EXCLUDED.votes_counted,
+				early_close = EXCLUDED.early_close, updated_at = (now() at time zone 'utc')
+			`, p.ID, results.Results, results.Results_float, results.Cid, results.Votes_counted,
+			results.Early_close); err != nil {
+			return err
+		}
+
+		closed = true
+		return nil
+	})
+	if err != nil || !closed {
+		return false, err
+	}
+
+	return true, p.GetProposalById(db)
+}
+
+// ReviewProposal moves a proposal out of the moderation queue. Approved
+// proposals without a snapshot block take the one given; a snapshot already
+// set is never replaced.
+func (p *Proposal) ReviewProposal(
+	db *s.Database,
+	status, reviewer string,
+	reason *string,
+	snapshot *uint64,
+) error {
+	_, err := db.Conn.Exec(db.Context, `
+		UPDATE proposals
+		SET status = $1, reviewed_by = $2, review_reason = $3, reviewed_at = (now() at time zone 'utc'),
+			block_height = COALESCE(block_height, $5),
+			version = version + 1, updated_at = (now() at time zone 'utc')
+		WHERE id = $4 AND status = 'pending_review'
+	`, status, reviewer, reason, p.ID, snapshot)
+	if err != nil {
+		return err
+	}
+
+	return p.GetProposalById(db)
+}
+
+// NormalizeTimes converts the start and end times to UTC. Timestamp columns
+// drop the offset of the times written to them, so times must be normalized
+// before they are stored.
+func (p *Proposal) NormalizeTimes() {
+	p.Start_time = p.Start_time.UTC()
+	p.End_time = p.End_time.UTC()
+}
+
+func (p *Proposal) IsAwaitingReview() bool {
+	return p.Status != nil && *p.Status == ProposalPendingReview
+}
+
+// IsWithheld reports whether the proposal is held for review or was
+// rejected, and so is only shown to its author and moderators.
+func (p *Proposal) IsWithheld() bool {
+	return p.Status != nil && (*p.Status == ProposalPendingReview || *p.Status == ProposalRejected)
+}
+
 func (p *Proposal) IsLive() bool {
-	now := time.Now().UTC()
-	return now.After(p.Start_time) && now.Before(p.End_time)
+	return p.IsLiveAt(time.Now().UTC())
+}
+
+// IsLiveAt tells whether the proposal was open for votes at the time.
+func (p *Proposal) IsLiveAt(t time.Time) bool {
+	return t.After(p.Start_time) && t.Before(p.End_time)
+}
+
+// IsLiveAtBlock tells whether the block is within the proposal's block
+// window. Proposals without one are live at every block.
+func (p *Proposal) IsLiveAtBlock(height uint64) bool {
+	return (p.Start_block == nil || height >= *p.Start_block) && (p.End_block == nil || height < *p.End_block)
+}
+
+// HasBlockWindow tells whether the proposal opens or closes at a block
+// height, its times then being estimates.
+func (p *Proposal) HasBlockWindow() bool {
+	return p.Start_block != nil || p.End_block != nil
 }
 
 // Returns an error if the account's balance is insufficient to cast
@@ -297,3 +658,56 @@ func handleCancelledProposal(db *s.Database, proposalId int) error {
 
 	return nil
 }
+
+// SetCid stores the CID the proposal was pinned under.
+func (p *Proposal) SetCid(db *s.Database, cid string) error {
+	_, err := db.Conn.Exec(db.Context, `UPDATE proposals SET cid = $2, updated_at = (now() at time zone 'utc') WHERE id = $1`, p.ID, cid)
+	return err
+}
+
+// BoundStrategy returns the strategy the proposal was created with. Proposals
+// that were never bound to one use the community's strategy of that name.
+func (p *Proposal) BoundStrategy(c *Community) (Strategy, error) {
+	if p.Strategy_config != nil {
+		return *p.Strategy_config, nil
+	}
+	if c.Strategies == nil || p.Strategy == nil {
+		return Strategy{}, fmt.Errorf("Community does not have strategy available")
+	}
+	return MatchStrategyByProposal(*c.Strategies, *p.Strategy)
+}
+
+// proposals in review, upcoming or active
+const openProposalsFilter = `(status = 'pending_review' OR (status = 'published' AND end_time > (now() at time zone 'utc')))`
+
+// GetStrategiesInUse returns the names of the strategies that proposals of
+// the community still to be voted on or being voted on are created with.
+func GetStrategiesInUse(db *s.Database, communityId int) ([]string, error) {
+	names := []string{}
+	err := pgxscan.Select(db.Context, db.Conn, &names,
+		`
+		SELECT DISTINCT strategy FROM proposals
+		WHERE community_id = $1 AND strategy IS NOT NULL
+		AND `+openProposalsFilter, communityId)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	return names, nil
+}
+
+// GetOpenProposalsUsingStrategies returns the proposals of the community in
+// review, upcoming or active that use any of the strategies.
+func GetOpenProposalsUsingStrategies(db *s.Database, communityId int, strategies []string) ([]*Proposal, error) {
+	proposals := []*Proposal{}
+	err := pgxscan.Select(db.Context, db.Conn, &proposals,
+		`
+		SELECT * FROM proposals
+		WHERE community_id = $1 AND strategy = ANY($2)
+		AND `+openProposalsFilter+`
+		ORDER BY start_time
+		`, communityId, strategies)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	return proposals, nil
+}
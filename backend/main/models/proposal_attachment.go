@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+// ProposalAttachment is an uploaded file attached to a proposal. Public
+// attachments are pinned to IPFS before the proposal, so the pinned
+// proposal references them by CID; private ones are only identified by
+// their content hash, since pinning would publish them.
+type ProposalAttachment struct {
+	ID          int       `json:"id"`
+	Proposal_id int       `json:"proposalId"`
+	Upload_id   int       `json:"uploadId" validate:"required"`
+	Name        string    `json:"name" validate:"required,max=256"`
+	Position    int       `json:"position"`
+	Created_at  time.Time `json:"createdAt"`
+
+	Content_hash string  `json:"contentHash"`
+	Content_type string  `json:"contentType"`
+	Size         int64   `json:"size"`
+	Private      bool    `json:"private"`
+	Cid          *string `json:"cid,omitempty"`
+	Url          *string `json:"url,omitempty"`
+}
+
+const proposalAttachmentsSQL = `
+	SELECT a.*, u.content_hash, u.content_type, u.size, u.private, u.cid,
+	CASE WHEN u.private THEN NULL ELSE u.url END AS url
+	FROM proposal_attachments a
+	JOIN uploads u ON u.id = a.upload_id
+	WHERE a.proposal_id = $1
+	ORDER BY a.position
+`
+
+func GetProposalAttachments(db *s.Database, proposalId int) ([]*ProposalAttachment, error) {
+	attachments := []*ProposalAttachment{}
+	err := pgxscan.Select(db.Context, db.Conn, &attachments, proposalAttachmentsSQL, proposalId)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+func GetProposalAttachmentsPage(db *s.Database, proposalId int, pageParams s.PageParams) ([]*ProposalAttachment, int, error) {
+	attachments := []*ProposalAttachment{}
+	err := pgxscan.Select(db.Context, db.Conn, &attachments,
+		proposalAttachmentsSQL+` LIMIT $2 OFFSET $3`,
+		proposalId, pageParams.Count, pageParams.Start)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, 0, err
+	}
+
+	var totalRecords int
+	countSql := `SELECT COUNT(*) FROM proposal_attachments WHERE proposal_id = $1`
+	_ = db.Conn.QueryRow(db.Context, countSql, proposalId).Scan(&totalRecords)
+
+	return attachments, totalRecords, nil
+}
+
+// AddProposalAttachments attaches files in the order given.
+func AddProposalAttachments(db *s.Database, proposalId int, attachments []*ProposalAttachment) error {
+	for i, a := range attachments {
+		a.Proposal_id = proposalId
+		a.Position = i
+		err := db.Conn.QueryRow(db.Context,
+			`
+			INSERT INTO proposal_attachments(proposal_id, upload_id, name, position)
+			VALUES($1, $2, $3, $4)
+			RETURNING id, created_at
+			`, a.Proposal_id, a.Upload_id, a.Name, a.Position).Scan(&a.ID, &a.Created_at)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
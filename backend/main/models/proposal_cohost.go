@@ -0,0 +1,110 @@
+package models
+
+import (
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+// ProposalCohost is a community that hosts a proposal alongside the
+// community that created it, weighing its votes with a strategy of its own.
+type ProposalCohost struct {
+	Proposal_id  int        `json:"proposalId"`
+	Community_id int        `json:"communityId"`
+	Strategy     string     `json:"strategy"`
+	Created_at   *time.Time `json:"createdAt,omitempty"`
+}
+
+type ProposalCohostPayload struct {
+	Community_id int        `json:"communityId" validate:"required"`
+	Strategy     string     `json:"strategy"    validate:"required"`
+	Voucher      *s.Voucher `json:"voucher,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
+// CommunityResults are a proposal's results weighed with the strategy of
+// one of its hosts.
+type CommunityResults struct {
+	Community_id  int                `json:"communityId"`
+	Strategy      string             `json:"strategy"`
+	Results       map[string]int     `json:"results"`
+	Results_float map[string]float64 `json:"resultsFloat"`
+}
+
+// CohostedResults report a proposal's results for each of its hosts, and
+// combined with every host having an equal say: the combined share of a
+// choice is the average of its shares across the hosts that counted votes.
+type CohostedResults struct {
+	Proposal_id int                 `json:"proposalId"`
+	Communities []*CommunityResults `json:"communities"`
+	Combined    map[string]float64  `json:"combined"`
+}
+
+func GetCohostsForProposal(db *s.Database, proposalId int) ([]*ProposalCohost, error) {
+	var cohosts []*ProposalCohost
+	err := pgxscan.Select(db.Context, db.Conn, &cohosts,
+		`SELECT * FROM proposal_cohosts WHERE proposal_id = $1 ORDER BY created_at ASC`,
+		proposalId)
+
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	} else if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return []*ProposalCohost{}, nil
+	}
+	return cohosts, nil
+}
+
+func (c *ProposalCohost) GetCohost(db *s.Database) error {
+	return pgxscan.Get(db.Context, db.Conn, c,
+		`SELECT * FROM proposal_cohosts WHERE proposal_id = $1 AND community_id = $2`,
+		c.Proposal_id, c.Community_id)
+}
+
+func (c *ProposalCohost) CreateCohost(db *s.Database) error {
+	return db.Conn.QueryRow(db.Context,
+		`
+		INSERT INTO proposal_cohosts(proposal_id, community_id, strategy)
+		VALUES($1, $2, $3)
+		RETURNING created_at
+		`, c.Proposal_id, c.Community_id, c.Strategy).
+		Scan(&c.Created_at)
+}
+
+func (c *ProposalCohost) DeleteCohost(db *s.Database) error {
+	_, err := db.Conn.Exec(db.Context,
+		`DELETE FROM proposal_cohosts WHERE proposal_id = $1 AND community_id = $2`,
+		c.Proposal_id, c.Community_id)
+	return err
+}
+
+// Combine averages each choice's share of the vote across the hosts' results.
+func (r *CohostedResults) Combine(choices []s.Choice) {
+	r.Combined = make(map[string]float64, len(choices))
+	for _, choice := range choices {
+		r.Combined[choice.Choice_text] = 0
+	}
+
+	counted := 0
+	for _, c := range r.Communities {
+		var total float64
+		for _, choice := range choices {
+			total += c.Results_float[choice.Choice_text]
+		}
+		if total == 0 {
+			continue
+		}
+		counted++
+		for _, choice := range choices {
+			r.Combined[choice.Choice_text] += c.Results_float[choice.Choice_text] / total * 100
+		}
+	}
+	if counted == 0 {
+		return
+	}
+	for choice := range r.Combined {
+		r.Combined[choice] /= float64(counted)
+	}
+}
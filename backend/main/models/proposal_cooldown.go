@@ -0,0 +1,68 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+const defaultProposalCooldownWindow = 7 * 24 * time.Hour
+
+// ProposalCooldown limits authors to Proposal_cooldown_limit proposals in a
+// community every Proposal_cooldown_window seconds, a week unless set. nil
+// or zero means no limit.
+type ProposalCooldown struct {
+	Proposal_cooldown_limit  *int `json:"proposalCooldownLimit,omitempty"`
+	Proposal_cooldown_window *int `json:"proposalCooldownWindow,omitempty"`
+}
+
+func (c ProposalCooldown) IsZero() bool {
+	return c.Proposal_cooldown_limit == nil || *c.Proposal_cooldown_limit == 0
+}
+
+func (c ProposalCooldown) Validate() error {
+	if c.Proposal_cooldown_limit != nil && *c.Proposal_cooldown_limit < 0 {
+		return errors.New("Proposal cooldown limit cannot be negative.")
+	}
+	if seconds(c.Proposal_cooldown_window) < 0 {
+		return errors.New("Proposal cooldown window cannot be negative.")
+	}
+	return nil
+}
+
+func (c ProposalCooldown) Window() time.Duration {
+	if window := seconds(c.Proposal_cooldown_window); window > 0 {
+		return window
+	}
+	return defaultProposalCooldownWindow
+}
+
+// NextProposalAt returns when addr may next create a proposal in the
+// community, or nil when it may now. Every proposal of the window counts,
+// whatever became of it, so rejecting or cancelling one frees no slot.
+func (c ProposalCooldown) NextProposalAt(db *s.Database, communityId int, addr string, now time.Time) (*time.Time, error) {
+	if c.IsZero() {
+		return nil, nil
+	}
+	limit, window := *c.Proposal_cooldown_limit, c.Window()
+
+	var created []time.Time
+	err := pgxscan.Select(db.Context, db.Conn, &created,
+		`
+		SELECT created_at FROM proposals
+		WHERE community_id = $1 AND creator_addr = $2 AND created_at > $3
+		ORDER BY created_at DESC
+		LIMIT $4
+		`, communityId, addr, now.Add(-window), limit)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	if len(created) < limit {
+		return nil, nil
+	}
+	next := created[limit-1].Add(window)
+	return &next, nil
+}
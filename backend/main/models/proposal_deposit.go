@@ -0,0 +1,139 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+// Where a proposal's deposit stands. It is locked once the author's
+// transaction is verified, due back or due to be slashed once the proposal
+// ends, and settled once the escrow's transaction is verified.
+const (
+	DepositLocked    = "locked"
+	DepositRefundDue = "refund_due"
+	DepositSlashDue  = "slash_due"
+	DepositRefunded  = "refunded"
+	DepositSlashed   = "slashed"
+)
+
+var flowAddrPattern = regexp.MustCompile(`^0x[0-9a-f]{16}$`)
+
+// ProposalDeposit is the bond a community asks of authors, paid in its
+// token to Deposit_escrow_addr before a proposal is published. It is
+// refunded when the proposal reaches quorum and slashed otherwise, to
+// Deposit_slash_addr when set. nil means no deposit.
+type ProposalDeposit struct {
+	Deposit_amount      *float64 `json:"depositAmount,omitempty"`
+	Deposit_escrow_addr *string  `json:"depositEscrowAddr,omitempty"`
+	Deposit_slash_addr  *string  `json:"depositSlashAddr,omitempty"`
+}
+
+func (d ProposalDeposit) IsZero() bool {
+	return d.Deposit_amount == nil || *d.Deposit_amount == 0
+}
+
+func (d ProposalDeposit) Validate() error {
+	if d.Deposit_amount != nil && *d.Deposit_amount < 0 {
+		return errors.New("Deposit amount cannot be negative.")
+	}
+	for _, addr := range []*string{d.Deposit_escrow_addr, d.Deposit_slash_addr} {
+		if addr != nil && !flowAddrPattern.MatchString(strings.ToLower(*addr)) {
+			return fmt.Errorf("Deposit address %q is not a Flow address.", *addr)
+		}
+	}
+	return nil
+}
+
+type DepositSettlementPayload struct {
+	Tx_id   string          `json:"txId"    validate:"required"`
+	Voucher *shared.Voucher `json:"voucher,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
+// DepositTxUsed reports whether a transaction, its ID normalized, already
+// paid the deposit of a proposal, or settled one.
+func DepositTxUsed(db *s.Database, txId string) (bool, error) {
+	var used bool
+	err := db.Conn.QueryRow(db.Context,
+		`
+		SELECT EXISTS(
+			SELECT 1 FROM proposals
+			WHERE deposit_tx_id = $1 OR deposit_settlement_tx_id = $1
+		)
+		`, txId).Scan(&used)
+	return used, err
+}
+
+// MarkDepositDue moves a locked deposit to refund_due or slash_due. A
+// deposit that isn't locked is left as it is.
+func (p *Proposal) MarkDepositDue(db *s.Database, status string) error {
+	err := db.Conn.QueryRow(db.Context,
+		`
+		UPDATE proposals SET deposit_status = $2
+		WHERE id = $1 AND deposit_status = 'locked'
+		RETURNING deposit_status
+		`, p.ID, status).Scan(&p.Deposit_status)
+	if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return nil
+	}
+	return err
+}
+
+// SettleDeposit records the transaction that refunded or slashed a due
+// deposit.
+func (p *Proposal) SettleDeposit(db *s.Database, txId string) error {
+	err := db.Conn.QueryRow(db.Context,
+		`
+		UPDATE proposals
+		SET deposit_status = CASE deposit_status WHEN 'refund_due' THEN 'refunded' ELSE 'slashed' END,
+			deposit_settlement_tx_id = $2
+		WHERE id = $1 AND deposit_status IN ('refund_due', 'slash_due')
+		RETURNING deposit_status, deposit_settlement_tx_id
+		`, p.ID, txId).Scan(&p.Deposit_status, &p.Deposit_settlement_tx_id)
+	if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return errors.New("Deposit was settled already.")
+	}
+	return err
+}
+
+// GetProposalDeposits lists the community's proposals with a deposit,
+// those in the given status only unless it is empty, oldest first so the
+// longest due are settled first.
+func GetProposalDeposits(
+	db *s.Database,
+	communityId int,
+	status string,
+	pageParams shared.PageParams,
+) ([]*Proposal, int, error) {
+	var proposals []*Proposal
+	err := pgxscan.Select(db.Context, db.Conn, &proposals,
+		fmt.Sprintf(`
+		SELECT *, %s FROM proposals
+		WHERE community_id = $1 AND deposit_status IS NOT NULL AND ($2 = '' OR deposit_status = $2)
+		ORDER BY end_time ASC, id ASC
+		LIMIT $3 OFFSET $4
+		`, computedStatusSQL), communityId, status, pageParams.Count, pageParams.Start)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, 0, err
+	}
+	if proposals == nil {
+		proposals = []*Proposal{}
+	}
+
+	var totalRecords int
+	countSql := `
+		SELECT COUNT(*) FROM proposals
+		WHERE community_id = $1 AND deposit_status IS NOT NULL AND ($2 = '' OR deposit_status = $2)
+	`
+	_ = db.Conn.QueryRow(db.Context, countSql, communityId, status).Scan(&totalRecords)
+
+	return proposals, totalRecords, nil
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+)
+
+const (
+	// abstain votes count toward quorum, never toward the outcome
+	ChoiceAbstain = "abstain"
+	// veto votes count against every choice, and invalidate the proposal
+	// once they reach its veto threshold
+	ChoiceVeto = "veto"
+)
+
+var CHOICE_TYPES = []string{ChoiceAbstain, ChoiceVeto}
+
+// DefaultVetoThreshold is the percent of the weight deciding the outcome
+// that vetoes a proposal with a veto choice and no threshold of its own.
+const DefaultVetoThreshold = 33.4
+
+// ProposalOutcome reports how the weight cast on a proposal decides it.
+// Outcome_weight is the weight cast for regular choices and vetoes, which
+// abstentions are left out of.
+type ProposalOutcome struct {
+	Total_weight   float64  `json:"totalWeight"`
+	Abstain_weight float64  `json:"abstainWeight"`
+	Veto_weight    float64  `json:"vetoWeight"`
+	Outcome_weight float64  `json:"outcomeWeight"`
+	Veto_percent   float64  `json:"vetoPercent"`
+	Veto_threshold *float64 `json:"vetoThreshold,omitempty"`
+	Vetoed         bool     `json:"vetoed"`
+	Quorum         *float64 `json:"quorum,omitempty"`
+	Quorum_met     bool     `json:"quorumMet"`
+	Winning_choice *string  `json:"winningChoice,omitempty"`
+}
+
+// ValidateChoiceTypes checks a proposal offers at least one regular choice,
+// and at most one abstain and one veto choice.
+func ValidateChoiceTypes(choices []s.Choice) error {
+	regular := 0
+	seen := map[string]bool{}
+	for _, choice := range choices {
+		switch choice.Choice_type {
+		case "":
+			regular++
+		case ChoiceAbstain, ChoiceVeto:
+			if seen[choice.Choice_type] {
+				return fmt.Errorf("A proposal can only have one %s choice.", choice.Choice_type)
+			}
+			seen[choice.Choice_type] = true
+		default:
+			return fmt.Errorf("Choice type %q is not one of %v.", choice.Choice_type, CHOICE_TYPES)
+		}
+	}
+	if regular == 0 {
+		return errors.New("A proposal needs at least one choice that isn't abstain or veto.")
+	}
+	return nil
+}
+
+// VetoThreshold is the veto threshold of a proposal with a veto choice.
+func (p *Proposal) VetoThreshold() *float64 {
+	for _, choice := range p.Choices {
+		if choice.Choice_type == ChoiceVeto {
+			if p.Veto_threshold != nil {
+				return p.Veto_threshold
+			}
+			threshold := DefaultVetoThreshold
+			return &threshold
+		}
+	}
+	return nil
+}
+
+// DecideOutcome works out the outcome of the proposal from the results. The
+// regular choice with the most weight wins, unless it ties with another or
+// with the vetoes, the quorum isn't met or the proposal is vetoed.
+func (r *ProposalResults) DecideOutcome(p Proposal) ProposalOutcome {
+	o := ProposalOutcome{Quorum: p.Quorum, Veto_threshold: p.VetoThreshold()}
+
+	var leading *string
+	var first, second float64
+	for i, choice := range p.Choices {
+		weight := r.Results_float[choice.Choice_text]
+		o.Total_weight += weight
+		switch choice.Choice_type {
+		case ChoiceAbstain:
+			o.Abstain_weight += weight
+			continue
+		case ChoiceVeto:
+			o.Veto_weight += weight
+			continue
+		}
+		if leading == nil || weight > first {
+			leading, first, second = &p.Choices[i].Choice_text, weight, first
+		} else if weight > second {
+			second = weight
+		}
+	}
+
+	o.Outcome_weight = o.Total_weight - o.Abstain_weight
+	if o.Outcome_weight > 0 {
+		o.Veto_percent = o.Veto_weight / o.Outcome_weight * 100
+	}
+	o.Vetoed = o.Veto_threshold != nil && o.Veto_weight > 0 && o.Veto_percent >= *o.Veto_threshold
+	o.Quorum_met = p.Quorum == nil || o.Total_weight >= *p.Quorum
+
+	if o.Quorum_met && !o.Vetoed && leading != nil && first > second && first > o.Veto_weight {
+		o.Winning_choice = leading
+	}
+	return o
+}
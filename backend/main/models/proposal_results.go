@@ -1,19 +1,28 @@
 package models
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"time"
 
 	s "github.com/DapperCollectives/CAST/backend/main/shared"
 	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
 )
 
 type ProposalResults struct {
 	Proposal_id       int                `json:"proposalId" validate:"required"`
-	Results       	  map[string]int     `json:"results" validate:"required"`
-	Results_float 	   map[string]float64  `json:"resultsFloat" validate:"required"`
+	Results           map[string]int     `json:"results" validate:"required"`
+	Results_float     map[string]float64 `json:"resultsFloat" validate:"required"`
 	Updated_at        time.Time          `json:"updatedAt" validate:"required"`
-	Cid           	  *string            `json:"cid,omitempty"`
+	Cid               *string            `json:"cid,omitempty"`
 	Achievements_done bool               `json:"achievementsDone"`
+	Votes_counted     int                `json:"-"`
+	Early_close       *EarlyClose        `json:"earlyClose,omitempty"`
+	Outcome           *ProposalOutcome   `json:"outcome,omitempty" db:"-"`
+	// weight per unit of float weight the strategy tallies with
+	scale float64
 }
 
 func NewProposalResults(id int, choices []s.Choice) *ProposalResults {
@@ -30,6 +39,26 @@ func NewProposalResults(id int, choices []s.Choice) *ProposalResults {
 	return p
 }
 
+// AddVote adds the weight of a vote to the choices it was cast for, split
+// by its shares once modified. Shares are added to Results_float, and
+// Results are its totals rounded, so that shares of a vote smaller than
+// one aren't lost.
+func (r *ProposalResults) AddVote(v *Vote, weight, floatWeight float64) {
+	weight, floatWeight = v.ModifyWeight(weight), v.ModifyWeight(floatWeight)
+	if floatWeight == 0 {
+		return
+	}
+	r.scale = weight / floatWeight
+	for choice, share := range v.Shares() {
+		r.Results_float[choice] += floatWeight * share
+		r.Results[choice] = r.roundedResult(r.Results_float[choice])
+	}
+}
+
+func (r *ProposalResults) roundedResult(total float64) int {
+	return int(math.Round(total * r.scale))
+}
+
 func (r *ProposalResults) GetLatestProposalResultsById(db *s.Database) error {
 	return pgxscan.Get(db.Context, db.Conn, r,
 		`
@@ -39,3 +68,118 @@ func (r *ProposalResults) GetLatestProposalResultsById(db *s.Database) error {
 		LIMIT 1
 		`, r.Proposal_id)
 }
+
+// AddVoteToProposalResults adds the weight of a single vote, tallied into
+// delta, to the stored results of its proposal. Proposals whose results
+// were never stored are left for a recount to pick up.
+func AddVoteToProposalResults(db *s.Database, delta ProposalResults) error {
+	var stored ProposalResults
+	err := pgxscan.Get(db.Context, db.Conn, &stored,
+		`SELECT * FROM proposal_results WHERE proposal_id = $1 FOR UPDATE`,
+		delta.Proposal_id,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if stored.Results == nil {
+		stored.Results = map[string]int{}
+	}
+	if stored.Results_float == nil {
+		stored.Results_float = map[string]float64{}
+	}
+	for choice, weight := range delta.Results_float {
+		stored.Results_float[choice] += weight
+		if delta.scale != 0 {
+			stored.Results[choice] = delta.roundedResult(stored.Results_float[choice])
+		} else {
+			stored.Results[choice] += delta.Results[choice]
+		}
+	}
+
+	_, err = db.Conn.Exec(db.Context,
+		`
+		UPDATE proposal_results
+		SET results = $2, results_float = $3, votes_counted = votes_counted + 1,
+			updated_at = (now() at time zone 'utc')
+		WHERE proposal_id = $1
+		`, delta.Proposal_id, stored.Results, stored.Results_float)
+	return err
+}
+
+// SaveTally replaces the stored results with a full recount. A vote cast
+// while the recount ran would be missing from it, so the results are only
+// saved, and true returned, when the recount covered every vote.
+func (r *ProposalResults) SaveTally(db *s.Database) (bool, error) {
+	saved := false
+	err := db.WithTx(func(tx *s.Database) error {
+		if _, err := tx.Conn.Exec(tx.Context,
+			`
+			INSERT INTO proposal_results(proposal_id, results, results_float)
+			VALUES($1, '{}', '{}')
+			ON CONFLICT (proposal_id) DO NOTHING
+			`, r.Proposal_id); err != nil {
+			return err
+		}
+		if _, err := tx.Conn.Exec(tx.Context,
+			`SELECT 1 FROM proposal_results WHERE proposal_id = $1 FOR UPDATE`,
+			r.Proposal_id); err != nil {
+			return err
+		}
+
+		var votes int
+		if err := tx.Conn.QueryRow(tx.Context,
+			`SELECT COUNT(*) FROM votes WHERE proposal_id = $1`,
+			r.Proposal_id).Scan(&votes); err != nil {
+			return err
+		}
+		if votes != r.Votes_counted {
+			return nil
+		}
+
+		if _, err := tx.Conn.Exec(tx.Context,
+			`
+			UPDATE proposal_results
+			SET results = $2, results_float = $3, votes_counted = $4,
+				updated_at = (now() at time zone 'utc')
+			WHERE proposal_id = $1
+			`, r.Proposal_id, r.Results, r.Results_float, r.Votes_counted); err != nil {
+			return err
+		}
+
+		saved = true
+		return nil
+	})
+	return saved, err
+}
+
+// GetProposalsPendingReconcile returns published proposals whose stored
+// results don't account for every vote cast on them.
+func GetProposalsPendingReconcile(db *s.Database) ([]*Proposal, error) {
+	var proposals []*Proposal
+	err := pgxscan.Select(db.Context, db.Conn, &proposals,
+		fmt.Sprintf(`
+		SELECT *, %s FROM proposals
+		WHERE status = 'published'
+		AND COALESCE((SELECT votes_counted FROM proposal_results r WHERE r.proposal_id = proposals.id), 0)
+			<> (SELECT COUNT(*) FROM votes v WHERE v.proposal_id = proposals.id)
+		ORDER BY id
+		LIMIT $1
+		`, computedStatusSQL), proposalCloseBatchSize)
+
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	return proposals, nil
+}
+
+// SetCid stores the CID the final results were pinned under.
+func (r *ProposalResults) SetCid(db *s.Database, cid string) error {
+	_, err := db.Conn.Exec(db.Context,
+		`UPDATE proposal_results SET cid = $2 WHERE proposal_id = $1`,
+		r.Proposal_id, cid)
+	return err
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	// time zone names must resolve in images without a zoneinfo database
+	_ "time/tzdata"
+)
+
+// ProposalWindow holds the community rules for when its proposals may run.
+// Durations are in seconds, and nil or zero means no limit. Proposal times
+// are RFC 3339 timestamps; with time zones set, their offsets must be the
+// offset of one of the zones at that time.
+type ProposalWindow struct {
+	Min_proposal_duration  *int     `json:"minProposalDuration,omitempty"`
+	Max_proposal_duration  *int     `json:"maxProposalDuration,omitempty"`
+	Min_proposal_lead_time *int     `json:"minProposalLeadTime,omitempty"`
+	Proposal_time_zones    []string `json:"proposalTimeZones,omitempty"`
+}
+
+// Merge returns the window with the settings present in the update applied.
+func (w ProposalWindow) Merge(update ProposalWindow) ProposalWindow {
+	if update.Min_proposal_duration != nil {
+		w.Min_proposal_duration = update.Min_proposal_duration
+	}
+	if update.Max_proposal_duration != nil {
+		w.Max_proposal_duration = update.Max_proposal_duration
+	}
+	if update.Min_proposal_lead_time != nil {
+		w.Min_proposal_lead_time = update.Min_proposal_lead_time
+	}
+	if update.Proposal_time_zones != nil {
+		w.Proposal_time_zones = update.Proposal_time_zones
+	}
+	return w
+}
+
+func (w ProposalWindow) IsZero() bool {
+	return w.Min_proposal_duration == nil &&
+		w.Max_proposal_duration == nil &&
+		w.Min_proposal_lead_time == nil &&
+		w.Proposal_time_zones == nil
+}
+
+func (w ProposalWindow) Validate() error {
+	minDuration, maxDuration := seconds(w.Min_proposal_duration), seconds(w.Max_proposal_duration)
+	if minDuration < 0 || maxDuration < 0 || seconds(w.Min_proposal_lead_time) < 0 {
+		return errors.New("Proposal durations and lead time cannot be negative.")
+	}
+	if maxDuration > 0 && minDuration > maxDuration {
+		return errors.New("Minimum proposal duration cannot exceed the maximum.")
+	}
+	for _, zone := range w.Proposal_time_zones {
+		if err := ValidateTimeZone(zone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateTimeZone checks the zone is an IANA time zone name such as
+// "Europe/Paris" or "UTC".
+func ValidateTimeZone(zone string) error {
+	if zone == "" || zone == "Local" {
+		return fmt.Errorf("Unknown time zone %q.", zone)
+	}
+	if _, err := time.LoadLocation(zone); err != nil {
+		return fmt.Errorf("Unknown time zone %q.", zone)
+	}
+	return nil
+}
+
+// Check reports the first rule broken by a proposal running from start to
+// end, created at now.
+func (w ProposalWindow) Check(start, end, now time.Time) error {
+	duration := end.Sub(start)
+	if min := seconds(w.Min_proposal_duration); min > 0 && duration < min {
+		return fmt.Errorf("Proposals must run for at least %s.", min)
+	}
+	if max := seconds(w.Max_proposal_duration); max > 0 && duration > max {
+		return fmt.Errorf("Proposals cannot run for more than %s.", max)
+	}
+	if lead := seconds(w.Min_proposal_lead_time); lead > 0 && start.Before(now.Add(lead)) {
+		return fmt.Errorf("Proposals must start at least %s after they are created.", lead)
+	}
+
+	if len(w.Proposal_time_zones) > 0 {
+		for _, t := range []time.Time{start, end} {
+			if !w.inTimeZones(t) {
+				return fmt.Errorf(
+					"Proposal times must be given in one of these time zones: %s.",
+					strings.Join(w.Proposal_time_zones, ", "),
+				)
+			}
+		}
+	}
+	return nil
+}
+
+func (w ProposalWindow) inTimeZones(t time.Time) bool {
+	_, offset := t.Zone()
+	for _, zone := range w.Proposal_time_zones {
+		loc, err := time.LoadLocation(zone)
+		if err != nil {
+			continue
+		}
+		if _, zoneOffset := t.In(loc).Zone(); zoneOffset == offset {
+			return true
+		}
+	}
+	return false
+}
+
+func seconds(s *int) time.Duration {
+	if s == nil {
+		return 0
+	}
+	return time.Duration(*s) * time.Second
+}
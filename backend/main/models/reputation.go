@@ -0,0 +1,192 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+// Reputation scores go from 0 to 100, averaging three factors between 0 and
+// 1 by the weights the community sets:
+//
+//   - participation, the share of the community's ended proposals voted on
+//   - authorship, the proposals authored out of the authorship target
+//   - tenure, the days since joining out of the tenure days
+const maxReputation = 100.0
+
+var DefaultReputationSettings = ReputationSettings{
+	Participation_weight: 0.5,
+	Authorship_weight:    0.3,
+	Tenure_weight:        0.2,
+	Authorship_target:    5,
+	Tenure_days:          365,
+}
+
+type ReputationSettings struct {
+	Community_id         int        `json:"communityId"`
+	Participation_weight float64    `json:"participationWeight"`
+	Authorship_weight    float64    `json:"authorshipWeight"`
+	Tenure_weight        float64    `json:"tenureWeight"`
+	Authorship_target    int        `json:"authorshipTarget"`
+	Tenure_days          int        `json:"tenureDays"`
+	Updated_at           *time.Time `json:"updatedAt,omitempty"`
+}
+
+// ReputationSettingsPayload changes the settings it sets.
+type ReputationSettingsPayload struct {
+	s.TimestampSignaturePayload
+	Voucher              *s.Voucher `json:"voucher,omitempty"`
+	Participation_weight *float64   `json:"participationWeight,omitempty" validate:"omitempty,min=0"`
+	Authorship_weight    *float64   `json:"authorshipWeight,omitempty"    validate:"omitempty,min=0"`
+	Tenure_weight        *float64   `json:"tenureWeight,omitempty"        validate:"omitempty,min=0"`
+	Authorship_target    *int       `json:"authorshipTarget,omitempty"    validate:"omitempty,min=1"`
+	Tenure_days          *int       `json:"tenureDays,omitempty"          validate:"omitempty,min=1"`
+}
+
+type ReputationScore struct {
+	Community_id  int        `json:"communityId"`
+	Addr          string     `json:"addr"`
+	Participation float64    `json:"participation"`
+	Authorship    float64    `json:"authorship"`
+	Tenure        float64    `json:"tenure"`
+	Score         float64    `json:"score"`
+	Computed_at   *time.Time `json:"computedAt,omitempty"`
+}
+
+// GetReputationSettings reads the settings of the community, the defaults
+// until its admins change them.
+func GetReputationSettings(db *s.Database, communityId int) (ReputationSettings, error) {
+	settings := DefaultReputationSettings
+	settings.Community_id = communityId
+	err := pgxscan.Get(db.Context, db.Conn, &settings,
+		`SELECT * FROM reputation_settings WHERE community_id = $1`, communityId)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return ReputationSettings{}, err
+	}
+	return settings, nil
+}
+
+func (rs *ReputationSettings) Validate() error {
+	if rs.Participation_weight+rs.Authorship_weight+rs.Tenure_weight <= 0 {
+		return errors.New("At least one reputation weight must be positive.")
+	}
+	return nil
+}
+
+func (rs *ReputationSettings) Upsert(db *s.Database) error {
+	return db.Conn.QueryRow(db.Context, `
+		INSERT INTO reputation_settings(community_id, participation_weight, authorship_weight,
+			tenure_weight, authorship_target, tenure_days)
+		VALUES($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (community_id) DO UPDATE
+		SET participation_weight = EXCLUDED.participation_weight,
+			authorship_weight = EXCLUDED.authorship_weight, tenure_weight = EXCLUDED.tenure_weight,
+			authorship_target = EXCLUDED.authorship_target, tenure_days = EXCLUDED.tenure_days,
+			updated_at = (now() at time zone 'utc')
+		RETURNING updated_at
+	`, rs.Community_id, rs.Participation_weight, rs.Authorship_weight, rs.Tenure_weight,
+		rs.Authorship_target, rs.Tenure_days).Scan(&rs.Updated_at)
+}
+
+// GetReputationScore reads the score of the address in the community, zero
+// until it is first computed.
+func GetReputationScore(db *s.Database, communityId int, addr string) (ReputationScore, error) {
+	score := ReputationScore{Community_id: communityId, Addr: addr}
+	err := pgxscan.Get(db.Context, db.Conn, &score,
+		`SELECT * FROM reputation_scores WHERE community_id = $1 AND addr = $2`, communityId, addr)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return ReputationScore{}, err
+	}
+	return score, nil
+}
+
+const refreshReputationSQL = `
+	WITH settings AS (
+		SELECT c.id AS community_id,
+			COALESCE(rs.participation_weight, $1) AS wp,
+			COALESCE(rs.authorship_weight, $2) AS wa,
+			COALESCE(rs.tenure_weight, $3) AS wt,
+			COALESCE(rs.authorship_target, $4) AS authorship_target,
+			COALESCE(rs.tenure_days, $5) AS tenure_days
+		FROM communities c LEFT JOIN reputation_settings rs ON rs.community_id = c.id
+		WHERE c.is_archived = 'false'
+	), members AS (
+		SELECT community_id, addr, MIN(created_at) AS joined_at
+		FROM community_users
+		GROUP BY community_id, addr
+	), ended AS (
+		SELECT id, community_id FROM proposals
+		WHERE status IN ('published', 'closed') AND end_time < (now() at time zone 'utc')
+	), ended_count AS (
+		SELECT community_id, COUNT(*) AS proposals FROM ended GROUP BY community_id
+	), voted AS (
+		SELECT e.community_id, v.addr, COUNT(DISTINCT e.id) AS proposals
+		FROM votes v JOIN ended e ON e.id = v.proposal_id
+		WHERE v.is_cancelled IS NOT TRUE
+		GROUP BY e.community_id, v.addr
+	), authored AS (
+		SELECT community_id, creator_addr AS addr, COUNT(*) AS proposals FROM proposals
+		WHERE status NOT IN ('pending_review', 'rejected', 'cancelled')
+		GROUP BY community_id, creator_addr
+	), factors AS (
+		SELECT m.community_id, m.addr, s.wp, s.wa, s.wt,
+			CASE WHEN COALESCE(e.proposals, 0) = 0 THEN 0
+				ELSE COALESCE(vd.proposals, 0)::float8 / e.proposals END AS participation,
+			LEAST(COALESCE(a.proposals, 0)::float8 / s.authorship_target, 1) AS authorship,
+			LEAST(COALESCE(EXTRACT(EPOCH FROM (now() at time zone 'utc') - m.joined_at)::float8 / 86400, 0) / s.tenure_days, 1) AS tenure
+		FROM members m
+		JOIN settings s ON s.community_id = m.community_id
+		LEFT JOIN ended_count e ON e.community_id = m.community_id
+		LEFT JOIN voted vd ON vd.community_id = m.community_id AND vd.addr = m.addr
+		LEFT JOIN authored a ON a.community_id = m.community_id AND a.addr = m.addr
+	)
+	INSERT INTO reputation_scores(community_id, addr, participation, authorship, tenure, score)
+	SELECT community_id, addr, participation, authorship, tenure,
+		CASE WHEN wp + wa + wt <= 0 THEN 0
+			ELSE $6 * (wp * participation + wa * authorship + wt * tenure) / (wp + wa + wt) END
+	FROM factors
+`
+
+// RefreshReputationScores recomputes the reputation of every member of an
+// active community.
+func RefreshReputationScores(db *s.Database) error {
+	d := DefaultReputationSettings
+	return db.WithTx(func(tx *s.Database) error {
+		if _, err := tx.Conn.Exec(tx.Context, `DELETE FROM reputation_scores`); err != nil {
+			return err
+		}
+		_, err := tx.Conn.Exec(tx.Context, refreshReputationSQL,
+			d.Participation_weight, d.Authorship_weight, d.Tenure_weight,
+			d.Authorship_target, d.Tenure_days, maxReputation)
+		return err
+	})
+}
+
+// SnapshotVoteReputation keeps the reputation the voter has in the
+// community when they first vote on the proposal, so the weight of their
+// vote doesn't change as scores are recomputed.
+func SnapshotVoteReputation(db *s.Database, proposalId, communityId int, addr string) error {
+	_, err := db.Conn.Exec(db.Context, `
+		INSERT INTO vote_reputation(proposal_id, addr, score)
+		VALUES($1, $3, COALESCE(
+			(SELECT score FROM reputation_scores WHERE community_id = $2 AND addr = $3), 0))
+		ON CONFLICT (proposal_id, addr) DO NOTHING
+	`, proposalId, communityId, addr)
+	return err
+}
+
+// GetVoteReputation is the reputation the voter had when they voted on the
+// proposal.
+func GetVoteReputation(db *s.Database, proposalId int, addr string) (float64, error) {
+	var score float64
+	err := db.Conn.QueryRow(db.Context,
+		`SELECT score FROM vote_reputation WHERE proposal_id = $1 AND addr = $2`,
+		proposalId, addr).Scan(&score)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	return score, err
+}
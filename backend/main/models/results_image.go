@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+)
+
+// active proposals re-render their image at least this often, so the time
+// left on it stays about right
+const resultsImageMaxAge = 10 * time.Minute
+
+// ResultsImage is the last rendered results chart of a proposal, along with
+// the vote milestone and status it was rendered at.
+type ResultsImage struct {
+	Proposal_id     int
+	Milestone       int
+	Computed_status string
+	Png             []byte
+	Rendered_at     time.Time
+}
+
+func GetResultsImage(db *s.Database, proposalId int) (*ResultsImage, error) {
+	var image ResultsImage
+	err := pgxscan.Get(db.Context, db.Conn, &image,
+		`SELECT * FROM proposal_result_images WHERE proposal_id = $1`, proposalId)
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+func (i *ResultsImage) Save(db *s.Database) error {
+	return db.Conn.QueryRow(db.Context, `
+		INSERT INTO proposal_result_images(proposal_id, milestone, computed_status, png)
+		VALUES($1, $2, $3, $4)
+		ON CONFLICT (proposal_id) DO UPDATE
+		SET milestone = EXCLUDED.milestone,
+			computed_status = EXCLUDED.computed_status,
+			png = EXCLUDED.png,
+			rendered_at = now()
+		RETURNING rendered_at
+	`, i.Proposal_id, i.Milestone, i.Computed_status, i.Png).Scan(&i.Rendered_at)
+}
+
+// Fresh tells whether the image still shows the proposal as it is: no
+// vote milestone was reached and its status didn't change since it was
+// rendered, and it isn't too old to tell the time left.
+func (i *ResultsImage) Fresh(p *Proposal, now time.Time) bool {
+	milestone, _ := HighestVoteMilestone(p.Total_votes)
+	if i.Milestone != milestone || p.Computed_status == nil || i.Computed_status != *p.Computed_status {
+		return false
+	}
+	switch i.Computed_status {
+	case "closed", "cancelled":
+		return true
+	}
+	return now.Sub(i.Rendered_at) < resultsImageMaxAge
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+)
+
+const SessionTokenAudience = "session"
+
+type LoginPayload struct {
+	Voucher *shared.Voucher `json:"voucher,omitempty"`
+
+	shared.TimestampSignaturePayload
+}
+
+// Session is handed out by /auth/login. The token is sent back as a
+// bearer token on reads that need to know who is asking.
+type Session struct {
+	Addr       string    `json:"addr"`
+	Token      string    `json:"token"`
+	Expires_at time.Time `json:"expiresAt"`
+}
@@ -36,3 +36,9 @@ func GetVotingStrategies(db *s.Database) ([]*VotingStrategy, error) {
 func IsNFTStrategy(name string) bool {
 	return name == "balance-of-nfts" || name == "float-nfts" || name == "custom-script"
 }
+
+// IsFungibleTokenStrategy tells whether the strategy weighs votes with the
+// balance of a fungible token.
+func IsFungibleTokenStrategy(name string) bool {
+	return name == "token-weighted-default" || name == "staked-token-weighted-default"
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+// StrategyVersion is the strategies of a community as they were after one
+// of its edits. Proposals record the version they were created under.
+type StrategyVersion struct {
+	Community_id int         `json:"communityId"`
+	Version      int         `json:"version"`
+	Strategies   *[]Strategy `json:"strategies"`
+	Created_by   *string     `json:"createdBy,omitempty"`
+	Created_at   time.Time   `json:"createdAt"`
+}
+
+// StrategyChangePayload is an intended change of a community's strategies.
+type StrategyChangePayload struct {
+	Strategies []Strategy `json:"strategies" validate:"required"`
+	Voucher    *s.Voucher `json:"voucher,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
+// StrategyChangeImpact lists what an intended change of strategies affects:
+// the strategies it changes or removes, and the proposals not closed yet
+// that use them.
+type StrategyChangeImpact struct {
+	Version   int         `json:"version"`
+	Changed   []string    `json:"changed"`
+	Proposals []*Proposal `json:"proposals"`
+}
+
+func GetStrategyVersions(db *s.Database, communityId int) ([]*StrategyVersion, error) {
+	versions := []*StrategyVersion{}
+	err := pgxscan.Select(db.Context, db.Conn, &versions,
+		`
+		SELECT * FROM community_strategy_versions
+		WHERE community_id = $1
+		ORDER BY version DESC
+		`, communityId)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	return versions, nil
+}
+
+func (v *StrategyVersion) GetStrategyVersion(db *s.Database) error {
+	return pgxscan.Get(db.Context, db.Conn, v,
+		`SELECT * FROM community_strategy_versions WHERE community_id = $1 AND version = $2`,
+		v.Community_id, v.Version)
+}
+
+// recordStrategyVersion adds the community's strategies as a new version,
+// unless they are the same as its latest version.
+func (c *Community) recordStrategyVersion(db *s.Database, createdBy *string) error {
+	var version int
+	err := db.Conn.QueryRow(db.Context,
+		`
+		INSERT INTO community_strategy_versions(community_id, version, strategies, created_by)
+		SELECT c.id, c.strategies_version + 1, c.strategies, $2 FROM communities c
+		WHERE c.id = $1 AND NOT EXISTS (
+			SELECT 1 FROM community_strategy_versions v
+			WHERE v.community_id = c.id AND v.version = c.strategies_version
+			AND v.strategies IS NOT DISTINCT FROM c.strategies
+		)
+		RETURNING version
+		`, c.ID, createdBy).Scan(&version)
+	if err == pgx.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	_, err = db.Conn.Exec(db.Context,
+		`UPDATE communities SET strategies_version = $2 WHERE id = $1`,
+		c.ID, version)
+	if err != nil {
+		return err
+	}
+	c.Strategies_version = version
+	return nil
+}
@@ -0,0 +1,204 @@
+package models
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+const MaxSyndicatedProposals = 50
+
+// SyndicatedProposal is a proposal as it is listed in feeds, along with the
+// community it belongs to.
+type SyndicatedProposal struct {
+	Proposal
+	Community_name string `json:"communityName"`
+}
+
+// GetProposalsForSyndication lists the latest proposals of a public
+// community, or of every public community of the tenant when communityId
+// is 0. Proposals awaiting review or rejected are left out.
+func GetProposalsForSyndication(db *s.Database, tenantId, communityId, limit int) ([]*SyndicatedProposal, error) {
+	var proposals []*SyndicatedProposal
+	sql := fmt.Sprintf(`
+		SELECT p.*, %s, c.name AS community_name
+		FROM proposals p
+		JOIN communities c ON c.id = p.community_id
+		WHERE c.tenant_id = $1 AND ($2 = 0 OR c.id = $2)
+			AND c.is_private = 'false' AND c.is_archived = 'false'
+			AND p.status NOT IN ('pending_review', 'rejected')
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $3
+	`, computedStatusSQL)
+	err := pgxscan.Select(db.Context, db.Conn, &proposals, sql, tenantId, communityId, limit)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	return proposals, nil
+}
+
+// Summary describes the results of a closed proposal in a sentence or two,
+// such as "Results: For 75.0% (3 votes), Against 25.0% (1 votes). Passed
+// with For."
+func (r *ProposalResults) Summary(p Proposal) string {
+	parts := make([]string, 0, len(p.Choices))
+	for _, result := range r.Breakdown(p.Choices) {
+		parts = append(parts, fmt.Sprintf("%s %.1f%% (%s votes)",
+			result.Choice, result.Percent, strconv.FormatFloat(result.Votes, 'f', -1, 64)))
+	}
+	summary := "Results: " + strings.Join(parts, ", ") + "."
+
+	outcome := r.DecideOutcome(p)
+	switch {
+	case outcome.Vetoed:
+		summary += " Vetoed."
+	case outcome.Quorum != nil && !outcome.Quorum_met:
+		summary += " Quorum not met."
+	case outcome.Winning_choice != nil:
+		summary += fmt.Sprintf(" Passed with %s.", *outcome.Winning_choice)
+	}
+	return summary
+}
+
+// SyndicationFeed is a feed of proposals, written out as RSS or Atom.
+type SyndicationFeed struct {
+	Title       string
+	Description string
+	Link        string
+	Self        string
+	Updated     time.Time
+	Items       []SyndicationItem
+}
+
+type SyndicationItem struct {
+	Title      string
+	Link       string
+	Summary    string
+	Author     string
+	Categories []string
+	Published  time.Time
+	Updated    time.Time
+}
+
+type RssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Channel RssChannel `xml:"channel"`
+}
+
+type RssChannel struct {
+	Title           string    `xml:"title"`
+	Link            string    `xml:"link"`
+	Description     string    `xml:"description"`
+	Self            AtomLink  `xml:"atom:link"`
+	Last_build_date string    `xml:"lastBuildDate"`
+	Items           []RssItem `xml:"item"`
+}
+
+type RssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Guid        RssGuid  `xml:"guid"`
+	Pub_date    string   `xml:"pubDate"`
+	Description string   `xml:"description"`
+	Categories  []string `xml:"category"`
+}
+
+type RssGuid struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+type AtomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	Id      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []AtomLink  `xml:"link"`
+	Entries []AtomEntry `xml:"entry"`
+}
+
+type AtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type AtomEntry struct {
+	Title      string         `xml:"title"`
+	Id         string         `xml:"id"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Link       AtomLink       `xml:"link"`
+	Author     AtomAuthor     `xml:"author"`
+	Summary    string         `xml:"summary"`
+	Categories []AtomCategory `xml:"category"`
+}
+
+type AtomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type AtomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// RSS writes the feed as RSS 2.0, linking back to itself with atom:link as
+// feed validators ask for.
+func (f SyndicationFeed) RSS() RssFeed {
+	channel := RssChannel{
+		Title:           f.Title,
+		Link:            f.Link,
+		Description:     f.Description,
+		Self:            AtomLink{Href: f.Self, Rel: "self", Type: "application/rss+xml"},
+		Last_build_date: f.Updated.UTC().Format(time.RFC1123Z),
+		Items:           make([]RssItem, 0, len(f.Items)),
+	}
+	for _, item := range f.Items {
+		channel.Items = append(channel.Items, RssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			Guid:        RssGuid{Value: item.Link, IsPermaLink: true},
+			Pub_date:    item.Published.UTC().Format(time.RFC1123Z),
+			Description: item.Summary,
+			Categories:  item.Categories,
+		})
+	}
+	return RssFeed{Version: "2.0", Atom: "http://www.w3.org/2005/Atom", Channel: channel}
+}
+
+func (f SyndicationFeed) Atom() AtomFeed {
+	feed := AtomFeed{
+		Title:   f.Title,
+		Id:      f.Link,
+		Updated: f.Updated.UTC().Format(time.RFC3339),
+		Links: []AtomLink{
+			{Href: f.Self, Rel: "self", Type: "application/atom+xml"},
+			{Href: f.Link, Rel: "alternate", Type: "text/html"},
+		},
+		Entries: make([]AtomEntry, 0, len(f.Items)),
+	}
+	for _, item := range f.Items {
+		entry := AtomEntry{
+			Title:     item.Title,
+			Id:        item.Link,
+			Published: item.Published.UTC().Format(time.RFC3339),
+			Updated:   item.Updated.UTC().Format(time.RFC3339),
+			Link:      AtomLink{Href: item.Link, Rel: "alternate", Type: "text/html"},
+			Author:    AtomAuthor{Name: item.Author},
+			Summary:   item.Summary,
+		}
+		for _, category := range item.Categories {
+			entry.Categories = append(entry.Categories, AtomCategory{Term: category})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return feed
+}
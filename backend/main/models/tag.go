@@ -0,0 +1,125 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+type CommunityTag struct {
+	ID           int        `json:"id"`
+	Community_id int        `json:"communityId"`
+	Name         string     `json:"name"`
+	Created_at   *time.Time `json:"createdAt,omitempty"`
+}
+
+type CommunityTagPayload struct {
+	Name    string          `json:"name"    validate:"required,max=32"`
+	Voucher *shared.Voucher `json:"voucher,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
+func GetTagsForCommunity(db *s.Database, communityId int) ([]*CommunityTag, error) {
+	var tags []*CommunityTag
+	err := pgxscan.Select(db.Context, db.Conn, &tags,
+		`SELECT * FROM community_tags WHERE community_id = $1 ORDER BY name ASC`,
+		communityId)
+
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	} else if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return []*CommunityTag{}, nil
+	}
+	return tags, nil
+}
+
+func (t *CommunityTag) GetTagById(db *s.Database) error {
+	return pgxscan.Get(db.Context, db.Conn, t,
+		`SELECT * FROM community_tags WHERE id = $1`,
+		t.ID)
+}
+
+func (t *CommunityTag) CreateTag(db *s.Database) error {
+	return db.Conn.QueryRow(db.Context,
+		`
+		INSERT INTO community_tags(community_id, name)
+		VALUES($1, $2)
+		RETURNING id, created_at
+		`, t.Community_id, t.Name).
+		Scan(&t.ID, &t.Created_at)
+}
+
+// DeleteTag removes the tag from the vocabulary and from every proposal of
+// the community that uses it.
+func (t *CommunityTag) DeleteTag(db *s.Database) error {
+	return db.WithTx(func(tx *s.Database) error {
+		if _, err := tx.Conn.Exec(tx.Context,
+			`
+			UPDATE proposals SET tags = array_remove(tags, $1), version = version + 1, updated_at = (now() at time zone 'utc')
+			WHERE community_id = $2 AND $1 = ANY(tags)
+			`,
+			t.Name, t.Community_id); err != nil {
+			return err
+		}
+		_, err := tx.Conn.Exec(tx.Context, `DELETE FROM community_tags WHERE id = $1`, t.ID)
+		return err
+	})
+}
+
+// EnsureTagsInVocabulary checks every tag is part of the community vocabulary.
+func EnsureTagsInVocabulary(db *s.Database, communityId int, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	vocabulary, err := GetTagsForCommunity(db, communityId)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool)
+	for _, t := range vocabulary {
+		known[t.Name] = true
+	}
+	for _, tag := range tags {
+		if !known[tag] {
+			return fmt.Errorf("tag %s is not defined for community %d", tag, communityId)
+		}
+	}
+	return nil
+}
+
+// GetTagCountsForCommunities counts the proposals carrying each tag across
+// the given communities.
+func GetTagCountsForCommunities(db *s.Database, communityIds []int) (map[string]int, error) {
+	tagCount := make(map[string]int)
+	if len(communityIds) == 0 {
+		return tagCount, nil
+	}
+
+	rows, err := db.Conn.Query(db.Context,
+		`
+		SELECT tag, COUNT(*) FROM proposals, unnest(tags) AS tag
+		WHERE community_id = ANY($1)
+		GROUP BY tag
+		`, communityIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tag string
+		var count int
+		if err := rows.Scan(&tag, &count); err != nil {
+			return nil, fmt.Errorf("error scanning tag row: %v", err)
+		}
+		tagCount[tag] = count
+	}
+
+	return tagCount, rows.Err()
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+// TallyProgress holds the running totals of a chunked tally and the last
+// vote counted, so the tally can pick up from there.
+type TallyProgress struct {
+	Proposal_id   int                `json:"proposalId"`
+	Last_vote_id  int                `json:"lastVoteId"`
+	Votes_counted int                `json:"votesCounted"`
+	Results       map[string]int     `json:"results"`
+	Results_float map[string]float64 `json:"resultsFloat"`
+	Updated_at    time.Time          `json:"updatedAt"`
+}
+
+// GetTallyProgress returns nil when no tally of the proposal was started.
+func GetTallyProgress(db *s.Database, proposalId int) (*TallyProgress, error) {
+	var tp TallyProgress
+	err := pgxscan.Get(db.Context, db.Conn, &tp,
+		`SELECT * FROM proposal_tally_progress WHERE proposal_id = $1`,
+		proposalId,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tp, nil
+}
+
+func (tp *TallyProgress) Save(db *s.Database) error {
+	_, err := db.Conn.Exec(db.Context,
+		`
+	INSERT INTO proposal_tally_progress (proposal_id, last_vote_id, votes_counted,
+	    results, results_float, updated_at)
+	VALUES ($1, $2, $3, $4, $5, now())
+	ON CONFLICT (proposal_id) DO UPDATE
+	SET last_vote_id = EXCLUDED.last_vote_id,
+		votes_counted = EXCLUDED.votes_counted,
+		results = EXCLUDED.results,
+		results_float = EXCLUDED.results_float,
+		updated_at = EXCLUDED.updated_at
+	`,
+		tp.Proposal_id, tp.Last_vote_id, tp.Votes_counted, tp.Results, tp.Results_float,
+	)
+	return err
+}
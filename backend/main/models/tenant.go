@@ -0,0 +1,126 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+// DefaultTenantID is the tenant serving requests that name no other, and
+// every deployment that isn't multi-tenant.
+const DefaultTenantID = 1
+
+var ErrTenantNotFound = errors.New("Tenant not found.")
+
+// Tenant is a frontend served by the backend, with its own branding,
+// admins and homepage.
+type Tenant struct {
+	ID          int               `json:"id"`
+	Slug        string            `json:"slug"`
+	Name        string            `json:"name"`
+	Hostnames   []string          `json:"hostnames"`
+	Branding    map[string]string `json:"branding"`
+	Admin_addrs []string          `json:"adminAddrs"`
+	Created_at  time.Time         `json:"createdAt"`
+	Updated_at  time.Time         `json:"updatedAt"`
+}
+
+// TenantBranding is what a frontend needs to present its tenant.
+type TenantBranding struct {
+	Slug     string            `json:"slug"`
+	Name     string            `json:"name"`
+	Branding map[string]string `json:"branding"`
+}
+
+// TenantPayload creates a tenant, or changes the fields it sets.
+type TenantPayload struct {
+	s.TimestampSignaturePayload
+	Slug        *string            `json:"slug,omitempty"        validate:"omitempty,min=1,max=64"`
+	Name        *string            `json:"name,omitempty"        validate:"omitempty,min=1,max=128"`
+	Hostnames   *[]string          `json:"hostnames,omitempty"   validate:"omitempty,max=20,dive,hostname_rfc1123"`
+	Branding    *map[string]string `json:"branding,omitempty"    validate:"omitempty,max=50"`
+	Admin_addrs *[]string          `json:"adminAddrs,omitempty"  validate:"omitempty,max=100,dive,required"`
+}
+
+func (t *Tenant) Public() TenantBranding {
+	return TenantBranding{Slug: t.Slug, Name: t.Name, Branding: t.Branding}
+}
+
+func (t *Tenant) IsAdmin(addr string) bool {
+	for _, a := range t.Admin_addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+func GetTenants(db *s.Database) ([]*Tenant, error) {
+	var tenants []*Tenant
+	err := pgxscan.Select(db.Context, db.Conn, &tenants, `SELECT * FROM tenants ORDER BY id`)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	} else if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return []*Tenant{}, nil
+	}
+	return tenants, nil
+}
+
+func (t *Tenant) GetTenant(db *s.Database) error {
+	err := pgxscan.Get(db.Context, db.Conn, t, `SELECT * FROM tenants WHERE id = $1`, t.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrTenantNotFound
+	}
+	return err
+}
+
+func (t *Tenant) CreateTenant(db *s.Database) error {
+	return db.Conn.QueryRow(db.Context, `
+		INSERT INTO tenants(slug, name, hostnames, branding, admin_addrs)
+		VALUES($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at
+	`, t.Slug, t.Name, t.Hostnames, t.Branding, t.Admin_addrs).Scan(&t.ID, &t.Created_at, &t.Updated_at)
+}
+
+func (t *Tenant) UpdateTenant(db *s.Database) error {
+	return db.Conn.QueryRow(db.Context, `
+		UPDATE tenants
+		SET slug = $2, name = $3, hostnames = $4, branding = $5, admin_addrs = $6,
+			updated_at = (now() at time zone 'utc')
+		WHERE id = $1
+		RETURNING updated_at
+	`, t.ID, t.Slug, t.Name, t.Hostnames, t.Branding, t.Admin_addrs).Scan(&t.Updated_at)
+}
+
+// recordTenantSQL finds the tenant of a record by the collection naming it
+// in request paths.
+var recordTenantSQL = map[string]string{
+	"communities": `SELECT tenant_id FROM communities WHERE id = $1`,
+	"proposals": `
+		SELECT c.tenant_id FROM proposals p JOIN communities c ON c.id = p.community_id
+		WHERE p.id = $1`,
+	"lists": `
+		SELECT c.tenant_id FROM lists l JOIN communities c ON c.id = l.community_id
+		WHERE l.id = $1`,
+}
+
+// GetRecordTenant returns the tenant of the community, proposal or list
+// with the id. It reports false for other collections and for records that
+// don't exist.
+func GetRecordTenant(db *s.Database, collection string, id int) (int, bool, error) {
+	sql, ok := recordTenantSQL[collection]
+	if !ok {
+		return 0, false, nil
+	}
+	var tenantId int
+	err := db.Conn.QueryRow(db.Context, sql, id).Scan(&tenantId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	} else if err != nil {
+		return 0, false, err
+	}
+	return tenantId, true, nil
+}
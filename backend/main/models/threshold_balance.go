@@ -0,0 +1,68 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+// ThresholdBalance is an author's balance of a community's token at a block
+// height, read to check it against the community's proposal threshold.
+type ThresholdBalance struct {
+	Addr         string    `json:"addr"`
+	Contract     string    `json:"contract"`
+	Block_height uint64    `json:"blockHeight"`
+	Balance      float64   `json:"balance"`
+	Created_at   time.Time `json:"createdAt"`
+}
+
+// ProposalEligibility tells whether an address can create proposals in a
+// community, and if the community has a proposal threshold, the balance it
+// was checked with.
+type ProposalEligibility struct {
+	Addr           string           `json:"addr"`
+	Eligible       bool             `json:"eligible"`
+	Only_authors   bool             `json:"onlyAuthors"`
+	Token          *string          `json:"token,omitempty"`
+	Token_metadata *s.TokenMetadata `json:"tokenMetadata,omitempty"`
+	Required       *float64         `json:"required,omitempty"`
+	Balance        *float64         `json:"balance,omitempty"`
+	Block_height   *uint64          `json:"blockHeight,omitempty"`
+	Participation  *Participation   `json:"participation,omitempty"`
+	// when a cooldown ends, see ProposalCooldown
+	Next_proposal_at *time.Time `json:"nextProposalAt,omitempty"`
+	Reason           string     `json:"reason,omitempty"`
+}
+
+// GetRecentThresholdBalance returns the latest balance read no longer than
+// maxAge ago, or nil when there is none.
+func GetRecentThresholdBalance(db *s.Database, addr, contract string, maxAge time.Duration) (*ThresholdBalance, error) {
+	var b ThresholdBalance
+	err := pgxscan.Get(db.Context, db.Conn, &b,
+		`
+		SELECT * FROM threshold_balances
+		WHERE addr = $1 AND contract = $2 AND created_at > $3
+		ORDER BY block_height DESC
+		LIMIT 1
+		`, addr, contract, time.Now().UTC().Add(-maxAge))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (b *ThresholdBalance) Save(db *s.Database) error {
+	_, err := db.Conn.Exec(db.Context,
+		`
+		INSERT INTO threshold_balances(addr, contract, block_height, balance)
+		VALUES($1, $2, $3, $4)
+		ON CONFLICT (addr, contract, block_height) DO NOTHING
+		`, b.Addr, b.Contract, b.Block_height, b.Balance)
+	return err
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+// TranslationTarget names the table translations of a kind of record are
+// kept in, and the column referencing the record.
+type TranslationTarget struct {
+	table  string
+	column string
+}
+
+var (
+	CommunityTranslations = TranslationTarget{"community_translations", "community_id"}
+	ProposalTranslations  = TranslationTarget{"proposal_translations", "proposal_id"}
+)
+
+// Translation holds the localized name and body of a community or
+// proposal. Fields left nil fall back to the original.
+type Translation struct {
+	Locale     string    `json:"locale"`
+	Name       *string   `json:"name,omitempty"`
+	Body       *string   `json:"body,omitempty"`
+	Updated_at time.Time `json:"updatedAt"`
+}
+
+type TranslationPayload struct {
+	s.TimestampSignaturePayload
+	Voucher *s.Voucher `json:"voucher,omitempty"`
+	Name    *string    `json:"name,omitempty" validate:"omitempty,min=1,max=256"`
+	Body    *string    `json:"body,omitempty"`
+}
+
+func GetTranslations(db *s.Database, target TranslationTarget, id int) ([]*Translation, error) {
+	translations := []*Translation{}
+	err := pgxscan.Select(db.Context, db.Conn, &translations,
+		`SELECT locale, name, body, updated_at FROM `+target.table+`
+		WHERE `+target.column+` = $1 ORDER BY locale`, id)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	return translations, nil
+}
+
+func GetTranslationLocales(db *s.Database, target TranslationTarget, id int) ([]string, error) {
+	locales := []string{}
+	err := pgxscan.Select(db.Context, db.Conn, &locales,
+		`SELECT locale FROM `+target.table+` WHERE `+target.column+` = $1 ORDER BY locale`, id)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	return locales, nil
+}
+
+func GetTranslation(db *s.Database, target TranslationTarget, id int, locale string) (*Translation, error) {
+	var t Translation
+	err := pgxscan.Get(db.Context, db.Conn, &t,
+		`SELECT locale, name, body, updated_at FROM `+target.table+`
+		WHERE `+target.column+` = $1 AND locale = $2`, id, locale)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (t *Translation) Upsert(db *s.Database, target TranslationTarget, id int) error {
+	return db.Conn.QueryRow(db.Context, `
+		INSERT INTO `+target.table+`(`+target.column+`, locale, name, body)
+		VALUES($1, $2, $3, $4)
+		ON CONFLICT (`+target.column+`, locale) DO UPDATE
+		SET name = EXCLUDED.name, body = EXCLUDED.body, updated_at = now()
+		RETURNING updated_at
+	`, id, t.Locale, t.Name, t.Body).Scan(&t.Updated_at)
+}
+
+func DeleteTranslation(db *s.Database, target TranslationTarget, id int, locale string) (bool, error) {
+	tag, err := db.Conn.Exec(db.Context,
+		`DELETE FROM `+target.table+` WHERE `+target.column+` = $1 AND locale = $2`, id, locale)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Localize replaces the name and body of the community with those of the
+// translation.
+func (c *Community) Localize(t *Translation) {
+	if t.Name != nil {
+		c.Name = *t.Name
+	}
+	if t.Body != nil {
+		c.Body = t.Body
+	}
+}
+
+func (p *Proposal) Localize(t *Translation) {
+	if t.Name != nil {
+		p.Name = *t.Name
+	}
+	if t.Body != nil {
+		p.Body = t.Body
+	}
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+// Treasury is an account holding a community's funds in a fungible token,
+// whose balance proposals can snapshot when they are created.
+type Treasury struct {
+	ID            int        `json:"id"`
+	Community_id  int        `json:"communityId"`
+	Name          string     `json:"name"         validate:"required,max=64"`
+	Addr          string     `json:"addr"         validate:"required"`
+	Contract_name string     `json:"contractName" validate:"required"`
+	Contract_addr string     `json:"contractAddr" validate:"required"`
+	Public_path   string     `json:"publicPath"   validate:"required"`
+	Created_at    *time.Time `json:"createdAt,omitempty"`
+}
+
+type TreasuryPayload struct {
+	Treasury
+	Voucher *s.Voucher `json:"voucher,omitempty"`
+
+	s.TimestampSignaturePayload
+}
+
+// TreasuryBalance is the balance a treasury held at the block a proposal
+// was created at. Snapshots keep the treasury's details, so they outlive
+// changes to the community's treasuries.
+type TreasuryBalance struct {
+	Proposal_id   int     `json:"-"`
+	Name          string  `json:"name"`
+	Addr          string  `json:"addr"`
+	Contract_name string  `json:"contractName"`
+	Contract_addr string  `json:"contractAddr"`
+	Balance       float64 `json:"balance"`
+	Block_height  uint64  `json:"blockHeight"`
+}
+
+func GetTreasuriesForCommunity(db *s.Database, communityId int) ([]*Treasury, error) {
+	var treasuries []*Treasury
+	err := pgxscan.Select(db.Context, db.Conn, &treasuries,
+		`SELECT * FROM community_treasuries WHERE community_id = $1 ORDER BY id ASC`,
+		communityId)
+
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	} else if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return []*Treasury{}, nil
+	}
+	return treasuries, nil
+}
+
+func (t *Treasury) GetTreasuryById(db *s.Database) error {
+	return pgxscan.Get(db.Context, db.Conn, t,
+		`SELECT * FROM community_treasuries WHERE id = $1`,
+		t.ID)
+}
+
+func (t *Treasury) CreateTreasury(db *s.Database) error {
+	return db.Conn.QueryRow(db.Context,
+		`
+		INSERT INTO community_treasuries(community_id, name, addr, contract_name, contract_addr, public_path)
+		VALUES($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+		`, t.Community_id, t.Name, t.Addr, t.Contract_name, t.Contract_addr, t.Public_path).
+		Scan(&t.ID, &t.Created_at)
+}
+
+func (t *Treasury) DeleteTreasury(db *s.Database) error {
+	_, err := db.Conn.Exec(db.Context, `DELETE FROM community_treasuries WHERE id = $1`, t.ID)
+	return err
+}
+
+func GetTreasurySnapshot(db *s.Database, proposalId int) ([]*TreasuryBalance, error) {
+	balances := []*TreasuryBalance{}
+	err := pgxscan.Select(db.Context, db.Conn, &balances,
+		`
+		SELECT * FROM proposal_treasury_snapshots
+		WHERE proposal_id = $1
+		ORDER BY name ASC, contract_name ASC
+		`, proposalId)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	return balances, nil
+}
+
+func AddTreasurySnapshot(db *s.Database, proposalId int, balances []*TreasuryBalance) error {
+	for _, b := range balances {
+		b.Proposal_id = proposalId
+		_, err := db.Conn.Exec(db.Context,
+			`
+			INSERT INTO proposal_treasury_snapshots(proposal_id, name, addr, contract_name, contract_addr, balance, block_height)
+			VALUES($1, $2, $3, $4, $5, $6, $7)
+			`, b.Proposal_id, b.Name, b.Addr, b.Contract_name, b.Contract_addr, b.Balance, b.Block_height)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"fmt"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+// Trending scores add up recent activity, each event weighing half as much
+// for every trendingHalfLife hours since it happened. Activity older than
+// trendingWindow is ignored.
+const (
+	trendingWindow   = "14 days"
+	trendingHalfLife = 72.0
+
+	trendingVoteWeight     = 1.0
+	trendingMemberWeight   = 2.0
+	trendingProposalWeight = 5.0
+)
+
+const trendingDecaySQL = `power(0.5, EXTRACT(EPOCH FROM (now() at time zone 'utc') - at) / 3600 / $2::float8)`
+
+const refreshCommunityTrendingSQL = `
+	WITH events AS (
+		SELECT p.community_id, v.created_at AS at, $3::float8 AS weight
+		FROM votes v JOIN proposals p ON p.id = v.proposal_id
+		WHERE v.created_at > (now() at time zone 'utc') - $1::interval
+		UNION ALL
+		SELECT community_id, created_at, $4::float8
+		FROM community_users
+		WHERE user_type = 'member' AND created_at > (now() at time zone 'utc') - $1::interval
+		UNION ALL
+		SELECT community_id, created_at, $5::float8
+		FROM proposals
+		WHERE status NOT IN ('cancelled', 'pending_review', 'rejected')
+		AND created_at > (now() at time zone 'utc') - $1::interval
+	)
+	INSERT INTO community_trending_scores(community_id, score)
+	SELECT community_id, SUM(weight * ` + trendingDecaySQL + `)
+	FROM events
+	GROUP BY community_id
+`
+
+const refreshProposalTrendingSQL = `
+	WITH events AS (
+		SELECT v.proposal_id, v.created_at AS at, $3::float8 AS weight
+		FROM votes v JOIN proposals p ON p.id = v.proposal_id
+		WHERE p.status = 'published' AND v.created_at > (now() at time zone 'utc') - $1::interval
+	)
+	INSERT INTO proposal_trending_scores(proposal_id, score)
+	SELECT proposal_id, SUM(weight * ` + trendingDecaySQL + `)
+	FROM events
+	GROUP BY proposal_id
+`
+
+// RefreshTrendingScores recomputes the trending score of every community
+// and proposal with recent activity.
+func RefreshTrendingScores(db *s.Database) error {
+	return db.WithTx(func(tx *s.Database) error {
+		if _, err := tx.Conn.Exec(tx.Context, `DELETE FROM community_trending_scores`); err != nil {
+			return err
+		}
+		_, err := tx.Conn.Exec(tx.Context, refreshCommunityTrendingSQL,
+			trendingWindow, trendingHalfLife, trendingVoteWeight, trendingMemberWeight, trendingProposalWeight)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Conn.Exec(tx.Context, `DELETE FROM proposal_trending_scores`); err != nil {
+			return err
+		}
+		_, err = tx.Conn.Exec(tx.Context, refreshProposalTrendingSQL,
+			trendingWindow, trendingHalfLife, trendingVoteWeight)
+		return err
+	})
+}
+
+// GetTrendingCommunities lists active communities by trending score, those
+// without recent activity last.
+func GetTrendingCommunities(db *s.Database, tenantId int, pageParams s.PageParams) ([]*Community, int, error) {
+	var communities []*Community
+	err := pgxscan.Select(db.Context, db.Conn, &communities,
+		`
+		SELECT c.*, t.score AS trending_score FROM communities c
+		LEFT JOIN community_trending_scores t ON t.community_id = c.id
+		WHERE c.is_archived = 'false' AND c.tenant_id = $3
+		ORDER BY COALESCE(t.score, 0) DESC, c.id ASC
+		LIMIT $1 OFFSET $2
+		`, pageParams.Count, pageParams.Start, tenantId)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, 0, err
+	} else if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return []*Community{}, 0, nil
+	}
+
+	var totalRecords int
+	countSql := `SELECT COUNT(*) FROM communities WHERE is_archived = 'false' AND tenant_id = $1`
+	_ = db.Conn.QueryRow(db.Context, countSql, tenantId).Scan(&totalRecords)
+
+	return communities, totalRecords, nil
+}
+
+// GetTrendingProposals lists the published proposals of public, active
+// communities that had votes recently, by trending score.
+func GetTrendingProposals(db *s.Database, pageParams s.PageParams) ([]*Proposal, int, error) {
+	const from = `
+		FROM proposals
+		JOIN proposal_trending_scores t ON t.proposal_id = proposals.id
+		WHERE proposals.community_id IN (
+			SELECT id FROM communities WHERE is_archived = 'false' AND is_private = 'false'
+		)
+	`
+
+	var proposals []*Proposal
+	sql := fmt.Sprintf(`SELECT proposals.*, t.score AS trending_score, %s `, computedStatusSQL) +
+		from + ` ORDER BY t.score DESC, proposals.id DESC LIMIT $1 OFFSET $2`
+	err := pgxscan.Select(db.Context, db.Conn, &proposals, sql, pageParams.Count, pageParams.Start)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, 0, err
+	} else if err != nil && err.Error() == pgx.ErrNoRows.Error() {
+		return []*Proposal{}, 0, nil
+	}
+
+	var totalRecords int
+	_ = db.Conn.QueryRow(db.Context, `SELECT COUNT(*) `+from).Scan(&totalRecords)
+
+	return proposals, totalRecords, nil
+}
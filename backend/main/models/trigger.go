@@ -0,0 +1,98 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+// Polling triggers, for automation tools that ask for new items rather than
+// take webhooks.
+const (
+	TriggerNewProposal    = "new-proposal"
+	TriggerProposalClosed = "proposal-closed"
+)
+
+// TRIGGER_EVENTS maps each trigger to the community event it reports.
+var TRIGGER_EVENTS = map[string]string{
+	TriggerNewProposal:    EventProposalCreated,
+	TriggerProposalClosed: EventProposalClosed,
+}
+
+const MaxTriggerItems = 100
+
+// TriggerItem is one occurrence of a trigger. Its ID never changes, so
+// pollers can drop items they have seen, and its Cursor is the since to
+// poll with for what happens after it.
+type TriggerItem struct {
+	ID             string    `json:"id"`
+	Cursor         int       `json:"cursor"`
+	Trigger        string    `json:"trigger"`
+	Occurred_at    time.Time `json:"occurredAt"`
+	Community_id   int       `json:"communityId"`
+	Proposal_id    int       `json:"proposalId"`
+	Name           string    `json:"name"`
+	Creator_addr   string    `json:"creatorAddr"`
+	Strategy       *string   `json:"strategy,omitempty"`
+	Tags           []string  `json:"tags"`
+	Start_time     time.Time `json:"startTime"`
+	End_time       time.Time `json:"endTime"`
+	Url            string    `json:"url"`
+	Winning_choice *string   `json:"winningChoice,omitempty"`
+}
+
+// GetTriggerItems lists the occurrences of a trigger in a community after
+// the since cursor, newest first. Only the tags, strategy and creator
+// filters apply.
+func GetTriggerItems(
+	db *s.Database,
+	communityId int,
+	trigger string,
+	since int,
+	filters ProposalFilters,
+	limit int,
+) ([]*TriggerItem, error) {
+	eventType, ok := TRIGGER_EVENTS[trigger]
+	if !ok {
+		return nil, fmt.Errorf("Unknown trigger %q.", trigger)
+	}
+
+	tags := filters.Tags
+	if len(tags) == 0 {
+		tags = nil
+	}
+
+	var items []*TriggerItem
+	err := pgxscan.Select(db.Context, db.Conn, &items,
+		`
+		SELECT e.id AS cursor, e.created_at AS occurred_at, e.community_id,
+			p.id AS proposal_id, p.name, p.creator_addr, p.strategy, p.tags, p.start_time, p.end_time
+		FROM community_events e
+		JOIN proposals p ON p.id = e.proposal_id
+		WHERE e.community_id = $1 AND e.event_type = $2 AND e.id > $3
+			AND ($4::text[] IS NULL OR p.tags @> $4)
+			AND ($5::text IS NULL OR p.strategy = $5)
+			AND ($6::text IS NULL OR p.creator_addr = $6)
+		ORDER BY e.id DESC
+		LIMIT $7
+		`, communityId, eventType, since, tags, filters.Strategy, filters.Creator_addr, limit)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+
+	for _, item := range items {
+		item.Trigger = trigger
+		item.ID = fmt.Sprintf("proposal-%d-%s", item.Proposal_id, strings.TrimPrefix(eventType, "proposal_"))
+		if item.Tags == nil {
+			item.Tags = []string{}
+		}
+	}
+	if items == nil {
+		items = []*TriggerItem{}
+	}
+	return items, nil
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	UploadUnscanned   = "unscanned"
+	UploadClean       = "clean"
+	UploadQuarantined = "quarantined"
+)
+
+// Upload records a file written to blob storage. Uploads are keyed by the
+// sha256 of their content, so the same file is only stored once per driver.
+type Upload struct {
+	ID           int       `json:"id"`
+	Content_hash string    `json:"contentHash"`
+	Driver       string    `json:"driver"`
+	Key          string    `json:"key"`
+	Url          string    `json:"url"`
+	Content_type string    `json:"contentType"`
+	Size         int64     `json:"size"`
+	Created_at   time.Time `json:"createdAt"`
+
+	// Status is the malware scan outcome; quarantined files are recorded
+	// so they are rejected on sight, but their content is never stored.
+	Status      string     `json:"status"`
+	Scan_result *string    `json:"scanResult,omitempty"`
+	Scanned_at  *time.Time `json:"scannedAt,omitempty"`
+
+	// private uploads are only served through signed URLs, issued to
+	// members of the community they were uploaded for
+	Private      bool `json:"private"`
+	Community_id *int `json:"communityId,omitempty"`
+
+	// Cid is set once the file is on IPFS, either stored there or pinned
+	// when attached to a proposal
+	Cid *string `json:"cid,omitempty"`
+}
+
+type UploadPayload struct {
+	s.TimestampSignaturePayload
+	Community_id *int `json:"communityId"`
+	Private      bool `json:"private"`
+}
+
+type SignedURL struct {
+	Url        string    `json:"url"`
+	Expires_at time.Time `json:"expiresAt"`
+}
+
+// GetUploadByHash returns nil when the content has not been uploaded with
+// the same visibility.
+func GetUploadByHash(db *s.Database, driver, contentHash string, private bool, communityId *int) (*Upload, error) {
+	var u Upload
+	sql := `
+	SELECT * FROM uploads
+	WHERE driver = $1 AND content_hash = $2 AND private = $3
+	AND COALESCE(community_id, 0) = COALESCE($4, 0)
+	`
+	err := pgxscan.Get(db.Context, db.Conn, &u, sql, driver, contentHash, private, communityId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// GetUploadByKey loads an upload stored under the key. Keys are content
+// addressed, so uploads sharing a key share their bytes.
+func (u *Upload) GetUploadByKey(db *s.Database) error {
+	sql := `SELECT * FROM uploads WHERE driver = $1 AND key = $2 ORDER BY id LIMIT 1`
+	return pgxscan.Get(db.Context, db.Conn, u, sql, u.Driver, u.Key)
+}
+
+func (u *Upload) GetUploadById(db *s.Database) error {
+	sql := `SELECT * FROM uploads WHERE id = $1`
+	return pgxscan.Get(db.Context, db.Conn, u, sql, u.ID)
+}
+
+// IsQuarantined reports whether the content was flagged by a scan under
+// any driver or visibility.
+func IsQuarantined(db *s.Database, contentHash string) (bool, error) {
+	var quarantined bool
+	sql := `SELECT EXISTS(SELECT 1 FROM uploads WHERE content_hash = $1 AND status = $2)`
+	err := db.Conn.QueryRow(db.Context, sql, contentHash, UploadQuarantined).Scan(&quarantined)
+	return quarantined, err
+}
+
+// Create inserts the upload, or loads the existing row when the same
+// content was stored concurrently.
+func (u *Upload) Create(db *s.Database) error {
+	sql := `
+	INSERT INTO uploads(content_hash, driver, key, url, content_type, size, status, scan_result, scanned_at, private, community_id, cid)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	ON CONFLICT (driver, content_hash, private, COALESCE(community_id, 0))
+	DO UPDATE SET content_hash = EXCLUDED.content_hash
+	RETURNING *
+	`
+	return pgxscan.Get(
+		db.Context,
+		db.Conn,
+		u,
+		sql,
+		u.Content_hash,
+		u.Driver,
+		u.Key,
+		u.Url,
+		u.Content_type,
+		u.Size,
+		u.Status,
+		u.Scan_result,
+		u.Scanned_at,
+		u.Private,
+		u.Community_id,
+		u.Cid,
+	)
+}
+
+func (u *Upload) SetCid(db *s.Database, cid string) error {
+	_, err := db.Conn.Exec(db.Context, `UPDATE uploads SET cid = $2 WHERE id = $1`, u.ID, cid)
+	if err == nil {
+		u.Cid = &cid
+	}
+	return err
+}
@@ -0,0 +1,212 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+// What the platform pays for on behalf of communities, counted per calendar
+// month: votes relayed without gas, records pinned to IPFS, bytes of
+// community uploads stored, and notifications sent.
+const (
+	UsageVotes         = "votes"
+	UsagePins          = "pins"
+	UsageStorage       = "storage"
+	UsageNotifications = "notifications"
+)
+
+var USAGE_METRICS = []string{
+	UsageVotes,
+	UsagePins,
+	UsageStorage,
+	UsageNotifications,
+}
+
+// How close a community is to a quota. Admins are warned past the soft
+// limit, and use is refused once the quota is reached.
+const (
+	UsageOk      = "ok"
+	UsageWarning = "warning"
+	UsageBlocked = "blocked"
+)
+
+// UsageMetric is how much of one quota a community used this month. A
+// metric without a quota has no limits.
+type UsageMetric struct {
+	Metric     string `json:"metric"`
+	Used       int64  `json:"used"`
+	Quota      *int64 `json:"quota,omitempty"`
+	Soft_limit *int64 `json:"softLimit,omitempty"`
+	Status     string `json:"status"`
+}
+
+// UsageRecord is what a community used of one metric in one month.
+type UsageRecord struct {
+	Metric string    `json:"metric"`
+	Period time.Time `json:"period"`
+	Amount int64     `json:"amount"`
+}
+
+type CommunityUsage struct {
+	Community_id int           `json:"communityId"`
+	Period       time.Time     `json:"period"`
+	Resets_at    time.Time     `json:"resetsAt"`
+	Metrics      []UsageMetric `json:"metrics"`
+	History      []UsageRecord `json:"history"`
+}
+
+// CommunityQuotasPayload sets a community's own quotas. A null quota goes
+// back to the platform's, and 0 lifts the limit.
+type CommunityQuotasPayload struct {
+	Quotas map[string]*int64 `json:"quotas" validate:"required,min=1"`
+
+	s.TimestampSignaturePayload
+}
+
+func EnsureValidUsageMetric(metric string) error {
+	for _, m := range USAGE_METRICS {
+		if m == metric {
+			return nil
+		}
+	}
+	return fmt.Errorf("Usage metric %q is not one of votes, pins, storage or notifications.", metric)
+}
+
+// UsagePeriod is the month t falls in, as its first day in UTC.
+func UsagePeriod(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// NewUsageMetric compares what was used to a quota, 0 being none, with the
+// soft limit at softLimit of it.
+func NewUsageMetric(metric string, used, quota int64, softLimit float64) UsageMetric {
+	m := UsageMetric{Metric: metric, Used: used, Status: UsageOk}
+	if quota <= 0 {
+		return m
+	}
+	soft := int64(math.Ceil(float64(quota) * softLimit))
+	m.Quota, m.Soft_limit = &quota, &soft
+	switch {
+	case used >= quota:
+		m.Status = UsageBlocked
+	case used >= soft:
+		m.Status = UsageWarning
+	}
+	return m
+}
+
+// RecordUsage adds to what a community used of a metric in a period, and
+// returns the new total.
+func RecordUsage(db *s.Database, communityId int, metric string, period time.Time, amount int64) (int64, error) {
+	var used int64
+	err := db.Conn.QueryRow(db.Context,
+		`
+		INSERT INTO community_usage(community_id, metric, period, amount)
+		VALUES($1, $2, $3, $4)
+		ON CONFLICT (community_id, metric, period) DO UPDATE
+		SET amount = community_usage.amount + $4
+		RETURNING amount
+		`, communityId, metric, period, amount).Scan(&used)
+	return used, err
+}
+
+// GetUsage returns what a community used of every metric in a period.
+// Metrics it didn't use are missing.
+func GetUsage(db *s.Database, communityId int, period time.Time) (map[string]int64, error) {
+	var records []UsageRecord
+	err := pgxscan.Select(db.Context, db.Conn, &records,
+		`
+		SELECT metric, period, amount FROM community_usage
+		WHERE community_id = $1 AND period = $2
+		`, communityId, period)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+
+	usage := map[string]int64{}
+	for _, r := range records {
+		usage[r.Metric] = r.Amount
+	}
+	return usage, nil
+}
+
+// GetUsageHistory lists what a community used from the period since on,
+// newest first.
+func GetUsageHistory(db *s.Database, communityId int, since time.Time) ([]UsageRecord, error) {
+	var records []UsageRecord
+	err := pgxscan.Select(db.Context, db.Conn, &records,
+		`
+		SELECT metric, period, amount FROM community_usage
+		WHERE community_id = $1 AND period >= $2
+		ORDER BY period DESC, metric ASC
+		`, communityId, since)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, err
+	}
+	if records == nil {
+		records = []UsageRecord{}
+	}
+	return records, nil
+}
+
+// MarkUsageWarned records that admins were warned about a metric for a
+// period, and reports whether they hadn't been yet.
+func MarkUsageWarned(db *s.Database, communityId int, metric string, period time.Time) (bool, error) {
+	tag, err := db.Conn.Exec(db.Context,
+		`
+		UPDATE community_usage SET warned_at = (now() at time zone 'utc')
+		WHERE community_id = $1 AND metric = $2 AND period = $3 AND warned_at IS NULL
+		`, communityId, metric, period)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// GetCommunityQuotas returns the quotas platform admins set for a
+// community, by metric.
+func GetCommunityQuotas(db *s.Database, communityId int) (map[string]int64, error) {
+	rows, err := db.Conn.Query(db.Context,
+		`SELECT metric, quota FROM community_quotas WHERE community_id = $1`,
+		communityId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	quotas := map[string]int64{}
+	for rows.Next() {
+		var metric string
+		var quota int64
+		if err := rows.Scan(&metric, &quota); err != nil {
+			return nil, err
+		}
+		quotas[metric] = quota
+	}
+	return quotas, rows.Err()
+}
+
+// SetCommunityQuota sets a community's own quota for a metric, or removes
+// it when quota is nil.
+func SetCommunityQuota(db *s.Database, communityId int, metric string, quota *int64, updatedBy string) error {
+	if quota == nil {
+		_, err := db.Conn.Exec(db.Context,
+			`DELETE FROM community_quotas WHERE community_id = $1 AND metric = $2`,
+			communityId, metric)
+		return err
+	}
+	_, err := db.Conn.Exec(db.Context,
+		`
+		INSERT INTO community_quotas(community_id, metric, quota, updated_by)
+		VALUES($1, $2, $3, $4)
+		ON CONFLICT (community_id, metric) DO UPDATE
+		SET quota = $3, updated_by = $4, updated_at = (now() at time zone 'utc')
+		`, communityId, metric, *quota, updatedBy)
+	return err
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+)
+
+var ErrSignatureReused = errors.New("Signature has already been used.")
+
+// SignedMessageHash identifies a message signed by an address, whatever
+// signature was produced for it.
+func SignedMessageHash(addr, message string) string {
+	sum := sha256.Sum256([]byte(addr + "\n" + message))
+	return hex.EncodeToString(sum[:])
+}
+
+// UseSignedMessage records the message as used, returning
+// ErrSignatureReused when it was used before.
+func UseSignedMessage(db *s.Database, addr, message string) error {
+	tag, err := db.Conn.Exec(db.Context,
+		`
+		INSERT INTO used_signatures(message_hash, addr)
+		VALUES($1, $2)
+		ON CONFLICT (message_hash) DO NOTHING
+		`, SignedMessageHash(addr, message), addr)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrSignatureReused
+	}
+	return nil
+}
+
+// PurgeUsedSignatures forgets messages used before the cutoff, which must
+// be older than any timestamp still accepted.
+func PurgeUsedSignatures(db *s.Database, before time.Time) error {
+	_, err := db.Conn.Exec(db.Context, `DELETE FROM used_signatures WHERE used_at < $1`, before)
+	return err
+}
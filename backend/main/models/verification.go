@@ -0,0 +1,77 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// Votes are verified like pinned records, though imported votes are the only
+// ones with a CID.
+const RecordVote = "vote"
+
+// CidVerification reports whether a record still matches what was pinned
+// to IPFS under its CID.
+type CidVerification struct {
+	Record_type    string   `json:"recordType"`
+	Record_id      int      `json:"recordId"`
+	Cid            *string  `json:"cid"`
+	Expected_cid   *string  `json:"expectedCid,omitempty"`
+	Cid_matches    bool     `json:"cidMatches"`
+	Pinned_cid     *string  `json:"pinnedCid,omitempty"`
+	Pinned_matches bool     `json:"pinnedMatches"`
+	Fetched        bool     `json:"fetched"`
+	Mismatches     []string `json:"mismatches"`
+	Verified       bool     `json:"verified"`
+}
+
+// SignatureVerification is the outcome of checking a signature against the
+// keys the account had at Block_height.
+type SignatureVerification struct {
+	Block_height uint64 `json:"blockHeight"`
+	Valid        bool   `json:"valid"`
+}
+
+type VoteVerification struct {
+	CidVerification
+	Signature *SignatureVerification `json:"signature,omitempty"`
+}
+
+// Fields the platform updates after a record is pinned, which don't mean
+// the record was tampered with.
+var derivedFields = map[string][]string{
+	PinProposal: {"cid", "computedStatus", "total_votes", "achievementsDone", "version", "updatedAt"},
+	RecordVote:  {"cid", "isEarly", "isWinning", "isCancelled"},
+}
+
+// DiffRecordFields returns the top-level fields whose values differ between
+// a record and the content pinned for it, leaving out derived fields.
+func DiffRecordFields(recordType string, record, pinned []byte) ([]string, error) {
+	var want, got map[string]interface{}
+	if err := json.Unmarshal(record, &want); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(pinned, &got); err != nil {
+		return nil, err
+	}
+
+	for _, field := range derivedFields[recordType] {
+		delete(want, field)
+		delete(got, field)
+	}
+
+	diffs := []string{}
+	for field, value := range want {
+		if !reflect.DeepEqual(value, got[field]) {
+			diffs = append(diffs, field)
+		}
+	}
+	for field := range got {
+		if _, ok := want[field]; !ok {
+			diffs = append(diffs, field)
+		}
+	}
+
+	sort.Strings(diffs)
+	return diffs, nil
+}
@@ -20,7 +20,7 @@ type Vote struct {
 	ID                   int                     `json:"id,omitempty"`
 	Proposal_id          int                     `json:"proposalId"`
 	Addr                 string                  `json:"addr"                validate:"required"`
-	Choice               string                  `json:"choice"              validate:"required"`
+	Choice               string                  `json:"choice"              validate:"required_without=Allocation"`
 	Composite_signatures *[]s.CompositeSignature `json:"compositeSignatures" validate:"required"`
 	Created_at           time.Time               `json:"createdAt,omitempty"`
 	Cid                  *string                 `json:"cid"`
@@ -29,8 +29,27 @@ type Vote struct {
 	IsCancelled          bool                    `json:"isCancelled"`
 	IsEarly              bool                    `json:"isEarly"`
 	IsWinning            bool                    `json:"isWinning"`
+	// Signature_block_height is the sealed height the signature was
+	// checked at, so it can be checked again against the keys of the time.
+	Signature_block_height *uint64 `json:"signatureBlockHeight,omitempty"`
+	// Source tells whether the vote was signed for CAST or cast on chain,
+	// in which case it carries the transaction and event it came from.
+	Source      string  `json:"source"`
+	Tx_id       *string `json:"txId,omitempty"`
+	Event_index *int    `json:"eventIndex,omitempty"`
+	// Allocation splits the vote across choices by percent, on proposals
+	// that allow it. Choice is then the choice with the largest share.
+	Allocation map[string]float64 `json:"allocation,omitempty"`
+	// Weight_multiplier scales the weight the strategy gives the vote, for
+	// members whose voting power decayed.
+	Weight_multiplier *float64 `json:"weightMultiplier,omitempty"`
 }
 
+const (
+	VoteOffchain = "offchain"
+	VoteOnchain  = "onchain"
+)
+
 type VoteWithBalance struct {
 	// Extend Vote
 	Vote
@@ -43,6 +62,8 @@ type VoteWithBalance struct {
 	Weight                  *float64 `json:"weight"`
 
 	NFTs []*NFT
+
+	Profile *ProfileSummary `json:"profile,omitempty" db:"-"`
 }
 
 type NFT struct {
@@ -52,6 +73,22 @@ type NFT struct {
 	Float_event_id uint64      `json:"event_id,omitempty"`
 }
 
+type VoteReceipt struct {
+	Proposal_id          int                     `json:"proposalId"`
+	Addr                 string                  `json:"addr"`
+	Choice               string                  `json:"choice"`
+	Allocation           map[string]float64      `json:"allocation,omitempty"`
+	Message              string                  `json:"message"`
+	Composite_signatures *[]s.CompositeSignature `json:"compositeSignatures"`
+	Voucher              *shared.Voucher         `json:"voucher,omitempty"`
+	Cid                  *string                 `json:"cid"`
+	Block_height         *uint64                 `json:"blockHeight"`
+	Weight               *float64                `json:"weight"`
+	Strategy             *string                 `json:"strategy"`
+	Created_at           time.Time               `json:"createdAt"`
+	Issued_at            time.Time               `json:"issuedAt"`
+}
+
 type VotingStreak struct {
 	Proposal_id  uint64
 	Addr         string
@@ -150,6 +187,64 @@ func GetAllVotesForProposal(db *s.Database, proposalId int, strategy string) ([]
 	return votes, nil
 }
 
+// StreamVotesForProposal hands a proposal's votes to fn in chunks of at most
+// chunkSize, in id order, starting after afterId. Each chunk is read with its
+// own query, so fn is free to use the database.
+func StreamVotesForProposal(
+	db *s.Database,
+	proposalId int,
+	strategy string,
+	afterId int,
+	chunkSize int,
+	fn func(votes []*VoteWithBalance) error,
+) error {
+	sql := `select v.*,
+		b.primary_account_balance,
+		b.secondary_account_balance,
+		b.staking_balance,
+		COALESCE(p.block_height, 0) as block_height
+	from votes v
+	join proposals p on p.id = $1
+	left join balances b on b.addr = v.addr
+		and p.block_height = b.block_height
+	where proposal_id = $1 and v.id > $2
+	order by v.id
+	limit $3
+	`
+
+	for {
+		var votes []*VoteWithBalance
+		if err := pgxscan.Select(db.Context, db.Conn, &votes, sql, proposalId, afterId, chunkSize); err != nil {
+			return err
+		}
+		if len(votes) == 0 {
+			return nil
+		}
+		afterId = votes[len(votes)-1].ID
+
+		if IsNFTStrategy(strategy) {
+			var err error
+			if votes, err = getUsersNFTs(db, votes); err != nil {
+				return err
+			}
+		}
+
+		if err := fn(votes); err != nil {
+			return err
+		}
+		if len(votes) < chunkSize {
+			return nil
+		}
+	}
+}
+
+// VoteSortColumns are the fields votes can be sorted by.
+var VoteSortColumns = shared.SortColumns{
+	"id":         "v.id",
+	"addr":       "v.addr",
+	"created_at": "v.created_at",
+}
+
 func GetVotesForProposal(
 	db *s.Database,
 	proposalId int,
@@ -164,6 +259,7 @@ func GetVotesForProposal(
 	} else {
 		orderBySql = "ORDER BY b.created_at ASC"
 	}
+	orderBySql = VoteSortColumns.OrderBy(pageParams.Sort, orderBySql)
 
 	//return all balances, strategy will do rest of the work
 	sql := `select v.*, p.block_height, 
@@ -248,38 +344,29 @@ func (v *Vote) GetVoteById(db *s.Database) error {
 func (v *Vote) CreateVote(db *s.Database) error {
 	var defaultEarlyVoteLength = 2 // in hours
 
-	err := createVote(db, v)
-	if err != nil {
-		return err
-	}
-
-	proposal, err := getProposal(db, v.Proposal_id)
-	if err != nil {
-		return err
-	}
-
-	isEarlyVote := v.Created_at.Before(proposal.Start_time.Add(time.Hour * time.Duration(defaultEarlyVoteLength)))
+	return db.WithTx(func(tx *s.Database) error {
+		if err := createVote(tx, v); err != nil {
+			return err
+		}
 
-	if isEarlyVote {
-		err = AddEarlyVoteAchievement(db, v)
+		proposal, err := getProposal(tx, v.Proposal_id)
 		if err != nil {
 			return err
 		}
-	}
 
-	return nil
+		isEarlyVote := v.Created_at.Before(proposal.Start_time.Add(time.Hour * time.Duration(defaultEarlyVoteLength)))
+
+		if isEarlyVote {
+			return AddEarlyVoteAchievement(tx, v)
+		}
+		return nil
+	})
 }
 
 func ValidateVoteMessage(message string, proposal Proposal) error {
-	log.Info().Msgf("validating message: %s", message)
-	vars := strings.Split(message, ":")
-
-	// check proposal choices to see if choice is valid
-	encodedChoice := vars[1]
-	choiceBytes, err := hex.DecodeString(encodedChoice)
-
+	choiceBytes, err := decodeVoteMessage(message)
 	if err != nil {
-		return errors.New("couldnt decode choice in message from hex string")
+		return err
 	}
 
 	validChoice := false
@@ -293,15 +380,31 @@ func ValidateVoteMessage(message string, proposal Proposal) error {
 		return errors.New("invalid choice for proposal")
 	}
 
+	return nil
+}
+
+// decodeVoteMessage returns the choice of a <proposalId>:<choice>:<timestamp>
+// message, once its timestamp is checked.
+func decodeVoteMessage(message string) ([]byte, error) {
+	log.Info().Msgf("validating message: %s", message)
+	vars := strings.Split(message, ":")
+
+	encodedChoice := vars[1]
+	choiceBytes, err := hex.DecodeString(encodedChoice)
+
+	if err != nil {
+		return nil, errors.New("couldnt decode choice in message from hex string")
+	}
+
 	// check timestamp and ensure no longer than 60 seconds has passed
 	timestamp, _ := strconv.ParseInt(vars[2], 10, 64)
 	uxTime := time.Unix(timestamp/1000, (timestamp%1000)*1000*1000)
 	diff := time.Now().UTC().Sub(uxTime).Seconds()
 	if diff > timestampExpiry {
-		return errors.New("timestamp on request has expired")
+		return nil, errors.New("timestamp on request has expired")
 	}
 
-	return nil
+	return choiceBytes, nil
 }
 
 func (v *Vote) ValidateChoice(proposal Proposal) error {
@@ -379,13 +482,23 @@ func DoesNFTExist(db *s.Database, v *VoteWithBalance) (bool, error) {
 }
 
 func createVote(db *s.Database, v *Vote) error {
+	if v.Source == "" {
+		v.Source = VoteOffchain
+	}
+	var allocation interface{}
+	if len(v.Allocation) > 0 {
+		allocation = v.Allocation
+	}
 	// Create Vote
 	err := db.Conn.QueryRow(db.Context,
 		`
-			INSERT INTO votes(proposal_id, addr, choice, composite_signatures, cid, message)
-			VALUES($1, $2, $3, $4, $5, $6)
+			INSERT INTO votes(proposal_id, addr, choice, composite_signatures, cid, message, signature_block_height,
+				source, tx_id, event_index, allocation, weight_multiplier)
+			VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 			RETURNING id, created_at
-		`, v.Proposal_id, v.Addr, v.Choice, v.Composite_signatures, v.Cid, v.Message).Scan(&v.ID, &v.Created_at)
+		`, v.Proposal_id, v.Addr, v.Choice, v.Composite_signatures, v.Cid, v.Message, v.Signature_block_height,
+		v.Source, v.Tx_id, v.Event_index, allocation, v.Weight_multiplier).
+		Scan(&v.ID, &v.Created_at)
 
 	return err
 }
@@ -410,7 +523,7 @@ func AddEarlyVoteAchievement(db *s.Database, v *Vote) error {
 	return nil
 }
 
-func AddWinningVoteAchievement(db *s.Database, votes []*VoteWithBalance, p ProposalResults) error {
+func getWinningChoice(p ProposalResults) string {
 	maxVotes := 0
 	winningChoice := ""
 	for k, v := range p.Results {
@@ -419,6 +532,11 @@ func AddWinningVoteAchievement(db *s.Database, votes []*VoteWithBalance, p Propo
 			winningChoice = k
 		}
 	}
+	return winningChoice
+}
+
+func AddWinningVoteAchievement(db *s.Database, votes []*VoteWithBalance, p ProposalResults) error {
+	winningChoice := getWinningChoice(p)
 	for _, v := range votes {
 		if v.Choice == winningChoice {
 			_, err := db.Conn.Exec(db.Context, `UPDATE votes SET is_winning = 'true' WHERE id = $1`, v.ID)
@@ -428,7 +546,7 @@ func AddWinningVoteAchievement(db *s.Database, votes []*VoteWithBalance, p Propo
 		}
 	}
 
-	_, err := db.Conn.Exec(db.Context, `UPDATE proposals SET achievements_done = 'true' WHERE id = $1`, p.Proposal_id)
+	_, err := db.Conn.Exec(db.Context, `UPDATE proposals SET achievements_done = 'true', updated_at = (now() at time zone 'utc') WHERE id = $1`, p.Proposal_id)
 	if err != nil {
 		return err
 	}
@@ -437,12 +555,23 @@ func AddWinningVoteAchievement(db *s.Database, votes []*VoteWithBalance, p Propo
 }
 
 func getStreakAchievement(db *s.Database, addr string, communityId int) (int, error) {
-	streaks := 0
-	votes, err := getUserVotes(db, addr, communityId)
+	streaks, err := getVotingStreaks(db, addr, communityId)
 	if err != nil {
 		return 0, err
 	}
 
+	return len(streaks), nil
+}
+
+// getVotingStreaks returns the proposal ids of each voting streak of the address
+// in the community.
+func getVotingStreaks(db *s.Database, addr string, communityId int) ([][]uint64, error) {
+	var streaks [][]uint64
+	votes, err := getUserVotes(db, addr, communityId)
+	if err != nil {
+		return nil, err
+	}
+
 	if len(votes) >= defaultStreakLength {
 		var proposals []uint64
 		for i, vote := range votes {
@@ -451,7 +580,7 @@ func getStreakAchievement(db *s.Database, addr string, communityId int) (int, er
 				proposals = append(proposals, vote.Proposal_id)
 				// check if vote is last in a streak
 				if len(proposals) >= defaultStreakLength && (i == len(votes)-1 || (i < len(votes)-1 && votes[i+1].Addr == "")) {
-					streaks = streaks + 1
+					streaks = append(streaks, proposals)
 
 					// reset proposals to check for other streaks
 					proposals = nil
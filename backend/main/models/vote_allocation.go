@@ -0,0 +1,89 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// a split vote's percents may be off 100 by rounding, no more
+const allocationTolerance = 1e-6
+
+// ValidateAllocation checks a split vote gives a positive percent to
+// choices of the proposal, adding up to 100.
+func (v *Vote) ValidateAllocation(proposal Proposal) error {
+	if !proposal.Allow_split_votes {
+		return errors.New("proposal does not allow split votes")
+	}
+	if len(v.Allocation) == 0 {
+		return errors.New("allocation must split the vote across at least one choice")
+	}
+
+	choices := make(map[string]bool, len(proposal.Choices))
+	for _, choice := range proposal.Choices {
+		choices[choice.Choice_text] = true
+	}
+
+	total := 0.0
+	for choice, percent := range v.Allocation {
+		if !choices[choice] {
+			return fmt.Errorf("%q is not a choice of proposal %d", choice, proposal.ID)
+		}
+		if percent <= 0 || math.IsNaN(percent) {
+			return fmt.Errorf("the percent given to %q must be positive", choice)
+		}
+		total += percent
+	}
+	if math.Abs(total-100) > allocationTolerance {
+		return fmt.Errorf("percents must add up to 100, not %g", total)
+	}
+	return nil
+}
+
+// LeadingChoice is the choice given the largest share of a split vote, the
+// first of the proposal's choices on a tie.
+func (v *Vote) LeadingChoice(proposal Proposal) string {
+	leading, largest := "", 0.0
+	for _, choice := range proposal.Choices {
+		if percent := v.Allocation[choice.Choice_text]; percent > largest {
+			leading, largest = choice.Choice_text, percent
+		}
+	}
+	return leading
+}
+
+// Shares are the fractions of the vote's weight each choice gets, all of it
+// going to the choice of a vote that isn't split.
+func (v *Vote) Shares() map[string]float64 {
+	if len(v.Allocation) == 0 {
+		return map[string]float64{v.Choice: 1}
+	}
+	shares := make(map[string]float64, len(v.Allocation))
+	for choice, percent := range v.Allocation {
+		shares[choice] = percent / 100
+	}
+	return shares
+}
+
+// ValidateMessage checks the signed message of the vote. Split votes sign
+// the JSON of their allocation in place of a choice.
+func (v *Vote) ValidateMessage(message string, proposal Proposal) error {
+	if len(v.Allocation) == 0 {
+		return ValidateVoteMessage(message, proposal)
+	}
+
+	allocationBytes, err := decodeVoteMessage(message)
+	if err != nil {
+		return err
+	}
+	var signed map[string]float64
+	if err := json.Unmarshal(allocationBytes, &signed); err != nil {
+		return errors.New("couldnt decode allocation in message")
+	}
+	if !reflect.DeepEqual(signed, v.Allocation) {
+		return errors.New("allocation does not match the signed message")
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+// CachedWeight is a balance read from Flow at a block height. Balances at
+// a given height never change, so entries are kept without expiry.
+type CachedWeight struct {
+	Addr                      string    `json:"addr"`
+	Strategy                  string    `json:"strategy"`
+	Block_height              uint64    `json:"blockHeight"`
+	Primary_account_balance   uint64    `json:"primaryAccountBalance"`
+	Secondary_account_balance uint64    `json:"secondaryAccountBalance"`
+	Staking_balance           uint64    `json:"stakingBalance"`
+	Balance                   uint64    `json:"balance"`
+	Created_at                time.Time `json:"createdAt"`
+}
+
+// WeightCacheKey identifies the strategy and token contract a cached
+// balance was read for.
+func WeightCacheKey(strategy *Strategy) string {
+	var name, addr, contract string
+	if strategy.Name != nil {
+		name = *strategy.Name
+	}
+	if strategy.Contract.Addr != nil {
+		addr = *strategy.Contract.Addr
+	}
+	if strategy.Contract.Name != nil {
+		contract = *strategy.Contract.Name
+	}
+	return fmt.Sprintf("%s:%s.%s", name, addr, contract)
+}
+
+// GetCachedWeight returns nil when nothing has been cached for the key.
+func GetCachedWeight(db *s.Database, addr, strategy string, blockHeight uint64) (*CachedWeight, error) {
+	var w CachedWeight
+	sql := `
+	SELECT * FROM weight_cache
+	WHERE addr = $1 AND strategy = $2 AND block_height = $3
+	`
+	err := pgxscan.Get(db.Context, db.Conn, &w, sql, addr, strategy, blockHeight)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (w *CachedWeight) Save(db *s.Database) error {
+	sql := `
+	INSERT INTO weight_cache (addr, strategy, block_height, primary_account_balance,
+	    secondary_account_balance, staking_balance, balance)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	ON CONFLICT (addr, strategy, block_height) DO NOTHING
+	`
+	_, err := db.Conn.Exec(db.Context, sql,
+		w.Addr, w.Strategy, w.Block_height, w.Primary_account_balance,
+		w.Secondary_account_balance, w.Staking_balance, w.Balance,
+	)
+	return err
+}
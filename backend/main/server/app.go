@@ -11,12 +11,13 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/DapperCollectives/CAST/backend/main/middleware"
 	"github.com/DapperCollectives/CAST/backend/main/models"
 	"github.com/DapperCollectives/CAST/backend/main/shared"
 	"github.com/DapperCollectives/CAST/backend/main/strategies"
-	"github.com/axiomzen/envconfig"
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx/v4/pgxpool"
 
@@ -45,21 +46,55 @@ type App struct {
 	DB          *shared.Database
 	IpfsClient  *shared.IpfsClient
 	FlowAdapter *shared.FlowAdapter
+	TokenList   *shared.TokenList
+	Storage     shared.Storage
+	Scanner     shared.Scanner
+	Sanitizer   *shared.Sanitizer
+	Classifier  shared.Moderator
+
+	ReceiptSigner      *shared.ReceiptSigner
+	TokenSigner        *shared.TokenSigner
+	SignatureVerifiers *shared.SignatureVerifiers
+	IdentityVerifiers  map[string]shared.IdentityVerifier
 
 	TxOptionsAddresses []string
 	Env                string
+	PublicAppURL       string
+	PublicApiURL       string
 	AdminAllowlist     shared.Allowlist
 	CommunityBlocklist shared.Allowlist
+	Tenants            tenantDirectory
 	Config             shared.Config
+	CustomScripts      []shared.CustomScript
+	ApiKeyLimits       *rateLimiter
+
+	// lifecycle, see lifecycle.go
+	server   *http.Server
+	ctx      context.Context
+	stop     context.CancelFunc
+	mu       sync.Mutex
+	stopping bool
+	workers  sync.WaitGroup
+	jobRuns  map[string]time.Time
 }
 
 type Strategy interface {
+	// TallyVotes adds the weight of votes to the totals in p, so a
+	// proposal can be tallied one chunk of votes at a time.
 	TallyVotes(votes []*models.VoteWithBalance, p *models.ProposalResults, proposal *models.Proposal) (models.ProposalResults, error)
 	GetVotes(votes []*models.VoteWithBalance, proposal *models.Proposal) ([]*models.VoteWithBalance, error)
 	GetVoteWeightForBalance(vote *models.VoteWithBalance, proposal *models.Proposal) (float64, error)
 	InitStrategy(f *shared.FlowAdapter, db *shared.Database)
 	FetchBalance(b *models.Balance, p *models.Proposal) (*models.Balance, error)
 	RequiresSnapshot() bool
+	EstimateWeight(addr string, strategy *models.Strategy, blockHeight uint64) (float64, error)
+}
+
+// WeightBoundedStrategy is a Strategy that knows the most weight still to be
+// cast on a proposal, so its proposals can close once their outcome is
+// decided.
+type WeightBoundedStrategy interface {
+	RemainingWeight(proposal *models.Proposal) (float64, error)
 }
 
 var strategyMap = map[string]Strategy{
@@ -69,10 +104,10 @@ var strategyMap = map[string]Strategy{
 	"balance-of-nfts":               &strategies.BalanceOfNfts{},
 	"float-nfts":                    &strategies.FloatNFTs{},
 	"custom-script":                 &strategies.CustomScript{},
+	"reputation-weighted":           &strategies.ReputationWeighted{},
+	"allowlist-one-vote":            &strategies.AllowlistOneVote{},
 }
 
-var customScripts []shared.CustomScript
-
 var helpers Helpers
 
 //////////////////////
@@ -81,6 +116,9 @@ var helpers Helpers
 
 func (a *App) Initialize() {
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stdout})
+	// logging outside of requests goes through the global logger
+	zerolog.DefaultContextLogger = &log.Logger
+	a.ctx, a.stop = context.WithCancel(context.Background())
 
 	// Env
 	env := os.Getenv("APP_ENV")
@@ -102,11 +140,15 @@ func (a *App) Initialize() {
 	}
 
 	// Set App-wide Config
-	err := envconfig.Process("FVT", &a.Config)
+	var err error
+	a.Config, err = shared.LoadConfig()
 	if err != nil {
 		log.Error().Err(err).Msg("Error Reading Configuration.")
 		os.Exit(1)
 	}
+	for _, warning := range a.Config.Warnings() {
+		log.Warn().Msg(warning)
+	}
 
 	////////////
 	// Clients
@@ -120,11 +162,9 @@ func (a *App) Initialize() {
 	flag.Parse()
 	if *arg == "local" {
 		os.Setenv("APP_ENV", "DEV")
+		a.Config.App_env = "DEV"
 	}
 
-	// Postgres
-	dbname := os.Getenv("DB_NAME")
-
 	// IPFS
 	if os.Getenv("APP_ENV") == "TEST" || os.Getenv("APP_ENV") == "DEV" {
 		flag.Bool("ipfs-override", true, "overrides ipfs call")
@@ -132,50 +172,129 @@ func (a *App) Initialize() {
 		flag.Bool("ipfs-override", false, "overrides ipfs call")
 	}
 
-	// TEST Env
-	if os.Getenv("APP_ENV") == "TEST" {
-		dbname = os.Getenv("TEST_DB_NAME")
-	}
-
 	// Postgres
-	a.ConnectDB(
-		os.Getenv("DB_USERNAME"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_PORT"),
-		dbname,
-	)
+	a.connectDatabase()
+	if err := a.CheckSchemaVersion(); err != nil {
+		log.Fatal().Err(err).Msg("Database schema check failed.")
+	}
 
 	// IPFS
-	a.IpfsClient = shared.NewIpfsClient(os.Getenv("IPFS_KEY"), os.Getenv("IPFS_SECRET"))
+	a.IpfsClient = shared.NewIpfsClient(a.Config.Ipfs_key, a.Config.Ipfs_secret)
+	if gateway := a.Config.Ipfs_gateway_url; gateway != "" {
+		a.IpfsClient.GatewayURL = gateway
+	}
 
 	// Flow
 
 	// Load custom scripts for strategies
-	scripts, err := ioutil.ReadFile("./main/cadence/scripts/custom/scripts.json")
+	scripts, err := ioutil.ReadFile(a.Config.Custom_scripts_path)
 	if err != nil {
 		log.Error().Err(err).Msg("Error Reading Custom Strategy scripts.")
 	}
 
-	err = json.Unmarshal(scripts, &customScripts)
+	err = json.Unmarshal(scripts, &a.CustomScripts)
 	if err != nil {
 		log.Error().Err(err).Msg("Error during Unmarshalling custom scripts")
 	}
 
 	// Create Map for Flow Adaptor to look up when voting
 	var customScriptsMap = make(map[string]shared.CustomScript)
-	for _, script := range customScripts {
+	for _, script := range a.CustomScripts {
 		customScriptsMap[script.Key] = script
 	}
 
-	if os.Getenv("FLOW_ENV") == "" {
-		os.Setenv("FLOW_ENV", "emulator")
+	// the rest of the app reads FLOW_ENV from the environment
+	os.Setenv("FLOW_ENV", a.Config.Flow_env)
+	a.FlowAdapter = shared.NewFlowClient(a.Config.Flow_env, a.Config.FlowNodeConfig, customScriptsMap)
+	a.TokenList, err = shared.NewTokenListFromEnv(os.Getenv("FLOW_ENV"))
+	if err != nil {
+		log.Error().Err(err).Msg("Error loading token list.")
+		os.Exit(1)
+	}
+	a.SignatureVerifiers, err = shared.NewSignatureVerifiersFromEnv(a.FlowAdapter)
+	if err != nil {
+		log.Error().Err(err).Msg("Error configuring signature verification.")
+		os.Exit(1)
+	}
+
+	// Vote Receipts
+	if os.Getenv("RECEIPT_SIGNING_KEY") == "" {
+		if !a.Config.IsDevelopment() {
+			log.Error().Msg("RECEIPT_SIGNING_KEY must be set, receipts signed with an ephemeral key stop verifying on restart.")
+			os.Exit(1)
+		}
+		log.Warn().Msg("RECEIPT_SIGNING_KEY not set, vote receipts will be signed with an ephemeral key.")
+	}
+	a.ReceiptSigner, err = shared.NewReceiptSigner(os.Getenv("RECEIPT_SIGNING_KEY"))
+	if err != nil {
+		log.Error().Err(err).Msg("Error creating receipt signer.")
+		os.Exit(1)
 	}
-	a.FlowAdapter = shared.NewFlowClient(os.Getenv("FLOW_ENV"), customScriptsMap)
+
+	// Signed Tokens
+	if os.Getenv("TOKEN_SIGNING_SECRET") == "" {
+		if !a.Config.IsDevelopment() {
+			log.Error().Msg("TOKEN_SIGNING_SECRET must be set, tokens signed with a random secret stop verifying on restart and on other instances.")
+			os.Exit(1)
+		}
+		log.Warn().Msg("TOKEN_SIGNING_SECRET not set, issued tokens will not survive a restart.")
+	}
+	a.TokenSigner, err = shared.NewTokenSigner(os.Getenv("TOKEN_SIGNING_SECRET"))
+	if err != nil {
+		log.Error().Err(err).Msg("Error creating token signer.")
+		os.Exit(1)
+	}
+
+	// Uploads
+	a.Storage, err = shared.NewStorage(shared.StorageConfigFromEnv(), a.IpfsClient, a.TokenSigner)
+	if err != nil {
+		log.Error().Err(err).Msg("Error configuring upload storage.")
+		os.Exit(1)
+	}
+	a.Scanner, err = shared.NewScannerFromEnv()
+	if err != nil {
+		log.Error().Err(err).Msg("Error configuring upload scanning.")
+		os.Exit(1)
+	}
+	if a.Scanner == nil {
+		log.Warn().Msg("SCAN_DRIVER not set, uploads will not be scanned for malware.")
+	}
+
+	// Links to the app and API, in link previews
+	a.PublicAppURL = strings.TrimSuffix(a.Config.Public_app_url, "/")
+	a.PublicApiURL = strings.TrimSuffix(a.Config.Public_api_url, "/")
+
+	// User content
+	a.Sanitizer, err = shared.NewSanitizerFromEnv()
+	if err != nil {
+		log.Error().Err(err).Msg("Error configuring content sanitization.")
+		os.Exit(1)
+	}
+	a.Classifier, err = shared.NewClassifierFromEnv()
+	if err != nil {
+		log.Error().Err(err).Msg("Error configuring the moderation classifier.")
+		os.Exit(1)
+	}
+
+	// Identity providers addresses can verify with
+	a.IdentityVerifiers = shared.NewIdentityVerifiers(a.Config.IdentityConfig)
 
 	// Snapshot
 	log.Info().Msgf("SNAPSHOT_BASE_URL: %s", os.Getenv("SNAPSHOT_BASE_URL"))
-	a.TxOptionsAddresses = strings.Fields(os.Getenv("TX_OPTIONS_ADDRS"))
+	a.TxOptionsAddresses = strings.Fields(a.Config.Tx_options_addrs)
+
+	// Platform admins and blocklist, ADMIN_ADDRS only seeds an empty list
+	if err := models.SeedPlatformAdmins(a.DB, strings.Fields(a.Config.Admin_addrs)); err != nil {
+		log.Fatal().Err(err).Msg("Error seeding platform admins.")
+	}
+	if err := a.ReloadAddressLists(); err != nil {
+		log.Fatal().Err(err).Msg("Error loading platform address lists.")
+	}
+	if err := a.ReloadTenants(); err != nil {
+		log.Fatal().Err(err).Msg("Error loading tenants.")
+	}
+
+	a.ApiKeyLimits = newRateLimiter(time.Minute)
 
 	// Router
 	a.Router = mux.NewRouter()
@@ -183,16 +302,34 @@ func (a *App) Initialize() {
 
 	// Middlewares
 	a.Router.Use(mux.CORSMethodMiddleware(a.Router))
+	a.Router.Use(middleware.RequestID)
 	a.Router.Use(middleware.Logger)
+	a.Router.Use(a.resolveTenant)
+	a.Router.Use(a.scopeToTenant)
+	a.Router.Use(middleware.SecurityHeaders(a.Config))
 	a.Router.Use(middleware.UseCors(a.Config))
+	a.Router.Use(a.limitBody)
+
+	// preflights of routes without OPTIONS don't match, so the router never
+	// runs its middleware for them
+	a.Router.MethodNotAllowedHandler = middleware.UseCors(a.Config)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}))
 
 	helpers.Initialize(a)
 }
 
-func (a *App) Run() {
-	addr := fmt.Sprintf(":%s", os.Getenv("API_PORT"))
-	log.Info().Msgf("Starting server on %s ...", addr)
-	log.Fatal().Err(http.ListenAndServe(addr, a.Router)).Msgf("Server at %s crashed!", addr)
+// connectDatabase connects to the configured database, using TEST_DB_NAME
+// when running tests.
+func (a *App) connectDatabase() {
+	a.ConnectDB(
+		a.Config.Db_username,
+		a.Config.Db_password,
+		a.Config.Db_host,
+		a.Config.Db_port,
+		a.Config.DatabaseName(),
+	)
 }
 
 func (a *App) ConnectDB(username, password, host, port, dbname string) {
@@ -216,7 +353,8 @@ func (a *App) ConnectDB(username, password, host, port, dbname string) {
 		pconf.MaxConns = 1
 	}
 
-	database.Conn, err = pgxpool.ConnectConfig(database.Context, pconf)
+	database.Pool, err = pgxpool.ConnectConfig(database.Context, pconf)
+	database.Conn = database.Pool
 
 	database.Env = &a.Env
 	if err != nil {
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultBodyReadTimeout   = 10 * time.Second
+	defaultReadHeaderTimeout = 10 * time.Second
+	// long enough to upload an import of MAX_IMPORT_SIZE on a slow link
+	defaultReadTimeout = 5 * time.Minute
+)
+
+const (
+	jsonBody      = "application/json"
+	multipartBody = "multipart/form-data"
+)
+
+// bodyPolicy is what an endpoint accepts as its request body.
+type bodyPolicy struct {
+	contentType string
+	limit       int64
+}
+
+// bodyPolicy is the policy of the route, by its path template: JSON of up to
+// MAX_BODY_SIZE unless the endpoint takes files or long address lists.
+func (a *App) bodyPolicy(route string) bodyPolicy {
+	switch route {
+	case "/upload", "/lists/{id:[0-9]+}/csv":
+		return bodyPolicy{multipartBody, a.Config.Max_file_size}
+	case "/communities/import":
+		return bodyPolicy{multipartBody, a.Config.Max_import_size}
+	case "/communities/{communityId:[0-9]+}/lists", "/lists/{id:[0-9]+}/add", "/lists/{id:[0-9]+}/remove":
+		return bodyPolicy{jsonBody, a.Config.Max_import_size}
+	}
+	return bodyPolicy{jsonBody, a.Config.Max_body_size}
+}
+
+// bodyError is a request body refused while it was read, answered with its
+// own error rather than as an invalid payload.
+type bodyError struct {
+	response errorResponse
+}
+
+func (e *bodyError) Error() string {
+	return e.response.Details
+}
+
+// limitBody checks the content type of request bodies and caps their size
+// and the time they take to send, before any handler decodes them.
+func (a *App) limitBody(next http.Handler) http.Handler {
+	timeout := envDuration("BODY_READ_TIMEOUT", defaultBodyReadTimeout)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		route, _ := mux.CurrentRoute(r).GetPathTemplate()
+		policy := a.bodyPolicy(route)
+
+		// a missing content type is read as the expected one
+		if header := r.Header.Get("Content-Type"); header != "" {
+			mediaType, _, err := mime.ParseMediaType(header)
+			if err != nil || mediaType != policy.contentType {
+				errResponse := errUnsupportedMediaType
+				errResponse.Details = fmt.Sprintf(errResponse.Details, policy.contentType, header)
+				respondWithError(w, errResponse)
+				return
+			}
+		}
+
+		tooLarge := errPayloadTooLarge
+		tooLarge.Details = fmt.Sprintf(tooLarge.Details, policy.limit)
+		if r.ContentLength > policy.limit {
+			log.Ctx(r.Context()).Warn().Msgf("Refused a body of %d bytes.", r.ContentLength)
+			respondWithError(w, tooLarge)
+			return
+		}
+
+		timedOut := errBodyTimeout
+		timedOut.Details = fmt.Sprintf(timedOut.Details, timeout)
+		r.Body = &limitedBody{
+			ReadCloser: http.MaxBytesReader(w, r.Body, policy.limit),
+			deadline:   time.Now().Add(timeout),
+			tooLarge:   &bodyError{tooLarge},
+			timedOut:   &bodyError{timedOut},
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limitedBody turns the errors of reading past the limit or the deadline
+// into bodyErrors. A read that stalls outright is cut by the server's
+// READ_TIMEOUT.
+type limitedBody struct {
+	io.ReadCloser
+	deadline time.Time
+	tooLarge *bodyError
+	timedOut *bodyError
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if time.Now().After(b.deadline) {
+		return 0, b.timedOut
+	}
+	n, err := b.ReadCloser.Read(p)
+	// http.MaxBytesReader has no error type to match until Go 1.19
+	if err != nil && strings.Contains(err.Error(), "request body too large") {
+		return n, b.tooLarge
+	}
+	return n, err
+}
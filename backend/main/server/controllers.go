@@ -1,23 +1,39 @@
 package server
 
 import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
+	"fmt"
+	"html/template"
 	"io"
+	"math"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/DapperCollectives/CAST/backend/main/middleware"
 	"github.com/DapperCollectives/CAST/backend/main/models"
 	"github.com/DapperCollectives/CAST/backend/main/shared"
 	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v4"
 	"github.com/rs/zerolog/log"
 )
 
 type errorResponse struct {
-	StatusCode int    `json:"statusCode,string"`
-	ErrorCode  string `json:"errorCode"`
-	Message    string `json:"message"`
-	Details    string `json:"details"`
+	StatusCode int                          `json:"statusCode,string"`
+	ErrorCode  string                       `json:"errorCode"`
+	Message    string                       `json:"message"`
+	Details    string                       `json:"details"`
+	Fields     *fieldErrors                 `json:"fields,omitempty"`
+	Duplicates *[]*models.DuplicateProposal `json:"duplicates,omitempty"`
+	// sent as Retry-After too
+	Retry_at *time.Time `json:"retryAt,omitempty"`
 }
 
 var (
@@ -107,6 +123,209 @@ var (
 		Details:    "There was an error creating the vote.",
 	}
 
+	errArchivedCommunity = errorResponse{
+		StatusCode: http.StatusBadRequest,
+		ErrorCode:  "ERR_1013",
+		Message:    "Community Archived",
+		Details:    "This community has been archived and is read-only.",
+	}
+
+	errBannedAddress = errorResponse{
+		StatusCode: http.StatusForbidden,
+		ErrorCode:  "ERR_1014",
+		Message:    "Address Banned",
+		Details:    "This address has been banned from the community.",
+	}
+
+	errMissingIfMatch = errorResponse{
+		StatusCode: http.StatusPreconditionRequired,
+		ErrorCode:  "ERR_1015",
+		Message:    "Precondition Required",
+		Details:    "Updates must send the version being updated in an If-Match header.",
+	}
+
+	errStaleVersion = errorResponse{
+		StatusCode: http.StatusConflict,
+		ErrorCode:  "ERR_1016",
+		Message:    "Conflict",
+		Details:    "This was changed by someone else since you loaded it, reload and try again.",
+	}
+
+	errReplayedSignature = errorResponse{
+		StatusCode: http.StatusForbidden,
+		ErrorCode:  "ERR_1017",
+		Message:    "Signature Already Used",
+		Details:    "This signed request was already made, sign it again to retry.",
+	}
+
+	errLoginRequired = errorResponse{
+		StatusCode: http.StatusUnauthorized,
+		ErrorCode:  "ERR_1018",
+		Message:    "Login Required",
+		Details:    "Log in with your wallet to see this, or log in again if your session has expired.",
+	}
+
+	errBlockedAddress = errorResponse{
+		StatusCode: http.StatusForbidden,
+		ErrorCode:  "ERR_1019",
+		Message:    "Address Blocked",
+		Details:    "This address has been blocked from creating communities.",
+	}
+
+	errFeatureDisabled = errorResponse{
+		StatusCode: http.StatusForbidden,
+		ErrorCode:  "ERR_1020",
+		Message:    "Feature Disabled",
+		Details:    "This feature is not enabled for the community.",
+	}
+
+	errApprovalsRequired = errorResponse{
+		StatusCode: http.StatusForbidden,
+		ErrorCode:  "ERR_1021",
+		Message:    "Approvals Required",
+		Details:    "This community requires several admins to approve this, propose it as an action instead.",
+	}
+
+	errStrategyInUse = errorResponse{
+		StatusCode: http.StatusConflict,
+		ErrorCode:  "ERR_1022",
+		Message:    "Strategy In Use",
+		Details:    "Strategies can't be changed or removed while proposals are voted on with them.",
+	}
+
+	errInsufficientProposalBalance = errorResponse{
+		StatusCode: http.StatusForbidden,
+		ErrorCode:  "ERR_1023",
+		Message:    "Insufficient Balance",
+		Details:    "Creating proposals requires a balance of %v %s, this address held %v at block %d.",
+	}
+
+	errInvalidPayload = errorResponse{
+		StatusCode: http.StatusBadRequest,
+		ErrorCode:  "ERR_1024",
+		Message:    "Invalid Payload",
+		Details:    "The request body could not be read, check it is JSON of the expected shape.",
+	}
+
+	errInvalidId = errorResponse{
+		StatusCode: http.StatusBadRequest,
+		ErrorCode:  "ERR_1025",
+		Message:    "Invalid Identifier",
+		Details:    "An identifier in the URL is not valid.",
+	}
+
+	errUnknownTenant = errorResponse{
+		StatusCode: http.StatusNotFound,
+		ErrorCode:  "ERR_1026",
+		Message:    "Unknown Tenant",
+		Details:    "The requested tenant does not exist.",
+	}
+
+	errUnverifiedVoter = errorResponse{
+		StatusCode: http.StatusForbidden,
+		ErrorCode:  "ERR_1027",
+		Message:    "Unverified Voter",
+		Details:    "Voting on this proposal requires verifying your identity with %s.",
+	}
+
+	errAccountTooNew = errorResponse{
+		StatusCode: http.StatusForbidden,
+		ErrorCode:  "ERR_1028",
+		Message:    "Account Too New",
+		Details:    "Voting on this proposal requires an account created by block %d.",
+	}
+
+	errNotOnAllowlist = errorResponse{
+		StatusCode: http.StatusForbidden,
+		ErrorCode:  "ERR_1029",
+		Message:    "Not Allowed To Vote",
+		Details:    "Only addresses on the community's list of allowed voters may vote on this proposal.",
+	}
+
+	errInsufficientParticipation = errorResponse{
+		StatusCode: http.StatusForbidden,
+		ErrorCode:  "ERR_1030",
+		Message:    "Insufficient Participation",
+		Details:    "Creating proposals requires voting on %d of the community's last %d proposals, you voted on %d.",
+	}
+
+	errInvalidApiKey = errorResponse{
+		StatusCode: http.StatusUnauthorized,
+		ErrorCode:  "ERR_1031",
+		Message:    "Invalid API Key",
+		Details:    "Send an API key for this community in the X-API-Key header, this one is missing, revoked or for another community.",
+	}
+
+	errRateLimited = errorResponse{
+		StatusCode: http.StatusTooManyRequests,
+		ErrorCode:  "ERR_1032",
+		Message:    "Rate Limited",
+		Details:    "This API key made more than %d requests in the last minute, retry after %d seconds.",
+	}
+
+	errPayloadTooLarge = errorResponse{
+		StatusCode: http.StatusRequestEntityTooLarge,
+		ErrorCode:  "ERR_1033",
+		Message:    "Payload Too Large",
+		Details:    "The request body is larger than the %d bytes this endpoint accepts.",
+	}
+
+	errUnsupportedMediaType = errorResponse{
+		StatusCode: http.StatusUnsupportedMediaType,
+		ErrorCode:  "ERR_1034",
+		Message:    "Unsupported Media Type",
+		Details:    "This endpoint accepts %s, not %s.",
+	}
+
+	errBodyTimeout = errorResponse{
+		StatusCode: http.StatusRequestTimeout,
+		ErrorCode:  "ERR_1035",
+		Message:    "Request Timeout",
+		Details:    "The request body took longer than %s to send.",
+	}
+
+	errDuplicateProposal = errorResponse{
+		StatusCode: http.StatusConflict,
+		ErrorCode:  "ERR_1036",
+		Message:    "Duplicate Proposal",
+		Details:    "This proposal closely matches recent proposals %s of the community, link to them instead.",
+	}
+
+	errProposalCooldown = errorResponse{
+		StatusCode: http.StatusTooManyRequests,
+		ErrorCode:  "ERR_1037",
+		Message:    "Proposal Cooldown",
+		Details:    "Authors may create %d proposals every %s in this community, you may create the next at %s.",
+	}
+
+	errDepositRequired = errorResponse{
+		StatusCode: http.StatusPaymentRequired,
+		ErrorCode:  "ERR_1038",
+		Message:    "Deposit Required",
+		Details:    "Proposals in this community require a deposit of %v %s to %s, send the ID of its transaction as depositTxId.",
+	}
+
+	errInvalidDeposit = errorResponse{
+		StatusCode: http.StatusBadRequest,
+		ErrorCode:  "ERR_1039",
+		Message:    "Invalid Deposit",
+		Details:    "The deposit transaction could not be verified.",
+	}
+
+	errUsageQuotaExceeded = errorResponse{
+		StatusCode: http.StatusTooManyRequests,
+		ErrorCode:  "ERR_1040",
+		Message:    "Usage Quota Exceeded",
+		Details:    "This community used its %s quota of %d for the month, it resets at %s.",
+	}
+
+	errPlanLimit = errorResponse{
+		StatusCode: http.StatusPaymentRequired,
+		ErrorCode:  "ERR_1041",
+		Message:    "Plan Limit Reached",
+		Details:    "The plan of this community doesn't allow this, upgrade the community for more.",
+	}
+
 	nilErr = errorResponse{}
 )
 
@@ -115,785 +334,4954 @@ func (a *App) health(w http.ResponseWriter, r *http.Request) {
 }
 
 func (a *App) upload(w http.ResponseWriter, r *http.Request) {
-	r.Body = http.MaxBytesReader(w, r.Body, maxFileSize)
-	if err := r.ParseMultipartForm(maxFileSize); err != nil {
-		log.Error().Err(err).Msgf("File cannot be larger than max file size of %v.\n", maxFileSize)
+	r.Body = http.MaxBytesReader(w, r.Body, a.Config.Max_file_size)
+	if err := r.ParseMultipartForm(a.Config.Max_file_size); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("File cannot be larger than max file size of %v.\n", a.Config.Max_file_size)
 		respondWithError(w, errIncompleteRequest)
 		return
 	}
 
-	resp, err := helpers.uploadFile(r)
+	resp, httpStatus, err := helpers.uploadFile(r)
 	if err != nil {
-		log.Error().Err(err).Msg("Error uploading file.")
-		respondWithError(w, errIncompleteRequest)
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error uploading file.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
 		return
 	}
 
 	respondWithJSON(w, http.StatusOK, resp)
 }
 
-// Votes
-func (a *App) getResultsForProposal(w http.ResponseWriter, r *http.Request) {
+func (a *App) getFile(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	proposal, err := helpers.fetchProposal(vars, "proposalId")
 
-	votes, err := models.GetAllVotesForProposal(a.DB, proposal.ID, *proposal.Strategy)
+	upload, content, httpStatus, err := helpers.readUpload(vars["key"], r.FormValue("token"))
 	if err != nil {
-		log.Error().Err(err).Msg("Error getting votes for proposal.")
-		respondWithError(w, errIncompleteRequest)
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error reading file.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
 		return
 	}
 
-	results, err := helpers.useStrategyTally(proposal, votes)
+	// content addressed, so the bytes behind a key never change
+	w.Header().Set("Content-Type", upload.Content_type)
+	if upload.Private {
+		w.Header().Set("Cache-Control", "private, no-store")
+	} else {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(content)
+}
+
+func (a *App) signUploadURL(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		log.Error().Err(err).Msg("Error tallying votes.")
-		respondWithError(w, errIncompleteRequest)
+		respondWithError(w, errInvalidId)
 		return
 	}
 
-	if *proposal.Computed_status == "closed" && !proposal.Achievements_done {
-		if err := models.AddWinningVoteAchievement(a.DB, votes, results); err != nil {
-			log.Error().Err(err).Msg("Error calculating winning votes")
-			respondWithError(w, errIncompleteRequest)
-		}
+	var payload shared.TimestampSignaturePayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid request payload.")
+		respondWithError(w, invalidPayload(err))
+		return
 	}
 
-	respondWithJSON(w, http.StatusOK, results)
-}
-
-func (a *App) getVotesForProposal(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	proposal, err := helpers.fetchProposal(vars, "proposalId")
+	signed, httpStatus, err := helpers.signUploadURL(id, payload)
 	if err != nil {
-		log.Error().Err(err).Msg("Invalid Proposal ID.")
-		respondWithError(w, errIncompleteRequest)
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error signing upload URL.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
 		return
 	}
 
-	votes, order, err := helpers.getPaginatedVotes(r, proposal)
-	if err != nil {
-		log.Error().Err(err).Msg("error getting paginated votes")
-		respondWithError(w, errIncompleteRequest)
+	respondWithJSON(w, http.StatusOK, signed)
+}
+
+// Auth
+func (a *App) login(w http.ResponseWriter, r *http.Request) {
+	var payload models.LoginPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
 		return
 	}
 
-	votesWithWeights, err := helpers.useStrategyGetVotes(proposal, votes)
+	session, httpStatus, err := helpers.login(payload)
 	if err != nil {
-		log.Error().Err(err).Msg("error calling useStrategyGetVotes")
-		respondWithError(w, errIncompleteRequest)
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error logging in.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
 		return
 	}
 
-	response := shared.GetPaginatedResponseWithPayload(votesWithWeights, order)
-	respondWithJSON(w, http.StatusOK, response)
+	respondWithJSON(w, http.StatusOK, session)
 }
 
-func (a *App) getVoteForAddress(w http.ResponseWriter, r *http.Request) {
+// Votes
+func (a *App) getResultsForProposal(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	addr := vars["addr"]
-
 	proposal, err := helpers.fetchProposal(vars, "proposalId")
 	if err != nil {
-		log.Error().Err(err).Msg("Invalid Proposal ID.")
-		respondWithError(w, errIncompleteRequest)
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Proposal %s not found.", vars["proposalId"])
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = http.StatusNotFound
+		errResponse.Details = "Proposal not found."
+		respondWithError(w, errResponse)
 		return
 	}
 
-	vote, err := helpers.processVote(addr, proposal)
+	// results are kept up to date as votes are cast; proposals without
+	// stored results are counted once and stored from then on
+	results, err := helpers.fetchProposalResults(proposal)
 	if err != nil {
-		log.Error().Err(err).Msg("Error processing vote.")
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error tallying votes.")
 		respondWithError(w, errIncompleteRequest)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, vote)
+	// results of closed proposals no longer change
+	if proposal.Computed_status != nil && *proposal.Computed_status == "closed" {
+		respondWithCacheableJSON(w, r, results, 0, &results.Updated_at, cacheClosedResults)
+		return
+	}
+	respondWithCacheableJSON(w, r, results, 0, nil, cacheOpenResults)
 }
 
-func (a *App) getVotesForAddress(w http.ResponseWriter, r *http.Request) {
-	var proposalIds []int
-
+func (a *App) getCohostedResultsForProposal(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	addr := vars["addr"]
-
-	err := json.Unmarshal([]byte(r.FormValue("proposalIds")), &proposalIds)
+	proposal, err := helpers.fetchProposal(vars, "proposalId")
 	if err != nil {
-		log.Error().Err(err).Msg("Error unmarshalling proposalIds")
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Proposal ID.")
 		respondWithError(w, errIncompleteRequest)
 		return
 	}
 
-	pageParams := getPageParams(*r, 25)
-
-	votes, pageParams, err := helpers.processVotes(addr, proposalIds, pageParams)
+	results, err := helpers.getCohostedResults(proposal)
 	if err != nil {
-		log.Error().Err(err).Msg("Error processing votes.")
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error tallying votes for co-hosts.")
 		respondWithError(w, errIncompleteRequest)
 		return
 	}
 
-	response := shared.GetPaginatedResponseWithPayload(votes, pageParams)
-	respondWithJSON(w, http.StatusOK, response)
+	respondWithJSON(w, http.StatusOK, results)
 }
 
-func (a *App) createVoteForProposal(w http.ResponseWriter, r *http.Request) {
+func (a *App) dryRunTally(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-
-	proposal, err := helpers.fetchProposal(vars, "proposalId")
+	communityId, err := strconv.Atoi(vars["communityId"])
 	if err != nil {
-		log.Error().Err(err).Msg("Invalid Proposal ID.")
-		respondWithError(w, errIncompleteRequest)
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
 		return
 	}
 
-	vote, errResponse := helpers.createVote(r, proposal)
-	if errResponse != nilErr {
-		log.Error().Err(err).Msg("Error creating vote.")
+	var payload models.DryRunTallyPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	tally, httpStatus, err := helpers.dryRunTally(communityId, payload)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error running dry-run tally")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
 		respondWithError(w, errResponse)
 		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, vote)
+	respondWithJSON(w, httpStatus, tally)
 }
 
-// Proposals
-func (a *App) getProposalsForCommunity(w http.ResponseWriter, r *http.Request) {
+func (a *App) getProposalEligibility(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	communityId, err := strconv.Atoi(vars["communityId"])
-
 	if err != nil {
-		log.Error().Err(err).Msg("Invalid Community ID")
-		respondWithError(w, errIncompleteRequest)
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
 		return
 	}
 
-	pageParams := getPageParams(*r, 25)
-	status := r.FormValue("status")
-
-	proposals, totalRecords, err := models.GetProposalsForCommunity(
-		a.DB,
-		communityId,
-		status,
-		pageParams,
-	)
+	c, err := helpers.fetchCommunity(communityId)
 	if err != nil {
-		log.Error().Err(err).Msg("Error getting proposals for community.")
-		respondWithError(w, errIncompleteRequest)
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error fetching community")
+		respondWithError(w, errGetCommunity)
 		return
 	}
 
-	pageParams.TotalRecords = totalRecords
+	eligibility, err := helpers.proposalEligibility(c, vars["addr"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error checking proposal eligibility of %s", vars["addr"])
+		errResponse := errIncompleteRequest
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
 
-	response := shared.GetPaginatedResponseWithPayload(proposals, pageParams)
-	respondWithJSON(w, http.StatusOK, response)
+	respondWithJSON(w, http.StatusOK, eligibility)
 }
 
-func (a *App) getProposal(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	p, err := helpers.fetchProposal(vars, "id")
+// getDuplicateProposals checks a draft against the community's recent
+// proposals, before it is submitted.
+func (a *App) getDuplicateProposals(w http.ResponseWriter, r *http.Request) {
+	communityId, err := strconv.Atoi(mux.Vars(r)["communityId"])
 	if err != nil {
-		log.Error().Err(err).Msg("Invalid Proposal ID.")
-		respondWithError(w, errIncompleteRequest)
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
 		return
 	}
 
-	c, err := helpers.fetchCommunity(p.Community_id)
+	duplicates, httpStatus, err := helpers.findDuplicateProposals(communityId, r.FormValue("name"), r.FormValue("body"))
 	if err != nil {
-		log.Error().Err(err).Msg("error fetching community")
-		respondWithError(w, errIncompleteRequest)
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error looking for duplicate proposals.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, duplicates)
+}
+
+func (a *App) getStrategyVersions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
 		return
 	}
 
-	_, err = models.MatchStrategyByProposal(*c.Strategies, *p.Strategy)
+	versions, err := models.GetStrategyVersions(a.DB, communityId)
 	if err != nil {
-		log.Error().Err(err).Msg("error getting strategy by proposal")
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting strategy versions")
 		respondWithError(w, errIncompleteRequest)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, p)
+	respondWithJSON(w, http.StatusOK, versions)
 }
 
-func (a *App) createProposal(w http.ResponseWriter, r *http.Request) {
+func (a *App) getStrategyVersion(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	communityId, err := strconv.Atoi(vars["communityId"])
 	if err != nil {
-		log.Error().Err(err).Msg("Invalid Community ID")
-		respondWithError(w, errIncompleteRequest)
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
 		return
 	}
-
-	var p models.Proposal
-	p.Community_id = communityId
-
-	if err := validatePayload(r.Body, &p); err != nil {
-		log.Error().Err(err).Msg("Error validating payload")
-		respondWithError(w, errIncompleteRequest)
+	version, err := strconv.Atoi(vars["version"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Strategy Version")
+		respondWithError(w, errInvalidId)
 		return
 	}
 
-	proposal, errResponse := helpers.createProposal(p)
-	if errResponse != nilErr {
-		log.Error().Err(err).Msg("Error creating proposal")
+	v := models.StrategyVersion{Community_id: communityId, Version: version}
+	if err := v.GetStrategyVersion(a.DB); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error getting version %d of community %d strategies", version, communityId)
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = http.StatusNotFound
+		errResponse.Details = "Strategy version not found."
 		respondWithError(w, errResponse)
 		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, proposal)
+	respondWithJSON(w, http.StatusOK, v)
 }
 
-func (a *App) updateProposal(w http.ResponseWriter, r *http.Request) {
+func (a *App) previewStrategyChange(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	p, err := helpers.fetchProposal(vars, "id")
+	communityId, err := strconv.Atoi(vars["communityId"])
 	if err != nil {
-		log.Error().Err(err).Msg("Invalid Proposal ID.")
-		respondWithError(w, errIncompleteRequest)
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
 		return
 	}
 
-	var payload models.UpdateProposalRequestPayload
+	var payload models.StrategyChangePayload
 	if err := validatePayload(r.Body, &payload); err != nil {
-		log.Error().Err(err).Msg("Error validating payload")
-		respondWithError(w, errIncompleteRequest)
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
 		return
 	}
 
-	// Check that status update is valid
-	// For now we are assuming proposals are creating with
-	// status 'published' and may be cancelled.
-	if payload.Status != "cancelled" {
-		log.Error().Err(err).Msg("Invalid status update")
-		respondWithError(w, errIncompleteRequest)
+	impact, httpStatus, err := helpers.previewStrategyChange(communityId, payload)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error previewing strategy change")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
 		return
 	}
 
-	if payload.Voucher != nil {
-		if err := helpers.validateUserWithRoleViaVoucher(
-			payload.Signing_addr,
-			payload.Voucher,
-			p.Community_id,
-			"author"); err != nil {
-			log.Error().Err(err).Msg("Error validating user with role via voucher")
-			respondWithError(w, errForbidden)
-			return
-		}
-	} else {
-		if err := helpers.validateUserWithRole(
-			payload.Signing_addr,
-			payload.Timestamp,
-			payload.Composite_signatures,
-			p.Community_id,
-			"author"); err != nil {
-			log.Error().Err(err).Msg("Error validating user with role")
-			respondWithError(w, errForbidden)
-			return
-		}
-	}
+	respondWithJSON(w, httpStatus, impact)
+}
 
-	p.Status = &payload.Status
-	p.Cid, err = helpers.pinJSONToIpfs(p)
+func (a *App) getProposalAnalytics(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	proposal, err := helpers.fetchProposal(vars, "proposalId")
 	if err != nil {
-		log.Error().Err(err).Msg("Error pinning proposal to IPFS")
-		respondWithError(w, errIncompleteRequest)
-		return
-	}
-
-	if err := p.UpdateProposal(a.DB); err != nil {
-		log.Error().Err(err).Msg("Error updating proposal")
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Proposal ID.")
 		respondWithError(w, errIncompleteRequest)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, p)
-}
-
-// Communities
-func (a *App) getCommunities(w http.ResponseWriter, r *http.Request) {
-	pageParams := getPageParams(*r, 25)
-
-	communities, totalRecords, err := models.GetCommunities(a.DB, pageParams)
+	analytics, err := helpers.getProposalAnalytics(proposal)
 	if err != nil {
-		log.Error().Err(err).Msg("Error fetching communities")
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting proposal analytics")
 		respondWithError(w, errIncompleteRequest)
 		return
 	}
 
-	pageParams.TotalRecords = totalRecords
-	response := shared.GetPaginatedResponseWithPayload(communities, pageParams)
-
-	respondWithJSON(w, http.StatusOK, response)
+	respondWithJSON(w, http.StatusOK, analytics)
 }
 
-func (a *App) searchCommunities(w http.ResponseWriter, r *http.Request) {
-	pageParams := getPageParams(*r, 25)
-	filters := r.FormValue("filters")
-	searchText := r.FormValue("text")
-
-	results, totalRecords, categories, err := helpers.searchCommunities(
-		searchText,
-		filters,
-		pageParams,
-	)
+func (a *App) getListsForProposal(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	proposal, err := helpers.fetchProposal(vars, "proposalId")
 	if err != nil {
-		log.Error().Err(err).Msg("Error searching communities")
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Proposal ID.")
 		respondWithError(w, errIncompleteRequest)
+		return
 	}
 
-	pageParams.TotalRecords = totalRecords
-
-	paginatedResults, err := helpers.appendFiltersToResponse(
-		results,
-		pageParams,
-		categories,
-	)
+	versions, err := models.GetListVersionsForProposal(a.DB, &proposal)
 	if err != nil {
-		log.Error().Err(err).Msg("Error appending filters to response")
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error resolving lists for proposal")
 		respondWithError(w, errIncompleteRequest)
+		return
 	}
 
-	respondWithJSON(w, http.StatusOK, paginatedResults)
+	respondWithJSON(w, http.StatusOK, versions)
 }
 
-func (a *App) getCommunity(w http.ResponseWriter, r *http.Request) {
+func (a *App) getVotesForProposal(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+	proposal, err := helpers.fetchProposal(vars, "proposalId")
 	if err != nil {
-		log.Error().Err(err).Msg("Invalid Community ID")
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Proposal ID.")
 		respondWithError(w, errIncompleteRequest)
 		return
 	}
 
-	c, err := helpers.fetchCommunity(id)
+	votes, order, err := helpers.getPaginatedVotes(r, proposal)
 	if err != nil {
-		log.Error().Err(err).Msg("Error fetching community")
+		log.Ctx(r.Context()).Error().Err(err).Msg("error getting paginated votes")
+		errResponse := errIncompleteRequest
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	votesWithWeights, err := helpers.useStrategyGetVotes(proposal, votes)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("error calling useStrategyGetVotes")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	helpers.attachProfilesToVotes(votesWithWeights)
+
+	respondWithPage(w, r, shared.GetPaginatedResponseWithPayload(votesWithWeights, order))
+}
+
+func (a *App) getVoteForAddress(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	addr := vars["addr"]
+
+	proposal, err := helpers.fetchProposal(vars, "proposalId")
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Proposal ID.")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	vote, err := helpers.processVote(addr, proposal)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error processing vote.")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	helpers.attachProfilesToVotes([]*models.VoteWithBalance{vote})
+
+	respondWithJSON(w, http.StatusOK, vote)
+}
+
+func (a *App) getVoteReceipt(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	addr := vars["addr"]
+
+	proposal, err := helpers.fetchProposal(vars, "proposalId")
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Proposal ID.")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	receipt, err := helpers.createVoteReceipt(addr, proposal)
+	if errors.Is(err, errVoteNotFound) {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("No vote of %s for receipt.", addr)
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = http.StatusNotFound
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error creating vote receipt.")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, receipt)
+}
+
+// getReceiptPublicKey publishes the key receipts are signed with, for
+// anyone to verify them against.
+func (a *App) getReceiptPublicKey(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"publicKey": a.ReceiptSigner.PublicKeyHex(),
+		"algorithm": "ed25519",
+	})
+}
+
+// verifyVote checks a vote against the content pinned under its CID, and
+// its signature against the voter's keys at the time it was cast.
+func (a *App) verifyVote(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	proposal, err := helpers.fetchProposal(vars, "proposalId")
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Proposal ID.")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	vote := models.Vote{Proposal_id: proposal.ID, Addr: vars["addr"]}
+	if err := vote.GetVote(a.DB); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting vote.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = http.StatusNotFound
+		errResponse.Details = "Vote not found."
+		respondWithError(w, errResponse)
+		return
+	}
+
+	cid := vote.Cid
+	vote.Cid = nil
+	cidVerification, err := helpers.verifyCid(models.RecordVote, vote.ID, vote, cid)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error verifying vote.")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	signature, err := helpers.verifyVoteSignature(vote, proposal)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error verifying vote signature.")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.VoteVerification{CidVerification: cidVerification, Signature: signature})
+}
+
+func (a *App) getVotesForAddress(w http.ResponseWriter, r *http.Request) {
+	var proposalIds []int
+
+	vars := mux.Vars(r)
+	addr := vars["addr"]
+
+	err := json.Unmarshal([]byte(r.FormValue("proposalIds")), &proposalIds)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error unmarshalling proposalIds")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	pageParams := getPageParams(*r, 25)
+
+	votes, pageParams, err := helpers.processVotes(addr, proposalIds, pageParams)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error processing votes.")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	respondWithPage(w, r, shared.GetPaginatedResponseWithPayload(votes, pageParams))
+}
+
+func (a *App) createVoteForProposal(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	proposal, err := helpers.fetchProposal(vars, "proposalId")
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Proposal ID.")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	vote, errResponse := helpers.createVote(r, proposal)
+	if errResponse != nilErr {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error creating vote.")
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, vote)
+}
+
+// Proposals
+func (a *App) getProposalsForCommunity(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	pageParams := getPageParams(*r, 25)
+	if pageParams.Sort, err = models.ProposalSortColumns.Parse(r.FormValue("sort")); err != nil {
+		errResponse := errIncompleteRequest
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+	filters, err := getProposalFilters(*r)
+	if err != nil {
+		errResponse := errIncompleteRequest
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+	if filters.IncludesWithheld() {
+		if httpStatus, err := helpers.validateModeratorSession(bearerToken(r), communityId); err != nil {
+			log.Ctx(r.Context()).Error().Err(err).Msg("Withheld proposals requested without moderation permission.")
+			errResponse := errIncompleteRequest
+			errResponse.StatusCode = httpStatus
+			errResponse.setDetails(err)
+			respondWithError(w, errResponse)
+			return
+		}
+	}
+
+	proposals, totalRecords, err := models.GetProposalsForCommunity(
+		a.DB,
+		communityId,
+		filters,
+		pageParams,
+	)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting proposals for community.")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	pageParams.TotalRecords = totalRecords
+
+	respondWithPage(w, r, shared.GetPaginatedResponseWithPayload(proposals, pageParams))
+}
+
+func (a *App) getProposalReviewQueue(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	if httpStatus, err := helpers.validateModeratorSession(bearerToken(r), communityId); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting proposal review queue.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	pageParams := getPageParams(*r, 25)
+	if pageParams.Sort, err = models.ProposalSortColumns.Parse(r.FormValue("sort")); err != nil {
+		errResponse := errIncompleteRequest
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	proposals, totalRecords, err := models.GetProposalsForCommunity(
+		a.DB,
+		communityId,
+		models.ProposalFilters{Statuses: []string{models.ProposalPendingReview}},
+		pageParams,
+	)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting proposal review queue.")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	pageParams.TotalRecords = totalRecords
+
+	respondWithPage(w, r, shared.GetPaginatedResponseWithPayload(proposals, pageParams))
+}
+
+func (a *App) approveProposal(w http.ResponseWriter, r *http.Request) {
+	a.reviewProposal(w, r, true)
+}
+
+func (a *App) rejectProposal(w http.ResponseWriter, r *http.Request) {
+	a.reviewProposal(w, r, false)
+}
+
+func (a *App) reviewProposal(w http.ResponseWriter, r *http.Request, approve bool) {
+	vars := mux.Vars(r)
+	p, err := helpers.fetchProposal(vars, "id")
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Proposal ID.")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	var payload models.ReviewProposalRequestPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	p, httpStatus, err := helpers.reviewProposal(p, payload, approve)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error reviewing proposal")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, p)
+}
+
+func (a *App) getModerationRules(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	rules, httpStatus, err := helpers.getModerationRules(bearerToken(r), communityId)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting moderation rules.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, rules)
+}
+
+func (a *App) createModerationRule(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.ModerationRulePayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	rule, httpStatus, err := helpers.createModerationRule(communityId, payload)
+	if errors.Is(err, models.ErrSignatureReused) {
+		respondWithError(w, errReplayedSignature)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error creating moderation rule.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, httpStatus, rule)
+}
+
+func (a *App) deleteModerationRule(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+	ruleId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Rule ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.CommunityRoleDeletePayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	httpStatus, err := helpers.deleteModerationRule(communityId, ruleId, payload)
+	if errors.Is(err, models.ErrSignatureReused) {
+		respondWithError(w, errReplayedSignature)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error deleting moderation rule.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// getModerationDecisions is the log of what new content scored, and what
+// was held for review because of it.
+func (a *App) getModerationDecisions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	pageParams := getPageParams(*r, 25)
+	decisions, pageParams, httpStatus, err := helpers.getModerationDecisions(
+		bearerToken(r),
+		communityId,
+		r.FormValue("action"),
+		pageParams,
+	)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting moderation decisions.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithPage(w, r, shared.GetPaginatedResponseWithPayload(decisions, pageParams))
+}
+
+func (a *App) settleProposalDeposit(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	p, err := helpers.fetchProposal(vars, "id")
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Proposal ID.")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	var payload models.DepositSettlementPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	p, httpStatus, err := helpers.settleProposalDeposit(p, payload)
+	if errors.Is(err, models.ErrSignatureReused) {
+		respondWithError(w, errReplayedSignature)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error settling the deposit of proposal %d.", p.ID)
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, p)
+}
+
+// getProposalDeposits lists the deposits of a community's proposals, for
+// its escrow to see what is due.
+func (a *App) getProposalDeposits(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	status := r.FormValue("status")
+	switch status {
+	case "", models.DepositLocked, models.DepositRefundDue, models.DepositSlashDue,
+		models.DepositRefunded, models.DepositSlashed:
+	default:
+		errResponse := errIncompleteRequest
+		errResponse.Details = fmt.Sprintf("Deposit status %q is not one of locked, refund_due, slash_due, refunded or slashed.", status)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	pageParams := getPageParams(*r, 25)
+	proposals, totalRecords, err := models.GetProposalDeposits(a.DB, communityId, status, pageParams)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting proposal deposits.")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+	pageParams.TotalRecords = totalRecords
+
+	respondWithPage(w, r, shared.GetPaginatedResponseWithPayload(proposals, pageParams))
+}
+
+// verifyProposal checks a proposal against the content pinned under its
+// CID, so observers can detect changes made behind IPFS's back.
+func (a *App) verifyProposal(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	p, err := helpers.fetchProposal(vars, "id")
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Proposal ID.")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	if err := helpers.fetchPinnedProposalContent(&p); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting proposal content.")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	cid := p.Cid
+	p.Cid = nil
+	verification, err := helpers.verifyCid(models.PinProposal, p.ID, p, cid)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error verifying proposal.")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, verification)
+}
+
+// getTrendingProposals lists proposals with recent votes by trending score.
+func (a *App) getTrendingProposals(w http.ResponseWriter, r *http.Request) {
+	pageParams := getPageParams(*r, 25)
+
+	proposals, totalRecords, err := models.GetTrendingProposals(a.DB, pageParams)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error fetching trending proposals")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	pageParams.TotalRecords = totalRecords
+	respondWithPage(w, r, shared.GetPaginatedResponseWithPayload(proposals, pageParams))
+}
+
+func (a *App) getProposal(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	p, err := helpers.fetchProposal(vars, "id")
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Proposal ID.")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	// withheld proposals are kept from everyone but their author and
+	// moderators, and from shared caches
+	cacheControl := cacheProposal
+	if p.IsWithheld() {
+		if err := helpers.ensureCanSeeWithheldProposal(bearerToken(r), p); err != nil {
+			log.Ctx(r.Context()).Error().Err(err).Msgf("Proposal %d is withheld.", p.ID)
+			errResponse := errIncompleteRequest
+			errResponse.StatusCode = http.StatusNotFound
+			errResponse.Details = "Proposal not found."
+			respondWithError(w, errResponse)
+			return
+		}
+		cacheControl = cachePrivate
+	}
+
+	c, err := helpers.fetchCommunity(p.Community_id)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("error fetching community")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	_, err = p.BoundStrategy(&c)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("error getting strategy by proposal")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	if err := helpers.fetchPinnedProposalContent(&p); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting proposal content.")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	t, err := helpers.localizedTranslation(models.ProposalTranslations, p.ID, r.Header.Get("Accept-Language"))
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error fetching translation of proposal %d.", p.ID)
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+	if t != nil {
+		p.Localize(t)
+		w.Header().Set("Content-Language", t.Locale)
+	}
+
+	switch r.FormValue("format") {
+	case "":
+	case "html":
+		if p.Body != nil {
+			body := a.Sanitizer.Sanitize(shared.RenderMarkdown(*p.Body))
+			p.Body = &body
+		}
+	default:
+		errResponse := errIncompleteRequest
+		errResponse.Details = "Proposal bodies can only be formatted as html."
+		respondWithError(w, errResponse)
+		return
+	}
+
+	// votes and the computed status change an open proposal without
+	// touching its record, so only ended ones are dated
+	var lastModified *time.Time
+	if p.Computed_status != nil {
+		switch *p.Computed_status {
+		case "closed":
+			lastModified = latestTime(p.Updated_at, &p.End_time)
+		case "cancelled":
+			lastModified = p.Updated_at
+		}
+	}
+	if lastModified != nil && t != nil {
+		lastModified = latestTime(lastModified, &t.Updated_at)
+	}
+
+	w.Header().Set("Vary", "Accept-Language")
+	respondWithCacheableJSON(w, r, p, p.Version, lastModified, cacheControl)
+}
+
+func (a *App) getProposalBatch(w http.ResponseWriter, r *http.Request) {
+	var payload models.ProposalBatchPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	proposals, httpStatus, err := helpers.getProposalBatch(payload)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error fetching proposal batch.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, proposals)
+}
+
+// Link Previews
+
+func (a *App) getCommunityPreview(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	preview, httpStatus, err := helpers.getCommunityPreview(id)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting community preview.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithPreview(w, r, preview)
+}
+
+func (a *App) getProposalPreview(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Proposal ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	preview, httpStatus, err := helpers.getProposalPreview(id)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting proposal preview.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithPreview(w, r, preview)
+}
+
+// getProposalFeed serves the latest proposals of a community, or of every
+// community when the route has no id, as RSS or as Atom depending on the
+// extension asked for.
+func (a *App) getProposalFeed(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId := 0
+	if id, ok := vars["id"]; ok {
+		var err error
+		if communityId, err = strconv.Atoi(id); err != nil {
+			log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+			respondWithError(w, errInvalidId)
+			return
+		}
+	}
+
+	feed, httpStatus, err := helpers.getProposalFeed(requestTenant(r), communityId, a.PublicApiURL+r.URL.Path)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting proposal feed.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	var document interface{} = feed.RSS()
+	contentType := "application/rss+xml; charset=utf-8"
+	if strings.HasSuffix(r.URL.Path, ".atom") {
+		document = feed.Atom()
+		contentType = "application/atom+xml; charset=utf-8"
+	}
+	response, err := xml.MarshalIndent(document, "", "  ")
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error writing proposal feed.")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", cacheFeed)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(xml.Header))
+	w.Write(response)
+}
+
+func (a *App) getProposalEmbed(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Proposal ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	embed, httpStatus, err := helpers.getProposalEmbed(id)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting proposal embed.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	if embed.Status == models.ProposalClosed {
+		respondWithCacheableJSON(w, r, embed, 0, embed.Updated_at, cacheClosedResults)
+		return
+	}
+	respondWithCacheableJSON(w, r, embed, 0, nil, cacheProposal)
+}
+
+// getProposalWidget renders the proposal card other sites frame.
+func (a *App) getProposalWidget(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Proposal ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	embed, httpStatus, err := helpers.getProposalEmbed(id)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting proposal widget.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	cacheControl := cacheProposal
+	if embed.Status == models.ProposalClosed {
+		cacheControl = cacheClosedResults
+	}
+	// the widget is meant to be framed by any site
+	w.Header().Del("X-Frame-Options")
+	w.Header().Set("Content-Security-Policy", widgetPolicy)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", cacheControl)
+	w.WriteHeader(http.StatusOK)
+	if err := widgetPage.Execute(w, embed); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error rendering proposal widget.")
+	}
+}
+
+func (a *App) getOEmbed(w http.ResponseWriter, r *http.Request) {
+	// only JSON is offered, as the spec allows
+	if format := r.FormValue("format"); format != "" && format != "json" {
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = http.StatusNotImplemented
+		errResponse.Details = "Only the json format is supported."
+		respondWithError(w, errResponse)
+		return
+	}
+	maxWidth, _ := strconv.Atoi(r.FormValue("maxwidth"))
+	maxHeight, _ := strconv.Atoi(r.FormValue("maxheight"))
+
+	o, httpStatus, err := helpers.getOEmbed(r.FormValue("url"), maxWidth, maxHeight)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting oEmbed.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", o.Cache_age))
+	respondWithJSON(w, http.StatusOK, o)
+}
+
+func (a *App) getProposalResultsChart(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["proposalId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Proposal ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	chart, httpStatus, err := helpers.getResultsChart(id)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting proposal results chart.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	w.WriteHeader(http.StatusOK)
+	w.Write(chart.SVG())
+}
+
+func (a *App) getProposalResultsImage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["proposalId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Proposal ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	png, httpStatus, err := helpers.getResultsImage(id)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting proposal results image.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	w.WriteHeader(http.StatusOK)
+	w.Write(png)
+}
+
+// Translations
+
+func (a *App) getCommunityTranslations(w http.ResponseWriter, r *http.Request) {
+	a.getTranslations(w, r, models.CommunityTranslations)
+}
+
+func (a *App) getProposalTranslations(w http.ResponseWriter, r *http.Request) {
+	a.getTranslations(w, r, models.ProposalTranslations)
+}
+
+func (a *App) getTranslations(w http.ResponseWriter, r *http.Request, target models.TranslationTarget) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	translations, err := models.GetTranslations(a.DB, target, id)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error fetching translations.")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, translations)
+}
+
+func (a *App) setCommunityTranslation(w http.ResponseWriter, r *http.Request) {
+	a.saveTranslation(w, r, models.CommunityTranslations, r.Method == http.MethodDelete)
+}
+
+func (a *App) setProposalTranslation(w http.ResponseWriter, r *http.Request) {
+	a.saveTranslation(w, r, models.ProposalTranslations, r.Method == http.MethodDelete)
+}
+
+func (a *App) getCommunityBranding(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	b, httpStatus, err := helpers.getCommunityBranding(requestTenant(r), id)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error fetching branding of community %d.", id)
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, b)
+}
+
+// getBranding finds the community served from the domain given, or the one
+// the request was sent to, for white-label frontends on a custom domain.
+func (a *App) getBranding(w http.ResponseWriter, r *http.Request) {
+	domain := r.FormValue("domain")
+	if domain == "" {
+		domain = r.Host
+	}
+
+	b, httpStatus, err := helpers.getBrandingForDomain(domain)
+	if err != nil {
+		log.Ctx(r.Context()).Warn().Err(err).Msgf("No branding for domain %s.", domain)
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, b)
+}
+
+func (a *App) setCommunityBranding(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.CommunityBrandingPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid request payload.")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	b, httpStatus, err := helpers.saveCommunityBranding(requestTenant(r), id, payload)
+	if errors.Is(err, models.ErrSignatureReused) {
+		respondWithError(w, errReplayedSignature)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error saving branding of community %d.", id)
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, b)
+}
+
+func (a *App) getReputationSettings(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	settings, httpStatus, err := helpers.getReputationSettings(requestTenant(r), id)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error fetching reputation settings of community %d.", id)
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, settings)
+}
+
+func (a *App) setReputationSettings(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.ReputationSettingsPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid request payload.")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	settings, httpStatus, err := helpers.saveReputationSettings(requestTenant(r), id, payload)
+	if errors.Is(err, models.ErrSignatureReused) {
+		respondWithError(w, errReplayedSignature)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error saving reputation settings of community %d.", id)
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, settings)
+}
+
+func (a *App) getUserReputation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	score, httpStatus, err := helpers.getReputationScore(requestTenant(r), id, vars["addr"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error fetching reputation of %s.", vars["addr"])
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, score)
+}
+
+func (a *App) saveTranslation(w http.ResponseWriter, r *http.Request, target models.TranslationTarget, remove bool) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.TranslationPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid request payload.")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	t, httpStatus, err := helpers.saveTranslation(target, id, vars["locale"], payload, remove)
+	if errors.Is(err, models.ErrSignatureReused) {
+		respondWithError(w, errReplayedSignature)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error saving translation.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	if remove {
+		respondWithJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+		return
+	}
+	respondWithJSON(w, http.StatusOK, t)
+}
+
+func (a *App) getProposalAttachments(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	p, err := helpers.fetchProposal(vars, "id")
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Proposal ID.")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+	pageParams := getPageParams(*r, maxProposalAttachments)
+
+	attachments, totalRecords, err := models.GetProposalAttachmentsPage(a.DB, p.ID, pageParams)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting proposal attachments.")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+	pageParams.TotalRecords = totalRecords
+
+	respondWithPage(w, r, shared.GetPaginatedResponseWithPayload(attachments, pageParams))
+}
+
+func (a *App) getProposalExecution(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	p, err := helpers.fetchProposal(vars, "id")
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Proposal ID.")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	receipt, httpStatus, err := helpers.getExecutionRecord(p)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Execution payload of proposal %d is not available.", p.ID)
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, receipt)
+}
+
+func (a *App) getProposalCohosts(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	p, err := helpers.fetchProposal(vars, "id")
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Proposal ID.")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	cohosts, err := models.GetCohostsForProposal(a.DB, p.ID)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting proposal co-hosts.")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, cohosts)
+}
+
+func (a *App) addProposalCohost(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	proposalId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Proposal ID.")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.ProposalCohostPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	cohost, httpStatus, err := helpers.addProposalCohost(proposalId, payload)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error adding proposal co-host")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, httpStatus, cohost)
+}
+
+func (a *App) removeProposalCohost(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	proposalId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Proposal ID.")
+		respondWithError(w, errInvalidId)
+		return
+	}
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.ProposalCohostPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	httpStatus, err := helpers.removeProposalCohost(proposalId, communityId, payload)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error removing proposal co-host")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, "OK")
+}
+
+func (a *App) createProposal(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var p models.Proposal
+	p.Community_id = communityId
+
+	if err := validatePayload(r.Body, &p); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	proposal, errResponse := helpers.createProposal(p)
+	if errResponse != nilErr {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error creating proposal")
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, proposal)
+}
+
+func (a *App) updateProposal(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	p, err := helpers.fetchProposal(vars, "id")
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Proposal ID.")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid If-Match header.")
+		respondWithError(w, errMissingIfMatch)
+		return
+	}
+
+	var payload models.UpdateProposalRequestPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	// Check that status update is valid
+	// For now we are assuming proposals are creating with
+	// status 'published' and may be cancelled.
+	if payload.Status != "cancelled" {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid status update")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	// imported proposals are read-only history
+	if p.Imported_from_id != nil {
+		log.Ctx(r.Context()).Error().Msg("Imported proposals cannot be updated")
+		respondWithError(w, errForbidden)
+		return
+	}
+
+	if err := helpers.validateCommunityPermission(
+		p.Community_id,
+		payload.TimestampSignaturePayload,
+		payload.Voucher,
+		models.PermCancelProposal,
+	); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating user permission")
+		respondWithError(w, errForbidden)
+		return
+	}
+
+	p.Status = &payload.Status
+	p.Version = version
+	p.Cid = nil
+
+	if err := helpers.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return p.UpdateProposal(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error consuming signature")
+		respondWithError(w, errReplayedSignature)
+		return
+	} else if errors.Is(err, models.ErrStaleVersion) {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Stale update of proposal %d.", p.ID)
+		respondWithError(w, errStaleVersion)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error updating proposal")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	helpers.queuePin(models.PinProposal, p.ID)
+	helpers.recordProposalEvent(p, models.EventProposalClosed)
+	// a cancelled proposal never reaches quorum
+	helpers.markDepositDue(&p, false)
+
+	w.Header().Set("ETag", etag(p.Version))
+	respondWithJSON(w, http.StatusOK, p)
+}
+
+// Communities
+func (a *App) getCommunities(w http.ResponseWriter, r *http.Request) {
+	pageParams := getPageParams(*r, 25)
+
+	var communities []*models.Community
+	var totalRecords int
+	var err error
+	if sort := r.FormValue("sort"); sort == "trending" {
+		communities, totalRecords, err = models.GetTrendingCommunities(a.DB, requestTenant(r).ID, pageParams)
+	} else {
+		if pageParams.Sort, err = models.CommunitySortColumns.Parse(sort); err != nil {
+			errResponse := errIncompleteRequest
+			errResponse.setDetails(err)
+			respondWithError(w, errResponse)
+			return
+		}
+		communities, totalRecords, err = models.GetCommunities(a.DB, requestTenant(r).ID, pageParams)
+	}
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error fetching communities")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	pageParams.TotalRecords = totalRecords
+	respondWithPage(w, r, shared.GetPaginatedResponseWithPayload(communities, pageParams))
+}
+
+func (a *App) searchCommunities(w http.ResponseWriter, r *http.Request) {
+	pageParams := getPageParams(*r, 25)
+	filters := r.FormValue("filters")
+	searchText := r.FormValue("text")
+
+	results, totalRecords, categories, err := helpers.searchCommunities(
+		requestTenant(r),
+		searchText,
+		filters,
+		pageParams,
+	)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error searching communities")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	pageParams.TotalRecords = totalRecords
+
+	tagCount, err := helpers.getTagCountsForResults(results)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error counting proposal tags")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	paginatedResults, err := helpers.appendFiltersToResponse(
+		results,
+		pageParams,
+		categories,
+		tagCount,
+	)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error appending filters to response")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	setPageLinks(w, r, paginatedResults.Results)
+	respondWithJSON(w, http.StatusOK, paginatedResults)
+}
+
+func (a *App) getCommunity(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	c, err := helpers.fetchCommunity(id)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error fetching community")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+	if c.Tenant_id != requestTenant(r).ID {
+		log.Ctx(r.Context()).Warn().Msgf("Community %d belongs to tenant %d.", id, c.Tenant_id)
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = http.StatusNotFound
+		errResponse.Details = "Community not found."
+		respondWithError(w, errResponse)
+		return
+	}
+	c.Tokens, err = helpers.communityTokens(c)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error fetching tokens of community %d.", id)
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	t, err := helpers.localizedTranslation(models.CommunityTranslations, id, r.Header.Get("Accept-Language"))
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error fetching translation of community %d.", id)
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+	if t != nil {
+		c.Localize(t)
+		w.Header().Set("Content-Language", t.Locale)
+	}
+
+	lastModified := c.Updated_at
+	if t != nil {
+		lastModified = latestTime(lastModified, &t.Updated_at)
+	}
+
+	w.Header().Set("Vary", "Accept-Language")
+	respondWithCacheableJSON(w, r, c, c.Version, lastModified, cacheCommunity)
+}
+
+func (a *App) getChildCommunities(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	pageParams := getPageParams(*r, 25)
+
+	communities, totalRecords, err := models.GetChildCommunities(a.DB, id, pageParams)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error fetching child communities")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	pageParams.TotalRecords = totalRecords
+	respondWithPage(w, r, shared.GetPaginatedResponseWithPayload(communities, pageParams))
+}
+
+func (a *App) getCommunityHierarchy(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	hierarchy, err := helpers.getCommunityHierarchy(id)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error fetching community hierarchy")
+		respondWithError(w, errGetCommunity)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, hierarchy)
+}
+
+func (a *App) getCommunitiesForHomePage(w http.ResponseWriter, r *http.Request) {
+	pageParams := getPageParams(*r, 25)
+	isSearch := false
+
+	communities, totalRecords, err := models.GetDefaultCommunities(
+		a.DB,
+		requestTenant(r).ID,
+		pageParams,
+		[]string{},
+		isSearch,
+	)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error fetching communities for home page")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	pageParams.TotalRecords = totalRecords
+
+	respondWithPage(w, r, shared.GetPaginatedResponseWithPayload(communities, pageParams))
+}
+
+// getHomepage lists the curated homepage sections with their communities.
+func (a *App) getHomepage(w http.ResponseWriter, r *http.Request) {
+	sections, err := helpers.getHomepage(requestTenant(r))
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error fetching homepage sections")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, sections)
+}
+
+func (a *App) createCommunity(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var c models.Community
+	var payload models.CreateCommunityRequestPayload
+
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	//Validate Strategies & Proposal Thresholds
+	if payload.Strategies != nil {
+		err = validateContractThreshold(*payload.Strategies)
+		if err != nil {
+			log.Ctx(r.Context()).Error().Err(err).Msg("Error validating contract threshold")
+			errResponse := errIncompleteRequest
+			errResponse.setDetails(err)
+			respondWithError(w, errResponse)
+			return
+		}
+	}
+	if payload.Proposal_threshold != nil && payload.Only_authors_to_submit != nil {
+		err = validateProposalThreshold(*payload.Proposal_threshold, *payload.Only_authors_to_submit)
+		if err != nil {
+			log.Ctx(r.Context()).Error().Err(err).Msg("Error validating proposal threshold")
+			errResponse := errIncompleteRequest
+			errResponse.setDetails(err)
+			respondWithError(w, errResponse)
+			return
+		}
+	}
+
+	c, err = helpers.createCommunity(requestTenant(r), payload)
+	if errors.Is(err, models.ErrSignatureReused) {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Replayed community creation")
+		respondWithError(w, errReplayedSignature)
+		return
+	} else if errors.Is(err, models.ErrAddressBlocked) {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Blocked address %s creating community.", payload.Creator_addr)
+		respondWithError(w, errBlockedAddress)
+		return
+	} else if errors.Is(err, errPlanLimitReached) {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Community strategies over plan limit.")
+		errResponse := errPlanLimit
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error creating community")
+		errResponse := errIncompleteRequest
+		if fieldErrorsOf(err) != nil {
+			errResponse.setDetails(err)
+		}
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, c)
+}
+
+func (a *App) importCommunity(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, a.Config.Max_import_size)
+	if err := r.ParseMultipartForm(a.Config.Max_import_size); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Archive cannot be larger than max import size of %v.\n", a.Config.Max_import_size)
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	c, httpStatus, err := helpers.importCommunity(r)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error importing community")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, httpStatus, c)
+}
+
+func (a *App) updateCommunity(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid If-Match header.")
+		respondWithError(w, errMissingIfMatch)
+		return
+	}
+
+	var payload models.UpdateCommunityRequestPayload
+
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	//Validate Contract Thresholds
+	if payload.Strategies != nil {
+		err = validateContractThreshold(*payload.Strategies)
+		if err != nil {
+			log.Ctx(r.Context()).Error().Err(err).Msg("Error validating contract threshold")
+			errResponse := errIncompleteRequest
+			errResponse.setDetails(err)
+			respondWithError(w, errResponse)
+			return
+		}
+	}
+
+	if payload.Proposal_threshold != nil && payload.Only_authors_to_submit != nil {
+		err = validateProposalThreshold(*payload.Proposal_threshold, *payload.Only_authors_to_submit)
+		if err != nil {
+			log.Ctx(r.Context()).Error().Err(err).Msg("Error validating proposal threshold")
+			errResponse := errIncompleteRequest
+			errResponse.setDetails(err)
+			respondWithError(w, errResponse)
+			return
+		}
+	}
+
+	c, err := helpers.updateCommunity(id, version, payload)
+	if errors.Is(err, errApprovalsNeeded) {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Direct strategy update of community %d.", id)
+		respondWithError(w, errApprovalsRequired)
+		return
+	} else if errors.Is(err, errFrozenStrategy) {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Update of strategies in use by community %d.", id)
+		errResponse := errStrategyInUse
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	} else if errors.Is(err, models.ErrSignatureReused) {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Replayed update of community %d.", id)
+		respondWithError(w, errReplayedSignature)
+		return
+	} else if errors.Is(err, models.ErrStaleVersion) {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Stale update of community %d.", id)
+		respondWithError(w, errStaleVersion)
+		return
+	} else if errors.Is(err, errUnregisteredToken) {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Strategy with unregistered token for community %d.", id)
+		errResponse := errIncompleteRequest
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	} else if errors.Is(err, errPlanLimitReached) {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Strategies of community %d over plan limit.", id)
+		errResponse := errPlanLimit
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error updating community")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	w.Header().Set("ETag", etag(c.Version))
+	respondWithJSON(w, http.StatusOK, c)
+}
+
+func (a *App) archiveCommunity(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.ArchiveCommunityRequestPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	c, err := helpers.setCommunityArchived(id, payload, true)
+	if errors.Is(err, errApprovalsNeeded) {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Direct archive of community %d.", id)
+		respondWithError(w, errApprovalsRequired)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error archiving community")
+		respondWithError(w, errForbidden)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, c)
+}
+
+func (a *App) unarchiveCommunity(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.ArchiveCommunityRequestPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	c, err := helpers.setCommunityArchived(id, payload, false)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error unarchiving community")
+		respondWithError(w, errForbidden)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, c)
+}
+
+func (a *App) deleteCommunity(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.ArchiveCommunityRequestPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	c, httpStatus, err := helpers.deleteCommunity(id, payload)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error deleting community")
+		errResponse := errUpdateCommunity
+		errResponse.StatusCode = httpStatus
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, httpStatus, c)
+}
+
+func validateConractThreshold(s []models.Strategy) error {
+	for _, s := range s {
+		if s.Threshold != nil {
+			if *s.Threshold < 1 {
+				return errors.New("Contract Threshold Cannot Be < 1.")
+			}
+		}
+	}
+	return nil
+}
+
+// Voting Strategies
+func (a *App) getVotingStrategies(w http.ResponseWriter, r *http.Request) {
+	vs, err := models.GetVotingStrategies(a.DB)
+
+	// Add custom scripts for the custom-script strategy
+	for _, strategy := range vs {
+		if strategy.Key == "custom-script" {
+			strategy.Scripts = a.CustomScripts
+		}
+	}
+
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error fetching voting strategies")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, vs)
+}
+
+// getPayloadSchemas lists the payloads with a published JSON Schema.
+func (a *App) getPayloadSchemas(w http.ResponseWriter, r *http.Request) {
+	respondWithCacheableJSON(w, r, payloadSchemas, 0, nil, cacheSchema)
+}
+
+func (a *App) getPayloadSchema(w http.ResponseWriter, r *http.Request) {
+	schema, ok := findPayloadSchema(mux.Vars(r)["name"])
+	if !ok {
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = http.StatusNotFound
+		errResponse.setDetails(fmt.Errorf("No schema named %s.", mux.Vars(r)["name"]))
+		respondWithError(w, errResponse)
+		return
+	}
+	respondWithCacheableJSON(w, r, schema.Schema(a.PublicApiURL), 0, nil, cacheSchema)
+}
+
+func (a *App) getCommunityCategories(w http.ResponseWriter, r *http.Request) {
+	vs, err := models.GetCommunityTypes(a.DB)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error fetching community categories")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, vs)
+}
+
+func (a *App) getActiveStrategiesForCommunity(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	strategies, err := models.GetActiveStrategiesForCommunity(a.DB, communityId)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error fetching active strategies for community")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, strategies)
+}
+
+////////////
+// Lists //
+///////////
+
+func (a *App) getListsForCommunity(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	lists, err := models.GetListsForCommunity(a.DB, communityId)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting lists for community")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, lists)
+}
+
+func (a *App) getList(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid List ID")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+	list := models.List{ID: id}
+
+	if err = list.GetListById(a.DB); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting list")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, list)
+}
+
+func (a *App) getListVersions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid List ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+	pageParams := getPageParams(*r, 25)
+
+	versions, totalRecords, err := models.GetListVersions(a.DB, id, pageParams)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting list versions")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+	pageParams.TotalRecords = totalRecords
+
+	respondWithPage(w, r, shared.GetPaginatedResponseWithPayload(versions, pageParams))
+}
+
+func (a *App) getListVersion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid List ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+	version, err := strconv.Atoi(vars["version"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid List Version")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	v, err := models.GetListVersion(a.DB, id, version)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting list version")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = http.StatusNotFound
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, v)
+}
+
+func (a *App) createListForCommunity(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	payload := models.ListPayload{}
+	payload.Community_id = communityId
+
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	l, httpStatus, err := helpers.createListForCommunity(payload)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error creating list for community")
+		errIncompleteRequest.StatusCode = httpStatus
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, l)
+}
+
+func (a *App) combineLists(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.ListSetOperationPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	l, httpStatus, err := helpers.combineLists(communityId, payload)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error combining lists")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, httpStatus, l)
+}
+
+// syncCommunityLists updates the lists a child community copied from its
+// parent to the parent's current addresses.
+func (a *App) syncCommunityLists(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.CommunityRoleDeletePayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	lists, httpStatus, err := helpers.syncCommunityLists(communityId, payload)
+	if errors.Is(err, models.ErrSignatureReused) {
+		respondWithError(w, errReplayedSignature)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error syncing lists with the parent community")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, lists)
+}
+
+func (a *App) addAddressesToList(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid List ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	payload := models.ListUpdatePayload{}
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	httpStatus, err := helpers.updateAddressesInList(id, payload, "add")
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error adding addresses to list")
+		errIncompleteRequest.StatusCode = httpStatus
+		respondWithError(w, errCreateCommunity)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, "OK")
+}
+
+func (a *App) uploadListCSV(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid List ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, a.Config.Max_file_size)
+	if err := r.ParseMultipartForm(a.Config.Max_file_size); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("File cannot be larger than max file size of %v.\n", a.Config.Max_file_size)
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	report, httpStatus, err := helpers.uploadListCSV(id, r)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error uploading list CSV")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, httpStatus, report)
+}
+
+func (a *App) downloadListCSV(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid List ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	list := models.List{ID: id}
+	if err := list.GetListById(a.DB); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting list")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=list-%d.csv", list.ID))
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"addr"})
+	for _, addr := range list.Addresses {
+		writer.Write([]string{addr})
+	}
+	writer.Flush()
+}
+
+func (a *App) removeAddressesFromList(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid List ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	payload := models.ListUpdatePayload{}
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	httpStatus, err := helpers.updateAddressesInList(id, payload, "remove")
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error removing addresses from list")
+		errIncompleteRequest.StatusCode = httpStatus
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, "OK")
+}
+
+//////////////
+// Accounts //
+//////////////
+
+func (a *App) getAccountAtBlockHeight(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	addr := vars["addr"]
+	var blockHeight uint64
+	blockHeight, err := strconv.ParseUint(vars["blockHeight"], 10, 64)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error parsing blockHeight param.")
+		respondWithError(w, errFetchingBalance)
+		return
+	}
+
+	flowToken := "FlowToken"
+
+	b := shared.FTBalanceResponse{}
+	acc, err := a.FlowAdapter.GetAccountAtBlockHeight(addr, blockHeight)
+
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error getting account %s at blockheight %d.", addr, blockHeight)
+		respondWithError(w, errFetchingBalance)
+		return
+	}
+
+	b.Balance = acc.Balance
+	b.Addr = addr
+	b.BlockHeight = blockHeight
+	b.FungibleTokenID = flowToken
+	flowTokenAddr := a.FlowAdapter.Config.Contracts[flowToken].Aliases[a.FlowAdapter.Env]
+	if token, ok := a.TokenList.Lookup(flowToken, flowTokenAddr); ok {
+		b.Token = &token
+	}
+
+	respondWithJSON(w, http.StatusOK, b)
+}
+
+func (a *App) getAdminList(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, a.AdminAllowlist.List())
+}
+
+// reconcilePins lists the records whose CID is missing or not pinned.
+func (a *App) reconcilePins(w http.ResponseWriter, r *http.Request) {
+	var payload models.PinReconcilePayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid request payload.")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	pageParams := getPageParams(*r, 100)
+	records, pageParams, httpStatus, err := helpers.reconcilePins(payload, pageParams)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error reconciling pins.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithPage(w, r, shared.GetPaginatedResponseWithPayload(records, pageParams))
+}
+
+func (a *App) getCommunityBlocklist(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, a.CommunityBlocklist.List())
+}
+
+// getCommunityFeatures lists which feature flags are on for the community.
+func (a *App) getCommunityFeatures(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	features, err := models.GetCommunityFeatures(a.DB, id)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error fetching features of community %d.", id)
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, features)
+}
+
+// Platform admin
+
+func (a *App) getAdminCommunities(w http.ResponseWriter, r *http.Request) {
+	pageParams := getPageParams(*r, 25)
+	filter := r.FormValue("filter")
+
+	communities, pageParams, httpStatus, err := helpers.getAdminCommunities(bearerToken(r), filter, pageParams)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error listing communities for admin.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithPage(w, r, shared.GetPaginatedResponseWithPayload(communities, pageParams))
+}
+
+func (a *App) featureCommunity(w http.ResponseWriter, r *http.Request) {
+	a.setCommunityFeatured(w, r, true)
+}
+
+func (a *App) unfeatureCommunity(w http.ResponseWriter, r *http.Request) {
+	a.setCommunityFeatured(w, r, false)
+}
+
+func (a *App) setCommunityFeatured(w http.ResponseWriter, r *http.Request, featured bool) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload shared.TimestampSignaturePayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid request payload.")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	c, httpStatus, err := helpers.setCommunityFeatured(requestTenant(r), id, payload, featured)
+	if errors.Is(err, models.ErrSignatureReused) {
+		respondWithError(w, errReplayedSignature)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error featuring community %d.", id)
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, c)
+}
+
+func (a *App) suspendCommunity(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.SuspendCommunityPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid request payload.")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	c, httpStatus, err := helpers.suspendCommunity(id, payload)
+	if errors.Is(err, models.ErrSignatureReused) {
+		respondWithError(w, errReplayedSignature)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error suspending community %d.", id)
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, c)
+}
+
+func (a *App) unsuspendCommunity(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload shared.TimestampSignaturePayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid request payload.")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	c, httpStatus, err := helpers.unsuspendCommunity(id, payload)
+	if errors.Is(err, models.ErrSignatureReused) {
+		respondWithError(w, errReplayedSignature)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error unsuspending community %d.", id)
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, c)
+}
+
+// setCommunityQuotas sets how much a community may use of what the platform
+// pays for, in place of the platform's quotas.
+func (a *App) setCommunityQuotas(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.CommunityQuotasPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid request payload.")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	usage, httpStatus, err := helpers.setCommunityQuotas(id, payload)
+	if errors.Is(err, models.ErrSignatureReused) {
+		respondWithError(w, errReplayedSignature)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error setting quotas of community %d.", id)
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, usage)
+}
+
+func (a *App) getFailedJobs(w http.ResponseWriter, r *http.Request) {
+	pageParams := getPageParams(*r, 25)
+	job := r.FormValue("job")
+
+	failures, pageParams, httpStatus, err := helpers.getFailedJobs(bearerToken(r), job, pageParams)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error listing failed jobs.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithPage(w, r, shared.GetPaginatedResponseWithPayload(failures, pageParams))
+}
+
+func (a *App) getPlatformAdmins(w http.ResponseWriter, r *http.Request) {
+	a.getAddressList(w, r, models.PlatformAdmins)
+}
+
+func (a *App) getPlatformBlocklist(w http.ResponseWriter, r *http.Request) {
+	a.getAddressList(w, r, models.PlatformBlocklist)
+}
+
+func (a *App) getAddressList(w http.ResponseWriter, r *http.Request, list models.AddressList) {
+	pageParams := getPageParams(*r, 25)
+
+	listed, pageParams, httpStatus, err := helpers.getAddressList(bearerToken(r), list, pageParams)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error listing %s.", list)
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithPage(w, r, shared.GetPaginatedResponseWithPayload(listed, pageParams))
+}
+
+func (a *App) addPlatformAdmins(w http.ResponseWriter, r *http.Request) {
+	a.updateAddressList(w, r, models.PlatformAdmins, true)
+}
+
+func (a *App) removePlatformAdmins(w http.ResponseWriter, r *http.Request) {
+	a.updateAddressList(w, r, models.PlatformAdmins, false)
+}
+
+func (a *App) blockAddresses(w http.ResponseWriter, r *http.Request) {
+	a.updateAddressList(w, r, models.PlatformBlocklist, true)
+}
+
+func (a *App) unblockAddresses(w http.ResponseWriter, r *http.Request) {
+	a.updateAddressList(w, r, models.PlatformBlocklist, false)
+}
+
+func (a *App) updateAddressList(w http.ResponseWriter, r *http.Request, list models.AddressList, add bool) {
+	var payload models.AddressListPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid request payload.")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	changed, httpStatus, err := helpers.updateAddressList(list, payload, add)
+	if errors.Is(err, models.ErrSignatureReused) {
+		respondWithError(w, errReplayedSignature)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error updating %s.", list)
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]int64{"updated": changed})
+}
+
+func (a *App) pinCommunity(w http.ResponseWriter, r *http.Request) {
+	a.setCommunityPinned(w, r, true)
+}
+
+func (a *App) unpinCommunity(w http.ResponseWriter, r *http.Request) {
+	a.setCommunityPinned(w, r, false)
+}
+
+func (a *App) setCommunityPinned(w http.ResponseWriter, r *http.Request, pinned bool) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload shared.TimestampSignaturePayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid request payload.")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	c, httpStatus, err := helpers.setCommunityPinned(requestTenant(r), id, payload, pinned)
+	if errors.Is(err, models.ErrSignatureReused) {
+		respondWithError(w, errReplayedSignature)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error pinning community %d.", id)
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, c)
+}
+
+func (a *App) setHomepageOrder(w http.ResponseWriter, r *http.Request) {
+	var payload models.HomepageOrderPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid request payload.")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	httpStatus, err := helpers.setHomepageOrder(requestTenant(r), payload)
+	if errors.Is(err, models.ErrSignatureReused) {
+		respondWithError(w, errReplayedSignature)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error ordering homepage communities.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (a *App) createHomepageSection(w http.ResponseWriter, r *http.Request) {
+	a.saveHomepageSection(w, r, 0)
+}
+
+func (a *App) updateHomepageSection(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Section ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+	a.saveHomepageSection(w, r, id)
+}
+
+func (a *App) saveHomepageSection(w http.ResponseWriter, r *http.Request, id int) {
+	var payload models.HomepageSectionPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid request payload.")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	section, httpStatus, err := helpers.saveHomepageSection(requestTenant(r), id, payload)
+	if errors.Is(err, models.ErrSignatureReused) {
+		respondWithError(w, errReplayedSignature)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error saving homepage section.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	status := http.StatusOK
+	if id == 0 {
+		status = http.StatusCreated
+	}
+	respondWithJSON(w, status, section)
+}
+
+func (a *App) deleteHomepageSection(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Section ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload shared.TimestampSignaturePayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid request payload.")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	httpStatus, err := helpers.deleteHomepageSection(requestTenant(r), id, payload)
+	if errors.Is(err, models.ErrSignatureReused) {
+		respondWithError(w, errReplayedSignature)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error deleting homepage section %d.", id)
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (a *App) getFeatureFlags(w http.ResponseWriter, r *http.Request) {
+	flags, httpStatus, err := helpers.getFeatureFlags(bearerToken(r))
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error listing feature flags.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, flags)
+}
+
+func (a *App) setFeatureRollout(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	var payload models.FeatureRolloutPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid request payload.")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	f, httpStatus, err := helpers.setFeatureRollout(name, payload)
+	if errors.Is(err, models.ErrSignatureReused) {
+		respondWithError(w, errReplayedSignature)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error setting rollout of %s.", name)
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, f)
+}
+
+func (a *App) setCommunityFeature(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.CommunityFeaturePayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid request payload.")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+	// DELETE returns the community to the rollout
+	if r.Method == http.MethodDelete {
+		payload.Enabled = nil
+	} else if payload.Enabled == nil {
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	f, httpStatus, err := helpers.setCommunityFeature(name, communityId, payload)
+	if errors.Is(err, models.ErrSignatureReused) {
+		respondWithError(w, errReplayedSignature)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error setting %s for community %d.", name, communityId)
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, f)
+}
+
+func (a *App) getPlatformStats(w http.ResponseWriter, r *http.Request) {
+	stats, httpStatus, err := helpers.getPlatformStats(bearerToken(r))
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error fetching platform stats.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, stats)
+}
+
+// getConfig shows platform admins the configuration the instance runs with,
+// secrets masked.
+func (a *App) getConfig(w http.ResponseWriter, r *http.Request) {
+	config, httpStatus, err := helpers.getConfig(bearerToken(r))
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error fetching configuration.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, config)
+}
+
+// getTenant shows the tenant serving the request, for the frontend to
+// brand itself.
+func (a *App) getTenant(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, requestTenant(r).Public())
+}
+
+func (a *App) getTenants(w http.ResponseWriter, r *http.Request) {
+	tenants, httpStatus, err := helpers.getTenants(bearerToken(r))
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error listing tenants.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, tenants)
+}
+
+func (a *App) createTenant(w http.ResponseWriter, r *http.Request) {
+	a.saveTenant(w, r, 0)
+}
+
+func (a *App) updateTenant(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Tenant ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+	a.saveTenant(w, r, id)
+}
+
+func (a *App) saveTenant(w http.ResponseWriter, r *http.Request, id int) {
+	var payload models.TenantPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid request payload.")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	t, httpStatus, err := helpers.saveTenant(id, payload)
+	if errors.Is(err, models.ErrSignatureReused) {
+		respondWithError(w, errReplayedSignature)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error saving tenant.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	status := http.StatusOK
+	if id == 0 {
+		status = http.StatusCreated
+	}
+	respondWithJSON(w, status, t)
+}
+
+func (a *App) createCommunityUser(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	payload := models.CommunityUserPayload{}
+	payload.Community_id = communityId
+
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	httpStatus, err := helpers.createCommunityUser(payload)
+	if errors.Is(err, errPlanLimitReached) {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Members of community %d over plan limit.", communityId)
+		errResponse := errPlanLimit
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error creating community user")
+		errCreateCommunity.StatusCode = httpStatus
+		respondWithError(w, errCreateCommunity)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, "OK")
+}
+
+func (a *App) getCommunityUsers(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	pageParams := getPageParams(*r, 100)
+	if pageParams.Sort, err = models.UserSortColumns.Parse(r.FormValue("sort")); err != nil {
+		errResponse := errIncompleteRequest
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	users, totalRecords, err := models.GetUsersForCommunity(a.DB, communityId, pageParams)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting community users")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	pageParams.TotalRecords = totalRecords
+
+	respondWithPage(w, r, shared.GetPaginatedResponseWithPayload(users, pageParams))
+
+}
+
+func (a *App) getCommunityUsersByType(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	userType := vars["userType"]
+	if !models.EnsureValidRole(userType) {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid User Type")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	pageParams := getPageParams(*r, 100)
+	if pageParams.Sort, err = models.UserTypeSortColumns.Parse(r.FormValue("sort")); err != nil {
+		errResponse := errIncompleteRequest
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+	users, totalRecords, err := models.GetUsersForCommunityByType(
+		a.DB,
+		communityId,
+		userType,
+		pageParams,
+	)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting community users")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+	pageParams.TotalRecords = totalRecords
+
+	respondWithPage(w, r, shared.GetPaginatedResponseWithPayload(users, pageParams))
+}
+
+func (a *App) createJoinRequest(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.JoinRequestPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	jr, httpStatus, err := helpers.createJoinRequest(communityId, payload)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error creating join request")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, httpStatus, jr)
+}
+
+func (a *App) getJoinRequests(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	addr, err := helpers.sessionAddr(bearerToken(r))
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid session")
+		respondWithError(w, errLoginRequired)
+		return
+	}
+	if err := models.EnsurePermissionForCommunity(a.DB, addr, communityId, models.PermManageMembers); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("%s cannot see join requests of community %d.", addr, communityId)
+		respondWithError(w, errForbidden)
+		return
+	}
+
+	status := r.FormValue("status")
+	pageParams := getPageParams(*r, 100)
+
+	requests, totalRecords, err := models.GetJoinRequestsForCommunity(a.DB, communityId, status, pageParams)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting join requests")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+	pageParams.TotalRecords = totalRecords
+
+	respondWithPage(w, r, shared.GetPaginatedResponseWithPayload(requests, pageParams))
+}
+
+func (a *App) approveJoinRequest(w http.ResponseWriter, r *http.Request) {
+	a.reviewJoinRequest(w, r, models.JoinRequestApproved)
+}
+
+func (a *App) denyJoinRequest(w http.ResponseWriter, r *http.Request) {
+	a.reviewJoinRequest(w, r, models.JoinRequestDenied)
+}
+
+func (a *App) reviewJoinRequest(w http.ResponseWriter, r *http.Request, status string) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+	requestId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Join Request ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.ReviewJoinRequestPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	jr, httpStatus, err := helpers.reviewJoinRequest(communityId, requestId, payload, status)
+	if errors.Is(err, errPlanLimitReached) {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Members of community %d over plan limit.", communityId)
+		errResponse := errPlanLimit
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error reviewing join request")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, jr)
+}
+
+func (a *App) createInvite(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.CreateInvitePayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	invite, httpStatus, err := helpers.createInvite(communityId, payload)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error creating invite")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, httpStatus, invite)
+}
+
+func (a *App) getInvites(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	// invites name the addresses they were issued to
+	addr, err := helpers.sessionAddr(bearerToken(r))
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid session")
+		respondWithError(w, errLoginRequired)
+		return
+	}
+	if err := models.EnsurePermissionForCommunity(a.DB, addr, communityId, models.PermManageMembers); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("%s cannot see invites of community %d.", addr, communityId)
+		respondWithError(w, errForbidden)
+		return
+	}
+
+	pageParams := getPageParams(*r, 100)
+
+	invites, totalRecords, err := models.GetInvitesForCommunity(a.DB, communityId, pageParams)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting invites")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+	pageParams.TotalRecords = totalRecords
+
+	respondWithPage(w, r, shared.GetPaginatedResponseWithPayload(invites, pageParams))
+}
+
+func (a *App) revokeInvite(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+	inviteId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Invite ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.RevokeInvitePayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	invite, httpStatus, err := helpers.revokeInvite(communityId, inviteId, payload)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error revoking invite")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, invite)
+}
+
+func (a *App) redeemInvite(w http.ResponseWriter, r *http.Request) {
+	var payload models.RedeemInvitePayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	user, httpStatus, err := helpers.redeemInvite(payload)
+	if errors.Is(err, errPlanLimitReached) {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invite redeemed over plan limit.")
+		errResponse := errPlanLimit
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error redeeming invite")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, httpStatus, user)
+}
+
+func (a *App) getPermissions(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"permissions":  models.PERMISSIONS,
+		"defaultRoles": models.DEFAULT_ROLE_PERMISSIONS,
+	})
+}
+
+func (a *App) getCommunityRoles(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	roles, err := models.GetRolesForCommunity(a.DB, communityId)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting community roles")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, roles)
+}
+
+func (a *App) getCommunityRoleMembers(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	roleId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Role ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	role := models.CommunityRole{ID: roleId}
+	addrs, err := role.GetRoleMembers(a.DB)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting role members")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, addrs)
+}
+
+func (a *App) getUserPermissions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	permissions, err := models.GetPermissionsForAddress(a.DB, vars["addr"], communityId)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting user permissions")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, permissions)
+}
+
+func (a *App) createCommunityRole(w http.ResponseWriter, r *http.Request) {
+	a.saveCommunityRole(w, r, false)
+}
+
+func (a *App) updateCommunityRole(w http.ResponseWriter, r *http.Request) {
+	a.saveCommunityRole(w, r, true)
+}
+
+func (a *App) saveCommunityRole(w http.ResponseWriter, r *http.Request, update bool) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+	var roleId int
+	if update {
+		roleId, err = strconv.Atoi(vars["id"])
+		if err != nil {
+			log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Role ID")
+			respondWithError(w, errInvalidId)
+			return
+		}
+	}
+
+	var payload models.CommunityRolePayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	role, httpStatus, err := helpers.saveCommunityRole(communityId, roleId, payload)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error saving community role")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, httpStatus, role)
+}
+
+func (a *App) deleteCommunityRole(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+	roleId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Role ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.CommunityRoleDeletePayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	httpStatus, err := helpers.deleteCommunityRole(communityId, roleId, payload)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error deleting community role")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, "OK")
+}
+
+func (a *App) assignCommunityRole(w http.ResponseWriter, r *http.Request) {
+	a.setCommunityRoleMember(w, r, true)
+}
+
+func (a *App) unassignCommunityRole(w http.ResponseWriter, r *http.Request) {
+	a.setCommunityRoleMember(w, r, false)
+}
+
+func (a *App) setCommunityRoleMember(w http.ResponseWriter, r *http.Request, assign bool) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+	roleId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Role ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.CommunityRoleMemberPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	httpStatus, err := helpers.setCommunityRoleMember(communityId, roleId, payload, assign)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error updating role members")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, "OK")
+}
+
+func (a *App) createCommunityExport(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.CreateExportPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	export, httpStatus, err := helpers.createExport(communityId, payload)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error creating community export")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, httpStatus, export)
+}
+
+func (a *App) getCommunityExport(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+	exportId, err := strconv.Atoi(vars["exportId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Export ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	export, httpStatus, err := helpers.getExport(communityId, exportId, r.FormValue("token"))
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting community export")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, export)
+}
+
+func (a *App) downloadCommunityExport(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+	exportId, err := strconv.Atoi(vars["exportId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Export ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	export, httpStatus, err := helpers.getExport(communityId, exportId, r.FormValue("token"))
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting community export")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		respondWithError(w, errResponse)
+		return
+	}
+	if export.Status != models.ExportCompleted {
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = http.StatusConflict
+		errResponse.Details = "Export is not ready yet."
+		respondWithError(w, errResponse)
+		return
+	}
+
+	archive, err := models.GetExportArchive(a.DB, export.ID)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error reading community export")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set(
+		"Content-Disposition",
+		fmt.Sprintf("attachment; filename=community-%d-export-%d.zip", communityId, export.ID),
+	)
+	w.WriteHeader(http.StatusOK)
+	w.Write(archive)
+}
+
+func (a *App) getCommunityBans(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	pageParams := getPageParams(*r, 100)
+
+	bans, totalRecords, err := models.GetBansForCommunity(a.DB, communityId, pageParams)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting community bans")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+	pageParams.TotalRecords = totalRecords
+
+	respondWithPage(w, r, shared.GetPaginatedResponseWithPayload(bans, pageParams))
+}
+
+func (a *App) exportCommunityBans(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	bans, err := models.GetAllBansForCommunity(a.DB, communityId)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error exporting community bans")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=community-%d-bans.csv", communityId))
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"addr", "reason", "bannedBy", "createdAt"})
+	for _, b := range bans {
+		reason := ""
+		if b.Reason != nil {
+			reason = *b.Reason
+		}
+		createdAt := ""
+		if b.Created_at != nil {
+			createdAt = b.Created_at.Format(time.RFC3339)
+		}
+		writer.Write([]string{b.Addr, reason, b.Banned_by, createdAt})
+	}
+	writer.Flush()
+}
+
+func (a *App) banAddress(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.CommunityBanPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	ban, httpStatus, err := helpers.banAddress(communityId, payload)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error banning address")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, httpStatus, ban)
+}
+
+func (a *App) unbanAddress(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.CommunityBanPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+	payload.Addr = vars["addr"]
+
+	httpStatus, err := helpers.unbanAddress(communityId, payload)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error unbanning address")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, "OK")
+}
+
+func (a *App) getCommunityTags(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	tags, err := models.GetTagsForCommunity(a.DB, communityId)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting community tags")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, tags)
+}
+
+func (a *App) createCommunityTag(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.CommunityTagPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	tag, httpStatus, err := helpers.createCommunityTag(communityId, payload)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error creating community tag")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, httpStatus, tag)
+}
+
+func (a *App) deleteCommunityTag(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+	tagId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Tag ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.CommunityTagPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	httpStatus, err := helpers.deleteCommunityTag(communityId, tagId, payload)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error deleting community tag")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, "OK")
+}
+
+func (a *App) getCommunityTreasuries(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	treasuries, err := models.GetTreasuriesForCommunity(a.DB, communityId)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting community treasuries")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, treasuries)
+}
+
+func (a *App) createTreasury(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.TreasuryPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	treasury, httpStatus, err := helpers.createTreasury(communityId, payload)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error creating community treasury")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, httpStatus, treasury)
+}
+
+func (a *App) deleteTreasury(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+	treasuryId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Treasury ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.TreasuryPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	httpStatus, err := helpers.deleteTreasury(communityId, treasuryId, payload)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error deleting community treasury")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, "OK")
+}
+
+func (a *App) getCommunityTokens(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	tokens, err := models.GetTokensForCommunity(a.DB, communityId)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting community tokens")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, tokens)
+}
+
+func (a *App) registerCommunityToken(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.CommunityTokenPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	token, httpStatus, err := helpers.registerCommunityToken(communityId, payload)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error registering community token")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, httpStatus, token)
+}
+
+func (a *App) deleteCommunityToken(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+	tokenId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Token ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.CommunityTokenPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	httpStatus, err := helpers.deleteCommunityToken(communityId, tokenId, payload)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error deleting community token")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, "OK")
+}
+
+// getTokens lists the tokens strategies can use, with the metadata clients
+// show them with: those of the token list, and with ?communityId= those
+// the community registered.
+func (a *App) getTokens(w http.ResponseWriter, r *http.Request) {
+	var communityId *int
+	if param := r.FormValue("communityId"); param != "" {
+		id, err := strconv.Atoi(param)
+		if err != nil {
+			log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+			respondWithError(w, errIncompleteRequest)
+			return
+		}
+		communityId = &id
+	}
+
+	tokens, err := helpers.listTokens(communityId)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error listing tokens")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, tokens)
+}
+
+func (a *App) getCommunityActions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	actions, err := models.GetPendingActionsForCommunity(a.DB, communityId, r.FormValue("status"))
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting community actions")
 		respondWithError(w, errIncompleteRequest)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, c)
+	respondWithJSON(w, http.StatusOK, actions)
+}
+
+func (a *App) proposeCommunityAction(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.PendingActionPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	action, httpStatus, err := helpers.proposeCommunityAction(communityId, payload)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error proposing community action")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, httpStatus, action)
+}
+
+func (a *App) approveCommunityAction(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+	actionId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Action ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.PendingActionApproval
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	action, httpStatus, err := helpers.approveCommunityAction(communityId, actionId, payload)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error approving community action")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, httpStatus, action)
+}
+
+func (a *App) cancelCommunityAction(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	communityId, err := strconv.Atoi(vars["communityId"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+	actionId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Action ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+
+	var payload models.PendingActionApproval
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	action, httpStatus, err := helpers.cancelCommunityAction(communityId, actionId, payload)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error cancelling community action")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, httpStatus, action)
 }
 
-func (a *App) getCommunitiesForHomePage(w http.ResponseWriter, r *http.Request) {
-	pageParams := getPageParams(*r, 25)
-	isSearch := false
+func (a *App) getCommunityFeed(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
 
-	communities, totalRecords, err := models.GetDefaultCommunities(
-		a.DB,
-		pageParams,
-		[]string{},
-		isSearch,
-	)
+	pageParams := getPageParams(*r, 50)
+
+	events, totalRecords, err := models.GetFeedForCommunity(a.DB, id, pageParams)
 	if err != nil {
-		log.Error().Err(err).Msg("Error fetching communities for home page")
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting community feed")
 		respondWithError(w, errIncompleteRequest)
 		return
 	}
-
 	pageParams.TotalRecords = totalRecords
 
-	response := shared.GetPaginatedResponseWithPayload(communities, pageParams)
-	respondWithJSON(w, http.StatusOK, response)
+	respondWithPage(w, r, shared.GetPaginatedResponseWithPayload(events, pageParams))
 }
 
-func (a *App) createCommunity(w http.ResponseWriter, r *http.Request) {
-	var err error
-	var c models.Community
-	var payload models.CreateCommunityRequestPayload
-
-	if err := validatePayload(r.Body, &payload); err != nil {
-		log.Error().Err(err).Msg("Error validating payload")
-		respondWithError(w, errIncompleteRequest)
+// getCommunityTrigger answers a poll of a trigger with a plain array of
+// items, newest first, which is what automation tools expect.
+func (a *App) getCommunityTrigger(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
 		return
 	}
 
-	//Validate Strategies & Proposal Thresholds
-	if payload.Strategies != nil {
-		err = validateContractThreshold(*payload.Strategies)
-		if err != nil {
-			log.Error().Err(err).Msg("Error validating contract threshold")
-			respondWithError(w, errIncompleteRequest)
-			return
-		}
+	since, _ := strconv.Atoi(r.FormValue("since"))
+	limit, _ := strconv.Atoi(r.FormValue("limit"))
+	if limit > models.MaxTriggerItems || limit < 1 {
+		limit = models.MaxTriggerItems
 	}
-	if payload.Proposal_threshold != nil && payload.Only_authors_to_submit != nil {
-		err = validateProposalThreshold(*payload.Proposal_threshold, *payload.Only_authors_to_submit)
-		if err != nil {
-			log.Error().Err(err).Msg("Error validating proposal threshold")
-			respondWithError(w, errIncompleteRequest)
-		}
+	filters, err := getProposalFilters(*r)
+	if err != nil {
+		errResponse := errIncompleteRequest
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
 	}
 
-	c, err = helpers.createCommunity(payload)
+	items, httpStatus, err := helpers.getTriggerItems(id, vars["trigger"], since, filters, limit)
 	if err != nil {
-		log.Error().Err(err).Msg("Error creating community")
-		respondWithError(w, errIncompleteRequest)
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error polling %s of community %d.", vars["trigger"], id)
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
 		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, c)
+	respondWithJSON(w, http.StatusOK, items)
 }
 
-func (a *App) updateCommunity(w http.ResponseWriter, r *http.Request) {
+func (a *App) getCommunityAnalytics(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		log.Error().Err(err).Msg("Invalid Community ID")
-		respondWithError(w, errIncompleteRequest)
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
 		return
 	}
-	var payload models.UpdateCommunityRequestPayload
 
-	if err := validatePayload(r.Body, &payload); err != nil {
-		log.Error().Err(err).Msg("Error validating payload")
+	months, _ := strconv.Atoi(r.FormValue("months"))
+	if months > defaultAnalyticsMonths || months < 1 {
+		months = defaultAnalyticsMonths
+	}
+	proposals, _ := strconv.Atoi(r.FormValue("proposals"))
+	if proposals > defaultAnalyticsProposals || proposals < 1 {
+		proposals = defaultAnalyticsProposals
+	}
+
+	analytics, err := models.GetCommunityAnalytics(a.DB, id, months, proposals)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting community analytics")
 		respondWithError(w, errIncompleteRequest)
 		return
 	}
 
-	//Validate Contract Thresholds
-	if payload.Strategies != nil {
-		err = validateContractThreshold(*payload.Strategies)
-		if err != nil {
-			log.Error().Err(err).Msg("Error validating contract threshold")
-			respondWithError(w, errIncompleteRequest)
-			return
-		}
-	}
+	respondWithJSON(w, http.StatusOK, analytics)
+}
 
-	if payload.Proposal_threshold != nil && payload.Only_authors_to_submit != nil {
-		err = validateProposalThreshold(*payload.Proposal_threshold, *payload.Only_authors_to_submit)
-		if err != nil {
-			log.Error().Err(err).Msg("Error validating proposal threshold")
-			respondWithError(w, errIncompleteRequest)
-		}
+func (a *App) getNotifications(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	addr := vars["addr"]
+
+	sessionAddr, err := helpers.sessionAddr(bearerToken(r))
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid session")
+		respondWithError(w, errLoginRequired)
+		return
+	}
+	if sessionAddr != addr {
+		log.Ctx(r.Context()).Error().Msgf("%s cannot read the notifications of %s.", sessionAddr, addr)
+		respondWithError(w, errForbidden)
+		return
 	}
 
-	c, err := helpers.updateCommunity(id, payload)
+	unreadOnly := r.FormValue("unread") == "true"
+	pageParams := getPageParams(*r, 50)
+
+	notifications, totalRecords, err := models.GetNotificationsForAddress(a.DB, addr, unreadOnly, pageParams)
 	if err != nil {
-		log.Error().Err(err).Msg("Error updating community")
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting notifications")
 		respondWithError(w, errIncompleteRequest)
 		return
 	}
+	pageParams.TotalRecords = totalRecords
 
-	respondWithJSON(w, http.StatusOK, c)
+	respondWithPage(w, r, shared.GetPaginatedResponseWithPayload(notifications, pageParams))
 }
 
-func validateConractThreshold(s []models.Strategy) error {
-	for _, s := range s {
-		if s.Threshold != nil {
-			if *s.Threshold < 1 {
-				return errors.New("Contract Threshold Cannot Be < 1.")
-			}
-		}
-	}
-	return nil
-}
+func (a *App) markNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	addr := vars["addr"]
 
-// Voting Strategies
-func (a *App) getVotingStrategies(w http.ResponseWriter, r *http.Request) {
-	vs, err := models.GetVotingStrategies(a.DB)
+	var payload models.MarkNotificationsReadPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
 
-	// Add custom scripts for the custom-script strategy
-	for _, strategy := range vs {
-		if strategy.Key == "custom-script" {
-			strategy.Scripts = customScripts
-		}
+	if err := helpers.validateSignedByAddress(addr, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating signature")
+		respondWithError(w, errForbidden)
+		return
 	}
 
-	if err != nil {
-		log.Error().Err(err).Msg("Error fetching voting strategies")
+	var updated int64
+	if err := helpers.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		var err error
+		updated, err = models.MarkNotificationsRead(tx, addr, payload.Ids)
+		return err
+	}); errors.Is(err, models.ErrSignatureReused) {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error consuming signature")
+		respondWithError(w, errReplayedSignature)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error marking notifications read")
 		respondWithError(w, errIncompleteRequest)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, vs)
+	respondWithJSON(w, http.StatusOK, map[string]int64{"updated": updated})
 }
 
-func (a *App) getCommunityCategories(w http.ResponseWriter, r *http.Request) {
-	vs, err := models.GetCommunityTypes(a.DB)
+func (a *App) getNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	preferences, err := models.GetNotificationPreferences(a.DB, vars["addr"])
 	if err != nil {
-		log.Error().Err(err).Msg("Error fetching community categories")
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting notification preferences")
 		respondWithError(w, errIncompleteRequest)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, vs)
+	respondWithJSON(w, http.StatusOK, preferences)
 }
 
-func (a *App) getActiveStrategiesForCommunity(w http.ResponseWriter, r *http.Request) {
+func (a *App) updateNotificationPreferences(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	communityId, err := strconv.Atoi(vars["communityId"])
 
+	var payload models.NotificationPreferencesPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	preferences, httpStatus, err := helpers.updateNotificationPreferences(vars["addr"], payload)
 	if err != nil {
-		log.Error().Err(err).Msg("Invalid Community ID")
-		respondWithError(w, errIncompleteRequest)
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error updating notification preferences")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		respondWithError(w, errResponse)
 		return
 	}
 
-	strategies, err := models.GetActiveStrategiesForCommunity(a.DB, communityId)
+	respondWithJSON(w, http.StatusOK, preferences)
+}
+
+func (a *App) getFollows(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	pageParams := getPageParams(*r, 100)
+
+	follows, totalRecords, err := models.GetFollowsForAddress(a.DB, vars["addr"], pageParams)
 	if err != nil {
-		log.Error().Err(err).Msg("Error fetching active strategies for community")
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting follows")
 		respondWithError(w, errIncompleteRequest)
 		return
 	}
+	pageParams.TotalRecords = totalRecords
 
-	respondWithJSON(w, http.StatusOK, strategies)
+	respondWithPage(w, r, shared.GetPaginatedResponseWithPayload(follows, pageParams))
 }
 
-////////////
-// Lists //
-///////////
+func (a *App) follow(w http.ResponseWriter, r *http.Request) {
+	a.setFollow(w, r, true)
+}
 
-func (a *App) getListsForCommunity(w http.ResponseWriter, r *http.Request) {
+func (a *App) unfollow(w http.ResponseWriter, r *http.Request) {
+	a.setFollow(w, r, false)
+}
+
+func (a *App) setFollow(w http.ResponseWriter, r *http.Request, follow bool) {
 	vars := mux.Vars(r)
-	communityId, err := strconv.Atoi(vars["communityId"])
+
+	var payload models.FollowPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	f, httpStatus, err := helpers.setFollow(vars["addr"], payload, follow)
 	if err != nil {
-		log.Error().Err(err).Msg("Invalid Community ID")
-		respondWithError(w, errIncompleteRequest)
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error updating follow")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		respondWithError(w, errResponse)
 		return
 	}
 
-	lists, err := models.GetListsForCommunity(a.DB, communityId)
+	respondWithJSON(w, httpStatus, f)
+}
+
+// getMyFeed returns the activity of everything the logged in address
+// follows.
+func (a *App) getMyFeed(w http.ResponseWriter, r *http.Request) {
+	addr, err := helpers.sessionAddr(bearerToken(r))
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid session")
+		respondWithError(w, errLoginRequired)
+		return
+	}
+
+	pageParams := getPageParams(*r, 50)
+
+	events, totalRecords, err := models.GetFeedForAddress(a.DB, addr, pageParams)
 	if err != nil {
-		log.Error().Err(err).Msg("Error getting lists for community")
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting feed")
 		respondWithError(w, errIncompleteRequest)
 		return
 	}
+	pageParams.TotalRecords = totalRecords
 
-	respondWithJSON(w, http.StatusOK, lists)
+	respondWithPage(w, r, shared.GetPaginatedResponseWithPayload(events, pageParams))
 }
 
-func (a *App) getList(w http.ResponseWriter, r *http.Request) {
+func (a *App) getUserProfile(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
 
+	profile := models.UserProfile{Addr: vars["addr"]}
+	if err := profile.GetProfile(a.DB); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting user profile")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+	if err := profile.GetStats(a.DB); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting user stats")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
+	verifiedBy, err := models.GetVerifiedProviders(a.DB, profile.Addr)
 	if err != nil {
-		log.Error().Err(err).Msg("Invalid List ID")
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting user verifications")
 		respondWithError(w, errIncompleteRequest)
 		return
 	}
-	list := models.List{ID: id}
+	profile.Verified_by = verifiedBy
 
-	if err = list.GetListById(a.DB); err != nil {
-		log.Error().Err(err).Msg("Error getting list")
+	respondWithJSON(w, http.StatusOK, profile)
+}
+
+func (a *App) getUserAchievements(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	addr := vars["addr"]
+	pageParams := getPageParams(*r, 25)
+
+	communityId := 0
+	if r.FormValue("communityId") != "" {
+		id, err := strconv.Atoi(r.FormValue("communityId"))
+		if err != nil {
+			log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+			respondWithError(w, errIncompleteRequest)
+			return
+		}
+		communityId = id
+	}
+
+	achievements, totalRecords, err := models.GetAchievementsForAddress(
+		a.DB,
+		addr,
+		communityId,
+		r.FormValue("type"),
+		pageParams,
+	)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting user achievements")
 		respondWithError(w, errIncompleteRequest)
 		return
 	}
+	pageParams.TotalRecords = totalRecords
 
-	respondWithJSON(w, http.StatusOK, list)
+	respondWithPage(w, r, shared.GetPaginatedResponseWithPayload(achievements, pageParams))
 }
 
-func (a *App) createListForCommunity(w http.ResponseWriter, r *http.Request) {
+func (a *App) getAccountActivity(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	communityId, err := strconv.Atoi(vars["communityId"])
+	addr := vars["addr"]
+	pageParams := getPageParams(*r, 50)
+
+	var types []string
+	if r.FormValue("type") != "" {
+		types = strings.Split(r.FormValue("type"), ",")
+	}
+	for _, t := range types {
+		if !models.EnsureValidActivityType(t) {
+			errResponse := errIncompleteRequest
+			errResponse.Details = fmt.Sprintf("Activity can only be of type %s.", strings.Join(models.ACTIVITY_TYPES, ", "))
+			respondWithError(w, errResponse)
+			return
+		}
+	}
+
+	activity, totalRecords, err := models.GetActivityForAddress(
+		a.DB,
+		requestTenant(r).ID,
+		addr,
+		types,
+		pageParams,
+	)
 	if err != nil {
-		log.Error().Err(err).Msg("Invalid Community ID")
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting account activity")
 		respondWithError(w, errIncompleteRequest)
 		return
 	}
+	pageParams.TotalRecords = totalRecords
 
-	payload := models.ListPayload{}
-	payload.Community_id = communityId
+	respondWithPage(w, r, shared.GetPaginatedResponseWithPayload(activity, pageParams))
+}
+
+func (a *App) updateUserProfile(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
 
+	var payload models.UpdateProfilePayload
 	if err := validatePayload(r.Body, &payload); err != nil {
-		log.Error().Err(err).Msg("Error validating payload")
-		respondWithError(w, errIncompleteRequest)
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
 		return
 	}
 
-	l, httpStatus, err := helpers.createListForCommunity(payload)
+	profile, httpStatus, err := helpers.updateUserProfile(vars["addr"], payload)
 	if err != nil {
-		log.Error().Err(err).Msg("Error creating list for community")
-		errIncompleteRequest.StatusCode = httpStatus
-		respondWithError(w, errIncompleteRequest)
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error updating user profile")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		respondWithError(w, errResponse)
 		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, l)
+	respondWithJSON(w, http.StatusOK, profile)
 }
 
-func (a *App) addAddressesToList(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+func (a *App) getAddressVerifications(w http.ResponseWriter, r *http.Request) {
+	addr := mux.Vars(r)["addr"]
+
+	verifications, httpStatus, err := helpers.getAddressVerifications(addr)
 	if err != nil {
-		log.Error().Err(err).Msg("Invalid List ID")
-		respondWithError(w, errIncompleteRequest)
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error fetching verifications of %s.", addr)
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		respondWithError(w, errResponse)
 		return
 	}
 
-	payload := models.ListUpdatePayload{}
+	respondWithJSON(w, http.StatusOK, verifications)
+}
+
+func (a *App) verifyAddress(w http.ResponseWriter, r *http.Request) {
+	addr := mux.Vars(r)["addr"]
+
+	var payload models.VerifyAddressPayload
 	if err := validatePayload(r.Body, &payload); err != nil {
-		log.Error().Err(err).Msg("Error validating payload")
-		respondWithError(w, errIncompleteRequest)
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid request payload.")
+		respondWithError(w, invalidPayload(err))
 		return
 	}
 
-	httpStatus, err := helpers.updateAddressesInList(id, payload, "add")
-	if err != nil {
-		log.Error().Err(err).Msg("Error adding addresses to list")
-		errIncompleteRequest.StatusCode = httpStatus
-		respondWithError(w, errCreateCommunity)
+	v, httpStatus, err := helpers.verifyAddress(addr, payload)
+	if errors.Is(err, models.ErrSignatureReused) {
+		respondWithError(w, errReplayedSignature)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error verifying %s.", addr)
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, v)
+}
+
+func (a *App) attestAddress(w http.ResponseWriter, r *http.Request) {
+	addr := mux.Vars(r)["addr"]
+
+	var payload models.AttestAddressPayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid request payload.")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	v, httpStatus, err := helpers.attestAddress(addr, payload)
+	if errors.Is(err, models.ErrSignatureReused) {
+		respondWithError(w, errReplayedSignature)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error attesting %s.", addr)
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, v)
+}
+
+func (a *App) revokeVerification(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var payload shared.TimestampSignaturePayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid request payload.")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
+
+	v, httpStatus, err := helpers.revokeVerification(vars["addr"], vars["provider"], payload)
+	if errors.Is(err, models.ErrSignatureReused) {
+		respondWithError(w, errReplayedSignature)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error revoking verification of %s.", vars["addr"])
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
 		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, "OK")
+	respondWithJSON(w, http.StatusOK, v)
 }
 
-func (a *App) removeAddressesFromList(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+func (a *App) getApiKeys(w http.ResponseWriter, r *http.Request) {
+	pageParams := getPageParams(*r, 25)
+	communityId, _ := strconv.Atoi(r.FormValue("communityId"))
+
+	keys, pageParams, httpStatus, err := helpers.getApiKeys(bearerToken(r), communityId, pageParams)
 	if err != nil {
-		log.Error().Err(err).Msg("Invalid List ID")
-		respondWithError(w, errIncompleteRequest)
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error listing API keys.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
 		return
 	}
 
-	payload := models.ListUpdatePayload{}
+	respondWithPage(w, r, shared.GetPaginatedResponseWithPayload(keys, pageParams))
+}
+
+func (a *App) createApiKey(w http.ResponseWriter, r *http.Request) {
+	var payload models.CreateApiKeyPayload
 	if err := validatePayload(r.Body, &payload); err != nil {
-		log.Error().Err(err).Msg("Error validating payload")
-		respondWithError(w, errIncompleteRequest)
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid request payload.")
+		respondWithError(w, invalidPayload(err))
 		return
 	}
 
-	httpStatus, err := helpers.updateAddressesInList(id, payload, "remove")
-	if err != nil {
-		log.Error().Err(err).Msg("Error removing addresses from list")
-		errIncompleteRequest.StatusCode = httpStatus
-		respondWithError(w, errIncompleteRequest)
+	key, httpStatus, err := helpers.createApiKey(payload)
+	if errors.Is(err, models.ErrSignatureReused) {
+		respondWithError(w, errReplayedSignature)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error creating API key.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, "OK")
+	respondWithJSON(w, httpStatus, key)
 }
 
-//////////////
-// Accounts //
-//////////////
-
-func (a *App) getAccountAtBlockHeight(w http.ResponseWriter, r *http.Request) {
+// getCommunityUsage shows a community's admins how much it used this month
+// of what the platform pays for, and of its quotas.
+func (a *App) getCommunityUsage(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	addr := vars["addr"]
-	var blockHeight uint64
-	blockHeight, err := strconv.ParseUint(vars["blockHeight"], 10, 64)
+	communityId, err := strconv.Atoi(vars["communityId"])
 	if err != nil {
-		log.Error().Err(err).Msg("Error parsing blockHeight param.")
-		respondWithError(w, errFetchingBalance)
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
 		return
 	}
+	months, err := strconv.Atoi(r.FormValue("months"))
+	if err != nil || months <= 0 {
+		months = defaultUsageMonths
+	} else if months > maxUsageMonths {
+		months = maxUsageMonths
+	}
 
-	flowToken := "FlowToken"
-
-	b := shared.FTBalanceResponse{}
-	acc, err := a.FlowAdapter.GetAccountAtBlockHeight(addr, blockHeight)
-
+	usage, httpStatus, err := helpers.getCommunityUsage(bearerToken(r), communityId, months)
 	if err != nil {
-		log.Error().Err(err).Msgf("Error getting account %s at blockheight %d.", addr, blockHeight)
-		respondWithError(w, errFetchingBalance)
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error getting usage of community %d.", communityId)
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
 		return
 	}
 
-	b.Balance = acc.Balance
-	b.Addr = addr
-	b.BlockHeight = blockHeight
-	b.FungibleTokenID = flowToken
-
-	respondWithJSON(w, http.StatusOK, b)
+	respondWithJSON(w, http.StatusOK, usage)
 }
 
-func (a *App) getAdminList(w http.ResponseWriter, r *http.Request) {
-	respondWithJSON(w, http.StatusOK, a.AdminAllowlist.Addresses)
-}
+func (a *App) getPlans(w http.ResponseWriter, r *http.Request) {
+	plans, err := models.GetPlans(a.DB)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting plans.")
+		respondWithError(w, errIncompleteRequest)
+		return
+	}
 
-func (a *App) getCommunityBlocklist(w http.ResponseWriter, r *http.Request) {
-	respondWithJSON(w, http.StatusOK, a.CommunityBlocklist.Addresses)
+	respondWithJSON(w, http.StatusOK, plans)
 }
 
-func (a *App) createCommunityUser(w http.ResponseWriter, r *http.Request) {
+// getCommunityBilling shows a community's admins its plan and how much of
+// it they use.
+func (a *App) getCommunityBilling(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	communityId, err := strconv.Atoi(vars["communityId"])
 	if err != nil {
-		log.Error().Err(err).Msg("Invalid Community ID")
-		respondWithError(w, errIncompleteRequest)
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
 		return
 	}
 
-	payload := models.CommunityUserPayload{}
-	payload.Community_id = communityId
+	billing, httpStatus, err := helpers.getCommunityBilling(bearerToken(r), communityId)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error getting billing of community %d.", communityId)
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
 
-	if err := validatePayload(r.Body, &payload); err != nil {
-		log.Error().Err(err).Msg("Error validating payload")
+	respondWithJSON(w, http.StatusOK, billing)
+}
+
+// handleBillingWebhook receives the entitlement webhooks of the payment
+// processor, signed over the raw body.
+func (a *App) handleBillingWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBillingWebhookSize))
+	r.Body.Close()
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error reading billing webhook.")
 		respondWithError(w, errIncompleteRequest)
 		return
 	}
 
-	httpStatus, err := helpers.createCommunityUser(payload)
+	httpStatus, err := helpers.handleBillingWebhook(body, r.Header.Get("X-Billing-Signature"))
 	if err != nil {
-		log.Error().Err(err).Msg("Error creating community user")
-		errCreateCommunity.StatusCode = httpStatus
-		respondWithError(w, errCreateCommunity)
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error handling billing webhook.")
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
 		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, "OK")
+	respondWithJSON(w, http.StatusOK, "OK")
 }
 
-func (a *App) getCommunityUsers(w http.ResponseWriter, r *http.Request) {
+func (a *App) getApiKeyUsage(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	communityId, err := strconv.Atoi(vars["communityId"])
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid API Key ID")
+		respondWithError(w, errInvalidId)
+		return
+	}
+	days, err := strconv.Atoi(r.FormValue("days"))
+	if err != nil || days <= 0 {
+		days = defaultApiKeyUsageDays
+	}
 
+	usage, httpStatus, err := helpers.getApiKeyUsage(bearerToken(r), id, days)
 	if err != nil {
-		log.Error().Err(err).Msg("Invalid Community ID")
-		respondWithError(w, errIncompleteRequest)
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error getting usage of API key %d.", id)
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
 		return
 	}
 
-	pageParams := getPageParams(*r, 100)
+	respondWithJSON(w, http.StatusOK, usage)
+}
 
-	users, totalRecords, err := models.GetUsersForCommunity(a.DB, communityId, pageParams)
+func (a *App) revokeApiKey(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		log.Error().Err(err).Msg("Error getting community users")
-		respondWithError(w, errIncompleteRequest)
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid API Key ID")
+		respondWithError(w, errInvalidId)
 		return
 	}
 
-	pageParams.TotalRecords = totalRecords
+	var payload shared.TimestampSignaturePayload
+	if err := validatePayload(r.Body, &payload); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid request payload.")
+		respondWithError(w, invalidPayload(err))
+		return
+	}
 
-	response := shared.GetPaginatedResponseWithPayload(users, pageParams)
-	respondWithJSON(w, http.StatusOK, response)
+	key, httpStatus, err := helpers.revokeApiKey(id, payload)
+	if errors.Is(err, models.ErrSignatureReused) {
+		respondWithError(w, errReplayedSignature)
+		return
+	} else if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error revoking API key %d.", id)
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = httpStatus
+		errResponse.setDetails(err)
+		respondWithError(w, errResponse)
+		return
+	}
 
+	respondWithJSON(w, http.StatusOK, key)
 }
 
-func (a *App) getCommunityUsersByType(w http.ResponseWriter, r *http.Request) {
+// getVoteDump streams every vote of a proposal with its weight, one JSON
+// object per line, so partners can read proposals of any size.
+func (a *App) getVoteDump(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	communityId, err := strconv.Atoi(vars["communityId"])
-
 	if err != nil {
-		log.Error().Err(err).Msg("Invalid Community ID")
-		respondWithError(w, errIncompleteRequest)
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+		respondWithError(w, errInvalidId)
 		return
 	}
-
-	userType := vars["userType"]
-	if !models.EnsureValidRole(userType) {
-		log.Error().Err(err).Msg("Invalid User Type")
-		respondWithError(w, errIncompleteRequest)
+	p, err := helpers.fetchProposal(vars, "proposalId")
+	if err != nil || p.Community_id != communityId {
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Proposal %s not found in community %d.", vars["proposalId"], communityId)
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = http.StatusNotFound
+		errResponse.Details = "Proposal not found."
+		respondWithError(w, errResponse)
 		return
 	}
 
-	pageParams := getPageParams(*r, 100)
-	users, totalRecords, err := models.GetUsersForCommunityByType(
-		a.DB,
-		communityId,
-		userType,
-		pageParams,
-	)
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=proposal-%d-votes.ndjson", p.ID))
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	err = helpers.streamVotesWithWeights(p, func(votes []*models.VoteWithBalance) error {
+		for _, v := range votes {
+			if err := encoder.Encode(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		log.Error().Err(err).Msg("Error getting community users")
-		respondWithError(w, errIncompleteRequest)
-		return
+		// the status is already sent, a partner sees a truncated dump
+		log.Ctx(r.Context()).Error().Err(err).Msgf("Error dumping votes of proposal %d.", p.ID)
 	}
-	pageParams.TotalRecords = totalRecords
-
-	response := shared.GetPaginatedResponseWithPayload(users, pageParams)
-	respondWithJSON(w, http.StatusOK, response)
 }
 
 func (a *App) getCommunityLeaderboard(w http.ResponseWriter, r *http.Request) {
@@ -901,7 +5289,7 @@ func (a *App) getCommunityLeaderboard(w http.ResponseWriter, r *http.Request) {
 	communityId, err := strconv.Atoi(vars["communityId"])
 
 	if err != nil {
-		log.Error().Err(err).Msg("Invalid Community ID")
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
 		respondWithError(w, errIncompleteRequest)
 		return
 	}
@@ -909,18 +5297,53 @@ func (a *App) getCommunityLeaderboard(w http.ResponseWriter, r *http.Request) {
 	addr := r.FormValue("addr")
 	pageParams := getPageParams(*r, 100)
 
-	leaderboard, totalRecords, err := models.GetCommunityLeaderboard(a.DB, communityId, addr, pageParams)
+	window := r.FormValue("window")
+	if window == "" {
+		window = models.LeaderboardWindowAll
+	}
+	metric := r.FormValue("metric")
+	if metric == "" {
+		metric = models.LeaderboardMetricScore
+	}
+	if !models.IsValidLeaderboardWindow(window) || !models.IsValidLeaderboardMetric(metric) {
+		errResponse := errIncompleteRequest
+		errResponse.StatusCode = http.StatusBadRequest
+		errResponse.Details = "Invalid leaderboard window or metric."
+		respondWithError(w, errResponse)
+		return
+	}
+
+	var leaderboard models.LeaderboardPayload
+	var totalRecords int
+	// The all-time score is computed live, other rankings come from the
+	// aggregates refreshed by the leaderboards job.
+	if window == models.LeaderboardWindowAll && metric == models.LeaderboardMetricScore {
+		leaderboard, totalRecords, err = models.GetCommunityLeaderboard(a.DB, communityId, addr, pageParams)
+	} else {
+		leaderboard, totalRecords, err = models.GetCommunityLeaderboardStats(
+			a.DB,
+			communityId,
+			addr,
+			window,
+			metric,
+			pageParams,
+		)
+	}
 	if err != nil {
-		log.Error().Err(err).Msg("Error getting community leaderboard")
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting community leaderboard")
 		respondWithError(w, errIncompleteRequest)
 		return
 	}
 	pageParams.TotalRecords = totalRecords
+	leaderboard.Window = window
+	leaderboard.Metric = metric
+
+	helpers.attachProfilesToLeaderboard(&leaderboard)
 
 	response := shared.GetPaginatedResponseWithPayload(leaderboard.Users, pageParams)
 	response.Data = leaderboard
 
-	respondWithJSON(w, http.StatusOK, response)
+	respondWithPage(w, r, response)
 }
 
 func (a *App) getUserCommunities(w http.ResponseWriter, r *http.Request) {
@@ -931,15 +5354,13 @@ func (a *App) getUserCommunities(w http.ResponseWriter, r *http.Request) {
 
 	communities, totalRecords, err := models.GetCommunitiesForUser(a.DB, addr, pageParams)
 	if err != nil {
-		log.Error().Err(err).Msg("Error getting user communities")
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error getting user communities")
 		respondWithError(w, errIncompleteRequest)
 		return
 	}
 
 	pageParams.TotalRecords = totalRecords
-	response := shared.GetPaginatedResponseWithPayload(communities, pageParams)
-
-	respondWithJSON(w, http.StatusOK, response)
+	respondWithPage(w, r, shared.GetPaginatedResponseWithPayload(communities, pageParams))
 
 }
 
@@ -950,7 +5371,7 @@ func (a *App) removeUserRole(w http.ResponseWriter, r *http.Request) {
 	communityId, err := strconv.Atoi(vars["communityId"])
 
 	if err != nil {
-		log.Error().Err(err).Msg("Invalid Community ID")
+		log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
 		respondWithError(w, errIncompleteRequest)
 		return
 	}
@@ -961,14 +5382,14 @@ func (a *App) removeUserRole(w http.ResponseWriter, r *http.Request) {
 	payload.User_type = userType
 
 	if err := validatePayload(r.Body, &payload); err != nil {
-		log.Error().Err(err).Msg("Error validating payload")
-		respondWithError(w, errIncompleteRequest)
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error validating payload")
+		respondWithError(w, invalidPayload(err))
 		return
 	}
 
 	_, err = helpers.removeUserRole(payload)
 	if err != nil {
-		log.Error().Err(err).Msg("Error removing user role")
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error removing user role")
 		respondWithError(w, errIncompleteRequest)
 		return
 	}
@@ -980,13 +5401,20 @@ func (a *App) removeUserRole(w http.ResponseWriter, r *http.Request) {
 // HELPERS //
 /////////////
 
+// respondWithError includes the id of the request, for users to quote when
+// reporting the error.
 func respondWithError(w http.ResponseWriter, err errorResponse) {
-	respondWithJSON(w, err.StatusCode, map[string]string{
-		"statusCode": strconv.Itoa(err.StatusCode),
-		"errorCode":  err.ErrorCode,
-		"message":    err.Message,
-		"details":    err.Details,
-	})
+	if err.Retry_at != nil {
+		seconds := int(math.Ceil(time.Until(*err.Retry_at).Seconds()))
+		if seconds < 1 {
+			seconds = 1
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	}
+	respondWithJSON(w, err.StatusCode, struct {
+		errorResponse
+		Request_id string `json:"requestId"`
+	}{err, w.Header().Get(middleware.RequestIDHeader)})
 }
 
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
@@ -996,25 +5424,424 @@ func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.Write(response)
 }
 
+// respondWithPage sends a page of results, linking the pages around it in
+// the body and in a Link header.
+func respondWithPage(w http.ResponseWriter, r *http.Request, page *shared.PaginatedResponse) {
+	setPageLinks(w, r, page)
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+// setPageLinks makes the page links absolute under PUBLIC_API_URL when it
+// is set, and relative to the request path otherwise.
+func setPageLinks(w http.ResponseWriter, r *http.Request, page *shared.PaginatedResponse) {
+	u := url.URL{Path: r.URL.Path, RawQuery: r.URL.RawQuery}
+	if base, err := url.Parse(helpers.A.PublicApiURL); err == nil && base.Host != "" {
+		u.Scheme, u.Host = base.Scheme, base.Host
+		u.Path = strings.TrimSuffix(base.Path, "/") + u.Path
+	}
+	page.SetLinks(u)
+	if link := page.LinkHeader(); link != "" {
+		w.Header().Set("Link", link)
+	}
+}
+
+var previewPage = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<meta name="description" content="{{.Description}}">
+<meta property="og:type" content="{{.Type}}">
+<meta property="og:site_name" content="{{.Site_name}}">
+<meta property="og:title" content="{{.Title}}">
+<meta property="og:description" content="{{.Description}}">
+<meta property="og:url" content="{{.Url}}">
+{{if .Image}}<meta property="og:image" content="{{.Image}}">
+<meta name="twitter:image" content="{{.Image}}">
+{{end}}<meta name="twitter:card" content="{{.Twitter_card}}">
+<meta name="twitter:title" content="{{.Title}}">
+<meta name="twitter:description" content="{{.Description}}">
+<meta http-equiv="refresh" content="0; url={{.Url}}">
+</head>
+<body><a href="{{.Url}}">{{.Title}}</a></body>
+</html>
+`))
+
+const widgetPolicy = "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors *"
+
+var widgetPage = template.Must(template.New("widget").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Name}}</title>
+<style>
+body{margin:0;font-family:Helvetica,Arial,sans-serif;color:#111}
+.card{padding:16px;border:1px solid #DDD;border-radius:8px}
+.community{color:#555;font-size:13px}
+h1{font-size:18px;margin:6px 0 12px}
+.choice{margin:8px 0;font-size:14px}
+.track{background:#EEE;height:8px;border-radius:4px}
+.bar{background:#5F4BFF;height:8px;border-radius:4px}
+.meta{color:#555;font-size:13px;margin-top:12px}
+a{color:inherit}
+</style>
+</head>
+<body>
+<div class="card">
+<div class="community">{{.Community.Name}}</div>
+<h1><a href="{{.Url}}" target="_blank" rel="noopener">{{.Name}}</a></h1>
+{{range .Results}}<div class="choice">{{.Choice}} {{printf "%.1f" .Percent}}%
+<div class="track"><div class="bar" style="width:{{printf "%.1f" .Percent}}%"></div></div></div>
+{{end}}<div class="meta">{{.Total_votes}} votes · {{.Voting_status}}{{if .Winning_choice}} · Passed with {{.Winning_choice}}{{end}}</div>
+</div>
+</body>
+</html>
+`))
+
+// respondWithPreview serves a link preview as JSON, or with format=html as
+// a page of meta tags for crawlers that redirects people to the app.
+func respondWithPreview(w http.ResponseWriter, r *http.Request, preview models.LinkPreview) {
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	if r.FormValue("format") != "html" {
+		respondWithJSON(w, http.StatusOK, preview)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if err := previewPage.Execute(w, preview); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Error rendering preview page.")
+	}
+}
+
+// Cache policies of read endpoints, revalidated with their validators once
+// stale.
+const (
+	cacheCommunity     = "public, max-age=60, stale-while-revalidate=300"
+	cacheProposal      = "public, max-age=15, stale-while-revalidate=60"
+	cacheOpenResults   = "public, no-cache"
+	cacheClosedResults = "public, max-age=86400"
+	cacheFeed          = "public, max-age=300"
+	cacheSchema        = "public, max-age=3600"
+	cachePrivate       = "private, no-cache"
+)
+
+// respondWithCacheableJSON serves a read along with validators for
+// conditional requests, or Not Modified when the client's copy is still
+// current. The entity tag hashes the response, led by the version of the
+// resource, if it has one, so it can be sent back in If-Match.
+func respondWithCacheableJSON(
+	w http.ResponseWriter,
+	r *http.Request,
+	payload interface{},
+	version int,
+	lastModified *time.Time,
+	cacheControl string,
+) {
+	response, _ := json.Marshal(payload)
+	sum := sha256.Sum256(response)
+	tag := hex.EncodeToString(sum[:8])
+	if version > 0 {
+		tag = strconv.Itoa(version) + "-" + tag
+	}
+	tag = strconv.Quote(tag)
+
+	w.Header().Set("ETag", tag)
+	w.Header().Set("Cache-Control", cacheControl)
+	if lastModified != nil {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(r, tag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(response)
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when there is
+// none, against the current validators.
+func notModified(r *http.Request, tag string, lastModified *time.Time) bool {
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		for _, candidate := range strings.Split(header, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == tag || candidate == "*" {
+				return true
+			}
+		}
+		return false
+	}
+
+	if lastModified == nil {
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+func latestTime(a, b *time.Time) *time.Time {
+	if a == nil || (b != nil && b.After(*a)) {
+		return b
+	}
+	return a
+}
+
+// etag formats a community or proposal version as an entity tag.
+func etag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// ifMatchVersion reads the version an update was made against from the
+// If-Match header, as returned in the ETag of the resource. Tags of reads
+// carry a hash of the response after the version, which is ignored.
+func ifMatchVersion(r *http.Request) (int, error) {
+	tag := strings.TrimPrefix(strings.TrimSpace(r.Header.Get("If-Match")), "W/")
+	if tag == "" {
+		return 0, errors.New("missing If-Match header")
+	}
+	versionTag, _, _ := strings.Cut(strings.Trim(tag, `"`), "-")
+	version, err := strconv.Atoi(versionTag)
+	if err != nil {
+		return 0, fmt.Errorf("invalid If-Match header %q", tag)
+	}
+	return version, nil
+}
+
+// bearerToken reads the token from an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) string {
+	header := strings.TrimSpace(r.Header.Get("Authorization"))
+	if len(header) < 7 || !strings.EqualFold(header[:7], "bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(header[7:])
+}
+
+// requireFeature wraps a handler so it answers only for communities with
+// the flag on. The community is read from the communityId or id route
+// variable.
+func (a *App) requireFeature(flag string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, ok := vars["communityId"]
+		if !ok {
+			id = vars["id"]
+		}
+		communityId, err := strconv.Atoi(id)
+		if err != nil {
+			log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+			respondWithError(w, errInvalidId)
+			return
+		}
+
+		err = helpers.requireFeature(communityId, flag)
+		if errors.Is(err, models.ErrFeatureDisabled) {
+			respondWithError(w, errFeatureDisabled)
+			return
+		} else if err != nil {
+			log.Ctx(r.Context()).Error().Err(err).Msgf("Error checking %s for community %d.", flag, communityId)
+			respondWithError(w, errIncompleteRequest)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// requireApiKey wraps a handler so it answers only to an API key for the
+// community in the communityId or id route variable, within the key's rate
+// limit.
+func (a *App) requireApiKey(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, ok := vars["communityId"]
+		if !ok {
+			id = vars["id"]
+		}
+		communityId, err := strconv.Atoi(id)
+		if err != nil {
+			log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+			respondWithError(w, errInvalidId)
+			return
+		}
+
+		key, httpStatus, err := helpers.authenticateApiKey(r.Header.Get("X-API-Key"), communityId)
+		if err != nil && httpStatus == http.StatusUnauthorized {
+			log.Ctx(r.Context()).Warn().Err(err).Msgf("Rejected API key for community %d.", communityId)
+			respondWithError(w, errInvalidApiKey)
+			return
+		} else if err != nil {
+			log.Ctx(r.Context()).Error().Err(err).Msgf("Error checking API key for community %d.", communityId)
+			respondWithError(w, errIncompleteRequest)
+			return
+		}
+
+		limit := a.Config.Api_key_rate_limit
+		if key.Rate_limit != nil {
+			limit = *key.Rate_limit
+		}
+		if ok, retryAfter := a.ApiKeyLimits.Allow(key.ID, limit); !ok {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			errResponse := errRateLimited
+			errResponse.Details = fmt.Sprintf(errResponse.Details, limit, seconds)
+			respondWithError(w, errResponse)
+			return
+		}
+
+		if err := key.RecordUse(a.DB); errors.Is(err, pgx.ErrNoRows) {
+			respondWithError(w, errInvalidApiKey)
+			return
+		} else if err != nil {
+			log.Ctx(r.Context()).Error().Err(err).Msgf("Error recording use of API key %d.", key.ID)
+			respondWithError(w, errIncompleteRequest)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// requirePlanLimit refuses requests adding one more of what plans limit to
+// a community at the limit of its plan.
+func (a *App) requirePlanLimit(limit string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions || !a.Config.Billing_enabled {
+			next(w, r)
+			return
+		}
+		communityId, err := strconv.Atoi(mux.Vars(r)["communityId"])
+		if err != nil {
+			log.Ctx(r.Context()).Error().Err(err).Msg("Invalid Community ID")
+			respondWithError(w, errInvalidId)
+			return
+		}
+		c, err := helpers.fetchCommunity(communityId)
+		if err != nil {
+			// the handler answers for communities that don't exist
+			next(w, r)
+			return
+		}
+
+		if err := helpers.checkPlanLimit(c, limit, 1); errors.Is(err, errPlanLimitReached) {
+			log.Ctx(r.Context()).Warn().Err(err).Msgf("Community %d at plan limit of %s.", communityId, limit)
+			errResponse := errPlanLimit
+			errResponse.setDetails(err)
+			respondWithError(w, errResponse)
+			return
+		} else if err != nil {
+			log.Ctx(r.Context()).Error().Err(err).Msgf("Error checking plan of community %d.", communityId)
+			respondWithError(w, errIncompleteRequest)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+var errInvalidTimestamp = errors.New("Invalid timestamp")
+
+// validatePayload decodes the JSON body into data. Payloads with a schema
+// are decoded strictly, refusing fields they don't declare, and validated.
 func validatePayload(body io.ReadCloser, data interface{}) error {
+	defer body.Close()
+
+	strict := strictPayload(data)
 	decoder := json.NewDecoder(body)
-	if err := decoder.Decode(&data); err != nil {
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(data); err != nil {
 		errMsg := "Invalid request payload."
 		log.Error().Err(err).Msg(errMsg)
+		var bodyErr *bodyError
+		if errors.As(err, &bodyErr) {
+			return err
+		}
+		// times must be ISO-8601 with a UTC offset, which is all the JSON
+		// decoder accepts
+		var parseErr *time.ParseError
+		if errors.As(err, &parseErr) {
+			return fmt.Errorf("%w %s, use ISO-8601 with an offset such as 2006-01-02T15:04:05Z.",
+				errInvalidTimestamp, parseErr.Value)
+		}
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return &fieldError{
+				Field:   typeErr.Field,
+				Rule:    "type",
+				Param:   typeErr.Type.String(),
+				Message: fmt.Sprintf("%s must be %s, not %s.", typeErr.Field, jsonTypeName(typeErr.Type), typeErr.Value),
+			}
+		}
+		if field, ok := unknownField(err); ok {
+			return &fieldError{Field: field, Rule: "unknown", Message: fmt.Sprintf("%s is not a field of this payload.", field)}
+		}
 		return errors.New(errMsg)
 	}
+	if decoder.More() {
+		return errors.New("The request body holds more than one JSON value.")
+	}
 
-	defer body.Close()
-
+	if strict {
+		return newValidator().Struct(data)
+	}
 	return nil
 }
 
+// getProposalFilters reads the filters of a proposal listing: statuses and
+// tags as comma-separated lists, a strategy, an author address, and
+// RFC 3339 bounds on the creation, start and end times.
+func getProposalFilters(r http.Request) (models.ProposalFilters, error) {
+	var f models.ProposalFilters
+	if status := r.FormValue("status"); status != "" {
+		f.Statuses = strings.Split(status, ",")
+	}
+	if tags := r.FormValue("tags"); tags != "" {
+		f.Tags = strings.Split(tags, ",")
+	}
+	if strategy := r.FormValue("strategy"); strategy != "" {
+		f.Strategy = &strategy
+	}
+	if author := r.FormValue("author"); author != "" {
+		f.Creator_addr = &author
+	}
+
+	for param, t := range map[string]**time.Time{
+		"createdAfter":  &f.Created_after,
+		"createdBefore": &f.Created_before,
+		"startAfter":    &f.Start_after,
+		"startBefore":   &f.Start_before,
+		"endAfter":      &f.End_after,
+		"endBefore":     &f.End_before,
+	} {
+		value := r.FormValue(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return f, fmt.Errorf("%s must be an RFC 3339 time.", param)
+		}
+		parsed = parsed.UTC()
+		*t = &parsed
+	}
+
+	return f, f.Validate()
+}
+
 func getPageParams(r http.Request, defaultCount int) shared.PageParams {
 	s, _ := strconv.Atoi(r.FormValue("start"))
 	c, _ := strconv.Atoi(r.FormValue("count"))
 	o := r.FormValue("order")
 
-	if o == "" {
+	// the order is written into queries, so only these two get through
+	if o != "asc" {
 		o = "desc"
 	}
 
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHealthCheckTimeout = 5 * time.Second
+
+	healthOK       = "ok"
+	healthDegraded = "degraded"
+	healthDown     = "down"
+	healthSkipped  = "skipped"
+)
+
+// dependencyHealth is the status of one dependency in the health report.
+type dependencyHealth struct {
+	Status  string      `json:"status"`
+	Latency string      `json:"latency,omitempty"`
+	Error   string      `json:"error,omitempty"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// healthReport is down when a dependency requests need is down, and
+// degraded when only the pinning service or background jobs are.
+type healthReport struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]dependencyHealth `json:"dependencies"`
+}
+
+type healthCheck struct {
+	name     string
+	critical bool
+	check    func(ctx context.Context) dependencyHealth
+}
+
+func (a *App) healthChecks() []healthCheck {
+	return []healthCheck{
+		{name: "database", critical: true, check: a.checkDatabase},
+		{name: "flow", critical: true, check: a.checkFlow},
+		{name: "ipfs", check: a.checkIpfs},
+		{name: "jobs", check: a.checkJobs},
+	}
+}
+
+// checkHealth runs the health checks side by side, each bounded by
+// HEALTH_CHECK_TIMEOUT.
+func (a *App) checkHealth(ctx context.Context) healthReport {
+	ctx, cancel := context.WithTimeout(ctx, envDuration("HEALTH_CHECK_TIMEOUT", defaultHealthCheckTimeout))
+	defer cancel()
+
+	checks := a.healthChecks()
+	results := make([]dependencyHealth, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c healthCheck) {
+			defer wg.Done()
+			start := time.Now()
+			results[i] = c.check(ctx)
+			if results[i].Status != healthSkipped {
+				results[i].Latency = time.Since(start).Round(time.Millisecond).String()
+			}
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := healthReport{Status: healthOK, Dependencies: map[string]dependencyHealth{}}
+	for i, c := range checks {
+		report.Dependencies[c.name] = results[i]
+		if results[i].Status != healthOK && results[i].Status != healthSkipped {
+			if c.critical {
+				report.Status = healthDown
+			} else if report.Status == healthOK {
+				report.Status = healthDegraded
+			}
+		}
+	}
+	return report
+}
+
+func (a *App) checkDatabase(ctx context.Context) dependencyHealth {
+	if err := a.DB.Pool.Ping(ctx); err != nil {
+		return dependencyHealth{Status: healthDown, Error: err.Error()}
+	}
+	return dependencyHealth{Status: healthOK}
+}
+
+func (a *App) checkFlow(ctx context.Context) dependencyHealth {
+	header, err := a.FlowAdapter.Client.GetLatestBlockHeader(ctx, true)
+	if err != nil {
+		return dependencyHealth{Status: healthDown, Error: err.Error()}
+	}
+	return dependencyHealth{
+		Status:  healthOK,
+		Details: map[string]interface{}{"blockHeight": header.Height, "blockTime": header.Timestamp.UTC()},
+	}
+}
+
+// checkIpfs is skipped where IPFS calls are overridden, as in tests.
+func (a *App) checkIpfs(ctx context.Context) dependencyHealth {
+	if flag.Lookup("ipfs-override").Value.(flag.Getter).Get().(bool) {
+		return dependencyHealth{Status: healthSkipped}
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- a.IpfsClient.TestAuthentication() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			return dependencyHealth{Status: healthDown, Error: err.Error()}
+		}
+		return dependencyHealth{Status: healthOK}
+	case <-ctx.Done():
+		return dependencyHealth{Status: healthDown, Error: ctx.Err().Error()}
+	}
+}
+
+// checkJobs reports the jobs that haven't completed a run in three of their
+// intervals, which are stuck or keep crashing. Jobs only run on instances
+// that started them.
+func (a *App) checkJobs(ctx context.Context) dependencyHealth {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.jobRuns == nil {
+		return dependencyHealth{Status: healthSkipped}
+	}
+
+	lastRuns := map[string]*time.Time{}
+	var stale []string
+	for _, j := range a.jobs() {
+		if last, ok := a.jobRuns[j.name]; ok {
+			last := last.UTC()
+			lastRuns[j.name] = &last
+			if time.Since(last) <= 3*j.interval {
+				continue
+			}
+		} else {
+			lastRuns[j.name] = nil
+		}
+		stale = append(stale, j.name)
+	}
+
+	status := dependencyHealth{Status: healthOK, Details: lastRuns}
+	if len(stale) > 0 {
+		status.Status = healthDegraded
+		status.Error = "stale jobs: " + strings.Join(stale, ", ")
+	}
+	return status
+}
+
+// getHealth reports the status of each dependency, with 503 when the
+// instance can't serve requests, for load balancers to take it out of
+// rotation.
+func (a *App) getHealth(w http.ResponseWriter, r *http.Request) {
+	report := a.checkHealth(r.Context())
+	status := http.StatusOK
+	if report.Status == healthDown {
+		status = http.StatusServiceUnavailable
+	}
+	respondWithJSON(w, status, report)
+}
@@ -1,29 +1,81 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"html"
+	"io"
+	"math"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/DapperCollectives/CAST/backend/main/models"
 	"github.com/DapperCollectives/CAST/backend/main/shared"
-	"github.com/go-playground/validator/v10"
 	"github.com/jackc/pgx/v4"
+	"github.com/onflow/flow-go-sdk"
 	"github.com/rs/zerolog/log"
 	"github.com/thoas/go-funk"
 )
 
-var allowedFileTypes = []string{"image/jpg", "image/jpeg", "image/png", "image/gif"}
+var allowedFileTypes = []string{"image/jpg", "image/jpeg", "image/png", "image/gif", "application/pdf"}
 
+// Link previews
 const (
-	maxFileSize = 5 * 1024 * 1024 // 5MB
+	previewSiteName          = "CAST"
+	previewDescriptionLength = 200
+)
+
+var errUploadQuarantined = errors.New("File was quarantined by malware scanning.")
+
+// proposalPathPattern matches the path of a proposal page in the app.
+var proposalPathPattern = regexp.MustCompile(`^/community/[0-9]+/proposal/([0-9]+)/?$`)
+
+// Embedded widgets
+const (
+	embedWidth    = 560
+	embedHeight   = 360
+	embedCacheAge = 60
+)
+
+const (
+	communityDeleteGracePeriod = 7 * 24 * time.Hour
+	defaultInviteExpiry        = 7 * 24 * time.Hour
+	defaultInviteMaxUses       = 1
+	defaultApiKeyUsageDays     = 30
+	defaultUsageMonths         = 6
+	maxUsageMonths             = 24
+	maxBillingWebhookSize      = 64 * 1024
+	defaultAnalyticsMonths     = 12
+	defaultAnalyticsProposals  = 20
+	maxDryRunAddresses         = 500
+	exportTokenExpiry          = 24 * time.Hour
+	sessionTokenExpiry         = time.Hour
+	usedSignatureRetention     = time.Hour // well past every timestamp window
+	maxTimestampSkew           = 10 * time.Second
+	maxProposalAttachments     = 10
+	maxAttachmentsSize         = 25 * 1024 * 1024 // 25MB per proposal
+	maxCommunityTreasuries     = 10
+	pendingActionExpiry        = 7 * 24 * time.Hour
+	thresholdBalanceMaxAge     = 5 * time.Minute
+	signedUrlExpiry            = 15 * time.Minute
+	tallyChunkSize             = 5000
+	maxReconcileAttempts       = 3
+	maxPinAttempts             = 8
+	pinBatchSize               = 50
+	chainEventsRange           = 250 // blocks the access API returns events of at once
 )
 
 type Helpers struct {
@@ -53,6 +105,130 @@ func (h *Helpers) useStrategyTally(
 	return results, nil
 }
 
+// useStrategyStreamingTally tallies a proposal's votes one chunk at a time,
+// so memory stays bounded however many votes were cast. Once voting has
+// ended no more votes can arrive: the running totals are saved after each
+// chunk and an interrupted tally resumes from the last vote counted.
+func (h *Helpers) useStrategyStreamingTally(p models.Proposal) (models.ProposalResults, error) {
+	s := h.initStrategy(*p.Strategy)
+	if s == nil {
+		return models.ProposalResults{}, errors.New("Strategy not found.")
+	}
+
+	results := models.NewProposalResults(p.ID, p.Choices)
+	ended := !p.End_time.After(time.Now())
+	progress := models.TallyProgress{Proposal_id: p.ID}
+
+	if ended {
+		saved, err := models.GetTallyProgress(h.A.DB, p.ID)
+		if err != nil {
+			return models.ProposalResults{}, err
+		}
+		if saved != nil {
+			progress.Last_vote_id = saved.Last_vote_id
+			progress.Votes_counted = saved.Votes_counted
+			for choice, total := range saved.Results {
+				results.Results[choice] = total
+			}
+			for choice, total := range saved.Results_float {
+				results.Results_float[choice] = total
+			}
+		}
+	}
+
+	err := models.StreamVotesForProposal(
+		h.A.DB,
+		p.ID,
+		*p.Strategy,
+		progress.Last_vote_id,
+		tallyChunkSize,
+		func(votes []*models.VoteWithBalance) error {
+			if _, err := s.TallyVotes(votes, results, &p); err != nil {
+				return err
+			}
+			progress.Votes_counted += len(votes)
+			if !ended {
+				return nil
+			}
+
+			progress.Last_vote_id = votes[len(votes)-1].ID
+			progress.Results = results.Results
+			progress.Results_float = results.Results_float
+			return progress.Save(h.A.DB)
+		},
+	)
+	if err != nil {
+		return models.ProposalResults{}, err
+	}
+
+	results.Votes_counted = progress.Votes_counted
+	return *results, nil
+}
+
+// reconcileProposalResults recounts a proposal's votes and stores the
+// results, retrying when votes arrive during the recount.
+func (h *Helpers) reconcileProposalResults(p models.Proposal) (models.ProposalResults, error) {
+	var results models.ProposalResults
+	for attempt := 0; attempt < maxReconcileAttempts; attempt++ {
+		var err error
+		results, err = h.useStrategyStreamingTally(p)
+		if err != nil {
+			return models.ProposalResults{}, err
+		}
+
+		saved, err := results.SaveTally(h.A.DB)
+		if err != nil || saved {
+			return results, err
+		}
+	}
+
+	log.Warn().Msgf("Results of proposal %d changed during every recount.", p.ID)
+	return results, nil
+}
+
+func (h *Helpers) reconcileResults() error {
+	proposals, err := models.GetProposalsPendingReconcile(h.A.DB)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range proposals {
+		if _, err := h.reconcileProposalResults(*p); err != nil {
+			log.Error().Err(err).Msgf("Error reconciling results for proposal %d.", p.ID)
+		}
+	}
+
+	return nil
+}
+
+// useStrategyTallyAs counts every vote of a proposal with another strategy
+// than its own, to report the results a co-host weighs them to. Balances
+// are those snapshotted for the proposal.
+func (h *Helpers) useStrategyTallyAs(p models.Proposal, strategy string) (models.ProposalResults, error) {
+	s := h.initStrategy(strategy)
+	if s == nil {
+		return models.ProposalResults{}, errors.New("Strategy not found.")
+	}
+
+	p.Strategy, p.Strategy_config = &strategy, nil
+	results := models.NewProposalResults(p.ID, p.Choices)
+	err := models.StreamVotesForProposal(
+		h.A.DB,
+		p.ID,
+		strategy,
+		0,
+		tallyChunkSize,
+		func(votes []*models.VoteWithBalance) error {
+			_, err := s.TallyVotes(votes, results, &p)
+			return err
+		},
+	)
+	if err != nil {
+		return models.ProposalResults{}, err
+	}
+	return *results, nil
+}
+
 func (h *Helpers) useStrategyGetVotes(
 	p models.Proposal,
 	v []*models.VoteWithBalance,
@@ -67,6 +243,12 @@ func (h *Helpers) useStrategyGetVotes(
 	if err != nil {
 		return nil, err
 	}
+	for _, vote := range votesWithWeights {
+		if vote.Weight != nil {
+			weight := vote.ModifyWeight(*vote.Weight)
+			vote.Weight = &weight
+		}
+	}
 
 	return votesWithWeights, nil
 }
@@ -109,13 +291,16 @@ func (h *Helpers) useStrategyFetchBalance(
 		return models.VoteWithBalance{}, errGetCommunity
 	}
 
-	strategy, err := c.GetStrategy(*p.Strategy)
+	strategy, err := p.BoundStrategy(&c)
 	if err != nil {
 		return models.VoteWithBalance{}, errStrategyNotFound
 	}
 
 	balance, err := s.FetchBalance(emptyBalance, &p)
-	if err != nil {
+	if errors.Is(err, models.ErrNotOnAllowlist) {
+		log.Error().Err(err).Msgf("Address %s is not allowed to vote.", v.Addr)
+		return models.VoteWithBalance{}, errNotOnAllowlist
+	} else if err != nil {
 		log.Error().Err(err).Msgf("User does not have the required balance %v.", v.Addr)
 		errResponse := errInsufficientBalance
 		errResponse.Details = fmt.Sprintf(errResponse.Details, *strategy.Threshold, *strategy.Contract.Name)
@@ -155,11 +340,11 @@ func (h *Helpers) fetchProposal(vars map[string]string, query string) (models.Pr
 	return p, nil
 }
 
-func (h *Helpers) uploadFile(r *http.Request) (interface{}, error) {
+func (h *Helpers) uploadFile(r *http.Request) (interface{}, int, error) {
 	file, handler, err := r.FormFile("file")
 	if err != nil {
 		log.Error().Err(err).Msg("FormFile Retrieval Error.")
-		return nil, err
+		return nil, http.StatusBadRequest, err
 	}
 	defer file.Close()
 
@@ -168,22 +353,212 @@ func (h *Helpers) uploadFile(r *http.Request) (interface{}, error) {
 	if !funk.Contains(allowedFileTypes, mime) {
 		msg := fmt.Sprintf("Uploaded file type of '%s' is not allowed.", mime)
 		log.Error().Msg(msg)
-		return nil, errors.New(msg)
+		return nil, http.StatusBadRequest, errors.New(msg)
+	}
+
+	// private uploads are made by authors for their community
+	var payload models.UploadPayload
+	if raw := r.FormValue("payload"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &payload); err != nil {
+			return nil, http.StatusBadRequest, errors.New("Invalid upload payload.")
+		}
+	}
+	if payload.Private {
+		if payload.Community_id == nil {
+			return nil, http.StatusBadRequest, errors.New("Private uploads require a communityId.")
+		}
+		if h.A.Storage.Driver() == shared.StorageIpfs {
+			return nil, http.StatusBadRequest, errors.New("Private uploads are not supported by IPFS storage.")
+		}
+		if err := h.validateUserWithRole(
+			payload.Signing_addr,
+			payload.Timestamp,
+			payload.Composite_signatures,
+			*payload.Community_id,
+			"author",
+		); err != nil {
+			return nil, http.StatusForbidden, err
+		}
+	} else {
+		payload.Community_id = nil
 	}
 
-	pin, err := h.A.IpfsClient.PinFile(file, handler.Filename)
+	content, err := io.ReadAll(file)
 	if err != nil {
-		log.Error().Err(err).Msg("Error pinning file to IPFS.")
-		return nil, err
+		log.Error().Err(err).Msg("Error reading uploaded file.")
+		return nil, http.StatusBadRequest, err
+	}
+	if payload.Community_id != nil {
+		if errResponse := h.checkUsageQuota(*payload.Community_id, models.UsageStorage, int64(len(content))); errResponse != nilErr {
+			return nil, errResponse.StatusCode, errors.New(errResponse.Details)
+		}
+	}
+
+	upload, httpStatus, err := h.storeUpload(content, mime, payload.Private, payload.Community_id)
+	if err != nil {
+		log.Error().Err(err).Msg("Error storing uploaded file.")
+		return nil, httpStatus, err
+	}
+
+	return upload, http.StatusOK, nil
+}
+
+// storeUpload scans content and writes it to blob storage under its
+// content key, returning the existing upload when the same bytes were
+// stored before with the same visibility. Files the scanner flags are
+// recorded as quarantined without being stored, and the upload fails.
+func (h *Helpers) storeUpload(
+	content []byte,
+	contentType string,
+	private bool,
+	communityId *int,
+) (*models.Upload, int, error) {
+	key := shared.ContentKey(content, contentType)
+	hash := key[:64]
+	if private {
+		key = shared.PrivateKeyPrefix + key
+	}
+
+	quarantined, err := models.IsQuarantined(h.A.DB, hash)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	if quarantined {
+		return nil, http.StatusUnprocessableEntity, errUploadQuarantined
+	}
+
+	existing, err := models.GetUploadByHash(h.A.DB, h.A.Storage.Driver(), hash, private, communityId)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	if existing != nil {
+		return existing, http.StatusOK, nil
+	}
+
+	upload := models.Upload{
+		Content_hash: hash,
+		Driver:       h.A.Storage.Driver(),
+		Content_type: contentType,
+		Size:         int64(len(content)),
+		Status:       models.UploadUnscanned,
+		Private:      private,
+		Community_id: communityId,
+	}
+
+	if h.A.Scanner != nil {
+		// fail closed, an unscanned file must not reach public storage
+		result, err := h.A.Scanner.Scan(content)
+		if err != nil {
+			log.Error().Err(err).Msg("Error scanning uploaded file.")
+			return nil, http.StatusServiceUnavailable, errors.New("File scanning is unavailable, try again later.")
+		}
+		now := time.Now().UTC()
+		upload.Scanned_at = &now
+		upload.Status = models.UploadClean
+
+		if !result.Clean {
+			log.Warn().Str("signature", result.Signature).Str("hash", hash).Msg("Quarantined uploaded file.")
+			upload.Status = models.UploadQuarantined
+			upload.Scan_result = &result.Signature
+			upload.Key = key
+			if err := upload.Create(h.A.DB); err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			return nil, http.StatusUnprocessableEntity, errUploadQuarantined
+		}
+	}
+
+	obj, err := h.A.Storage.Put(key, content, contentType)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	upload.Key = obj.Key
+	upload.Url = obj.URL
+	// clients build gateway links from the cid of files stored on IPFS
+	if upload.Driver == shared.StorageIpfs {
+		upload.Cid = &obj.Key
+	}
+
+	if err := upload.Create(h.A.DB); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	if communityId != nil {
+		h.recordUsage(*communityId, models.UsageStorage, upload.Size)
+	}
+
+	return &upload, http.StatusOK, nil
+}
+
+// readUpload returns a stored file, requiring a token from a signed URL
+// for private uploads.
+func (h *Helpers) readUpload(key, token string) (*models.Upload, []byte, int, error) {
+	upload := models.Upload{Driver: h.A.Storage.Driver(), Key: key}
+	if err := upload.GetUploadByKey(h.A.DB); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, http.StatusNotFound, errors.New("file not found")
+		}
+		return nil, nil, http.StatusInternalServerError, err
+	}
+
+	if upload.Status == models.UploadQuarantined {
+		return nil, nil, http.StatusForbidden, errUploadQuarantined
+	}
+	if upload.Private {
+		var claims shared.TokenClaims
+		if err := h.A.TokenSigner.Verify(token, &claims); err != nil ||
+			claims.Audience != shared.FileTokenAudience ||
+			claims.Subject != upload.Key {
+			return nil, nil, http.StatusForbidden, errors.New("Invalid or expired file token.")
+		}
+	}
+
+	content, err := h.A.Storage.Get(upload.Key)
+	if err != nil {
+		if errors.Is(err, shared.ErrObjectNotFound) {
+			return nil, nil, http.StatusNotFound, errors.New("file not found")
+		}
+		return nil, nil, http.StatusInternalServerError, err
+	}
+
+	return &upload, content, http.StatusOK, nil
+}
+
+// signUploadURL issues a short-lived URL for a private upload to a member
+// of its community. Public uploads are returned at their stable URL.
+func (h *Helpers) signUploadURL(id int, payload shared.TimestampSignaturePayload) (models.SignedURL, int, error) {
+	upload := models.Upload{ID: id}
+	if err := upload.GetUploadById(h.A.DB); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.SignedURL{}, http.StatusNotFound, errors.New("Upload not found.")
+		}
+		return models.SignedURL{}, http.StatusInternalServerError, err
+	}
+	if upload.Status == models.UploadQuarantined {
+		return models.SignedURL{}, http.StatusForbidden, errUploadQuarantined
+	}
+	if !upload.Private {
+		return models.SignedURL{Url: upload.Url}, http.StatusOK, nil
+	}
+
+	if err := h.validateUserWithRole(
+		payload.Signing_addr,
+		payload.Timestamp,
+		payload.Composite_signatures,
+		*upload.Community_id,
+		"member",
+	); err != nil {
+		return models.SignedURL{}, http.StatusForbidden, err
 	}
 
-	resp := struct {
-		Cid string `json:"cid"`
-	}{
-		Cid: pin.IpfsHash,
+	url, err := h.A.Storage.SignedURL(upload.Key, signedUrlExpiry)
+	if err != nil {
+		return models.SignedURL{}, http.StatusInternalServerError, err
 	}
 
-	return resp, nil
+	return models.SignedURL{
+		Url:        url,
+		Expires_at: time.Now().Add(signedUrlExpiry).UTC(),
+	}, http.StatusOK, nil
 }
 
 func (h *Helpers) getPaginatedVotes(
@@ -196,6 +571,11 @@ func (h *Helpers) getPaginatedVotes(
 ) {
 
 	pageParams := getPageParams(*r, 25)
+	sort, err := models.VoteSortColumns.Parse(r.FormValue("sort"))
+	if err != nil {
+		return nil, shared.PageParams{}, err
+	}
+	pageParams.Sort = sort
 
 	votes, totalRecords, err := models.GetVotesForProposal(
 		h.A.DB,
@@ -223,10 +603,38 @@ func (h *Helpers) processVote(addr string, p models.Proposal) (*models.VoteWithB
 		return nil, err
 	}
 
+	weight = vote.ModifyWeight(weight)
 	vote.Weight = &weight
 	return vote, err
 }
 
+func (h *Helpers) createVoteReceipt(addr string, p models.Proposal) (*shared.SignedReceipt, error) {
+	vote, err := h.processVote(addr, p)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt := models.VoteReceipt{
+		Proposal_id:          vote.Proposal_id,
+		Addr:                 vote.Addr,
+		Choice:               vote.Choice,
+		Allocation:           vote.Allocation,
+		Message:              vote.Message,
+		Composite_signatures: vote.Composite_signatures,
+		Voucher:              vote.Voucher,
+		Cid:                  vote.Cid,
+		Block_height:         p.Block_height,
+		Weight:               vote.Weight,
+		Strategy:             p.Strategy,
+		Created_at:           vote.Created_at,
+		Issued_at:            time.Now().UTC(),
+	}
+
+	return h.A.ReceiptSigner.Sign(receipt)
+}
+
+var errVoteNotFound = errors.New("Vote not found.")
+
 func (h *Helpers) fetchVote(addr string, id int) (*models.VoteWithBalance, error) {
 	voteWithBalance := &models.VoteWithBalance{
 		Vote: models.Vote{
@@ -237,8 +645,7 @@ func (h *Helpers) fetchVote(addr string, id int) (*models.VoteWithBalance, error
 	if err := voteWithBalance.GetVote(h.A.DB); err != nil {
 		switch err.Error() {
 		case pgx.ErrNoRows.Error():
-			msg := fmt.Sprintf("Vote not found.")
-			return nil, errors.New(msg)
+			return nil, errVoteNotFound
 		default:
 			return nil, err
 		}
@@ -291,6 +698,7 @@ func (h *Helpers) processVotes(
 			return nil, pageParams, err
 		}
 
+		weight = vote.ModifyWeight(weight)
 		vote.Weight = &weight
 		votesWithBalances = append(votesWithBalances, vote)
 	}
@@ -304,7 +712,9 @@ func (h *Helpers) createVote(r *http.Request, p models.Proposal) (*models.VoteWi
 	var v models.Vote
 	if err := validatePayload(r.Body, &v); err != nil {
 		log.Error().Err(err).Msg("Invalid request payload.")
-		return nil, errIncompleteRequest
+		errResponse := errIncompleteRequest
+		errResponse.setPayloadDetails(err)
+		return nil, errResponse
 	}
 
 	v.Proposal_id = p.ID
@@ -318,16 +728,56 @@ func (h *Helpers) createVote(r *http.Request, p models.Proposal) (*models.VoteWi
 		return nil, errResponse
 	}
 
+	// proposals in the moderation queue or rejected by a moderator are not votable
+	if p.IsAwaitingReview() || (p.Status != nil && *p.Status == models.ProposalRejected) {
+		return nil, errInactiveProposal
+	}
+
 	// check that proposal is live
 	if os.Getenv("APP_ENV") != "DEV" {
 		if !p.IsLive() {
 			return nil, errInactiveProposal
 		}
+		// times of block windows are estimates, the chain has the last word
+		if p.HasBlockWindow() {
+			height, err := h.A.FlowAdapter.GetCurrentBlockHeight()
+			if err != nil {
+				log.Error().Err(err).Msg("Error getting current block height.")
+				return nil, errIncompleteRequest
+			}
+			if !p.IsLiveAtBlock(uint64(height)) {
+				return nil, errInactiveProposal
+			}
+		}
+	}
+
+	community, err := h.fetchCommunity(p.Community_id)
+	if err != nil {
+		return nil, errGetCommunity
+	}
+	if community.Is_archived {
+		return nil, errArchivedCommunity
+	}
+	if errResponse := h.ensureNotBanned(community.ID, v.Addr); errResponse != nilErr {
+		return nil, errResponse
+	}
+	if errResponse := h.ensureVerified(community, p, v.Addr); errResponse != nilErr {
+		return nil, errResponse
+	}
+	if errResponse := h.ensureAccountAge(community, p, v.Addr); errResponse != nilErr {
+		return nil, errResponse
+	}
+	if errResponse := h.checkUsageQuota(community.ID, models.UsageVotes, 1); errResponse != nilErr {
+		return nil, errResponse
 	}
 
 	if errResponse := h.validateVote(p, v); errResponse != nilErr {
 		return nil, errResponse
 	}
+	if v.Allocation != nil {
+		v.Choice = v.LeadingChoice(p)
+	}
+	v.Signature_block_height = h.signatureBlockHeight()
 
 	v.Proposal_id = p.ID
 
@@ -344,6 +794,7 @@ func (h *Helpers) createVote(r *http.Request, p models.Proposal) (*models.VoteWi
 	if errResponse := h.insertVote(voteWithBalance, p); errResponse != nilErr {
 		return nil, errResponse
 	}
+	h.recordUsage(community.ID, models.UsageVotes, 1)
 
 	return &voteWithBalance, nilErr
 }
@@ -360,11 +811,22 @@ func (h *Helpers) insertVote(v models.VoteWithBalance, p models.Proposal) errorR
 		return errGetCommunity
 	}
 
-	strategy, err := c.GetStrategy(*p.Strategy)
+	strategy, err := p.BoundStrategy(&c)
 	if err != nil {
 		return errStrategyNotFound
 	}
 
+	// the decay of the voter's power is kept with the vote, so later votes
+	// don't change the weight it was cast with
+	if !c.PowerDecay.IsZero() {
+		multiplier, err := c.PowerDecay.WeightMultiplier(h.A.DB, p, v.Addr)
+		if err != nil {
+			log.Error().Err(err).Msgf("Error getting the participation of %s.", v.Addr)
+			return errIncompleteRequest
+		}
+		v.Weight_multiplier = &multiplier
+	}
+
 	fmt.Println(weight, "weight")
 	if err = p.ValidateBalance(weight); err != nil {
 		log.Error().Err(err).Msg("Account balance is too low to vote on this proposal.")
@@ -386,29 +848,193 @@ func (h *Helpers) insertVote(v models.VoteWithBalance, p models.Proposal) errorR
 		}
 	*/
 
+	// the vote's weight is tallied up front, since strategies read through
+	// the pool rather than the transaction
+	delta, err := h.useStrategyTally(p, []*models.VoteWithBalance{&v})
+	if err != nil {
+		log.Error().Err(err).Msgf("Error tallying vote for address %s.", v.Addr)
+		return errIncompleteRequest
+	}
+
 	fmt.Println("create vote")
 
-	if err := v.CreateVote(h.A.DB); err != nil {
+	// the signed message is spent with the vote, so a vote turned down
+	// above can be cast again with the same signature
+	if err := h.A.DB.WithTx(func(tx *shared.Database) error {
+		if v.Source != models.VoteOnchain {
+			if err := h.consumeSignature(tx, v.Addr, v.Message, v.Voucher); err != nil {
+				return err
+			}
+		}
+		if err := v.CreateVote(tx); err != nil {
+			return err
+		}
+		return models.AddVoteToProposalResults(tx, delta)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		log.Error().Err(err).Msgf("Vote message of %s was replayed.", v.Addr)
+		return errReplayedSignature
+	} else if err != nil {
 		msg := fmt.Sprintf("Error creating vote for address %s.", v.Addr)
 		log.Error().Err(err).Msg(msg)
 		return errCreateVote
 	}
 
+	h.recordVoteMilestone(p)
+
 	return nilErr
 }
 
+// ingestChainVotes records the votes cast on chain with the CASTVoting
+// contract at CAST_VOTING_CONTRACT_ADDR since the last run, starting from
+// CAST_VOTING_START_HEIGHT, or the latest block, on the first one.
+func (h *Helpers) ingestChainVotes() error {
+	contractAddr := os.Getenv("CAST_VOTING_CONTRACT_ADDR")
+	if contractAddr == "" {
+		return nil
+	}
+	eventType := shared.VoteCastEventType(contractAddr)
+
+	latest, err := h.A.FlowAdapter.GetCurrentBlockHeight()
+	if err != nil {
+		return err
+	}
+	cursor, err := models.GetChainEventCursor(h.A.DB, eventType)
+	if err != nil {
+		return err
+	}
+
+	var start uint64
+	if cursor != nil {
+		start = *cursor + 1
+	} else if height := os.Getenv("CAST_VOTING_START_HEIGHT"); height != "" {
+		if start, err = strconv.ParseUint(height, 10, 64); err != nil {
+			return fmt.Errorf("invalid CAST_VOTING_START_HEIGHT: %w", err)
+		}
+	} else {
+		start = uint64(latest)
+	}
+
+	for start <= uint64(latest) {
+		end := start + chainEventsRange - 1
+		if end > uint64(latest) {
+			end = uint64(latest)
+		}
+
+		votes, err := h.A.FlowAdapter.GetVoteCastEvents(contractAddr, start, end)
+		if err != nil {
+			return err
+		}
+		for _, vote := range votes {
+			if err := h.ingestChainVote(vote); err != nil {
+				log.Warn().Err(err).Msgf("Ignoring vote of %s cast on chain in transaction %s.", vote.Voter, vote.Tx_id)
+			}
+		}
+
+		if err := models.SetChainEventCursor(h.A.DB, eventType, end); err != nil {
+			return err
+		}
+		start = end + 1
+	}
+	return nil
+}
+
+// ingestChainVote records a vote cast on chain once it passes the checks a
+// signed vote does, the proposal being open when its block was sealed.
+// The transaction takes the place of the signature.
+func (h *Helpers) ingestChainVote(cast shared.VoteCast) error {
+	p := models.Proposal{ID: int(cast.Proposal_id)}
+	if err := p.GetProposalById(h.A.DB); err != nil {
+		return fmt.Errorf("proposal %d not found", cast.Proposal_id)
+	}
+	if err := h.requireFeature(p.Community_id, models.FlagOnchainVoting); err != nil {
+		return err
+	}
+
+	v := models.Vote{
+		Proposal_id: p.ID,
+		Addr:        cast.Voter,
+		Choice:      cast.Choice,
+		Message:     cast.Tx_id,
+		Source:      models.VoteOnchain,
+		Tx_id:       &cast.Tx_id,
+		Event_index: &cast.Event_index,
+	}
+
+	existingVote := models.Vote{Proposal_id: v.Proposal_id, Addr: v.Addr}
+	if err := existingVote.GetVote(h.A.DB); err == nil {
+		return fmt.Errorf("%s already voted on proposal %d", v.Addr, p.ID)
+	}
+	if p.IsAwaitingReview() || (p.Status != nil && *p.Status == models.ProposalRejected) || !p.IsLiveAt(cast.Block_time) ||
+		!p.IsLiveAtBlock(cast.Block_height) {
+		return errors.New(errInactiveProposal.Message)
+	}
+
+	community, err := h.fetchCommunity(p.Community_id)
+	if err != nil {
+		return err
+	}
+	if community.Is_archived {
+		return errors.New(errArchivedCommunity.Message)
+	}
+	if errResponse := h.ensureNotBanned(community.ID, v.Addr); errResponse != nilErr {
+		return errors.New(errResponse.Message)
+	}
+	if errResponse := h.ensureVerified(community, p, v.Addr); errResponse != nilErr {
+		return errors.New(errResponse.Message)
+	}
+	if errResponse := h.ensureAccountAge(community, p, v.Addr); errResponse != nilErr {
+		return errors.New(errResponse.Message)
+	}
+	if err := h.validateBlocklist(v.Addr, p); err != nil {
+		return err
+	}
+	if err := v.ValidateChoice(p); err != nil {
+		return err
+	}
+
+	s := h.initStrategy(*p.Strategy)
+	if s == nil {
+		return errors.New(errStrategyNotFound.Message)
+	}
+	voteWithBalance, errResponse := h.useStrategyFetchBalance(v, p, s)
+	if errResponse != nilErr {
+		return errors.New(errResponse.Message)
+	}
+	if errResponse := h.insertVote(voteWithBalance, p); errResponse != nilErr {
+		return errors.New(errResponse.Message)
+	}
+	return nil
+}
+
 func (h *Helpers) validateVote(p models.Proposal, v models.Vote) errorResponse {
 
 	// validate the user is not on community's blocklist
-	if err := h.validateBlocklist(v.Addr, p.Community_id); err != nil {
+	if err := h.validateBlocklist(v.Addr, p); err != nil {
 		log.Error().Err(err).Msgf(fmt.Sprintf("Address %v is on blocklist for community id %v.\n", v.Addr, p.Community_id))
 		return errForbidden
 	}
 
-	// validate choice exists on proposal
-	if err := v.ValidateChoice(p); err != nil {
+	// validate split votes share the vote across choices of the proposal
+	if v.Allocation != nil {
+		if err := v.ValidateAllocation(p); err != nil {
+			log.Error().Err(err)
+			errResponse := errIncompleteRequest
+			errResponse.setDetails(&fieldError{
+				Field:   "allocation",
+				Rule:    "split",
+				Message: err.Error(),
+			})
+			return errResponse
+		}
+	} else if err := v.ValidateChoice(p); err != nil {
 		log.Error().Err(err)
-		return errIncompleteRequest
+		errResponse := errIncompleteRequest
+		errResponse.setDetails(&fieldError{
+			Field:   "choice",
+			Rule:    "oneof",
+			Message: fmt.Sprintf("%q is not a choice of proposal %d.", v.Choice, p.ID),
+		})
+		return errResponse
 	}
 
 	// If voucher is present
@@ -436,7 +1062,7 @@ func (h *Helpers) validateVote(p models.Proposal, v models.Vote) errorResponse {
 
 		// validate proper message format
 		//<proposalId>:<choice>:<timestamp>
-		if err := models.ValidateVoteMessage(string(messageBytes), p); err != nil {
+		if err := v.ValidateMessage(string(messageBytes), p); err != nil {
 			log.Error().Err(err)
 			return errIncompleteRequest
 		}
@@ -453,7 +1079,7 @@ func (h *Helpers) validateVote(p models.Proposal, v models.Vote) errorResponse {
 	} else {
 		// validate proper message format
 		// hex decode before validating
-		if err := models.ValidateVoteMessage(v.Message, p); err != nil {
+		if err := v.ValidateMessage(v.Message, p); err != nil {
 			log.Error().Err(err)
 			return errIncompleteRequest
 		}
@@ -466,11 +1092,56 @@ func (h *Helpers) validateVote(p models.Proposal, v models.Vote) errorResponse {
 	return nilErr
 }
 
-func (h *Helpers) fetchCommunity(id int) (models.Community, error) {
-	community := models.Community{ID: id}
-
-	if err := community.GetCommunity(h.A.DB); err != nil {
-		log.Error().Err(err)
+// signatureBlockHeight is the height a signature was just checked at, or
+// nil when signatures aren't checked.
+func (h *Helpers) signatureBlockHeight() *uint64 {
+	if !h.A.Config.Features["validateSigs"] {
+		return nil
+	}
+	height, err := h.A.FlowAdapter.GetCurrentBlockHeight()
+	if err != nil {
+		log.Warn().Err(err).Msg("Error getting block height of signature check.")
+		return nil
+	}
+	blockHeight := uint64(height)
+	return &blockHeight
+}
+
+// verifyVoteSignature checks the vote signature against the keys the voter
+// had when it was cast, falling back to the proposal snapshot for votes
+// cast before the height was recorded.
+func (h *Helpers) verifyVoteSignature(v models.Vote, p models.Proposal) (*models.SignatureVerification, error) {
+	height := v.Signature_block_height
+	if height == nil {
+		height = p.Block_height
+	}
+	if height == nil || v.Composite_signatures == nil {
+		return nil, nil
+	}
+
+	message := hex.EncodeToString([]byte(v.Message))
+	sigs := *v.Composite_signatures
+	messageType := "USER"
+	if v.Voucher != nil {
+		message = shared.EncodeMessageFromVoucher(v.Voucher)
+		sigs = *shared.GetUserCompositeSignatureFromVoucher(v.Voucher)
+		messageType = "TRANSACTION"
+	}
+
+	verifier := shared.AccountKeyVerifier{Accounts: h.A.FlowAdapter, BlockHeight: *height}
+	err := verifier.Verify(v.Addr, message, sigs, messageType)
+	if err != nil && !errors.Is(err, shared.ErrInvalidSignature) {
+		return nil, err
+	}
+
+	return &models.SignatureVerification{Block_height: *height, Valid: err == nil}, nil
+}
+
+func (h *Helpers) fetchCommunity(id int) (models.Community, error) {
+	community := models.Community{ID: id}
+
+	if err := community.GetCommunity(h.A.DB); err != nil {
+		log.Error().Err(err)
 		return models.Community{}, err
 	}
 
@@ -478,6 +1149,7 @@ func (h *Helpers) fetchCommunity(id int) (models.Community, error) {
 }
 
 func (h *Helpers) searchCommunities(
+	tenant *models.Tenant,
 	searchText string,
 	filters string,
 	pageParams shared.PageParams,
@@ -493,6 +1165,7 @@ func (h *Helpers) searchCommunities(
 
 		results, totalRecords, err := models.GetDefaultCommunities(
 			h.A.DB,
+			tenant.ID,
 			pageParams,
 			filtersSlice,
 			isSearch,
@@ -502,7 +1175,7 @@ func (h *Helpers) searchCommunities(
 			return nil, 0, nil, err
 		}
 
-		categoryCount, err := models.GetCategoryCount(h.A.DB, searchText)
+		categoryCount, err := models.GetCategoryCount(h.A.DB, tenant.ID, searchText)
 		if err != nil {
 			return []*models.Community{}, 0, nil, err
 		}
@@ -511,6 +1184,7 @@ func (h *Helpers) searchCommunities(
 	} else {
 		results, totalRecords, err := models.SearchForCommunity(
 			h.A.DB,
+			tenant.ID,
 			searchText,
 			filtersSlice,
 			pageParams,
@@ -519,7 +1193,7 @@ func (h *Helpers) searchCommunities(
 			return []*models.Community{}, 0, nil, err
 		}
 
-		categoryCount, err := models.GetCategoryCount(h.A.DB, searchText)
+		categoryCount, err := models.GetCategoryCount(h.A.DB, tenant.ID, searchText)
 		if err != nil {
 			return []*models.Community{}, 0, nil, err
 		}
@@ -549,11 +1223,34 @@ func (h *Helpers) createProposal(p models.Proposal) (models.Proposal, errorRespo
 		return models.Proposal{}, errIncompleteRequest
 	}
 
+	if community.Is_archived {
+		return models.Proposal{}, errArchivedCommunity
+	}
+	if errResponse := h.ensureNotBanned(community.ID, p.Creator_addr); errResponse != nilErr {
+		return models.Proposal{}, errResponse
+	}
+
 	strategy, err := models.MatchStrategyByProposal(*community.Strategies, *p.Strategy)
 	if err != nil {
 		log.Error().Err(err).Msg("Community does not have this strategy available.")
 		return models.Proposal{}, errIncompleteRequest
 	}
+	if *p.Strategy == "allowlist-one-vote" {
+		if errResponse := h.ensureAllowlist(community.ID, strategy); errResponse != nilErr {
+			return models.Proposal{}, errResponse
+		}
+	}
+	if p.Allow_early_close {
+		if _, ok := h.initStrategy(*p.Strategy).(WeightBoundedStrategy); !ok {
+			errResponse := errIncompleteRequest
+			errResponse.setDetails(fmt.Errorf("Proposals voted with %s cannot close early.", *p.Strategy))
+			return models.Proposal{}, errResponse
+		}
+	}
+	// the proposal is tallied with the strategy as it is now, whatever
+	// later changes are made to the community
+	p.Strategy_config = &strategy
+	p.Strategy_version = &community.Strategies_version
 
 	// Set Min Balance/Max Weight to community defaults if not provided
 	if p.Min_balance == nil {
@@ -569,17 +1266,122 @@ func (h *Helpers) createProposal(p models.Proposal) (models.Proposal, errorRespo
 		return models.Proposal{}, errIncompleteRequest
 	}
 
-	p.Block_height = &header.Height
+	// authors may pick the snapshot, otherwise it is the block the
+	// proposal is published at
+	if p.Block_height != nil {
+		if err := h.validateSnapshotHeight(*p.Block_height, header); err != nil {
+			log.Error().Err(err).Msg("Invalid proposal snapshot block.")
+			errResponse := errIncompleteRequest
+			errResponse.setDetails(err)
+			return models.Proposal{}, errResponse
+		}
+	}
+	snapshotHeight := header.Height
+	if p.Block_height != nil {
+		snapshotHeight = *p.Block_height
+	}
+
+	if p.HasBlockWindow() {
+		if err := h.estimateProposalWindow(&p, header); err != nil {
+			log.Error().Err(err).Msg("Invalid proposal block window.")
+			errResponse := errIncompleteRequest
+			errResponse.setDetails(err)
+			return models.Proposal{}, errResponse
+		}
+	}
+
+	if errResponse := h.enforceCommunityRestrictions(community, p); errResponse != nilErr {
+		return models.Proposal{}, errResponse
+	}
+
+	if err := community.ProposalWindow.Check(p.Start_time, p.End_time, time.Now()); err != nil {
+		log.Error().Err(err).Msg("Proposal is outside the community voting window.")
+		errResponse := errIncompleteRequest
+		errResponse.setDetails(err)
+		return models.Proposal{}, errResponse
+	}
+
+	if err := models.EnsureTagsInVocabulary(h.A.DB, community.ID, p.Tags); err != nil {
+		log.Error().Err(err).Msg("Invalid proposal tags.")
+		return models.Proposal{}, errIncompleteRequest
+	}
 
-	if err := h.enforceCommunityRestrictions(community, p, strategy); err != nil {
+	if err := models.EnsureListVersionsForCommunity(h.A.DB, community.ID, p.List_versions); err != nil {
+		log.Error().Err(err).Msg("Invalid proposal list versions.")
 		return models.Proposal{}, errIncompleteRequest
 	}
 
-	validate := validator.New()
+	if err := h.validateProposalAttachments(community.ID, p.Attachments); err != nil {
+		log.Error().Err(err).Msg("Invalid proposal attachments.")
+		errResponse := errIncompleteRequest
+		errResponse.setDetails(err)
+		return models.Proposal{}, errResponse
+	}
+
+	if err := models.ValidateChoiceTypes(p.Choices); err != nil {
+		errResponse := errIncompleteRequest
+		errResponse.setDetails(&fieldError{Field: "choices", Rule: "choiceType", Message: err.Error()})
+		return models.Proposal{}, errResponse
+	}
+
+	if p.Execution_payload != nil {
+		if err := p.Execution_payload.Validate(p.Choices); err != nil {
+			log.Error().Err(err).Msg("Invalid proposal execution payload.")
+			errResponse := errIncompleteRequest
+			errResponse.setDetails(err)
+			return models.Proposal{}, errResponse
+		}
+	}
+
+	p.Treasury_snapshot = nil
+	if p.Snapshot_treasury {
+		p.Treasury_snapshot, err = h.snapshotTreasuries(community.ID, snapshotHeight)
+		if err != nil {
+			log.Error().Err(err).Msg("Error taking treasury snapshot.")
+			errResponse := errIncompleteRequest
+			errResponse.setDetails(err)
+			return models.Proposal{}, errResponse
+		}
+	}
+
+	if p.Body != nil {
+		body := h.A.Sanitizer.Sanitize(*p.Body)
+		p.Body = &body
+	}
+
+	validate := newValidator()
 	vErr := validate.Struct(p)
 	if vErr != nil {
 		log.Error().Err(vErr)
-		return models.Proposal{}, errIncompleteRequest
+		errResponse := errIncompleteRequest
+		errResponse.setDetails(vErr)
+		return models.Proposal{}, errResponse
+	}
+
+	body := ""
+	if p.Body != nil {
+		body = *p.Body
+	}
+
+	p.Duplicates = nil
+	if !community.DuplicateCheck.IsZero() {
+		duplicates, err := models.FindDuplicateProposals(
+			h.A.DB, community.ID, p.Name, body, community.DuplicateCheck.Threshold(), 0)
+		if err != nil {
+			log.Error().Err(err).Msg("Error looking for duplicate proposals.")
+			return models.Proposal{}, errIncompleteRequest
+		}
+		if len(duplicates) > 0 && community.DuplicateCheck.Blocks() {
+			ids := make([]string, len(duplicates))
+			for i, d := range duplicates {
+				ids[i] = strconv.Itoa(d.ID)
+			}
+			errResponse := errDuplicateProposal
+			errResponse.Details = fmt.Sprintf(errResponse.Details, strings.Join(ids, ", "))
+			errResponse.Duplicates = &duplicates
+			return models.Proposal{}, errResponse
+		}
+		p.Duplicates = duplicates
 	}
 
 	if os.Getenv("APP_ENV") == "PRODUCTION" {
@@ -588,596 +1390,6454 @@ func (h *Helpers) createProposal(p models.Proposal) (models.Proposal, errorRespo
 		}
 	}
 
-	if err := p.CreateProposal(h.A.DB); err != nil {
-		return models.Proposal{}, errIncompleteRequest
+	if errResponse := h.verifyProposalDeposit(community, &p); errResponse != nilErr {
+		return models.Proposal{}, errResponse
 	}
 
-	return p, nilErr
-}
-
-func (h *Helpers) validateStrategyName(name string) error {
-	if name == "" {
-		return errors.New("Strategy name is required.")
+	decision, err := h.moderateContent(community, shared.ModerationContent{
+		Kind:         models.ModerationProposal,
+		Community_id: community.ID,
+		Author:       p.Creator_addr,
+		Title:        p.Name,
+		Body:         body,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Error scoring proposal for moderation.")
+		return models.Proposal{}, errIncompleteRequest
+	}
+	if decision != nil && decision.Action == models.ModerationHold {
+		status := models.ProposalPendingReview
+		p.Status = &status
 	}
 
-	for k, _ := range strategyMap {
-		if name == k {
-			return nil
-		} else {
-			continue
+	// pre-moderated communities queue new proposals for review,
+	// unless the author can moderate proposals themselves
+	if community.Require_proposal_review {
+		if err := models.EnsurePermissionForCommunity(
+			h.A.DB,
+			p.Creator_addr,
+			community.ID,
+			models.PermModerateProposals,
+		); err != nil {
+			status := models.ProposalPendingReview
+			p.Status = &status
 		}
 	}
+	if !p.IsAwaitingReview() {
+		p.Block_height = &snapshotHeight
+	}
 
-	return errors.New("Strategy not found.")
-}
-
-func (h *Helpers) enforceCommunityRestrictions(
-	c models.Community,
-	p models.Proposal,
-	s models.Strategy,
-) error {
-
-	if *c.Only_authors_to_submit {
-		if err := models.EnsureRoleForCommunity(h.A.DB, p.Creator_addr, c.ID, "author"); err != nil {
-			errMsg := fmt.Sprintf("Account %s is not an author for community %d.", p.Creator_addr, p.Community_id)
-			log.Error().Err(err).Msg(errMsg)
-			return errors.New(errMsg)
+	p.Cid = nil
+	if err := h.A.DB.WithTx(func(tx *shared.Database) error {
+		if err := h.consumeSignature(tx, p.Creator_addr, p.Timestamp, p.Voucher); err != nil {
+			return err
 		}
-	} else {
-		fmt.Println("Community does not require authors to submit proposals")
-
-		threshold, err := strconv.ParseFloat(*c.Proposal_threshold, 64)
-		if err != nil {
-			log.Error().Err(err).Msg("Invalid proposal threshold")
-			return errors.New("Invalid proposal threshold")
+		if err := p.CreateProposal(tx); err != nil {
+			return err
 		}
-
-		contract := shared.Contract{
-			Name:        c.Contract_name,
-			Addr:        c.Contract_addr,
-			Public_path: c.Public_path,
-			Threshold:   &threshold,
+		if err := models.AddTreasurySnapshot(tx, p.ID, p.Treasury_snapshot); err != nil {
+			return err
 		}
-		hasBalance, err := h.processTokenThreshold(p.Creator_addr, contract, *c.Contract_type)
-		if err != nil {
-			errMsg := "Error processing Token Threshold."
-			log.Error().Err(err).Msg(errMsg)
-			return errors.New(errMsg)
+		if p.Execution_payload != nil {
+			p.Execution_payload.Proposal_id = p.ID
+			if err := p.Execution_payload.CreateExecutionPayload(tx); err != nil {
+				return err
+			}
 		}
-
-		if !hasBalance {
-			errMsg := "Insufficient token balance to create proposal."
-			log.Error().Err(err).Msg(errMsg)
-			return errors.New(errMsg)
+		if decision != nil {
+			decision.Content_id = p.ID
+			if err := decision.CreateModerationDecision(tx); err != nil {
+				return err
+			}
 		}
+		return models.AddProposalAttachments(tx, p.ID, p.Attachments)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.Proposal{}, errReplayedSignature
+	} else if err != nil {
+		log.Error().Err(err).Msg("Error creating proposal.")
+		return models.Proposal{}, errIncompleteRequest
 	}
+	h.queuePin(models.PinProposal, p.ID)
 
-	return nil
+	if !p.IsAwaitingReview() {
+		h.recordProposalEvent(p, models.EventProposalCreated)
+	}
+
+	return p, nilErr
 }
 
-func (h *Helpers) createCommunity(payload models.CreateCommunityRequestPayload) (models.Community, error) {
-	c := payload.Community
+// verifyProposalDeposit checks the author's transaction moved the deposit
+// the community asks for to its escrow, and locks it on the proposal. The
+// deposit fields of the proposal are only ever set from the chain.
+func (h *Helpers) verifyProposalDeposit(c models.Community, p *models.Proposal) errorResponse {
+	p.Deposit_amount, p.Deposit_status, p.Deposit_settlement_tx_id = nil, nil, nil
+	if c.ProposalDeposit.IsZero() {
+		p.Deposit_tx_id = nil
+		return nilErr
+	}
 
-	if c.Voucher != nil {
-		log.Info().Msgf("validate user via voucher %v \n", c.Voucher)
-		if err := h.validateUserViaVoucher(c.Creator_addr, c.Voucher); err != nil {
-			return models.Community{}, err
-		}
-	} else {
-		if err := h.validateUser(c.Creator_addr, c.Timestamp, c.Composite_signatures); err != nil {
-			return models.Community{}, err
-		}
+	if c.Deposit_escrow_addr == nil || c.Contract_name == nil || c.Contract_addr == nil {
+		log.Error().Msgf("Community %d asks for deposits without an escrow address or token.", c.ID)
+		errResponse := errIncompleteRequest
+		errResponse.Details = "The community asks for a deposit but has no escrow address or token set."
+		return errResponse
+	}
+	amount := *c.Deposit_amount
+	if p.Deposit_tx_id == nil || *p.Deposit_tx_id == "" {
+		errResponse := errDepositRequired
+		errResponse.Details = fmt.Sprintf(errResponse.Details, amount, *c.Contract_name, *c.Deposit_escrow_addr)
+		return errResponse
 	}
 
-	cid, err := h.pinJSONToIpfs(c)
+	invalid := func(err error) errorResponse {
+		log.Error().Err(err).Msgf("Invalid deposit for a proposal of community %d.", c.ID)
+		errResponse := errInvalidDeposit
+		errResponse.setDetails(err)
+		return errResponse
+	}
+	txId, err := shared.NormalizeTxId(*p.Deposit_tx_id)
 	if err != nil {
-		log.Error().Err(err).Msg("Error pinning JSON to IPFS.")
-		return models.Community{}, err
+		return invalid(err)
+	}
+	p.Deposit_tx_id = &txId
+	used, err := models.DepositTxUsed(h.A.DB, txId)
+	if err != nil {
+		log.Error().Err(err).Msg("Error checking deposit transaction.")
+		return errIncompleteRequest
+	}
+	if used {
+		return invalid(fmt.Errorf("Transaction %s already paid or settled the deposit of a proposal.", txId))
+	}
+	transfers, err := h.A.FlowAdapter.GetFTTransfers(txId, *c.Contract_name, *c.Contract_addr)
+	if err != nil {
+		return invalid(err)
+	}
+	if !transfers.Moved(p.Creator_addr, *c.Deposit_escrow_addr, amount) {
+		return invalid(fmt.Errorf(
+			"Transaction %s does not move %v %s from %s to %s.",
+			txId, amount, *c.Contract_name, p.Creator_addr, *c.Deposit_escrow_addr,
+		))
 	}
-	c.Cid = cid
 
-	validate := validator.New()
-	vErr := validate.Struct(c)
-	if vErr != nil {
-		log.Error().Err(vErr).Msg("Invalid community.")
-		return models.Community{}, err
+	status := models.DepositLocked
+	p.Deposit_amount = &amount
+	p.Deposit_status = &status
+	return nilErr
+}
+
+// markDepositDue settles the fate of a locked deposit once its proposal
+// ends: refunded when it reached quorum, slashed otherwise.
+func (h *Helpers) markDepositDue(p *models.Proposal, quorumMet bool) {
+	if p.Deposit_status == nil || *p.Deposit_status != models.DepositLocked {
+		return
 	}
+	status := models.DepositSlashDue
+	if quorumMet {
+		status = models.DepositRefundDue
+	}
+	if err := p.MarkDepositDue(h.A.DB, status); err != nil {
+		log.Error().Err(err).Msgf("Error marking the deposit of proposal %d %s.", p.ID, status)
+	}
+}
 
-	if err := c.CreateCommunity(h.A.DB); err != nil {
-		log.Error().Err(err).Msg("Database error creating community.")
-		return models.Community{}, err
+// settleProposalDeposit records the escrow's transaction that refunded a
+// deposit to the author, or slashed it to the community's slash address.
+func (h *Helpers) settleProposalDeposit(
+	p models.Proposal,
+	payload models.DepositSettlementPayload,
+) (models.Proposal, int, error) {
+	if vErr := newValidator().Struct(payload); vErr != nil {
+		return models.Proposal{}, http.StatusBadRequest, vErr
+	}
+	if err := h.validateCommunityAdmin(p.Community_id, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+		return models.Proposal{}, http.StatusForbidden, err
 	}
 
-	if err := h.processCommunityRoles(&c, &payload); err != nil {
-		log.Error().Err(err).Msg("Error processing community roles.")
-		return models.Community{}, err
+	if p.Deposit_status == nil ||
+		(*p.Deposit_status != models.DepositRefundDue && *p.Deposit_status != models.DepositSlashDue) {
+		return models.Proposal{}, http.StatusBadRequest, fmt.Errorf("The deposit of proposal %d is not due.", p.ID)
+	}
+	c, err := h.fetchCommunity(p.Community_id)
+	if err != nil {
+		return models.Proposal{}, http.StatusInternalServerError, err
+	}
+	if c.Deposit_escrow_addr == nil || c.Contract_name == nil || c.Contract_addr == nil {
+		return models.Proposal{}, http.StatusBadRequest, errors.New("The community has no escrow address or token set.")
 	}
 
-	return c, nil
-}
+	txId, err := shared.NormalizeTxId(payload.Tx_id)
+	if err != nil {
+		return models.Proposal{}, http.StatusBadRequest, err
+	}
+	payload.Tx_id = txId
+	used, err := models.DepositTxUsed(h.A.DB, payload.Tx_id)
+	if err != nil {
+		return models.Proposal{}, http.StatusInternalServerError, err
+	}
+	if used {
+		return models.Proposal{}, http.StatusBadRequest,
+			fmt.Errorf("Transaction %s already paid or settled the deposit of a proposal.", payload.Tx_id)
+	}
+	transfers, err := h.A.FlowAdapter.GetFTTransfers(payload.Tx_id, *c.Contract_name, *c.Contract_addr)
+	if err != nil {
+		return models.Proposal{}, http.StatusBadRequest, err
+	}
 
-func (h *Helpers) processCommunityRoles(
-	c *models.Community,
-	p *models.CreateCommunityRequestPayload,
-) error {
-	if err := models.GrantRolesToCommunityCreator(h.A.DB, c.Creator_addr, c.ID); err != nil {
-		errMsg := "Database error adding community creator roles."
-		log.Error().Err(err).Msg(errMsg)
-		return errors.New(errMsg)
+	escrow, amount := *c.Deposit_escrow_addr, *p.Deposit_amount
+	settled := false
+	switch {
+	case *p.Deposit_status == models.DepositRefundDue:
+		settled = transfers.Moved(escrow, p.Creator_addr, amount)
+	case c.Deposit_slash_addr != nil:
+		settled = transfers.Moved(escrow, *c.Deposit_slash_addr, amount)
+	default:
+		// without a slash address, slashed deposits go where the community sends them
+		settled = transfers.Sent(escrow, amount)
+	}
+	if !settled {
+		return models.Proposal{}, http.StatusBadRequest, fmt.Errorf(
+			"Transaction %s does not move the %v %s deposit of proposal %d out of %s as due.",
+			payload.Tx_id, amount, *c.Contract_name, p.ID, escrow,
+		)
 	}
 
-	if p.Additional_admins != nil {
-		for _, addr := range *p.Additional_admins {
-			if err := models.GrantAdminRolesToAddress(h.A.DB, c.ID, addr); err != nil {
-				log.Error().Err(err)
-				return err
-			}
-		}
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return p.SettleDeposit(tx, payload.Tx_id)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.Proposal{}, http.StatusForbidden, err
+	} else if err != nil {
+		return models.Proposal{}, http.StatusConflict, err
 	}
+	return p, http.StatusOK, nil
+}
 
-	if p.Additional_authors != nil {
-		for _, addr := range *p.Additional_authors {
-			if err := models.GrantAuthorRolesToAddress(h.A.DB, c.ID, addr); err != nil {
-				log.Error().Err(err)
-				return err
-			}
-		}
+// validateSnapshotHeight checks a snapshot block chosen by the author is
+// sealed and can still be read from the chain.
+func (h *Helpers) validateSnapshotHeight(height uint64, head *flow.BlockHeader) error {
+	if height > head.Height {
+		return fmt.Errorf("Snapshot block %d is not sealed yet, the chain is at block %d.", height, head.Height)
+	}
+	if _, err := h.A.FlowAdapter.GetBlockHeaderByHeight(height); err != nil {
+		return fmt.Errorf("Snapshot block %d is out of the range of blocks the access node serves.", height)
 	}
 
-	return nil
+	archived, err := h.A.FlowAdapter.CheckSnapshotHeight(height, head.Height)
+	if errors.Is(err, shared.ErrBeyondRetention) {
+		return fmt.Errorf(
+			"Snapshot block %d is older than the access node keeps state for, the oldest is %d.",
+			height,
+			h.A.FlowAdapter.Retention.OldestExecutableHeight(head.Height),
+		)
+	}
+	if archived {
+		log.Warn().Msgf("Snapshot block %d is only served by the archive node.", height)
+	}
+	return err
 }
 
-func (h *Helpers) updateCommunity(id int, payload models.UpdateCommunityRequestPayload) (models.Community, error) {
-	c, err := h.fetchCommunity(id)
+// estimateProposalWindow checks the block window of a new proposal, and
+// sets its times to when its blocks are estimated to be sealed.
+func (h *Helpers) estimateProposalWindow(p *models.Proposal, head *flow.BlockHeader) error {
+	if p.End_block != nil && *p.End_block <= head.Height {
+		return fmt.Errorf("End block %d is already sealed, the chain is at block %d.", *p.End_block, head.Height)
+	}
+	if p.Start_block != nil && p.End_block != nil && *p.Start_block >= *p.End_block {
+		return errors.New("Start block must come before the end block.")
+	}
+
+	start, end, err := h.blockWindowTimes(*p, head, h.A.FlowAdapter.BlockInterval(head))
 	if err != nil {
-		return models.Community{}, err
+		return err
 	}
+	p.Start_time, p.End_time = start, end
+	return nil
+}
 
-	// validate is community creator
-	// TODO: update to validating address is admin
-	if err := c.CanUpdateCommunity(h.A.DB, payload.Signing_addr); err != nil {
-		log.Error().Err(err)
-		return models.Community{}, err
+// blockWindowTimes estimates when a proposal with a block window opens and
+// closes, keeping the times of whichever end is set as a timestamp. Blocks
+// not sealed yet are never estimated before now, so a stale estimate
+// doesn't open or close a proposal early.
+func (h *Helpers) blockWindowTimes(
+	p models.Proposal,
+	head *flow.BlockHeader,
+	interval time.Duration,
+) (time.Time, time.Time, error) {
+	now := time.Now().UTC()
+	estimate := func(height uint64) (time.Time, error) {
+		t, err := h.A.FlowAdapter.EstimateBlockTime(height, head, interval)
+		if err == nil && height > head.Height && !t.After(now) {
+			t = now.Add(interval)
+		}
+		return t, err
 	}
 
-	if payload.Voucher != nil {
-		if err := h.validateUserViaVoucher(payload.Signing_addr, payload.Voucher); err != nil {
-			log.Error().Err(err)
-			return models.Community{}, err
+	start, end := p.Start_time, p.End_time
+	var err error
+	if p.Start_block != nil {
+		if start, err = estimate(*p.Start_block); err != nil {
+			return start, end, err
 		}
-	} else {
-		if err := h.validateUser(payload.Signing_addr, payload.Timestamp, payload.Composite_signatures); err != nil {
-			log.Error().Err(err)
-			return models.Community{}, err
+	}
+	if p.End_block != nil {
+		if end, err = estimate(*p.End_block); err != nil {
+			return start, end, err
 		}
 	}
+	return start, end, nil
+}
 
-	if err := c.UpdateCommunity(h.A.DB, &payload); err != nil {
-		log.Error().Err(err)
-		return models.Community{}, err
+// trackBlockWindows follows the chain head to keep the times of proposals
+// with a block window close to when their blocks are sealed, and exact
+// once they are.
+func (h *Helpers) trackBlockWindows() error {
+	proposals, err := models.GetProposalsWithBlockWindows(h.A.DB)
+	if err != nil || len(proposals) == 0 {
+		return err
 	}
 
-	c, err = h.fetchCommunity(id)
+	head, err := h.A.FlowAdapter.GetLatestSealedHeader()
 	if err != nil {
-		return models.Community{}, err
+		return err
 	}
+	interval := h.A.FlowAdapter.BlockInterval(head)
 
-	return c, nil
-}
-
-func (h *Helpers) removeUserRole(payload models.CommunityUserPayload) (int, error) {
-	if payload.Voucher != nil {
-		if err := h.validateUserViaVoucher(payload.Signing_addr, payload.Voucher); err != nil {
-			log.Error().Err(err)
-			return http.StatusForbidden, err
+	for _, p := range proposals {
+		start, end, err := h.blockWindowTimes(*p, head, interval)
+		if err != nil {
+			log.Error().Err(err).Msgf("Error estimating block window of proposal %d.", p.ID)
+			continue
 		}
-	} else {
-		if err := h.validateUser(payload.Signing_addr, payload.Timestamp, payload.Composite_signatures); err != nil {
-			log.Error().Err(err)
-			return http.StatusForbidden, err
+		if start.Equal(p.Start_time) && end.Equal(p.End_time) {
+			continue
 		}
-	}
 
-	if payload.User_type == "member" {
-		if payload.Addr == payload.Signing_addr {
-			// If a member is removing themselves, remove all their other roles as well
-			userRoles, err := models.GetAllRolesForUserInCommunity(h.A.DB, payload.Addr, payload.Community_id)
-			if err != nil {
-				log.Error().Err(err)
-				return http.StatusInternalServerError, err
-			}
-			for _, userRole := range userRoles {
-				if err := userRole.Remove(h.A.DB); err != nil {
-					log.Error().Err(err)
-					return http.StatusInternalServerError, err
-				}
-			}
-		} else {
-			// validate someone else is not removing a "member" role
-			CANNOT_REMOVE_MEMBER_ERR := errors.New("Cannot remove another member from a community.")
-			log.Error().Err(CANNOT_REMOVE_MEMBER_ERR)
-			return http.StatusForbidden, CANNOT_REMOVE_MEMBER_ERR
+		p.Start_time, p.End_time = start, end
+		if err := p.UpdateProposalWindow(h.A.DB); err != nil {
+			log.Error().Err(err).Msgf("Error updating block window of proposal %d.", p.ID)
 		}
 	}
+	return nil
+}
+
+// validateProposalAttachments checks attachments are within limits and
+// refer to files the proposal may use: private files must belong to the
+// proposal's community, and quarantined files can't be attached.
+func (h *Helpers) validateProposalAttachments(communityId int, attachments []*models.ProposalAttachment) error {
+	if len(attachments) > maxProposalAttachments {
+		return fmt.Errorf("Proposals can have at most %d attachments.", maxProposalAttachments)
+	}
 
-	u := payload.CommunityUser
+	var totalSize int64
+	seen := make(map[int]bool)
+	for _, a := range attachments {
+		if seen[a.Upload_id] {
+			return fmt.Errorf("Upload %d is attached more than once.", a.Upload_id)
+		}
+		seen[a.Upload_id] = true
 
-	if payload.User_type == "admin" {
-		// validate signer is admin
-		var adminUser = models.CommunityUser{Addr: payload.Signing_addr, Community_id: payload.Community_id, User_type: "admin"}
-		if err := adminUser.GetCommunityUser(h.A.DB); err != nil {
-			USER_MUST_BE_ADMIN_ERR := errors.New("User must be community admin.")
-			log.Error().Err(err).Msg("Database error.")
-			log.Error().Err(USER_MUST_BE_ADMIN_ERR)
-			return http.StatusForbidden, USER_MUST_BE_ADMIN_ERR
+		upload := models.Upload{ID: a.Upload_id}
+		if err := upload.GetUploadById(h.A.DB); err != nil {
+			return fmt.Errorf("Upload %d not found.", a.Upload_id)
 		}
-		// If the admin role is being removed, remove author role as well
-		author := models.CommunityUser{Addr: u.Addr, Community_id: u.Community_id, User_type: "author"}
-		if err := author.Remove(h.A.DB); err != nil {
-			return http.StatusInternalServerError, err
+		if upload.Status == models.UploadQuarantined {
+			return fmt.Errorf("Upload %d was quarantined by malware scanning.", a.Upload_id)
 		}
-		// remove admin role
-		if err := u.Remove(h.A.DB); err != nil {
-			return http.StatusInternalServerError, err
+		if upload.Private && (upload.Community_id == nil || *upload.Community_id != communityId) {
+			return fmt.Errorf("Upload %d belongs to another community.", a.Upload_id)
 		}
-		// Otherwise, just remove the specified user role
-	} else if err := u.Remove(h.A.DB); err != nil {
-		return http.StatusInternalServerError, err
+		totalSize += upload.Size
 	}
 
-	return http.StatusOK, nil
+	if totalSize > maxAttachmentsSize {
+		return fmt.Errorf("Attachments cannot be larger than %d bytes in total.", maxAttachmentsSize)
+	}
+	return nil
 }
 
-func (h *Helpers) createCommunityUser(payload models.CommunityUserPayload) (int, error) {
-	// validate community_user payload fields
-	validate := validator.New()
+// localizedTranslation finds the translation of a record that best fits
+// the Accept-Language header, or nil when none does.
+func (h *Helpers) localizedTranslation(
+	target models.TranslationTarget,
+	id int,
+	acceptLanguage string,
+) (*models.Translation, error) {
+	if acceptLanguage == "" {
+		return nil, nil
+	}
+	locales, err := models.GetTranslationLocales(h.A.DB, target, id)
+	if err != nil {
+		return nil, err
+	}
+	locale, ok := shared.MatchLocale(acceptLanguage, locales)
+	if !ok {
+		return nil, nil
+	}
+	return models.GetTranslation(h.A.DB, target, id, locale)
+}
+
+// saveTranslation adds or replaces a translation, or deletes it when remove
+// is set. Communities are translated by their admins, proposals by their
+// author or moderators.
+func (h *Helpers) saveTranslation(
+	target models.TranslationTarget,
+	id int,
+	locale string,
+	payload models.TranslationPayload,
+	remove bool,
+) (*models.Translation, int, error) {
+	validate := newValidator()
+	if vErr := validate.Struct(payload); vErr != nil {
+		return nil, http.StatusBadRequest, vErr
+	}
+	locale, err := shared.NormalizeLocale(locale)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("Invalid locale: %w", err)
+	}
+	if !remove && payload.Name == nil && payload.Body == nil {
+		return nil, http.StatusBadRequest, errors.New("A translated name or body is required.")
+	}
+
+	if target == models.CommunityTranslations {
+		if _, err := h.fetchCommunity(id); err != nil {
+			return nil, http.StatusNotFound, err
+		}
+		if err := h.validateCommunityAdmin(id, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+			return nil, http.StatusForbidden, err
+		}
+	} else {
+		p := models.Proposal{ID: id}
+		if err := p.GetProposalById(h.A.DB); err != nil {
+			return nil, http.StatusNotFound, err
+		}
+		if err := h.validateProposalTranslator(p, payload); err != nil {
+			return nil, http.StatusForbidden, err
+		}
+	}
+
+	if remove {
+		status := http.StatusInternalServerError
+		err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+			deleted, err := models.DeleteTranslation(tx, target, id, locale)
+			if err == nil && !deleted {
+				status = http.StatusNotFound
+				return fmt.Errorf("No %s translation found.", locale)
+			}
+			return err
+		})
+		if errors.Is(err, models.ErrSignatureReused) {
+			return nil, http.StatusForbidden, err
+		} else if err != nil {
+			return nil, status, err
+		}
+		return nil, http.StatusOK, nil
+	}
+
+	t := models.Translation{Locale: locale, Name: payload.Name, Body: payload.Body}
+	if t.Body != nil {
+		body := h.A.Sanitizer.Sanitize(*t.Body)
+		t.Body = &body
+	}
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return t.Upsert(tx, target, id)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return nil, http.StatusForbidden, err
+	} else if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return &t, http.StatusOK, nil
+}
+
+// getCommunityBranding returns the branding of the community, filled in
+// from its profile.
+func (h *Helpers) getCommunityBranding(tenant *models.Tenant, id int) (models.CommunityBranding, int, error) {
+	c, err := h.fetchTenantCommunity(tenant, id)
+	if err != nil {
+		return models.CommunityBranding{}, http.StatusNotFound, err
+	}
+	b, err := models.GetCommunityBranding(h.A.DB, id)
+	if err != nil {
+		return models.CommunityBranding{}, http.StatusInternalServerError, err
+	}
+	return b.WithCommunityDefaults(c), http.StatusOK, nil
+}
+
+func (h *Helpers) getReputationSettings(tenant *models.Tenant, id int) (models.ReputationSettings, int, error) {
+	if _, err := h.fetchTenantCommunity(tenant, id); err != nil {
+		return models.ReputationSettings{}, http.StatusNotFound, err
+	}
+	settings, err := models.GetReputationSettings(h.A.DB, id)
+	if err != nil {
+		return models.ReputationSettings{}, http.StatusInternalServerError, err
+	}
+	return settings, http.StatusOK, nil
+}
+
+// saveReputationSettings changes how the community scores reputation,
+// taking effect the next time scores are computed.
+func (h *Helpers) saveReputationSettings(
+	tenant *models.Tenant,
+	id int,
+	payload models.ReputationSettingsPayload,
+) (models.ReputationSettings, int, error) {
+	validate := newValidator()
+	if vErr := validate.Struct(payload); vErr != nil {
+		return models.ReputationSettings{}, http.StatusBadRequest, vErr
+	}
+	if _, err := h.fetchTenantCommunity(tenant, id); err != nil {
+		return models.ReputationSettings{}, http.StatusNotFound, err
+	}
+	if err := h.validateCommunityAdmin(id, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+		return models.ReputationSettings{}, http.StatusForbidden, err
+	}
+
+	settings, err := models.GetReputationSettings(h.A.DB, id)
+	if err != nil {
+		return models.ReputationSettings{}, http.StatusInternalServerError, err
+	}
+	if payload.Participation_weight != nil {
+		settings.Participation_weight = *payload.Participation_weight
+	}
+	if payload.Authorship_weight != nil {
+		settings.Authorship_weight = *payload.Authorship_weight
+	}
+	if payload.Tenure_weight != nil {
+		settings.Tenure_weight = *payload.Tenure_weight
+	}
+	if payload.Authorship_target != nil {
+		settings.Authorship_target = *payload.Authorship_target
+	}
+	if payload.Tenure_days != nil {
+		settings.Tenure_days = *payload.Tenure_days
+	}
+	if err := settings.Validate(); err != nil {
+		return models.ReputationSettings{}, http.StatusBadRequest, err
+	}
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return settings.Upsert(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.ReputationSettings{}, http.StatusForbidden, err
+	} else if err != nil {
+		return models.ReputationSettings{}, http.StatusInternalServerError, err
+	}
+	return settings, http.StatusOK, nil
+}
+
+func (h *Helpers) getReputationScore(tenant *models.Tenant, id int, addr string) (models.ReputationScore, int, error) {
+	if _, err := h.fetchTenantCommunity(tenant, id); err != nil {
+		return models.ReputationScore{}, http.StatusNotFound, err
+	}
+	score, err := models.GetReputationScore(h.A.DB, id, addr)
+	if err != nil {
+		return models.ReputationScore{}, http.StatusInternalServerError, err
+	}
+	return score, http.StatusOK, nil
+}
+
+func (h *Helpers) getAddressVerifications(addr string) ([]*models.AddressVerification, int, error) {
+	verifications, err := models.GetVerificationsForAddress(h.A.DB, addr)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return verifications, http.StatusOK, nil
+}
+
+// verifyAddress checks the address with the identity provider and records
+// the result, whether or not the provider vouches for it.
+func (h *Helpers) verifyAddress(addr string, payload models.VerifyAddressPayload) (models.AddressVerification, int, error) {
+	validate := newValidator()
+	if vErr := validate.Struct(payload); vErr != nil {
+		return models.AddressVerification{}, http.StatusBadRequest, vErr
+	}
+	verifier, ok := h.A.IdentityVerifiers[payload.Provider]
+	if !ok {
+		return models.AddressVerification{}, http.StatusBadRequest,
+			fmt.Errorf("Verifying with %q is not available.", payload.Provider)
+	}
+
+	// BrightID links the address itself, other providers know another account
+	externalId := addr
+	if payload.Provider != shared.IdentityBrightId {
+		if payload.External_id == nil {
+			return models.AddressVerification{}, http.StatusBadRequest,
+				fmt.Errorf("Verifying with %s requires the externalId it knows.", payload.Provider)
+		}
+		externalId = strings.ToLower(*payload.External_id)
+	}
+
+	if err := h.validateSignedByAddress(addr, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+		return models.AddressVerification{}, http.StatusForbidden, err
+	}
+
+	check, err := verifier.Verify(externalId)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error verifying %s with %s.", addr, payload.Provider)
+		return models.AddressVerification{}, http.StatusBadGateway, err
+	}
+
+	v := models.AddressVerification{
+		Addr:        addr,
+		Provider:    payload.Provider,
+		External_id: externalId,
+		Status:      models.VerificationUnverified,
+		Score:       check.Score,
+	}
+	if check.Verified {
+		now := time.Now().UTC()
+		expires := now.Add(models.VerificationTTL)
+		v.Status = models.VerificationVerified
+		v.Verified_at = &now
+		v.Expires_at = &expires
+	}
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return v.Upsert(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.AddressVerification{}, http.StatusForbidden, err
+	} else if errors.Is(err, models.ErrIdentityInUse) {
+		return models.AddressVerification{}, http.StatusConflict, err
+	} else if err != nil {
+		return models.AddressVerification{}, http.StatusInternalServerError, err
+	}
+	return v, http.StatusOK, nil
+}
+
+// attestAddress records a platform admin's word that the address belongs to
+// a unique human, checked outside of CAST.
+func (h *Helpers) attestAddress(addr string, payload models.AttestAddressPayload) (models.AddressVerification, int, error) {
+	validate := newValidator()
+	if vErr := validate.Struct(payload); vErr != nil {
+		return models.AddressVerification{}, http.StatusBadRequest, vErr
+	}
+	if err := h.validatePlatformAdmin(payload.TimestampSignaturePayload); err != nil {
+		return models.AddressVerification{}, http.StatusForbidden, err
+	}
+
+	now := time.Now().UTC()
+	if payload.Expires_at != nil && !payload.Expires_at.After(now) {
+		return models.AddressVerification{}, http.StatusBadRequest, errors.New("An attestation must expire in the future.")
+	}
+	v := models.AddressVerification{
+		Addr:        addr,
+		Provider:    shared.IdentityAttestation,
+		External_id: addr,
+		Status:      models.VerificationVerified,
+		Verified_by: &payload.Signing_addr,
+		Verified_at: &now,
+		Expires_at:  payload.Expires_at,
+	}
+	if payload.External_id != nil {
+		v.External_id = *payload.External_id
+	}
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, nil, func(tx *shared.Database) error {
+		return v.Upsert(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.AddressVerification{}, http.StatusForbidden, err
+	} else if errors.Is(err, models.ErrIdentityInUse) {
+		return models.AddressVerification{}, http.StatusConflict, err
+	} else if err != nil {
+		return models.AddressVerification{}, http.StatusInternalServerError, err
+	}
+	return v, http.StatusOK, nil
+}
+
+// revokeVerification lets platform admins withdraw any verification, such
+// as one obtained by a sybil.
+func (h *Helpers) revokeVerification(
+	addr, provider string,
+	payload shared.TimestampSignaturePayload,
+) (models.AddressVerification, int, error) {
+	if err := h.validatePlatformAdmin(payload); err != nil {
+		return models.AddressVerification{}, http.StatusForbidden, err
+	}
+	var v models.AddressVerification
+	err := h.withSignature(payload.Signing_addr, payload.Timestamp, nil, func(tx *shared.Database) error {
+		var err error
+		v, err = models.RevokeVerification(tx, addr, provider)
+		return err
+	})
+	if errors.Is(err, models.ErrSignatureReused) {
+		return models.AddressVerification{}, http.StatusForbidden, err
+	} else if errors.Is(err, pgx.ErrNoRows) {
+		return models.AddressVerification{}, http.StatusNotFound,
+			fmt.Errorf("Address %s has no %s verification.", addr, provider)
+	} else if err != nil {
+		return models.AddressVerification{}, http.StatusInternalServerError, err
+	}
+	return v, http.StatusOK, nil
+}
+
+// ensureVerified checks the voter is verified with a provider the community
+// requires, and with one the strategy of the proposal requires.
+func (h *Helpers) ensureVerified(c models.Community, p models.Proposal, addr string) errorResponse {
+	var strategyRequires []string
+	if strategy, err := p.BoundStrategy(&c); err == nil {
+		strategyRequires = strategy.Required_verifications
+	}
+	if len(c.Required_verifications) == 0 && len(strategyRequires) == 0 {
+		return nilErr
+	}
+
+	verified, err := models.GetVerifiedProviders(h.A.DB, addr)
+	if err != nil {
+		log.Error().Err(err).Msg("Error checking address verifications.")
+		return errIncompleteRequest
+	}
+	for _, required := range [][]string{c.Required_verifications, strategyRequires} {
+		if !models.MeetsVerificationRequirement(verified, required) {
+			log.Error().Msgf("address %s is not verified with %v", addr, required)
+			errResponse := errUnverifiedVoter
+			errResponse.Details = fmt.Sprintf(errResponse.Details, strings.Join(required, " or "))
+			return errResponse
+		}
+	}
+	return nilErr
+}
+
+// ensureAccountAge checks the voting account existed on Flow as long as the
+// community and the strategy of the proposal require.
+func (h *Helpers) ensureAccountAge(c models.Community, p models.Proposal, addr string) errorResponse {
+	requirements := []models.AccountAge{c.AccountAge}
+	if strategy, err := p.BoundStrategy(&c); err == nil {
+		requirements = append(requirements, strategy.AccountAge)
+	}
+
+	// the account must exist at the earliest block any requirement names
+	var cutoff *uint64
+	lower := func(height uint64) {
+		if cutoff == nil || height < *cutoff {
+			cutoff = &height
+		}
+	}
+	for _, r := range requirements {
+		if r.Account_created_before_block != nil {
+			lower(*r.Account_created_before_block)
+		}
+		if createdBy, ok := r.CreatedBy(p.Start_time); ok {
+			head, err := h.A.FlowAdapter.GetLatestSealedHeader()
+			if err != nil {
+				log.Error().Err(err).Msg("Error getting latest block.")
+				return errIncompleteRequest
+			}
+			lower(shared.EstimateBlockHeight(createdBy, head, h.A.FlowAdapter.BlockInterval(head)))
+		}
+	}
+	if cutoff == nil {
+		return nilErr
+	}
+
+	existed, err := h.A.FlowAdapter.AccountExistedAt(addr, *cutoff)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error getting account %s at block %d.", addr, *cutoff)
+		return errIncompleteRequest
+	}
+	if !existed {
+		log.Error().Msgf("account %s did not exist at block %d", addr, *cutoff)
+		errResponse := errAccountTooNew
+		errResponse.Details = fmt.Sprintf(errResponse.Details, *cutoff)
+		return errResponse
+	}
+	return nilErr
+}
+
+// ensureAllowlist checks an allowlist strategy names a list of the
+// community.
+func (h *Helpers) ensureAllowlist(communityId int, strategy models.Strategy) errorResponse {
+	errResponse := errIncompleteRequest
+	if strategy.List_id == nil {
+		errResponse.setDetails(errors.New("The allowlist-one-vote strategy requires a listId."))
+		return errResponse
+	}
+	l := models.List{ID: *strategy.List_id}
+	if err := l.GetListById(h.A.DB); err != nil || l.Community_id != communityId {
+		errResponse.setDetails(fmt.Errorf("List %d is not a list of community %d.", *strategy.List_id, communityId))
+		return errResponse
+	}
+	return nilErr
+}
+
+// validateRequiredVerifications checks the identity providers a community
+// and its strategies require voters to verify with.
+func validateRequiredVerifications(required []string, strategies *[]models.Strategy) error {
+	if err := models.ValidateIdentityProviders(required); err != nil {
+		return err
+	}
+	if strategies == nil {
+		return nil
+	}
+	for _, s := range *strategies {
+		if err := models.ValidateIdentityProviders(s.Required_verifications); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateAccountAges(age models.AccountAge, strategies *[]models.Strategy) error {
+	if err := age.Validate(); err != nil {
+		return err
+	}
+	if strategies == nil {
+		return nil
+	}
+	for _, s := range *strategies {
+		if err := s.AccountAge.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getBrandingForDomain returns the branding of the community served from
+// the custom domain.
+func (h *Helpers) getBrandingForDomain(domain string) (models.CommunityBranding, int, error) {
+	if host, _, err := net.SplitHostPort(domain); err == nil {
+		domain = host
+	}
+	b, err := models.GetCommunityBrandingByDomain(h.A.DB, domain)
+	if errors.Is(err, models.ErrBrandingNotFound) {
+		return models.CommunityBranding{}, http.StatusNotFound, err
+	} else if err != nil {
+		return models.CommunityBranding{}, http.StatusInternalServerError, err
+	}
+	c, err := h.fetchCommunity(b.Community_id)
+	if err != nil {
+		return models.CommunityBranding{}, http.StatusInternalServerError, err
+	}
+	return b.WithCommunityDefaults(c), http.StatusOK, nil
+}
+
+func (h *Helpers) saveCommunityBranding(
+	tenant *models.Tenant,
+	id int,
+	payload models.CommunityBrandingPayload,
+) (models.CommunityBranding, int, error) {
+	validate := newValidator()
+	if vErr := validate.Struct(payload); vErr != nil {
+		return models.CommunityBranding{}, http.StatusBadRequest, vErr
+	}
+	c, err := h.fetchTenantCommunity(tenant, id)
+	if err != nil {
+		return models.CommunityBranding{}, http.StatusNotFound, err
+	}
+	if err := h.validateCommunityAdmin(id, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+		return models.CommunityBranding{}, http.StatusForbidden, err
+	}
+
+	b, err := models.GetCommunityBranding(h.A.DB, id)
+	if err != nil {
+		return models.CommunityBranding{}, http.StatusInternalServerError, err
+	}
+	if payload.Custom_domain != nil {
+		b.Custom_domain = nil
+		if domain := strings.ToLower(*payload.Custom_domain); domain != "" {
+			if other, err := models.GetCommunityBrandingByDomain(h.A.DB, domain); err == nil && other.Community_id != id {
+				return models.CommunityBranding{}, http.StatusConflict, fmt.Errorf("Domain %s is used by another community.", domain)
+			}
+			if t, ok := h.A.Tenants.ForHost(domain); ok {
+				return models.CommunityBranding{}, http.StatusConflict, fmt.Errorf("Domain %s is used by tenant %s.", domain, t.Slug)
+			}
+			b.Custom_domain = &domain
+		}
+	}
+	if payload.Logos != nil {
+		b.Logos = *payload.Logos
+	}
+	if payload.Theme != nil {
+		b.Theme = *payload.Theme
+	}
+	if payload.Social_links != nil {
+		b.Social_links = *payload.Social_links
+	}
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return b.Upsert(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.CommunityBranding{}, http.StatusForbidden, err
+	} else if err != nil {
+		return models.CommunityBranding{}, http.StatusInternalServerError, err
+	}
+	return b.WithCommunityDefaults(c), http.StatusOK, nil
+}
+
+func (h *Helpers) validateProposalTranslator(p models.Proposal, payload models.TranslationPayload) error {
+	if payload.Signing_addr != p.Creator_addr {
+		return h.validateCommunityPermission(
+			p.Community_id,
+			payload.TimestampSignaturePayload,
+			payload.Voucher,
+			models.PermModerateProposals,
+		)
+	}
+	if payload.Voucher != nil {
+		return h.validateUserViaVoucher(payload.Signing_addr, payload.Voucher)
+	}
+	return h.validateUser(payload.Signing_addr, payload.Timestamp, payload.Composite_signatures)
+}
+
+// getProposalBatch returns the proposals in the order asked for, leaving
+// out those that don't exist.
+func (h *Helpers) getProposalBatch(payload models.ProposalBatchPayload) ([]*models.ProposalWithCommunity, int, error) {
+	validate := newValidator()
+	if vErr := validate.Struct(payload); vErr != nil {
+		return nil, http.StatusBadRequest, vErr
+	}
+
+	proposals, err := models.GetProposalsByIds(h.A.DB, payload.Ids)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	byId := make(map[int]*models.Proposal, len(proposals))
+	communityIds := []int{}
+	for _, p := range proposals {
+		byId[p.ID] = p
+		communityIds = append(communityIds, p.Community_id)
+	}
+
+	communities, err := models.GetCommunitiesByIds(h.A.DB, communityIds)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	communitiesById := make(map[int]*models.Community, len(communities))
+	for _, c := range communities {
+		communitiesById[c.ID] = c
+	}
+
+	batch := []*models.ProposalWithCommunity{}
+	seen := map[int]bool{}
+	for _, id := range payload.Ids {
+		p, ok := byId[id]
+		if !ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		item := models.ProposalWithCommunity{Proposal: p, Community: communitiesById[p.Community_id]}
+		if item.Community != nil {
+			if strategy, err := p.BoundStrategy(item.Community); err == nil {
+				item.Strategy_info = &strategy
+			}
+		}
+		batch = append(batch, &item)
+	}
+	return batch, http.StatusOK, nil
+}
+
+// fetchProposalAttachments loads the attachments of a proposal the way
+// they are pinned, leaving none when there are none.
+func (h *Helpers) fetchProposalAttachments(p *models.Proposal) error {
+	attachments, err := models.GetProposalAttachments(h.A.DB, p.ID)
+	if err != nil {
+		return err
+	}
+	p.Attachments = nil
+	if len(attachments) > 0 {
+		p.Attachments = attachments
+	}
+	return nil
+}
+
+// fetchPinnedProposalContent loads what is pinned along with a proposal
+// from the tables it is kept in.
+func (h *Helpers) fetchPinnedProposalContent(p *models.Proposal) error {
+	if err := h.fetchProposalAttachments(p); err != nil {
+		return err
+	}
+	if err := h.fetchTreasurySnapshot(p); err != nil {
+		return err
+	}
+	payload, err := models.GetExecutionPayload(h.A.DB, p.ID)
+	if err != nil {
+		return err
+	}
+	p.Execution_payload = payload
+	return nil
+}
+
+func (h *Helpers) fetchTreasurySnapshot(p *models.Proposal) error {
+	balances, err := models.GetTreasurySnapshot(h.A.DB, p.ID)
+	if err != nil {
+		return err
+	}
+	p.Treasury_snapshot = nil
+	if len(balances) > 0 {
+		p.Treasury_snapshot = balances
+	}
+	return nil
+}
+
+// snapshotTreasuries reads the balance of each of the community's
+// treasuries at the block height.
+func (h *Helpers) snapshotTreasuries(communityId int, blockHeight uint64) ([]*models.TreasuryBalance, error) {
+	treasuries, err := models.GetTreasuriesForCommunity(h.A.DB, communityId)
+	if err != nil {
+		return nil, err
+	}
+	if len(treasuries) == 0 {
+		return nil, errors.New("Community has no treasuries to snapshot.")
+	}
+
+	balances := make([]*models.TreasuryBalance, 0, len(treasuries))
+	for _, t := range treasuries {
+		balance, err := h.A.FlowAdapter.GetFTBalance(t.Addr, blockHeight, t.Contract_name, t.Contract_addr, t.Public_path)
+		if err != nil {
+			return nil, fmt.Errorf("Balance of treasury %s could not be read.", t.Name)
+		}
+		balances = append(balances, &models.TreasuryBalance{
+			Name:          t.Name,
+			Addr:          t.Addr,
+			Contract_name: t.Contract_name,
+			Contract_addr: t.Contract_addr,
+			Balance:       balance,
+			Block_height:  blockHeight,
+		})
+	}
+	return balances, nil
+}
+
+func (h *Helpers) reviewProposal(
+	p models.Proposal,
+	payload models.ReviewProposalRequestPayload,
+	approve bool,
+) (models.Proposal, int, error) {
+	if err := h.validateCommunityPermission(
+		p.Community_id,
+		payload.TimestampSignaturePayload,
+		payload.Voucher,
+		models.PermModerateProposals,
+	); err != nil {
+		return models.Proposal{}, http.StatusForbidden, err
+	}
+
+	if !p.IsAwaitingReview() {
+		return models.Proposal{}, http.StatusBadRequest, errors.New("Proposal is not awaiting review.")
+	}
+
+	status := models.ProposalRejected
+	var snapshot *uint64
+	if approve {
+		status = models.ProposalPublished
+		header, err := h.A.FlowAdapter.Client.GetLatestBlockHeader(context.Background(), true)
+		if err != nil {
+			return models.Proposal{}, http.StatusInternalServerError, err
+		}
+		snapshot = &header.Height
+	} else if payload.Reason == nil || *payload.Reason == "" {
+		return models.Proposal{}, http.StatusBadRequest, errors.New("A reason is required to reject a proposal.")
+	}
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return p.ReviewProposal(tx, status, payload.Signing_addr, payload.Reason, snapshot)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.Proposal{}, http.StatusForbidden, err
+	} else if err != nil {
+		return models.Proposal{}, http.StatusInternalServerError, err
+	}
+
+	h.queuePin(models.PinProposal, p.ID)
+	h.onProposalReviewed(p)
+	if approve {
+		h.recordProposalEvent(p, models.EventProposalCreated)
+	} else {
+		h.markDepositDue(&p, false)
+	}
+
+	return p, http.StatusOK, nil
+}
+
+// onProposalReviewed is the notification hook for proposal authors when a
+// moderator approves or rejects their proposal.
+func (h *Helpers) onProposalReviewed(p models.Proposal) {
+	log.Info().Msgf("proposal %d by %s was %s by %s", p.ID, p.Creator_addr, *p.Status, *p.Reviewed_by)
+
+	data := map[string]interface{}{"name": p.Name, "status": *p.Status}
+	if p.Review_reason != nil {
+		data["reason"] = *p.Review_reason
+	}
+	h.notify(models.Notification{
+		Addr:              p.Creator_addr,
+		Notification_type: models.NotificationProposalReviewed,
+		Community_id:      &p.Community_id,
+		Proposal_id:       &p.ID,
+		Data:              data,
+	})
+}
+
+// moderateContent scores new content with the community's keyword rules
+// and the classifier, and holds it for review at the community's hold
+// score, unless the author moderates the community. It returns nil when
+// there is nothing to score with. An unreachable classifier lets content
+// through on the keyword score alone.
+func (h *Helpers) moderateContent(
+	community models.Community,
+	content shared.ModerationContent,
+) (*models.ModerationDecision, error) {
+	rules, err := models.GetModerationRules(h.A.DB, community.ID)
+	if err != nil {
+		return nil, err
+	}
+	moderators := []shared.Moderator{}
+	if len(rules) > 0 {
+		moderators = append(moderators, models.KeywordRules(rules))
+	}
+	if h.A.Classifier != nil {
+		moderators = append(moderators, h.A.Classifier)
+	}
+	if len(moderators) == 0 {
+		return nil, nil
+	}
+
+	decision := &models.ModerationDecision{
+		Community_id: community.ID,
+		Content_type: content.Kind,
+		Author_addr:  content.Author,
+		Labels:       []string{},
+		Action:       models.ModerationAllow,
+	}
+	for _, m := range moderators {
+		score, err := m.Score(content)
+		if err != nil {
+			if _, ok := m.(models.KeywordRules); ok {
+				return nil, err
+			}
+			log.Warn().Err(err).Msgf("Moderation classifier failed on a %s of community %d.", content.Kind, community.ID)
+			decision.Labels = append(decision.Labels, "classifier-unavailable")
+			continue
+		}
+		decision.Score = math.Max(decision.Score, score.Score)
+		decision.Labels = append(decision.Labels, score.Labels...)
+	}
+
+	if decision.Score >= community.ModerationSettings.HoldScore() {
+		if err := models.EnsurePermissionForCommunity(
+			h.A.DB,
+			content.Author,
+			community.ID,
+			models.PermModerateProposals,
+		); err != nil {
+			decision.Action = models.ModerationHold
+		}
+	}
+	return decision, nil
+}
+
+func (h *Helpers) getModerationRules(token string, communityId int) ([]*models.ModerationRule, int, error) {
+	if httpStatus, err := h.validateModeratorSession(token, communityId); err != nil {
+		return nil, httpStatus, err
+	}
+	rules, err := models.GetModerationRules(h.A.DB, communityId)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return rules, http.StatusOK, nil
+}
+
+func (h *Helpers) createModerationRule(
+	communityId int,
+	payload models.ModerationRulePayload,
+) (models.ModerationRule, int, error) {
+	if vErr := newValidator().Struct(payload); vErr != nil {
+		return models.ModerationRule{}, http.StatusBadRequest, vErr
+	}
+	if err := h.validateCommunityPermission(
+		communityId,
+		payload.TimestampSignaturePayload,
+		payload.Voucher,
+		models.PermModerateProposals,
+	); err != nil {
+		return models.ModerationRule{}, http.StatusForbidden, err
+	}
+
+	rule := models.ModerationRule{
+		Community_id: communityId,
+		Pattern:      strings.ToLower(strings.TrimSpace(payload.Pattern)),
+		Weight:       payload.Weight,
+		Created_by:   payload.Signing_addr,
+	}
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return rule.CreateModerationRule(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.ModerationRule{}, http.StatusForbidden, err
+	} else if err != nil {
+		log.Error().Err(err).Msg("Error creating moderation rule.")
+		return models.ModerationRule{}, http.StatusBadRequest,
+			fmt.Errorf("Rule %q already exists for community %d.", rule.Pattern, communityId)
+	}
+	return rule, http.StatusCreated, nil
+}
+
+func (h *Helpers) deleteModerationRule(
+	communityId, ruleId int,
+	payload models.CommunityRoleDeletePayload,
+) (int, error) {
+	rule := models.ModerationRule{ID: ruleId}
+	if err := rule.GetModerationRuleById(h.A.DB); err != nil || rule.Community_id != communityId {
+		return http.StatusNotFound, errors.New("Moderation rule not found.")
+	}
+	if err := h.validateCommunityPermission(
+		communityId,
+		payload.TimestampSignaturePayload,
+		payload.Voucher,
+		models.PermModerateProposals,
+	); err != nil {
+		return http.StatusForbidden, err
+	}
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return rule.DeleteModerationRule(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return http.StatusForbidden, err
+	} else if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (h *Helpers) getModerationDecisions(
+	token string,
+	communityId int,
+	action string,
+	pageParams shared.PageParams,
+) ([]*models.ModerationDecision, shared.PageParams, int, error) {
+	if httpStatus, err := h.validateModeratorSession(token, communityId); err != nil {
+		return nil, pageParams, httpStatus, err
+	}
+	switch action {
+	case "", models.ModerationAllow, models.ModerationHold:
+	default:
+		return nil, pageParams, http.StatusBadRequest, fmt.Errorf("Action must be allow or hold, not %q.", action)
+	}
+
+	decisions, totalRecords, err := models.GetModerationDecisions(h.A.DB, communityId, action, pageParams)
+	if err != nil {
+		return nil, pageParams, http.StatusInternalServerError, err
+	}
+	pageParams.TotalRecords = totalRecords
+	return decisions, pageParams, http.StatusOK, nil
+}
+
+// validateModeratorSession checks a session was issued to a moderator of
+// the community. Rules and scores are kept from authors, who could word
+// around them.
+func (h *Helpers) validateModeratorSession(token string, communityId int) (int, error) {
+	addr, err := h.sessionAddr(token)
+	if err != nil {
+		return http.StatusUnauthorized, err
+	}
+	if err := models.EnsurePermissionForCommunity(h.A.DB, addr, communityId, models.PermModerateProposals); err != nil {
+		return http.StatusForbidden, err
+	}
+	return http.StatusOK, nil
+}
+
+// ensureCanSeeWithheldProposal checks a session was issued to the author
+// of a proposal held for review or rejected, or to a moderator of its
+// community.
+func (h *Helpers) ensureCanSeeWithheldProposal(token string, p models.Proposal) error {
+	addr, err := h.sessionAddr(token)
+	if err != nil {
+		return err
+	}
+	if addr == p.Creator_addr {
+		return nil
+	}
+	return models.EnsurePermissionForCommunity(h.A.DB, addr, p.Community_id, models.PermModerateProposals)
+}
+
+// validateCommunityAdminSession checks that a session was issued to an
+// admin of the community or to a platform admin.
+func (h *Helpers) validateCommunityAdminSession(token string, communityId int) (int, error) {
+	addr, err := h.sessionAddr(token)
+	if err != nil {
+		return http.StatusUnauthorized, err
+	}
+	if h.A.AdminAllowlist.Contains(addr) {
+		return http.StatusOK, nil
+	}
+	if err := models.EnsureRoleForCommunity(h.A.DB, addr, communityId, "admin"); err != nil {
+		return http.StatusForbidden, err
+	}
+	return http.StatusOK, nil
+}
+
+func (h *Helpers) validateSignedByAddress(
+	addr string,
+	payload shared.TimestampSignaturePayload,
+	voucher *shared.Voucher,
+) error {
+	if payload.Signing_addr != addr {
+		return errors.New("Requests must be signed by the address they act on.")
+	}
+	if voucher != nil {
+		return h.validateUserViaVoucher(payload.Signing_addr, voucher)
+	}
+	return h.validateUser(payload.Signing_addr, payload.Timestamp, payload.Composite_signatures)
+}
+
+// login checks the signature once and issues a session token for the
+// signing address, accepted in its place on reads that need identity.
+func (h *Helpers) login(payload models.LoginPayload) (models.Session, int, error) {
+	if payload.Signing_addr == "" {
+		return models.Session{}, http.StatusBadRequest, errors.New("Signing address is required.")
+	}
+	if err := h.validateSignedByAddress(payload.Signing_addr, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+		return models.Session{}, http.StatusForbidden, err
+	}
+	if err := h.consumeSignature(h.A.DB, payload.Signing_addr, payload.Timestamp, payload.Voucher); err != nil {
+		return models.Session{}, http.StatusForbidden, err
+	}
+
+	now := time.Now()
+	session := models.Session{
+		Addr:       payload.Signing_addr,
+		Expires_at: now.Add(sessionTokenExpiry).UTC(),
+	}
+	token, err := h.A.TokenSigner.Sign(shared.TokenClaims{
+		Subject:   session.Addr,
+		Audience:  models.SessionTokenAudience,
+		ExpiresAt: session.Expires_at.Unix(),
+		IssuedAt:  now.Unix(),
+	})
+	if err != nil {
+		return models.Session{}, http.StatusInternalServerError, err
+	}
+	session.Token = token
+
+	return session, http.StatusOK, nil
+}
+
+// sessionAddr returns the address a session token was issued to.
+func (h *Helpers) sessionAddr(token string) (string, error) {
+	if token == "" {
+		return "", errors.New("Missing session token.")
+	}
+	var claims shared.TokenClaims
+	if err := h.A.TokenSigner.Verify(token, &claims); err != nil {
+		return "", err
+	}
+	if claims.Audience != models.SessionTokenAudience || claims.Subject == "" {
+		return "", shared.ErrInvalidToken
+	}
+	return claims.Subject, nil
+}
+
+func (h *Helpers) updateNotificationPreferences(
+	addr string,
+	payload models.NotificationPreferencesPayload,
+) ([]models.NotificationPreference, int, error) {
+	validate := newValidator()
+	if vErr := validate.Struct(payload); vErr != nil {
+		errMsg := "Invalid notification preferences."
+		log.Error().Err(vErr).Msg(errMsg)
+		return nil, http.StatusBadRequest, errors.New(errMsg)
+	}
+	for _, p := range payload.Preferences {
+		if err := models.EnsureValidNotificationType(p.Notification_type); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	}
+
+	if err := h.validateSignedByAddress(addr, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+		return nil, http.StatusForbidden, err
+	}
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		for _, p := range payload.Preferences {
+			p.Addr = addr
+			if err := p.SavePreference(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return nil, http.StatusForbidden, err
+	} else if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	preferences, err := models.GetNotificationPreferences(h.A.DB, addr)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return preferences, http.StatusOK, nil
+}
+
+func (h *Helpers) validateStrategyName(name string) error {
+	if name == "" {
+		return errors.New("Strategy name is required.")
+	}
+
+	for k, _ := range strategyMap {
+		if name == k {
+			return nil
+		} else {
+			continue
+		}
+	}
+
+	return errors.New("Strategy not found.")
+}
+
+// dryRunTally estimates the weights a draft strategy would give to a set of
+// addresses, without storing balances, so admins can check it before use.
+func (h *Helpers) dryRunTally(communityId int, payload models.DryRunTallyPayload) (models.DryRunTally, int, error) {
+	if err := h.validateCommunityAdmin(communityId, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+		return models.DryRunTally{}, http.StatusForbidden, err
+	}
+
+	if payload.Strategy.Name == nil {
+		return models.DryRunTally{}, http.StatusBadRequest, errors.New("Strategy name is required.")
+	}
+	s := h.initStrategy(*payload.Strategy.Name)
+	if s == nil {
+		return models.DryRunTally{}, http.StatusBadRequest, errors.New("Strategy not found.")
+	}
+	c := payload.Strategy.Contract
+	needsContract := !funk.ContainsString(
+		[]string{"one-address-one-vote", "reputation-weighted", "allowlist-one-vote"}, *payload.Strategy.Name)
+	if needsContract && (c.Name == nil || c.Addr == nil || c.Public_path == nil) {
+		return models.DryRunTally{}, http.StatusBadRequest, errors.New("Strategy contract requires a name, address and public path.")
+	}
+
+	addresses := payload.Addresses
+	if payload.List_id != nil {
+		l := models.List{ID: *payload.List_id}
+		if err := l.GetListById(h.A.DB); err != nil || l.Community_id != communityId {
+			return models.DryRunTally{}, http.StatusNotFound, errors.New("List not found.")
+		}
+		addresses = l.Addresses
+	}
+	if len(addresses) == 0 {
+		return models.DryRunTally{}, http.StatusBadRequest, errors.New("Addresses or a list are required.")
+	}
+	if len(addresses) > maxDryRunAddresses {
+		errMsg := fmt.Sprintf("Dry runs are limited to %d addresses.", maxDryRunAddresses)
+		return models.DryRunTally{}, http.StatusBadRequest, errors.New(errMsg)
+	}
+
+	var blockHeight uint64
+	if payload.Block_height != nil {
+		blockHeight = *payload.Block_height
+	} else {
+		height, err := h.A.FlowAdapter.GetCurrentBlockHeight()
+		if err != nil {
+			return models.DryRunTally{}, http.StatusInternalServerError, err
+		}
+		blockHeight = uint64(height)
+	}
+
+	maxWeight := payload.Strategy.Contract.MaxWeight
+	weights := []models.DryRunWeight{}
+	for _, addr := range addresses {
+		w := models.DryRunWeight{Addr: addr}
+		weight, err := s.EstimateWeight(addr, &payload.Strategy, blockHeight)
+		if err != nil {
+			w.Error = err.Error()
+		} else if maxWeight != nil && weight > *maxWeight {
+			w.Weight = *maxWeight
+			w.Capped = true
+		} else {
+			w.Weight = weight
+		}
+		weights = append(weights, w)
+	}
+
+	return models.BuildDryRunTally(payload.Strategy, blockHeight, weights), http.StatusOK, nil
+}
+
+func (h *Helpers) enforceCommunityRestrictions(c models.Community, p models.Proposal) errorResponse {
+	eligibility, err := h.proposalEligibility(c, p.Creator_addr)
+	if err != nil {
+		errMsg := "Error processing Token Threshold."
+		log.Error().Err(err).Msg(errMsg)
+		errResponse := errIncompleteRequest
+		errResponse.Details = errMsg
+		return errResponse
+	}
+	if eligibility.Eligible {
+		return nilErr
+	}
+
+	log.Error().Msgf("%s can't create proposals for community %d: %s", p.Creator_addr, c.ID, eligibility.Reason)
+	if next := eligibility.Next_proposal_at; next != nil {
+		errResponse := errProposalCooldown
+		errResponse.Details = fmt.Sprintf(
+			errResponse.Details,
+			*c.Proposal_cooldown_limit,
+			roughDuration(c.ProposalCooldown.Window()),
+			next.Format(time.RFC3339),
+		)
+		errResponse.Retry_at = next
+		return errResponse
+	}
+	if participation := eligibility.Participation; participation != nil {
+		errResponse := errInsufficientParticipation
+		errResponse.Details = fmt.Sprintf(
+			errResponse.Details,
+			c.AuthorParticipation.RequiredVotes(*participation),
+			participation.Ended,
+			participation.Voted,
+		)
+		return errResponse
+	}
+	if eligibility.Only_authors {
+		errResponse := errIncompleteRequest
+		errResponse.Details = eligibility.Reason
+		return errResponse
+	}
+	errResponse := errInsufficientProposalBalance
+	errResponse.Details = fmt.Sprintf(
+		errResponse.Details,
+		*eligibility.Required,
+		*eligibility.Token,
+		*eligibility.Balance,
+		*eligibility.Block_height,
+	)
+	return errResponse
+}
+
+// proposalEligibility checks whether the address can create proposals in
+// the community: whether it is an author of communities that only let
+// authors submit, and otherwise whether it holds the proposal threshold.
+// Either way, it must have voted as much as the community requires, and
+// be out of the community's cooldown.
+func (h *Helpers) proposalEligibility(c models.Community, addr string) (models.ProposalEligibility, error) {
+	eligibility, err := h.authorEligibility(c, addr)
+	if err != nil || !eligibility.Eligible {
+		return eligibility, err
+	}
+
+	if !c.AuthorParticipation.IsZero() {
+		participation, met, err := c.AuthorParticipation.CheckAuthor(h.A.DB, c.ID, addr)
+		if err != nil {
+			return eligibility, err
+		}
+		if !met {
+			eligibility.Eligible = false
+			eligibility.Participation = &participation
+			eligibility.Reason = fmt.Sprintf(
+				"Account %s voted on %d of the last %d proposals of community %d, %d are required.",
+				addr, participation.Voted, participation.Ended, c.ID, c.AuthorParticipation.RequiredVotes(participation),
+			)
+			return eligibility, nil
+		}
+	}
+
+	next, err := h.nextProposalAt(c, addr)
+	if err != nil {
+		return eligibility, err
+	}
+	if next != nil {
+		eligibility.Eligible = false
+		eligibility.Next_proposal_at = next
+		eligibility.Reason = fmt.Sprintf(
+			"Account %s created %d proposals in community %d in the last %s, it may create the next at %s.",
+			addr, *c.Proposal_cooldown_limit, c.ID, roughDuration(c.ProposalCooldown.Window()), next.Format(time.RFC3339),
+		)
+	}
+	return eligibility, nil
+}
+
+// nextProposalAt is when the address is out of the community's cooldown,
+// or nil when it is now. Moderators have no cooldown.
+func (h *Helpers) nextProposalAt(c models.Community, addr string) (*time.Time, error) {
+	if c.ProposalCooldown.IsZero() {
+		return nil, nil
+	}
+	if err := models.EnsurePermissionForCommunity(h.A.DB, addr, c.ID, models.PermModerateProposals); err == nil {
+		return nil, nil
+	}
+	return c.ProposalCooldown.NextProposalAt(h.A.DB, c.ID, addr, time.Now().UTC())
+}
+
+func (h *Helpers) authorEligibility(c models.Community, addr string) (models.ProposalEligibility, error) {
+	eligibility := models.ProposalEligibility{Addr: addr}
+
+	if c.Only_authors_to_submit != nil && *c.Only_authors_to_submit {
+		eligibility.Only_authors = true
+		if err := models.EnsurePermissionForCommunity(h.A.DB, addr, c.ID, models.PermCreateProposal); err != nil {
+			eligibility.Reason = fmt.Sprintf("Account %s is not an author for community %d.", addr, c.ID)
+			return eligibility, nil
+		}
+		eligibility.Eligible = true
+		return eligibility, nil
+	}
+
+	if c.Proposal_threshold == nil {
+		return eligibility, errors.New("Community has no proposal threshold.")
+	}
+	threshold, err := strconv.ParseFloat(*c.Proposal_threshold, 64)
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid proposal threshold")
+		return eligibility, errors.New("Invalid proposal threshold")
+	}
+	if c.Contract_name == nil || c.Contract_addr == nil || c.Public_path == nil {
+		return eligibility, errors.New("Community has no token to check the proposal threshold with.")
+	}
+
+	balance, err := h.thresholdBalance(addr, c)
+	if err != nil {
+		return eligibility, err
+	}
+
+	eligibility.Token = c.Contract_name
+	eligibility.Token_metadata, err = h.tokenMetadata(c.ID, *c.Contract_name, *c.Contract_addr)
+	if err != nil {
+		return eligibility, err
+	}
+	eligibility.Required = &threshold
+	eligibility.Balance = &balance.Balance
+	eligibility.Block_height = &balance.Block_height
+	eligibility.Eligible = balance.Balance >= threshold
+	if !eligibility.Eligible {
+		eligibility.Reason = "Insufficient token balance to create proposal."
+	}
+	return eligibility, nil
+}
+
+// thresholdBalance reads the balance of the community's token an address
+// holds at the latest block, reusing a balance read recently.
+func (h *Helpers) thresholdBalance(addr string, c models.Community) (models.ThresholdBalance, error) {
+	contract := *c.Contract_addr + "." + *c.Contract_name
+	cached, err := models.GetRecentThresholdBalance(h.A.DB, addr, contract, thresholdBalanceMaxAge)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error reading threshold balance cache")
+	} else if cached != nil {
+		return *cached, nil
+	}
+
+	header, err := h.A.FlowAdapter.Client.GetLatestBlockHeader(context.Background(), true)
+	if err != nil {
+		return models.ThresholdBalance{}, err
+	}
+
+	scriptPath := "./main/cadence/scripts/get_balance.cdc"
+	if c.Contract_type != nil && *c.Contract_type == "nft" {
+		scriptPath = "./main/cadence/scripts/get_nfts_ids.cdc"
+	}
+	token := shared.Contract{
+		Name:        c.Contract_name,
+		Addr:        c.Contract_addr,
+		Public_path: c.Public_path,
+	}
+	amount, err := h.A.FlowAdapter.GetThresholdBalance(scriptPath, addr, header.Height, &token)
+	if err != nil {
+		return models.ThresholdBalance{}, err
+	}
+
+	balance := models.ThresholdBalance{
+		Addr:         addr,
+		Contract:     contract,
+		Block_height: header.Height,
+		Balance:      amount,
+	}
+	if err := balance.Save(h.A.DB); err != nil {
+		log.Warn().Err(err).Msg("Error writing threshold balance cache")
+	}
+	return balance, nil
+}
+
+func (h *Helpers) createCommunity(tenant *models.Tenant, payload models.CreateCommunityRequestPayload) (models.Community, error) {
+	c := payload.Community
+	c.Tenant_id = tenant.ID
+
+	if c.Voucher != nil {
+		log.Info().Msgf("validate user via voucher %v \n", c.Voucher)
+		if err := h.validateUserViaVoucher(c.Creator_addr, c.Voucher); err != nil {
+			return models.Community{}, err
+		}
+	} else {
+		if err := h.validateUser(c.Creator_addr, c.Timestamp, c.Composite_signatures); err != nil {
+			return models.Community{}, err
+		}
+	}
+	if h.A.CommunityBlocklist.Contains(c.Creator_addr) {
+		return models.Community{}, models.ErrAddressBlocked
+	}
+
+	var parent models.Community
+	if c.Parent_id != nil {
+		var err error
+		parent, err = h.fetchCommunity(*c.Parent_id)
+		if err != nil {
+			return models.Community{}, err
+		}
+		if parent.Tenant_id != tenant.ID {
+			return models.Community{}, fmt.Errorf("Parent community %d belongs to another tenant.", parent.ID)
+		}
+		if err := models.EnsureRoleForCommunity(h.A.DB, c.Creator_addr, parent.ID, "admin"); err != nil {
+			errMsg := fmt.Sprintf("Account %s is not an admin of parent community %d.", c.Creator_addr, parent.ID)
+			log.Error().Err(err).Msg(errMsg)
+			return models.Community{}, errors.New(errMsg)
+		}
+		inheritParentSettings(&c, parent)
+	}
+
+	c.Cid = nil
+
+	validate := newValidator()
+	vErr := validate.Struct(c)
+	if vErr != nil {
+		log.Error().Err(vErr).Msg("Invalid community.")
+		return models.Community{}, vErr
+	}
+
+	if err := c.ProposalWindow.Validate(); err != nil {
+		log.Error().Err(err).Msg("Invalid proposal window.")
+		return models.Community{}, err
+	}
+	if c.Timezone != nil {
+		if err := models.ValidateTimeZone(*c.Timezone); err != nil {
+			return models.Community{}, err
+		}
+	}
+	if err := validateRequiredVerifications(c.Required_verifications, c.Strategies); err != nil {
+		return models.Community{}, err
+	}
+	if err := validateAccountAges(c.AccountAge, c.Strategies); err != nil {
+		return models.Community{}, err
+	}
+	// a new community starts on the free plan
+	if err := h.checkPlanStrategies(models.Community{}, c.Strategies); err != nil {
+		return models.Community{}, err
+	}
+	if err := c.PowerDecay.Validate(); err != nil {
+		return models.Community{}, err
+	}
+	if err := c.AuthorParticipation.Validate(); err != nil {
+		return models.Community{}, err
+	}
+	if err := c.DuplicateCheck.Validate(); err != nil {
+		return models.Community{}, err
+	}
+	if err := c.ModerationSettings.Validate(); err != nil {
+		return models.Community{}, err
+	}
+	if err := c.ProposalCooldown.Validate(); err != nil {
+		return models.Community{}, err
+	}
+	if err := c.ProposalDeposit.Validate(); err != nil {
+		return models.Community{}, err
+	}
+
+	// the community, its roles and inherited lists are created together,
+	// spending the creator's signature
+	err := h.withSignature(c.Creator_addr, c.Timestamp, c.Voucher, func(tx *shared.Database) error {
+		if err := c.CreateCommunity(tx); err != nil {
+			log.Error().Err(err).Msg("Database error creating community.")
+			return err
+		}
+
+		if err := processCommunityRoles(tx, &c, &payload); err != nil {
+			log.Error().Err(err).Msg("Error processing community roles.")
+			return err
+		}
+
+		if c.Parent_id != nil {
+			if _, err := syncParentLists(tx, c.ID, parent.ID); err != nil {
+				log.Error().Err(err).Msg("Error copying parent community lists.")
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Community{}, err
+	}
+	h.queuePin(models.PinCommunity, c.ID)
+
+	return c, nil
+}
+
+// Child communities default to the strategies and token settings of their
+// parent unless they are provided explicitly.
+func inheritParentSettings(c *models.Community, parent models.Community) {
+	if c.Strategies == nil {
+		c.Strategies = parent.Strategies
+	}
+	if c.Strategy == nil {
+		c.Strategy = parent.Strategy
+	}
+	if c.Contract_name == nil {
+		c.Contract_name = parent.Contract_name
+		c.Contract_addr = parent.Contract_addr
+		c.Contract_type = parent.Contract_type
+		c.Public_path = parent.Public_path
+	}
+	if c.Proposal_threshold == nil {
+		c.Proposal_threshold = parent.Proposal_threshold
+	}
+	if c.Only_authors_to_submit == nil {
+		c.Only_authors_to_submit = parent.Only_authors_to_submit
+	}
+	if c.ProposalWindow.IsZero() {
+		c.ProposalWindow = parent.ProposalWindow
+	}
+	if c.Timezone == nil {
+		c.Timezone = parent.Timezone
+	}
+}
+
+// syncParentLists copies the lists of the parent community into the child.
+// Lists are copied rather than shared, so later changes to the parent only
+// reach the child when it is synced again. A list of the child with the
+// type and name of a parent list is replaced with the parent's addresses as
+// a new version, except dynamic lists which keep computing their own. Lists
+// only the child has are left as they are.
+func syncParentLists(db *shared.Database, communityId, parentId int) ([]models.List, error) {
+	parentLists, err := models.GetListsForCommunity(db, parentId)
+	if err != nil {
+		return nil, err
+	}
+	lists, err := models.GetListsForCommunity(db, communityId)
+	if err != nil {
+		return nil, err
+	}
+
+	synced := []models.List{}
+	for _, pl := range parentLists {
+		i := -1
+		for j, l := range lists {
+			if equalStringPtr(l.List_type, pl.List_type) && equalStringPtr(l.Name, pl.Name) {
+				i = j
+				break
+			}
+		}
+
+		if i < 0 {
+			childList := models.List{
+				Community_id: communityId,
+				Addresses:    pl.Addresses,
+				List_type:    pl.List_type,
+				Cid:          pl.Cid,
+				Rule:         pl.Rule,
+				Name:         pl.Name,
+			}
+			if err := childList.CreateList(db); err != nil {
+				return nil, err
+			}
+			synced = append(synced, childList)
+			continue
+		}
+
+		childList := lists[i]
+		if childList.IsDynamic() || reflect.DeepEqual(childList.Addresses, pl.Addresses) {
+			continue
+		}
+		childList.Addresses = pl.Addresses
+		childList.Cid = pl.Cid
+		if err := childList.UpdateList(db); err != nil {
+			return nil, err
+		}
+		synced = append(synced, childList)
+	}
+
+	return synced, nil
+}
+
+func equalStringPtr(a, b *string) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+// syncCommunityLists brings the lists copied from the parent community up
+// to date with it.
+func (h *Helpers) syncCommunityLists(
+	id int,
+	payload models.CommunityRoleDeletePayload,
+) ([]models.List, int, error) {
+	c, err := h.fetchCommunity(id)
+	if err != nil {
+		return nil, http.StatusNotFound, err
+	}
+	if c.Parent_id == nil {
+		return nil, http.StatusBadRequest, errors.New("Community has no parent to sync lists from.")
+	}
+	if err := h.validateCommunityPermission(
+		c.ID,
+		payload.TimestampSignaturePayload,
+		payload.Voucher,
+		models.PermManageLists,
+	); err != nil {
+		return nil, http.StatusForbidden, err
+	}
+
+	var lists []models.List
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		lists, err = syncParentLists(tx, c.ID, *c.Parent_id)
+		return err
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return nil, http.StatusForbidden, err
+	} else if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return lists, http.StatusOK, nil
+}
+
+func (h *Helpers) getCommunityHierarchy(id int) (models.CommunityHierarchy, error) {
+	c, err := h.fetchCommunity(id)
+	if err != nil {
+		return models.CommunityHierarchy{}, err
+	}
+
+	return models.GetCommunityHierarchy(h.A.DB, &c)
+}
+
+func processCommunityRoles(
+	db *shared.Database,
+	c *models.Community,
+	p *models.CreateCommunityRequestPayload,
+) error {
+	if err := models.GrantRolesToCommunityCreator(db, c.Creator_addr, c.ID); err != nil {
+		errMsg := "Database error adding community creator roles."
+		log.Error().Err(err).Msg(errMsg)
+		return errors.New(errMsg)
+	}
+
+	if p.Additional_admins != nil {
+		for _, addr := range *p.Additional_admins {
+			if err := models.GrantAdminRolesToAddress(db, c.ID, addr); err != nil {
+				log.Error().Err(err)
+				return err
+			}
+		}
+	}
+
+	if p.Additional_authors != nil {
+		for _, addr := range *p.Additional_authors {
+			if err := models.GrantAuthorRolesToAddress(db, c.ID, addr); err != nil {
+				log.Error().Err(err)
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (h *Helpers) updateCommunity(
+	id int,
+	version int,
+	payload models.UpdateCommunityRequestPayload,
+) (models.Community, error) {
+	c, err := h.fetchCommunity(id)
+	if err != nil {
+		return models.Community{}, err
+	}
+	c.Version = version
+	if c.Admin_approvals_required > 1 && (payload.Strategies != nil || payload.Strategy != nil) {
+		return models.Community{}, errApprovalsNeeded
+	}
+
+	// strategy only updates can be made by anyone with the manage strategies
+	// permission, every other change requires an admin
+	if payload.UpdatesOnlyStrategies() {
+		if err := models.EnsurePermissionForCommunity(
+			h.A.DB,
+			payload.Signing_addr,
+			c.ID,
+			models.PermManageStrategies,
+		); err != nil {
+			log.Error().Err(err)
+			return models.Community{}, err
+		}
+	} else if err := c.CanUpdateCommunity(h.A.DB, payload.Signing_addr); err != nil {
+		log.Error().Err(err)
+		return models.Community{}, err
+	}
+
+	if payload.Voucher != nil {
+		if err := h.validateUserViaVoucher(payload.Signing_addr, payload.Voucher); err != nil {
+			log.Error().Err(err)
+			return models.Community{}, err
+		}
+	} else {
+		if err := h.validateUser(payload.Signing_addr, payload.Timestamp, payload.Composite_signatures); err != nil {
+			log.Error().Err(err)
+			return models.Community{}, err
+		}
+	}
+
+	if err := c.ProposalWindow.Merge(payload.ProposalWindow).Validate(); err != nil {
+		log.Error().Err(err).Msg("Invalid proposal window.")
+		return models.Community{}, err
+	}
+	if payload.Timezone != nil {
+		if err := models.ValidateTimeZone(*payload.Timezone); err != nil {
+			return models.Community{}, err
+		}
+	}
+	var requiredVerifications []string
+	if payload.Required_verifications != nil {
+		requiredVerifications = *payload.Required_verifications
+	}
+	if err := validateRequiredVerifications(requiredVerifications, payload.Strategies); err != nil {
+		return models.Community{}, err
+	}
+	if err := validateAccountAges(payload.AccountAge, payload.Strategies); err != nil {
+		return models.Community{}, err
+	}
+	if err := payload.PowerDecay.Validate(); err != nil {
+		return models.Community{}, err
+	}
+	if err := payload.AuthorParticipation.Validate(); err != nil {
+		return models.Community{}, err
+	}
+	if err := payload.DuplicateCheck.Validate(); err != nil {
+		return models.Community{}, err
+	}
+	if err := payload.ModerationSettings.Validate(); err != nil {
+		return models.Community{}, err
+	}
+	if err := payload.ProposalCooldown.Validate(); err != nil {
+		return models.Community{}, err
+	}
+	if err := payload.ProposalDeposit.Validate(); err != nil {
+		return models.Community{}, err
+	}
+	if payload.Strategies != nil {
+		if err := h.ensureStrategiesUnchanged(c, *payload.Strategies); err != nil {
+			return models.Community{}, err
+		}
+		if err := h.ensureRegisteredTokens(c, *payload.Strategies); err != nil {
+			return models.Community{}, err
+		}
+		if err := h.checkPlanStrategies(c, payload.Strategies); err != nil {
+			return models.Community{}, err
+		}
+	}
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return c.UpdateCommunity(tx, &payload)
+	}); err != nil {
+		log.Error().Err(err)
+		return models.Community{}, err
+	}
+
+	c, err = h.fetchCommunity(id)
+	if err != nil {
+		return models.Community{}, err
+	}
+
+	return c, nil
+}
+
+// errFrozenStrategy is returned for changes to strategies that proposals
+// are being voted on with.
+var errFrozenStrategy = errors.New("Strategy is used by proposals that are not closed yet")
+
+// ensureStrategiesUnchanged rejects updated strategies that would remove or
+// change a strategy used by proposals in review, upcoming or active.
+func (h *Helpers) ensureStrategiesUnchanged(c models.Community, updated []models.Strategy) error {
+	changed := changedStrategies(c, updated)
+	if len(changed) == 0 {
+		return nil
+	}
+	inUse, err := models.GetStrategiesInUse(h.A.DB, c.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range inUse {
+		if funk.ContainsString(changed, name) {
+			return fmt.Errorf("%w: %s", errFrozenStrategy, name)
+		}
+	}
+	return nil
+}
+
+// changedStrategies returns the names of the community's strategies that
+// the updated strategies remove or change.
+func changedStrategies(c models.Community, updated []models.Strategy) []string {
+	changed := []string{}
+	if c.Strategies == nil {
+		return changed
+	}
+	for _, current := range *c.Strategies {
+		next, err := models.MatchStrategyByProposal(updated, *current.Name)
+		if err != nil || !reflect.DeepEqual(current, next) {
+			changed = append(changed, *current.Name)
+		}
+	}
+	return changed
+}
+
+// previewStrategyChange lists the proposals an intended change of the
+// community's strategies would affect, without changing anything.
+func (h *Helpers) previewStrategyChange(
+	communityId int,
+	payload models.StrategyChangePayload,
+) (models.StrategyChangeImpact, int, error) {
+	validate := newValidator()
+	if vErr := validate.Struct(payload); vErr != nil {
+		return models.StrategyChangeImpact{}, http.StatusBadRequest, vErr
+	}
+	if err := h.validateCommunityAdmin(communityId, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+		return models.StrategyChangeImpact{}, http.StatusForbidden, err
+	}
+
+	c, err := h.fetchCommunity(communityId)
+	if err != nil {
+		return models.StrategyChangeImpact{}, http.StatusNotFound, errors.New("Community not found.")
+	}
+
+	impact := models.StrategyChangeImpact{
+		Version: c.Strategies_version,
+		Changed: changedStrategies(c, payload.Strategies),
+	}
+	impact.Proposals, err = models.GetOpenProposalsUsingStrategies(h.A.DB, c.ID, impact.Changed)
+	if err != nil {
+		return models.StrategyChangeImpact{}, http.StatusInternalServerError, err
+	}
+	return impact, http.StatusOK, nil
+}
+
+func (h *Helpers) validateCommunityAdmin(
+	communityId int,
+	payload shared.TimestampSignaturePayload,
+	voucher *shared.Voucher,
+) error {
+	if voucher != nil {
+		return h.validateUserWithRoleViaVoucher(payload.Signing_addr, voucher, communityId, "admin")
+	}
+	return h.validateUserWithRole(
+		payload.Signing_addr,
+		payload.Timestamp,
+		payload.Composite_signatures,
+		communityId,
+		"admin",
+	)
+}
+
+func (h *Helpers) setCommunityArchived(
+	id int,
+	payload models.ArchiveCommunityRequestPayload,
+	archive bool,
+) (models.Community, error) {
+	c, err := h.fetchCommunity(id)
+	if err != nil {
+		return models.Community{}, err
+	}
+
+	if archive && c.Admin_approvals_required > 1 {
+		return models.Community{}, errApprovalsNeeded
+	}
+
+	if err := h.validateCommunityAdmin(c.ID, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+		return models.Community{}, err
+	}
+	if !archive && c.Suspended_at != nil {
+		return models.Community{}, errors.New("Community was suspended by the platform.")
+	}
+
+	err = h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		if archive {
+			return c.ArchiveCommunity(tx)
+		}
+		return c.UnarchiveCommunity(tx)
+	})
+	if err != nil {
+		return models.Community{}, err
+	}
+
+	return c, nil
+}
+
+// deleteCommunity schedules an archived community for deletion. Once the
+// grace period has passed, the communities job or a second request
+// permanently removes it.
+func (h *Helpers) deleteCommunity(
+	id int,
+	payload models.ArchiveCommunityRequestPayload,
+) (models.Community, int, error) {
+	c, err := h.fetchCommunity(id)
+	if err != nil {
+		return models.Community{}, http.StatusNotFound, err
+	}
+
+	if err := h.validateCommunityAdmin(c.ID, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+		return models.Community{}, http.StatusForbidden, err
+	}
+
+	if !c.Is_archived {
+		return models.Community{}, http.StatusBadRequest, errors.New("Community must be archived before it can be deleted.")
+	}
+
+	if c.Delete_after == nil {
+		if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+			return c.ScheduleDelete(tx, communityDeleteGracePeriod)
+		}); errors.Is(err, models.ErrSignatureReused) {
+			return models.Community{}, http.StatusForbidden, err
+		} else if err != nil {
+			return models.Community{}, http.StatusInternalServerError, err
+		}
+		return c, http.StatusAccepted, nil
+	}
+
+	if time.Now().UTC().Before(*c.Delete_after) {
+		errMsg := fmt.Sprintf("Community %d cannot be deleted before %s.", c.ID, c.Delete_after.Format(time.RFC3339))
+		return models.Community{}, http.StatusConflict, errors.New(errMsg)
+	}
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return c.HardDeleteCommunity(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.Community{}, http.StatusForbidden, err
+	} else if err != nil {
+		return models.Community{}, http.StatusInternalServerError, err
+	}
+
+	return c, http.StatusOK, nil
+}
+
+// purgeDeletedCommunities permanently removes the communities scheduled
+// for deletion once their grace period has passed.
+func (h *Helpers) purgeDeletedCommunities() error {
+	communities, err := models.GetCommunitiesDueForDelete(h.A.DB)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range communities {
+		if err := c.HardDeleteCommunity(h.A.DB); err != nil {
+			log.Error().Err(err).Msgf("Error deleting community %d.", c.ID)
+			continue
+		}
+		log.Info().Msgf("Deleted community %d after its grace period.", c.ID)
+	}
+	return nil
+}
+
+func (h *Helpers) removeUserRole(payload models.CommunityUserPayload) (int, error) {
+	if payload.Voucher != nil {
+		if err := h.validateUserViaVoucher(payload.Signing_addr, payload.Voucher); err != nil {
+			log.Error().Err(err)
+			return http.StatusForbidden, err
+		}
+	} else {
+		if err := h.validateUser(payload.Signing_addr, payload.Timestamp, payload.Composite_signatures); err != nil {
+			log.Error().Err(err)
+			return http.StatusForbidden, err
+		}
+	}
+
+	// validate someone else is not removing a "member" role
+	if payload.User_type == "member" && payload.Addr != payload.Signing_addr {
+		CANNOT_REMOVE_MEMBER_ERR := errors.New("Cannot remove another member from a community.")
+		log.Error().Err(CANNOT_REMOVE_MEMBER_ERR)
+		return http.StatusForbidden, CANNOT_REMOVE_MEMBER_ERR
+	}
+
+	u := payload.CommunityUser
+
+	if payload.User_type == "admin" {
+		// validate signer is admin
+		if err := h.ensureCanManageRole(payload.Signing_addr, payload.Community_id, payload.User_type); err != nil {
+			USER_MUST_BE_ADMIN_ERR := errors.New("User must be community admin.")
+			log.Error().Err(err).Msg("Database error.")
+			log.Error().Err(USER_MUST_BE_ADMIN_ERR)
+			return http.StatusForbidden, USER_MUST_BE_ADMIN_ERR
+		}
+	}
+
+	err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		switch payload.User_type {
+		case "member":
+			// If a member is removing themselves, remove all their other roles as well
+			userRoles, err := models.GetAllRolesForUserInCommunity(tx, payload.Addr, payload.Community_id)
+			if err != nil {
+				return err
+			}
+			for _, userRole := range userRoles {
+				if err := userRole.Remove(tx); err != nil {
+					return err
+				}
+			}
+		case "admin":
+			// If the admin role is being removed, remove author role as well
+			author := models.CommunityUser{Addr: u.Addr, Community_id: u.Community_id, User_type: "author"}
+			if err := author.Remove(tx); err != nil {
+				return err
+			}
+		}
+		return u.Remove(tx)
+	})
+	if errors.Is(err, models.ErrSignatureReused) {
+		return http.StatusForbidden, err
+	} else if err != nil {
+		log.Error().Err(err)
+		return http.StatusInternalServerError, err
+	}
+
+	h.recordMembershipEvent(u, models.EventRoleRemoved)
+
+	return http.StatusOK, nil
+}
+
+func (h *Helpers) createCommunityUser(payload models.CommunityUserPayload) (int, error) {
+	// validate community_user payload fields
+	validate := newValidator()
 	vErr := validate.Struct(payload)
 	if vErr != nil {
 		errMsg := "Invalid community user."
 		log.Error().Err(vErr).Msg(errMsg)
 		return http.StatusBadRequest, errors.New(errMsg)
 	}
-	// validate user is allowed to create this user
-	if payload.User_type != "member" {
-		if payload.Signing_addr == payload.Addr {
-			CANNOT_GRANT_SELF_ERR := errors.New("Users cannot grant themselves a privileged user_type.")
-			log.Error().Err(CANNOT_GRANT_SELF_ERR)
-			return http.StatusForbidden, CANNOT_GRANT_SELF_ERR
+	// validate user is allowed to create this user
+	if payload.User_type != "member" {
+		if payload.Signing_addr == payload.Addr {
+			CANNOT_GRANT_SELF_ERR := errors.New("Users cannot grant themselves a privileged user_type.")
+			log.Error().Err(CANNOT_GRANT_SELF_ERR)
+			return http.StatusForbidden, CANNOT_GRANT_SELF_ERR
+		}
+		// If signing address is not user address, verify they can manage members in this community.
+		// Only admins can grant the admin role.
+		if err := h.ensureCanManageRole(payload.Signing_addr, payload.Community_id, payload.User_type); err != nil {
+			USER_MUST_BE_ADMIN_ERR := errors.New("User must be community admin to grant privileges.")
+			log.Error().Err(err).Msg("Database error.")
+			log.Error().Err(USER_MUST_BE_ADMIN_ERR)
+			return http.StatusForbidden, USER_MUST_BE_ADMIN_ERR
+		}
+	}
+	// only an account can add itself as a "member", unless an admin is granting
+	// an address a privileged role
+	if payload.User_type == "member" && payload.Addr != payload.Signing_addr {
+		CANNOT_ADD_MEMBER_ERR := errors.New(
+			"An account can only add itself as a community member, unless an admin is granting privileged role.",
+		)
+		log.Error().Err(CANNOT_ADD_MEMBER_ERR)
+		return http.StatusForbidden, CANNOT_ADD_MEMBER_ERR
+	}
+
+	if errResponse := h.ensureNotBanned(payload.Community_id, payload.Addr); errResponse != nilErr {
+		return errResponse.StatusCode, errors.New(errResponse.Details)
+	}
+
+	// private communities only accept new members through join requests
+	if payload.User_type == "member" {
+		community, err := h.fetchCommunity(payload.Community_id)
+		if err != nil {
+			return http.StatusNotFound, err
+		}
+		if community.Is_private {
+			JOIN_REQUEST_REQUIRED_ERR := errors.New("Community is private, a join request is required.")
+			log.Error().Err(JOIN_REQUEST_REQUIRED_ERR)
+			return http.StatusForbidden, JOIN_REQUEST_REQUIRED_ERR
+		}
+	}
+
+	if payload.Voucher != nil {
+		if err := h.validateUserViaVoucher(payload.Signing_addr, payload.Voucher); err != nil {
+			log.Error().Err(err)
+			return http.StatusForbidden, err
+		}
+	} else {
+		if err := h.validateUser(payload.Signing_addr, payload.Timestamp, payload.Composite_signatures); err != nil {
+			log.Error().Err(err)
+			return http.StatusForbidden, err
+		}
+	}
+
+	// check that community user doesnt already exist
+	// should throw a "ErrNoRows" error
+	u := payload.CommunityUser
+	if err := u.GetCommunityUser(h.A.DB); err == nil {
+		errMsg := fmt.Sprintf("Error: Address %s is already a %s of community %d.\n", u.Addr, u.User_type, u.Community_id)
+		log.Error().Err(err).Msg(errMsg)
+		return http.StatusBadRequest, errors.New(errMsg)
+	}
+	if err := h.checkPlanMember(u.Community_id, u.Addr); err != nil {
+		return planLimitStatus(err), err
+	}
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return grantCommunityRole(tx, u)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return http.StatusForbidden, err
+	} else if err != nil {
+		log.Error().Err(err)
+		return http.StatusInternalServerError, err
+	}
+	h.recordMembershipEvent(u, models.EventRoleGranted)
+
+	return http.StatusCreated, nil
+}
+
+func grantCommunityRole(db *shared.Database, u models.CommunityUser) error {
+	switch u.User_type {
+	case "admin":
+		return models.GrantAdminRolesToAddress(db, u.Community_id, u.Addr)
+	case "author":
+		return models.GrantAuthorRolesToAddress(db, u.Community_id, u.Addr)
+	default:
+		return u.CreateCommunityUser(db)
+	}
+}
+
+func (h *Helpers) createJoinRequest(
+	communityId int,
+	payload models.JoinRequestPayload,
+) (models.JoinRequest, int, error) {
+	validate := newValidator()
+	if vErr := validate.Struct(payload); vErr != nil {
+		errMsg := "Invalid join request."
+		log.Error().Err(vErr).Msg(errMsg)
+		return models.JoinRequest{}, http.StatusBadRequest, errors.New(errMsg)
+	}
+
+	if payload.Addr != payload.Signing_addr {
+		return models.JoinRequest{}, http.StatusForbidden, errors.New("An account can only request to join for itself.")
+	}
+
+	if payload.Voucher != nil {
+		if err := h.validateUserViaVoucher(payload.Signing_addr, payload.Voucher); err != nil {
+			return models.JoinRequest{}, http.StatusForbidden, err
+		}
+	} else {
+		if err := h.validateUser(payload.Signing_addr, payload.Timestamp, payload.Composite_signatures); err != nil {
+			return models.JoinRequest{}, http.StatusForbidden, err
+		}
+	}
+
+	community, err := h.fetchCommunity(communityId)
+	if err != nil {
+		return models.JoinRequest{}, http.StatusNotFound, err
+	}
+	if !community.Is_private {
+		return models.JoinRequest{}, http.StatusBadRequest, errors.New("Community is public, join requests are not required.")
+	}
+	if errResponse := h.ensureNotBanned(communityId, payload.Addr); errResponse != nilErr {
+		return models.JoinRequest{}, errResponse.StatusCode, errors.New(errResponse.Details)
+	}
+
+	if err := models.EnsureRoleForCommunity(h.A.DB, payload.Addr, communityId, "member"); err == nil {
+		errMsg := fmt.Sprintf("Address %s is already a member of community %d.", payload.Addr, communityId)
+		return models.JoinRequest{}, http.StatusBadRequest, errors.New(errMsg)
+	}
+
+	jr := models.JoinRequest{
+		Community_id:         communityId,
+		Addr:                 payload.Addr,
+		Message:              payload.Message,
+		Composite_signatures: payload.Composite_signatures,
+	}
+	if err := jr.GetPendingJoinRequest(h.A.DB); err == nil {
+		errMsg := fmt.Sprintf("Address %s already has a pending join request.", payload.Addr)
+		return models.JoinRequest{}, http.StatusBadRequest, errors.New(errMsg)
+	}
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return jr.CreateJoinRequest(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.JoinRequest{}, http.StatusForbidden, err
+	} else if err != nil {
+		return models.JoinRequest{}, http.StatusInternalServerError, err
+	}
+
+	h.onJoinRequestChange(jr)
+
+	return jr, http.StatusCreated, nil
+}
+
+func (h *Helpers) reviewJoinRequest(
+	communityId int,
+	requestId int,
+	payload models.ReviewJoinRequestPayload,
+	status string,
+) (models.JoinRequest, int, error) {
+	jr := models.JoinRequest{ID: requestId}
+	if err := jr.GetJoinRequestById(h.A.DB); err != nil || jr.Community_id != communityId {
+		return models.JoinRequest{}, http.StatusNotFound, errors.New("Join request not found.")
+	}
+
+	if jr.Status != models.JoinRequestPending {
+		return models.JoinRequest{}, http.StatusBadRequest, errors.New("Join request has already been reviewed.")
+	}
+
+	if err := h.validateCommunityPermission(
+		communityId,
+		payload.TimestampSignaturePayload,
+		payload.Voucher,
+		models.PermManageMembers,
+	); err != nil {
+		return models.JoinRequest{}, http.StatusForbidden, err
+	}
+	member := models.CommunityUser{Community_id: communityId, Addr: jr.Addr, User_type: "member"}
+	if status == models.JoinRequestApproved {
+		// the address may have been banned since it asked to join
+		if errResponse := h.ensureNotBanned(communityId, jr.Addr); errResponse != nilErr {
+			return models.JoinRequest{}, errResponse.StatusCode, errors.New(errResponse.Details)
+		}
+		if err := h.checkPlanMember(communityId, jr.Addr); err != nil {
+			return models.JoinRequest{}, planLimitStatus(err), err
+		}
+	}
+
+	// an approved request and the new membership are stored together
+	granted := false
+	err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		if err := jr.ReviewJoinRequest(tx, status, payload.Signing_addr, payload.Reason); err != nil {
+			return err
+		}
+
+		if status == models.JoinRequestApproved {
+			if err := member.GetCommunityUser(tx); err != nil {
+				granted = true
+				return member.CreateCommunityUser(tx)
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, models.ErrSignatureReused) {
+		return models.JoinRequest{}, http.StatusForbidden, err
+	} else if err != nil {
+		return models.JoinRequest{}, http.StatusInternalServerError, err
+	}
+
+	if granted {
+		h.recordMembershipEvent(member, models.EventRoleGranted)
+	}
+	h.onJoinRequestChange(jr)
+
+	return jr, http.StatusOK, nil
+}
+
+func (h *Helpers) createInvite(
+	communityId int,
+	payload models.CreateInvitePayload,
+) (models.InviteWithToken, int, error) {
+	validate := newValidator()
+	if vErr := validate.Struct(payload); vErr != nil {
+		errMsg := "Invalid invite."
+		log.Error().Err(vErr).Msg(errMsg)
+		return models.InviteWithToken{}, http.StatusBadRequest, errors.New(errMsg)
+	}
+
+	if err := h.validateCommunityPermission(
+		communityId,
+		payload.TimestampSignaturePayload,
+		payload.Voucher,
+		models.PermManageMembers,
+	); err != nil {
+		return models.InviteWithToken{}, http.StatusForbidden, err
+	}
+	if err := h.ensureCanManageRole(payload.Signing_addr, communityId, payload.User_type); err != nil {
+		return models.InviteWithToken{}, http.StatusForbidden, errors.New("Only admins can invite admins.")
+	}
+
+	expiry := defaultInviteExpiry
+	if payload.Expires_in_hours > 0 {
+		expiry = time.Duration(payload.Expires_in_hours) * time.Hour
+	}
+	// invites are single use unless made unlimited with an explicit 0
+	maxUses := defaultInviteMaxUses
+	if payload.Max_uses != nil {
+		maxUses = *payload.Max_uses
+	}
+
+	invite := models.Invite{
+		Community_id: communityId,
+		User_type:    payload.User_type,
+		Addr:         payload.Addr,
+		Max_uses:     maxUses,
+		Created_by:   payload.Signing_addr,
+		Expires_at:   time.Now().UTC().Add(expiry),
+	}
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return invite.CreateInvite(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.InviteWithToken{}, http.StatusForbidden, err
+	} else if err != nil {
+		return models.InviteWithToken{}, http.StatusInternalServerError, err
+	}
+
+	claims := models.InviteClaims{
+		TokenClaims: shared.TokenClaims{
+			Audience:  "invite",
+			ExpiresAt: invite.Expires_at.Unix(),
+			IssuedAt:  time.Now().Unix(),
+		},
+		Invite_id:    invite.ID,
+		Community_id: communityId,
+		User_type:    invite.User_type,
+	}
+	if invite.Addr != nil {
+		claims.Subject = *invite.Addr
+	}
+
+	token, err := h.A.TokenSigner.Sign(claims)
+	if err != nil {
+		return models.InviteWithToken{}, http.StatusInternalServerError, err
+	}
+
+	return models.InviteWithToken{Invite: invite, Token: token}, http.StatusCreated, nil
+}
+
+func (h *Helpers) revokeInvite(
+	communityId int,
+	inviteId int,
+	payload models.RevokeInvitePayload,
+) (models.Invite, int, error) {
+	invite := models.Invite{ID: inviteId}
+	if err := invite.GetInviteById(h.A.DB); err != nil || invite.Community_id != communityId {
+		return models.Invite{}, http.StatusNotFound, errors.New("Invite not found.")
+	}
+
+	if err := h.validateCommunityPermission(
+		communityId,
+		payload.TimestampSignaturePayload,
+		payload.Voucher,
+		models.PermManageMembers,
+	); err != nil {
+		return models.Invite{}, http.StatusForbidden, err
+	}
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return invite.RevokeInvite(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.Invite{}, http.StatusForbidden, err
+	} else if err != nil {
+		return models.Invite{}, http.StatusBadRequest, errors.New("Invite has already been revoked.")
+	}
+
+	return invite, http.StatusOK, nil
+}
+
+func (h *Helpers) redeemInvite(payload models.RedeemInvitePayload) (models.CommunityUser, int, error) {
+	validate := newValidator()
+	if vErr := validate.Struct(payload); vErr != nil {
+		errMsg := "Invalid invite redemption."
+		log.Error().Err(vErr).Msg(errMsg)
+		return models.CommunityUser{}, http.StatusBadRequest, errors.New(errMsg)
+	}
+
+	var claims models.InviteClaims
+	if err := h.A.TokenSigner.Verify(payload.Token, &claims); err != nil || claims.Audience != "invite" {
+		return models.CommunityUser{}, http.StatusForbidden, errors.New("Invalid or expired invite.")
+	}
+
+	if payload.Addr != payload.Signing_addr {
+		return models.CommunityUser{}, http.StatusForbidden, errors.New("An account can only redeem an invite for itself.")
+	}
+	if claims.Subject != "" && claims.Subject != payload.Addr {
+		return models.CommunityUser{}, http.StatusForbidden, errors.New("Invite was issued to a different address.")
+	}
+
+	if payload.Voucher != nil {
+		if err := h.validateUserViaVoucher(payload.Signing_addr, payload.Voucher); err != nil {
+			return models.CommunityUser{}, http.StatusForbidden, err
+		}
+	} else {
+		if err := h.validateUser(payload.Signing_addr, payload.Timestamp, payload.Composite_signatures); err != nil {
+			return models.CommunityUser{}, http.StatusForbidden, err
+		}
+	}
+
+	invite := models.Invite{ID: claims.Invite_id}
+	if err := invite.GetInviteById(h.A.DB); err != nil || invite.Community_id != claims.Community_id {
+		return models.CommunityUser{}, http.StatusNotFound, errors.New("Invite not found.")
+	}
+
+	if errResponse := h.ensureNotBanned(invite.Community_id, payload.Addr); errResponse != nilErr {
+		return models.CommunityUser{}, errResponse.StatusCode, errors.New(errResponse.Details)
+	}
+
+	u := models.CommunityUser{Community_id: invite.Community_id, Addr: payload.Addr, User_type: invite.User_type}
+	if err := u.GetCommunityUser(h.A.DB); err == nil {
+		errMsg := fmt.Sprintf("Address %s is already a %s of community %d.", u.Addr, u.User_type, u.Community_id)
+		return models.CommunityUser{}, http.StatusBadRequest, errors.New(errMsg)
+	}
+
+	if !invite.IsUsable() {
+		return models.CommunityUser{}, http.StatusForbidden, errors.New("Invite is no longer valid.")
+	}
+	if err := h.checkPlanMember(u.Community_id, u.Addr); err != nil {
+		return models.CommunityUser{}, planLimitStatus(err), err
+	}
+
+	// the invite is used up with the role it grants
+	status := http.StatusInternalServerError
+	err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		if err := invite.ConsumeInvite(tx); err != nil {
+			status = http.StatusForbidden
+			return errors.New("Invite is no longer valid.")
+		}
+		return grantCommunityRole(tx, u)
+	})
+	if errors.Is(err, models.ErrSignatureReused) {
+		return models.CommunityUser{}, http.StatusForbidden, err
+	} else if err != nil {
+		return models.CommunityUser{}, status, err
+	}
+	h.recordMembershipEvent(u, models.EventRoleGranted)
+
+	return u, http.StatusCreated, nil
+}
+
+func (h *Helpers) saveCommunityRole(
+	communityId int,
+	roleId int,
+	payload models.CommunityRolePayload,
+) (models.CommunityRole, int, error) {
+	validate := newValidator()
+	if vErr := validate.Struct(payload); vErr != nil {
+		errMsg := "Invalid community role."
+		log.Error().Err(vErr).Msg(errMsg)
+		return models.CommunityRole{}, http.StatusBadRequest, errors.New(errMsg)
+	}
+	if err := models.EnsureValidPermissions(payload.Permissions); err != nil {
+		return models.CommunityRole{}, http.StatusBadRequest, err
+	}
+	if models.EnsureValidRole(payload.Name) {
+		return models.CommunityRole{}, http.StatusBadRequest, errors.New("Custom roles cannot reuse a built-in role name.")
+	}
+
+	if err := h.validateCommunityAdmin(communityId, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+		return models.CommunityRole{}, http.StatusForbidden, err
+	}
+
+	role := models.CommunityRole{ID: roleId, Community_id: communityId}
+	if roleId != 0 {
+		if err := role.GetRoleById(h.A.DB); err != nil || role.Community_id != communityId {
+			return models.CommunityRole{}, http.StatusNotFound, errors.New("Role not found.")
+		}
+	}
+	role.Name = payload.Name
+	role.Permissions = payload.Permissions
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		if roleId == 0 {
+			return role.CreateRole(tx)
+		}
+		return role.UpdateRole(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.CommunityRole{}, http.StatusForbidden, err
+	} else if err != nil {
+		return models.CommunityRole{}, http.StatusInternalServerError, err
+	}
+
+	if roleId == 0 {
+		return role, http.StatusCreated, nil
+	}
+	return role, http.StatusOK, nil
+}
+
+func (h *Helpers) deleteCommunityRole(
+	communityId int,
+	roleId int,
+	payload models.CommunityRoleDeletePayload,
+) (int, error) {
+	role := models.CommunityRole{ID: roleId}
+	if err := role.GetRoleById(h.A.DB); err != nil || role.Community_id != communityId {
+		return http.StatusNotFound, errors.New("Role not found.")
+	}
+
+	if err := h.validateCommunityAdmin(communityId, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+		return http.StatusForbidden, err
+	}
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return role.DeleteRole(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return http.StatusForbidden, err
+	} else if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (h *Helpers) setCommunityRoleMember(
+	communityId int,
+	roleId int,
+	payload models.CommunityRoleMemberPayload,
+	assign bool,
+) (int, error) {
+	validate := newValidator()
+	if vErr := validate.Struct(payload); vErr != nil {
+		errMsg := "Invalid role assignment."
+		log.Error().Err(vErr).Msg(errMsg)
+		return http.StatusBadRequest, errors.New(errMsg)
+	}
+
+	role := models.CommunityRole{ID: roleId}
+	if err := role.GetRoleById(h.A.DB); err != nil || role.Community_id != communityId {
+		return http.StatusNotFound, errors.New("Role not found.")
+	}
+
+	if err := h.validateCommunityAdmin(communityId, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+		return http.StatusForbidden, err
+	}
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		if assign {
+			return role.AssignRole(tx, payload.Addr)
+		}
+		return role.UnassignRole(tx, payload.Addr)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return http.StatusForbidden, err
+	} else if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (h *Helpers) ensureNotBanned(communityId int, addr string) errorResponse {
+	banned, err := models.IsAddressBanned(h.A.DB, communityId, addr)
+	if err != nil {
+		log.Error().Err(err).Msg("Error checking community bans.")
+		return errIncompleteRequest
+	}
+	if banned {
+		log.Error().Msgf("address %s is banned from community %d", addr, communityId)
+		return errBannedAddress
+	}
+	return nilErr
+}
+
+func (h *Helpers) banAddress(communityId int, payload models.CommunityBanPayload) (models.CommunityBan, int, error) {
+	validate := newValidator()
+	if vErr := validate.Struct(payload); vErr != nil {
+		errMsg := "Invalid ban."
+		log.Error().Err(vErr).Msg(errMsg)
+		return models.CommunityBan{}, http.StatusBadRequest, errors.New(errMsg)
+	}
+
+	if err := h.validateCommunityPermission(
+		communityId,
+		payload.TimestampSignaturePayload,
+		payload.Voucher,
+		models.PermManageMembers,
+	); err != nil {
+		return models.CommunityBan{}, http.StatusForbidden, err
+	}
+
+	if err := models.EnsureRoleForCommunity(h.A.DB, payload.Addr, communityId, "admin"); err == nil {
+		return models.CommunityBan{}, http.StatusBadRequest, errors.New("Community admins cannot be banned.")
+	}
+
+	ban := models.CommunityBan{
+		Community_id: communityId,
+		Addr:         payload.Addr,
+		Reason:       payload.Reason,
+		Banned_by:    payload.Signing_addr,
+	}
+	// banned addresses lose their roles in the community
+	var roles []models.CommunityUser
+	err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		if err := ban.CreateBan(tx); err != nil {
+			return err
+		}
+
+		var err error
+		roles, err = models.GetAllRolesForUserInCommunity(tx, payload.Addr, communityId)
+		if err != nil {
+			return err
+		}
+		for _, role := range roles {
+			if err := role.Remove(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, models.ErrSignatureReused) {
+		return models.CommunityBan{}, http.StatusForbidden, err
+	} else if err != nil {
+		return models.CommunityBan{}, http.StatusInternalServerError, err
+	}
+
+	for _, role := range roles {
+		h.recordMembershipEvent(role, models.EventRoleRemoved)
+	}
+
+	return ban, http.StatusCreated, nil
+}
+
+func (h *Helpers) unbanAddress(communityId int, payload models.CommunityBanPayload) (int, error) {
+	if err := h.validateCommunityPermission(
+		communityId,
+		payload.TimestampSignaturePayload,
+		payload.Voucher,
+		models.PermManageMembers,
+	); err != nil {
+		return http.StatusForbidden, err
+	}
+
+	ban := models.CommunityBan{Community_id: communityId, Addr: payload.Addr}
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return ban.RemoveBan(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return http.StatusForbidden, err
+	} else if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (h *Helpers) getTagCountsForResults(results []*models.Community) (map[string]int, error) {
+	ids := make([]int, 0, len(results))
+	for _, c := range results {
+		ids = append(ids, c.ID)
+	}
+	return models.GetTagCountsForCommunities(h.A.DB, ids)
+}
+
+func (h *Helpers) createCommunityTag(
+	communityId int,
+	payload models.CommunityTagPayload,
+) (models.CommunityTag, int, error) {
+	validate := newValidator()
+	if vErr := validate.Struct(payload); vErr != nil {
+		errMsg := "Invalid tag."
+		log.Error().Err(vErr).Msg(errMsg)
+		return models.CommunityTag{}, http.StatusBadRequest, errors.New(errMsg)
+	}
+
+	if err := h.validateCommunityAdmin(communityId, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+		return models.CommunityTag{}, http.StatusForbidden, err
+	}
+
+	tag := models.CommunityTag{Community_id: communityId, Name: strings.ToLower(strings.TrimSpace(payload.Name))}
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return tag.CreateTag(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.CommunityTag{}, http.StatusForbidden, err
+	} else if err != nil {
+		errMsg := fmt.Sprintf("Tag %s already exists for community %d.", tag.Name, communityId)
+		log.Error().Err(err).Msg(errMsg)
+		return models.CommunityTag{}, http.StatusBadRequest, errors.New(errMsg)
+	}
+
+	return tag, http.StatusCreated, nil
+}
+
+func (h *Helpers) deleteCommunityTag(communityId, tagId int, payload models.CommunityTagPayload) (int, error) {
+	tag := models.CommunityTag{ID: tagId}
+	if err := tag.GetTagById(h.A.DB); err != nil || tag.Community_id != communityId {
+		return http.StatusNotFound, errors.New("Tag not found.")
+	}
+
+	if err := h.validateCommunityAdmin(communityId, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+		return http.StatusForbidden, err
+	}
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return tag.DeleteTag(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return http.StatusForbidden, err
+	} else if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (h *Helpers) addProposalCohost(
+	proposalId int,
+	payload models.ProposalCohostPayload,
+) (models.ProposalCohost, int, error) {
+	validate := newValidator()
+	if vErr := validate.Struct(payload); vErr != nil {
+		return models.ProposalCohost{}, http.StatusBadRequest, vErr
+	}
+
+	p := models.Proposal{ID: proposalId}
+	if err := p.GetProposalById(h.A.DB); err != nil {
+		return models.ProposalCohost{}, http.StatusNotFound, fmt.Errorf("Proposal with ID %d not found.", proposalId)
+	}
+	if p.Community_id == payload.Community_id {
+		return models.ProposalCohost{}, http.StatusBadRequest, errors.New("Proposal is already hosted by this community.")
+	}
+	if p.Computed_status != nil {
+		switch *p.Computed_status {
+		case "closed", "cancelled", models.ProposalRejected:
+			return models.ProposalCohost{}, http.StatusBadRequest, errors.New("Proposal can no longer be co-hosted.")
+		}
+	}
+
+	c, err := h.fetchCommunity(payload.Community_id)
+	if err != nil {
+		return models.ProposalCohost{}, http.StatusNotFound, err
+	}
+	if c.Is_archived {
+		return models.ProposalCohost{}, http.StatusBadRequest, errors.New("Archived communities can not co-host proposals.")
+	}
+	if _, err := c.GetStrategy(payload.Strategy); err != nil || h.initStrategy(payload.Strategy) == nil {
+		return models.ProposalCohost{}, http.StatusBadRequest, fmt.Errorf("Strategy %s is not available to community %d.", payload.Strategy, c.ID)
+	}
+
+	// a community co-hosts a proposal at its own admins' request
+	if err := h.validateCommunityAdmin(c.ID, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+		return models.ProposalCohost{}, http.StatusForbidden, err
+	}
+
+	cohost := models.ProposalCohost{Proposal_id: p.ID, Community_id: c.ID, Strategy: payload.Strategy}
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return cohost.CreateCohost(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.ProposalCohost{}, http.StatusForbidden, err
+	} else if err != nil {
+		errMsg := fmt.Sprintf("Community %d already co-hosts proposal %d.", c.ID, p.ID)
+		log.Error().Err(err).Msg(errMsg)
+		return models.ProposalCohost{}, http.StatusBadRequest, errors.New(errMsg)
+	}
+	return cohost, http.StatusCreated, nil
+}
+
+// removeProposalCohost ends a co-hosting, at the request of admins of
+// either the co-host or the community that created the proposal.
+func (h *Helpers) removeProposalCohost(
+	proposalId, communityId int,
+	payload models.ProposalCohostPayload,
+) (int, error) {
+	p := models.Proposal{ID: proposalId}
+	if err := p.GetProposalById(h.A.DB); err != nil {
+		return http.StatusNotFound, fmt.Errorf("Proposal with ID %d not found.", proposalId)
+	}
+	cohost := models.ProposalCohost{Proposal_id: proposalId, Community_id: communityId}
+	if err := cohost.GetCohost(h.A.DB); err != nil {
+		return http.StatusNotFound, fmt.Errorf("Community %d does not co-host proposal %d.", communityId, proposalId)
+	}
+
+	if err := h.validateCommunityAdmin(communityId, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+		if err := h.validateCommunityAdmin(p.Community_id, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+			return http.StatusForbidden, err
+		}
+	}
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return cohost.DeleteCohost(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return http.StatusForbidden, err
+	} else if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// getExecutionRecord returns the execution payload of a proposal that
+// passed with its choice, signed along with the pinned results that prove
+// it. Proposals are final once they are closed and both they and their
+// results are pinned.
+func (h *Helpers) getExecutionRecord(p models.Proposal) (*shared.SignedReceipt, int, error) {
+	payload, err := models.GetExecutionPayload(h.A.DB, p.ID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	if payload == nil {
+		return nil, http.StatusNotFound, fmt.Errorf("Proposal %d has no execution payload.", p.ID)
+	}
+
+	results := models.ProposalResults{Proposal_id: p.ID}
+	if p.Status == nil || *p.Status != models.ProposalClosed || p.Cid == nil ||
+		results.GetLatestProposalResultsById(h.A.DB) != nil || results.Cid == nil {
+		return nil, http.StatusConflict, fmt.Errorf("Proposal %d is not finalized yet.", p.ID)
+	}
+	if !results.PassedWith(p, payload.Choice) {
+		return nil, http.StatusConflict, fmt.Errorf("Proposal %d did not pass with %s.", p.ID, payload.Choice)
+	}
+
+	record := models.ExecutionRecord{
+		Proposal_id:   p.ID,
+		Community_id:  p.Community_id,
+		Choice:        payload.Choice,
+		Cadence:       payload.Cadence,
+		Arguments:     payload.Arguments,
+		Results:       results.Results,
+		Results_float: results.Results_float,
+		Proposal_cid:  *p.Cid,
+		Results_cid:   *results.Cid,
+		Block_height:  p.Block_height,
+		Closed_at:     p.Closed_at,
+		Issued_at:     time.Now().UTC(),
+	}
+	receipt, err := h.A.ReceiptSigner.Sign(record)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return receipt, http.StatusOK, nil
+}
+
+// getCohostedResults reports the results as each host weighs them: the
+// creating community by the proposal's stored results, co-hosts by
+// counting the votes again with their strategies.
+func (h *Helpers) getCohostedResults(p models.Proposal) (models.CohostedResults, error) {
+	report := models.CohostedResults{Proposal_id: p.ID}
+
+	results, err := h.fetchProposalResults(p)
+	if err != nil {
+		return report, err
+	}
+	report.Communities = append(report.Communities, &models.CommunityResults{
+		Community_id:  p.Community_id,
+		Strategy:      *p.Strategy,
+		Results:       results.Results,
+		Results_float: results.Results_float,
+	})
+
+	cohosts, err := models.GetCohostsForProposal(h.A.DB, p.ID)
+	if err != nil {
+		return report, err
+	}
+	for _, cohost := range cohosts {
+		results, err := h.useStrategyTallyAs(p, cohost.Strategy)
+		if err != nil {
+			return report, err
+		}
+		report.Communities = append(report.Communities, &models.CommunityResults{
+			Community_id:  cohost.Community_id,
+			Strategy:      cohost.Strategy,
+			Results:       results.Results,
+			Results_float: results.Results_float,
+		})
+	}
+
+	report.Combine(p.Choices)
+	return report, nil
+}
+
+func (h *Helpers) createTreasury(communityId int, payload models.TreasuryPayload) (models.Treasury, int, error) {
+	validate := newValidator()
+	if vErr := validate.Struct(payload); vErr != nil {
+		return models.Treasury{}, http.StatusBadRequest, vErr
+	}
+
+	if err := h.validateCommunityAdmin(communityId, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+		return models.Treasury{}, http.StatusForbidden, err
+	}
+
+	treasuries, err := models.GetTreasuriesForCommunity(h.A.DB, communityId)
+	if err != nil {
+		return models.Treasury{}, http.StatusInternalServerError, err
+	}
+	if len(treasuries) >= maxCommunityTreasuries {
+		return models.Treasury{}, http.StatusBadRequest,
+			fmt.Errorf("Communities can have at most %d treasuries.", maxCommunityTreasuries)
+	}
+
+	treasury := payload.Treasury
+	treasury.Community_id = communityId
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return treasury.CreateTreasury(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.Treasury{}, http.StatusForbidden, err
+	} else if err != nil {
+		errMsg := fmt.Sprintf("Treasury %s already holds %s for community %d.", treasury.Addr, treasury.Contract_name, communityId)
+		log.Error().Err(err).Msg(errMsg)
+		return models.Treasury{}, http.StatusBadRequest, errors.New(errMsg)
+	}
+	return treasury, http.StatusCreated, nil
+}
+
+func (h *Helpers) deleteTreasury(communityId, treasuryId int, payload models.TreasuryPayload) (int, error) {
+	treasury := models.Treasury{ID: treasuryId}
+	if err := treasury.GetTreasuryById(h.A.DB); err != nil || treasury.Community_id != communityId {
+		return http.StatusNotFound, errors.New("Treasury not found.")
+	}
+
+	if err := h.validateCommunityAdmin(communityId, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+		return http.StatusForbidden, err
+	}
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return treasury.DeleteTreasury(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return http.StatusForbidden, err
+	} else if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (h *Helpers) registerCommunityToken(communityId int, payload models.CommunityTokenPayload) (models.CommunityToken, int, error) {
+	validate := newValidator()
+	if vErr := validate.Struct(payload); vErr != nil {
+		return models.CommunityToken{}, http.StatusBadRequest, vErr
+	}
+	if err := payload.ValidateIdentifiers(); err != nil {
+		return models.CommunityToken{}, http.StatusBadRequest, err
+	}
+
+	if err := h.validateCommunityAdmin(communityId, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+		return models.CommunityToken{}, http.StatusForbidden, err
+	}
+
+	token := payload.CommunityToken
+	contract := token.Contract()
+	if err := h.A.FlowAdapter.ValidateFungibleToken(&contract, token.Vault_path, token.Receiver_path); err != nil {
+		return models.CommunityToken{}, http.StatusBadRequest, err
+	}
+
+	token.Community_id = communityId
+	token.Created_by = payload.Signing_addr
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return token.CreateCommunityToken(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.CommunityToken{}, http.StatusForbidden, err
+	} else if err != nil {
+		errMsg := fmt.Sprintf("Token %s at %s is already registered for community %d.", token.Contract_name, token.Contract_addr, communityId)
+		log.Error().Err(err).Msg(errMsg)
+		return models.CommunityToken{}, http.StatusBadRequest, errors.New(errMsg)
+	}
+	return token, http.StatusCreated, nil
+}
+
+func (h *Helpers) deleteCommunityToken(communityId, tokenId int, payload models.CommunityTokenPayload) (int, error) {
+	token := models.CommunityToken{ID: tokenId}
+	if err := token.GetCommunityTokenById(h.A.DB); err != nil || token.Community_id != communityId {
+		return http.StatusNotFound, errors.New("Token not found.")
+	}
+
+	if err := h.validateCommunityAdmin(communityId, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+		return http.StatusForbidden, err
+	}
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return token.DeleteCommunityToken(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return http.StatusForbidden, err
+	} else if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// tokenMetadata looks the token up in the token list, then among those the
+// community registered. It returns nil for tokens in neither.
+func (h *Helpers) tokenMetadata(communityId int, name, addr string) (*shared.TokenMetadata, error) {
+	if token, ok := h.A.TokenList.Lookup(name, addr); ok {
+		return &token, nil
+	}
+	registered, err := models.GetCommunityTokenByContract(h.A.DB, communityId, name, addr)
+	if err != nil || registered == nil {
+		return nil, err
+	}
+	token := registered.Metadata()
+	return &token, nil
+}
+
+// listTokens lists the tokens of the token list, and when a community is
+// given, those it registered.
+func (h *Helpers) listTokens(communityId *int) ([]shared.TokenMetadata, error) {
+	tokens := h.A.TokenList.All()
+	if communityId == nil {
+		return tokens, nil
+	}
+
+	registered, err := models.GetTokensForCommunity(h.A.DB, *communityId)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range registered {
+		if _, listed := h.A.TokenList.Lookup(t.Contract_name, t.Contract_addr); !listed {
+			tokens = append(tokens, t.Metadata())
+		}
+	}
+	return tokens, nil
+}
+
+// communityTokens returns the metadata of the tokens the community's token
+// strategies and proposal threshold use.
+func (h *Helpers) communityTokens(c models.Community) ([]shared.TokenMetadata, error) {
+	contracts := []shared.Contract{}
+	if c.Strategies != nil {
+		for _, strategy := range *c.Strategies {
+			if strategy.Name != nil && models.IsFungibleTokenStrategy(*strategy.Name) {
+				contracts = append(contracts, strategy.Contract)
+			}
+		}
+	}
+	if c.Contract_name != nil && c.Contract_addr != nil {
+		contracts = append(contracts, shared.Contract{Name: c.Contract_name, Addr: c.Contract_addr})
+	}
+
+	tokens := []shared.TokenMetadata{}
+	seen := map[string]bool{}
+	for _, contract := range contracts {
+		if contract.Name == nil || contract.Addr == nil || seen[*contract.Addr+"."+*contract.Name] {
+			continue
+		}
+		seen[*contract.Addr+"."+*contract.Name] = true
+
+		token, err := h.tokenMetadata(c.ID, *contract.Name, *contract.Addr)
+		if err != nil {
+			return nil, err
+		}
+		if token != nil {
+			tokens = append(tokens, *token)
+		}
+	}
+	return tokens, nil
+}
+
+// errUnregisteredToken is returned for token strategies weighing votes
+// with a token the community did not register.
+var errUnregisteredToken = errors.New("Strategy uses a token that is not registered")
+
+// ensureRegisteredTokens rejects token strategies added or changed by the
+// update unless their token is known to the network or was registered,
+// and so validated, by the community. Strategies left as they are stay
+// usable.
+func (h *Helpers) ensureRegisteredTokens(c models.Community, updated []models.Strategy) error {
+	current := []models.Strategy{}
+	if c.Strategies != nil {
+		current = *c.Strategies
+	}
+
+	for _, next := range updated {
+		if next.Name == nil || !models.IsFungibleTokenStrategy(*next.Name) {
+			continue
+		}
+		if prev, err := models.MatchStrategyByProposal(current, *next.Name); err == nil && reflect.DeepEqual(prev, next) {
+			continue
+		}
+		if next.Contract.Name != nil && next.Addr != nil {
+			if _, listed := h.A.TokenList.Lookup(*next.Contract.Name, *next.Addr); listed ||
+				h.A.FlowAdapter.IsKnownToken(*next.Contract.Name, *next.Addr) {
+				continue
+			}
+		}
+
+		registered, err := models.IsRegisteredToken(h.A.DB, c.ID, next.Contract)
+		if err != nil {
+			return err
+		}
+		if !registered {
+			return fmt.Errorf("%w: %s", errUnregisteredToken, *next.Name)
+		}
+	}
+	return nil
+}
+
+// errApprovalsNeeded is returned for sensitive changes made directly to
+// communities that require them to be approved by several admins.
+var errApprovalsNeeded = errors.New("Community requires this action to be approved by several admins.")
+
+// proposeCommunityAction records a sensitive action along with the approval
+// of the admin proposing it. Communities requiring a single approval have
+// it applied right away.
+func (h *Helpers) proposeCommunityAction(
+	communityId int,
+	payload models.PendingActionPayload,
+) (models.PendingAction, int, error) {
+	validate := newValidator()
+	if vErr := validate.Struct(payload); vErr != nil {
+		return models.PendingAction{}, http.StatusBadRequest, vErr
+	}
+
+	c, err := h.fetchCommunity(communityId)
+	if err != nil {
+		return models.PendingAction{}, http.StatusNotFound, errors.New("Community not found.")
+	}
+	if err := h.validateCommunityAdmin(c.ID, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+		return models.PendingAction{}, http.StatusForbidden, err
+	}
+
+	if len(payload.Payload) == 0 {
+		payload.Payload = json.RawMessage("{}")
+	}
+	action := models.PendingAction{
+		Community_id: c.ID,
+		Action:       payload.Action,
+		Payload:      payload.Payload,
+		Proposed_by:  payload.Signing_addr,
+		Expires_at:   time.Now().UTC().Add(pendingActionExpiry),
+	}
+	if err := validateCommunityAction(c, action); err != nil {
+		return models.PendingAction{}, http.StatusBadRequest, err
+	}
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return action.CreatePendingAction(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.PendingAction{}, http.StatusForbidden, err
+	} else if err != nil {
+		return models.PendingAction{}, http.StatusInternalServerError, err
+	}
+
+	if err := h.applyIfApproved(&c, &action); err != nil {
+		// an action that can't be applied right away never will be
+		action.Resolve(h.A.DB, models.ActionCancelled)
+		return models.PendingAction{}, http.StatusBadRequest, err
+	}
+	return action, http.StatusCreated, nil
+}
+
+func (h *Helpers) approveCommunityAction(
+	communityId, actionId int,
+	payload models.PendingActionApproval,
+) (models.PendingAction, int, error) {
+	action := models.PendingAction{ID: actionId}
+	if err := action.GetPendingAction(h.A.DB); err != nil || action.Community_id != communityId {
+		return models.PendingAction{}, http.StatusNotFound, errors.New("Action not found.")
+	}
+	c, err := h.fetchCommunity(communityId)
+	if err != nil {
+		return models.PendingAction{}, http.StatusNotFound, errors.New("Community not found.")
+	}
+
+	if err := h.validateCommunityAdmin(c.ID, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+		return models.PendingAction{}, http.StatusForbidden, err
+	}
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return action.Approve(tx, payload.Signing_addr)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.PendingAction{}, http.StatusForbidden, err
+	} else if errors.Is(err, models.ErrActionNotPending) {
+		return models.PendingAction{}, http.StatusConflict, err
+	} else if err != nil {
+		return models.PendingAction{}, http.StatusBadRequest, err
+	}
+
+	if err := h.applyIfApproved(&c, &action); errors.Is(err, models.ErrActionNotPending) {
+		return models.PendingAction{}, http.StatusConflict, err
+	} else if err != nil {
+		return models.PendingAction{}, http.StatusBadRequest, err
+	}
+	return action, http.StatusOK, nil
+}
+
+func (h *Helpers) cancelCommunityAction(
+	communityId, actionId int,
+	payload models.PendingActionApproval,
+) (models.PendingAction, int, error) {
+	action := models.PendingAction{ID: actionId}
+	if err := action.GetPendingAction(h.A.DB); err != nil || action.Community_id != communityId {
+		return models.PendingAction{}, http.StatusNotFound, errors.New("Action not found.")
+	}
+
+	if err := h.validateCommunityAdmin(communityId, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+		return models.PendingAction{}, http.StatusForbidden, err
+	}
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return action.Resolve(tx, models.ActionCancelled)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.PendingAction{}, http.StatusForbidden, err
+	} else if errors.Is(err, models.ErrActionNotPending) {
+		return models.PendingAction{}, http.StatusConflict, err
+	} else if err != nil {
+		return models.PendingAction{}, http.StatusInternalServerError, err
+	}
+	return action, http.StatusOK, nil
+}
+
+// validateCommunityAction checks an action can be applied to the community
+// as it is when proposed. Whatever may change until it is approved is
+// checked again when it is applied.
+func validateCommunityAction(c models.Community, a models.PendingAction) error {
+	validate := newValidator()
+	switch a.Action {
+	case models.ActionUpdateStrategies:
+		var update models.UpdateStrategiesAction
+		if err := json.Unmarshal(a.Payload, &update); err != nil {
+			return err
+		}
+		if err := validate.Struct(update); err != nil {
+			return err
+		}
+		return validateContractThreshold(update.Strategies)
+	case models.ActionPurgeMembers:
+		var purge models.PurgeMembersAction
+		if err := json.Unmarshal(a.Payload, &purge); err != nil {
+			return err
+		}
+		return validate.Struct(purge)
+	case models.ActionArchive:
+		if c.Is_archived {
+			return errors.New("Community is already archived.")
+		}
+	case models.ActionSetApprovalsRequired:
+		var set models.SetApprovalsRequiredAction
+		if err := json.Unmarshal(a.Payload, &set); err != nil {
+			return err
+		}
+		return validate.Struct(set)
+	}
+	return nil
+}
+
+// applyIfApproved applies the action once enough of the current admins
+// approved it. Approvals of those no longer admins don't count.
+func (h *Helpers) applyIfApproved(c *models.Community, a *models.PendingAction) error {
+	approvals, err := a.CountAdminApprovals(h.A.DB)
+	if err != nil {
+		return err
+	}
+	if approvals < c.Admin_approvals_required {
+		return nil
+	}
+
+	// checks that read through the pool run before the transaction is opened
+	var update models.UpdateStrategiesAction
+	if a.Action == models.ActionUpdateStrategies {
+		if err := json.Unmarshal(a.Payload, &update); err != nil {
+			return err
+		}
+		if err := h.ensureStrategiesUnchanged(*c, update.Strategies); err != nil {
+			return err
+		}
+		if err := h.ensureRegisteredTokens(*c, update.Strategies); err != nil {
+			return err
+		}
+		if err := h.checkPlanStrategies(*c, &update.Strategies); err != nil {
+			return err
+		}
+	}
+
+	return h.A.DB.WithTx(func(tx *shared.Database) error {
+		if err := a.Resolve(tx, models.ActionApplied); err != nil {
+			return err
+		}
+
+		switch a.Action {
+		case models.ActionUpdateStrategies:
+			return c.UpdateCommunity(tx, &models.UpdateCommunityRequestPayload{
+				Strategies: &update.Strategies,
+				Strategy:   update.Strategy,
+				// the version is recorded as made by the admin who proposed it
+				TimestampSignaturePayload: shared.TimestampSignaturePayload{Signing_addr: a.Proposed_by},
+			})
+		case models.ActionPurgeMembers:
+			var purge models.PurgeMembersAction
+			if err := json.Unmarshal(a.Payload, &purge); err != nil {
+				return err
+			}
+			if err := models.PurgeMembers(tx, c.ID, purge.Addresses); err != nil {
+				return err
+			}
+			admins, err := models.CountCommunityAdmins(tx, c.ID)
+			if err != nil {
+				return err
+			}
+			if admins < c.Admin_approvals_required {
+				return fmt.Errorf("Purging these members would leave fewer than the %d admins required to approve actions.", c.Admin_approvals_required)
+			}
+			return nil
+		case models.ActionArchive:
+			return c.ArchiveCommunity(tx)
+		case models.ActionSetApprovalsRequired:
+			var set models.SetApprovalsRequiredAction
+			if err := json.Unmarshal(a.Payload, &set); err != nil {
+				return err
+			}
+			admins, err := models.CountCommunityAdmins(tx, c.ID)
+			if err != nil {
+				return err
+			}
+			if set.Required > admins {
+				return fmt.Errorf("Community only has %d admins to approve actions.", admins)
+			}
+			return c.SetAdminApprovalsRequired(tx, set.Required)
+		}
+		return fmt.Errorf("Unknown action %s.", a.Action)
+	})
+}
+
+// recordEvent adds an entry to the community activity feed. Failing to
+// record an event never fails the request that triggered it.
+func (h *Helpers) recordEvent(e *models.CommunityEvent) {
+	if err := e.CreateEvent(h.A.DB); err != nil {
+		log.Error().Err(err).Msgf("Error recording %s event for community %d.", e.Event_type, e.Community_id)
+		return
+	}
+	// already recorded events are not announced twice
+	if e.ID != 0 {
+		h.notifyForEvent(e)
+	}
+}
+
+// notifyForEvent delivers feed events to the inboxes of interested addresses.
+func (h *Helpers) notifyForEvent(e *models.CommunityEvent) {
+	var err error
+	switch e.Event_type {
+	case models.EventProposalCreated:
+		h.notifyCommunityAudience(*e, models.NotificationProposalOpened)
+	case models.EventProposalClosed:
+		h.notifyCommunityAudience(*e, models.NotificationProposalClosed)
+	case models.EventVoteMilestone:
+		p := models.Proposal{ID: *e.Proposal_id}
+		if err = p.GetProposalById(h.A.DB); err == nil {
+			h.notify(models.Notification{
+				Addr:              p.Creator_addr,
+				Notification_type: models.NotificationProposalVotes,
+				Community_id:      &e.Community_id,
+				Proposal_id:       e.Proposal_id,
+				Event_id:          &e.ID,
+				Data:              e.Data,
+			})
+		}
+	}
+	if err != nil {
+		log.Error().Err(err).Msgf("Error sending notifications for event %d.", e.ID)
+	}
+}
+
+func (h *Helpers) notify(n models.Notification) {
+	if n.Community_id != nil {
+		if errResponse := h.checkUsageQuota(*n.Community_id, models.UsageNotifications, 1); errResponse != nilErr {
+			log.Warn().Msgf("Not sending %s notification to %s, %s", n.Notification_type, n.Addr, errResponse.Details)
+			return
+		}
+	}
+	if err := n.CreateNotification(h.A.DB); err != nil {
+		log.Error().Err(err).Msgf("Error sending %s notification to %s.", n.Notification_type, n.Addr)
+		return
+	}
+	// opted out recipients get nothing
+	if n.Community_id != nil && n.ID != 0 {
+		h.recordUsage(*n.Community_id, models.UsageNotifications, 1)
+	}
+}
+
+// notifyCommunityAudience fans an event out unless the community used its
+// notifications for the month.
+func (h *Helpers) notifyCommunityAudience(e models.CommunityEvent, notificationType string) {
+	if errResponse := h.checkUsageQuota(e.Community_id, models.UsageNotifications, 1); errResponse != nilErr {
+		log.Warn().Msgf("Not sending %s notifications for event %d, %s", notificationType, e.ID, errResponse.Details)
+		return
+	}
+	sent, err := models.NotifyCommunityAudience(h.A.DB, e, notificationType)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error sending notifications for event %d.", e.ID)
+		return
+	}
+	h.recordUsage(e.Community_id, models.UsageNotifications, sent)
+}
+
+func (h *Helpers) recordProposalEvent(p models.Proposal, eventType string) {
+	data := map[string]interface{}{"name": p.Name}
+	if p.Status != nil {
+		data["status"] = *p.Status
+	}
+	h.recordEvent(&models.CommunityEvent{
+		Community_id: p.Community_id,
+		Event_type:   eventType,
+		Actor_addr:   &p.Creator_addr,
+		Proposal_id:  &p.ID,
+		Data:         data,
+	})
+}
+
+func (h *Helpers) recordMembershipEvent(u models.CommunityUser, eventType string) {
+	h.recordEvent(&models.CommunityEvent{
+		Community_id: u.Community_id,
+		Event_type:   eventType,
+		Actor_addr:   &u.Addr,
+		Data:         map[string]interface{}{"userType": u.User_type},
+	})
+}
+
+func (h *Helpers) recordVoteMilestone(p models.Proposal) {
+	count, err := models.GetVoteCountForProposal(h.A.DB, p.ID)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error counting votes for proposal %d.", p.ID)
+		return
+	}
+	milestone, ok := models.HighestVoteMilestone(count)
+	if !ok {
+		return
+	}
+	h.recordEvent(&models.CommunityEvent{
+		Community_id: p.Community_id,
+		Event_type:   models.EventVoteMilestone,
+		Proposal_id:  &p.ID,
+		Milestone:    &milestone,
+		Data:         map[string]interface{}{"name": p.Name, "votes": milestone},
+	})
+
+	// the vote that reached the milestone renders the results image anew
+	if count == milestone {
+		if _, _, err := h.getResultsImage(p.ID); err != nil {
+			log.Error().Err(err).Msgf("Error rendering results image of proposal %d.", p.ID)
+		}
+	}
+}
+
+func (h *Helpers) computeAchievements() error {
+	proposals, err := models.GetProposalsPendingAchievements(h.A.DB)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range proposals {
+		votes, err := models.GetAllVotesForProposal(h.A.DB, p.ID, *p.Strategy)
+		if err != nil {
+			log.Error().Err(err).Msgf("Error getting votes for proposal %d.", p.ID)
+			continue
+		}
+
+		// the results finalized when the proposal was closed, tallied again
+		// for proposals closed by hand
+		results := models.ProposalResults{Proposal_id: p.ID}
+		if err := results.GetLatestProposalResultsById(h.A.DB); err != nil {
+			results, err = h.useStrategyTally(*p, votes)
+			if err != nil {
+				log.Error().Err(err).Msgf("Error tallying votes for proposal %d.", p.ID)
+				continue
+			}
+		}
+
+		if err := models.AddProposalAchievements(h.A.DB, p, votes, results); err != nil {
+			log.Error().Err(err).Msgf("Error adding achievements for proposal %d.", p.ID)
+		}
+	}
+
+	return nil
+}
+
+// closeProposals closes every published proposal past its end time: the
+// final results are tallied and stored, then the close event is recorded and
+// achievements are awarded. A proposal is only ever closed once, so these
+// side effects don't repeat; failed achievements are retried by their job.
+// Proposals allowing it are closed before their end time once decided.
+func (h *Helpers) closeProposals() error {
+	proposals, err := models.GetProposalsPendingClose(h.A.DB)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range proposals {
+		if err := h.closeProposal(p); err != nil {
+			log.Error().Err(err).Msgf("Error closing proposal %d.", p.ID)
+		}
+	}
+
+	decided, err := models.GetProposalsForEarlyClose(h.A.DB)
+	if err != nil {
+		return err
+	}
+	for _, p := range decided {
+		if err := h.closeDecidedProposal(p); err != nil {
+			log.Error().Err(err).Msgf("Error closing proposal %d early.", p.ID)
+		}
+	}
+
+	return nil
+}
+
+func (h *Helpers) closeProposal(p *models.Proposal) error {
+	results, err := h.useStrategyStreamingTally(*p)
+	if err != nil {
+		return err
+	}
+
+	closed, err := p.CloseProposal(h.A.DB, results)
+	if err != nil || !closed {
+		return err
+	}
+
+	h.finalizeClosedProposal(p, results)
+	return nil
+}
+
+// closeDecidedProposal closes a proposal allowing it before its end time
+// once no choice can overtake the leading one with the weight still to be
+// cast.
+func (h *Helpers) closeDecidedProposal(p *models.Proposal) error {
+	s, ok := h.initStrategy(*p.Strategy).(WeightBoundedStrategy)
+	if !ok {
+		return nil
+	}
+
+	results, err := h.useStrategyStreamingTally(*p)
+	if err != nil {
+		return err
+	}
+	remaining, err := s.RemainingWeight(p)
+	if err != nil {
+		return err
+	}
+	early := results.DecisiveLead(*p, remaining)
+	if early == nil {
+		return nil
+	}
+
+	closed, err := p.CloseProposalEarly(h.A.DB, results, *early)
+	if err != nil || !closed {
+		return err
+	}
+
+	log.Info().Msgf("Closed proposal %d early, %s can no longer be overtaken.", p.ID, early.Leading_choice)
+	h.finalizeClosedProposal(p, results)
+	return nil
+}
+
+// finalizeClosedProposal pins a proposal that was just closed along with its
+// results, records the close and awards achievements.
+func (h *Helpers) finalizeClosedProposal(p *models.Proposal, results models.ProposalResults) {
+
+	h.queuePin(models.PinProposal, p.ID)
+	h.queuePin(models.PinProposalResults, p.ID)
+
+	h.markDepositDue(p, results.DecideOutcome(*p).Quorum_met)
+
+	h.recordProposalEvent(*p, models.EventProposalClosed)
+
+	votes, err := models.GetAllVotesForProposal(h.A.DB, p.ID, *p.Strategy)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error getting votes for proposal %d.", p.ID)
+		return
+	}
+
+	if err := models.AddProposalAchievements(h.A.DB, p, votes, results); err != nil {
+		log.Error().Err(err).Msgf("Error adding achievements for proposal %d.", p.ID)
+	}
+}
+
+func (h *Helpers) getProposalAnalytics(p models.Proposal) (models.ProposalAnalytics, error) {
+	votes, err := models.GetAllVotesForProposal(h.A.DB, p.ID, *p.Strategy)
+	if err != nil {
+		return models.ProposalAnalytics{}, err
+	}
+
+	votesWithWeights, err := h.useStrategyGetVotes(p, votes)
+	if err != nil {
+		return models.ProposalAnalytics{}, err
+	}
+
+	analytics := models.BuildProposalAnalytics(&p, votesWithWeights)
+
+	overlap, err := models.GetVoterOverlap(h.A.DB, &p)
+	if err != nil {
+		return models.ProposalAnalytics{}, err
+	}
+	analytics.Overlap = overlap
+
+	return analytics, nil
+}
+
+// fetchProposalResults returns the stored results of the proposal, counting
+// and storing them first if they never were, with the outcome they decide.
+func (h *Helpers) fetchProposalResults(p models.Proposal) (models.ProposalResults, error) {
+	results := models.ProposalResults{Proposal_id: p.ID}
+	if err := results.GetLatestProposalResultsById(h.A.DB); err != nil {
+		if results, err = h.reconcileProposalResults(p); err != nil {
+			return models.ProposalResults{}, err
+		}
+	}
+
+	outcome := results.DecideOutcome(p)
+	results.Outcome = &outcome
+	return results, nil
+}
+
+// fetchPublicProposal returns the proposal unless it or its community
+// can't be seen by everyone.
+func (h *Helpers) fetchPublicProposal(id int) (models.Proposal, models.Community, int, error) {
+	p := models.Proposal{ID: id}
+	if err := p.GetProposalById(h.A.DB); err != nil {
+		return p, models.Community{}, http.StatusNotFound, err
+	}
+	c, err := h.fetchCommunity(p.Community_id)
+	if err != nil {
+		return p, c, http.StatusNotFound, err
+	}
+	if c.Is_private || p.IsWithheld() {
+		return p, c, http.StatusNotFound, fmt.Errorf("Proposal with ID %d not found.", id)
+	}
+	return p, c, http.StatusOK, nil
+}
+
+func (h *Helpers) getCommunityPreview(id int) (models.LinkPreview, int, error) {
+	c, err := h.fetchCommunity(id)
+	if err != nil {
+		return models.LinkPreview{}, http.StatusNotFound, err
+	}
+	if c.Is_private {
+		return models.LinkPreview{}, http.StatusNotFound, fmt.Errorf("Community with ID %d not found.", id)
+	}
+
+	preview := models.LinkPreview{
+		Type:         "website",
+		Title:        c.Name,
+		Url:          fmt.Sprintf("%s/community/%d", h.A.PublicAppURL, c.ID),
+		Site_name:    previewSiteName,
+		Twitter_card: "summary",
+	}
+	if c.Body != nil {
+		preview.Description = shared.PlainText(*c.Body, previewDescriptionLength)
+	}
+	if c.Banner_img_url != nil && *c.Banner_img_url != "" {
+		preview.Image = c.Banner_img_url
+		preview.Twitter_card = "summary_large_image"
+	} else if c.Logo != nil && *c.Logo != "" {
+		preview.Image = c.Logo
+	}
+	return preview, http.StatusOK, nil
+}
+
+// getTriggerItems polls a trigger of a public community. Closed proposals
+// report the choice they were decided for.
+// findDuplicateProposals lists the recent proposals of a public community a
+// draft looks like, with the community's threshold even when it doesn't
+// check new proposals itself.
+func (h *Helpers) findDuplicateProposals(communityId int, name, body string) ([]*models.DuplicateProposal, int, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, http.StatusBadRequest, errors.New("A name is required to look for duplicates.")
+	}
+	c, err := h.fetchCommunity(communityId)
+	if err != nil || c.Is_private {
+		return nil, http.StatusNotFound, fmt.Errorf("Community with ID %d not found.", communityId)
+	}
+
+	duplicates, err := models.FindDuplicateProposals(h.A.DB, c.ID, name, body, c.DuplicateCheck.Threshold(), 0)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	if duplicates == nil {
+		duplicates = []*models.DuplicateProposal{}
+	}
+	return duplicates, http.StatusOK, nil
+}
+
+func (h *Helpers) getTriggerItems(
+	communityId int,
+	trigger string,
+	since int,
+	filters models.ProposalFilters,
+	limit int,
+) ([]*models.TriggerItem, int, error) {
+	if _, ok := models.TRIGGER_EVENTS[trigger]; !ok {
+		return nil, http.StatusNotFound, fmt.Errorf("Unknown trigger %q.", trigger)
+	}
+	c, err := h.fetchCommunity(communityId)
+	if err != nil || c.Is_private {
+		return nil, http.StatusNotFound, fmt.Errorf("Community with ID %d not found.", communityId)
+	}
+
+	items, err := models.GetTriggerItems(h.A.DB, c.ID, trigger, since, filters, limit)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	for _, item := range items {
+		item.Url = fmt.Sprintf("%s/community/%d/proposal/%d", h.A.PublicAppURL, c.ID, item.Proposal_id)
+		if trigger != models.TriggerProposalClosed {
+			continue
+		}
+		p := models.Proposal{ID: item.Proposal_id}
+		if err := p.GetProposalById(h.A.DB); err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		results, err := h.fetchProposalResults(p)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		item.Winning_choice = results.Outcome.Winning_choice
+	}
+
+	return items, http.StatusOK, nil
+}
+
+// getProposalFeed lists the latest proposals of a public community, or of
+// the whole tenant when communityId is 0, as a feed. Closed proposals are
+// summarized with their results.
+func (h *Helpers) getProposalFeed(tenant *models.Tenant, communityId int, self string) (models.SyndicationFeed, int, error) {
+	feed := models.SyndicationFeed{
+		Title:       previewSiteName + " proposals",
+		Description: "The latest proposals on " + previewSiteName + ".",
+		Link:        h.A.PublicAppURL,
+		Self:        self,
+		Updated:     time.Now().UTC(),
+	}
+	if communityId != 0 {
+		c, err := h.fetchCommunity(communityId)
+		if err != nil || c.Is_private || c.Tenant_id != tenant.ID {
+			return feed, http.StatusNotFound, fmt.Errorf("Community with ID %d not found.", communityId)
+		}
+		feed.Title = c.Name + " proposals"
+		feed.Description = "The latest proposals of " + c.Name + " on " + previewSiteName + "."
+		feed.Link = fmt.Sprintf("%s/community/%d", h.A.PublicAppURL, c.ID)
+	}
+
+	proposals, err := models.GetProposalsForSyndication(h.A.DB, tenant.ID, communityId, models.MaxSyndicatedProposals)
+	if err != nil {
+		return feed, http.StatusInternalServerError, err
+	}
+
+	for i, p := range proposals {
+		item := models.SyndicationItem{
+			Title:      p.Name,
+			Link:       fmt.Sprintf("%s/community/%d/proposal/%d", h.A.PublicAppURL, p.Community_id, p.ID),
+			Author:     p.Creator_addr,
+			Categories: p.Tags,
+		}
+		if communityId == 0 {
+			item.Title = p.Community_name + ": " + p.Name
+		}
+		if p.Created_at != nil {
+			item.Published = *p.Created_at
+		}
+		item.Updated = item.Published
+		if p.Updated_at != nil {
+			item.Updated = *p.Updated_at
+		}
+		if p.Body != nil {
+			item.Summary = shared.PlainText(*p.Body, previewDescriptionLength)
+		}
+
+		if p.Computed_status != nil && *p.Computed_status == models.ProposalClosed {
+			results, err := h.fetchProposalResults(p.Proposal)
+			if err != nil {
+				return feed, http.StatusInternalServerError, err
+			}
+			item.Summary = strings.TrimSpace(item.Summary + "\n\n" + results.Summary(p.Proposal))
+			if p.Closed_at != nil {
+				item.Updated = *p.Closed_at
+			} else if p.End_time.After(item.Updated) {
+				item.Updated = p.End_time
+			}
+		}
+
+		// the feed changed when its newest item did
+		if i == 0 || item.Updated.After(feed.Updated) {
+			feed.Updated = item.Updated
+		}
+		feed.Items = append(feed.Items, item)
+	}
+
+	return feed, http.StatusOK, nil
+}
+
+// getProposalEmbed describes a public proposal for a widget on another
+// site.
+func (h *Helpers) getProposalEmbed(id int) (models.ProposalEmbed, int, error) {
+	p, c, httpStatus, err := h.fetchPublicProposal(id)
+	if err != nil {
+		return models.ProposalEmbed{}, httpStatus, err
+	}
+	results, err := h.fetchProposalResults(p)
+	if err != nil {
+		return models.ProposalEmbed{}, http.StatusInternalServerError, err
+	}
+
+	embed := models.ProposalEmbed{
+		ID:            p.ID,
+		Name:          p.Name,
+		Community:     models.CommunityEmbed{ID: c.ID, Name: c.Name, Logo: c.Logo},
+		Voting_status: votingTimeLeft(p, time.Now()),
+		Start_time:    p.Start_time,
+		End_time:      p.End_time,
+		Results:       results.Breakdown(p.Choices),
+		Total_votes:   p.Total_votes,
+		Url:           fmt.Sprintf("%s/community/%d/proposal/%d", h.A.PublicAppURL, c.ID, p.ID),
+		Image:         fmt.Sprintf("%s/proposals/%d/results.png", h.A.PublicApiURL, p.ID),
+	}
+	if p.Computed_status != nil {
+		embed.Status = *p.Computed_status
+	}
+	if embed.Status == models.ProposalClosed {
+		embed.Winning_choice = results.Outcome.Winning_choice
+		embed.Updated_at = &results.Updated_at
+	}
+	return embed, http.StatusOK, nil
+}
+
+// getOEmbed answers an oEmbed request for a proposal page of the app with
+// the proposal's widget, fit within the maximum size asked for.
+func (h *Helpers) getOEmbed(rawUrl string, maxWidth, maxHeight int) (models.OEmbed, int, error) {
+	u, err := url.Parse(rawUrl)
+	app, _ := url.Parse(h.A.PublicAppURL)
+	if err != nil || app == nil || !strings.EqualFold(u.Host, app.Host) {
+		return models.OEmbed{}, http.StatusNotFound, fmt.Errorf("%s is not a link to %s.", rawUrl, previewSiteName)
+	}
+	match := proposalPathPattern.FindStringSubmatch(strings.TrimPrefix(u.Path, app.Path))
+	if match == nil {
+		return models.OEmbed{}, http.StatusNotFound, fmt.Errorf("%s is not a link to a proposal.", rawUrl)
+	}
+	id, _ := strconv.Atoi(match[1])
+
+	embed, httpStatus, err := h.getProposalEmbed(id)
+	if err != nil {
+		return models.OEmbed{}, httpStatus, err
+	}
+
+	width, height := embedWidth, embedHeight
+	if maxWidth > 0 && maxWidth < width {
+		width = maxWidth
+	}
+	if maxHeight > 0 && maxHeight < height {
+		height = maxHeight
+	}
+	widget := fmt.Sprintf("%s/embed/proposals/%d/widget", h.A.PublicApiURL, embed.ID)
+	o := models.OEmbed{
+		Version:       models.OEmbedVersion,
+		Type:          "rich",
+		Provider_name: previewSiteName,
+		Provider_url:  h.A.PublicAppURL,
+		Title:         embed.Name,
+		Author_name:   embed.Community.Name,
+		Author_url:    fmt.Sprintf("%s/community/%d", h.A.PublicAppURL, embed.Community.ID),
+		Html: fmt.Sprintf(`<iframe src="%s" width="%d" height="%d" frameborder="0" title="%s"></iframe>`,
+			html.EscapeString(widget), width, height, html.EscapeString(embed.Name)),
+		Width:     width,
+		Height:    height,
+		Cache_age: embedCacheAge,
+	}
+	// the thumbnail may only be offered when it fits
+	chartWidth, chartHeight := shared.ResultsChart{}.Size()
+	if (maxWidth == 0 || chartWidth <= maxWidth) && (maxHeight == 0 || chartHeight <= maxHeight) {
+		o.Thumbnail_url = embed.Image
+		o.Thumbnail_width, o.Thumbnail_height = chartWidth, chartHeight
+	}
+	return o, http.StatusOK, nil
+}
+
+// getProposalPreview describes the proposal with its live results, and
+// uses the results chart as its image.
+func (h *Helpers) getProposalPreview(id int) (models.LinkPreview, int, error) {
+	p, c, httpStatus, err := h.fetchPublicProposal(id)
+	if err != nil {
+		return models.LinkPreview{}, httpStatus, err
+	}
+	results, err := h.fetchProposalResults(p)
+	if err != nil {
+		return models.LinkPreview{}, http.StatusInternalServerError, err
+	}
+
+	image := fmt.Sprintf("%s/proposals/%d/results.png", h.A.PublicApiURL, p.ID)
+	preview := models.LinkPreview{
+		Type:         "article",
+		Title:        fmt.Sprintf("%s | %s", p.Name, c.Name),
+		Url:          fmt.Sprintf("%s/community/%d/proposal/%d", h.A.PublicAppURL, c.ID, p.ID),
+		Image:        &image,
+		Site_name:    previewSiteName,
+		Twitter_card: "summary_large_image",
+		Results:      results.Breakdown(p.Choices),
+		Total_votes:  &p.Total_votes,
+		End_time:     &p.End_time,
+	}
+
+	summary := fmt.Sprintf("%d votes · %s.", p.Total_votes, votingTimeLeft(p, time.Now()))
+	if p.Body != nil {
+		summary += " " + shared.PlainText(*p.Body, previewDescriptionLength)
+	}
+	preview.Description = shared.PlainText(summary, previewDescriptionLength)
+	return preview, http.StatusOK, nil
+}
+
+func (h *Helpers) getResultsChart(id int) (shared.ResultsChart, int, error) {
+	p, _, httpStatus, err := h.fetchPublicProposal(id)
+	if err != nil {
+		return shared.ResultsChart{}, httpStatus, err
+	}
+	chart, err := h.buildResultsChart(p)
+	if err != nil {
+		return shared.ResultsChart{}, http.StatusInternalServerError, err
+	}
+	return chart, http.StatusOK, nil
+}
+
+func (h *Helpers) buildResultsChart(p models.Proposal) (shared.ResultsChart, error) {
+	results, err := h.fetchProposalResults(p)
+	if err != nil {
+		return shared.ResultsChart{}, err
+	}
+
+	chart := shared.ResultsChart{
+		Title:  p.Name,
+		Votes:  p.Total_votes,
+		Status: votingTimeLeft(p, time.Now()),
+	}
+	for _, result := range results.Breakdown(p.Choices) {
+		chart.Bars = append(chart.Bars, shared.ChartBar{Label: result.Choice, Percent: result.Percent})
+	}
+	return chart, nil
+}
+
+// getResultsImage returns the results chart of the proposal as a PNG,
+// rendering it again only once the stored one is out of date.
+func (h *Helpers) getResultsImage(id int) ([]byte, int, error) {
+	p, _, httpStatus, err := h.fetchPublicProposal(id)
+	if err != nil {
+		return nil, httpStatus, err
+	}
+
+	image, err := models.GetResultsImage(h.A.DB, p.ID)
+	if err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		return nil, http.StatusInternalServerError, err
+	}
+	if image != nil && image.Fresh(&p, time.Now()) {
+		return image.Png, http.StatusOK, nil
+	}
+
+	chart, err := h.buildResultsChart(p)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	png, err := chart.PNG()
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	milestone, _ := models.HighestVoteMilestone(p.Total_votes)
+	image = &models.ResultsImage{Proposal_id: p.ID, Milestone: milestone, Png: png}
+	if p.Computed_status != nil {
+		image.Computed_status = *p.Computed_status
+	}
+	if err := image.Save(h.A.DB); err != nil {
+		log.Error().Err(err).Msgf("Error storing results image of proposal %d.", p.ID)
+	}
+	return png, http.StatusOK, nil
+}
+
+// votingTimeLeft describes when voting on the proposal starts or ends,
+// e.g. "Ends in 3 days".
+func votingTimeLeft(p models.Proposal, now time.Time) string {
+	if p.Computed_status != nil {
+		switch *p.Computed_status {
+		case "cancelled":
+			return "Cancelled"
+		case "closed":
+			return "Closed"
+		}
+	}
+	switch {
+	case now.Before(p.Start_time):
+		return "Starts in " + roughDuration(p.Start_time.Sub(now))
+	case now.Before(p.End_time):
+		return "Ends in " + roughDuration(p.End_time.Sub(now))
+	default:
+		return "Closed"
+	}
+}
+
+func roughDuration(d time.Duration) string {
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return "1 " + unit
+		}
+		return fmt.Sprintf("%d %ss", n, unit)
+	}
+	switch {
+	case d >= 48*time.Hour:
+		return plural(int(d.Hours()/24), "day")
+	case d >= time.Hour:
+		return plural(int(d.Hours()), "hour")
+	default:
+		return plural(int(d.Minutes())+1, "minute")
+	}
+}
+
+func (h *Helpers) computeAnalytics() error {
+	proposals, err := models.GetProposalsPendingStats(h.A.DB)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range proposals {
+		votes, err := models.GetAllVotesForProposal(h.A.DB, p.ID, *p.Strategy)
+		if err != nil {
+			log.Error().Err(err).Msgf("Error getting votes for proposal %d.", p.ID)
+			continue
+		}
+
+		votesWithWeights, err := h.useStrategyGetVotes(*p, votes)
+		if err != nil {
+			log.Error().Err(err).Msgf("Error getting vote weights for proposal %d.", p.ID)
+			continue
+		}
+
+		if err := models.UpsertProposalStats(h.A.DB, p, votesWithWeights); err != nil {
+			log.Error().Err(err).Msgf("Error updating stats for proposal %d.", p.ID)
+		}
+	}
+
+	return models.RefreshCommunityStats(h.A.DB)
+}
+
+// materializeDynamicLists recomputes the addresses of every dynamic list from
+// chain data at the current block height.
+func (h *Helpers) materializeDynamicLists() error {
+	lists, err := models.GetDynamicLists(h.A.DB)
+	if err != nil || len(lists) == 0 {
+		return err
+	}
+
+	blockHeight, err := h.A.FlowAdapter.GetCurrentBlockHeight()
+	if err != nil {
+		return err
+	}
+
+	for _, l := range lists {
+		if err := h.materializeList(&l, uint64(blockHeight)); err != nil {
+			log.Error().Err(err).Msgf("Error materializing dynamic list %d.", l.ID)
+		}
+	}
+
+	return nil
+}
+
+func (h *Helpers) materializeList(l *models.List, blockHeight uint64) error {
+	candidates, err := models.GetListCandidates(h.A.DB, l.Community_id)
+	if err != nil {
+		return err
+	}
+
+	addresses := []string{}
+	for _, addr := range candidates {
+		matches, err := h.matchesListRule(addr, l.Rule, blockHeight)
+		if err != nil {
+			return err
+		}
+		if matches {
+			addresses = append(addresses, addr)
+		}
+	}
+
+	// only pin and version the list when its contents changed
+	changed := !funk.Equal(addresses, l.Addresses)
+	if changed {
+		l.Addresses = addresses
+		l.Cid = nil
+	}
+
+	if err := h.A.DB.WithTx(func(tx *shared.Database) error {
+		if changed {
+			if err := l.UpdateList(tx); err != nil {
+				return err
+			}
+		}
+		return l.MarkMaterialized(tx)
+	}); err != nil {
+		return err
+	}
+
+	if changed {
+		h.queuePin(models.PinList, l.ID)
+	}
+	return nil
+}
+
+func (h *Helpers) matchesListRule(addr string, rule *models.ListRule, blockHeight uint64) (bool, error) {
+	c := rule.Contract
+	switch rule.Type {
+	case models.ListRuleFTBalance:
+		balance, err := h.A.FlowAdapter.GetFTBalance(addr, blockHeight, *c.Name, *c.Addr, *c.Public_path)
+		if err != nil {
+			return false, err
+		}
+		return balance > 0 && balance >= rule.Min_amount, nil
+	case models.ListRuleNFTOwnership:
+		nftIds, err := h.A.FlowAdapter.GetNFTIds(addr, &c, "./main/cadence/scripts/get_nfts_ids.cdc")
+		if err != nil {
+			return false, err
+		}
+		return len(nftIds) > 0 && float64(len(nftIds)) >= rule.Min_amount, nil
+	}
+	return false, fmt.Errorf("Invalid list rule type %q.", rule.Type)
+}
+
+func (h *Helpers) createExport(communityId int, payload models.CreateExportPayload) (models.ExportWithToken, int, error) {
+	if err := h.validateCommunityAdmin(communityId, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+		return models.ExportWithToken{}, http.StatusForbidden, err
+	}
+
+	export := models.CommunityExport{Community_id: communityId, Requested_by: payload.Signing_addr}
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return export.CreateExport(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.ExportWithToken{}, http.StatusForbidden, err
+	} else if err != nil {
+		return models.ExportWithToken{}, http.StatusInternalServerError, err
+	}
+
+	claims := models.ExportClaims{
+		TokenClaims: shared.TokenClaims{
+			Subject:   payload.Signing_addr,
+			Audience:  "export",
+			ExpiresAt: time.Now().Add(exportTokenExpiry).Unix(),
+			IssuedAt:  time.Now().Unix(),
+		},
+		Export_id:    export.ID,
+		Community_id: communityId,
+	}
+	token, err := h.A.TokenSigner.Sign(claims)
+	if err != nil {
+		return models.ExportWithToken{}, http.StatusInternalServerError, err
+	}
+
+	// start right away instead of waiting for the next exports job tick
+	h.A.goBackground(func() {
+		if err := h.processExports(); err != nil {
+			log.Error().Err(err).Msg("Error processing exports.")
+		}
+	})
+
+	return models.ExportWithToken{CommunityExport: export, Token: token}, http.StatusAccepted, nil
+}
+
+// getExport returns the export the token was issued for.
+func (h *Helpers) getExport(communityId, exportId int, token string) (models.CommunityExport, int, error) {
+	var claims models.ExportClaims
+	if err := h.A.TokenSigner.Verify(token, &claims); err != nil ||
+		claims.Audience != "export" ||
+		claims.Export_id != exportId ||
+		claims.Community_id != communityId {
+		return models.CommunityExport{}, http.StatusForbidden, errors.New("Invalid or expired export token.")
+	}
+
+	export := models.CommunityExport{ID: exportId}
+	if err := export.GetExportById(h.A.DB); err != nil || export.Community_id != communityId {
+		return models.CommunityExport{}, http.StatusNotFound, errors.New("Export not found.")
+	}
+
+	return export, http.StatusOK, nil
+}
+
+// processExports runs pending exports one at a time until none are left.
+func (h *Helpers) processExports() error {
+	for {
+		export, err := models.ClaimPendingExport(h.A.DB)
+		if err != nil {
+			return err
+		}
+		if export == nil {
+			return nil
+		}
+
+		archive, err := h.buildCommunityArchive(export.Community_id)
+		if err != nil {
+			log.Error().Err(err).Msgf("Error exporting community %d.", export.Community_id)
+			if err := export.FailExport(h.A.DB, err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := export.CompleteExport(h.A.DB, archive); err != nil {
+			return err
+		}
+	}
+}
+
+func (h *Helpers) buildCommunityArchive(communityId int) ([]byte, error) {
+	archive, err := models.GetCommunityArchive(h.A.DB, communityId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range archive.Proposals {
+		if p.Computed_status == nil || *p.Computed_status != "closed" || p.Strategy == nil {
+			continue
+		}
+		votes, err := models.GetAllVotesForProposal(h.A.DB, p.ID, *p.Strategy)
+		if err != nil {
+			return nil, err
+		}
+		results, err := h.useStrategyTally(*p, votes)
+		if err != nil {
+			return nil, err
+		}
+		archive.Results = append(archive.Results, results)
+	}
+
+	return models.WriteArchive(archive)
+}
+
+func (h *Helpers) importCommunity(r *http.Request) (models.Community, int, error) {
+	var payload models.ImportCommunityPayload
+	if err := json.Unmarshal([]byte(r.FormValue("payload")), &payload); err != nil {
+		return models.Community{}, http.StatusBadRequest, errors.New("Invalid import payload.")
+	}
+	if err := h.validateSignedByAddress(payload.Signing_addr, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+		return models.Community{}, http.StatusForbidden, err
+	}
+
+	file, _, err := r.FormFile("archive")
+	if err != nil {
+		return models.Community{}, http.StatusBadRequest, errors.New("Missing archive file.")
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return models.Community{}, http.StatusBadRequest, err
+	}
+
+	archive, err := models.ReadArchive(data)
+	if err != nil {
+		return models.Community{}, http.StatusBadRequest, err
+	}
+
+	// only the creator or an admin of the exported community may import it
+	authorized := archive.Community.Creator_addr == payload.Signing_addr
+	for _, m := range archive.Members {
+		if m.Addr == payload.Signing_addr && m.User_type == "admin" {
+			authorized = true
+		}
+	}
+	if !authorized {
+		return models.Community{}, http.StatusForbidden, errors.New("Only an admin of the exported community can import it.")
+	}
+
+	if err := models.ValidateArchiveCids(archive); err != nil {
+		return models.Community{}, http.StatusBadRequest, err
+	}
+	if err := h.validateArchiveSignatures(archive); err != nil {
+		return models.Community{}, http.StatusBadRequest, err
+	}
+
+	// the archive may come from another deployment, with other tenants
+	archive.Community.Tenant_id = requestTenant(r).ID
+	var c models.Community
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		var err error
+		c, err = models.ImportCommunityArchive(tx, archive)
+		return err
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.Community{}, http.StatusForbidden, err
+	} else if err != nil {
+		return models.Community{}, http.StatusInternalServerError, err
+	}
+
+	return c, http.StatusCreated, nil
+}
+
+// validateArchiveSignatures re-checks the signatures of archived proposals and
+// votes against their original ids. Proposals signed without a voucher only
+// signed a timestamp that is not stored, so they are covered by their cid.
+func (h *Helpers) validateArchiveSignatures(archive models.CommunityArchive) error {
+	proposals := map[int]*models.Proposal{}
+	for _, p := range archive.Proposals {
+		proposals[p.ID] = p
+		if p.Voucher == nil {
+			continue
+		}
+		message := shared.EncodeMessageFromVoucher(p.Voucher)
+		sigs := shared.GetUserCompositeSignatureFromVoucher(p.Voucher)
+		if err := h.validateTxSignature(p.Creator_addr, message, sigs); err != nil {
+			return fmt.Errorf("Invalid signature for proposal %d.", p.ID)
+		}
+	}
+
+	for _, v := range archive.Votes {
+		p, ok := proposals[v.Proposal_id]
+		if !ok {
+			return fmt.Errorf("Vote %d references unknown proposal %d.", v.ID, v.Proposal_id)
+		}
+
+		if v.Voucher != nil {
+			// the stored message is the encoded voucher transaction
+			if err := h.validateTxSignature(v.Addr, v.Message, v.Composite_signatures); err != nil {
+				return fmt.Errorf("Invalid signature for vote %d.", v.ID)
+			}
+			continue
+		}
+
+		if err := models.ValidateArchivedVoteMessage(v, p); err != nil {
+			return err
+		}
+		if err := h.validateUserSignature(v.Addr, v.Message, v.Composite_signatures); err != nil {
+			return fmt.Errorf("Invalid signature for vote %d.", v.ID)
+		}
+	}
+
+	return nil
+}
+
+func (h *Helpers) setFollow(addr string, payload models.FollowPayload, follow bool) (models.Follow, int, error) {
+	f := models.Follow{
+		Follower_addr: addr,
+		Community_id:  payload.Community_id,
+		Followed_addr: payload.Followed_addr,
+	}
+	if err := f.Validate(); err != nil {
+		return models.Follow{}, http.StatusBadRequest, err
+	}
+
+	if err := h.validateSignedByAddress(addr, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+		return models.Follow{}, http.StatusForbidden, err
+	}
+
+	if follow && f.Community_id != nil {
+		if _, err := h.fetchCommunity(*f.Community_id); err != nil {
+			return models.Follow{}, http.StatusNotFound, err
+		}
+	}
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		if !follow {
+			return f.RemoveFollow(tx)
+		}
+		return f.CreateFollow(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.Follow{}, http.StatusForbidden, err
+	} else if err != nil {
+		return models.Follow{}, http.StatusInternalServerError, err
+	}
+
+	if !follow {
+		return f, http.StatusOK, nil
+	}
+	return f, http.StatusCreated, nil
+}
+
+func (h *Helpers) updateUserProfile(addr string, payload models.UpdateProfilePayload) (models.UserProfile, int, error) {
+	validate := newValidator()
+	if vErr := validate.Struct(payload); vErr != nil {
+		errMsg := "Invalid profile."
+		log.Error().Err(vErr).Msg(errMsg)
+		return models.UserProfile{}, http.StatusBadRequest, errors.New(errMsg)
+	}
+
+	if err := h.validateSignedByAddress(addr, payload.TimestampSignaturePayload, payload.Voucher); err != nil {
+		return models.UserProfile{}, http.StatusForbidden, err
+	}
+
+	profile := models.UserProfile{Addr: addr}
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return profile.UpdateProfile(tx, &payload)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.UserProfile{}, http.StatusForbidden, err
+	} else if err != nil {
+		return models.UserProfile{}, http.StatusInternalServerError, err
+	}
+	if err := profile.GetStats(h.A.DB); err != nil {
+		return models.UserProfile{}, http.StatusInternalServerError, err
+	}
+
+	return profile, http.StatusOK, nil
+}
+
+func (h *Helpers) attachProfilesToVotes(votes []*models.VoteWithBalance) {
+	addrs := make([]string, 0, len(votes))
+	for _, v := range votes {
+		addrs = append(addrs, v.Addr)
+	}
+	profiles, err := models.GetProfileSummaries(h.A.DB, addrs)
+	if err != nil {
+		log.Error().Err(err).Msg("Error getting profile summaries.")
+		return
+	}
+	for _, v := range votes {
+		v.Profile = profiles[v.Addr]
+	}
+}
+
+func (h *Helpers) attachProfilesToLeaderboard(l *models.LeaderboardPayload) {
+	addrs := []string{l.CurrentUser.Addr}
+	for _, u := range l.Users {
+		addrs = append(addrs, u.Addr)
+	}
+	profiles, err := models.GetProfileSummaries(h.A.DB, addrs)
+	if err != nil {
+		log.Error().Err(err).Msg("Error getting profile summaries.")
+		return
+	}
+	for i := range l.Users {
+		l.Users[i].Profile = profiles[l.Users[i].Addr]
+	}
+	l.CurrentUser.Profile = profiles[l.CurrentUser.Addr]
+}
+
+// onJoinRequestChange is the notification hook for join request activity:
+// admins hear of new requests, and requesters of how theirs was reviewed.
+func (h *Helpers) onJoinRequestChange(jr models.JoinRequest) {
+	log.Info().Msgf("join request %d for %s in community %d is %s", jr.ID, jr.Addr, jr.Community_id, jr.Status)
+	if jr.Status == models.JoinRequestPending {
+		h.notifyJoinRequest(jr)
+		return
+	}
+
+	data := map[string]interface{}{"status": jr.Status}
+	if jr.Reason != nil {
+		data["reason"] = *jr.Reason
+	}
+	h.notify(models.Notification{
+		Addr:              jr.Addr,
+		Notification_type: models.NotificationJoinRequest,
+		Community_id:      &jr.Community_id,
+		Data:              data,
+	})
+}
+
+// notifyJoinRequest tells the admins of a community about a new join request
+// unless the community used its notifications for the month.
+func (h *Helpers) notifyJoinRequest(jr models.JoinRequest) {
+	if errResponse := h.checkUsageQuota(jr.Community_id, models.UsageNotifications, 1); errResponse != nilErr {
+		log.Warn().Msgf("Not sending %s notifications for join request %d, %s", models.NotificationJoinRequestNew, jr.ID, errResponse.Details)
+		return
+	}
+	data := map[string]interface{}{"requestId": jr.ID, "addr": jr.Addr}
+	if jr.Message != nil {
+		data["message"] = *jr.Message
+	}
+	sent, err := models.NotifyCommunityAdmins(h.A.DB, jr.Community_id, models.NotificationJoinRequestNew, data)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error sending notifications for join request %d.", jr.ID)
+		return
+	}
+	h.recordUsage(jr.Community_id, models.UsageNotifications, sent)
+}
+
+func (h *Helpers) updateAddressesInList(id int, payload models.ListUpdatePayload, action string) (int, error) {
+	l := models.List{ID: id}
+
+	// get current proposal from DB
+	if err := l.GetListById(h.A.DB); err != nil {
+		errMsg := fmt.Sprintf("Error querying list with id %v.", id)
+		log.Error().Err(err).Msg(errMsg)
+		return http.StatusInternalServerError, err
+	}
+
+	if l.IsDynamic() {
+		return http.StatusBadRequest, errors.New("Dynamic list addresses are computed from its rule.")
+	}
+
+	validate := newValidator()
+	if vErr := validate.Struct(payload); vErr != nil {
+		errMsg := "Remove from list validation error."
+		if action == "add" {
+			errMsg = "Add to list validation error."
+		}
+		log.Error().Err(vErr).Msg(errMsg)
+		return http.StatusBadRequest, errors.New(errMsg)
+	}
+
+	if err := h.validateUserWithPermission(
+		payload.Signing_addr,
+		payload.Timestamp,
+		payload.Composite_signatures,
+		l.Community_id,
+		models.PermManageLists,
+	); err != nil {
+		log.Error().Err(err)
+		return http.StatusForbidden, err
+	}
+
+	if action == "remove" {
+		l.RemoveAddresses(payload.Addresses)
+	} else {
+		l.AddAddresses(payload.Addresses)
+	}
+
+	l.Cid = nil
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, nil, func(tx *shared.Database) error {
+		return l.UpdateList(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return http.StatusForbidden, err
+	} else if err != nil {
+		errMsg := "Database error updating list."
+		log.Error().Err(err).Msg(errMsg)
+		return http.StatusInternalServerError, err
+	}
+	h.queuePin(models.PinList, l.ID)
+
+	return http.StatusOK, nil
+}
+
+// uploadListCSV adds the addresses of an uploaded CSV file to a list. Rows
+// that are malformed or already present are skipped and listed in the report.
+func (h *Helpers) uploadListCSV(id int, r *http.Request) (models.ListCSVReport, int, error) {
+	var payload models.ListUpdatePayload
+	if err := json.Unmarshal([]byte(r.FormValue("payload")), &payload); err != nil {
+		return models.ListCSVReport{}, http.StatusBadRequest, errors.New("Invalid list upload payload.")
+	}
+
+	l := models.List{ID: id}
+	if err := l.GetListById(h.A.DB); err != nil {
+		log.Error().Err(err).Msgf("Error querying list with id %v.", id)
+		return models.ListCSVReport{}, http.StatusNotFound, errors.New("List not found.")
+	}
+	if l.IsDynamic() {
+		return models.ListCSVReport{}, http.StatusBadRequest, errors.New("Dynamic list addresses are computed from its rule.")
+	}
+
+	if err := h.validateUserWithPermission(
+		payload.Signing_addr,
+		payload.Timestamp,
+		payload.Composite_signatures,
+		l.Community_id,
+		models.PermManageLists,
+	); err != nil {
+		return models.ListCSVReport{}, http.StatusForbidden, err
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return models.ListCSVReport{}, http.StatusBadRequest, errors.New("Missing CSV file.")
+	}
+	defer file.Close()
+
+	report, err := models.ParseListCSV(file, l.Addresses)
+	if err != nil {
+		return models.ListCSVReport{}, http.StatusBadRequest, err
+	}
+	if len(report.Added) == 0 {
+		return report, http.StatusOK, nil
+	}
+
+	l.AddAddresses(report.Added)
+
+	l.Cid = nil
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, nil, func(tx *shared.Database) error {
+		return l.UpdateList(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.ListCSVReport{}, http.StatusForbidden, err
+	} else if err != nil {
+		log.Error().Err(err).Msg("Database error updating list.")
+		return models.ListCSVReport{}, http.StatusInternalServerError, err
+	}
+	h.queuePin(models.PinList, l.ID)
+
+	return report, http.StatusOK, nil
+}
+
+func (h *Helpers) createListForCommunity(payload models.ListPayload) (models.List, int, error) {
+	if existingList, _ := models.GetListForCommunityByType(h.A.DB, payload.Community_id, *payload.List_type); existingList.ID > 0 {
+		errMsg := fmt.Sprintf("List of type %s already exists for community %d.", *payload.List_type, payload.Community_id)
+		return models.List{}, http.StatusBadRequest, errors.New(errMsg)
+	}
+
+	// dynamic list addresses are materialized from the rule
+	if payload.Rule != nil {
+		if err := payload.Rule.Validate(); err != nil {
+			return models.List{}, http.StatusBadRequest, err
+		}
+		payload.Addresses = []string{}
+	}
+
+	// validate payload fields
+	validate := newValidator()
+	if vErr := validate.Struct(payload); vErr != nil {
+		errMsg := "Validation error in list payload."
+		log.Error().Err(vErr).Msg(errMsg)
+		return models.List{}, http.StatusBadRequest, errors.New(errMsg)
+	}
+
+	if err := h.validateUserWithPermission(
+		payload.Signing_addr,
+		payload.Timestamp,
+		payload.Composite_signatures,
+		payload.Community_id,
+		models.PermManageLists,
+	); err != nil {
+		log.Error().Err(err)
+		return models.List{}, http.StatusForbidden, err
+	}
+
+	l := payload.List
+	l.Cid = nil
+
+	// create list
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, nil, func(tx *shared.Database) error {
+		return l.CreateList(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.List{}, http.StatusForbidden, err
+	} else if err != nil {
+		return models.List{}, http.StatusInternalServerError, err
+	}
+	h.queuePin(models.PinList, l.ID)
+
+	return l, http.StatusCreated, nil
+}
+
+// combineLists stores the result of a set operation on two lists of the
+// community as a new static list.
+func (h *Helpers) combineLists(communityId int, payload models.ListSetOperationPayload) (models.List, int, error) {
+	validate := newValidator()
+	if vErr := validate.Struct(payload); vErr != nil {
+		log.Error().Err(vErr).Msg("List operation validation error.")
+		return models.List{}, http.StatusBadRequest, errors.New("Invalid list operation payload.")
+	}
+
+	if err := h.validateCommunityPermission(
+		communityId,
+		payload.TimestampSignaturePayload,
+		payload.Voucher,
+		models.PermManageLists,
+	); err != nil {
+		return models.List{}, http.StatusForbidden, err
+	}
+
+	left := models.List{ID: payload.Left_list_id}
+	right := models.List{ID: payload.Right_list_id}
+	for _, l := range []*models.List{&left, &right} {
+		if err := l.GetListById(h.A.DB); err != nil || l.Community_id != communityId {
+			return models.List{}, http.StatusNotFound, fmt.Errorf("List %d not found in community %d.", l.ID, communityId)
+		}
+	}
+
+	if payload.List_type != nil {
+		if existingList, _ := models.GetListForCommunityByType(h.A.DB, communityId, *payload.List_type); existingList.ID > 0 {
+			errMsg := fmt.Sprintf("List of type %s already exists for community %d.", *payload.List_type, communityId)
+			return models.List{}, http.StatusBadRequest, errors.New(errMsg)
+		}
+	}
+
+	addresses, err := models.CombineLists(payload.Operation, left.Addresses, right.Addresses)
+	if err != nil {
+		return models.List{}, http.StatusBadRequest, err
+	}
+
+	l := models.List{
+		Community_id: communityId,
+		Addresses:    addresses,
+		List_type:    payload.List_type,
+		Name:         payload.Name,
+	}
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, payload.Voucher, func(tx *shared.Database) error {
+		return l.CreateList(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.List{}, http.StatusForbidden, err
+	} else if err != nil {
+		return models.List{}, http.StatusInternalServerError, err
+	}
+	h.queuePin(models.PinList, l.ID)
+
+	return l, http.StatusCreated, nil
+}
+
+func (h *Helpers) validateUserSignature(addr string, message string, sigs *[]shared.CompositeSignature) error {
+	shouldValidateSignature := h.A.Config.Features["validateSigs"]
+
+	if !shouldValidateSignature {
+		return nil
+	}
+
+	hexMessage := hex.EncodeToString([]byte(message))
+	if err := h.A.SignatureVerifiers.Verify(addr, hexMessage, sigs, "USER"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (h *Helpers) validateTxSignature(addr string, message string, sigs *[]shared.CompositeSignature) error {
+	shouldValidateSignature := h.A.Config.Features["validateSigs"]
+
+	if !shouldValidateSignature {
+		return nil
+	}
+
+	if err := h.A.SignatureVerifiers.Verify(addr, message, sigs, "TRANSACTION"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validateBlocklist checks the address against the community blocklist as it
+// was resolved for the proposal, so later list edits don't change eligibility.
+func (h *Helpers) validateBlocklist(addr string, p models.Proposal) error {
+	if !h.A.Config.Features["validateBlocklist"] {
+		return nil
+	}
+
+	blockList, err := models.GetListForCommunityByType(h.A.DB, p.Community_id, "block")
+	if err != nil {
+		return nil
+	}
+	version, err := models.GetListVersionForProposal(h.A.DB, blockList.ID, &p)
+	if err != nil {
+		return err
+	}
+	isBlocked := funk.Contains(version.Addresses, addr)
+
+	isTest := flag.Lookup("test.v") != nil
+
+	if isBlocked && !isTest {
+		return errors.New("User does not have permission.")
+	}
+	return nil
+}
+
+// consumeSignature marks the signed message of a mutation as used, so a
+// captured request can't be replayed while its timestamp is still valid.
+// Vouchers are consumed by their encoded transaction message. It is the
+// last step before the write and takes the write's transaction, so a
+// request turned down by validation can be retried with its signature.
+func (h *Helpers) consumeSignature(db *shared.Database, addr, message string, voucher *shared.Voucher) error {
+	if voucher != nil {
+		message = shared.EncodeMessageFromVoucher(voucher)
+	}
+	return models.UseSignedMessage(db, addr, message)
+}
+
+// withSignature runs write in a transaction that spends the signature
+// first, so the signature is only used up by a write that lands.
+func (h *Helpers) withSignature(
+	addr, message string,
+	voucher *shared.Voucher,
+	write func(tx *shared.Database) error,
+) error {
+	return h.A.DB.WithTx(func(tx *shared.Database) error {
+		if err := h.consumeSignature(tx, addr, message, voucher); err != nil {
+			return err
+		}
+		return write(tx)
+	})
+}
+
+// purgeUsedSignatures forgets used messages once their timestamps have
+// expired. Timestamps are never accepted further ahead than the clock skew,
+// so every message used before the retention period has expired by then.
+// Without timestamp validation old messages stay acceptable, so they are
+// kept.
+func (h *Helpers) purgeUsedSignatures() error {
+	if !h.A.Config.Features["validateTimestamps"] {
+		return nil
+	}
+	return models.PurgeUsedSignatures(h.A.DB, time.Now().Add(-usedSignatureRetention))
+}
+
+// Need to move this to conditional middleware
+func (h *Helpers) validateTimestamp(timestamp string, expiry int) error {
+	if !h.A.Config.Features["validateTimestamps"] {
+		return nil
+	}
+	// check timestamp and ensure no longer than expiry seconds has passed
+	stamp, _ := strconv.ParseInt(timestamp, 10, 64)
+	uxTime := time.Unix(stamp/1000, (stamp%1000)*1000*1000)
+	diff := time.Now().UTC().Sub(uxTime).Seconds()
+	if diff > float64(expiry) {
+		err := errors.New("Timestamp on request has expired.")
+		log.Error().Err(err).Msgf("expiry error: %v", diff)
+		return err
+	}
+	// a timestamp ahead of the clock would outlive the used signature record
+	if -diff > maxTimestampSkew.Seconds() {
+		err := errors.New("Timestamp on request is in the future.")
+		log.Error().Err(err).Msgf("expiry error: %v", diff)
+		return err
+	}
+	return nil
+}
+
+func (h *Helpers) validateUser(addr, timestamp string, compositeSignatures *[]shared.CompositeSignature) error {
+
+	if err := h.validateTimestamp(timestamp, 60); err != nil {
+		return err
+	}
+
+	if err := h.validateUserSignature(addr, timestamp, compositeSignatures); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (h *Helpers) validateUserViaVoucher(addr string, voucher *shared.Voucher) error {
+	timestamp := voucher.Arguments[0]["value"]
+	if err := h.validateTimestamp(timestamp, 60); err != nil {
+		return err
+	}
+
+	compositeSignatures := shared.GetUserCompositeSignatureFromVoucher(voucher)
+	// Validate authorizer
+	authorizer := voucher.Authorizers[0]
+	if authorizer != addr || authorizer != (*compositeSignatures)[0].Addr {
+		err := errors.New("authorizer address must match voter address and envelope signer")
+		log.Error().Err(err)
+		return err
+	}
+	// validate signature using encoded transaction payload as message
+	message := shared.EncodeMessageFromVoucher(voucher)
+	if err := h.validateTxSignature(addr, message, compositeSignatures); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (h *Helpers) validateUserWithRole(addr, timestamp string, compositeSignatures *[]shared.CompositeSignature, communityId int, role string) error {
+	//print out all the params
+	if err := h.validateTimestamp(timestamp, 60); err != nil {
+		return err
+	}
+	if err := h.validateUserSignature(addr, timestamp, compositeSignatures); err != nil {
+		return err
+	}
+	if err := models.EnsureRoleForCommunity(h.A.DB, addr, communityId, role); err != nil {
+		errMsg := fmt.Sprintf("Account %s is not an author for community %d.", addr, communityId)
+		log.Error().Err(err).Msg(errMsg)
+		return err
+	}
+
+	return nil
+}
+
+func (h *Helpers) validateUserWithPermission(
+	addr, timestamp string,
+	compositeSignatures *[]shared.CompositeSignature,
+	communityId int,
+	permission string,
+) error {
+	if err := h.validateUser(addr, timestamp, compositeSignatures); err != nil {
+		return err
+	}
+	if err := models.EnsurePermissionForCommunity(h.A.DB, addr, communityId, permission); err != nil {
+		log.Error().Err(err).Msg("Permission denied.")
+		return err
+	}
+
+	return nil
+}
+
+func (h *Helpers) validateUserWithPermissionViaVoucher(
+	addr string,
+	voucher *shared.Voucher,
+	communityId int,
+	permission string,
+) error {
+	if err := h.validateUserViaVoucher(addr, voucher); err != nil {
+		return err
+	}
+	if err := models.EnsurePermissionForCommunity(h.A.DB, addr, communityId, permission); err != nil {
+		log.Error().Err(err).Msg("Permission denied.")
+		return err
+	}
+
+	return nil
+}
+
+// validateCommunityPermission is the single entry point write handlers use to
+// check a signed request against the community permission matrix.
+func (h *Helpers) validateCommunityPermission(
+	communityId int,
+	payload shared.TimestampSignaturePayload,
+	voucher *shared.Voucher,
+	permission string,
+) error {
+	if voucher != nil {
+		return h.validateUserWithPermissionViaVoucher(payload.Signing_addr, voucher, communityId, permission)
+	}
+	return h.validateUserWithPermission(
+		payload.Signing_addr,
+		payload.Timestamp,
+		payload.Composite_signatures,
+		communityId,
+		permission,
+	)
+}
+
+// ensureCanManageRole checks the address may grant or revoke the given user
+// type. The admin role can only be managed by admins.
+func (h *Helpers) ensureCanManageRole(addr string, communityId int, userType string) error {
+	if userType == "admin" {
+		return models.EnsureRoleForCommunity(h.A.DB, addr, communityId, "admin")
+	}
+	return models.EnsurePermissionForCommunity(h.A.DB, addr, communityId, models.PermManageMembers)
+}
+
+func (h *Helpers) validateUserWithRoleViaVoucher(addr string, voucher *shared.Voucher, communityId int, role string) error {
+	timestamp := voucher.Arguments[0]["value"]
+	if err := h.validateTimestamp(timestamp, 60); err != nil {
+		return err
+	}
+
+	compositeSignatures := shared.GetUserCompositeSignatureFromVoucher(voucher)
+	// Validate authorizer
+	authorizer := voucher.Authorizers[0]
+	if authorizer != addr || authorizer != (*compositeSignatures)[0].Addr {
+		err := errors.New("authorizer address must match voter address and envelope signer")
+		log.Error().Err(err)
+		return err
+	}
+
+	// validate signature using encoded transaction payload as message
+	message := shared.EncodeMessageFromVoucher(voucher)
+	if err := h.validateTxSignature(addr, message, compositeSignatures); err != nil {
+		return err
+	}
+	if err := models.EnsureRoleForCommunity(h.A.DB, addr, communityId, role); err != nil {
+		errMsg := fmt.Sprintf("Account %s is not an author for community %d.", addr, communityId)
+		log.Error().Err(err).Msg(errMsg)
+		return err
+	}
+
+	return nil
+}
+
+func (h *Helpers) initStrategy(name string) Strategy {
+	s := strategyMap[name]
+	if s == nil {
+		return nil
+	}
+
+	s.InitStrategy(h.A.FlowAdapter, h.A.DB)
+
+	return s
+}
+
+func (h *Helpers) pinJSONToIpfs(data interface{}) (*string, error) {
+	shouldOverride := flag.Lookup("ipfs-override").Value.(flag.Getter).Get().(bool)
+	if shouldOverride {
+		dummyHash := "dummy-hash"
+		return &dummyHash, nil
+	}
+
+	pin, err := h.A.IpfsClient.PinJson(data)
+	if err != nil {
+		return nil, err
+	}
+	return &pin.IpfsHash, nil
+}
+
+// pinProposalAttachments pins the public attachments of a proposal that
+// are not on IPFS yet, so the proposal can reference them by CID.
+func (h *Helpers) pinProposalAttachments(proposalId int) error {
+	attachments, err := models.GetProposalAttachments(h.A.DB, proposalId)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range attachments {
+		if a.Private || a.Cid != nil {
+			continue
+		}
+		upload := models.Upload{ID: a.Upload_id}
+		if err := upload.GetUploadById(h.A.DB); err != nil {
+			return err
+		}
+		cid, err := h.pinUploadToIpfs(upload)
+		if err != nil {
+			return err
+		}
+		if err := upload.SetCid(h.A.DB, *cid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *Helpers) pinUploadToIpfs(upload models.Upload) (*string, error) {
+	shouldOverride := flag.Lookup("ipfs-override").Value.(flag.Getter).Get().(bool)
+	if shouldOverride {
+		dummyHash := "dummy-hash"
+		return &dummyHash, nil
+	}
+
+	content, err := h.A.Storage.Get(upload.Key)
+	if err != nil {
+		return nil, err
+	}
+	pin, err := h.A.IpfsClient.PinFile(bytes.NewReader(content), upload.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &pin.IpfsHash, nil
+}
+
+// fetchPinnedContent reads content back from IPFS, which is unavailable
+// when pinning is overridden.
+func (h *Helpers) fetchPinnedContent(cid string) ([]byte, error) {
+	shouldOverride := flag.Lookup("ipfs-override").Value.(flag.Getter).Get().(bool)
+	if shouldOverride {
+		return nil, errors.New("IPFS is disabled")
+	}
+	return h.A.IpfsClient.FetchContent(cid)
+}
+
+// verifyCid re-serializes a record the way it is pinned and checks it
+// against its stored CID and the content pinned under that CID.
+func (h *Helpers) verifyCid(recordType string, recordId int, record interface{}, cid *string) (models.CidVerification, error) {
+	v := models.CidVerification{
+		Record_type: recordType,
+		Record_id:   recordId,
+		Cid:         cid,
+		Mismatches:  []string{},
+	}
+
+	serialized, err := json.Marshal(record)
+	if err != nil {
+		return v, err
+	}
+	if expected, err := shared.ComputeCid(serialized); err == nil {
+		v.Expected_cid = &expected
+		v.Cid_matches = cid != nil && *cid == expected
+	}
+
+	if cid == nil {
+		v.Mismatches = append(v.Mismatches, "The record has no CID.")
+		return v, nil
+	}
+
+	content, err := h.fetchPinnedContent(*cid)
+	if err != nil {
+		v.Mismatches = append(v.Mismatches, fmt.Sprintf("Pinned content could not be fetched: %s.", err))
+		return v, nil
+	}
+	v.Fetched = true
+
+	if pinned, err := shared.ComputeCid(content); err == nil {
+		v.Pinned_cid = &pinned
+		v.Pinned_matches = pinned == *cid
+		if !v.Pinned_matches {
+			v.Mismatches = append(v.Mismatches, "Pinned content does not hash to the stored CID.")
+		}
+	}
+
+	fields, err := models.DiffRecordFields(recordType, serialized, content)
+	if err != nil {
+		v.Mismatches = append(v.Mismatches, "Pinned content is not a JSON record.")
+		return v, nil
+	}
+	for _, field := range fields {
+		v.Mismatches = append(v.Mismatches, fmt.Sprintf("Field %s differs from the pinned content.", field))
+	}
+
+	v.Verified = len(v.Mismatches) == 0
+	return v, nil
+}
+
+// queuePin schedules a record that was just written to be pinned. A record
+// that fails to queue keeps a missing CID, so reconciliation still finds it.
+func (h *Helpers) queuePin(recordType string, recordId int) {
+	if err := models.QueuePin(h.A.DB, recordType, recordId); err != nil {
+		log.Error().Err(err).Msgf("Error queueing pin for %s %d.", recordType, recordId)
+	}
+}
+
+func (h *Helpers) processPins() error {
+	pins, err := models.GetPendingPins(h.A.DB, maxPinAttempts, pinBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, pin := range pins {
+		communityId, err := pin.CommunityId(h.A.DB)
+		if err != nil {
+			log.Error().Err(err).Msgf("Error getting the community of %s %d.", pin.Record_type, pin.Record_id)
+			continue
 		}
-		// If signing address is not user address, verify they have admin status in this community
-		var communityAdmin = models.CommunityUser{Community_id: payload.Community_id, Addr: payload.Signing_addr, User_type: "admin"}
-		if err := communityAdmin.GetCommunityUser(h.A.DB); err != nil {
-			USER_MUST_BE_ADMIN_ERR := errors.New("User must be community admin to grant privileges.")
-			log.Error().Err(err).Msg("Database error.")
-			log.Error().Err(USER_MUST_BE_ADMIN_ERR)
-			return http.StatusForbidden, USER_MUST_BE_ADMIN_ERR
+		// pins over the community's quota wait for the next month
+		if errResponse := h.checkUsageQuota(communityId, models.UsagePins, 1); errResponse.Retry_at != nil {
+			log.Warn().Msgf("Deferring pin of %s %d, %s", pin.Record_type, pin.Record_id, errResponse.Details)
+			if err := pin.Defer(h.A.DB, *errResponse.Retry_at); err != nil {
+				log.Error().Err(err).Msg("Error deferring pin.")
+			}
+			continue
 		}
-	}
-	// only an account can add itself as a "member", unless an admin is granting
-	// an address a privileged role
-	if payload.User_type == "member" && payload.Addr != payload.Signing_addr {
-		CANNOT_ADD_MEMBER_ERR := errors.New(
-			"An account can only add itself as a community member, unless an admin is granting privileged role.",
-		)
-		log.Error().Err(CANNOT_ADD_MEMBER_ERR)
-		return http.StatusForbidden, CANNOT_ADD_MEMBER_ERR
+
+		if err := h.processPin(pin); err != nil {
+			log.Error().Err(err).Msgf("Error pinning %s %d.", pin.Record_type, pin.Record_id)
+			if err := pin.MarkFailed(h.A.DB, err); err != nil {
+				log.Error().Err(err).Msg("Error recording failed pin.")
+			}
+			continue
+		}
+		h.recordUsage(communityId, models.UsagePins, 1)
 	}
 
-	if payload.Voucher != nil {
-		if err := h.validateUserViaVoucher(payload.Signing_addr, payload.Voucher); err != nil {
-			log.Error().Err(err)
-			return http.StatusForbidden, err
+	return nil
+}
+
+// processPin pins the current content of a record and stores its CID.
+func (h *Helpers) processPin(pin *models.Pin) error {
+	var record interface{}
+	var setCid func(db *shared.Database, cid string) error
+
+	switch pin.Record_type {
+	case models.PinCommunity:
+		c := models.Community{ID: pin.Record_id}
+		if err := c.GetCommunity(h.A.DB); err != nil {
+			return err
 		}
-	} else {
-		if err := h.validateUser(payload.Signing_addr, payload.Timestamp, payload.Composite_signatures); err != nil {
-			log.Error().Err(err)
-			return http.StatusForbidden, err
+		c.Cid = nil
+		record, setCid = c, c.SetCid
+	case models.PinProposal:
+		p := models.Proposal{ID: pin.Record_id}
+		if err := p.GetProposalById(h.A.DB); err != nil {
+			return err
 		}
+		if err := h.pinProposalAttachments(p.ID); err != nil {
+			return err
+		}
+		if err := h.fetchPinnedProposalContent(&p); err != nil {
+			return err
+		}
+		p.Cid = nil
+		record, setCid = p, p.SetCid
+	case models.PinProposalResults:
+		r := models.ProposalResults{Proposal_id: pin.Record_id}
+		if err := r.GetLatestProposalResultsById(h.A.DB); err != nil {
+			return err
+		}
+		r.Cid = nil
+		record, setCid = r, r.SetCid
+	case models.PinList:
+		l := models.List{ID: pin.Record_id}
+		if err := l.GetListById(h.A.DB); err != nil {
+			return err
+		}
+		l.Cid = nil
+		record, setCid = l, l.SetCid
+	default:
+		return fmt.Errorf("unknown pin record type %s", pin.Record_type)
 	}
 
-	// check that community user doesnt already exist
-	// should throw a "ErrNoRows" error
-	u := payload.CommunityUser
-	if err := u.GetCommunityUser(h.A.DB); err == nil {
-		errMsg := fmt.Sprintf("Error: Address %s is already a %s of community %d.\n", u.Addr, u.User_type, u.Community_id)
-		log.Error().Err(err).Msg(errMsg)
-		return http.StatusBadRequest, errors.New(errMsg)
+	cid, err := h.pinJSONToIpfs(record)
+	if err != nil {
+		return err
 	}
 
-	// Grant appropriate roles
-	if u.User_type == "admin" {
-		if err := models.GrantAdminRolesToAddress(h.A.DB, u.Community_id, u.Addr); err != nil {
-			log.Error().Err(err)
-			return http.StatusInternalServerError, err
+	return h.A.DB.WithTx(func(tx *shared.Database) error {
+		if err := setCid(tx, *cid); err != nil {
+			return err
 		}
-	} else if u.User_type == "author" {
-		if err := models.GrantAuthorRolesToAddress(h.A.DB, u.Community_id, u.Addr); err != nil {
-			return http.StatusInternalServerError, err
+		return pin.MarkPinned(tx, *cid)
+	})
+}
+
+// reconcilePins lists the records whose CID is missing or unpinned, and
+// queues them to be pinned again when asked to.
+func (h *Helpers) reconcilePins(
+	payload models.PinReconcilePayload,
+	pageParams shared.PageParams,
+) ([]*models.UnpinnedRecord, shared.PageParams, int, error) {
+	if err := h.validatePlatformAdmin(payload.TimestampSignaturePayload); err != nil {
+		return nil, pageParams, http.StatusForbidden, err
+	}
+
+	records, totalRecords, err := models.GetUnpinnedRecords(h.A.DB, pageParams)
+	if err != nil {
+		return nil, pageParams, http.StatusInternalServerError, err
+	}
+	pageParams.TotalRecords = totalRecords
+
+	if payload.Requeue {
+		if err := h.consumeSignature(h.A.DB, payload.Signing_addr, payload.Timestamp, nil); err != nil {
+			return nil, pageParams, http.StatusForbidden, err
 		}
-	} else {
-		// grant member role
-		if err := u.CreateCommunityUser(h.A.DB); err != nil {
-			log.Error().Err(err)
-			return http.StatusInternalServerError, err
+		for _, r := range records {
+			h.queuePin(r.Record_type, r.Record_id)
 		}
 	}
 
-	return http.StatusCreated, nil
+	return records, pageParams, http.StatusOK, nil
 }
 
-func (h *Helpers) updateAddressesInList(id int, payload models.ListUpdatePayload, action string) (int, error) {
-	l := models.List{ID: id}
+// validatePlatformAdmin checks that a request was signed by an address on
+// the admin allowlist.
+func (h *Helpers) validatePlatformAdmin(payload shared.TimestampSignaturePayload) error {
+	if !h.A.AdminAllowlist.Contains(payload.Signing_addr) {
+		return fmt.Errorf("Address %s is not a platform admin.", payload.Signing_addr)
+	}
+	return h.validateUser(payload.Signing_addr, payload.Timestamp, payload.Composite_signatures)
+}
 
-	// get current proposal from DB
-	if err := l.GetListById(h.A.DB); err != nil {
-		errMsg := fmt.Sprintf("Error querying list with id %v.", id)
-		log.Error().Err(err).Msg(errMsg)
+// validatePlatformAdminSession returns the address of a session issued to a
+// platform admin, which admin reads accept in place of a signature.
+func (h *Helpers) validatePlatformAdminSession(token string) (string, int, error) {
+	addr, err := h.sessionAddr(token)
+	if err != nil {
+		return "", http.StatusUnauthorized, err
+	}
+	if !h.A.AdminAllowlist.Contains(addr) {
+		return "", http.StatusForbidden, fmt.Errorf("Address %s is not a platform admin.", addr)
+	}
+	return addr, http.StatusOK, nil
+}
+
+// validateTenantAdminChange checks a signed change to what the tenant
+// curates, which its own admins may make as well as platform admins.
+func (h *Helpers) validateTenantAdminChange(tenant *models.Tenant, payload shared.TimestampSignaturePayload) error {
+	if !h.A.AdminAllowlist.Contains(payload.Signing_addr) && !tenant.IsAdmin(payload.Signing_addr) {
+		return fmt.Errorf("Address %s is not an admin of tenant %s.", payload.Signing_addr, tenant.Slug)
+	}
+	return h.validateUser(payload.Signing_addr, payload.Timestamp, payload.Composite_signatures)
+}
+
+// fetchTenantCommunity fetches a community of the tenant, other tenants'
+// communities are not found.
+func (h *Helpers) fetchTenantCommunity(tenant *models.Tenant, id int) (models.Community, error) {
+	c, err := h.fetchCommunity(id)
+	if err != nil {
+		return models.Community{}, err
+	}
+	if c.Tenant_id != tenant.ID {
+		return models.Community{}, fmt.Errorf("Community %d not found in tenant %s.", id, tenant.Slug)
+	}
+	return c, nil
+}
+
+func (h *Helpers) getAdminCommunities(
+	token string,
+	filter string,
+	pageParams shared.PageParams,
+) ([]*models.Community, shared.PageParams, int, error) {
+	if _, httpStatus, err := h.validatePlatformAdminSession(token); err != nil {
+		return nil, pageParams, httpStatus, err
+	}
+	switch filter {
+	case "", models.CommunityFilterFeatured, models.CommunityFilterArchived, models.CommunityFilterSuspended:
+	default:
+		return nil, pageParams, http.StatusBadRequest, fmt.Errorf("Unknown community filter %q.", filter)
+	}
+
+	communities, totalRecords, err := models.GetCommunitiesForAdmin(h.A.DB, filter, pageParams)
+	if err != nil {
+		return nil, pageParams, http.StatusInternalServerError, err
+	}
+	pageParams.TotalRecords = totalRecords
+
+	return communities, pageParams, http.StatusOK, nil
+}
+
+func (h *Helpers) setCommunityFeatured(
+	tenant *models.Tenant,
+	id int,
+	payload shared.TimestampSignaturePayload,
+	featured bool,
+) (models.Community, int, error) {
+	if err := h.validateTenantAdminChange(tenant, payload); err != nil {
+		return models.Community{}, http.StatusForbidden, err
+	}
+	c, err := h.fetchTenantCommunity(tenant, id)
+	if err != nil {
+		return models.Community{}, http.StatusNotFound, err
+	}
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, nil, func(tx *shared.Database) error {
+		return c.SetFeatured(tx, featured)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.Community{}, http.StatusForbidden, err
+	} else if err != nil {
+		return models.Community{}, http.StatusInternalServerError, err
+	}
+	return c, http.StatusOK, nil
+}
+
+// suspendCommunity archives a community on behalf of the platform. Unlike
+// an archive, its admins can't undo it.
+func (h *Helpers) suspendCommunity(id int, payload models.SuspendCommunityPayload) (models.Community, int, error) {
+	validate := newValidator()
+	if vErr := validate.Struct(payload); vErr != nil {
+		return models.Community{}, http.StatusBadRequest, errors.New("A reason is required to suspend a community.")
+	}
+	if err := h.validatePlatformAdmin(payload.TimestampSignaturePayload); err != nil {
+		return models.Community{}, http.StatusForbidden, err
+	}
+	c, err := h.fetchCommunity(id)
+	if err != nil {
+		return models.Community{}, http.StatusNotFound, err
+	}
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, nil, func(tx *shared.Database) error {
+		return c.SuspendCommunity(tx, payload.Reason)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.Community{}, http.StatusForbidden, err
+	} else if err != nil {
+		return models.Community{}, http.StatusInternalServerError, err
+	}
+	return c, http.StatusOK, nil
+}
+
+func (h *Helpers) unsuspendCommunity(id int, payload shared.TimestampSignaturePayload) (models.Community, int, error) {
+	if err := h.validatePlatformAdmin(payload); err != nil {
+		return models.Community{}, http.StatusForbidden, err
+	}
+	c, err := h.fetchCommunity(id)
+	if err != nil {
+		return models.Community{}, http.StatusNotFound, err
+	}
+	if c.Suspended_at == nil {
+		return models.Community{}, http.StatusBadRequest, errors.New("Community is not suspended.")
+	}
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, nil, func(tx *shared.Database) error {
+		return c.UnsuspendCommunity(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.Community{}, http.StatusForbidden, err
+	} else if err != nil {
+		return models.Community{}, http.StatusInternalServerError, err
+	}
+	return c, http.StatusOK, nil
+}
+
+func (h *Helpers) getFailedJobs(
+	token string,
+	job string,
+	pageParams shared.PageParams,
+) ([]*models.FailedJob, shared.PageParams, int, error) {
+	if _, httpStatus, err := h.validatePlatformAdminSession(token); err != nil {
+		return nil, pageParams, httpStatus, err
+	}
+
+	failures, totalRecords, err := models.GetFailedJobs(h.A.DB, job, pageParams)
+	if err != nil {
+		return nil, pageParams, http.StatusInternalServerError, err
+	}
+	pageParams.TotalRecords = totalRecords
+
+	return failures, pageParams, http.StatusOK, nil
+}
+
+func (h *Helpers) getAddressList(
+	token string,
+	list models.AddressList,
+	pageParams shared.PageParams,
+) ([]*models.ListedAddress, shared.PageParams, int, error) {
+	if _, httpStatus, err := h.validatePlatformAdminSession(token); err != nil {
+		return nil, pageParams, httpStatus, err
+	}
+
+	listed, totalRecords, err := models.GetListedAddresses(h.A.DB, list, pageParams)
+	if err != nil {
+		return nil, pageParams, http.StatusInternalServerError, err
+	}
+	pageParams.TotalRecords = totalRecords
+
+	return listed, pageParams, http.StatusOK, nil
+}
+
+func (h *Helpers) createApiKey(payload models.CreateApiKeyPayload) (models.ApiKeyWithToken, int, error) {
+	validate := newValidator()
+	if vErr := validate.Struct(payload); vErr != nil {
+		return models.ApiKeyWithToken{}, http.StatusBadRequest, vErr
+	}
+	if err := h.validatePlatformAdmin(payload.TimestampSignaturePayload); err != nil {
+		return models.ApiKeyWithToken{}, http.StatusForbidden, err
+	}
+
+	community := models.Community{ID: payload.Community_id}
+	if err := community.GetCommunity(h.A.DB); err != nil {
+		return models.ApiKeyWithToken{}, http.StatusNotFound,
+			fmt.Errorf("Community %d not found.", payload.Community_id)
+	}
+
+	key := models.ApiKey{
+		Community_id: community.ID,
+		Name:         payload.Name,
+		Rate_limit:   payload.Rate_limit,
+		Created_by:   payload.Signing_addr,
+	}
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, nil, func(tx *shared.Database) error {
+		return key.CreateApiKey(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.ApiKeyWithToken{}, http.StatusForbidden, err
+	} else if err != nil {
+		return models.ApiKeyWithToken{}, http.StatusInternalServerError, err
+	}
+
+	// keys don't expire, they are revoked
+	token, err := h.A.TokenSigner.Sign(models.ApiKeyClaims{
+		TokenClaims: shared.TokenClaims{
+			Audience: models.ApiKeyTokenAudience,
+			IssuedAt: time.Now().Unix(),
+		},
+		Api_key_id:   key.ID,
+		Community_id: key.Community_id,
+	})
+	if err != nil {
+		return models.ApiKeyWithToken{}, http.StatusInternalServerError, err
+	}
+
+	return models.ApiKeyWithToken{ApiKey: key, Token: token}, http.StatusCreated, nil
+}
+
+func (h *Helpers) getApiKeys(
+	token string,
+	communityId int,
+	pageParams shared.PageParams,
+) ([]*models.ApiKey, shared.PageParams, int, error) {
+	if _, httpStatus, err := h.validatePlatformAdminSession(token); err != nil {
+		return nil, pageParams, httpStatus, err
+	}
+
+	keys, totalRecords, err := models.GetApiKeys(h.A.DB, communityId, pageParams)
+	if err != nil {
+		return nil, pageParams, http.StatusInternalServerError, err
+	}
+	pageParams.TotalRecords = totalRecords
+
+	return keys, pageParams, http.StatusOK, nil
+}
+
+func (h *Helpers) getApiKeyUsage(token string, apiKeyId int, days int) ([]models.ApiKeyUsage, int, error) {
+	if _, httpStatus, err := h.validatePlatformAdminSession(token); err != nil {
+		return nil, httpStatus, err
+	}
+
+	key := models.ApiKey{ID: apiKeyId}
+	if err := key.GetApiKeyById(h.A.DB); err != nil {
+		return nil, http.StatusNotFound, errors.New("API key not found.")
+	}
+
+	usage, err := models.GetApiKeyUsage(h.A.DB, key.ID, days)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return usage, http.StatusOK, nil
+}
+
+func (h *Helpers) revokeApiKey(apiKeyId int, payload shared.TimestampSignaturePayload) (models.ApiKey, int, error) {
+	if err := h.validatePlatformAdmin(payload); err != nil {
+		return models.ApiKey{}, http.StatusForbidden, err
+	}
+
+	key := models.ApiKey{ID: apiKeyId}
+	if err := key.GetApiKeyById(h.A.DB); errors.Is(err, pgx.ErrNoRows) {
+		return models.ApiKey{}, http.StatusNotFound, errors.New("API key not found.")
+	} else if err != nil {
+		return models.ApiKey{}, http.StatusInternalServerError, err
+	}
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, nil, func(tx *shared.Database) error {
+		return key.RevokeApiKey(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.ApiKey{}, http.StatusForbidden, err
+	} else if errors.Is(err, pgx.ErrNoRows) {
+		// nothing is updated once the key is revoked
+		return models.ApiKey{}, http.StatusBadRequest, errors.New("API key has already been revoked.")
+	} else if err != nil {
+		return models.ApiKey{}, http.StatusInternalServerError, err
+	}
+
+	return key, http.StatusOK, nil
+}
+
+// authenticateApiKey checks token is a live API key for the community and
+// counts the request against it.
+func (h *Helpers) authenticateApiKey(token string, communityId int) (models.ApiKey, int, error) {
+	if token == "" {
+		return models.ApiKey{}, http.StatusUnauthorized, errors.New("Missing API key.")
+	}
+	var claims models.ApiKeyClaims
+	if err := h.A.TokenSigner.Verify(token, &claims); err != nil {
+		return models.ApiKey{}, http.StatusUnauthorized, err
+	}
+	if claims.Audience != models.ApiKeyTokenAudience || claims.Community_id != communityId {
+		return models.ApiKey{}, http.StatusUnauthorized, shared.ErrInvalidToken
+	}
+
+	key := models.ApiKey{ID: claims.Api_key_id}
+	if err := key.GetApiKeyById(h.A.DB); errors.Is(err, pgx.ErrNoRows) {
+		return models.ApiKey{}, http.StatusUnauthorized, errors.New("API key not found.")
+	} else if err != nil {
+		return models.ApiKey{}, http.StatusInternalServerError, err
+	}
+	if key.Revoked_at != nil {
+		return models.ApiKey{}, http.StatusUnauthorized, errors.New("API key has been revoked.")
+	}
+
+	return key, http.StatusOK, nil
+}
+
+// streamVotesWithWeights hands the votes of a proposal to fn a chunk at a
+// time, weighed by its strategy.
+func (h *Helpers) streamVotesWithWeights(p models.Proposal, fn func(votes []*models.VoteWithBalance) error) error {
+	return models.StreamVotesForProposal(
+		h.A.DB,
+		p.ID,
+		*p.Strategy,
+		0,
+		tallyChunkSize,
+		func(votes []*models.VoteWithBalance) error {
+			votesWithWeights, err := h.useStrategyGetVotes(p, votes)
+			if err != nil {
+				return err
+			}
+			return fn(votesWithWeights)
+		},
+	)
+}
+
+// updateAddressList adds or removes addresses from a platform list and
+// reloads the in-memory copies, returning how many entries changed.
+func (h *Helpers) updateAddressList(
+	list models.AddressList,
+	payload models.AddressListPayload,
+	add bool,
+) (int64, int, error) {
+	validate := newValidator()
+	if vErr := validate.Struct(payload); vErr != nil {
+		return 0, http.StatusBadRequest, errors.New("Invalid list addresses.")
+	}
+	if err := h.validatePlatformAdmin(payload.TimestampSignaturePayload); err != nil {
+		return 0, http.StatusForbidden, err
+	}
+	// an admin can't remove themselves, which also keeps the list from
+	// being emptied
+	if !add && list == models.PlatformAdmins && funk.ContainsString(payload.Addresses, payload.Signing_addr) {
+		return 0, http.StatusBadRequest, errors.New("Admins can't remove themselves.")
+	}
+
+	var changed int64
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, nil, func(tx *shared.Database) error {
+		if add {
+			changed = int64(len(payload.Addresses))
+			return models.AddListedAddresses(tx, list, payload.Addresses, payload.Reason, payload.Signing_addr)
+		}
+		var err error
+		changed, err = models.RemoveListedAddresses(tx, list, payload.Addresses)
+		return err
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return 0, http.StatusForbidden, err
+	} else if err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+
+	if err := h.A.ReloadAddressLists(); err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+	return changed, http.StatusOK, nil
+}
+
+// getHomepage assembles the curated homepage sections of the tenant.
+func (h *Helpers) getHomepage(tenant *models.Tenant) ([]*models.HomepageSection, error) {
+	sections, err := models.GetHomepageSections(h.A.DB, tenant.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, section := range sections {
+		if err := section.LoadCommunities(h.A.DB); err != nil {
+			return nil, err
+		}
+	}
+	return sections, nil
+}
+
+func (h *Helpers) setCommunityPinned(
+	tenant *models.Tenant,
+	id int,
+	payload shared.TimestampSignaturePayload,
+	pinned bool,
+) (models.Community, int, error) {
+	if err := h.validateTenantAdminChange(tenant, payload); err != nil {
+		return models.Community{}, http.StatusForbidden, err
+	}
+	c, err := h.fetchTenantCommunity(tenant, id)
+	if err != nil {
+		return models.Community{}, http.StatusNotFound, err
+	}
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, nil, func(tx *shared.Database) error {
+		return c.SetHomepagePinned(tx, pinned)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.Community{}, http.StatusForbidden, err
+	} else if err != nil {
+		return models.Community{}, http.StatusInternalServerError, err
+	}
+	return c, http.StatusOK, nil
+}
+
+func (h *Helpers) setHomepageOrder(tenant *models.Tenant, payload models.HomepageOrderPayload) (int, error) {
+	validate := newValidator()
+	if vErr := validate.Struct(payload); vErr != nil {
+		return http.StatusBadRequest, errors.New("Between 1 and 100 community IDs are required.")
+	}
+	if err := h.validateTenantAdminChange(tenant, payload.TimestampSignaturePayload); err != nil {
+		return http.StatusForbidden, err
+	}
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, nil, func(tx *shared.Database) error {
+		return models.SetHomepageOrder(tx, tenant.ID, payload.Community_ids)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return http.StatusForbidden, err
+	} else if err != nil {
 		return http.StatusInternalServerError, err
 	}
+	return http.StatusOK, nil
+}
 
-	validate := validator.New()
+// saveHomepageSection creates a section when id is 0, and otherwise applies
+// the fields the payload sets to the section.
+func (h *Helpers) saveHomepageSection(
+	tenant *models.Tenant,
+	id int,
+	payload models.HomepageSectionPayload,
+) (models.HomepageSection, int, error) {
+	validate := newValidator()
 	if vErr := validate.Struct(payload); vErr != nil {
-		errMsg := "Remove from list validation error."
-		if action == "add" {
-			errMsg = "Add to list validation error."
+		return models.HomepageSection{}, http.StatusBadRequest, vErr
+	}
+	if err := h.validateTenantAdminChange(tenant, payload.TimestampSignaturePayload); err != nil {
+		return models.HomepageSection{}, http.StatusForbidden, err
+	}
+
+	section := models.HomepageSection{ID: id, Size: 6, Tenant_id: tenant.ID}
+	if id != 0 {
+		if err := section.GetHomepageSection(h.A.DB); err != nil {
+			return models.HomepageSection{}, http.StatusNotFound, err
 		}
-		log.Error().Err(vErr).Msg(errMsg)
-		return http.StatusBadRequest, errors.New(errMsg)
+		if section.Tenant_id != tenant.ID {
+			return models.HomepageSection{}, http.StatusNotFound, fmt.Errorf("Homepage section %d not found in tenant %s.", id, tenant.Slug)
+		}
+	} else if payload.Title == nil || payload.Kind == nil {
+		return models.HomepageSection{}, http.StatusBadRequest, errors.New("A title and kind are required.")
+	}
+
+	if payload.Title != nil {
+		section.Title = *payload.Title
+	}
+	if payload.Kind != nil {
+		section.Kind = *payload.Kind
+	}
+	if payload.Category != nil {
+		section.Category = payload.Category
+	}
+	if payload.Display_order != nil {
+		section.Display_order = *payload.Display_order
+	}
+	if payload.Size != nil {
+		section.Size = *payload.Size
+	}
+	if section.Kind == models.SectionCategory && (section.Category == nil || *section.Category == "") {
+		return models.HomepageSection{}, http.StatusBadRequest, errors.New("Category sections need a category.")
+	}
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, nil, func(tx *shared.Database) error {
+		if id == 0 {
+			return section.CreateHomepageSection(tx)
+		}
+		return section.UpdateHomepageSection(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.HomepageSection{}, http.StatusForbidden, err
+	} else if err != nil {
+		return models.HomepageSection{}, http.StatusInternalServerError, err
 	}
+	return section, http.StatusOK, nil
+}
 
-	if err := h.validateUserWithRole(payload.Signing_addr, payload.Timestamp, payload.Composite_signatures, l.Community_id, "admin"); err != nil {
-		log.Error().Err(err)
+func (h *Helpers) deleteHomepageSection(tenant *models.Tenant, id int, payload shared.TimestampSignaturePayload) (int, error) {
+	if err := h.validateTenantAdminChange(tenant, payload); err != nil {
 		return http.StatusForbidden, err
 	}
-
-	if action == "remove" {
-		l.RemoveAddresses(payload.Addresses)
-	} else {
-		l.AddAddresses(payload.Addresses)
+	section := models.HomepageSection{ID: id}
+	if err := section.GetHomepageSection(h.A.DB); err != nil {
+		return http.StatusNotFound, err
 	}
-
-	cid, err := h.pinJSONToIpfs(l)
-	if err != nil {
-		log.Error().Err(err).Msg("IPFS error: " + err.Error())
-		return http.StatusInternalServerError, errors.New("Error pinning JSON to IPFS.")
+	if section.Tenant_id != tenant.ID {
+		return http.StatusNotFound, fmt.Errorf("Homepage section %d not found in tenant %s.", id, tenant.Slug)
 	}
-	l.Cid = cid
 
-	if err := l.UpdateList(h.A.DB); err != nil {
-		errMsg := "Database error updating list."
-		log.Error().Err(err).Msg(errMsg)
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, nil, func(tx *shared.Database) error {
+		return section.DeleteHomepageSection(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return http.StatusForbidden, err
+	} else if err != nil {
 		return http.StatusInternalServerError, err
 	}
-
 	return http.StatusOK, nil
 }
 
-func (h *Helpers) createListForCommunity(payload models.ListPayload) (models.List, int, error) {
-	if existingList, _ := models.GetListForCommunityByType(h.A.DB, payload.Community_id, *payload.List_type); existingList.ID > 0 {
-		errMsg := fmt.Sprintf("List of type %s already exists for community %d.", *payload.List_type, payload.Community_id)
-		return models.List{}, http.StatusBadRequest, errors.New(errMsg)
+func (h *Helpers) getFeatureFlags(token string) ([]*models.FeatureFlag, int, error) {
+	if _, httpStatus, err := h.validatePlatformAdminSession(token); err != nil {
+		return nil, httpStatus, err
 	}
 
-	// validate payload fields
-	validate := validator.New()
-	if vErr := validate.Struct(payload); vErr != nil {
-		errMsg := "Validation error in list payload."
-		log.Error().Err(vErr).Msg(errMsg)
-		return models.List{}, http.StatusBadRequest, errors.New(errMsg)
+	flags, err := models.GetFeatureFlags(h.A.DB)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
 	}
+	return flags, http.StatusOK, nil
+}
 
-	if err := h.validateUserWithRole(payload.Signing_addr, payload.Timestamp, payload.Composite_signatures, payload.Community_id, "admin"); err != nil {
-		log.Error().Err(err)
-		return models.List{}, http.StatusForbidden, err
+func (h *Helpers) fetchFeatureFlag(name string) (*models.FeatureFlag, int, error) {
+	f, err := models.GetFeatureFlag(h.A.DB, name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, http.StatusNotFound, fmt.Errorf("Feature flag %q not found.", name)
+	} else if err != nil {
+		return nil, http.StatusInternalServerError, err
 	}
+	return f, http.StatusOK, nil
+}
 
-	l := payload.List
-
-	cid, err := h.pinJSONToIpfs(l)
+func (h *Helpers) setFeatureRollout(name string, payload models.FeatureRolloutPayload) (*models.FeatureFlag, int, error) {
+	validate := newValidator()
+	if vErr := validate.Struct(payload); vErr != nil {
+		return nil, http.StatusBadRequest, errors.New("Rollout must be a percentage from 0 to 100.")
+	}
+	if err := h.validatePlatformAdmin(payload.TimestampSignaturePayload); err != nil {
+		return nil, http.StatusForbidden, err
+	}
+	f, httpStatus, err := h.fetchFeatureFlag(name)
 	if err != nil {
-		log.Error().Err(err).Msg("IPFS error: " + err.Error())
-		return models.List{}, http.StatusInternalServerError, errors.New("Error pinning JSON to IPFS.")
+		return nil, httpStatus, err
 	}
-	l.Cid = cid
 
-	// create list
-	if err := l.CreateList(h.A.DB); err != nil {
-		return models.List{}, http.StatusInternalServerError, err
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, nil, func(tx *shared.Database) error {
+		return f.SetRollout(tx, *payload.Rollout_percent)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return nil, http.StatusForbidden, err
+	} else if err != nil {
+		return nil, http.StatusInternalServerError, err
 	}
-
-	return l, http.StatusCreated, nil
+	return f, http.StatusOK, nil
 }
 
-func (h *Helpers) validateUserSignature(addr string, message string, sigs *[]shared.CompositeSignature) error {
-	shouldValidateSignature := h.A.Config.Features["validateSigs"]
+// setCommunityFeature turns a flag on or off for a community, or returns
+// it to the rollout when payload.Enabled is nil.
+func (h *Helpers) setCommunityFeature(
+	name string,
+	communityId int,
+	payload models.CommunityFeaturePayload,
+) (*models.FeatureFlag, int, error) {
+	if err := h.validatePlatformAdmin(payload.TimestampSignaturePayload); err != nil {
+		return nil, http.StatusForbidden, err
+	}
+	f, httpStatus, err := h.fetchFeatureFlag(name)
+	if err != nil {
+		return nil, httpStatus, err
+	}
+	if _, err := h.fetchCommunity(communityId); err != nil {
+		return nil, http.StatusNotFound, err
+	}
 
-	if !shouldValidateSignature {
-		return nil
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, nil, func(tx *shared.Database) error {
+		if payload.Enabled == nil {
+			return f.ClearForCommunity(tx, communityId)
+		}
+		return f.SetForCommunity(tx, communityId, *payload.Enabled)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return nil, http.StatusForbidden, err
+	} else if err != nil {
+		return nil, http.StatusInternalServerError, err
 	}
+	return f, http.StatusOK, nil
+}
 
-	hexMessage := hex.EncodeToString([]byte(message))
-	if err := h.A.FlowAdapter.ValidateSignature(addr, hexMessage, sigs, "USER"); err != nil {
+// requireFeature returns ErrFeatureDisabled unless the flag is on for the
+// community.
+func (h *Helpers) requireFeature(communityId int, flag string) error {
+	enabled, err := models.IsFeatureEnabled(h.A.DB, flag, communityId)
+	if err != nil {
 		return err
 	}
-
+	if !enabled {
+		return models.ErrFeatureDisabled
+	}
 	return nil
 }
 
-func (h *Helpers) validateTxSignature(addr string, message string, sigs *[]shared.CompositeSignature) error {
-	shouldValidateSignature := h.A.Config.Features["validateSigs"]
-
-	if !shouldValidateSignature {
-		return nil
+func (h *Helpers) getPlatformStats(token string) (models.PlatformStats, int, error) {
+	if _, httpStatus, err := h.validatePlatformAdminSession(token); err != nil {
+		return models.PlatformStats{}, httpStatus, err
 	}
 
-	if err := h.A.FlowAdapter.ValidateSignature(addr, message, sigs, "TRANSACTION"); err != nil {
-		return err
+	stats, err := models.GetPlatformStats(h.A.DB)
+	if err != nil {
+		return models.PlatformStats{}, http.StatusInternalServerError, err
 	}
-	return nil
+	return stats, http.StatusOK, nil
 }
 
-func (h *Helpers) validateBlocklist(addr string, communityId int) error {
-	if !h.A.Config.Features["validateBlocklist"] {
-		return nil
+// usageQuota is a community's quota for a metric, the one platform admins
+// set for it or else the platform's. 0 is no quota.
+func (h *Helpers) usageQuota(communityId int, metric string) (int64, error) {
+	quotas, err := models.GetCommunityQuotas(h.A.DB, communityId)
+	if err != nil {
+		return 0, err
+	}
+	if quota, ok := quotas[metric]; ok {
+		return quota, nil
 	}
 
-	blockList, _ := models.GetListForCommunityByType(h.A.DB, communityId, "block")
-	isBlocked := funk.Contains(blockList.Addresses, addr)
+	switch metric {
+	case models.UsageVotes:
+		return h.A.Config.Usage_quota_votes, nil
+	case models.UsagePins:
+		return h.A.Config.Usage_quota_pins, nil
+	case models.UsageStorage:
+		return h.A.Config.Usage_quota_storage, nil
+	case models.UsageNotifications:
+		return h.A.Config.Usage_quota_notifications, nil
+	}
+	return 0, nil
+}
 
-	isTest := flag.Lookup("test.v") != nil
+// checkUsageQuota refuses use that would take a community past its quota
+// for the month, until the month is over.
+func (h *Helpers) checkUsageQuota(communityId int, metric string, amount int64) errorResponse {
+	quota, err := h.usageQuota(communityId, metric)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error getting the %s quota of community %d.", metric, communityId)
+		return errIncompleteRequest
+	}
+	if quota == 0 {
+		return nilErr
+	}
 
-	if isBlocked && !isTest {
-		return errors.New("User does not have permission.")
+	period := models.UsagePeriod(time.Now())
+	usage, err := models.GetUsage(h.A.DB, communityId, period)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error getting the usage of community %d.", communityId)
+		return errIncompleteRequest
 	}
-	return nil
+	if usage[metric]+amount <= quota {
+		return nilErr
+	}
+
+	resetsAt := period.AddDate(0, 1, 0)
+	errResponse := errUsageQuotaExceeded
+	errResponse.Details = fmt.Sprintf(errResponse.Details, metric, quota, resetsAt.Format(time.RFC3339))
+	errResponse.Retry_at = &resetsAt
+	return errResponse
 }
 
-// Need to move this to conditional middleware
-func (h *Helpers) validateTimestamp(timestamp string, expiry int) error {
-	if !h.A.Config.Features["validateTimestamps"] {
-		return nil
+// recordUsage counts use against a community, and warns its admins the
+// first time in a month it passes the soft limit of a quota.
+func (h *Helpers) recordUsage(communityId int, metric string, amount int64) {
+	if amount <= 0 {
+		return
 	}
-	// check timestamp and ensure no longer than expiry seconds has passed
-	stamp, _ := strconv.ParseInt(timestamp, 10, 64)
-	uxTime := time.Unix(stamp/1000, (stamp%1000)*1000*1000)
-	diff := time.Now().UTC().Sub(uxTime).Seconds()
-	if diff > float64(expiry) {
-		err := errors.New("Timestamp on request has expired.")
-		log.Error().Err(err).Msgf("expiry error: %v", diff)
-		return err
+	period := models.UsagePeriod(time.Now())
+	used, err := models.RecordUsage(h.A.DB, communityId, metric, period, amount)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error recording %s usage of community %d.", metric, communityId)
+		return
+	}
+	quota, err := h.usageQuota(communityId, metric)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error getting the %s quota of community %d.", metric, communityId)
+		return
+	}
+	m := models.NewUsageMetric(metric, used, quota, h.A.Config.Usage_soft_limit)
+	if m.Status == models.UsageOk {
+		return
+	}
+
+	first, err := models.MarkUsageWarned(h.A.DB, communityId, metric, period)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error recording usage warning of community %d.", communityId)
+		return
+	}
+	if !first {
+		return
+	}
+	log.Warn().Msgf("Community %d used %d of its %s quota of %d.", communityId, used, metric, quota)
+	data := map[string]interface{}{
+		"metric":   metric,
+		"used":     used,
+		"quota":    quota,
+		"status":   m.Status,
+		"resetsAt": period.AddDate(0, 1, 0),
+	}
+	if _, err := models.NotifyCommunityAdmins(h.A.DB, communityId, models.NotificationUsageWarning, data); err != nil {
+		log.Error().Err(err).Msgf("Error warning the admins of community %d about usage.", communityId)
 	}
-	return nil
 }
 
-func (h *Helpers) validateUser(addr, timestamp string, compositeSignatures *[]shared.CompositeSignature) error {
+// communityUsage reports a community's usage this month against its
+// quotas, and its usage of the last months months.
+func (h *Helpers) communityUsage(communityId int, months int) (models.CommunityUsage, error) {
+	period := models.UsagePeriod(time.Now())
+	usage, err := models.GetUsage(h.A.DB, communityId, period)
+	if err != nil {
+		return models.CommunityUsage{}, err
+	}
+	history, err := models.GetUsageHistory(h.A.DB, communityId, period.AddDate(0, 1-months, 0))
+	if err != nil {
+		return models.CommunityUsage{}, err
+	}
 
-	if err := h.validateTimestamp(timestamp, 60); err != nil {
-		return err
+	report := models.CommunityUsage{
+		Community_id: communityId,
+		Period:       period,
+		Resets_at:    period.AddDate(0, 1, 0),
+		History:      history,
 	}
+	for _, metric := range models.USAGE_METRICS {
+		quota, err := h.usageQuota(communityId, metric)
+		if err != nil {
+			return models.CommunityUsage{}, err
+		}
+		report.Metrics = append(report.Metrics,
+			models.NewUsageMetric(metric, usage[metric], quota, h.A.Config.Usage_soft_limit))
+	}
+	return report, nil
+}
 
-	if err := h.validateUserSignature(addr, timestamp, compositeSignatures); err != nil {
-		return err
+// getCommunityUsage shows a community's usage to its admins and to
+// platform admins.
+func (h *Helpers) getCommunityUsage(token string, communityId int, months int) (models.CommunityUsage, int, error) {
+	if httpStatus, err := h.validateCommunityAdminSession(token, communityId); err != nil {
+		return models.CommunityUsage{}, httpStatus, err
+	}
+	if _, err := h.fetchCommunity(communityId); err != nil {
+		return models.CommunityUsage{}, http.StatusNotFound, err
 	}
 
-	return nil
+	report, err := h.communityUsage(communityId, months)
+	if err != nil {
+		return models.CommunityUsage{}, http.StatusInternalServerError, err
+	}
+	return report, http.StatusOK, nil
 }
 
-func (h *Helpers) validateUserViaVoucher(addr string, voucher *shared.Voucher) error {
-	timestamp := voucher.Arguments[0]["value"]
-	if err := h.validateTimestamp(timestamp, 60); err != nil {
-		return err
+// setCommunityQuotas sets a community's own quotas on behalf of the
+// platform.
+func (h *Helpers) setCommunityQuotas(
+	communityId int,
+	payload models.CommunityQuotasPayload,
+) (models.CommunityUsage, int, error) {
+	if vErr := newValidator().Struct(payload); vErr != nil {
+		return models.CommunityUsage{}, http.StatusBadRequest, errors.New("At least one quota is required.")
+	}
+	for metric, quota := range payload.Quotas {
+		if err := models.EnsureValidUsageMetric(metric); err != nil {
+			return models.CommunityUsage{}, http.StatusBadRequest, err
+		}
+		if quota != nil && *quota < 0 {
+			return models.CommunityUsage{}, http.StatusBadRequest, fmt.Errorf("Quota of %s cannot be negative.", metric)
+		}
+	}
+	if err := h.validatePlatformAdmin(payload.TimestampSignaturePayload); err != nil {
+		return models.CommunityUsage{}, http.StatusForbidden, err
+	}
+	if _, err := h.fetchCommunity(communityId); err != nil {
+		return models.CommunityUsage{}, http.StatusNotFound, err
 	}
 
-	compositeSignatures := shared.GetUserCompositeSignatureFromVoucher(voucher)
-	// Validate authorizer
-	authorizer := voucher.Authorizers[0]
-	if authorizer != addr || authorizer != (*compositeSignatures)[0].Addr {
-		err := errors.New("authorizer address must match voter address and envelope signer")
-		log.Error().Err(err)
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, nil, func(tx *shared.Database) error {
+		for metric, quota := range payload.Quotas {
+			if err := models.SetCommunityQuota(tx, communityId, metric, quota, payload.Signing_addr); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.CommunityUsage{}, http.StatusForbidden, err
+	} else if err != nil {
+		return models.CommunityUsage{}, http.StatusInternalServerError, err
+	}
+
+	report, err := h.communityUsage(communityId, 1)
+	if err != nil {
+		return models.CommunityUsage{}, http.StatusInternalServerError, err
+	}
+	return report, http.StatusOK, nil
+}
+
+// errPlanLimitReached is returned for changes the plan of a community
+// doesn't allow.
+var errPlanLimitReached = errors.New("Plan limit reached.")
+
+// checkPlanLimit refuses adding more of what plans limit past the limit of
+// the community's plan. Plans are only enforced with billing enabled.
+func (h *Helpers) checkPlanLimit(c models.Community, limit string, adding int) error {
+	if !h.A.Config.Billing_enabled || adding <= 0 {
+		return nil
+	}
+	plan, _, err := models.GetCommunityPlan(h.A.DB, c.ID)
+	if err != nil {
 		return err
 	}
-	// validate signature using encoded transaction payload as message
-	message := shared.EncodeMessageFromVoucher(voucher)
-	if err := h.validateTxSignature(addr, message, compositeSignatures); err != nil {
+	max := plan.Limit(limit)
+	if max == nil {
+		return nil
+	}
+	usage, err := models.GetPlanUsage(h.A.DB, c)
+	if err != nil {
 		return err
 	}
-
-	return nil
+	if usage.Used(limit)+adding <= *max {
+		return nil
+	}
+	return fmt.Errorf("%w The %s plan allows %d %s, upgrade the community for more.",
+		errPlanLimitReached, plan.Display_name, *max, limit)
 }
 
-func (h *Helpers) validateUserWithRole(addr, timestamp string, compositeSignatures *[]shared.CompositeSignature, communityId int, role string) error {
-	//print out all the params
-	if err := h.validateTimestamp(timestamp, 60); err != nil {
-		return err
+// checkPlanMember refuses a new member past the limit of the community's
+// plan. Members given another role are not new.
+func (h *Helpers) checkPlanMember(communityId int, addr string) error {
+	if !h.A.Config.Billing_enabled {
+		return nil
 	}
-	if err := h.validateUserSignature(addr, timestamp, compositeSignatures); err != nil {
+	roles, err := models.GetAllRolesForUserInCommunity(h.A.DB, addr, communityId)
+	if err != nil {
 		return err
 	}
-	if err := models.EnsureRoleForCommunity(h.A.DB, addr, communityId, role); err != nil {
-		errMsg := fmt.Sprintf("Account %s is not an author for community %d.", addr, communityId)
-		log.Error().Err(err).Msg(errMsg)
+	if len(roles) > 0 {
+		return nil
+	}
+	c, err := h.fetchCommunity(communityId)
+	if err != nil {
 		return err
 	}
+	return h.checkPlanLimit(c, models.PlanMembers, 1)
+}
 
-	return nil
+// checkPlanStrategies refuses strategies with more custom scripts than the
+// community's plan allows. Communities past the limit can still remove some.
+func (h *Helpers) checkPlanStrategies(c models.Community, strategies *[]models.Strategy) error {
+	adding := models.CountCustomStrategies(strategies) - models.CountCustomStrategies(c.Strategies)
+	return h.checkPlanLimit(c, models.PlanCustomStrategies, adding)
 }
 
-func (h *Helpers) validateUserWithRoleViaVoucher(addr string, voucher *shared.Voucher, communityId int, role string) error {
-	timestamp := voucher.Arguments[0]["value"]
-	if err := h.validateTimestamp(timestamp, 60); err != nil {
-		return err
+// planLimitStatus is the status to answer a failed plan check with.
+func planLimitStatus(err error) int {
+	if errors.Is(err, errPlanLimitReached) {
+		return http.StatusPaymentRequired
 	}
+	return http.StatusInternalServerError
+}
 
-	compositeSignatures := shared.GetUserCompositeSignatureFromVoucher(voucher)
-	// Validate authorizer
-	authorizer := voucher.Authorizers[0]
-	if authorizer != addr || authorizer != (*compositeSignatures)[0].Addr {
-		err := errors.New("authorizer address must match voter address and envelope signer")
-		log.Error().Err(err)
-		return err
+// getCommunityBilling shows a community's admins its plan, how much of it
+// they use, and where to upgrade.
+func (h *Helpers) getCommunityBilling(token string, communityId int) (models.CommunityBilling, int, error) {
+	if httpStatus, err := h.validateCommunityAdminSession(token, communityId); err != nil {
+		return models.CommunityBilling{}, httpStatus, err
+	}
+	c, err := h.fetchCommunity(communityId)
+	if err != nil {
+		return models.CommunityBilling{}, http.StatusNotFound, err
 	}
 
-	// validate signature using encoded transaction payload as message
-	message := shared.EncodeMessageFromVoucher(voucher)
-	if err := h.validateTxSignature(addr, message, compositeSignatures); err != nil {
-		return err
+	plan, expiresAt, err := models.GetCommunityPlan(h.A.DB, c.ID)
+	if err != nil {
+		return models.CommunityBilling{}, http.StatusInternalServerError, err
 	}
-	if err := models.EnsureRoleForCommunity(h.A.DB, addr, communityId, role); err != nil {
-		errMsg := fmt.Sprintf("Account %s is not an author for community %d.", addr, communityId)
-		log.Error().Err(err).Msg(errMsg)
-		return err
+	usage, err := models.GetPlanUsage(h.A.DB, c)
+	if err != nil {
+		return models.CommunityBilling{}, http.StatusInternalServerError, err
 	}
 
-	return nil
+	billing := models.CommunityBilling{
+		Community_id: c.ID,
+		Plan:         plan,
+		Expires_at:   expiresAt,
+		Usage:        usage,
+		Enforced:     h.A.Config.Billing_enabled,
+	}
+	if checkout := h.A.Config.Billing_checkout_url; checkout != "" {
+		url := strings.ReplaceAll(checkout, "{communityId}", strconv.Itoa(c.ID))
+		billing.Checkout_url = &url
+	}
+	return billing, http.StatusOK, nil
 }
 
-func (h *Helpers) processTokenThreshold(address string, c shared.Contract, contractType string) (bool, error) {
-	var scriptPath string
+// handleBillingWebhook applies an entitlement webhook of the payment
+// processor to the plan of a community.
+func (h *Helpers) handleBillingWebhook(body []byte, signature string) (int, error) {
+	if err := shared.VerifyBillingWebhook(h.A.Config.Billing_webhook_secret, signature, body, time.Now()); err != nil {
+		return http.StatusUnauthorized, err
+	}
 
-	if contractType == "nft" {
-		scriptPath = "./main/cadence/scripts/get_nfts_ids.cdc"
-	} else {
-		scriptPath = "./main/cadence/scripts/get_balance.cdc"
+	var event models.EntitlementEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return http.StatusBadRequest, errors.New("Invalid billing webhook.")
+	}
+	if vErr := newValidator().Struct(event); vErr != nil {
+		return http.StatusBadRequest, vErr
+	}
+	switch event.Type {
+	case models.EntitlementUpdated:
+		if _, err := models.GetPlan(h.A.DB, event.Data.Plan); err != nil {
+			return http.StatusBadRequest, fmt.Errorf("Plan %q not found.", event.Data.Plan)
+		}
+	case models.EntitlementRevoked:
+	default:
+		// processors send events nobody subscribed to, they are acknowledged
+		log.Info().Msgf("Ignoring billing webhook %s of type %s.", event.ID, event.Type)
+		return http.StatusOK, nil
+	}
+	if _, err := h.fetchCommunity(event.Data.Community_id); err != nil {
+		return http.StatusNotFound, err
 	}
 
-	hasBalance, err := h.A.FlowAdapter.EnforceTokenThreshold(scriptPath, address, &c)
+	applied, err := models.ApplyEntitlement(h.A.DB, event)
 	if err != nil {
-		return false, err
+		return http.StatusInternalServerError, err
 	}
-
-	return hasBalance, nil
+	if applied {
+		log.Info().Msgf("Applied billing webhook %s to community %d.", event.ID, event.Data.Community_id)
+	}
+	return http.StatusOK, nil
 }
 
-func (h *Helpers) initStrategy(name string) Strategy {
-	s := strategyMap[name]
-	if s == nil {
-		return nil
+func (h *Helpers) getConfig(token string) (shared.Config, int, error) {
+	if _, httpStatus, err := h.validatePlatformAdminSession(token); err != nil {
+		return shared.Config{}, httpStatus, err
 	}
+	return h.A.Config.Redacted(), http.StatusOK, nil
+}
 
-	s.InitStrategy(h.A.FlowAdapter, h.A.DB)
+func (h *Helpers) getTenants(token string) ([]*models.Tenant, int, error) {
+	if _, httpStatus, err := h.validatePlatformAdminSession(token); err != nil {
+		return nil, httpStatus, err
+	}
 
-	return s
+	tenants, err := models.GetTenants(h.A.DB)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return tenants, http.StatusOK, nil
 }
 
-func (h *Helpers) pinJSONToIpfs(data interface{}) (*string, error) {
-	shouldOverride := flag.Lookup("ipfs-override").Value.(flag.Getter).Get().(bool)
-	if shouldOverride {
-		dummyHash := "dummy-hash"
-		return &dummyHash, nil
+// saveTenant creates a tenant when id is 0, and otherwise applies the
+// fields the payload sets to the tenant, then reloads the tenants so the
+// change applies to the next request.
+func (h *Helpers) saveTenant(id int, payload models.TenantPayload) (models.Tenant, int, error) {
+	validate := newValidator()
+	if vErr := validate.Struct(payload); vErr != nil {
+		return models.Tenant{}, http.StatusBadRequest, vErr
+	}
+	if payload.Slug != nil && !tenantSlug.MatchString(*payload.Slug) {
+		return models.Tenant{}, http.StatusBadRequest, errors.New("Tenant slugs may only have lowercase letters, digits and dashes.")
+	}
+	if err := h.validatePlatformAdmin(payload.TimestampSignaturePayload); err != nil {
+		return models.Tenant{}, http.StatusForbidden, err
 	}
 
-	pin, err := h.A.IpfsClient.PinJson(data)
-	if err != nil {
-		return nil, err
+	t := models.Tenant{ID: id, Hostnames: []string{}, Branding: map[string]string{}, Admin_addrs: []string{}}
+	if id != 0 {
+		if err := t.GetTenant(h.A.DB); err != nil {
+			return models.Tenant{}, http.StatusNotFound, err
+		}
+	} else if payload.Slug == nil || payload.Name == nil {
+		return models.Tenant{}, http.StatusBadRequest, errors.New("A slug and name are required.")
 	}
-	return &pin.IpfsHash, nil
+
+	if payload.Slug != nil {
+		t.Slug = *payload.Slug
+	}
+	if payload.Name != nil {
+		t.Name = *payload.Name
+	}
+	if payload.Hostnames != nil {
+		t.Hostnames = *payload.Hostnames
+	}
+	if payload.Branding != nil {
+		t.Branding = *payload.Branding
+	}
+	if payload.Admin_addrs != nil {
+		t.Admin_addrs = *payload.Admin_addrs
+	}
+	for _, host := range t.Hostnames {
+		if other, ok := h.A.Tenants.ForHost(host); ok && other.ID != t.ID {
+			return models.Tenant{}, http.StatusConflict, fmt.Errorf("Hostname %s is served by tenant %s.", host, other.Slug)
+		}
+	}
+	if other, ok := h.A.Tenants.Lookup(t.Slug); ok && other.ID != t.ID {
+		return models.Tenant{}, http.StatusConflict, fmt.Errorf("Tenant slug %s is taken.", t.Slug)
+	}
+
+	if err := h.withSignature(payload.Signing_addr, payload.Timestamp, nil, func(tx *shared.Database) error {
+		if id == 0 {
+			return t.CreateTenant(tx)
+		}
+		return t.UpdateTenant(tx)
+	}); errors.Is(err, models.ErrSignatureReused) {
+		return models.Tenant{}, http.StatusForbidden, err
+	} else if err != nil {
+		return models.Tenant{}, http.StatusInternalServerError, err
+	}
+
+	if err := h.A.ReloadTenants(); err != nil {
+		return models.Tenant{}, http.StatusInternalServerError, err
+	}
+	return t, http.StatusOK, nil
+}
+
+// searchResponse is a page of search results, with the counts of each
+// category and tag to filter them by.
+type searchResponse struct {
+	Filters []shared.SearchFilter     `json:"filters"`
+	Tags    []shared.SearchFilter     `json:"tags"`
+	Results *shared.PaginatedResponse `json:"results"`
 }
 
 func (h *Helpers) appendFiltersToResponse(
 	results []*models.Community,
 	pageParams shared.PageParams,
 	count map[string]int,
-) (interface{}, error) {
+	tagCount map[string]int,
+) (searchResponse, error) {
 	var filters []shared.SearchFilter
 	var CATEGORIES = []string{
 		"all",
@@ -1206,36 +7866,60 @@ func (h *Helpers) appendFiltersToResponse(
 		Amount: totalCount,
 	})
 
-	paginatedResults := shared.GetPaginatedResponseWithPayload(results, pageParams)
-	appendedResponse := struct {
-		Filters []shared.SearchFilter    `json:"filters"`
-		Results shared.PaginatedResponse `json:"results"`
-	}{
-		Filters: filters,
-		Results: *paginatedResults,
+	tags := []shared.SearchFilter{}
+	for tag, amount := range tagCount {
+		tags = append(tags, shared.SearchFilter{Text: tag, Amount: amount})
 	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Amount == tags[j].Amount {
+			return tags[i].Text < tags[j].Text
+		}
+		return tags[i].Amount > tags[j].Amount
+	})
 
-	return appendedResponse, nil
+	return searchResponse{
+		Filters: filters,
+		Tags:    tags,
+		Results: shared.GetPaginatedResponseWithPayload(results, pageParams),
+	}, nil
 }
 
 func validateContractThreshold(s []models.Strategy) error {
-	for _, s := range s {
+	var invalid fieldErrors
+	for i, s := range s {
 		if s.Threshold != nil {
 			if *s.Threshold < 1 {
-				return errors.New("Contract Threshold cannot be less than 1.")
+				invalid = append(invalid, fieldError{
+					Field:   fmt.Sprintf("strategies[%d].contract.threshold", i),
+					Rule:    "min",
+					Param:   "1",
+					Message: "Contract Threshold cannot be less than 1.",
+				})
 			}
 		}
 	}
+	if invalid != nil {
+		return invalid
+	}
 	return nil
 }
 
 func validateProposalThreshold(threshold string, onlyAuthorsToSubmit bool) error {
 	propThreshold, err := strconv.ParseFloat(threshold, 64)
 	if err != nil {
-		return errors.New("Error Converting Proposal Threshold to Float.")
+		return &fieldError{
+			Field:   "proposalThreshold",
+			Rule:    "numeric",
+			Message: "Error Converting Proposal Threshold to Float.",
+		}
 	}
 	if !onlyAuthorsToSubmit && propThreshold < 1 {
-		return errors.New("Proposal Threshold cannot be less than 1.")
+		return &fieldError{
+			Field:   "proposalThreshold",
+			Rule:    "min",
+			Param:   "1",
+			Message: "Proposal Threshold cannot be less than 1.",
+		}
 	}
 	return nil
 }
@@ -0,0 +1,270 @@
+package server
+
+import (
+	"os"
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultProposalsInterval    = 30 * time.Second
+	defaultAchievementsInterval = time.Minute
+	defaultLeaderboardsInterval = 5 * time.Minute
+	defaultAnalyticsInterval    = 15 * time.Minute
+	defaultExportsInterval      = time.Minute
+	defaultListsInterval        = 10 * time.Minute
+	defaultResultsInterval      = 5 * time.Minute
+	defaultPinsInterval         = 30 * time.Second
+	defaultSignaturesInterval   = 10 * time.Minute
+	defaultAddressListsInterval = time.Minute
+	defaultTenantsInterval      = time.Minute
+	defaultTrendingInterval     = 15 * time.Minute
+	defaultReputationInterval   = time.Hour
+	defaultChainVotesInterval   = 15 * time.Second
+	defaultCommunitiesInterval  = time.Hour
+)
+
+// job is a task run periodically in the background while the server is up.
+type job struct {
+	name     string
+	interval time.Duration
+	run      func() error
+}
+
+func (a *App) jobs() []job {
+	return []job{
+		{
+			name:     "proposals",
+			interval: envDuration("PROPOSALS_JOB_INTERVAL", defaultProposalsInterval),
+			run:      a.CloseProposals,
+		},
+		{
+			name:     "achievements",
+			interval: envDuration("ACHIEVEMENTS_JOB_INTERVAL", defaultAchievementsInterval),
+			run:      a.ComputeAchievements,
+		},
+		{
+			name:     "leaderboards",
+			interval: envDuration("LEADERBOARDS_JOB_INTERVAL", defaultLeaderboardsInterval),
+			run:      a.RefreshLeaderboards,
+		},
+		{
+			name:     "analytics",
+			interval: envDuration("ANALYTICS_JOB_INTERVAL", defaultAnalyticsInterval),
+			run:      a.ComputeAnalytics,
+		},
+		{
+			name:     "exports",
+			interval: envDuration("EXPORTS_JOB_INTERVAL", defaultExportsInterval),
+			run:      a.ProcessExports,
+		},
+		{
+			name:     "lists",
+			interval: envDuration("LISTS_JOB_INTERVAL", defaultListsInterval),
+			run:      a.MaterializeLists,
+		},
+		{
+			name:     "results",
+			interval: envDuration("RESULTS_JOB_INTERVAL", defaultResultsInterval),
+			run:      a.ReconcileResults,
+		},
+		{
+			name:     "pins",
+			interval: envDuration("PINS_JOB_INTERVAL", defaultPinsInterval),
+			run:      a.ProcessPins,
+		},
+		{
+			name:     "signatures",
+			interval: envDuration("SIGNATURES_JOB_INTERVAL", defaultSignaturesInterval),
+			run:      a.PurgeUsedSignatures,
+		},
+		{
+			name:     "trending",
+			interval: envDuration("TRENDING_JOB_INTERVAL", defaultTrendingInterval),
+			run:      a.ComputeTrending,
+		},
+		{
+			name:     "reputation",
+			interval: envDuration("REPUTATION_JOB_INTERVAL", defaultReputationInterval),
+			run:      a.ComputeReputation,
+		},
+		{
+			name:     "chain-votes",
+			interval: envDuration("CHAIN_VOTES_JOB_INTERVAL", defaultChainVotesInterval),
+			run:      a.IngestChainVotes,
+		},
+		{
+			name:     "communities",
+			interval: envDuration("COMMUNITIES_JOB_INTERVAL", defaultCommunitiesInterval),
+			run:      a.PurgeDeletedCommunities,
+		},
+		{
+			name:     "address-lists",
+			interval: envDuration("ADDRESS_LISTS_JOB_INTERVAL", defaultAddressListsInterval),
+			run:      a.ReloadAddressLists,
+		},
+		{
+			name:     "tenants",
+			interval: envDuration("TENANTS_JOB_INTERVAL", defaultTenantsInterval),
+			run:      a.ReloadTenants,
+		},
+	}
+}
+
+// StartJobs runs every background job on its own ticker until the app shuts
+// down. Jobs must be idempotent: a run that fails is simply retried on the
+// next tick, and a run in progress at shutdown is allowed to finish.
+func (a *App) StartJobs() {
+	a.mu.Lock()
+	a.jobRuns = map[string]time.Time{}
+	a.mu.Unlock()
+
+	for _, j := range a.jobs() {
+		j := j
+		a.goBackground(func() {
+			log.Info().Msgf("Starting %s job every %s", j.name, j.interval)
+			ticker := time.NewTicker(j.interval)
+			defer ticker.Stop()
+			for {
+				if err := j.run(); err != nil {
+					log.Error().Err(err).Msgf("Error running %s job.", j.name)
+					if err := models.RecordJobFailure(a.DB, j.name, err); err != nil {
+						log.Error().Err(err).Msgf("Error recording %s job failure.", j.name)
+					}
+				}
+				a.recordJobRun(j.name)
+				select {
+				case <-a.ctx.Done():
+					log.Info().Msgf("Stopped %s job", j.name)
+					return
+				case <-ticker.C:
+				}
+			}
+		})
+	}
+}
+
+// recordJobRun notes the job completed a run, successful or not, for the
+// health report to tell stuck jobs apart.
+func (a *App) recordJobRun(name string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.jobRuns[name] = time.Now()
+}
+
+// CloseProposals finalizes proposals that reached their end time, after
+// moving the times of proposals with a block window along with the chain.
+func (a *App) CloseProposals() error {
+	if err := helpers.trackBlockWindows(); err != nil {
+		log.Error().Err(err).Msg("Error tracking proposal block windows.")
+	}
+	return helpers.closeProposals()
+}
+
+// ComputeAchievements awards the achievements of every closed proposal that
+// has not been processed yet.
+func (a *App) ComputeAchievements() error {
+	return helpers.computeAchievements()
+}
+
+// RefreshLeaderboards recomputes the windowed leaderboard aggregates.
+func (a *App) RefreshLeaderboards() error {
+	return models.RefreshLeaderboardStats(a.DB)
+}
+
+// ComputeAnalytics refreshes the proposal and community stats behind the
+// analytics endpoint.
+func (a *App) ComputeAnalytics() error {
+	return helpers.computeAnalytics()
+}
+
+// ProcessExports builds the archives of pending community exports.
+func (a *App) ProcessExports() error {
+	return helpers.processExports()
+}
+
+// MaterializeLists recomputes the addresses of dynamic lists from chain data.
+func (a *App) MaterializeLists() error {
+	return helpers.materializeDynamicLists()
+}
+
+// IngestChainVotes records the votes cast on chain since the last run.
+func (a *App) IngestChainVotes() error {
+	return helpers.ingestChainVotes()
+}
+
+// ReconcileResults recounts proposals whose stored results have drifted
+// from the votes cast on them.
+func (a *App) ReconcileResults() error {
+	return helpers.reconcileResults()
+}
+
+// ProcessPins pins queued records to IPFS and retries failed pins.
+func (a *App) ProcessPins() error {
+	return helpers.processPins()
+}
+
+// PurgeUsedSignatures forgets replay protected messages whose timestamps
+// have expired.
+func (a *App) PurgeUsedSignatures() error {
+	return helpers.purgeUsedSignatures()
+}
+
+// PurgeDeletedCommunities removes the communities whose grace period
+// before deletion has passed.
+func (a *App) PurgeDeletedCommunities() error {
+	return helpers.purgeDeletedCommunities()
+}
+
+// ComputeTrending recomputes the trending scores of communities and
+// proposals.
+func (a *App) ComputeTrending() error {
+	return models.RefreshTrendingScores(a.DB)
+}
+
+// ComputeReputation recomputes the reputation of community members.
+func (a *App) ComputeReputation() error {
+	return models.RefreshReputationScores(a.DB)
+}
+
+// ReloadAddressLists refreshes the in-memory admin allowlist and community
+// blocklist, picking up changes made through other instances.
+func (a *App) ReloadAddressLists() error {
+	admins, err := models.GetAddressList(a.DB, models.PlatformAdmins)
+	if err != nil {
+		return err
+	}
+	blocked, err := models.GetAddressList(a.DB, models.PlatformBlocklist)
+	if err != nil {
+		return err
+	}
+
+	a.AdminAllowlist.Set(admins)
+	a.CommunityBlocklist.Set(blocked)
+	return nil
+}
+
+// ReloadTenants refreshes the in-memory tenants, picking up changes made
+// through other instances.
+func (a *App) ReloadTenants() error {
+	tenants, err := models.GetTenants(a.DB)
+	if err != nil {
+		return err
+	}
+	a.Tenants.Set(tenants)
+	return nil
+}
+
+// envDuration reads a duration such as "30s" from the environment.
+func envDuration(envVar string, fallback time.Duration) time.Duration {
+	if v := os.Getenv(envVar); v != "" {
+		d, err := time.ParseDuration(v)
+		if err == nil && d > 0 {
+			return d
+		}
+		log.Warn().Msgf("Invalid %s %q, using %s.", envVar, v, fallback)
+	}
+	return fallback
+}
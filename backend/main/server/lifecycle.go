@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const defaultShutdownTimeout = 30 * time.Second
+
+// Run serves the API and runs the background jobs until SIGINT or SIGTERM,
+// then shuts down gracefully.
+func (a *App) Run() {
+	a.StartJobs()
+
+	addr := fmt.Sprintf(":%s", a.Config.Api_port)
+	a.server = &http.Server{
+		Addr:              addr,
+		Handler:           a.Router,
+		ReadHeaderTimeout: envDuration("READ_HEADER_TIMEOUT", defaultReadHeaderTimeout),
+		ReadTimeout:       envDuration("READ_TIMEOUT", defaultReadTimeout),
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Info().Msgf("Starting server on %s ...", addr)
+		serveErr <- a.server.ListenAndServe()
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal().Err(err).Msgf("Server at %s crashed!", addr)
+		}
+	case sig := <-signals:
+		log.Info().Msgf("Received %s, shutting down ...", sig)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), envDuration("SHUTDOWN_TIMEOUT", defaultShutdownTimeout))
+	defer cancel()
+	if err := a.Shutdown(ctx); err != nil {
+		log.Error().Err(err).Msg("Error shutting down.")
+		os.Exit(1)
+	}
+	log.Info().Msg("Server stopped")
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests,
+// including their IPFS and Flow calls, to complete. Background jobs are then
+// stopped once their current run is over, and the database pool is closed.
+// Work still running when ctx expires is abandoned.
+func (a *App) Shutdown(ctx context.Context) error {
+	var err error
+	if a.server != nil {
+		err = a.server.Shutdown(ctx)
+	}
+
+	a.mu.Lock()
+	a.stopping = true
+	a.stop()
+	a.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		a.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		if err == nil {
+			err = errors.New("Timed out waiting for background work to finish.")
+		}
+	}
+
+	a.DB.Pool.Close()
+	return err
+}
+
+// goBackground runs fn in a goroutine that shutdown waits for. Nothing new is
+// started once the app is shutting down.
+func (a *App) goBackground(fn func()) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.stopping {
+		return
+	}
+
+	a.workers.Add(1)
+	go func() {
+		defer a.workers.Done()
+		fn()
+	}()
+}
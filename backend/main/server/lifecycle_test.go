@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/stretchr/testify/assert"
+)
+
+// newLifecycleApp returns an app with just enough state to shut down. The
+// pool never connects.
+func newLifecycleApp(t *testing.T) *App {
+	config, err := pgxpool.ParseConfig("postgres://localhost:5432/cast")
+	assert.NoError(t, err)
+	config.LazyConnect = true
+	pool, err := pgxpool.ConnectConfig(context.Background(), config)
+	assert.NoError(t, err)
+
+	a := &App{DB: &shared.Database{Pool: pool}}
+	a.ctx, a.stop = context.WithCancel(context.Background())
+	return a
+}
+
+func TestShutdownDrainsBackgroundWork(t *testing.T) {
+	a := newLifecycleApp(t)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	finished := false
+	a.goBackground(func() {
+		close(started)
+		<-release
+		finished = true
+	})
+	<-started
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, a.Shutdown(ctx))
+	assert.True(t, finished)
+	assert.Error(t, a.ctx.Err())
+
+	ran := make(chan struct{})
+	a.goBackground(func() { close(ran) })
+	select {
+	case <-ran:
+		t.Fatal("background work started after shutdown")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestShutdownGivesUpAtDeadline(t *testing.T) {
+	a := newLifecycleApp(t)
+
+	release := make(chan struct{})
+	defer close(release)
+	a.goBackground(func() { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Error(t, a.Shutdown(ctx))
+}
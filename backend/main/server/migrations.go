@@ -0,0 +1,179 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/DapperCollectives/CAST/backend/migrations"
+	"github.com/golang-migrate/migrate/v4"
+	pgxmigrate "github.com/golang-migrate/migrate/v4/database/pgx"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v4/stdlib"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const migrateUsage = "usage: migrate up | down [steps] | goto <version> | force <version> | version"
+
+// migrateLogger routes golang-migrate output through zerolog.
+type migrateLogger struct{}
+
+func (migrateLogger) Printf(format string, v ...interface{}) {
+	log.Info().Msg(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (migrateLogger) Verbose() bool {
+	return zerolog.GlobalLevel() <= zerolog.DebugLevel
+}
+
+// newMigrator returns a migrator over the migrations embedded in the binary,
+// along with the latest version they define.
+func (a *App) newMigrator() (*migrate.Migrate, uint, error) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	latest, err := src.First()
+	if err != nil {
+		return nil, 0, err
+	}
+	for {
+		next, err := src.Next(latest)
+		if errors.Is(err, fs.ErrNotExist) {
+			break
+		} else if err != nil {
+			return nil, 0, err
+		}
+		latest = next
+	}
+
+	db := stdlib.OpenDB(*a.DB.Pool.Config().ConnConfig)
+	driver, err := pgxmigrate.WithInstance(db, &pgxmigrate.Config{})
+	if err != nil {
+		db.Close()
+		return nil, 0, err
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, "pgx", driver)
+	if err != nil {
+		driver.Close()
+		return nil, 0, err
+	}
+	m.Log = migrateLogger{}
+	return m, latest, nil
+}
+
+// CheckSchemaVersion refuses to start against a database that is dirty or
+// behind the migrations this build was shipped with, unless DB_AUTO_MIGRATE
+// is set, in which case pending migrations are applied. A database ahead of
+// the build is allowed so that a rollback does not need a down migration.
+func (a *App) CheckSchemaVersion() error {
+	m, latest, err := a.newMigrator()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		version = 0
+	} else if err != nil {
+		return err
+	}
+
+	if dirty {
+		return fmt.Errorf("Database schema is dirty at version %d, fix it and run migrate force.", version)
+	}
+
+	switch {
+	case version < latest && a.Config.Db_auto_migrate:
+		log.Info().Msgf("Migrating database schema from version %d to %d", version, latest)
+		return m.Up()
+	case version < latest:
+		return fmt.Errorf("Database schema is at version %d but this build requires %d, run migrate up.", version, latest)
+	case version > latest:
+		log.Warn().Msgf("Database schema version %d is newer than this build's %d.", version, latest)
+	default:
+		log.Info().Msgf("Database schema at version %d", version)
+	}
+	return nil
+}
+
+// Migrate runs the migrate subcommand against the configured database.
+func (a *App) Migrate(args []string) error {
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stdout})
+	if a.DB == nil {
+		a.connectDatabase()
+		defer a.DB.Pool.Close()
+	}
+
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	m, latest, err := a.newMigrator()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	arg := func() (int, error) {
+		if len(args) < 2 {
+			return 0, errors.New(migrateUsage)
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("Invalid number %q.", args[1])
+		}
+		return n, nil
+	}
+
+	switch args[0] {
+	case "up":
+		err = m.Up()
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = arg(); err != nil {
+				return err
+			}
+		}
+		err = m.Steps(-steps)
+	case "goto":
+		var version int
+		if version, err = arg(); err != nil {
+			return err
+		}
+		err = m.Migrate(uint(version))
+	case "force":
+		var version int
+		if version, err = arg(); err != nil {
+			return err
+		}
+		err = m.Force(version)
+	case "version":
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	if errors.Is(err, migrate.ErrNoChange) {
+		log.Info().Msg("No change")
+	} else if err != nil {
+		return err
+	}
+
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		log.Info().Msgf("No migrations applied, latest is %d", latest)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	log.Info().Msgf("Database schema at version %d (dirty: %t), latest is %d", version, dirty, latest)
+	return nil
+}
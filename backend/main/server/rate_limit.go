@@ -0,0 +1,50 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter counts requests per key in fixed windows. Counts are kept in
+// memory, so each instance of the API enforces the limit on its own.
+type rateLimiter struct {
+	window time.Duration
+
+	mu      sync.Mutex
+	windows map[int]*rateWindow
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+func newRateLimiter(window time.Duration) *rateLimiter {
+	return &rateLimiter{window: window, windows: make(map[int]*rateWindow)}
+}
+
+// Allow counts a request for key and reports whether it is within limit,
+// and if not how long until the window resets.
+func (l *rateLimiter) Allow(key int, limit int) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) >= l.window {
+		// drop windows that have run out, so keys no longer used are forgotten
+		for k, other := range l.windows {
+			if now.Sub(other.start) >= l.window {
+				delete(l.windows, k)
+			}
+		}
+		w = &rateWindow{start: now}
+		l.windows[key] = w
+	}
+
+	if w.count >= limit {
+		return false, w.start.Add(l.window).Sub(now)
+	}
+	w.count++
+	return true, 0
+}
@@ -1,57 +1,273 @@
 package server
 
+import "github.com/DapperCollectives/CAST/backend/main/models"
+
 func (a *App) initializeRoutes() {
 	// Health
 	a.Router.HandleFunc("/", a.health).Methods("GET")
 	a.Router.HandleFunc("/api", a.health).Methods("GET")
+	a.Router.HandleFunc("/health", a.getHealth).Methods("GET")
+	a.Router.HandleFunc("/api/health", a.getHealth).Methods("GET")
 	// File upload
 	a.Router.HandleFunc("/upload", a.upload).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/files/{key:.+}", a.getFile).Methods("GET")
+	a.Router.HandleFunc("/uploads/{id:[0-9]+}/signed-url", a.signUploadURL).Methods("POST", "OPTIONS")
+	// Auth
+	a.Router.HandleFunc("/auth/login", a.login).Methods("POST", "OPTIONS")
 	// Communities
 	a.Router.HandleFunc("/communities", a.getCommunities).Methods("GET")
 	a.Router.HandleFunc("/communities-for-homepage", a.getCommunitiesForHomePage).Methods("GET")
+	a.Router.HandleFunc("/homepage", a.getHomepage).Methods("GET")
+	a.Router.HandleFunc("/tenant", a.getTenant).Methods("GET")
+	a.Router.HandleFunc("/branding", a.getBranding).Methods("GET")
 	a.Router.HandleFunc("/communities/{id:[0-9]+}", a.getCommunity).Methods("GET")
 	a.Router.HandleFunc("/communities/{id:[0-9]+}", a.updateCommunity).Methods("PATCH", "OPTIONS")
+	a.Router.HandleFunc("/communities/{id:[0-9]+}", a.deleteCommunity).Methods("DELETE", "OPTIONS")
+	a.Router.HandleFunc("/communities/{id:[0-9]+}/children", a.getChildCommunities).Methods("GET")
+	a.Router.HandleFunc("/communities/{id:[0-9]+}/hierarchy", a.getCommunityHierarchy).Methods("GET")
+	a.Router.HandleFunc("/communities/{id:[0-9]+}/feed", a.getCommunityFeed).Methods("GET")
+	a.Router.HandleFunc("/communities/{id:[0-9]+}/triggers/{trigger}", a.getCommunityTrigger).Methods("GET")
+	a.Router.HandleFunc("/communities/{id:[0-9]+}/analytics", a.getCommunityAnalytics).Methods("GET")
+	a.Router.HandleFunc("/communities/{id:[0-9]+}/features", a.getCommunityFeatures).Methods("GET")
+	a.Router.HandleFunc("/communities/{id:[0-9]+}/og", a.getCommunityPreview).Methods("GET")
+	a.Router.HandleFunc("/communities/{id:[0-9]+}/proposals.rss", a.getProposalFeed).Methods("GET")
+	a.Router.HandleFunc("/communities/{id:[0-9]+}/proposals.atom", a.getProposalFeed).Methods("GET")
+	a.Router.HandleFunc("/communities/{id:[0-9]+}/translations", a.getCommunityTranslations).Methods("GET")
+	a.Router.HandleFunc("/communities/{id:[0-9]+}/translations/{locale}", a.setCommunityTranslation).Methods("PUT", "DELETE", "OPTIONS")
+	a.Router.HandleFunc("/communities/{id:[0-9]+}/branding", a.getCommunityBranding).Methods("GET")
+	a.Router.HandleFunc("/communities/{id:[0-9]+}/branding", a.setCommunityBranding).Methods("PUT", "OPTIONS")
+	a.Router.HandleFunc("/communities/{id:[0-9]+}/archive", a.archiveCommunity).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/communities/{id:[0-9]+}/unarchive", a.unarchiveCommunity).Methods("POST", "OPTIONS")
 	a.Router.HandleFunc("/communities", a.createCommunity).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/communities/import", a.importCommunity).Methods("POST", "OPTIONS")
 	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/strategies", a.getActiveStrategiesForCommunity).Methods("GET")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/strategies/dry-run", a.dryRunTally).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/strategies/versions", a.getStrategyVersions).Methods("GET")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/strategies/versions/{version:[0-9]+}", a.getStrategyVersion).
+		Methods("GET")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/strategies/impact", a.previewStrategyChange).
+		Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/proposal-eligibility/{addr:0x[a-zA-Z0-9]{16}}", a.getProposalEligibility).
+		Methods("GET")
 	//Community Search
 	a.Router.HandleFunc("/communities/search", a.searchCommunities).Methods("GET")
 	// Proposals
+	a.Router.HandleFunc("/proposals/trending", a.getTrendingProposals).Methods("GET")
+	a.Router.HandleFunc("/proposals.rss", a.getProposalFeed).Methods("GET")
+	a.Router.HandleFunc("/proposals.atom", a.getProposalFeed).Methods("GET")
+	a.Router.HandleFunc("/proposals/batch", a.getProposalBatch).Methods("POST", "OPTIONS")
 	a.Router.HandleFunc("/proposals/{id:[0-9]+}", a.getProposal).Methods("GET")
 	a.Router.HandleFunc("/proposals/{id:[0-9]+}", a.updateProposal).Methods("PUT", "OPTIONS")
 	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/proposals", a.getProposalsForCommunity).Methods("GET")
 	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/proposals/{id:[0-9]+}", a.getProposal).Methods("GET")
-	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/proposals", a.createProposal).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/proposals", a.requirePlanLimit(models.PlanActiveProposals, a.createProposal)).
+		Methods("POST", "OPTIONS")
 	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/proposals/{id:[0-9]+}", a.updateProposal).
 		Methods("PUT", "OPTIONS")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/proposals/review-queue", a.getProposalReviewQueue).
+		Methods("GET")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/proposals/duplicates", a.getDuplicateProposals).
+		Methods("GET")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/moderation/rules", a.getModerationRules).Methods("GET")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/moderation/rules", a.createModerationRule).
+		Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/moderation/rules/{id:[0-9]+}", a.deleteModerationRule).
+		Methods("DELETE", "OPTIONS")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/moderation/decisions", a.getModerationDecisions).
+		Methods("GET")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/deposits", a.getProposalDeposits).Methods("GET")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/usage", a.getCommunityUsage).Methods("GET")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/billing", a.getCommunityBilling).Methods("GET")
+	a.Router.HandleFunc("/proposals/{id:[0-9]+}/verify", a.verifyProposal).Methods("GET")
+	a.Router.HandleFunc("/proposals/{id:[0-9]+}/deposit/settlement", a.settleProposalDeposit).
+		Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/proposals/{id:[0-9]+}/attachments", a.getProposalAttachments).Methods("GET")
+	a.Router.HandleFunc("/proposals/{id:[0-9]+}/execution", a.getProposalExecution).Methods("GET")
+	a.Router.HandleFunc("/proposals/{id:[0-9]+}/cohosts", a.getProposalCohosts).Methods("GET")
+	a.Router.HandleFunc("/proposals/{id:[0-9]+}/cohosts", a.addProposalCohost).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/proposals/{id:[0-9]+}/cohosts/{communityId:[0-9]+}", a.removeProposalCohost).
+		Methods("DELETE", "OPTIONS")
+	a.Router.HandleFunc("/proposals/{id:[0-9]+}/og", a.getProposalPreview).Methods("GET")
+	// Embeds, for widgets on other sites
+	a.Router.HandleFunc("/embed/proposals/{id:[0-9]+}", a.getProposalEmbed).Methods("GET", "OPTIONS")
+	a.Router.HandleFunc("/embed/proposals/{id:[0-9]+}/widget", a.getProposalWidget).Methods("GET")
+	a.Router.HandleFunc("/oembed", a.getOEmbed).Methods("GET", "OPTIONS")
+	a.Router.HandleFunc("/proposals/{proposalId:[0-9]+}/results.svg", a.getProposalResultsChart).Methods("GET")
+	a.Router.HandleFunc("/proposals/{proposalId:[0-9]+}/results.png", a.getProposalResultsImage).Methods("GET")
+	a.Router.HandleFunc("/proposals/{id:[0-9]+}/translations", a.getProposalTranslations).Methods("GET")
+	a.Router.HandleFunc("/proposals/{id:[0-9]+}/translations/{locale}", a.setProposalTranslation).Methods("PUT", "DELETE", "OPTIONS")
+	a.Router.HandleFunc("/proposals/{id:[0-9]+}/approve", a.approveProposal).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/proposals/{id:[0-9]+}/reject", a.rejectProposal).Methods("POST", "OPTIONS")
+	// Tags
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/tags", a.getCommunityTags).Methods("GET")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/tags", a.createCommunityTag).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/tags/{id:[0-9]+}", a.deleteCommunityTag).
+		Methods("DELETE", "OPTIONS")
+	// Treasuries
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/treasuries", a.getCommunityTreasuries).Methods("GET")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/treasuries", a.createTreasury).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/treasuries/{id:[0-9]+}", a.deleteTreasury).
+		Methods("DELETE", "OPTIONS")
+	// Tokens
+	a.Router.HandleFunc("/tokens", a.getTokens).Methods("GET")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/tokens", a.getCommunityTokens).Methods("GET")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/tokens", a.registerCommunityToken).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/tokens/{id:[0-9]+}", a.deleteCommunityToken).
+		Methods("DELETE", "OPTIONS")
+	// Admin approved actions
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/actions", a.getCommunityActions).Methods("GET")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/actions", a.proposeCommunityAction).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/actions/{id:[0-9]+}/approve", a.approveCommunityAction).
+		Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/actions/{id:[0-9]+}/cancel", a.cancelCommunityAction).
+		Methods("POST", "OPTIONS")
 	// Lists
 	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/lists", a.getListsForCommunity).Methods("GET")
 	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/lists", a.createListForCommunity).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/lists/combine", a.combineLists).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/lists/sync", a.syncCommunityLists).Methods("POST", "OPTIONS")
 	a.Router.HandleFunc("/lists/{id:[0-9]+}", a.getList).Methods("GET")
 	a.Router.HandleFunc("/lists/{id:[0-9]+}/add", a.addAddressesToList).Methods("POST", "OPTIONS")
 	a.Router.HandleFunc("/lists/{id:[0-9]+}/remove", a.removeAddressesFromList).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/lists/{id:[0-9]+}/csv", a.uploadListCSV).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/lists/{id:[0-9]+}/csv", a.downloadListCSV).Methods("GET")
+	a.Router.HandleFunc("/lists/{id:[0-9]+}/versions", a.getListVersions).Methods("GET")
+	a.Router.HandleFunc("/lists/{id:[0-9]+}/versions/{version:[0-9]+}", a.getListVersion).Methods("GET")
 	// Votes
 	a.Router.HandleFunc("/proposals/{proposalId:[0-9]+}/votes", a.getVotesForProposal).Methods("GET")
 	a.Router.HandleFunc("/proposals/{proposalId:[0-9]+}/votes/{addr:0x[a-zA-Z0-9]+}", a.getVoteForAddress).Methods("GET")
+	a.Router.HandleFunc("/proposals/{proposalId:[0-9]+}/votes/{addr:0x[a-zA-Z0-9]+}/receipt", a.getVoteReceipt).Methods("GET")
+	a.Router.HandleFunc("/receipts/public-key", a.getReceiptPublicKey).Methods("GET")
+	a.Router.HandleFunc("/proposals/{proposalId:[0-9]+}/votes/{addr:0x[a-zA-Z0-9]+}/verify", a.verifyVote).Methods("GET")
 	a.Router.HandleFunc("/proposals/{proposalId:[0-9]+}/votes", a.createVoteForProposal).Methods("POST", "OPTIONS")
 	a.Router.HandleFunc("/votes/{addr:0x[a-zA-Z0-9]+}", a.getVotesForAddress).Methods("GET")
 	//Strategies
 	// a.Router.HandleFunc("/proposals/{proposalId:[0-9]+}/votes/{addr:0x[a-zA-Z0-9]{16}}", a.updateVoteForProposal).Methods("PUT", "OPTIONS")
 	a.Router.HandleFunc("/proposals/{proposalId:[0-9]+}/results", a.getResultsForProposal)
+	a.Router.HandleFunc("/proposals/{proposalId:[0-9]+}/results/communities", a.getCohostedResultsForProposal).Methods("GET")
+	a.Router.HandleFunc("/proposals/{proposalId:[0-9]+}/analytics", a.getProposalAnalytics).Methods("GET")
+	a.Router.HandleFunc("/proposals/{proposalId:[0-9]+}/lists", a.getListsForProposal).Methods("GET")
 	// Types
 	a.Router.HandleFunc("/voting-strategies", a.getVotingStrategies).Methods("GET")
 	a.Router.HandleFunc("/community-categories", a.getCommunityCategories).Methods("GET")
+	a.Router.HandleFunc("/schemas", a.getPayloadSchemas).Methods("GET")
+	a.Router.HandleFunc("/schemas/{name}", a.getPayloadSchema).Methods("GET")
 	// Users
 	a.Router.HandleFunc("/users/{addr:0x[a-zA-Z0-9]{16}}/communities", a.getUserCommunities).Methods("GET")
+	a.Router.HandleFunc("/users/{addr:0x[a-zA-Z0-9]{16}}/profile", a.getUserProfile).Methods("GET")
+	a.Router.HandleFunc("/users/{addr:0x[a-zA-Z0-9]{16}}/profile", a.updateUserProfile).Methods("PUT", "OPTIONS")
+	a.Router.HandleFunc("/users/{addr:0x[a-zA-Z0-9]{16}}/achievements", a.getUserAchievements).Methods("GET")
+	a.Router.HandleFunc("/users/{addr:0x[a-zA-Z0-9]{16}}/verifications", a.getAddressVerifications).Methods("GET")
+	a.Router.HandleFunc("/users/{addr:0x[a-zA-Z0-9]{16}}/verifications", a.verifyAddress).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/users/{addr:0x[a-zA-Z0-9]{16}}/follows", a.getFollows).Methods("GET")
+	a.Router.HandleFunc("/users/{addr:0x[a-zA-Z0-9]{16}}/follows", a.follow).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/users/{addr:0x[a-zA-Z0-9]{16}}/follows", a.unfollow).Methods("DELETE", "OPTIONS")
+	a.Router.HandleFunc("/me/feed", a.getMyFeed).Methods("GET")
+	a.Router.HandleFunc("/users/{addr:0x[a-zA-Z0-9]{16}}/notifications", a.getNotifications).Methods("GET")
+	a.Router.HandleFunc("/users/{addr:0x[a-zA-Z0-9]{16}}/notifications/read", a.markNotificationsRead).
+		Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/users/{addr:0x[a-zA-Z0-9]{16}}/notification-preferences", a.getNotificationPreferences).
+		Methods("GET")
+	a.Router.HandleFunc("/users/{addr:0x[a-zA-Z0-9]{16}}/notification-preferences", a.updateNotificationPreferences).
+		Methods("PUT", "OPTIONS")
 	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/users", a.createCommunityUser).Methods("POST", "OPTIONS")
 	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/users", a.getCommunityUsers).Methods("GET")
 	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/users/type/{userType:[a-zA-Z]+}", a.getCommunityUsersByType).
 		Methods("GET")
 	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/users/{addr:0x[a-zA-Z0-9]{16}}/{userType:[a-zA-Z]+}", a.removeUserRole).
 		Methods("DELETE", "OPTIONS")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/join-requests", a.requirePlanLimit(models.PlanMembers, a.createJoinRequest)).
+		Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/join-requests", a.getJoinRequests).Methods("GET")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/join-requests/{id:[0-9]+}/approve", a.approveJoinRequest).
+		Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/join-requests/{id:[0-9]+}/deny", a.denyJoinRequest).
+		Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/invites", a.requirePlanLimit(models.PlanMembers, a.createInvite)).
+		Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/invites", a.getInvites).Methods("GET")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/invites/{id:[0-9]+}/revoke", a.revokeInvite).
+		Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/invites/redeem", a.redeemInvite).Methods("POST", "OPTIONS")
+	// Bans
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/bans", a.getCommunityBans).Methods("GET")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/bans/export", a.exportCommunityBans).Methods("GET")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/exports", a.createCommunityExport).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/exports/{exportId:[0-9]+}", a.getCommunityExport).Methods("GET")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/exports/{exportId:[0-9]+}/download", a.downloadCommunityExport).
+		Methods("GET")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/bans", a.banAddress).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/bans/{addr:0x[a-zA-Z0-9]{16}}", a.unbanAddress).
+		Methods("DELETE", "OPTIONS")
+	// Roles & Permissions
+	a.Router.HandleFunc("/permissions", a.getPermissions).Methods("GET")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/roles", a.getCommunityRoles).Methods("GET")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/roles", a.createCommunityRole).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/roles/{id:[0-9]+}", a.updateCommunityRole).
+		Methods("PUT", "OPTIONS")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/roles/{id:[0-9]+}", a.deleteCommunityRole).
+		Methods("DELETE", "OPTIONS")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/roles/{id:[0-9]+}/members", a.getCommunityRoleMembers).
+		Methods("GET")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/roles/{id:[0-9]+}/members", a.assignCommunityRole).
+		Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/roles/{id:[0-9]+}/members", a.unassignCommunityRole).
+		Methods("DELETE", "OPTIONS")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/users/{addr:0x[a-zA-Z0-9]{16}}/permissions", a.getUserPermissions).
+		Methods("GET")
 	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/leaderboard", a.getCommunityLeaderboard).Methods("GET")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/reputation-settings", a.getReputationSettings).Methods("GET")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/reputation-settings", a.setReputationSettings).Methods("PUT", "OPTIONS")
+	a.Router.HandleFunc("/communities/{communityId:[0-9]+}/users/{addr:0x[a-zA-Z0-9]{16}}/reputation", a.getUserReputation).
+		Methods("GET")
+	// Partner reads, with an API key instead of a session
+	a.Router.HandleFunc("/partner/communities/{id:[0-9]+}", a.requireApiKey(a.getCommunity)).Methods("GET")
+	a.Router.HandleFunc("/partner/communities/{communityId:[0-9]+}/proposals", a.requireApiKey(a.getProposalsForCommunity)).
+		Methods("GET")
+	a.Router.HandleFunc("/partner/communities/{communityId:[0-9]+}/proposals/{proposalId:[0-9]+}/votes", a.requireApiKey(a.getVoteDump)).
+		Methods("GET")
+	// Billing
+	a.Router.HandleFunc("/plans", a.getPlans).Methods("GET")
+	a.Router.HandleFunc("/billing/webhooks", a.handleBillingWebhook).Methods("POST")
 	// Utilities
 	a.Router.HandleFunc("/accounts/admin", a.getAdminList).Methods("GET")
+	a.Router.HandleFunc("/admin/pins/reconcile", a.reconcilePins).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/admin/communities", a.getAdminCommunities).Methods("GET")
+	a.Router.HandleFunc("/admin/communities/{id:[0-9]+}/feature", a.featureCommunity).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/admin/communities/{id:[0-9]+}/unfeature", a.unfeatureCommunity).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/admin/communities/{id:[0-9]+}/pin", a.pinCommunity).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/admin/communities/{id:[0-9]+}/unpin", a.unpinCommunity).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/admin/homepage/order", a.setHomepageOrder).Methods("PUT", "OPTIONS")
+	a.Router.HandleFunc("/admin/homepage/sections", a.createHomepageSection).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/admin/homepage/sections/{id:[0-9]+}", a.updateHomepageSection).Methods("PATCH", "OPTIONS")
+	a.Router.HandleFunc("/admin/homepage/sections/{id:[0-9]+}", a.deleteHomepageSection).Methods("DELETE", "OPTIONS")
+	a.Router.HandleFunc("/admin/communities/{id:[0-9]+}/suspend", a.suspendCommunity).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/admin/communities/{id:[0-9]+}/unsuspend", a.unsuspendCommunity).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/admin/communities/{id:[0-9]+}/quotas", a.setCommunityQuotas).Methods("PUT", "OPTIONS")
+	a.Router.HandleFunc("/admin/jobs/failed", a.getFailedJobs).Methods("GET")
+	a.Router.HandleFunc("/admin/allowlist", a.getPlatformAdmins).Methods("GET")
+	a.Router.HandleFunc("/admin/allowlist", a.addPlatformAdmins).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/admin/allowlist", a.removePlatformAdmins).Methods("DELETE", "OPTIONS")
+	a.Router.HandleFunc("/admin/blocklist", a.getPlatformBlocklist).Methods("GET")
+	a.Router.HandleFunc("/admin/blocklist", a.blockAddresses).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/admin/blocklist", a.unblockAddresses).Methods("DELETE", "OPTIONS")
+	a.Router.HandleFunc("/admin/verifications/{addr:0x[a-zA-Z0-9]{16}}", a.attestAddress).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/admin/verifications/{addr:0x[a-zA-Z0-9]{16}}/{provider}", a.revokeVerification).
+		Methods("DELETE", "OPTIONS")
+	a.Router.HandleFunc("/admin/api-keys", a.getApiKeys).Methods("GET")
+	a.Router.HandleFunc("/admin/api-keys", a.createApiKey).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/admin/api-keys/{id:[0-9]+}/usage", a.getApiKeyUsage).Methods("GET")
+	a.Router.HandleFunc("/admin/api-keys/{id:[0-9]+}", a.revokeApiKey).Methods("DELETE", "OPTIONS")
+	a.Router.HandleFunc("/admin/stats", a.getPlatformStats).Methods("GET")
+	a.Router.HandleFunc("/admin/config", a.getConfig).Methods("GET")
+	a.Router.HandleFunc("/admin/tenants", a.getTenants).Methods("GET")
+	a.Router.HandleFunc("/admin/tenants", a.createTenant).Methods("POST", "OPTIONS")
+	a.Router.HandleFunc("/admin/tenants/{id:[0-9]+}", a.updateTenant).Methods("PATCH", "OPTIONS")
+	a.Router.HandleFunc("/admin/features", a.getFeatureFlags).Methods("GET")
+	a.Router.HandleFunc("/admin/features/{name}", a.setFeatureRollout).Methods("PUT", "OPTIONS")
+	a.Router.HandleFunc("/admin/features/{name}/communities/{communityId:[0-9]+}", a.setCommunityFeature).Methods("PUT", "DELETE", "OPTIONS")
 	a.Router.HandleFunc("/accounts/blocklist", a.getCommunityBlocklist).Methods("GET")
+	a.Router.HandleFunc("/accounts/{addr:0x[a-zA-Z0-9]{16}}/activity", a.getAccountActivity).Methods("GET")
 	a.Router.HandleFunc("/accounts/{addr:0x[a-zA-Z0-9]{16}}/{blockHeight:[0-9]+}", a.getAccountAtBlockHeight).Methods("GET")
 
 }
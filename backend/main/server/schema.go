@@ -0,0 +1,234 @@
+package server
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+)
+
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// payloadSchema is a request payload decoded strictly: fields its type
+// doesn't declare are refused, and the type is validated as soon as it is
+// decoded. Its JSON Schema is published at /schemas/{name}.
+type payloadSchema struct {
+	Name    string `json:"name"`
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	payload interface{}
+}
+
+var payloadSchemas = []payloadSchema{
+	{"community", "POST", "/communities", models.CreateCommunityRequestPayload{}},
+	{"proposal", "POST", "/communities/{communityId}/proposals", models.Proposal{}},
+	{"vote", "POST", "/proposals/{proposalId}/votes", models.Vote{}},
+	{"strategy-change", "POST", "/communities/{communityId}/strategies/impact", models.StrategyChangePayload{}},
+	{"strategy-dry-run", "POST", "/communities/{communityId}/strategies/dry-run", models.DryRunTallyPayload{}},
+}
+
+// strictPayload reports whether data, a pointer to a payload, is one of
+// payloadSchemas.
+func strictPayload(data interface{}) bool {
+	t := reflect.TypeOf(data)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	for _, s := range payloadSchemas {
+		if reflect.TypeOf(s.payload) == t {
+			return true
+		}
+	}
+	return false
+}
+
+func findPayloadSchema(name string) (payloadSchema, bool) {
+	for _, s := range payloadSchemas {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return payloadSchema{}, false
+}
+
+// jsonSchema is the part of JSON Schema that describes Go types.
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Id                   string                 `json:"$id,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Type                 interface{}            `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+	MinItems             *int                   `json:"minItems,omitempty"`
+	MaxItems             *int                   `json:"maxItems,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+}
+
+// Schema describes the payload from the json and validate tags of its type,
+// the same tags it is decoded and validated with.
+func (s payloadSchema) Schema(baseUrl string) *jsonSchema {
+	schema := schemaOf(reflect.TypeOf(s.payload), map[reflect.Type]bool{})
+	schema.Schema = jsonSchemaDraft
+	schema.Title = s.Method + " " + s.Path
+	if baseUrl != "" {
+		schema.Id = strings.TrimSuffix(baseUrl, "/") + "/schemas/" + s.Name
+	}
+	return schema
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+func schemaOf(t reflect.Type, seen map[reflect.Type]bool) *jsonSchema {
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+
+	schema := &jsonSchema{}
+	switch {
+	case t == timeType:
+		schema.Type, schema.Format = "string", "date-time"
+	case t == rawMessageType, t.Kind() == reflect.Interface:
+		// any JSON value
+		return schema
+	case t.Kind() == reflect.String:
+		schema.Type = "string"
+	case t.Kind() == reflect.Bool:
+		schema.Type = "boolean"
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		schema.Type = "integer"
+	case t.Kind() == reflect.Float32, t.Kind() == reflect.Float64:
+		schema.Type = "number"
+	case t.Kind() == reflect.Slice, t.Kind() == reflect.Array:
+		schema.Type = "array"
+		schema.Items = schemaOf(t.Elem(), seen)
+	case t.Kind() == reflect.Map:
+		schema.Type = "object"
+	case t.Kind() == reflect.Struct:
+		if seen[t] {
+			// a type that contains itself is left open where it recurs
+			return schema
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		closed := false
+		schema.Type = "object"
+		schema.Properties = make(map[string]*jsonSchema)
+		schema.AdditionalProperties = &closed
+		addFields(schema, t, seen)
+	}
+
+	if nullable && schema.Type != nil {
+		schema.Type = []string{schema.Type.(string), "null"}
+	}
+	return schema
+}
+
+// addFields adds the fields of t to schema, those of embedded structs without
+// a JSON name inline as encoding/json decodes them.
+func addFields(schema *jsonSchema, t reflect.Type, seen map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		name := strings.SplitN(tag, ",", 2)[0]
+		if name == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addFields(schema, ft, seen)
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		property := schemaOf(f.Type, seen)
+		if applyRules(property, f.Tag.Get("validate")) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+}
+
+// applyRules adds the validate rules JSON Schema can express to schema, and
+// reports whether the field is required.
+func applyRules(schema *jsonSchema, rules string) bool {
+	required := false
+	for _, rule := range strings.Split(rules, ",") {
+		name, param := rule, ""
+		if eq := strings.Index(rule, "="); eq >= 0 {
+			name, param = rule[:eq], rule[eq+1:]
+		}
+
+		switch name {
+		case "dive":
+			// the rules that follow are about the items
+			return required
+		case "required":
+			required = true
+		case "oneof":
+			schema.Enum = strings.Fields(param)
+		case "url":
+			schema.Format = "uri"
+		case "min", "max", "len":
+			n, err := strconv.Atoi(param)
+			if err != nil {
+				continue
+			}
+			setBound(schema, name, n)
+		}
+	}
+	return required
+}
+
+func setBound(schema *jsonSchema, rule string, n int) {
+	f := float64(n)
+	kind := schema.Type
+	if types, ok := kind.([]string); ok {
+		kind = types[0]
+	}
+	switch kind {
+	case "string":
+		if rule != "max" {
+			schema.MinLength = &n
+		}
+		if rule != "min" {
+			schema.MaxLength = &n
+		}
+	case "array":
+		if rule != "max" {
+			schema.MinItems = &n
+		}
+		if rule != "min" {
+			schema.MaxItems = &n
+		}
+	case "integer", "number":
+		if rule != "max" {
+			schema.Minimum = &f
+		}
+		if rule != "min" {
+			schema.Maximum = &f
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/DapperCollectives/CAST/backend/main/middleware"
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// TenantHeader names the tenant of a request, by slug or id, for frontends
+// that don't have a hostname of their own.
+const TenantHeader = middleware.TenantHeader
+
+// slugs can't be taken for ids
+var tenantSlug = regexp.MustCompile(`^[a-z][a-z0-9-]{0,63}$`)
+
+type tenantKey struct{}
+
+// tenantDirectory holds the tenants in memory, so requests are resolved to
+// a tenant without a query.
+type tenantDirectory struct {
+	mu     sync.RWMutex
+	byId   map[int]*models.Tenant
+	bySlug map[string]*models.Tenant
+	byHost map[string]*models.Tenant
+}
+
+func (d *tenantDirectory) Set(tenants []*models.Tenant) {
+	byId := make(map[int]*models.Tenant, len(tenants))
+	bySlug := make(map[string]*models.Tenant, len(tenants))
+	byHost := make(map[string]*models.Tenant)
+	for _, t := range tenants {
+		byId[t.ID] = t
+		bySlug[t.Slug] = t
+		for _, host := range t.Hostnames {
+			byHost[strings.ToLower(host)] = t
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.byId, d.bySlug, d.byHost = byId, bySlug, byHost
+}
+
+// Lookup finds a tenant by its slug or id.
+func (d *tenantDirectory) Lookup(ref string) (*models.Tenant, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if t, ok := d.bySlug[ref]; ok {
+		return t, true
+	}
+	if id, err := strconv.Atoi(ref); err == nil {
+		t, ok := d.byId[id]
+		return t, ok
+	}
+	return nil, false
+}
+
+func (d *tenantDirectory) ForHost(host string) (*models.Tenant, bool) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	t, ok := d.byHost[strings.ToLower(host)]
+	return t, ok
+}
+
+// Default is the default tenant, or a stand-in for it until the tenants are
+// loaded.
+func (d *tenantDirectory) Default() *models.Tenant {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if t, ok := d.byId[models.DefaultTenantID]; ok {
+		return t
+	}
+	return &models.Tenant{ID: models.DefaultTenantID, Slug: "default"}
+}
+
+// resolveTenant adds the tenant of the request to its context. In
+// multi-tenant mode the tenant is the one named by X-Tenant-ID, or else the
+// one serving the hostname, or else the default tenant; otherwise every
+// request belongs to the default tenant.
+func (a *App) resolveTenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant := a.Tenants.Default()
+		if a.Config.Multi_tenant {
+			if ref := r.Header.Get(TenantHeader); ref != "" {
+				t, ok := a.Tenants.Lookup(ref)
+				if !ok {
+					log.Ctx(r.Context()).Warn().Msgf("Unknown tenant %q.", ref)
+					respondWithError(w, errUnknownTenant)
+					return
+				}
+				tenant = t
+			} else if t, ok := a.Tenants.ForHost(r.Host); ok {
+				tenant = t
+			}
+		}
+
+		logger := log.Ctx(r.Context()).With().Str("tenant", tenant.Slug).Logger()
+		ctx := context.WithValue(logger.WithContext(r.Context()), tenantKey{}, tenant)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requestTenant is the tenant resolveTenant found for the request.
+func requestTenant(r *http.Request) *models.Tenant {
+	if t, ok := r.Context().Value(tenantKey{}).(*models.Tenant); ok {
+		return t
+	}
+	return &models.Tenant{ID: models.DefaultTenantID, Slug: "default"}
+}
+
+// scopeToTenant answers Not Found for communities, proposals and lists of
+// another tenant named in the path, so neither they nor their votes,
+// results and other sub-resources are served to the request's tenant.
+func (a *App) scopeToTenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		tenant := requestTenant(r)
+		vars := mux.Vars(r)
+		route, _ := mux.CurrentRoute(r).GetPathTemplate()
+		segments := strings.Split(route, "/")
+		for i := 1; i < len(segments); i++ {
+			id, err := strconv.Atoi(vars[pathVarName(segments[i])])
+			if err != nil {
+				continue
+			}
+			tenantId, found, err := models.GetRecordTenant(a.DB, segments[i-1], id)
+			if err != nil {
+				log.Ctx(r.Context()).Error().Err(err).Msgf("Error finding the tenant of %s %d.", segments[i-1], id)
+				respondWithError(w, errIncompleteRequest)
+				return
+			}
+			if found && tenantId != tenant.ID {
+				log.Ctx(r.Context()).Warn().Msgf("%s %d belongs to tenant %d.", segments[i-1], id, tenantId)
+				errResponse := errIncompleteRequest
+				errResponse.StatusCode = http.StatusNotFound
+				errResponse.Details = "Not found."
+				respondWithError(w, errResponse)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// pathVarName is the name of the variable in a route template segment such
+// as {id:[0-9]+}, or "" for a literal segment.
+func pathVarName(segment string) string {
+	if !strings.HasPrefix(segment, "{") {
+		return ""
+	}
+	name := strings.TrimPrefix(segment, "{")
+	if end := strings.IndexAny(name, ":}"); end >= 0 {
+		name = name[:end]
+	}
+	return name
+}
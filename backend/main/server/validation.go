@@ -0,0 +1,166 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// fieldError is a validation error of one field of a payload, named by its
+// JSON path such as strategies[0].contract.addr, for clients to point at
+// the field that failed.
+type fieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e *fieldError) Error() string {
+	return e.Message
+}
+
+type fieldErrors []fieldError
+
+func (e fieldErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, f := range e {
+		messages[i] = f.Message
+	}
+	return strings.Join(messages, " ")
+}
+
+// newValidator reports fields by their JSON names.
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return v
+}
+
+// fieldErrorsOf lists the fields err is about, or nil when it isn't a
+// validation error.
+func fieldErrorsOf(err error) fieldErrors {
+	var single *fieldError
+	if errors.As(err, &single) {
+		return fieldErrors{*single}
+	}
+	var many fieldErrors
+	if errors.As(err, &many) {
+		return many
+	}
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		return nil
+	}
+
+	fields := make(fieldErrors, len(invalid))
+	for i, f := range invalid {
+		// the namespace starts with the name of the payload type
+		path := f.Namespace()
+		if dot := strings.Index(path, "."); dot >= 0 {
+			path = path[dot+1:]
+		}
+		fields[i] = fieldError{
+			Field:   path,
+			Rule:    f.Tag(),
+			Param:   f.Param(),
+			Message: validationMessage(path, f),
+		}
+	}
+	return fields
+}
+
+func validationMessage(path string, f validator.FieldError) string {
+	switch f.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required.", path)
+	case "len":
+		return fmt.Sprintf("%s must be %s characters long.", path, f.Param())
+	case "min":
+		return fmt.Sprintf("%s must be at least %s.", path, f.Param())
+	case "max":
+		return fmt.Sprintf("%s must be at most %s.", path, f.Param())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of %s.", path, f.Param())
+	case "hexadecimal":
+		return fmt.Sprintf("%s must be hexadecimal.", path)
+	case "url":
+		return fmt.Sprintf("%s must be a URL.", path)
+	default:
+		return fmt.Sprintf("%s is not valid (%s).", path, f.Tag())
+	}
+}
+
+// setDetails explains the error with err, listing the fields that failed
+// when it is a validation error.
+func (e *errorResponse) setDetails(err error) {
+	e.Details = err.Error()
+	if fields := fieldErrorsOf(err); fields != nil {
+		e.Details = fields.Error()
+		e.Fields = &fields
+	}
+}
+
+// setPayloadDetails explains why a payload was refused, keeping the generic
+// details when the body couldn't be read at all. Bodies too large or too
+// slow to send are answered with their own error.
+func (e *errorResponse) setPayloadDetails(err error) {
+	var bodyErr *bodyError
+	if errors.As(err, &bodyErr) {
+		*e = bodyErr.response
+		return
+	}
+	if fieldErrorsOf(err) != nil || errors.Is(err, errInvalidTimestamp) {
+		e.setDetails(err)
+	}
+}
+
+// invalidPayload is errInvalidPayload explaining err.
+func invalidPayload(err error) errorResponse {
+	errResponse := errInvalidPayload
+	errResponse.setPayloadDetails(err)
+	return errResponse
+}
+
+// unknownField is the field named by the error encoding/json returns for
+// fields the payload doesn't declare.
+func unknownField(err error) (string, bool) {
+	const prefix = "json: unknown field "
+	if !strings.HasPrefix(err.Error(), prefix) {
+		return "", false
+	}
+	field, uerr := strconv.Unquote(strings.TrimPrefix(err.Error(), prefix))
+	return field, uerr == nil
+}
+
+// jsonTypeName names the JSON type a Go type is decoded from.
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
@@ -0,0 +1,68 @@
+package shared
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// webhooks older than this are refused, so captured ones can't be replayed
+const billingSignatureTolerance = 5 * time.Minute
+
+var ErrInvalidBillingSignature = errors.New("Invalid billing webhook signature.")
+
+// SignBillingWebhook signs a webhook body the way the payment processor
+// does, as "t=<unix time>,v1=<hex HMAC-SHA256 of t.body>".
+func SignBillingWebhook(secret string, body []byte, at time.Time) string {
+	t := strconv.FormatInt(at.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", t, billingMac(secret, t, body))
+}
+
+// VerifyBillingWebhook checks the signature of a webhook body and that it
+// was signed recently.
+func VerifyBillingWebhook(secret, signature string, body []byte, now time.Time) error {
+	if secret == "" {
+		return ErrInvalidBillingSignature
+	}
+
+	var t, v1 string
+	for _, part := range strings.Split(signature, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			t = value
+		case "v1":
+			v1 = value
+		}
+	}
+	unix, err := strconv.ParseInt(t, 10, 64)
+	if err != nil {
+		return ErrInvalidBillingSignature
+	}
+	sig, err := hex.DecodeString(v1)
+	if err != nil {
+		return ErrInvalidBillingSignature
+	}
+	expected, _ := hex.DecodeString(billingMac(secret, t, body))
+	if !hmac.Equal(sig, expected) {
+		return ErrInvalidBillingSignature
+	}
+
+	signedAt := time.Unix(unix, 0)
+	if now.Sub(signedAt) > billingSignatureTolerance || signedAt.Sub(now) > billingSignatureTolerance {
+		return ErrInvalidBillingSignature
+	}
+	return nil
+}
+
+func billingMac(secret, t string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(t + "."))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
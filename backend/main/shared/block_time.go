@@ -0,0 +1,62 @@
+package shared
+
+import (
+	"os"
+	"time"
+
+	"github.com/onflow/flow-go-sdk"
+)
+
+const (
+	// blocks the average block interval is measured over
+	blockIntervalWindow  = 1000
+	defaultBlockInterval = 1250 * time.Millisecond
+)
+
+func (fa *FlowAdapter) GetLatestSealedHeader() (*flow.BlockHeader, error) {
+	return fa.Client.GetLatestBlockHeader(fa.Context, true)
+}
+
+// BlockInterval is FLOW_BLOCK_INTERVAL when set, or else the average time
+// between the blocks leading to head.
+func (fa *FlowAdapter) BlockInterval(head *flow.BlockHeader) time.Duration {
+	if interval, err := time.ParseDuration(os.Getenv("FLOW_BLOCK_INTERVAL")); err == nil && interval > 0 {
+		return interval
+	}
+	if head.Height < blockIntervalWindow {
+		return defaultBlockInterval
+	}
+
+	past, err := fa.Client.GetBlockHeaderByHeight(fa.Context, head.Height-blockIntervalWindow)
+	if err != nil || !head.Timestamp.After(past.Timestamp) {
+		return defaultBlockInterval
+	}
+	return head.Timestamp.Sub(past.Timestamp) / blockIntervalWindow
+}
+
+// EstimateBlockTime is when the block at the height was, or is expected to
+// be, sealed: the time of the block once it is sealed, and before that the
+// time of head plus a block interval for each block still to come.
+func (fa *FlowAdapter) EstimateBlockTime(height uint64, head *flow.BlockHeader, interval time.Duration) (time.Time, error) {
+	if height <= head.Height {
+		header, err := fa.Client.GetBlockHeaderByHeight(fa.Context, height)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return header.Timestamp.UTC(), nil
+	}
+	return head.Timestamp.UTC().Add(time.Duration(height-head.Height) * interval), nil
+}
+
+// EstimateBlockHeight is the block sealed at t, estimated back from head a
+// block interval at a time.
+func EstimateBlockHeight(t time.Time, head *flow.BlockHeader, interval time.Duration) uint64 {
+	if !t.Before(head.Timestamp) {
+		return head.Height
+	}
+	blocks := uint64(head.Timestamp.Sub(t) / interval)
+	if blocks >= head.Height {
+		return 0
+	}
+	return head.Height - blocks
+}
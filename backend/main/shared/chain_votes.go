@@ -0,0 +1,89 @@
+package shared
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/client"
+)
+
+// VoteCast is a vote cast on chain with the CASTVoting contract, along
+// with the transaction and block it was cast in.
+type VoteCast struct {
+	Proposal_id  uint64
+	Voter        string
+	Choice       string
+	Tx_id        string
+	Event_index  int
+	Block_height uint64
+	Block_time   time.Time
+}
+
+// VoteCastEventType is the type of the VoteCast events of the CASTVoting
+// contract deployed to the address.
+func VoteCastEventType(contractAddr string) string {
+	return fmt.Sprintf("A.%s.CASTVoting.VoteCast", strings.TrimPrefix(contractAddr, "0x"))
+}
+
+// GetVoteCastEvents returns the votes cast on chain between the heights,
+// both included, in the order they were cast.
+func (fa *FlowAdapter) GetVoteCastEvents(contractAddr string, start, end uint64) ([]VoteCast, error) {
+	blocks, err := fa.Client.GetEventsForHeightRange(fa.Context, client.EventRangeQuery{
+		Type:        VoteCastEventType(contractAddr),
+		StartHeight: start,
+		EndHeight:   end,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	votes := []VoteCast{}
+	for _, block := range blocks {
+		for _, event := range block.Events {
+			vote, err := decodeVoteCast(event.Value)
+			if err != nil {
+				return nil, fmt.Errorf("event %d of transaction %s: %w", event.EventIndex, event.TransactionID, err)
+			}
+			vote.Tx_id = event.TransactionID.Hex()
+			vote.Event_index = event.EventIndex
+			vote.Block_height = block.Height
+			vote.Block_time = block.BlockTimestamp
+			votes = append(votes, vote)
+		}
+	}
+	return votes, nil
+}
+
+func decodeVoteCast(event cadence.Event) (VoteCast, error) {
+	var vote VoteCast
+	if len(event.Fields) != len(event.EventType.Fields) {
+		return vote, fmt.Errorf("malformed VoteCast event")
+	}
+
+	for i, field := range event.EventType.Fields {
+		var ok bool
+		switch field.Identifier {
+		case "proposalId":
+			var id cadence.UInt64
+			id, ok = event.Fields[i].(cadence.UInt64)
+			vote.Proposal_id = uint64(id)
+		case "voter":
+			var voter cadence.Address
+			voter, ok = event.Fields[i].(cadence.Address)
+			vote.Voter = "0x" + flow.Address(voter).Hex()
+		case "choice":
+			var choice cadence.String
+			choice, ok = event.Fields[i].(cadence.String)
+			vote.Choice = string(choice)
+		default:
+			ok = true
+		}
+		if !ok {
+			return vote, fmt.Errorf("VoteCast field %s has an unexpected type", field.Identifier)
+		}
+	}
+	return vote, nil
+}
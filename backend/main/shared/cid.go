@@ -0,0 +1,50 @@
+package shared
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+)
+
+// IPFS stores files up to its default chunk size as a single block.
+const maxSingleBlockSize = 256 * 1024
+
+var ErrContentTooLarge = errors.New("content spans several IPFS blocks")
+
+// ComputeCid returns the CIDv0 that IPFS, with its default settings, assigns
+// to content added as a file. Only content fitting in a single block is
+// supported.
+func ComputeCid(content []byte) (string, error) {
+	if len(content) > maxSingleBlockSize {
+		return "", ErrContentTooLarge
+	}
+
+	// unixfs Data message for a file: Type, Data, filesize
+	file := []byte{0x08, 0x02}
+	if len(content) > 0 {
+		file = append(file, 0x12)
+		file = appendUvarint(file, uint64(len(content)))
+		file = append(file, content...)
+	}
+	file = append(file, 0x18)
+	file = appendUvarint(file, uint64(len(content)))
+
+	// dag-pb node holding the file as its Data, without links
+	node := []byte{0x0a}
+	node = appendUvarint(node, uint64(len(file)))
+	node = append(node, file...)
+
+	hash, err := multihash.Sum(node, multihash.SHA2_256, -1)
+	if err != nil {
+		return "", err
+	}
+	return cid.NewCidV0(hash).String(), nil
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, v)
+	return append(b, buf[:n]...)
+}
@@ -0,0 +1,304 @@
+package shared
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/axiomzen/envconfig"
+)
+
+const redacted = "********"
+
+var flowAddress = regexp.MustCompile(`^0x[0-9a-fA-F]{16}$`)
+
+// Config is the configuration of the app, read from the environment once at
+// startup. Every variable may also be set with the FVT_ prefix, which takes
+// precedence.
+type Config struct {
+	Features map[string]bool `json:"features" default:"useCorsMiddleware:false,validateTimestamps:true,validateAllowlist:true,validateBlocklist:true,validateSigs:true"`
+
+	App_env        string `json:"appEnv"        envconfig:"APP_ENV"`
+	Flow_env       string `json:"flowEnv"       envconfig:"FLOW_ENV" default:"emulator"`
+	Api_port       string `json:"apiPort"       envconfig:"API_PORT"`
+	Public_app_url string `json:"publicAppUrl"  envconfig:"PUBLIC_APP_URL"`
+	Public_api_url string `json:"publicApiUrl"  envconfig:"PUBLIC_API_URL"`
+	Multi_tenant   bool   `json:"multiTenant"   envconfig:"MULTI_TENANT"`
+
+	DatabaseConfig `json:"database"`
+	FlowNodeConfig `json:"flowNodes"`
+	IpfsConfig     `json:"ipfs"`
+	UploadConfig   `json:"uploads"`
+	AccessConfig   `json:"access"`
+	IdentityConfig `json:"identity"`
+	HttpConfig     `json:"http"`
+	UsageConfig    `json:"usage"`
+	BillingConfig  `json:"billing"`
+}
+
+type DatabaseConfig struct {
+	Db_host         string `json:"host"        envconfig:"DB_HOST"`
+	Db_port         string `json:"port"        envconfig:"DB_PORT" default:"5432"`
+	Db_name         string `json:"name"        envconfig:"DB_NAME"`
+	Test_db_name    string `json:"testName"    envconfig:"TEST_DB_NAME"`
+	Db_username     string `json:"username"    envconfig:"DB_USERNAME"`
+	Db_password     string `json:"password"    envconfig:"DB_PASSWORD"`
+	Db_auto_migrate bool   `json:"autoMigrate" envconfig:"DB_AUTO_MIGRATE"`
+}
+
+// FlowNodeConfig says how far back the access node executes scripts, and
+// where to read older blocks; see ScriptRetention.
+type FlowNodeConfig struct {
+	Flow_archive_node       *string `json:"archiveNode"       envconfig:"FLOW_ARCHIVE_NODE"`
+	Flow_access_root_height uint64  `json:"accessRootHeight"  envconfig:"FLOW_ACCESS_ROOT_HEIGHT"`
+	Flow_script_retention   uint64  `json:"scriptRetention"   envconfig:"FLOW_SCRIPT_RETENTION"`
+}
+
+type IpfsConfig struct {
+	Ipfs_key         string `json:"key"        envconfig:"IPFS_KEY"`
+	Ipfs_secret      string `json:"secret"     envconfig:"IPFS_SECRET"`
+	Ipfs_gateway_url string `json:"gatewayUrl" envconfig:"IPFS_GATEWAY_URL"`
+}
+
+type UploadConfig struct {
+	Max_body_size       int64  `json:"maxBodySize"       envconfig:"MAX_BODY_SIZE"       default:"1048576"`
+	Max_file_size       int64  `json:"maxFileSize"       envconfig:"MAX_FILE_SIZE"       default:"5242880"`
+	Max_import_size     int64  `json:"maxImportSize"     envconfig:"MAX_IMPORT_SIZE"     default:"52428800"`
+	Custom_scripts_path string `json:"customScriptsPath" envconfig:"CUSTOM_SCRIPTS_PATH" default:"./main/cadence/scripts/custom/scripts.json"`
+}
+
+// AccessConfig lists addresses as the environment gives them, separated by
+// whitespace.
+type AccessConfig struct {
+	Admin_addrs      string `json:"adminAddrs"     envconfig:"ADMIN_ADDRS"`
+	Tx_options_addrs string `json:"txOptionsAddrs" envconfig:"TX_OPTIONS_ADDRS"`
+
+	// requests per minute an API key may make unless it sets its own limit
+	Api_key_rate_limit int `json:"apiKeyRateLimit" envconfig:"API_KEY_RATE_LIMIT" default:"600"`
+}
+
+// IdentityConfig sets up the identity providers addresses can verify with.
+// A provider is only offered once it is configured.
+type IdentityConfig struct {
+	Brightid_node_url          string  `json:"brightidNodeUrl"          envconfig:"BRIGHTID_NODE_URL"          default:"https://app.brightid.org/node/v6"`
+	Brightid_context           string  `json:"brightidContext"          envconfig:"BRIGHTID_CONTEXT"`
+	Gitcoin_passport_api_key   string  `json:"gitcoinPassportApiKey"    envconfig:"GITCOIN_PASSPORT_API_KEY"`
+	Gitcoin_passport_scorer_id string  `json:"gitcoinPassportScorerId"  envconfig:"GITCOIN_PASSPORT_SCORER_ID"`
+	Gitcoin_passport_threshold float64 `json:"gitcoinPassportThreshold" envconfig:"GITCOIN_PASSPORT_THRESHOLD" default:"20"`
+}
+
+// HttpConfig lists the browser origins allowed to call the API, separated by
+// whitespace, with the admin routes kept to their own list. Embeds are open
+// to every origin whatever the lists say.
+type HttpConfig struct {
+	Cors_origins       string `json:"corsOrigins"      envconfig:"CORS_ORIGINS"`
+	Cors_admin_origins string `json:"corsAdminOrigins" envconfig:"CORS_ADMIN_ORIGINS"`
+
+	// seconds browsers should only use https, 0 to not send the header
+	Hsts_max_age int `json:"hstsMaxAge" envconfig:"HSTS_MAX_AGE"`
+}
+
+// UsageConfig is how much of what the platform pays for each community may
+// use a month, 0 for no limit. Storage is in bytes. Admins are warned once
+// a community uses Usage_soft_limit of a quota.
+type UsageConfig struct {
+	Usage_quota_votes         int64   `json:"quotaVotes"         envconfig:"USAGE_QUOTA_VOTES"`
+	Usage_quota_pins          int64   `json:"quotaPins"          envconfig:"USAGE_QUOTA_PINS"`
+	Usage_quota_storage       int64   `json:"quotaStorage"       envconfig:"USAGE_QUOTA_STORAGE"`
+	Usage_quota_notifications int64   `json:"quotaNotifications" envconfig:"USAGE_QUOTA_NOTIFICATIONS"`
+	Usage_soft_limit          float64 `json:"softLimit"          envconfig:"USAGE_SOFT_LIMIT" default:"0.8"`
+}
+
+// BillingConfig turns on the limits of community plans, and sets up the
+// payment processor, which grants plans through entitlement webhooks
+// signed with Billing_webhook_secret. Admins are sent to
+// Billing_checkout_url to upgrade, with {communityId} filled in.
+type BillingConfig struct {
+	Billing_enabled        bool   `json:"enabled"       envconfig:"BILLING_ENABLED"`
+	Billing_webhook_secret string `json:"webhookSecret" envconfig:"BILLING_WEBHOOK_SECRET"`
+	Billing_checkout_url   string `json:"checkoutUrl"   envconfig:"BILLING_CHECKOUT_URL"`
+}
+
+// LoadConfig reads the configuration from the environment and validates it.
+func LoadConfig() (Config, error) {
+	var c Config
+	if err := envconfig.Process("FVT", &c); err != nil {
+		return c, err
+	}
+	return c, c.Validate()
+}
+
+// Validate lists every setting that is wrong, rather than the first one.
+func (c Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	switch c.Flow_env {
+	case "emulator", "testnet", "mainnet":
+	default:
+		add("FLOW_ENV must be emulator, testnet or mainnet, not %q.", c.Flow_env)
+	}
+
+	if c.Db_host == "" {
+		add("DB_HOST is required.")
+	}
+	if c.Db_username == "" {
+		add("DB_USERNAME is required.")
+	}
+	if c.DatabaseName() == "" {
+		if c.App_env == "TEST" {
+			add("TEST_DB_NAME is required to run tests.")
+		} else {
+			add("DB_NAME is required.")
+		}
+	}
+	if port, err := strconv.Atoi(c.Db_port); err != nil || port <= 0 {
+		add("DB_PORT must be a port number, not %q.", c.Db_port)
+	}
+
+	if c.Max_body_size <= 0 {
+		add("MAX_BODY_SIZE must be a positive number of bytes.")
+	}
+	if c.Max_file_size <= 0 {
+		add("MAX_FILE_SIZE must be a positive number of bytes.")
+	}
+	if c.Max_import_size <= 0 {
+		add("MAX_IMPORT_SIZE must be a positive number of bytes.")
+	}
+	if c.Api_key_rate_limit <= 0 {
+		add("API_KEY_RATE_LIMIT must be a positive number of requests per minute.")
+	}
+
+	for name, value := range map[string]string{
+		"PUBLIC_APP_URL":       c.Public_app_url,
+		"PUBLIC_API_URL":       c.Public_api_url,
+		"IPFS_GATEWAY_URL":     c.Ipfs_gateway_url,
+		"BRIGHTID_NODE_URL":    c.Brightid_node_url,
+		"BILLING_CHECKOUT_URL": c.Billing_checkout_url,
+	} {
+		if value == "" {
+			continue
+		}
+		if u, err := url.Parse(value); err != nil || u.Scheme == "" || u.Host == "" {
+			add("%s must be an absolute URL, not %q.", name, value)
+		}
+	}
+
+	for name, value := range map[string]string{
+		"ADMIN_ADDRS":      c.Admin_addrs,
+		"TX_OPTIONS_ADDRS": c.Tx_options_addrs,
+	} {
+		for _, addr := range strings.Fields(value) {
+			if !flowAddress.MatchString(addr) {
+				add("%s has %q, which is not a Flow address like 0x0123456789abcdef.", name, addr)
+			}
+		}
+	}
+
+	for name, value := range map[string]string{
+		"CORS_ORIGINS":       c.Cors_origins,
+		"CORS_ADMIN_ORIGINS": c.Cors_admin_origins,
+	} {
+		for _, origin := range strings.Fields(value) {
+			if origin == "*" {
+				if name == "CORS_ADMIN_ORIGINS" {
+					add("CORS_ADMIN_ORIGINS must list origins, * would let any site call the admin API.")
+				}
+				continue
+			}
+			if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" {
+				add("%s has %q, which is not an origin like https://example.com.", name, origin)
+			}
+		}
+	}
+	if c.Hsts_max_age < 0 {
+		add("HSTS_MAX_AGE must not be negative.")
+	}
+
+	if c.Gitcoin_passport_threshold < 0 {
+		add("GITCOIN_PASSPORT_THRESHOLD must not be negative.")
+	}
+
+	for name, value := range map[string]int64{
+		"USAGE_QUOTA_VOTES":         c.Usage_quota_votes,
+		"USAGE_QUOTA_PINS":          c.Usage_quota_pins,
+		"USAGE_QUOTA_STORAGE":       c.Usage_quota_storage,
+		"USAGE_QUOTA_NOTIFICATIONS": c.Usage_quota_notifications,
+	} {
+		if value < 0 {
+			add("%s must not be negative.", name)
+		}
+	}
+	if c.Usage_soft_limit <= 0 || c.Usage_soft_limit > 1 {
+		add("USAGE_SOFT_LIMIT must be above 0 and at most 1.")
+	}
+
+	if len(problems) > 0 {
+		return errors.New("Invalid configuration: " + strings.Join(problems, " "))
+	}
+	return nil
+}
+
+// DatabaseName is the database to connect to, TEST_DB_NAME when running
+// tests.
+func (c Config) DatabaseName() string {
+	if c.App_env == "TEST" {
+		return c.Test_db_name
+	}
+	return c.Db_name
+}
+
+// IsDevelopment reports whether the app runs locally or under tests, where
+// secrets may be left unset.
+func (c Config) IsDevelopment() bool {
+	return c.App_env == "TEST" || c.App_env == "DEV"
+}
+
+// Warnings lists settings that are valid but likely a mistake.
+func (c Config) Warnings() []string {
+	var warnings []string
+	if !c.IsDevelopment() && (c.Ipfs_key == "" || c.Ipfs_secret == "") {
+		warnings = append(warnings, "IPFS_KEY and IPFS_SECRET are not set, pinning to IPFS will fail.")
+	}
+	if c.Billing_enabled && c.Billing_webhook_secret == "" {
+		warnings = append(warnings, "BILLING_WEBHOOK_SECRET is not set, communities can't be upgraded from the free plan.")
+	}
+	return warnings
+}
+
+// Redacted is the configuration with its secrets masked, safe to show.
+func (c Config) Redacted() Config {
+	mask := func(s *string) {
+		if *s != "" {
+			*s = redacted
+		}
+	}
+	mask(&c.Db_password)
+	mask(&c.Ipfs_key)
+	mask(&c.Ipfs_secret)
+	mask(&c.Gitcoin_passport_api_key)
+	mask(&c.Billing_webhook_secret)
+	return c
+}
+
+// Retention is how far back the configured access node executes scripts.
+func (c FlowNodeConfig) Retention() ScriptRetention {
+	return ScriptRetention{Root: c.Flow_access_root_height, Blocks: c.Flow_script_retention}
+}
+
+// ArchiveNode is FLOW_ARCHIVE_NODE, or the public archive node on mainnet
+// when it is unset. Setting it empty turns the archive node off.
+func (c FlowNodeConfig) ArchiveNode(flowEnv string) string {
+	if c.Flow_archive_node != nil {
+		return *c.Flow_archive_node
+	}
+	if flowEnv == "mainnet" {
+		return mainnetArchiveNode
+	}
+	return ""
+}
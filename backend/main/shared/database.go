@@ -0,0 +1,48 @@
+package shared
+
+import (
+	"context"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// Querier is implemented by both the connection pool and a transaction, so
+// models run the same statements whether or not they are part of one.
+type Querier interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+type Database struct {
+	Conn    Querier
+	Pool    *pgxpool.Pool
+	Context context.Context
+	Name    string
+	Env     *string
+}
+
+// WithTx runs fn against a copy of the database bound to a transaction,
+// committing when fn succeeds and rolling back otherwise. Called on a
+// database that is already in a transaction, it uses a savepoint.
+//
+// fn must only use the database it is given: the pool may have a single
+// connection, held by the transaction.
+func (db *Database) WithTx(fn func(tx *Database) error) error {
+	tx, err := db.Conn.Begin(db.Context)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(db.Context)
+
+	txDB := *db
+	txDB.Conn = tx
+	if err := fn(&txDB); err != nil {
+		return err
+	}
+
+	return tx.Commit(db.Context)
+}
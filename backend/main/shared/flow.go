@@ -18,12 +18,15 @@ import (
 	"github.com/onflow/flow-go-sdk"
 	"github.com/onflow/flow-go-sdk/client"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type FlowAdapter struct {
 	Config           FlowConfig
 	ArchiveClient    *client.Client
 	Client           *client.Client
+	Retention        ScriptRetention
 	Context          context.Context
 	CustomScriptsMap map[string]CustomScript
 	URL              string
@@ -47,6 +50,7 @@ type Contract struct {
 	Threshold      *float64 `json:"threshold,omitempty,string"`
 	MaxWeight      *float64 `json:"maxWeight,omitempty,string"`
 	Float_event_id *uint64  `json:"floatEventId,omitempty,string"`
+	List_id        *int     `json:"listId,omitempty,string"`
 	Script         *string  `json:"script,omitempty"`
 }
 
@@ -60,7 +64,7 @@ var (
 	placeholderTopshotAddr          = regexp.MustCompile(`"[^"\s]*TOPSHOT_ADDRESS"`)
 )
 
-func NewFlowClient(flowEnv string, customScriptsMap map[string]CustomScript) *FlowAdapter {
+func NewFlowClient(flowEnv string, nodes FlowNodeConfig, customScriptsMap map[string]CustomScript) *FlowAdapter {
 	adapter := FlowAdapter{}
 	adapter.Context = context.Background()
 	adapter.Env = flowEnv
@@ -95,12 +99,16 @@ func NewFlowClient(flowEnv string, customScriptsMap map[string]CustomScript) *Fl
 	}
 	adapter.Client = FlowClient
 
-	FlowClientArchive, err := client.New("archive.mainnet.nodes.onflow.org:9000", grpc.WithInsecure())
-	if err != nil {
-		log.Panic().Msgf("Failed to connect to %s.", adapter.URL)
+	// heights the access node no longer executes scripts at are read from
+	// the archive node, when there is one
+	adapter.Retention = nodes.Retention()
+	if archiveURL := nodes.ArchiveNode(adapter.Env); archiveURL != "" {
+		FlowClientArchive, err := client.New(archiveURL, grpc.WithInsecure())
+		if err != nil {
+			log.Panic().Msgf("Failed to connect to %s.", archiveURL)
+		}
+		adapter.ArchiveClient = FlowClientArchive
 	}
-
-	adapter.ArchiveClient = FlowClientArchive
 	return &adapter
 }
 
@@ -115,9 +123,43 @@ func (fa *FlowAdapter) GetAddressBalanceAtBlockHeight(addr string, blockHeight u
 	return nil
 }
 
+// GetAccountAtBlockHeight returns the account, keys included, as it was at
+// the block. Access nodes only serve heights since their spork, so older
+// heights are read from the archive node.
 func (fa *FlowAdapter) GetAccountAtBlockHeight(addr string, blockheight uint64) (*flow.Account, error) {
 	hexAddr := flow.HexToAddress(addr)
-	return fa.Client.GetAccountAtBlockHeight(fa.Context, hexAddr, blockheight)
+	account, err := fa.Client.GetAccountAtBlockHeight(fa.Context, hexAddr, blockheight)
+	if err != nil && fa.HasArchive() {
+		return fa.ArchiveClient.GetAccountAtBlockHeight(fa.Context, hexAddr, blockheight)
+	}
+	return account, err
+}
+
+// AccountExistedAt tells whether the account was created by the block.
+// Without an archive node, blocks before the spork are checked at its root.
+func (fa *FlowAdapter) AccountExistedAt(addr string, height uint64) (bool, error) {
+	if height < fa.Retention.Root && !fa.HasArchive() {
+		height = fa.Retention.Root
+	}
+	_, err := fa.GetAccountAtBlockHeight(addr, height)
+	if status.Code(err) == codes.NotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// GetBlockHeaderByHeight returns the sealed block header at the height,
+// from the archive node for heights before the spork.
+func (fa *FlowAdapter) GetBlockHeaderByHeight(height uint64) (*flow.BlockHeader, error) {
+	header, err := fa.Client.GetBlockHeaderByHeight(fa.Context, height)
+	if err != nil && fa.HasArchive() {
+		return fa.ArchiveClient.GetBlockHeaderByHeight(fa.Context, height)
+	}
+	return header, err
+}
+
+func (fa *FlowAdapter) GetAccount(addr string) (*flow.Account, error) {
+	return fa.Client.GetAccount(fa.Context, flow.HexToAddress(addr))
 }
 
 func (fa *FlowAdapter) GetCurrentBlockHeight() (int, error) {
@@ -187,14 +229,16 @@ func (fa *FlowAdapter) ValidateSignature(address, message string, sigs *[]Compos
 	}
 
 	if value != cadence.NewBool(true) {
-		return errors.New("invalid signature")
+		return ErrInvalidSignature
 	}
 
 	return nil
 
 }
 
-func (fa *FlowAdapter) EnforceTokenThreshold(scriptPath, creatorAddr string, c *Contract) (bool, error) {
+// GetThresholdBalance reads the balance an address holds of a contract at a
+// block height: its token balance, or for NFT scripts the number of NFTs.
+func (fa *FlowAdapter) GetThresholdBalance(scriptPath, creatorAddr string, blockHeight uint64, c *Contract) (float64, error) {
 
 	var balance float64
 	flowAddress := flow.HexToAddress(creatorAddr)
@@ -204,7 +248,7 @@ func (fa *FlowAdapter) EnforceTokenThreshold(scriptPath, creatorAddr string, c *
 	script, err := ioutil.ReadFile(scriptPath)
 	if err != nil {
 		log.Error().Err(err).Msgf("Error reading cadence script file.")
-		return false, err
+		return 0, err
 	}
 
 	var cadenceValue cadence.Value
@@ -214,15 +258,16 @@ func (fa *FlowAdapter) EnforceTokenThreshold(scriptPath, creatorAddr string, c *
 		script = fa.ReplaceContractPlaceholders(string(script[:]), c, isFungible)
 
 		//call the non-fungible token script to verify balance
-		cadenceValue, err = fa.Client.ExecuteScriptAtLatestBlock(
+		cadenceValue, err = fa.scriptClient(blockHeight).ExecuteScriptAtBlockHeight(
 			fa.Context,
+			blockHeight,
 			script,
 			[]cadence.Value{
 				cadenceAddress,
 			})
 		if err != nil {
 			log.Error().Err(err).Msg("Error executing Non-Fungible-Token script.")
-			return false, err
+			return 0, err
 		}
 		value := CadenceValueToInterface(cadenceValue)
 
@@ -234,8 +279,9 @@ func (fa *FlowAdapter) EnforceTokenThreshold(scriptPath, creatorAddr string, c *
 		script = fa.ReplaceContractPlaceholders(string(script[:]), c, isFungible)
 
 		//call the fungible-token script to verify balance
-		cadenceValue, err = fa.Client.ExecuteScriptAtLatestBlock(
+		cadenceValue, err = fa.scriptClient(blockHeight).ExecuteScriptAtBlockHeight(
 			fa.Context,
+			blockHeight,
 			script,
 			[]cadence.Value{
 				cadencePath,
@@ -243,26 +289,67 @@ func (fa *FlowAdapter) EnforceTokenThreshold(scriptPath, creatorAddr string, c *
 			})
 		if err != nil {
 			log.Error().Err(err).Msg("Error executing Funigble-Token Script.")
-			return false, err
+			return 0, err
 		}
 
 		value := CadenceValueToInterface(cadenceValue)
 		balance, err = strconv.ParseFloat(value.(string), 64)
 		if err != nil {
 			log.Error().Err(err).Msg("Error converting cadence value to float.")
-			return false, err
+			return 0, err
 		}
 	}
 
-	//check if balance is greater than threshold
-	if balance < *c.Threshold {
-		return false, nil
+	return balance, nil
+}
+
+// ValidateFungibleToken checks the contract is deployed to the address and
+// is a fungible token whose vault is stored and published at the paths.
+func (fa *FlowAdapter) ValidateFungibleToken(c *Contract, vaultPath, receiverPath string) error {
+	account, err := fa.GetAccount(*c.Addr)
+	if err != nil {
+		return fmt.Errorf("account %s could not be read: %w", *c.Addr, err)
+	}
+	if _, ok := account.Contracts[*c.Name]; !ok {
+		return fmt.Errorf("contract %s is not deployed to %s", *c.Name, *c.Addr)
+	}
+
+	script, err := ioutil.ReadFile("./main/cadence/scripts/validate_fungible_token.cdc")
+	if err != nil {
+		log.Error().Err(err).Msgf("Error reading cadence script file.")
+		return err
 	}
+	script = fa.ReplaceContractPlaceholders(string(script[:]), c, true)
 
-	return true, nil
+	_, err = fa.Client.ExecuteScriptAtLatestBlock(
+		fa.Context,
+		script,
+		[]cadence.Value{
+			cadence.NewAddress(flow.HexToAddress(*c.Addr)),
+			cadence.Path{Domain: "storage", Identifier: vaultPath},
+			cadence.Path{Domain: "public", Identifier: receiverPath},
+			cadence.Path{Domain: "public", Identifier: *c.Public_path},
+		})
+	if err != nil {
+		log.Error().Err(err).Msg("Error executing fungible token validation script.")
+		return fmt.Errorf("contract %s is not a fungible token stored and published at these paths", *c.Name)
+	}
+	return nil
+}
+
+// IsKnownToken tells whether the contract is one of the tokens configured
+// for the network in flow.json.
+func (fa *FlowAdapter) IsKnownToken(name, addr string) bool {
+	contract, ok := fa.Config.Contracts[name]
+	if !ok {
+		return false
+	}
+	known := contract.Aliases[os.Getenv("FLOW_ENV")]
+	return known != "" && strings.EqualFold(strings.TrimPrefix(known, "0x"), strings.TrimPrefix(addr, "0x"))
 }
 
-// bluesign: this is called in archival node now
+// GetFTBalance runs on the archive node once the height is beyond the
+// retention of the access node.
 func (fa *FlowAdapter) GetFTBalance(address string, blockHeight uint64, contractName string, contractAddress string, publicPath string) (float64, error) {
 	flowAddress := flow.HexToAddress(address)
 	cadenceAddress := cadence.NewAddress(flowAddress)
@@ -282,7 +369,7 @@ func (fa *FlowAdapter) GetFTBalance(address string, blockHeight uint64, contract
 	script = fa.ReplaceContractPlaceholders(string(script[:]), &dummyContract, true)
 	cadencePath := cadence.Path{Domain: "public", Identifier: *dummyContract.Public_path}
 
-	cadenceValue, err := fa.ArchiveClient.ExecuteScriptAtBlockHeight(
+	cadenceValue, err := fa.scriptClient(blockHeight).ExecuteScriptAtBlockHeight(
 		fa.Context,
 		blockHeight,
 		script,
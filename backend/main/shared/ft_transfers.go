@@ -0,0 +1,126 @@
+package shared
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+)
+
+var txIdPattern = regexp.MustCompile(`^(0x)?[0-9a-fA-F]{64}$`)
+
+// ufix64 amounts are fixed point numbers with 8 decimals
+const ufix64Scale = 1e8
+
+// FTTransfers are the amounts of a fungible token withdrawn from and
+// deposited to each address by one transaction, from the TokensWithdrawn
+// and TokensDeposited events of the token contract.
+type FTTransfers struct {
+	Withdrawn map[string]float64
+	Deposited map[string]float64
+}
+
+// half the smallest ufix64 unit, for amounts that went through floats
+const ufix64Epsilon = 0.5 / ufix64Scale
+
+// Sent reports whether at least amount was withdrawn from the address.
+func (t FTTransfers) Sent(from string, amount float64) bool {
+	return t.Withdrawn[strings.ToLower(from)]+ufix64Epsilon >= amount
+}
+
+// Received reports whether at least amount was deposited to the address.
+func (t FTTransfers) Received(to string, amount float64) bool {
+	return t.Deposited[strings.ToLower(to)]+ufix64Epsilon >= amount
+}
+
+// Moved reports whether at least amount was withdrawn from one address and
+// deposited to the other.
+func (t FTTransfers) Moved(from, to string, amount float64) bool {
+	return t.Sent(from, amount) && t.Received(to, amount)
+}
+
+// NormalizeTxId returns a transaction ID the way it is stored, in lower
+// case without 0x, so that each transaction has a single form.
+func NormalizeTxId(txId string) (string, error) {
+	if !txIdPattern.MatchString(txId) {
+		return "", fmt.Errorf("%q is not a transaction ID.", txId)
+	}
+	return strings.ToLower(strings.TrimPrefix(txId, "0x")), nil
+}
+
+// GetFTTransfers reads the transfers of a token made by a transaction,
+// which must be sealed and successful.
+func (fa *FlowAdapter) GetFTTransfers(txId, contractName, contractAddr string) (FTTransfers, error) {
+	txId, err := NormalizeTxId(txId)
+	if err != nil {
+		return FTTransfers{}, err
+	}
+	result, err := fa.Client.GetTransactionResult(fa.Context, flow.HexToID(txId))
+	if err != nil {
+		return FTTransfers{}, fmt.Errorf("Transaction %s was not found.", txId)
+	}
+	if result.Status != flow.TransactionStatusSealed {
+		return FTTransfers{}, fmt.Errorf("Transaction %s is not sealed yet, retry once it is.", txId)
+	}
+	if result.Error != nil {
+		return FTTransfers{}, fmt.Errorf("Transaction %s failed: %v", txId, result.Error)
+	}
+
+	prefix := fmt.Sprintf("A.%s.%s.", strings.TrimPrefix(contractAddr, "0x"), contractName)
+	transfers := FTTransfers{Withdrawn: map[string]float64{}, Deposited: map[string]float64{}}
+	for _, event := range result.Events {
+		var totals map[string]float64
+		var field string
+		switch event.Type {
+		case prefix + "TokensWithdrawn":
+			totals, field = transfers.Withdrawn, "from"
+		case prefix + "TokensDeposited":
+			totals, field = transfers.Deposited, "to"
+		default:
+			continue
+		}
+		addr, amount, err := decodeFTEvent(event.Value, field)
+		if err != nil {
+			return FTTransfers{}, fmt.Errorf("event %d of transaction %s: %w", event.EventIndex, txId, err)
+		}
+		// tokens moved between vaults without an owner have no address
+		if addr != "" {
+			totals[addr] += amount
+		}
+	}
+	return transfers, nil
+}
+
+func decodeFTEvent(event cadence.Event, addrField string) (string, float64, error) {
+	if len(event.Fields) != len(event.EventType.Fields) {
+		return "", 0, fmt.Errorf("malformed %s event", event.EventType.QualifiedIdentifier)
+	}
+
+	addr, amount := "", 0.0
+	for i, field := range event.EventType.Fields {
+		value := event.Fields[i]
+		if optional, ok := value.(cadence.Optional); ok {
+			value = optional.Value
+		}
+		switch field.Identifier {
+		case "amount":
+			v, ok := value.(cadence.UFix64)
+			if !ok {
+				return "", 0, fmt.Errorf("field amount has an unexpected type")
+			}
+			amount = float64(v) / ufix64Scale
+		case addrField:
+			if value == nil {
+				continue
+			}
+			v, ok := value.(cadence.Address)
+			if !ok {
+				return "", 0, fmt.Errorf("field %s has an unexpected type", addrField)
+			}
+			addr = "0x" + flow.Address(v).Hex()
+		}
+	}
+	return addr, amount, nil
+}
@@ -0,0 +1,160 @@
+package shared
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	IdentityBrightId        = "brightid"
+	IdentityGitcoinPassport = "gitcoin-passport"
+	// attestations are made by platform admins, for proof-of-humanity style
+	// checks that happen outside of CAST
+	IdentityAttestation = "attestation"
+)
+
+var IDENTITY_PROVIDERS = []string{IdentityBrightId, IdentityGitcoinPassport, IdentityAttestation}
+
+const (
+	defaultBrightIdNodeURL = "https://app.brightid.org/node/v6"
+	gitcoinPassportURL     = "https://api.scorer.gitcoin.co"
+)
+
+// IdentityCheck is what a provider says of an account it knows.
+type IdentityCheck struct {
+	Verified bool
+	Score    *float64
+}
+
+// IdentityVerifier asks a provider whether the account it knows as
+// externalId belongs to a unique human.
+type IdentityVerifier interface {
+	Verify(externalId string) (IdentityCheck, error)
+}
+
+// NewIdentityVerifiers are the verifiers of the configured providers.
+// Attestations need none, since admins make them.
+func NewIdentityVerifiers(c IdentityConfig) map[string]IdentityVerifier {
+	client := &http.Client{Timeout: 10 * time.Second}
+	verifiers := map[string]IdentityVerifier{}
+	if c.Brightid_context != "" {
+		nodeURL := c.Brightid_node_url
+		if nodeURL == "" {
+			nodeURL = defaultBrightIdNodeURL
+		}
+		verifiers[IdentityBrightId] = &BrightIdVerifier{
+			NodeURL:    strings.TrimRight(nodeURL, "/"),
+			Context:    c.Brightid_context,
+			HTTPClient: client,
+		}
+	}
+	if c.Gitcoin_passport_api_key != "" && c.Gitcoin_passport_scorer_id != "" {
+		verifiers[IdentityGitcoinPassport] = &PassportVerifier{
+			BaseURL:    gitcoinPassportURL,
+			apiKey:     c.Gitcoin_passport_api_key,
+			ScorerId:   c.Gitcoin_passport_scorer_id,
+			Threshold:  c.Gitcoin_passport_threshold,
+			HTTPClient: client,
+		}
+	}
+	return verifiers
+}
+
+// BrightIdVerifier checks the address was linked to a verified BrightID in
+// the app's context, with the address as the context id.
+type BrightIdVerifier struct {
+	NodeURL    string
+	Context    string
+	HTTPClient *http.Client
+}
+
+type brightIdResponse struct {
+	Data []struct {
+		Unique bool `json:"unique"`
+	} `json:"data"`
+	ErrorMessage string `json:"errorMessage"`
+}
+
+func (v *BrightIdVerifier) Verify(externalId string) (IdentityCheck, error) {
+	u := fmt.Sprintf("%s/verifications/%s/%s", v.NodeURL, url.PathEscape(v.Context), url.PathEscape(externalId))
+	res, err := v.HTTPClient.Get(u)
+	if err != nil {
+		return IdentityCheck{}, err
+	}
+	defer res.Body.Close()
+
+	// the node doesn't know addresses that aren't linked or verified
+	if res.StatusCode == http.StatusNotFound {
+		return IdentityCheck{}, nil
+	}
+	var body brightIdResponse
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return IdentityCheck{}, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return IdentityCheck{}, fmt.Errorf("BrightID node error, status code %d: %s", res.StatusCode, body.ErrorMessage)
+	}
+
+	for _, d := range body.Data {
+		if d.Unique {
+			return IdentityCheck{Verified: true}, nil
+		}
+	}
+	return IdentityCheck{}, nil
+}
+
+// PassportVerifier checks the Gitcoin Passport of an Ethereum address
+// scores at least the threshold.
+type PassportVerifier struct {
+	BaseURL    string
+	apiKey     string
+	ScorerId   string
+	Threshold  float64
+	HTTPClient *http.Client
+}
+
+type passportResponse struct {
+	Status string `json:"status"`
+	Score  string `json:"score"`
+	Error  string `json:"error"`
+}
+
+func (v *PassportVerifier) Verify(externalId string) (IdentityCheck, error) {
+	payload, _ := json.Marshal(map[string]string{"address": externalId, "scorer_id": v.ScorerId})
+	req, err := http.NewRequest("POST", v.BaseURL+"/registry/submit-passport", bytes.NewReader(payload))
+	if err != nil {
+		return IdentityCheck{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-KEY", v.apiKey)
+
+	res, err := v.HTTPClient.Do(req)
+	if err != nil {
+		return IdentityCheck{}, err
+	}
+	defer res.Body.Close()
+
+	var body passportResponse
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return IdentityCheck{}, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return IdentityCheck{}, fmt.Errorf("Gitcoin Passport error, status code %d: %s", res.StatusCode, body.Error)
+	}
+	if body.Status != "DONE" {
+		return IdentityCheck{}, errors.New("The passport is still being scored, try again shortly.")
+	}
+
+	score, err := strconv.ParseFloat(body.Score, 64)
+	if err != nil {
+		return IdentityCheck{}, err
+	}
+	return IdentityCheck{Verified: score >= v.Threshold, Score: &score}, nil
+}
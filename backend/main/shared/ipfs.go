@@ -12,11 +12,13 @@ import (
 )
 
 const (
-	baseUrl = "https://api.pinata.cloud"
+	baseUrl    = "https://api.pinata.cloud"
+	gatewayUrl = "https://gateway.pinata.cloud/ipfs/"
 )
 
 type IpfsClient struct {
 	BaseURL    string
+	GatewayURL string
 	apiKey     string
 	apiSecret  string
 	HTTPClient *http.Client
@@ -41,9 +43,10 @@ type Pin struct {
 
 func NewIpfsClient(apiKey string, apiSecret string) *IpfsClient {
 	return &IpfsClient{
-		BaseURL:   baseUrl,
-		apiKey:    apiKey,
-		apiSecret: apiSecret,
+		BaseURL:    baseUrl,
+		GatewayURL: gatewayUrl,
+		apiKey:     apiKey,
+		apiSecret:  apiSecret,
 		HTTPClient: &http.Client{
 			Timeout: time.Second * 10,
 		},
@@ -100,7 +103,7 @@ func (c *IpfsClient) PinJson(data interface{}) (*Pin, error) {
 	return &res, nil
 }
 
-func (c *IpfsClient) PinFile(file multipart.File, fileName string) (*Pin, error) {
+func (c *IpfsClient) PinFile(file io.Reader, fileName string) (*Pin, error) {
 	url := c.BaseURL + "/pinning/pinFileToIPFS"
 
 	body := &bytes.Buffer{}
@@ -120,3 +123,24 @@ func (c *IpfsClient) PinFile(file multipart.File, fileName string) (*Pin, error)
 
 	return &res, nil
 }
+
+// TestAuthentication checks the pinning service is up and accepts the keys.
+func (c *IpfsClient) TestAuthentication() error {
+	req, _ := http.NewRequest("GET", c.BaseURL+"/data/testAuthentication", nil)
+	var res map[string]interface{}
+	return c.sendRequest(req, &res)
+}
+
+// FetchContent reads pinned content back through the IPFS gateway.
+func (c *IpfsClient) FetchContent(cid string) ([]byte, error) {
+	res, err := c.HTTPClient.Get(c.GatewayURL + cid)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("gateway returned status code %d", res.StatusCode)
+	}
+	return io.ReadAll(res.Body)
+}
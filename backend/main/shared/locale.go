@@ -0,0 +1,47 @@
+package shared
+
+import (
+	"golang.org/x/text/language"
+)
+
+// NormalizeLocale parses a BCP 47 language tag into its canonical form,
+// e.g. "pt_br" becomes "pt-BR".
+func NormalizeLocale(locale string) (string, error) {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return "", err
+	}
+	return tag.String(), nil
+}
+
+// MatchLocale picks the available locale that best fits an Accept-Language
+// header, if any fits at all.
+func MatchLocale(acceptLanguage string, available []string) (string, bool) {
+	if acceptLanguage == "" || len(available) == 0 {
+		return "", false
+	}
+	accepted, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(accepted) == 0 {
+		return "", false
+	}
+
+	tags := make([]language.Tag, 0, len(available))
+	locales := make([]string, 0, len(available))
+	for _, locale := range available {
+		tag, err := language.Parse(locale)
+		if err != nil {
+			continue
+		}
+		tags = append(tags, tag)
+		locales = append(locales, locale)
+	}
+	if len(tags) == 0 {
+		return "", false
+	}
+
+	_, index, confidence := language.NewMatcher(tags).Match(accepted...)
+	if confidence == language.No {
+		return "", false
+	}
+	return locales[index], true
+}
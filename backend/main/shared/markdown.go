@@ -0,0 +1,126 @@
+package shared
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// RenderMarkdown turns the commonly used subset of markdown into HTML:
+// headings, paragraphs, block quotes, lists, rules, fenced and inline code,
+// links, images and emphasis. HTML in the source is passed through, so the
+// result must be sanitized before it is served.
+func RenderMarkdown(source string) string {
+	lines := strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n")
+	var out strings.Builder
+	renderBlocks(&out, lines)
+	return out.String()
+}
+
+var (
+	headingPattern     = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	rulePattern        = regexp.MustCompile(`^\s{0,3}([-*_])(\s*([-*_])){2,}\s*$`)
+	unorderedPattern   = regexp.MustCompile(`^\s{0,3}[-*+]\s+(.*)$`)
+	orderedPattern     = regexp.MustCompile(`^\s{0,3}\d+[.)]\s+(.*)$`)
+	quotePattern       = regexp.MustCompile(`^\s{0,3}>\s?(.*)$`)
+	imagePattern       = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)\)`)
+	linkPattern        = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	strongPattern      = regexp.MustCompile(`(\*\*|__)(\S(?:.*?\S)?)(\*\*|__)`)
+	emphasisPattern    = regexp.MustCompile(`(^|[^\w*])[*_](\S(?:.*?\S)?)[*_]($|[^\w*])`)
+	strikePattern      = regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`)
+	inlineCodePattern  = regexp.MustCompile("`([^`]+)`")
+	codeFenceDelimiter = "```"
+)
+
+func renderBlocks(out *strings.Builder, lines []string) {
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			out.WriteString("<p>" + renderInline(strings.Join(paragraph, "\n")) + "</p>\n")
+			paragraph = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			flush()
+
+		case strings.HasPrefix(trimmed, codeFenceDelimiter):
+			flush()
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), codeFenceDelimiter); i++ {
+				code = append(code, lines[i])
+			}
+			out.WriteString("<pre><code>" + html.EscapeString(strings.Join(code, "\n")) + "</code></pre>\n")
+
+		case headingPattern.MatchString(trimmed):
+			flush()
+			m := headingPattern.FindStringSubmatch(trimmed)
+			level := string(rune('0' + len(m[1])))
+			out.WriteString("<h" + level + ">" + renderInline(m[2]) + "</h" + level + ">\n")
+
+		case rulePattern.MatchString(line):
+			flush()
+			out.WriteString("<hr>\n")
+
+		case quotePattern.MatchString(line):
+			flush()
+			var quoted []string
+			for ; i < len(lines) && quotePattern.MatchString(lines[i]); i++ {
+				quoted = append(quoted, quotePattern.FindStringSubmatch(lines[i])[1])
+			}
+			i--
+			out.WriteString("<blockquote>\n")
+			renderBlocks(out, quoted)
+			out.WriteString("</blockquote>\n")
+
+		case unorderedPattern.MatchString(line), orderedPattern.MatchString(line):
+			flush()
+			item, tag := unorderedPattern, "ul"
+			if !unorderedPattern.MatchString(line) {
+				item, tag = orderedPattern, "ol"
+			}
+			out.WriteString("<" + tag + ">\n")
+			for ; i < len(lines) && item.MatchString(lines[i]); i++ {
+				out.WriteString("<li>" + renderInline(item.FindStringSubmatch(lines[i])[1]) + "</li>\n")
+			}
+			i--
+			out.WriteString("</" + tag + ">\n")
+
+		default:
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+	flush()
+}
+
+// renderInline formats a span of text, leaving code spans as written.
+func renderInline(text string) string {
+	var out strings.Builder
+	last := 0
+	for _, m := range inlineCodePattern.FindAllStringSubmatchIndex(text, -1) {
+		out.WriteString(renderEmphasis(text[last:m[0]]))
+		out.WriteString("<code>" + html.EscapeString(text[m[2]:m[3]]) + "</code>")
+		last = m[1]
+	}
+	out.WriteString(renderEmphasis(text[last:]))
+	return out.String()
+}
+
+func renderEmphasis(text string) string {
+	text = imagePattern.ReplaceAllStringFunc(text, func(s string) string {
+		m := imagePattern.FindStringSubmatch(s)
+		return `<img src="` + html.EscapeString(m[2]) + `" alt="` + html.EscapeString(m[1]) + `">`
+	})
+	text = linkPattern.ReplaceAllStringFunc(text, func(s string) string {
+		m := linkPattern.FindStringSubmatch(s)
+		return `<a href="` + html.EscapeString(m[2]) + `">` + m[1] + `</a>`
+	})
+	text = strongPattern.ReplaceAllString(text, "<strong>$2</strong>")
+	text = emphasisPattern.ReplaceAllString(text, "$1<em>$2</em>$3")
+	return strikePattern.ReplaceAllString(text, "<del>$1</del>")
+}
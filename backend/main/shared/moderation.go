@@ -0,0 +1,108 @@
+package shared
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+)
+
+// ModerationContent is user content scored before it is published. Kind
+// says what it is, e.g. "proposal".
+type ModerationContent struct {
+	Kind         string `json:"kind"`
+	Community_id int    `json:"communityId"`
+	Author       string `json:"author"`
+	Title        string `json:"title,omitempty"`
+	Body         string `json:"body"`
+}
+
+// ModerationScore is how likely content is spam or abuse, from 0 to 1, and
+// the labels of what was found.
+type ModerationScore struct {
+	Score  float64  `json:"score"`
+	Labels []string `json:"labels,omitempty"`
+}
+
+// Moderator scores content. Communities' keyword rules are one, an external
+// classifier another.
+type Moderator interface {
+	Score(content ModerationContent) (ModerationScore, error)
+}
+
+// NewClassifierFromEnv returns the classifier at MODERATION_CLASSIFIER_URL,
+// or nil when there is none.
+func NewClassifierFromEnv() (Moderator, error) {
+	url := os.Getenv("MODERATION_CLASSIFIER_URL")
+	if url == "" {
+		return nil, nil
+	}
+	timeout := time.Second * 5
+	if v := os.Getenv("MODERATION_CLASSIFIER_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid MODERATION_CLASSIFIER_TIMEOUT %q", v)
+		}
+		timeout = d
+	}
+	c := NewHttpClassifier(url, os.Getenv("MODERATION_CLASSIFIER_KEY"))
+	c.HTTPClient.Timeout = timeout
+	return c, nil
+}
+
+// HttpClassifier posts content as JSON to an external classifier, which
+// replies with a JSON ModerationScore.
+type HttpClassifier struct {
+	URL        string
+	apiKey     string
+	HTTPClient *http.Client
+}
+
+func NewHttpClassifier(url, apiKey string) *HttpClassifier {
+	return &HttpClassifier{
+		URL:    url,
+		apiKey: apiKey,
+		HTTPClient: &http.Client{
+			Timeout: time.Second * 5,
+		},
+	}
+}
+
+func (c *HttpClassifier) Score(content ModerationContent) (ModerationScore, error) {
+	body, err := json.Marshal(content)
+	if err != nil {
+		return ModerationScore{}, err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return ModerationScore{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return ModerationScore{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		reply, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+		return ModerationScore{}, fmt.Errorf("classifier returned status code %d: %s", res.StatusCode, reply)
+	}
+
+	var score ModerationScore
+	if err := json.NewDecoder(res.Body).Decode(&score); err != nil {
+		return ModerationScore{}, err
+	}
+	if score.Score < 0 || score.Score > 1 {
+		return ModerationScore{}, fmt.Errorf("classifier returned score %v outside 0 to 1", score.Score)
+	}
+	return score, nil
+}
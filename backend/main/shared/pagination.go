@@ -0,0 +1,46 @@
+package shared
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// SetLinks points NextUrl and PrevUrl at the pages around this one, as u
+// with its start and count replaced. Other query parameters are kept so
+// filters carry over.
+func (p *PaginatedResponse) SetLinks(u url.URL) {
+	p.NextUrl, p.PrevUrl = nil, nil
+	if p.Next >= 0 {
+		next := p.pageURL(u, p.Next)
+		p.NextUrl = &next
+	}
+	if p.Prev >= 0 {
+		prev := p.pageURL(u, p.Prev)
+		p.PrevUrl = &prev
+	}
+}
+
+// LinkHeader lists the page links as an RFC 5988 Link header, empty when
+// the response has a single page.
+func (p *PaginatedResponse) LinkHeader() string {
+	var links []string
+	if p.NextUrl != nil {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, *p.NextUrl))
+	}
+	if p.PrevUrl != nil {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, *p.PrevUrl))
+	}
+	return strings.Join(links, ", ")
+}
+
+func (p *PaginatedResponse) pageURL(u url.URL, start int) string {
+	query := u.Query()
+	query.Set("start", strconv.Itoa(start))
+	if p.pageSize > 0 {
+		query.Set("count", strconv.Itoa(p.pageSize))
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
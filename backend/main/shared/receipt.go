@@ -0,0 +1,98 @@
+package shared
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const receiptAlgorithm = "ed25519"
+
+type ReceiptSigner struct {
+	privateKey ed25519.PrivateKey
+	publicKey  ed25519.PublicKey
+}
+
+// SignedReceipt carries the exact bytes that were signed so that third
+// parties can verify the signature offline without re-serializing the payload.
+type SignedReceipt struct {
+	Payload        interface{} `json:"payload"`
+	EncodedPayload string      `json:"encodedPayload"`
+	Signature      string      `json:"signature"`
+	PublicKey      string      `json:"publicKey"`
+	Algorithm      string      `json:"algorithm"`
+}
+
+// NewReceiptSigner builds a signer from a hex encoded 32 byte ed25519 seed.
+// If no seed is provided an ephemeral key is generated, which only suits
+// development as its receipts stop verifying once the server restarts.
+func NewReceiptSigner(seedHex string) (*ReceiptSigner, error) {
+	if seedHex == "" {
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return &ReceiptSigner{privateKey: priv, publicKey: pub}, nil
+	}
+
+	seed, err := hex.DecodeString(seedHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid receipt signing key: %v", err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("receipt signing key must be %d bytes", ed25519.SeedSize)
+	}
+
+	priv := ed25519.NewKeyFromSeed(seed)
+	return &ReceiptSigner{privateKey: priv, publicKey: priv.Public().(ed25519.PublicKey)}, nil
+}
+
+func (rs *ReceiptSigner) PublicKey() ed25519.PublicKey {
+	return rs.publicKey
+}
+
+func (rs *ReceiptSigner) PublicKeyHex() string {
+	return hex.EncodeToString(rs.publicKey)
+}
+
+func (rs *ReceiptSigner) Sign(payload interface{}) (*SignedReceipt, error) {
+	message, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	signature := ed25519.Sign(rs.privateKey, message)
+
+	return &SignedReceipt{
+		Payload:        payload,
+		EncodedPayload: base64.StdEncoding.EncodeToString(message),
+		Signature:      hex.EncodeToString(signature),
+		PublicKey:      rs.PublicKeyHex(),
+		Algorithm:      receiptAlgorithm,
+	}, nil
+}
+
+// VerifyReceipt checks a receipt was signed with publicKey, the key the
+// server publishes. The key a receipt names is whichever its issuer chose,
+// so it proves nothing and is not used.
+func VerifyReceipt(r *SignedReceipt, publicKey ed25519.PublicKey) error {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return errors.New("invalid receipt public key")
+	}
+	message, err := base64.StdEncoding.DecodeString(r.EncodedPayload)
+	if err != nil {
+		return err
+	}
+	sig, err := hex.DecodeString(r.Signature)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(publicKey, message, sig) {
+		return errors.New("invalid receipt signature")
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package shared
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+)
+
+// ResultsChart summarizes the results of a proposal for sharing as an
+// image: a bar per choice, the turnout and how long voting has left.
+type ResultsChart struct {
+	Title  string
+	Bars   []ChartBar
+	Votes  int
+	Status string
+}
+
+type ChartBar struct {
+	Label   string
+	Percent float64
+}
+
+// sized for Open Graph and Twitter large image cards
+const (
+	chartWidth    = 1200
+	chartHeight   = 630
+	chartMargin   = 60
+	chartMaxBars  = 5
+	chartBarColor = "#5F4BFF"
+)
+
+var (
+	chartInk       = color.RGBA{0x11, 0x11, 0x11, 0xFF}
+	chartMutedInk  = color.RGBA{0x55, 0x55, 0x55, 0xFF}
+	chartTrack     = color.RGBA{0xEE, 0xEE, 0xEE, 0xFF}
+	chartBarFill   = color.RGBA{0x5F, 0x4B, 0xFF, 0xFF}
+	chartTextScale = 3
+)
+
+// Size is the width and height of the chart in pixels.
+func (c ResultsChart) Size() (int, int) {
+	return chartWidth, chartHeight
+}
+
+func (c ResultsChart) SVG() []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`,
+		chartWidth, chartHeight, chartWidth, chartHeight)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="#FFFFFF"/>`)
+	fmt.Fprintf(&b, `<g font-family="Helvetica, Arial, sans-serif" fill="#111111">`)
+	fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="44" font-weight="bold">%s</text>`,
+		chartMargin, chartMargin+40, svgText(c.Title, 48))
+
+	bars := c.Bars
+	if len(bars) > chartMaxBars {
+		bars = bars[:chartMaxBars]
+	}
+	track := chartWidth - 2*chartMargin
+	for i, bar := range bars {
+		y := 170 + i*75
+		fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="26">%s</text>`,
+			chartMargin, y, svgText(bar.Label, 60))
+		fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="26" text-anchor="end">%.1f%%</text>`,
+			chartWidth-chartMargin, y, bar.Percent)
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="20" rx="10" fill="#EEEEEE"/>`,
+			chartMargin, y+14, track)
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="20" rx="10" fill="%s"/>`,
+			chartMargin, y+14, int(float64(track)*bar.Percent/100), chartBarColor)
+	}
+
+	fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="28" fill="#555555">%d votes · %s</text>`,
+		chartMargin, chartHeight-chartMargin, c.Votes, svgText(c.Status, 60))
+	b.WriteString(`</g></svg>`)
+	return b.Bytes()
+}
+
+// PNG draws the chart with the same layout as SVG, for sites that only
+// show raster images.
+func (c ResultsChart) PNG() ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, chartWidth, chartHeight))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	drawText(img, chartMargin, chartMargin, 5, truncate(c.Title, 36), chartInk)
+
+	bars := c.Bars
+	if len(bars) > chartMaxBars {
+		bars = bars[:chartMaxBars]
+	}
+	track := chartWidth - 2*chartMargin
+	lineHeight := glyphHeight * chartTextScale
+	for i, bar := range bars {
+		y := 170 + i*75
+		drawText(img, chartMargin, y-lineHeight, chartTextScale, truncate(bar.Label, 50), chartInk)
+		percent := fmt.Sprintf("%.1f%%", bar.Percent)
+		drawText(img, chartWidth-chartMargin-textWidth(percent, chartTextScale), y-lineHeight,
+			chartTextScale, percent, chartInk)
+
+		draw.Draw(img, image.Rect(chartMargin, y+14, chartMargin+track, y+34),
+			image.NewUniform(chartTrack), image.Point{}, draw.Src)
+		filled := int(float64(track) * bar.Percent / 100)
+		draw.Draw(img, image.Rect(chartMargin, y+14, chartMargin+filled, y+34),
+			image.NewUniform(chartBarFill), image.Point{}, draw.Src)
+	}
+
+	footer := fmt.Sprintf("%d votes · %s", c.Votes, truncate(c.Status, 40))
+	drawText(img, chartMargin, chartHeight-chartMargin-lineHeight, chartTextScale, footer, chartMutedInk)
+
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func truncate(text string, max int) string {
+	if runes := []rune(text); len(runes) > max {
+		return string(runes[:max-1]) + "…"
+	}
+	return text
+}
+
+func svgText(text string, max int) string {
+	return html.EscapeString(truncate(text, max))
+}
@@ -0,0 +1,70 @@
+package shared
+
+import (
+	"errors"
+
+	"github.com/onflow/flow-go-sdk/client"
+	"github.com/rs/zerolog/log"
+)
+
+const mainnetArchiveNode = "archive.mainnet.nodes.onflow.org:9000"
+
+var ErrBeyondRetention = errors.New("block is beyond the retention of the access node")
+
+// ScriptRetention is how far back the access node can execute scripts:
+// not before the root block of its spork, and only as many blocks back
+// as execution nodes keep state for.
+type ScriptRetention struct {
+	Root   uint64
+	Blocks uint64
+}
+
+// OldestExecutableHeight is the oldest block the access node can execute
+// scripts at while the chain is at head.
+func (r ScriptRetention) OldestExecutableHeight(head uint64) uint64 {
+	oldest := r.Root
+	if r.Blocks > 0 && head > r.Blocks && head-r.Blocks > oldest {
+		oldest = head - r.Blocks
+	}
+	return oldest
+}
+
+func (fa *FlowAdapter) HasArchive() bool {
+	return fa.ArchiveClient != nil
+}
+
+// CheckSnapshotHeight tells whether scripts can still be executed at the
+// height, returning ErrBeyondRetention when neither the access node nor an
+// archive node can. archived is true when only the archive node can.
+func (fa *FlowAdapter) CheckSnapshotHeight(height, head uint64) (archived bool, err error) {
+	if height >= fa.Retention.OldestExecutableHeight(head) {
+		return false, nil
+	}
+	if !fa.HasArchive() {
+		return false, ErrBeyondRetention
+	}
+	return true, nil
+}
+
+// scriptClient is the client to execute scripts at the height with: the
+// access node while it keeps the state, and the archive node after.
+func (fa *FlowAdapter) scriptClient(height uint64) *client.Client {
+	if !fa.HasArchive() {
+		return fa.Client
+	}
+
+	var head uint64
+	if fa.Retention.Blocks > 0 {
+		header, err := fa.GetLatestSealedHeader()
+		if err != nil {
+			log.Warn().Err(err).Msg("Couldn't get the latest block, using the archive node.")
+			return fa.ArchiveClient
+		}
+		head = header.Height
+	}
+	if height < fa.Retention.OldestExecutableHeight(head) {
+		log.Debug().Msgf("Executing script at block %d on the archive node.", height)
+		return fa.ArchiveClient
+	}
+	return fa.Client
+}
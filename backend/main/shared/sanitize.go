@@ -0,0 +1,201 @@
+package shared
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// DefaultAllowedTags is the formatting kept in user content unless
+// SANITIZE_ALLOWED_TAGS says otherwise, each tag with the attributes it may
+// carry.
+var DefaultAllowedTags = map[string][]string{
+	"p": nil, "br": nil, "hr": nil, "span": nil, "div": nil,
+	"h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+	"strong": nil, "b": nil, "em": nil, "i": nil, "u": nil, "s": nil, "del": nil,
+	"blockquote": nil, "code": nil, "pre": nil,
+	"ul": nil, "ol": nil, "li": nil,
+	"table": nil, "thead": nil, "tbody": nil, "tr": nil, "th": nil, "td": nil,
+	"a":   {"href", "title"},
+	"img": {"src", "alt", "title"},
+}
+
+// dropped along with everything inside them
+var unsafeTags = map[string]bool{
+	"script": true, "style": true, "iframe": true, "frame": true, "frameset": true,
+	"object": true, "embed": true, "applet": true, "noscript": true, "noembed": true,
+	"noframes": true, "template": true, "svg": true, "math": true, "textarea": true,
+	"select": true, "title": true, "xmp": true, "plaintext": true, "head": true,
+}
+
+var voidTags = map[string]bool{"br": true, "hr": true, "img": true}
+
+var safeSchemes = map[string]bool{"http": true, "https": true, "mailto": true, "ipfs": true}
+
+// Sanitizer strips user content of any tags and attributes it does not
+// allow. Text is kept as written, so markdown passes through untouched.
+type Sanitizer struct {
+	allowed map[string]map[string]bool
+}
+
+func NewSanitizer(tags map[string][]string) *Sanitizer {
+	allowed := make(map[string]map[string]bool, len(tags))
+	for tag, attrs := range tags {
+		tag = strings.ToLower(tag)
+		allowed[tag] = map[string]bool{}
+		for _, attr := range attrs {
+			allowed[tag][strings.ToLower(attr)] = true
+		}
+	}
+	return &Sanitizer{allowed: allowed}
+}
+
+// NewSanitizerFromEnv allows the tags listed in SANITIZE_ALLOWED_TAGS,
+// e.g. "p em strong a:href,title", or DefaultAllowedTags when it is unset.
+func NewSanitizerFromEnv() (*Sanitizer, error) {
+	list := os.Getenv("SANITIZE_ALLOWED_TAGS")
+	if list == "" {
+		return NewSanitizer(DefaultAllowedTags), nil
+	}
+
+	tags := map[string][]string{}
+	for _, field := range strings.Fields(list) {
+		tag, attrs, _ := strings.Cut(field, ":")
+		if tag == "" || unsafeTags[strings.ToLower(tag)] {
+			return nil, fmt.Errorf("tag %q can not be allowed", tag)
+		}
+		tags[tag] = nil
+		if attrs != "" {
+			tags[tag] = strings.Split(attrs, ",")
+		}
+	}
+	return NewSanitizer(tags), nil
+}
+
+func (s *Sanitizer) Sanitize(input string) string {
+	var out strings.Builder
+	z := html.NewTokenizer(strings.NewReader(input))
+
+	// name and nesting of the unsafe tag being skipped
+	skip, depth := "", 0
+
+	for {
+		tt := z.Next()
+		// the end of the input, or what's left of it can't be tokenized
+		if tt == html.ErrorToken {
+			return out.String()
+		}
+
+		// Token unescapes text in place, so the raw text is copied first
+		raw := string(z.Raw())
+		token := z.Token()
+		if skip != "" {
+			switch {
+			case tt == html.StartTagToken && token.Data == skip:
+				depth++
+			case tt == html.EndTagToken && token.Data == skip:
+				depth--
+				if depth == 0 {
+					skip = ""
+				}
+			}
+			continue
+		}
+
+		switch tt {
+		case html.TextToken:
+			out.WriteString(raw)
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if unsafeTags[token.Data] {
+				if tt == html.StartTagToken {
+					skip, depth = token.Data, 1
+				}
+				continue
+			}
+			if attrs, ok := s.allowed[token.Data]; ok {
+				s.writeStartTag(&out, token, attrs)
+			}
+		case html.EndTagToken:
+			if _, ok := s.allowed[token.Data]; ok && !voidTags[token.Data] {
+				out.WriteString("</" + token.Data + ">")
+			}
+		}
+		// comments and doctypes are dropped
+	}
+}
+
+func (s *Sanitizer) writeStartTag(out *strings.Builder, token html.Token, allowed map[string]bool) {
+	out.WriteString("<" + token.Data)
+	for _, attr := range token.Attr {
+		if attr.Namespace != "" || !allowed[attr.Key] {
+			continue
+		}
+		if (attr.Key == "href" || attr.Key == "src") && !isSafeURL(attr.Val) {
+			continue
+		}
+		out.WriteString(" " + attr.Key + `="` + html.EscapeString(attr.Val) + `"`)
+	}
+	if token.Data == "a" {
+		out.WriteString(` rel="nofollow noopener noreferrer"`)
+	}
+	out.WriteString(">")
+}
+
+// isSafeURL allows relative links, and absolute ones of schemes that can't
+// run script.
+func isSafeURL(raw string) bool {
+	// browsers ignore whitespace and control characters in the scheme
+	cleaned := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, raw)
+
+	u, err := url.Parse(cleaned)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "" || safeSchemes[strings.ToLower(u.Scheme)]
+}
+
+// PlainText reduces user content to its text, with whitespace collapsed,
+// cut at a word boundary to at most max characters.
+func PlainText(input string, max int) string {
+	var words []string
+	z := html.NewTokenizer(strings.NewReader(input))
+	skip, depth := "", 0
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		token := z.Token()
+		switch {
+		case skip != "" && tt == html.StartTagToken && token.Data == skip:
+			depth++
+		case skip != "" && tt == html.EndTagToken && token.Data == skip:
+			if depth--; depth == 0 {
+				skip = ""
+			}
+		case skip != "":
+		case tt == html.StartTagToken && unsafeTags[token.Data]:
+			skip, depth = token.Data, 1
+		case tt == html.TextToken:
+			words = append(words, strings.Fields(token.Data)...)
+		}
+	}
+
+	text := strings.Join(words, " ")
+	if len([]rune(text)) <= max {
+		return text
+	}
+	cut := string([]rune(text)[:max-1])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+	return cut + "…"
+}
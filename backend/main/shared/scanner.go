@@ -0,0 +1,170 @@
+package shared
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	ScanClamav = "clamav"
+	ScanHttp   = "http"
+
+	clamavChunkSize = 64 * 1024
+)
+
+// ScanResult is a scanner's verdict on a file. Signature names what was
+// found when the file is not clean.
+type ScanResult struct {
+	Clean     bool   `json:"clean"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// Scanner checks uploads for malware before they are stored.
+type Scanner interface {
+	Scan(content []byte) (ScanResult, error)
+}
+
+// NewScannerFromEnv returns the scanner named by SCAN_DRIVER, or nil when
+// scanning is not configured.
+func NewScannerFromEnv() (Scanner, error) {
+	switch driver := os.Getenv("SCAN_DRIVER"); driver {
+	case "":
+		return nil, nil
+	case ScanClamav:
+		addr := os.Getenv("CLAMAV_ADDR")
+		if addr == "" {
+			addr = "localhost:3310"
+		}
+		return NewClamavScanner(addr), nil
+	case ScanHttp:
+		url := os.Getenv("SCAN_API_URL")
+		if url == "" {
+			return nil, fmt.Errorf("http scanning requires SCAN_API_URL")
+		}
+		return NewHttpScanner(url, os.Getenv("SCAN_API_KEY")), nil
+	default:
+		return nil, fmt.Errorf("unknown scan driver %q", driver)
+	}
+}
+
+// ClamavScanner streams files to clamd with the INSTREAM command. Addr is
+// a host:port, or the path of clamd's unix socket.
+type ClamavScanner struct {
+	Addr    string
+	Timeout time.Duration
+}
+
+func NewClamavScanner(addr string) *ClamavScanner {
+	return &ClamavScanner{Addr: addr, Timeout: time.Second * 30}
+}
+
+func (c *ClamavScanner) Scan(content []byte) (ScanResult, error) {
+	network := "tcp"
+	if strings.HasPrefix(c.Addr, "/") {
+		network = "unix"
+	}
+
+	conn, err := net.DialTimeout(network, c.Addr, c.Timeout)
+	if err != nil {
+		return ScanResult{}, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(c.Timeout))
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return ScanResult{}, err
+	}
+	for len(content) > 0 {
+		n := len(content)
+		if n > clamavChunkSize {
+			n = clamavChunkSize
+		}
+		if err := binary.Write(conn, binary.BigEndian, uint32(n)); err != nil {
+			return ScanResult{}, err
+		}
+		if _, err := conn.Write(content[:n]); err != nil {
+			return ScanResult{}, err
+		}
+		content = content[n:]
+	}
+	// a zero length chunk ends the stream
+	if err := binary.Write(conn, binary.BigEndian, uint32(0)); err != nil {
+		return ScanResult{}, err
+	}
+
+	reply, err := ioutil.ReadAll(conn)
+	if err != nil {
+		return ScanResult{}, err
+	}
+	return parseClamavReply(string(reply))
+}
+
+// parseClamavReply reads replies like "stream: OK" and
+// "stream: Eicar-Signature FOUND".
+func parseClamavReply(reply string) (ScanResult, error) {
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	switch {
+	case strings.HasSuffix(reply, " OK"):
+		return ScanResult{Clean: true}, nil
+	case strings.HasSuffix(reply, " FOUND"):
+		signature := strings.TrimSuffix(strings.TrimPrefix(reply, "stream: "), " FOUND")
+		return ScanResult{Clean: false, Signature: signature}, nil
+	default:
+		return ScanResult{}, fmt.Errorf("clamav: %s", reply)
+	}
+}
+
+// HttpScanner posts files to an external scanning API, which replies with
+// a JSON ScanResult.
+type HttpScanner struct {
+	URL        string
+	apiKey     string
+	HTTPClient *http.Client
+}
+
+func NewHttpScanner(url, apiKey string) *HttpScanner {
+	return &HttpScanner{
+		URL:    url,
+		apiKey: apiKey,
+		HTTPClient: &http.Client{
+			Timeout: time.Second * 30,
+		},
+	}
+}
+
+func (c *HttpScanner) Scan(content []byte) (ScanResult, error) {
+	req, err := http.NewRequest(http.MethodPost, c.URL, bytes.NewReader(content))
+	if err != nil {
+		return ScanResult{}, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return ScanResult{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+		return ScanResult{}, fmt.Errorf("scanning API returned status code %d: %s", res.StatusCode, body)
+	}
+
+	var result ScanResult
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return ScanResult{}, err
+	}
+	return result, nil
+}
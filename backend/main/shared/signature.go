@@ -0,0 +1,183 @@
+package shared
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+)
+
+const (
+	// SchemeFlowScript checks signatures with a Cadence script run on an
+	// access node, the way CAST always has.
+	SchemeFlowScript = "flow-script"
+	// SchemeFlowKeys checks signatures locally against the account's keys.
+	SchemeFlowKeys = "flow-keys"
+)
+
+var (
+	ErrInvalidSignature       = errors.New("invalid signature")
+	ErrUnknownSignatureScheme = errors.New("unknown signature scheme")
+)
+
+// SignatureVerifier checks composite signatures by address over a hex
+// encoded message. messageType is "USER" or "TRANSACTION", which selects
+// the domain tag the wallet signed with.
+type SignatureVerifier interface {
+	Verify(address, message string, sigs []CompositeSignature, messageType string) error
+}
+
+// SignatureVerifiers routes signatures to the verifier registered for the
+// scheme they name, or to the default one when they name none.
+type SignatureVerifiers struct {
+	Default   string
+	verifiers map[string]SignatureVerifier
+}
+
+func NewSignatureVerifiers(defaultScheme string) *SignatureVerifiers {
+	return &SignatureVerifiers{Default: defaultScheme, verifiers: map[string]SignatureVerifier{}}
+}
+
+// NewSignatureVerifiersFromEnv registers the Flow verifiers, defaulting to
+// the scheme named by SIGNATURE_SCHEME.
+func NewSignatureVerifiersFromEnv(fa *FlowAdapter) (*SignatureVerifiers, error) {
+	scheme := os.Getenv("SIGNATURE_SCHEME")
+	if scheme == "" {
+		scheme = SchemeFlowScript
+	}
+
+	v := NewSignatureVerifiers(scheme)
+	v.Register(SchemeFlowScript, ScriptVerifier{Adapter: fa})
+	v.Register(SchemeFlowKeys, AccountKeyVerifier{Accounts: fa})
+	if v.verifiers[scheme] == nil {
+		return nil, fmt.Errorf("%w %q", ErrUnknownSignatureScheme, scheme)
+	}
+	return v, nil
+}
+
+// Register adds a verifier, replacing any registered under the scheme.
+func (r *SignatureVerifiers) Register(scheme string, v SignatureVerifier) {
+	r.verifiers[scheme] = v
+}
+
+// Verify passes the signatures to the verifier of their scheme. Every
+// signature of a request must use the same scheme.
+func (r *SignatureVerifiers) Verify(address, message string, sigs *[]CompositeSignature, messageType string) error {
+	if sigs == nil || len(*sigs) == 0 {
+		return errors.New("no signatures provided")
+	}
+
+	scheme := r.Default
+	for i, sig := range *sigs {
+		s := r.Default
+		if sig.Scheme != nil && *sig.Scheme != "" {
+			s = *sig.Scheme
+		}
+		if i > 0 && s != scheme {
+			return errors.New("signatures must all use the same scheme")
+		}
+		scheme = s
+	}
+
+	v := r.verifiers[scheme]
+	if v == nil {
+		return fmt.Errorf("%w %q", ErrUnknownSignatureScheme, scheme)
+	}
+	return v.Verify(address, message, *sigs, messageType)
+}
+
+// ScriptVerifier checks signatures with validate_signature.cdc.
+type ScriptVerifier struct {
+	Adapter *FlowAdapter
+}
+
+func (v ScriptVerifier) Verify(address, message string, sigs []CompositeSignature, messageType string) error {
+	return v.Adapter.ValidateSignature(address, message, &sigs, messageType)
+}
+
+type AccountGetter interface {
+	GetAccount(address string) (*flow.Account, error)
+	GetAccountAtBlockHeight(address string, blockHeight uint64) (*flow.Account, error)
+}
+
+// AccountKeyVerifier checks each signature against the account key it
+// names, using that key's signature and hash algorithms. Signatures by
+// distinct, unrevoked keys add their weight until it reaches the account
+// threshold; repeating a key adds nothing.
+//
+// Keys are those of BlockHeight when it is set, so a signature stays valid
+// after the key that made it is rotated out or revoked.
+type AccountKeyVerifier struct {
+	Accounts    AccountGetter
+	BlockHeight uint64
+}
+
+func (v AccountKeyVerifier) Verify(address, message string, sigs []CompositeSignature, messageType string) error {
+	messageBytes, err := hex.DecodeString(message)
+	if err != nil {
+		return fmt.Errorf("message is not hex encoded: %w", err)
+	}
+	tag := flow.UserDomainTag
+	if messageType == "TRANSACTION" {
+		tag = flow.TransactionDomainTag
+	}
+	signed := append(tag[:], messageBytes...)
+
+	account, err := v.account(address)
+	if err != nil {
+		return err
+	}
+	keys := make(map[int]*flow.AccountKey, len(account.Keys))
+	for _, key := range account.Keys {
+		keys[key.Index] = key
+	}
+
+	weight := 0
+	counted := make(map[int]bool, len(sigs))
+	for _, sig := range sigs {
+		if sig.Addr != "" && flow.HexToAddress(sig.Addr) != flow.HexToAddress(address) {
+			return errors.New("signature was made by a different address")
+		}
+
+		key := keys[int(sig.Key_id)]
+		if key == nil || key.Revoked || counted[key.Index] {
+			continue
+		}
+		if ok, err := verifyWithKey(key, sig.Signature, signed); err != nil || !ok {
+			continue
+		}
+
+		counted[key.Index] = true
+		weight += key.Weight
+		if weight >= flow.AccountKeyWeightThreshold {
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
+
+func (v AccountKeyVerifier) account(address string) (*flow.Account, error) {
+	if v.BlockHeight > 0 {
+		return v.Accounts.GetAccountAtBlockHeight(address, v.BlockHeight)
+	}
+	return v.Accounts.GetAccount(address)
+}
+
+func verifyWithKey(key *flow.AccountKey, signature string, signed []byte) (bool, error) {
+	if !crypto.CompatibleAlgorithms(key.SigAlgo, key.HashAlgo) {
+		return false, fmt.Errorf("key %d pairs incompatible algorithms %s and %s", key.Index, key.SigAlgo, key.HashAlgo)
+	}
+	sig, err := hex.DecodeString(signature)
+	if err != nil {
+		return false, err
+	}
+	hasher, err := crypto.NewHasher(key.HashAlgo)
+	if err != nil {
+		return false, err
+	}
+	return key.PublicKey.Verify(sig, signed, hasher)
+}
@@ -0,0 +1,70 @@
+package shared
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SortField is a field a list is sorted by, descending when Desc.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// SortColumns are the fields a list can be sorted by, each mapped to the
+// SQL expression it sorts on. Only these ever reach a query.
+type SortColumns map[string]string
+
+// Parse reads a sort parameter such as "created_at,-name": fields in order
+// of precedence, each descending when prefixed by "-".
+func (c SortColumns) Parse(param string) ([]SortField, error) {
+	if param == "" {
+		return nil, nil
+	}
+
+	var fields []SortField
+	seen := map[string]bool{}
+	for _, f := range strings.Split(param, ",") {
+		field := SortField{Field: strings.TrimSpace(f)}
+		if strings.HasPrefix(field.Field, "-") {
+			field.Field, field.Desc = field.Field[1:], true
+		}
+		if _, ok := c[field.Field]; !ok {
+			return nil, fmt.Errorf("Cannot sort by %q, only by %s.", field.Field, c.names())
+		}
+		if seen[field.Field] {
+			return nil, fmt.Errorf("Cannot sort by %q twice.", field.Field)
+		}
+		seen[field.Field] = true
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// OrderBy renders the sort as an ORDER BY clause, or fallback when there
+// is no sort.
+func (c SortColumns) OrderBy(fields []SortField, fallback string) string {
+	if len(fields) == 0 {
+		return fallback
+	}
+
+	terms := make([]string, len(fields))
+	for i, f := range fields {
+		direction := "ASC"
+		if f.Desc {
+			direction = "DESC"
+		}
+		terms[i] = c[f.Field] + " " + direction
+	}
+	return " ORDER BY " + strings.Join(terms, ", ")
+}
+
+func (c SortColumns) names() string {
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,269 @@
+package shared
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	StorageIpfs  = "ipfs"
+	StorageLocal = "local"
+	StorageS3    = "s3"
+	StorageGcs   = "gcs"
+)
+
+// PrivateKeyPrefix keeps private objects apart from public ones, so a
+// bucket policy can allow public reads on everything else.
+const PrivateKeyPrefix = "private/"
+
+const FileTokenAudience = "file"
+
+var (
+	ErrObjectNotFound     = errors.New("object not found")
+	ErrInvalidKey         = errors.New("invalid object key")
+	ErrSignedURLsDisabled = errors.New("storage driver does not support signed URLs")
+)
+
+// extensions are fixed here rather than looked up in the host's mime
+// tables, so a file gets the same key everywhere
+var contentTypeExtensions = map[string]string{
+	"image/jpg":  ".jpg",
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+
+	"application/pdf": ".pdf",
+}
+
+// content keys are the hex sha256 of the content plus an optional extension
+var contentKeyPattern = regexp.MustCompile(`^(private/)?[0-9a-f]{64}(\.[0-9a-z]+)?$`)
+
+// StoredObject is where Storage put an upload.
+type StoredObject struct {
+	Key string
+	URL string
+}
+
+// Storage is a destination for uploaded files. Objects are written once
+// and read back by the key Put returns, which is usually the key it was
+// given; drivers that address content themselves (IPFS) return their own.
+type Storage interface {
+	Driver() string
+	Put(key string, content []byte, contentType string) (StoredObject, error)
+	Get(key string) ([]byte, error)
+	Delete(key string) error
+	// SignedURL returns a URL that reads the object until expiry, whatever
+	// access the object otherwise has.
+	SignedURL(key string, expiry time.Duration) (string, error)
+}
+
+type StorageConfig struct {
+	Driver    string
+	LocalDir  string
+	PublicURL string
+	Bucket    string
+	Region    string
+	Endpoint  string
+	AccessKey string
+	SecretKey string
+}
+
+func StorageConfigFromEnv() StorageConfig {
+	return StorageConfig{
+		Driver:    os.Getenv("STORAGE_DRIVER"),
+		LocalDir:  os.Getenv("STORAGE_LOCAL_DIR"),
+		PublicURL: os.Getenv("STORAGE_PUBLIC_URL"),
+		Bucket:    os.Getenv("STORAGE_BUCKET"),
+		Region:    os.Getenv("STORAGE_REGION"),
+		Endpoint:  os.Getenv("STORAGE_ENDPOINT"),
+		AccessKey: os.Getenv("STORAGE_ACCESS_KEY"),
+		SecretKey: os.Getenv("STORAGE_SECRET_KEY"),
+	}
+}
+
+// NewStorage builds the driver named in c, defaulting to IPFS. Local
+// storage signs URLs with signer.
+func NewStorage(c StorageConfig, ipfs *IpfsClient, signer *TokenSigner) (Storage, error) {
+	switch c.Driver {
+	case "", StorageIpfs:
+		return &ipfsStorage{client: ipfs}, nil
+	case StorageLocal:
+		dir := c.LocalDir
+		if dir == "" {
+			dir = "./uploads"
+		}
+		publicURL := c.PublicURL
+		if publicURL == "" {
+			publicURL = "/files/"
+		}
+		return NewLocalStorage(dir, publicURL, signer)
+	case StorageS3:
+		if c.Region == "" {
+			c.Region = "us-east-1"
+		}
+		if c.Endpoint == "" {
+			c.Endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", c.Region)
+		}
+		return newS3Storage(StorageS3, c)
+	case StorageGcs:
+		// GCS is reached through its S3 compatible XML API with HMAC keys
+		if c.Region == "" {
+			c.Region = "auto"
+		}
+		if c.Endpoint == "" {
+			c.Endpoint = "https://storage.googleapis.com"
+		}
+		return newS3Storage(StorageGcs, c)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", c.Driver)
+	}
+}
+
+// ContentKey names content by its sha256, so repeated uploads of the
+// same file land on the same key.
+func ContentKey(content []byte, contentType string) string {
+	sum := sha256.Sum256(content)
+	key := hex.EncodeToString(sum[:])
+	return key + contentTypeExtensions[contentType]
+}
+
+func ValidContentKey(key string) bool {
+	return contentKeyPattern.MatchString(key)
+}
+
+func joinURL(base, key string) string {
+	return strings.TrimRight(base, "/") + "/" + key
+}
+
+type ipfsStorage struct {
+	client *IpfsClient
+}
+
+func (s *ipfsStorage) Driver() string { return StorageIpfs }
+
+func (s *ipfsStorage) Put(key string, content []byte, contentType string) (StoredObject, error) {
+	pin, err := s.client.PinFile(bytes.NewReader(content), key)
+	if err != nil {
+		return StoredObject{}, err
+	}
+	return StoredObject{Key: pin.IpfsHash, URL: s.client.GatewayURL + pin.IpfsHash}, nil
+}
+
+func (s *ipfsStorage) Get(key string) ([]byte, error) {
+	return s.client.FetchContent(key)
+}
+
+// Delete is a no-op, pinned content is left to Pinata's own retention.
+func (s *ipfsStorage) Delete(key string) error {
+	return nil
+}
+
+// SignedURL is unsupported, anything pinned to IPFS is public.
+func (s *ipfsStorage) SignedURL(key string, expiry time.Duration) (string, error) {
+	return "", ErrSignedURLsDisabled
+}
+
+// LocalStorage keeps uploads on disk, to be served by the API itself.
+// Signed URLs carry a token the API checks before serving the file.
+type LocalStorage struct {
+	Dir       string
+	PublicURL string
+	signer    *TokenSigner
+}
+
+func NewLocalStorage(dir, publicURL string, signer *TokenSigner) (*LocalStorage, error) {
+	if err := os.MkdirAll(filepath.Join(dir, PrivateKeyPrefix), 0755); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{Dir: dir, PublicURL: publicURL, signer: signer}, nil
+}
+
+func (s *LocalStorage) Driver() string { return StorageLocal }
+
+func (s *LocalStorage) path(key string) (string, error) {
+	if !ValidContentKey(key) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.Dir, key), nil
+}
+
+func (s *LocalStorage) Put(key string, content []byte, contentType string) (StoredObject, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return StoredObject{}, err
+	}
+
+	// content addressed, so an existing file already holds these bytes
+	if _, err := os.Stat(path); err != nil {
+		tmp, err := ioutil.TempFile(filepath.Dir(path), ".upload-*")
+		if err != nil {
+			return StoredObject{}, err
+		}
+		defer os.Remove(tmp.Name())
+		if _, err := tmp.Write(content); err != nil {
+			tmp.Close()
+			return StoredObject{}, err
+		}
+		if err := tmp.Close(); err != nil {
+			return StoredObject{}, err
+		}
+		if err := os.Rename(tmp.Name(), path); err != nil {
+			return StoredObject{}, err
+		}
+	}
+
+	return StoredObject{Key: key, URL: joinURL(s.PublicURL, key)}, nil
+}
+
+func (s *LocalStorage) Get(key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrObjectNotFound
+	}
+	return content, err
+}
+
+func (s *LocalStorage) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) SignedURL(key string, expiry time.Duration) (string, error) {
+	if !ValidContentKey(key) {
+		return "", ErrInvalidKey
+	}
+	if s.signer == nil {
+		return "", ErrSignedURLsDisabled
+	}
+
+	now := time.Now()
+	token, err := s.signer.Sign(TokenClaims{
+		Subject:   key,
+		Audience:  FileTokenAudience,
+		ExpiresAt: now.Add(expiry).Unix(),
+		IssuedAt:  now.Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	return joinURL(s.PublicURL, key) + "?token=" + token, nil
+}
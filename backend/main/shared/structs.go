@@ -1,46 +1,68 @@
 package shared
 
 import (
-	"context"
 	"os"
 	"reflect"
+	"sync"
 	"time"
-
-	"github.com/jackc/pgx/v4/pgxpool"
 )
 
-type Config struct {
-	Features map[string]bool `default:"useCorsMiddleware:false,validateTimestamps:true,validateAllowlist:true,validateBlocklist:true,validateSigs:true"`
-}
-
-type Database struct {
-	Conn    *pgxpool.Pool
-	Context context.Context
-	Name    string
-	Env     *string
-}
-
 type StrategyStruct struct {
 	FlowAdapter *FlowAdapter
 	DB          *Database
 }
 
+// Allowlist is an in-memory copy of a list of addresses, safe to replace
+// while requests read it.
 type Allowlist struct {
-	Addresses []string `json:"addresses"`
+	mu        sync.RWMutex
+	addresses []string
+}
+
+func (l *Allowlist) Set(addresses []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.addresses = addresses
+}
+
+func (l *Allowlist) List() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return append([]string{}, l.addresses...)
 }
 
+func (l *Allowlist) Contains(addr string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, a := range l.addresses {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// PaginatedResponse is the envelope of every paginated endpoint. Next and
+// Prev are the start of the pages around this one, -1 when there is none,
+// and NextUrl and PrevUrl link to them; see SetLinks.
 type PaginatedResponse struct {
 	Data         interface{} `json:"data"`
 	Start        int         `json:"start"`
 	Count        int         `json:"count"`
 	TotalRecords int         `json:"totalRecords"`
 	Next         int         `json:"next"`
+	Prev         int         `json:"prev"`
+	NextUrl      *string     `json:"nextUrl"`
+	PrevUrl      *string     `json:"prevUrl"`
+
+	pageSize int
 }
 
 type PageParams struct {
 	Start        int
 	Count        int
 	Order        string
+	Sort         []SortField
 	TotalRecords int
 }
 
@@ -55,6 +77,9 @@ type CompositeSignature struct {
 	Signature string  `json:"signature"`
 	F_type    *string `json:"f_type,omitempty"`
 	F_vsn     *string `json:"f_vsn,omitempty"`
+	// Scheme names the verifier for wallets that sign other than FCL's
+	// default, see SignatureVerifiers.
+	Scheme *string `json:"scheme,omitempty"`
 }
 
 type TimestampSignaturePayload struct {
@@ -67,6 +92,8 @@ type TimestampSignaturePayload struct {
 type Choice struct {
 	Choice_text    string  `json:"choiceText"`
 	Choice_img_url *string `json:"choiceImgUrl"`
+	// Choice_type is empty for choices that can win, or abstain or veto
+	Choice_type string `json:"choiceType,omitempty"`
 }
 
 type MintParams struct {
@@ -94,6 +121,8 @@ type FTBalanceResponse struct {
 	Proposal_id             int       `json:"proposal_id"`
 	NFTCount                int       `json:"nftCount"`
 	CreatedAt               time.Time `json:"createdAt"`
+
+	Token *TokenMetadata `json:"token,omitempty"`
 }
 
 type CustomScript struct {
@@ -117,22 +146,33 @@ func (b *FTBalanceResponse) NewFTBalance() {
 
 // Underlying value of payload needs to be a slice
 func GetPaginatedResponseWithPayload(payload interface{}, p PageParams) *PaginatedResponse {
-	// Tricky way of getting the length of a slice
-	// that is typed as interface{}
+	// a nil slice is sent as an empty list, not null
+	value := reflect.ValueOf(payload)
+	if value.Kind() == reflect.Slice && value.IsNil() {
+		payload = reflect.MakeSlice(value.Type(), 0, 0).Interface()
+	}
+	_count := value.Len()
 
-	_count := reflect.ValueOf(payload).Len()
-	var next int
-	if p.Start+_count >= (p.TotalRecords - 1) {
-		next = -1
-	} else {
+	next := -1
+	if p.Start+_count < p.TotalRecords {
 		next = p.Start + _count
 	}
+	prev := -1
+	if p.Start > 0 {
+		prev = p.Start - p.Count
+		if prev < 0 {
+			prev = 0
+		}
+	}
+
 	response := PaginatedResponse{
 		Data:         payload,
 		Start:        p.Start,
 		Count:        _count,
 		TotalRecords: p.TotalRecords,
 		Next:         next,
+		Prev:         prev,
+		pageSize:     p.Count,
 	}
 
 	return &response
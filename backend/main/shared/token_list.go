@@ -0,0 +1,125 @@
+package shared
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	TokenSourceList      = "token-list"
+	TokenSourceCommunity = "community"
+)
+
+// TokenMetadata describes a fungible token the way clients show it, so
+// they don't have to keep their own copy of symbols, decimals and logos.
+type TokenMetadata struct {
+	Symbol        string     `json:"symbol"`
+	Name          string     `json:"name"`
+	Decimals      int        `json:"decimals"`
+	Logo_uri      string     `json:"logoURI,omitempty"`
+	Contract_name string     `json:"contractName"`
+	Contract_addr string     `json:"contractAddr"`
+	Paths         TokenPaths `json:"path"`
+	Source        string     `json:"source"`
+}
+
+type TokenPaths struct {
+	Vault    string `json:"vault"`
+	Receiver string `json:"receiver"`
+	Balance  string `json:"balance"`
+}
+
+// TokenList holds the tokens of a Flow token list, in the format of
+// https://github.com/FlowFans/flow-token-list, looked up by contract.
+type TokenList struct {
+	mu     sync.RWMutex
+	tokens map[string]TokenMetadata
+}
+
+type tokenListFile struct {
+	Tokens []struct {
+		Address      string     `json:"address"`
+		ContractName string     `json:"contractName"`
+		Symbol       string     `json:"symbol"`
+		Name         string     `json:"name"`
+		Decimals     int        `json:"decimals"`
+		LogoURI      string     `json:"logoURI"`
+		Path         TokenPaths `json:"path"`
+	} `json:"tokens"`
+}
+
+// NewTokenListFromEnv loads the token list at TOKEN_LIST_PATH, or the one
+// bundled for the network when it is unset.
+func NewTokenListFromEnv(env string) (*TokenList, error) {
+	path := os.Getenv("TOKEN_LIST_PATH")
+	if path == "" {
+		path = "./main/tokens/" + env + ".tokenlist.json"
+	}
+
+	l := &TokenList{tokens: map[string]TokenMetadata{}}
+	content, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && os.Getenv("TOKEN_LIST_PATH") == "" {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return l, l.Load(content)
+}
+
+// Load replaces the tokens with those of the token list.
+func (l *TokenList) Load(content []byte) error {
+	var file tokenListFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return err
+	}
+
+	tokens := make(map[string]TokenMetadata, len(file.Tokens))
+	for _, t := range file.Tokens {
+		token := TokenMetadata{
+			Symbol:        t.Symbol,
+			Name:          t.Name,
+			Decimals:      t.Decimals,
+			Logo_uri:      t.LogoURI,
+			Contract_name: t.ContractName,
+			Contract_addr: t.Address,
+			Paths:         t.Path,
+			Source:        TokenSourceList,
+		}
+		tokens[tokenKey(token.Contract_name, token.Contract_addr)] = token
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = tokens
+	return nil
+}
+
+func (l *TokenList) Lookup(name, addr string) (TokenMetadata, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	token, ok := l.tokens[tokenKey(name, addr)]
+	return token, ok
+}
+
+// All lists the tokens ordered by symbol.
+func (l *TokenList) All() []TokenMetadata {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	tokens := make([]TokenMetadata, 0, len(l.tokens))
+	for _, t := range l.tokens {
+		tokens = append(tokens, t)
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].Symbol < tokens[j].Symbol })
+	return tokens
+}
+
+// addresses are compared without their prefix, whatever their case
+func tokenKey(name, addr string) string {
+	return strings.ToLower(strings.TrimPrefix(addr, "0x")) + "." + name
+}
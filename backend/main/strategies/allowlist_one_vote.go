@@ -0,0 +1,172 @@
+package strategies
+
+import (
+	"errors"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/jackc/pgx/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// AllowlistOneVote gives one vote to each address on a list of the
+// community, as the list was when the proposal started or at the version
+// the proposal is bound to.
+type AllowlistOneVote struct {
+	s.StrategyStruct
+	DB *s.Database
+}
+
+func (s *AllowlistOneVote) FetchBalance(
+	b *models.Balance,
+	p *models.Proposal,
+) (*models.Balance, error) {
+
+	allowed, err := s.allowlist(p)
+	if err != nil {
+		log.Error().Err(err).Msg("Error getting the allowlist of the proposal.")
+		return nil, err
+	}
+	if !allowed[b.Addr] {
+		return nil, models.ErrNotOnAllowlist
+	}
+
+	if err := b.GetBalanceByAddressAndBlockHeight(s.DB); err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		log.Error().Err(err).Msg("Error querying address b at blockheight.")
+		return nil, err
+	}
+
+	if b.ID == "" {
+		if err := b.CreateBalance(s.DB); err != nil {
+			log.Error().Err(err).Msg("Error saving b to database.")
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+func (s *AllowlistOneVote) TallyVotes(
+	votes []*models.VoteWithBalance,
+	r *models.ProposalResults,
+	proposal *models.Proposal,
+) (models.ProposalResults, error) {
+
+	allowed, err := s.allowlist(proposal)
+	if err != nil {
+		return models.ProposalResults{}, err
+	}
+
+	for _, vote := range votes {
+		if allowed[vote.Addr] {
+			r.AddVote(&vote.Vote, 1, 1)
+		}
+	}
+
+	return *r, nil
+}
+
+func (s *AllowlistOneVote) GetVoteWeightForBalance(
+	vote *models.VoteWithBalance,
+	proposal *models.Proposal,
+) (float64, error) {
+	allowed, err := s.allowlist(proposal)
+	if err != nil {
+		return 0.00, err
+	}
+	if !allowed[vote.Addr] {
+		return 0.00, nil
+	}
+	return 1.00, nil
+}
+
+func (s *AllowlistOneVote) GetVotes(
+	votes []*models.VoteWithBalance,
+	proposal *models.Proposal,
+) ([]*models.VoteWithBalance, error) {
+
+	allowed, err := s.allowlist(proposal)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, vote := range votes {
+		weight := 0.00
+		if allowed[vote.Addr] {
+			weight = 1.00
+		}
+		vote.Weight = &weight
+	}
+
+	return votes, nil
+}
+
+func (s *AllowlistOneVote) RequiresSnapshot() bool {
+	return false
+}
+
+// EstimateWeight is one vote for addresses on the list as it is now.
+func (s *AllowlistOneVote) EstimateWeight(
+	addr string,
+	strategy *models.Strategy,
+	blockHeight uint64,
+) (float64, error) {
+	if strategy.List_id == nil {
+		return 0, errors.New("The strategy names no list.")
+	}
+	l := models.List{ID: *strategy.List_id}
+	if err := l.GetListById(s.DB); err != nil {
+		return 0, err
+	}
+	for _, a := range l.Addresses {
+		if a == addr {
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+// RemainingWeight is a vote for each address on the list that has yet to
+// vote on the proposal.
+func (s *AllowlistOneVote) RemainingWeight(proposal *models.Proposal) (float64, error) {
+	allowed, err := s.allowlist(proposal)
+	if err != nil {
+		return 0, err
+	}
+
+	addrs := make([]string, 0, len(allowed))
+	for addr := range allowed {
+		addrs = append(addrs, addr)
+	}
+	voted, err := models.CountVotesFrom(s.DB, proposal.ID, addrs)
+	if err != nil {
+		return 0, err
+	}
+	return float64(len(addrs) - voted), nil
+}
+
+func (s *AllowlistOneVote) InitStrategy(
+	f *shared.FlowAdapter,
+	db *shared.Database,
+) {
+	s.FlowAdapter = f
+	s.DB = db
+}
+
+// allowlist is the set of addresses on the list the proposal is bound to.
+func (s *AllowlistOneVote) allowlist(p *models.Proposal) (map[string]bool, error) {
+	if p.Strategy_config == nil || p.Strategy_config.List_id == nil {
+		return nil, errors.New("The strategy of the proposal names no list.")
+	}
+	version, err := models.GetListVersionForProposal(s.DB, *p.Strategy_config.List_id, p)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := make(map[string]bool, len(version.Addresses))
+	for _, addr := range version.Addresses {
+		allowed[addr] = true
+	}
+	return allowed, nil
+}
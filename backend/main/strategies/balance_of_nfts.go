@@ -27,7 +27,7 @@ func (b *BalanceOfNfts) FetchBalance(
 		return nil, err
 	}
 
-	strategy, err := models.MatchStrategyByProposal(*c.Strategies, *p.Strategy)
+	strategy, err := p.BoundStrategy(&c)
 	if err != nil {
 		log.Error().Err(err).Msg("Unable to find strategy for contract.")
 		return nil, err
@@ -92,8 +92,7 @@ func (b *BalanceOfNfts) TallyVotes(
 				return models.ProposalResults{}, err
 			}
 
-			r.Results[vote.Choice] += int(voteWeight)
-			r.Results_float[vote.Choice] += voteWeight
+			r.AddVote(&vote.Vote, voteWeight, voteWeight)
 		}
 	}
 
@@ -133,6 +132,24 @@ func (b *BalanceOfNfts) RequiresSnapshot() bool {
 	return false
 }
 
+// EstimateWeight counts the NFTs currently held, without recording them.
+func (b *BalanceOfNfts) EstimateWeight(
+	addr string,
+	strategy *models.Strategy,
+	blockHeight uint64,
+) (float64, error) {
+	nftIds, err := b.FlowAdapter.GetNFTIds(
+		addr,
+		&strategy.Contract,
+		"./main/cadence/scripts/get_nfts_ids.cdc",
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(len(nftIds)), nil
+}
+
 func (b *BalanceOfNfts) InitStrategy(
 	f *shared.FlowAdapter,
 	db *shared.Database,
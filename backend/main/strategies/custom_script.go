@@ -1,6 +1,7 @@
 package strategies
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/DapperCollectives/CAST/backend/main/models"
@@ -29,7 +30,7 @@ func (cs *CustomScript) FetchBalance(
 		return nil, err
 	}
 
-	strategy, err := models.MatchStrategyByProposal(*c.Strategies, *p.Strategy)
+	strategy, err := p.BoundStrategy(&c)
 	if err != nil {
 		log.Error().Err(err).Msg("Unable to find strategy for contract.")
 		return nil, err
@@ -100,8 +101,7 @@ func (cs *CustomScript) TallyVotes(
 				return models.ProposalResults{}, err
 			}
 
-			r.Results[vote.Choice] += int(voteWeight)
-			r.Results_float[vote.Choice] += voteWeight
+			r.AddVote(&vote.Vote, voteWeight, voteWeight)
 		}
 	}
 
@@ -144,6 +144,27 @@ func (cs *CustomScript) RequiresSnapshot() bool {
 	return false
 }
 
+// EstimateWeight counts the NFTs returned by the custom script.
+func (cs *CustomScript) EstimateWeight(
+	addr string,
+	strategy *models.Strategy,
+	blockHeight uint64,
+) (float64, error) {
+	if strategy.Contract.Script == nil {
+		return 0, errors.New("No custom script name field was found for contract.")
+	}
+
+	scriptName := cs.FlowAdapter.CustomScriptsMap[*strategy.Contract.Script].Src
+	scriptPath := fmt.Sprintf("./main/cadence/scripts/custom/%s", scriptName)
+
+	nftIds, err := cs.FlowAdapter.GetNFTIds(addr, &strategy.Contract, scriptPath)
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(len(nftIds)), nil
+}
+
 func (cs *CustomScript) InitStrategy(
 	f *shared.FlowAdapter,
 	db *shared.Database,
@@ -29,7 +29,7 @@ func (f *FloatNFTs) FetchBalance(
 		return nil, err
 	}
 
-	strategy, err := models.MatchStrategyByProposal(*c.Strategies, *p.Strategy)
+	strategy, err := p.BoundStrategy(&c)
 	if err != nil {
 		log.Error().Err(err).Msg("Unable to find strategy for contract.")
 		return nil, err
@@ -100,8 +100,7 @@ func (f *FloatNFTs) TallyVotes(
 				return models.ProposalResults{}, err
 			}
 
-			r.Results[vote.Choice] += int(voteWeight)
-			r.Results_float[vote.Choice] += voteWeight
+			r.AddVote(&vote.Vote, voteWeight, voteWeight)
 		}
 	}
 
@@ -142,6 +141,24 @@ func (f *FloatNFTs) RequiresSnapshot() bool {
 	return false
 }
 
+// EstimateWeight checks the address holds the FLOAT event.
+func (f *FloatNFTs) EstimateWeight(
+	addr string,
+	strategy *models.Strategy,
+	blockHeight uint64,
+) (float64, error) {
+	hasEventNFT, err := f.FlowAdapter.CheckIfUserHasEvent(addr, &strategy.Contract)
+	if err != nil {
+		return 0, err
+	}
+
+	// only one vote per event, as in queryNFTs
+	if hasEventNFT {
+		return 1, nil
+	}
+	return 0, nil
+}
+
 func (f *FloatNFTs) InitStrategy(
 	fa *shared.FlowAdapter,
 	db *shared.Database,
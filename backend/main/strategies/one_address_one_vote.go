@@ -42,7 +42,7 @@ func (s *OneAddressOneVote) TallyVotes(
 ) (models.ProposalResults, error) {
 
 	for _, vote := range votes {
-		r.Results[vote.Choice]++
+		r.AddVote(&vote.Vote, 1, 1)
 	}
 
 	return *r, nil
@@ -83,6 +83,15 @@ func (s *OneAddressOneVote) RequiresSnapshot() bool {
 	return false
 }
 
+// EstimateWeight is always one vote per address.
+func (s *OneAddressOneVote) EstimateWeight(
+	addr string,
+	strategy *models.Strategy,
+	blockHeight uint64,
+) (float64, error) {
+	return 1, nil
+}
+
 func (s *OneAddressOneVote) InitStrategy(
 	f *shared.FlowAdapter,
 	db *shared.Database,
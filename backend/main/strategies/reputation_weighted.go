@@ -0,0 +1,116 @@
+package strategies
+
+import (
+	"errors"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/jackc/pgx/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// ReputationWeighted weighs each vote by the reputation the voter had in
+// the proposal's community when they voted.
+type ReputationWeighted struct {
+	s.StrategyStruct
+	DB *s.Database
+}
+
+func (s *ReputationWeighted) FetchBalance(
+	b *models.Balance,
+	p *models.Proposal,
+) (*models.Balance, error) {
+
+	if err := b.GetBalanceByAddressAndBlockHeight(s.DB); err != nil && err.Error() != pgx.ErrNoRows.Error() {
+		log.Error().Err(err).Msg("Error querying address b at blockheight.")
+		return nil, err
+	}
+
+	if b.ID == "" {
+		if err := b.CreateBalance(s.DB); err != nil {
+			log.Error().Err(err).Msg("Error saving b to database.")
+			return nil, err
+		}
+	}
+
+	if err := models.SnapshotVoteReputation(s.DB, p.ID, p.Community_id, b.Addr); err != nil {
+		log.Error().Err(err).Msg("Error saving the reputation of the voter.")
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (s *ReputationWeighted) TallyVotes(
+	votes []*models.VoteWithBalance,
+	r *models.ProposalResults,
+	proposal *models.Proposal,
+) (models.ProposalResults, error) {
+
+	for _, vote := range votes {
+		weight, err := s.GetVoteWeightForBalance(vote, proposal)
+		if err != nil {
+			return models.ProposalResults{}, err
+		}
+
+		r.AddVote(&vote.Vote, weight, weight)
+	}
+
+	return *r, nil
+}
+
+func (s *ReputationWeighted) GetVoteWeightForBalance(
+	vote *models.VoteWithBalance,
+	proposal *models.Proposal,
+) (float64, error) {
+	weight, err := models.GetVoteReputation(s.DB, proposal.ID, vote.Addr)
+	if err != nil {
+		log.Error().Err(err).Msg("Error getting the reputation of the voter.")
+		return 0.00, err
+	}
+
+	if proposal.Max_weight != nil && weight > *proposal.Max_weight {
+		weight = *proposal.Max_weight
+	}
+
+	return weight, nil
+}
+
+func (s *ReputationWeighted) GetVotes(
+	votes []*models.VoteWithBalance,
+	proposal *models.Proposal,
+) ([]*models.VoteWithBalance, error) {
+
+	for _, vote := range votes {
+		weight, err := s.GetVoteWeightForBalance(vote, proposal)
+		if err != nil {
+			return nil, err
+		}
+		vote.Weight = &weight
+	}
+
+	return votes, nil
+}
+
+func (s *ReputationWeighted) RequiresSnapshot() bool {
+	return false
+}
+
+// EstimateWeight can't tell the weight of an address, which depends on the
+// community it votes in.
+func (s *ReputationWeighted) EstimateWeight(
+	addr string,
+	strategy *models.Strategy,
+	blockHeight uint64,
+) (float64, error) {
+	return 0, errors.New("Reputation weights depend on the community; see the reputation of its users.")
+}
+
+func (s *ReputationWeighted) InitStrategy(
+	f *shared.FlowAdapter,
+	db *shared.Database,
+) {
+	s.FlowAdapter = f
+	s.DB = db
+}
@@ -25,7 +25,7 @@ func (s *StakedTokenWeightedDefault) FetchBalance(
 		return nil, err
 	}
 
-	strategy, err := models.MatchStrategyByProposal(*c.Strategies, *p.Strategy)
+	strategy, err := p.BoundStrategy(&c)
 	if err != nil {
 		log.Error().Err(err).Msg("Unable to find strategy for contract")
 		return nil, err
@@ -53,11 +53,13 @@ func (s *StakedTokenWeightedDefault) FetchBalanceFromSnapshot(
 	ftBalance.NewFTBalance()
 
 	if *strategy.Contract.Name == "FlowToken" {
-		if err := s.FlowAdapter.GetAddressBalanceAtBlockHeight(
+		if err := balanceAtBlockHeight(
+			s.DB,
+			s.FlowAdapter,
+			strategy,
 			b.Addr,
 			b.BlockHeight,
 			ftBalance,
-			&strategy.Contract,
 		); err != nil {
 			log.Error().Err(err).Msg("Error fetching balance from snapshot client")
 			return err
@@ -67,11 +69,13 @@ func (s *StakedTokenWeightedDefault) FetchBalanceFromSnapshot(
 		b.StakingBalance = ftBalance.StakingBalance
 
 	} else {
-		if err := s.FlowAdapter.GetAddressBalanceAtBlockHeight(
+		if err := balanceAtBlockHeight(
+			s.DB,
+			s.FlowAdapter,
+			strategy,
 			b.Addr,
 			b.BlockHeight,
 			ftBalance,
-			&strategy.Contract,
 		); err != nil {
 			log.Error().Err(err).Msg("Error fetching balance.")
 			return err
@@ -100,8 +104,7 @@ func (s *StakedTokenWeightedDefault) TallyVotes(
 				allowedBalance = float64(*vote.StakingBalance)
 			}
 
-			r.Results[vote.Choice] += int(allowedBalance)
-			r.Results_float[vote.Choice] += allowedBalance * math.Pow(10, -8)
+			r.AddVote(&vote.Vote, allowedBalance, allowedBalance*math.Pow(10, -8))
 		}
 	}
 
@@ -156,6 +159,21 @@ func (s *StakedTokenWeightedDefault) RequiresSnapshot() bool {
 	return true
 }
 
+// EstimateWeight reads the staked balance at the block height without
+// storing it.
+func (s *StakedTokenWeightedDefault) EstimateWeight(
+	addr string,
+	strategy *models.Strategy,
+	blockHeight uint64,
+) (float64, error) {
+	b := &models.Balance{Addr: addr, BlockHeight: blockHeight}
+	if err := s.FetchBalanceFromSnapshot(strategy, b); err != nil {
+		return 0, err
+	}
+
+	return float64(b.StakingBalance) * math.Pow(10, -8), nil
+}
+
 func (s *StakedTokenWeightedDefault) InitStrategy(
 	f *shared.FlowAdapter,
 	db *shared.Database,
@@ -26,7 +26,7 @@ func (s *TokenWeightedDefault) FetchBalance(
 		return nil, err
 	}
 
-	strategy, err := models.MatchStrategyByProposal(*c.Strategies, *p.Strategy)
+	strategy, err := p.BoundStrategy(&c)
 	if err != nil {
 		log.Error().Err(err).Msg("Unable to find strategy for contract")
 		return nil, err
@@ -55,11 +55,13 @@ func (s *TokenWeightedDefault) FetchBalanceFromSnapshot(
 	ftBalance.NewFTBalance()
 
 	if *strategy.Contract.Name == "FlowToken" {
-		if err := s.FlowAdapter.GetAddressBalanceAtBlockHeight(
+		if err := balanceAtBlockHeight(
+			s.DB,
+			s.FlowAdapter,
+			strategy,
 			b.Addr,
 			b.BlockHeight,
 			ftBalance,
-			&strategy.Contract,
 		); err != nil {
 			log.Error().Err(err).Msg("Error fetching balance from snapshot client")
 			return err
@@ -69,11 +71,13 @@ func (s *TokenWeightedDefault) FetchBalanceFromSnapshot(
 		b.StakingBalance = ftBalance.StakingBalance
 
 	} else {
-		if err := s.FlowAdapter.GetAddressBalanceAtBlockHeight(
+		if err := balanceAtBlockHeight(
+			s.DB,
+			s.FlowAdapter,
+			strategy,
 			b.Addr,
 			b.BlockHeight,
 			ftBalance,
-			&strategy.Contract,
 		); err != nil {
 			log.Error().Err(err).Msg("Error fetching balance.")
 			return err
@@ -102,8 +106,7 @@ func (s *TokenWeightedDefault) TallyVotes(
 				allowedBalance = float64(*vote.PrimaryAccountBalance)
 			}
 
-			r.Results[vote.Choice] += int(allowedBalance)
-			r.Results_float[vote.Choice] += allowedBalance * math.Pow(10, -8)
+			r.AddVote(&vote.Vote, allowedBalance, allowedBalance*math.Pow(10, -8))
 		}
 	}
 
@@ -157,6 +160,21 @@ func (s *TokenWeightedDefault) RequiresSnapshot() bool {
 	return true
 }
 
+// EstimateWeight reads the token balance at the block height without
+// storing it.
+func (s *TokenWeightedDefault) EstimateWeight(
+	addr string,
+	strategy *models.Strategy,
+	blockHeight uint64,
+) (float64, error) {
+	b := &models.Balance{Addr: addr, BlockHeight: blockHeight}
+	if err := s.FetchBalanceFromSnapshot(strategy, b); err != nil {
+		return 0, err
+	}
+
+	return float64(b.PrimaryAccountBalance) * math.Pow(10, -8), nil
+}
+
 func (s *TokenWeightedDefault) InitStrategy(
 	f *shared.FlowAdapter,
 	db *shared.Database,
@@ -0,0 +1,55 @@
+package strategies
+
+import (
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	s "github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/rs/zerolog/log"
+)
+
+// balanceAtBlockHeight reads an address's token balance at a block height,
+// consulting the weight cache before calling Flow. Cache failures are
+// logged and fall through to Flow.
+func balanceAtBlockHeight(
+	db *s.Database,
+	fa *s.FlowAdapter,
+	strategy *models.Strategy,
+	addr string,
+	blockHeight uint64,
+	ftBalance *s.FTBalanceResponse,
+) error {
+	key := models.WeightCacheKey(strategy)
+
+	if db != nil && blockHeight > 0 {
+		cached, err := models.GetCachedWeight(db, addr, key, blockHeight)
+		if err != nil {
+			log.Warn().Err(err).Msg("Error reading weight cache")
+		} else if cached != nil {
+			ftBalance.PrimaryAccountBalance = cached.Primary_account_balance
+			ftBalance.SecondaryAccountBalance = cached.Secondary_account_balance
+			ftBalance.StakingBalance = cached.Staking_balance
+			ftBalance.Balance = cached.Balance
+			return nil
+		}
+	}
+
+	if err := fa.GetAddressBalanceAtBlockHeight(addr, blockHeight, ftBalance, &strategy.Contract); err != nil {
+		return err
+	}
+
+	if db != nil && blockHeight > 0 {
+		w := models.CachedWeight{
+			Addr:                      addr,
+			Strategy:                  key,
+			Block_height:              blockHeight,
+			Primary_account_balance:   ftBalance.PrimaryAccountBalance,
+			Secondary_account_balance: ftBalance.SecondaryAccountBalance,
+			Staking_balance:           ftBalance.StakingBalance,
+			Balance:                   ftBalance.Balance,
+		}
+		if err := w.Save(db); err != nil {
+			log.Warn().Err(err).Msg("Error writing weight cache")
+		}
+	}
+
+	return nil
+}
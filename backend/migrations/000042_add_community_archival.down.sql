@@ -0,0 +1,3 @@
+ALTER TABLE communities DROP COLUMN is_archived;
+ALTER TABLE communities DROP COLUMN archived_at;
+ALTER TABLE communities DROP COLUMN delete_after;
@@ -0,0 +1,3 @@
+ALTER TABLE communities ADD COLUMN is_archived BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE communities ADD COLUMN archived_at TIMESTAMP;
+ALTER TABLE communities ADD COLUMN delete_after TIMESTAMP;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS communities_parent_id_idx;
+ALTER TABLE communities DROP COLUMN parent_id;
@@ -0,0 +1,2 @@
+ALTER TABLE communities ADD COLUMN parent_id INT REFERENCES communities(id) ON DELETE SET NULL;
+CREATE INDEX communities_parent_id_idx ON communities(parent_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS community_join_requests;
+DROP TYPE IF EXISTS join_request_statuses;
+ALTER TABLE communities DROP COLUMN is_private;
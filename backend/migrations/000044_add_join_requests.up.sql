@@ -0,0 +1,19 @@
+ALTER TABLE communities ADD COLUMN is_private BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TYPE join_request_statuses AS enum ('pending', 'approved', 'denied');
+
+CREATE TABLE community_join_requests (
+  id BIGSERIAL primary key,
+  community_id INT not null references communities(id) ON DELETE CASCADE,
+  addr VARCHAR(18) not null,
+  message TEXT,
+  status join_request_statuses not null default 'pending',
+  composite_signatures jsonb,
+  reviewer_addr VARCHAR(18),
+  reason TEXT,
+  created_at TIMESTAMP without time zone default (now() at time zone 'utc'),
+  reviewed_at TIMESTAMP without time zone
+);
+
+CREATE UNIQUE INDEX community_join_requests_pending_idx
+  ON community_join_requests(community_id, addr) WHERE status = 'pending';
@@ -0,0 +1,14 @@
+CREATE TABLE community_invites (
+  id BIGSERIAL primary key,
+  community_id INT not null references communities(id) ON DELETE CASCADE,
+  user_type VARCHAR(12) not null default 'member',
+  addr VARCHAR(18),
+  max_uses INT not null default 1,
+  uses INT not null default 0,
+  created_by VARCHAR(18) not null,
+  expires_at TIMESTAMP without time zone not null,
+  revoked_at TIMESTAMP without time zone,
+  created_at TIMESTAMP without time zone default (now() at time zone 'utc')
+);
+
+CREATE INDEX community_invites_community_id_idx ON community_invites(community_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS community_role_members;
+DROP TABLE IF EXISTS community_roles;
@@ -0,0 +1,17 @@
+CREATE TABLE community_roles (
+  id BIGSERIAL primary key,
+  community_id INT not null references communities(id) ON DELETE CASCADE,
+  name VARCHAR(64) not null,
+  permissions TEXT[] not null default '{}',
+  created_at TIMESTAMP without time zone default (now() at time zone 'utc'),
+  UNIQUE (community_id, name)
+);
+
+CREATE TABLE community_role_members (
+  role_id BIGINT not null references community_roles(id) ON DELETE CASCADE,
+  addr VARCHAR(18) not null,
+  created_at TIMESTAMP without time zone default (now() at time zone 'utc'),
+  PRIMARY KEY (role_id, addr)
+);
+
+CREATE INDEX community_role_members_addr_idx ON community_role_members(addr);
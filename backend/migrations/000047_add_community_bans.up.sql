@@ -0,0 +1,9 @@
+CREATE TABLE community_bans (
+  id BIGSERIAL primary key,
+  community_id INT not null references communities(id) ON DELETE CASCADE,
+  addr VARCHAR(18) not null,
+  reason TEXT,
+  banned_by VARCHAR(18) not null,
+  created_at TIMESTAMP without time zone default (now() at time zone 'utc'),
+  UNIQUE (community_id, addr)
+);
@@ -0,0 +1,8 @@
+ALTER TABLE proposals DROP COLUMN IF EXISTS reviewed_at;
+ALTER TABLE proposals DROP COLUMN IF EXISTS reviewed_by;
+ALTER TABLE proposals DROP COLUMN IF EXISTS review_reason;
+
+ALTER TABLE communities DROP COLUMN IF EXISTS require_proposal_review;
+
+-- enum values cannot be dropped, move affected proposals to a known status
+UPDATE proposals SET status = 'cancelled' WHERE status IN ('pending_review', 'rejected');
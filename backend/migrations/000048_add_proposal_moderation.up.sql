@@ -0,0 +1,8 @@
+ALTER TYPE statuses ADD VALUE 'pending_review';
+ALTER TYPE statuses ADD VALUE 'rejected';
+
+ALTER TABLE communities ADD COLUMN require_proposal_review BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE proposals ADD COLUMN review_reason TEXT;
+ALTER TABLE proposals ADD COLUMN reviewed_by VARCHAR(18);
+ALTER TABLE proposals ADD COLUMN reviewed_at TIMESTAMP without time zone;
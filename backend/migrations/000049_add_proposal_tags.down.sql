@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS proposals_tags_idx;
+ALTER TABLE proposals DROP COLUMN IF EXISTS tags;
+DROP TABLE IF EXISTS community_tags;
@@ -0,0 +1,11 @@
+CREATE TABLE community_tags (
+  id BIGSERIAL primary key,
+  community_id INT not null references communities(id) ON DELETE CASCADE,
+  name VARCHAR(32) not null,
+  created_at TIMESTAMP without time zone default (now() at time zone 'utc'),
+  UNIQUE (community_id, name)
+);
+
+ALTER TABLE proposals ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX proposals_tags_idx ON proposals USING GIN (tags);
@@ -0,0 +1,18 @@
+CREATE TABLE community_events (
+  id BIGSERIAL primary key,
+  community_id INT not null references communities(id) ON DELETE CASCADE,
+  event_type VARCHAR(32) not null,
+  actor_addr VARCHAR(18),
+  proposal_id INT references proposals(id) ON DELETE CASCADE,
+  data jsonb not null default '{}',
+  milestone INT,
+  created_at TIMESTAMP without time zone default (now() at time zone 'utc')
+);
+
+CREATE INDEX community_events_feed_idx ON community_events(community_id, created_at DESC);
+
+-- closing and vote milestones are recorded lazily, keep them idempotent
+CREATE UNIQUE INDEX community_events_proposal_closed_idx
+  ON community_events(proposal_id) WHERE event_type = 'proposal_closed';
+CREATE UNIQUE INDEX community_events_vote_milestone_idx
+  ON community_events(proposal_id, milestone) WHERE event_type = 'vote_milestone';
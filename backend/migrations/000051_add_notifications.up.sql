@@ -0,0 +1,20 @@
+CREATE TABLE notifications (
+  id BIGSERIAL primary key,
+  addr VARCHAR(18) not null,
+  notification_type VARCHAR(32) not null,
+  community_id INT references communities(id) ON DELETE CASCADE,
+  proposal_id INT references proposals(id) ON DELETE CASCADE,
+  event_id BIGINT references community_events(id) ON DELETE CASCADE,
+  data jsonb not null default '{}',
+  read_at TIMESTAMP without time zone,
+  created_at TIMESTAMP without time zone default (now() at time zone 'utc')
+);
+
+CREATE INDEX notifications_inbox_idx ON notifications(addr, created_at DESC);
+
+CREATE TABLE notification_preferences (
+  addr VARCHAR(18) not null,
+  notification_type VARCHAR(32) not null,
+  enabled BOOLEAN not null default TRUE,
+  PRIMARY KEY (addr, notification_type)
+);
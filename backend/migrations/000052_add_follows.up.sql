@@ -0,0 +1,13 @@
+CREATE TABLE follows (
+  id BIGSERIAL primary key,
+  follower_addr VARCHAR(18) not null,
+  community_id INT references communities(id) ON DELETE CASCADE,
+  followed_addr VARCHAR(18),
+  created_at TIMESTAMP without time zone default (now() at time zone 'utc'),
+  CHECK ((community_id IS NULL) <> (followed_addr IS NULL))
+);
+
+CREATE UNIQUE INDEX follows_community_idx ON follows(follower_addr, community_id) WHERE community_id IS NOT NULL;
+CREATE UNIQUE INDEX follows_addr_idx ON follows(follower_addr, followed_addr) WHERE followed_addr IS NOT NULL;
+CREATE INDEX follows_followed_community_idx ON follows(community_id);
+CREATE INDEX follows_followed_addr_idx ON follows(followed_addr);
@@ -0,0 +1,9 @@
+CREATE TABLE user_profiles (
+  addr VARCHAR(18) primary key,
+  display_name VARCHAR(64),
+  avatar VARCHAR(256),
+  bio TEXT,
+  socials jsonb not null default '{}',
+  created_at TIMESTAMP without time zone default (now() at time zone 'utc'),
+  updated_at TIMESTAMP without time zone default (now() at time zone 'utc')
+);
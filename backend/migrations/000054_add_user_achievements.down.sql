@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS user_achievements;
+DROP TYPE IF EXISTS achievement_types;
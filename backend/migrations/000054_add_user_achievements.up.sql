@@ -0,0 +1,14 @@
+CREATE TYPE achievement_types AS enum ('firstVote', 'earlyVote', 'streak', 'winningVote', 'proposalAuthor');
+
+CREATE TABLE user_achievements (
+  id BIGSERIAL primary key,
+  addr VARCHAR(18) NOT NULL,
+  achievement_type achievement_types NOT NULL,
+  community_id INT NOT NULL references communities(id) ON DELETE CASCADE,
+  proposals BIGINT array,
+  details VARCHAR NOT NULL UNIQUE,
+  created_at timestamp with time zone NOT NULL DEFAULT now(),
+  updated_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE INDEX user_achievements_addr_idx ON user_achievements(addr);
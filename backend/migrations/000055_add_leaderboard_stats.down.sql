@@ -0,0 +1,2 @@
+DROP MATERIALIZED VIEW IF EXISTS leaderboard_stats;
+ALTER TABLE user_achievements DROP COLUMN IF EXISTS points;
@@ -0,0 +1,42 @@
+ALTER TABLE user_achievements ADD COLUMN points INT NOT NULL DEFAULT 1;
+UPDATE user_achievements SET points = 2 WHERE achievement_type = 'proposalAuthor';
+
+-- Per address activity in each leaderboard window, refreshed by a background job.
+CREATE MATERIALIZED VIEW leaderboard_stats AS
+WITH windows(time_window, since) AS (
+  VALUES
+    ('30d', (now() at time zone 'utc') - interval '30 days'),
+    ('90d', (now() at time zone 'utc') - interval '90 days'),
+    ('all', '-infinity'::timestamp)
+),
+activity AS (
+  SELECT p.community_id, v.addr, v.created_at,
+    1 AS votes, 0 AS proposals, 0 AS points, 0 AS bonus
+  FROM votes v
+  JOIN proposals p ON p.id = v.proposal_id
+  WHERE v.is_cancelled != 'true'
+  UNION ALL
+  SELECT community_id, creator_addr, created_at,
+    0, 1, 0, 0
+  FROM proposals
+  WHERE status NOT IN ('cancelled', 'pending_review', 'rejected')
+  UNION ALL
+  SELECT community_id, addr, created_at at time zone 'utc',
+    0, 0, points,
+    CASE WHEN achievement_type IN ('earlyVote', 'streak', 'winningVote') THEN 1 ELSE 0 END
+  FROM user_achievements
+)
+SELECT
+  a.community_id,
+  a.addr,
+  w.time_window,
+  SUM(a.votes)::int AS votes_cast,
+  SUM(a.proposals)::int AS proposals_authored,
+  SUM(a.points)::int AS achievement_points,
+  (SUM(a.votes) + SUM(a.bonus))::int AS score,
+  now() AS refreshed_at
+FROM activity a
+JOIN windows w ON a.created_at >= w.since
+GROUP BY a.community_id, a.addr, w.time_window;
+
+CREATE UNIQUE INDEX leaderboard_stats_idx ON leaderboard_stats(community_id, time_window, addr);
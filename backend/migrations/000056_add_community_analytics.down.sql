@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS community_stats;
+DROP TABLE IF EXISTS proposal_stats;
+ALTER TABLE community_users DROP COLUMN IF EXISTS created_at;
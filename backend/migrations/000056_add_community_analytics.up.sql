@@ -0,0 +1,30 @@
+-- existing memberships keep a NULL join date
+ALTER TABLE community_users ADD COLUMN created_at TIMESTAMP without time zone;
+ALTER TABLE community_users ALTER COLUMN created_at SET DEFAULT (now() at time zone 'utc');
+
+CREATE TABLE proposal_stats (
+  proposal_id INT primary key references proposals(id) ON DELETE CASCADE,
+  community_id INT not null references communities(id) ON DELETE CASCADE,
+  strategy VARCHAR(64),
+  unique_voters INT not null default 0,
+  total_weight DOUBLE PRECISION not null default 0,
+  gini DOUBLE PRECISION not null default 0,
+  is_final BOOLEAN not null default false,
+  end_time TIMESTAMP without time zone,
+  updated_at TIMESTAMP without time zone default (now() at time zone 'utc')
+);
+
+CREATE INDEX proposal_stats_community_id_idx ON proposal_stats(community_id, end_time);
+
+CREATE TABLE community_stats (
+  community_id INT not null references communities(id) ON DELETE CASCADE,
+  period DATE not null,
+  proposals INT not null default 0,
+  votes INT not null default 0,
+  unique_voters INT not null default 0,
+  new_members INT not null default 0,
+  members INT not null default 0,
+  participation_rate DOUBLE PRECISION not null default 0,
+  updated_at TIMESTAMP without time zone default (now() at time zone 'utc'),
+  PRIMARY KEY (community_id, period)
+);
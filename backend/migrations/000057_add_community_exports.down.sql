@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS community_exports;
+DROP TYPE IF EXISTS export_statuses;
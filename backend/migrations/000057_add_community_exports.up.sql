@@ -0,0 +1,16 @@
+CREATE TYPE export_statuses AS enum ('pending', 'running', 'completed', 'failed');
+
+CREATE TABLE community_exports (
+  id BIGSERIAL primary key,
+  community_id INT not null references communities(id) ON DELETE CASCADE,
+  requested_by VARCHAR(18) not null,
+  status export_statuses not null default 'pending',
+  error TEXT,
+  archive BYTEA,
+  size INT not null default 0,
+  created_at TIMESTAMP without time zone default (now() at time zone 'utc'),
+  started_at TIMESTAMP without time zone,
+  completed_at TIMESTAMP without time zone
+);
+
+CREATE INDEX community_exports_status_idx ON community_exports(status);
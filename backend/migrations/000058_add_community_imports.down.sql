@@ -0,0 +1,3 @@
+ALTER TABLE proposal_results DROP COLUMN IF EXISTS results_float;
+ALTER TABLE proposals DROP COLUMN IF EXISTS imported_from_id;
+ALTER TABLE communities DROP COLUMN IF EXISTS imported_at;
@@ -0,0 +1,3 @@
+ALTER TABLE communities ADD COLUMN imported_at TIMESTAMP without time zone;
+ALTER TABLE proposals ADD COLUMN imported_from_id BIGINT;
+ALTER TABLE proposal_results ADD COLUMN results_float JSON;
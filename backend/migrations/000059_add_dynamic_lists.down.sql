@@ -0,0 +1,2 @@
+ALTER TABLE lists DROP COLUMN IF EXISTS materialized_at;
+ALTER TABLE lists DROP COLUMN IF EXISTS rule;
@@ -0,0 +1,2 @@
+ALTER TABLE lists ADD COLUMN rule JSONB;
+ALTER TABLE lists ADD COLUMN materialized_at TIMESTAMP without time zone;
@@ -0,0 +1,3 @@
+ALTER TABLE proposals DROP COLUMN IF EXISTS list_versions;
+DROP TABLE IF EXISTS list_versions;
+ALTER TABLE lists DROP COLUMN IF EXISTS version;
@@ -0,0 +1,17 @@
+ALTER TABLE lists ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+CREATE TABLE list_versions (
+    list_id BIGINT NOT NULL REFERENCES lists(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    addresses varchar array NOT NULL DEFAULT '{}',
+    cid VARCHAR(64),
+    created_at TIMESTAMP without time zone NOT NULL DEFAULT (now() at time zone 'utc'),
+    PRIMARY KEY (list_id, version)
+);
+
+INSERT INTO list_versions(list_id, version, addresses, cid, created_at)
+SELECT id, 1, COALESCE(addresses, '{}'), cid, COALESCE(created_at, (now() at time zone 'utc'))
+FROM lists;
+
+-- list id => version each proposal is bound to
+ALTER TABLE proposals ADD COLUMN list_versions JSONB;
@@ -0,0 +1 @@
+ALTER TABLE lists ADD COLUMN name VARCHAR(255);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS proposals_pending_close_idx;
+ALTER TABLE proposals DROP COLUMN IF EXISTS closed_at;
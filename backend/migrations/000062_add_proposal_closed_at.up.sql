@@ -0,0 +1,2 @@
+ALTER TABLE proposals ADD COLUMN closed_at TIMESTAMP without time zone;
+CREATE INDEX IF NOT EXISTS proposals_pending_close_idx ON proposals(end_time) WHERE status = 'published';
@@ -0,0 +1,2 @@
+ALTER TABLE communities ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE proposals ADD COLUMN version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,4 @@
+ALTER TABLE communities DROP COLUMN IF EXISTS proposal_time_zones;
+ALTER TABLE communities DROP COLUMN IF EXISTS min_proposal_lead_time;
+ALTER TABLE communities DROP COLUMN IF EXISTS max_proposal_duration;
+ALTER TABLE communities DROP COLUMN IF EXISTS min_proposal_duration;
@@ -0,0 +1,4 @@
+ALTER TABLE communities ADD COLUMN min_proposal_duration INT;
+ALTER TABLE communities ADD COLUMN max_proposal_duration INT;
+ALTER TABLE communities ADD COLUMN min_proposal_lead_time INT;
+ALTER TABLE communities ADD COLUMN proposal_time_zones TEXT[];
@@ -0,0 +1 @@
+ALTER TABLE communities DROP COLUMN IF EXISTS timezone;
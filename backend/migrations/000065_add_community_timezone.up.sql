@@ -0,0 +1 @@
+ALTER TABLE communities ADD COLUMN timezone TEXT;
@@ -0,0 +1,11 @@
+CREATE TABLE weight_cache (
+  addr VARCHAR(18) NOT NULL,
+  strategy VARCHAR NOT NULL,
+  block_height BIGINT NOT NULL,
+  primary_account_balance BIGINT NOT NULL DEFAULT 0,
+  secondary_account_balance BIGINT NOT NULL DEFAULT 0,
+  staking_balance BIGINT NOT NULL DEFAULT 0,
+  balance BIGINT NOT NULL DEFAULT 0,
+  created_at timestamp with time zone NOT NULL DEFAULT now(),
+  PRIMARY KEY (addr, strategy, block_height)
+);
@@ -0,0 +1,7 @@
+CREATE TABLE proposal_tally_progress (
+  proposal_id INT PRIMARY KEY references proposals(id) ON DELETE CASCADE,
+  last_vote_id INT NOT NULL DEFAULT 0,
+  results JSON NOT NULL,
+  results_float JSON NOT NULL,
+  updated_at timestamp with time zone NOT NULL DEFAULT now()
+);
@@ -0,0 +1,4 @@
+ALTER TABLE proposal_tally_progress DROP COLUMN IF EXISTS votes_counted;
+
+ALTER TABLE proposal_results DROP COLUMN IF EXISTS votes_counted;
+ALTER TABLE proposal_results DROP CONSTRAINT IF EXISTS proposal_results_pkey;
@@ -0,0 +1,9 @@
+DELETE FROM proposal_results WHERE ctid NOT IN (
+  SELECT DISTINCT ON (proposal_id) ctid FROM proposal_results
+  ORDER BY proposal_id, updated_at DESC NULLS LAST
+);
+
+ALTER TABLE proposal_results ADD PRIMARY KEY (proposal_id);
+ALTER TABLE proposal_results ADD COLUMN votes_counted INT NOT NULL DEFAULT 0;
+
+ALTER TABLE proposal_tally_progress ADD COLUMN votes_counted INT NOT NULL DEFAULT 0;
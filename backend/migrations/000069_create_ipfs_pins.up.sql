@@ -0,0 +1,14 @@
+CREATE TABLE ipfs_pins (
+  record_type VARCHAR(32) NOT NULL,
+  record_id INT NOT NULL,
+  status VARCHAR(16) NOT NULL DEFAULT 'pending',
+  attempts INT NOT NULL DEFAULT 0,
+  last_error TEXT,
+  cid VARCHAR(64),
+  requested_at timestamp with time zone NOT NULL DEFAULT now(),
+  next_attempt_at timestamp with time zone NOT NULL DEFAULT now(),
+  pinned_at timestamp with time zone,
+  PRIMARY KEY (record_type, record_id)
+);
+
+CREATE INDEX ipfs_pins_status_idx ON ipfs_pins(status, next_attempt_at);
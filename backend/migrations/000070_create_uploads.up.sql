@@ -0,0 +1,11 @@
+CREATE TABLE uploads (
+  id BIGSERIAL PRIMARY KEY,
+  content_hash VARCHAR(64) NOT NULL,
+  driver VARCHAR(16) NOT NULL,
+  key VARCHAR(128) NOT NULL,
+  url TEXT NOT NULL,
+  content_type VARCHAR(128) NOT NULL,
+  size BIGINT NOT NULL,
+  created_at timestamp with time zone NOT NULL DEFAULT now(),
+  UNIQUE (driver, content_hash)
+);
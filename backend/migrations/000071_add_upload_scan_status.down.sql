@@ -0,0 +1,4 @@
+ALTER TABLE uploads
+  DROP COLUMN IF EXISTS status,
+  DROP COLUMN IF EXISTS scan_result,
+  DROP COLUMN IF EXISTS scanned_at;
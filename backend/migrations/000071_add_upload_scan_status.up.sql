@@ -0,0 +1,4 @@
+ALTER TABLE uploads
+  ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'unscanned',
+  ADD COLUMN scan_result TEXT,
+  ADD COLUMN scanned_at timestamp with time zone;
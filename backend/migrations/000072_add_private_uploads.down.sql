@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS uploads_key_idx;
+DROP INDEX IF EXISTS uploads_content_idx;
+DELETE FROM uploads WHERE private;
+ALTER TABLE uploads ADD CONSTRAINT uploads_driver_content_hash_key UNIQUE (driver, content_hash);
+ALTER TABLE uploads
+  DROP COLUMN IF EXISTS private,
+  DROP COLUMN IF EXISTS community_id;
@@ -0,0 +1,9 @@
+ALTER TABLE uploads
+  ADD COLUMN private BOOLEAN NOT NULL DEFAULT false,
+  ADD COLUMN community_id INT REFERENCES communities(id) ON DELETE CASCADE;
+
+-- private uploads belong to a community, so the same file may be uploaded
+-- once publicly and once privately for each community
+ALTER TABLE uploads DROP CONSTRAINT uploads_driver_content_hash_key;
+CREATE UNIQUE INDEX uploads_content_idx ON uploads(driver, content_hash, private, COALESCE(community_id, 0));
+CREATE INDEX uploads_key_idx ON uploads(driver, key);
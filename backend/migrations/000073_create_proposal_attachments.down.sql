@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS proposal_attachments;
+ALTER TABLE uploads DROP COLUMN IF EXISTS cid;
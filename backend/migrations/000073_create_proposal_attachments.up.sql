@@ -0,0 +1,12 @@
+ALTER TABLE uploads ADD COLUMN cid VARCHAR(64);
+UPDATE uploads SET cid = key WHERE driver = 'ipfs';
+
+CREATE TABLE proposal_attachments (
+  id SERIAL PRIMARY KEY,
+  proposal_id INT NOT NULL REFERENCES proposals(id) ON DELETE CASCADE,
+  upload_id BIGINT NOT NULL REFERENCES uploads(id),
+  name VARCHAR(256) NOT NULL,
+  position INT NOT NULL,
+  created_at timestamp with time zone NOT NULL DEFAULT now(),
+  UNIQUE (proposal_id, upload_id)
+);
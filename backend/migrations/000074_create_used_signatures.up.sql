@@ -0,0 +1,7 @@
+CREATE TABLE used_signatures (
+  message_hash VARCHAR(64) PRIMARY KEY,
+  addr VARCHAR(18) NOT NULL,
+  used_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE INDEX used_signatures_used_at_idx ON used_signatures(used_at);
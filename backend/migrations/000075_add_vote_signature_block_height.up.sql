@@ -0,0 +1 @@
+ALTER TABLE votes ADD COLUMN signature_block_height BIGINT;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS job_failures;
+DROP TABLE IF EXISTS platform_blocklist;
+ALTER TABLE communities
+  DROP COLUMN IF EXISTS suspended_at,
+  DROP COLUMN IF EXISTS suspension_reason;
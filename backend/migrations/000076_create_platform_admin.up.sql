@@ -0,0 +1,19 @@
+ALTER TABLE communities
+  ADD COLUMN suspended_at TIMESTAMP,
+  ADD COLUMN suspension_reason TEXT;
+
+CREATE TABLE platform_blocklist (
+  addr VARCHAR(18) PRIMARY KEY,
+  reason TEXT,
+  added_by VARCHAR(18) NOT NULL,
+  created_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE TABLE job_failures (
+  id SERIAL PRIMARY KEY,
+  job VARCHAR(64) NOT NULL,
+  error TEXT NOT NULL,
+  failed_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE INDEX job_failures_failed_at_idx ON job_failures(failed_at);
@@ -0,0 +1,6 @@
+CREATE TABLE platform_admins (
+  addr VARCHAR(18) PRIMARY KEY,
+  reason TEXT,
+  added_by VARCHAR(18),
+  created_at timestamp with time zone NOT NULL DEFAULT now()
+);
@@ -0,0 +1,19 @@
+CREATE TABLE feature_flags (
+  name VARCHAR(64) PRIMARY KEY,
+  description TEXT,
+  rollout_percent INT NOT NULL DEFAULT 0 CHECK (rollout_percent BETWEEN 0 AND 100),
+  updated_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE TABLE community_feature_flags (
+  community_id INT NOT NULL REFERENCES communities(id) ON DELETE CASCADE,
+  flag VARCHAR(64) NOT NULL REFERENCES feature_flags(name) ON DELETE CASCADE,
+  enabled BOOLEAN NOT NULL,
+  updated_at timestamp with time zone NOT NULL DEFAULT now(),
+  PRIMARY KEY (community_id, flag)
+);
+
+INSERT INTO feature_flags(name, description) VALUES
+  ('comments', 'Comments on proposals'),
+  ('shielded-voting', 'Votes hidden until a proposal closes'),
+  ('delegation', 'Delegating voting power to another address');
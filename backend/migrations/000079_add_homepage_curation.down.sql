@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS homepage_sections;
+ALTER TABLE communities
+  DROP COLUMN IF EXISTS homepage_pinned,
+  DROP COLUMN IF EXISTS homepage_order;
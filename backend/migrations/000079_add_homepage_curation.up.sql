@@ -0,0 +1,18 @@
+ALTER TABLE communities
+  ADD COLUMN homepage_pinned BOOLEAN NOT NULL DEFAULT false,
+  ADD COLUMN homepage_order INT;
+
+CREATE TABLE homepage_sections (
+  id SERIAL PRIMARY KEY,
+  title VARCHAR(128) NOT NULL,
+  kind VARCHAR(32) NOT NULL,
+  category VARCHAR(256),
+  display_order INT NOT NULL DEFAULT 0,
+  size INT NOT NULL DEFAULT 6,
+  created_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+INSERT INTO homepage_sections(title, kind, display_order) VALUES
+  ('Featured', 'featured', 0),
+  ('Trending', 'trending', 1),
+  ('New', 'new', 2);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS proposal_trending_scores;
+DROP TABLE IF EXISTS community_trending_scores;
@@ -0,0 +1,14 @@
+CREATE TABLE community_trending_scores (
+  community_id INT PRIMARY KEY REFERENCES communities(id) ON DELETE CASCADE,
+  score DOUBLE PRECISION NOT NULL,
+  computed_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE TABLE proposal_trending_scores (
+  proposal_id INT PRIMARY KEY REFERENCES proposals(id) ON DELETE CASCADE,
+  score DOUBLE PRECISION NOT NULL,
+  computed_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE INDEX community_trending_scores_score_idx ON community_trending_scores(score DESC);
+CREATE INDEX proposal_trending_scores_score_idx ON proposal_trending_scores(score DESC);
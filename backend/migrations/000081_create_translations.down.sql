@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS proposal_translations;
+DROP TABLE IF EXISTS community_translations;
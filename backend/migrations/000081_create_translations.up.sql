@@ -0,0 +1,17 @@
+CREATE TABLE community_translations (
+  community_id INT NOT NULL REFERENCES communities(id) ON DELETE CASCADE,
+  locale VARCHAR(35) NOT NULL,
+  name VARCHAR(256),
+  body TEXT,
+  updated_at timestamp with time zone NOT NULL DEFAULT now(),
+  PRIMARY KEY (community_id, locale)
+);
+
+CREATE TABLE proposal_translations (
+  proposal_id INT NOT NULL REFERENCES proposals(id) ON DELETE CASCADE,
+  locale VARCHAR(35) NOT NULL,
+  name VARCHAR(256),
+  body TEXT,
+  updated_at timestamp with time zone NOT NULL DEFAULT now(),
+  PRIMARY KEY (proposal_id, locale)
+);
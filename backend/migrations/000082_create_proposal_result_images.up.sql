@@ -0,0 +1,7 @@
+CREATE TABLE proposal_result_images (
+  proposal_id INT PRIMARY KEY REFERENCES proposals(id) ON DELETE CASCADE,
+  milestone INT NOT NULL,
+  computed_status VARCHAR(32) NOT NULL,
+  png BYTEA NOT NULL,
+  rendered_at timestamp with time zone NOT NULL DEFAULT now()
+);
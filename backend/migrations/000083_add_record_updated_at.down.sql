@@ -0,0 +1,2 @@
+ALTER TABLE proposals DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE communities DROP COLUMN IF EXISTS updated_at;
@@ -0,0 +1,5 @@
+ALTER TABLE communities ADD COLUMN updated_at TIMESTAMP without time zone default (now() at time zone 'utc');
+ALTER TABLE proposals ADD COLUMN updated_at TIMESTAMP without time zone default (now() at time zone 'utc');
+
+UPDATE communities SET updated_at = COALESCE(archived_at, imported_at, created_at, updated_at);
+UPDATE proposals SET updated_at = COALESCE(closed_at, reviewed_at, created_at, updated_at);
@@ -0,0 +1,9 @@
+CREATE TABLE proposal_cohosts (
+  proposal_id INT NOT NULL REFERENCES proposals(id) ON DELETE CASCADE,
+  community_id INT NOT NULL REFERENCES communities(id) ON DELETE CASCADE,
+  strategy VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP without time zone default (now() at time zone 'utc'),
+  PRIMARY KEY (proposal_id, community_id)
+);
+
+CREATE INDEX proposal_cohosts_community_id_idx ON proposal_cohosts(community_id);
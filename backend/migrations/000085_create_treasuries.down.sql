@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS proposal_treasury_snapshots;
+DROP TABLE IF EXISTS community_treasuries;
@@ -0,0 +1,22 @@
+CREATE TABLE community_treasuries (
+  id SERIAL PRIMARY KEY,
+  community_id INT NOT NULL REFERENCES communities(id) ON DELETE CASCADE,
+  name VARCHAR(64) NOT NULL,
+  addr VARCHAR(18) NOT NULL,
+  contract_name VARCHAR(255) NOT NULL,
+  contract_addr VARCHAR(18) NOT NULL,
+  public_path VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP without time zone default (now() at time zone 'utc'),
+  UNIQUE (community_id, addr, contract_name)
+);
+
+CREATE TABLE proposal_treasury_snapshots (
+  proposal_id INT NOT NULL REFERENCES proposals(id) ON DELETE CASCADE,
+  name VARCHAR(64) NOT NULL,
+  addr VARCHAR(18) NOT NULL,
+  contract_name VARCHAR(255) NOT NULL,
+  contract_addr VARCHAR(18) NOT NULL,
+  balance DOUBLE PRECISION NOT NULL,
+  block_height BIGINT NOT NULL,
+  PRIMARY KEY (proposal_id, addr, contract_name)
+);
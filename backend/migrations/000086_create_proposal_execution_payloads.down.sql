@@ -0,0 +1 @@
+DROP TABLE IF EXISTS proposal_execution_payloads;
@@ -0,0 +1,7 @@
+CREATE TABLE proposal_execution_payloads (
+  proposal_id INT PRIMARY KEY REFERENCES proposals(id) ON DELETE CASCADE,
+  choice TEXT NOT NULL,
+  cadence TEXT NOT NULL,
+  arguments JSONB NOT NULL DEFAULT '[]',
+  created_at TIMESTAMP without time zone default (now() at time zone 'utc')
+);
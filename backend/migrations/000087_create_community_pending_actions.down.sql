@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS community_action_approvals;
+DROP TABLE IF EXISTS community_pending_actions;
+ALTER TABLE communities DROP COLUMN IF EXISTS admin_approvals_required;
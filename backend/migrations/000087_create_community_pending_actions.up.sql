@@ -0,0 +1,22 @@
+ALTER TABLE communities ADD COLUMN admin_approvals_required INT NOT NULL DEFAULT 1;
+
+CREATE TABLE community_pending_actions (
+  id SERIAL PRIMARY KEY,
+  community_id INT NOT NULL REFERENCES communities(id) ON DELETE CASCADE,
+  action VARCHAR(32) NOT NULL,
+  payload JSONB NOT NULL DEFAULT '{}',
+  proposed_by VARCHAR(18) NOT NULL,
+  status VARCHAR(16) NOT NULL DEFAULT 'pending',
+  created_at TIMESTAMP without time zone default (now() at time zone 'utc'),
+  expires_at TIMESTAMP without time zone NOT NULL,
+  resolved_at TIMESTAMP without time zone
+);
+
+CREATE INDEX community_pending_actions_community_id_idx ON community_pending_actions(community_id, status);
+
+CREATE TABLE community_action_approvals (
+  action_id INT NOT NULL REFERENCES community_pending_actions(id) ON DELETE CASCADE,
+  addr VARCHAR(18) NOT NULL,
+  created_at TIMESTAMP without time zone default (now() at time zone 'utc'),
+  PRIMARY KEY (action_id, addr)
+);
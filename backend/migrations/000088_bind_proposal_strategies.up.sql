@@ -0,0 +1,7 @@
+ALTER TABLE proposals ADD COLUMN strategy_config JSONB;
+
+-- existing proposals are bound to the strategy their community has now
+UPDATE proposals p SET strategy_config = s.config
+FROM communities c, jsonb_array_elements(c.strategies) AS s(config)
+WHERE c.id = p.community_id
+  AND s.config->>'name' = p.strategy;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS community_strategy_versions;
+ALTER TABLE proposals DROP COLUMN IF EXISTS strategy_version;
+ALTER TABLE communities DROP COLUMN IF EXISTS strategies_version;
@@ -0,0 +1,20 @@
+ALTER TABLE communities ADD COLUMN strategies_version INT NOT NULL DEFAULT 0;
+ALTER TABLE proposals ADD COLUMN strategy_version INT;
+
+CREATE TABLE community_strategy_versions (
+  community_id INT NOT NULL REFERENCES communities(id) ON DELETE CASCADE,
+  version INT NOT NULL,
+  strategies JSONB,
+  created_by VARCHAR(18),
+  created_at TIMESTAMP without time zone default (now() at time zone 'utc'),
+  PRIMARY KEY (community_id, version)
+);
+
+-- the strategies communities have now are their first version, which
+-- existing proposals were bound to
+INSERT INTO community_strategy_versions(community_id, version, strategies)
+SELECT id, 1, strategies FROM communities;
+
+UPDATE communities SET strategies_version = 1;
+
+UPDATE proposals SET strategy_version = 1 WHERE strategy_config IS NOT NULL;
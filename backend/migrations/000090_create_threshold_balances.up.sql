@@ -0,0 +1,10 @@
+CREATE TABLE threshold_balances (
+  addr VARCHAR(18) NOT NULL,
+  contract VARCHAR NOT NULL,
+  block_height BIGINT NOT NULL,
+  balance DOUBLE PRECISION NOT NULL,
+  created_at TIMESTAMP without time zone default (now() at time zone 'utc'),
+  PRIMARY KEY (addr, contract, block_height)
+);
+
+CREATE INDEX threshold_balances_created_at_idx ON threshold_balances(addr, contract, created_at);
@@ -0,0 +1,13 @@
+CREATE TABLE community_tokens (
+  id SERIAL PRIMARY KEY,
+  community_id INT NOT NULL REFERENCES communities(id) ON DELETE CASCADE,
+  contract_addr VARCHAR(18) NOT NULL,
+  contract_name VARCHAR(64) NOT NULL,
+  vault_path VARCHAR(128) NOT NULL,
+  receiver_path VARCHAR(128) NOT NULL,
+  balance_path VARCHAR(128) NOT NULL,
+  decimals INT NOT NULL DEFAULT 8,
+  created_by VARCHAR(18) NOT NULL,
+  created_at TIMESTAMP without time zone default (now() at time zone 'utc'),
+  UNIQUE (community_id, contract_addr, contract_name)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE community_tokens DROP COLUMN IF EXISTS logo_uri;
+ALTER TABLE community_tokens DROP COLUMN IF EXISTS name;
+ALTER TABLE community_tokens DROP COLUMN IF EXISTS symbol;
@@ -0,0 +1,3 @@
+ALTER TABLE community_tokens ADD COLUMN symbol VARCHAR(16) NOT NULL DEFAULT '';
+ALTER TABLE community_tokens ADD COLUMN name VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE community_tokens ADD COLUMN logo_uri VARCHAR(512) NOT NULL DEFAULT '';
@@ -0,0 +1,8 @@
+DELETE FROM feature_flags WHERE name = 'onchain-voting';
+
+DROP TABLE IF EXISTS chain_event_cursors;
+
+DROP INDEX IF EXISTS votes_onchain_event_idx;
+ALTER TABLE votes DROP COLUMN IF EXISTS event_index;
+ALTER TABLE votes DROP COLUMN IF EXISTS tx_id;
+ALTER TABLE votes DROP COLUMN IF EXISTS source;
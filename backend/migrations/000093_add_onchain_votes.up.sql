@@ -0,0 +1,13 @@
+ALTER TABLE votes ADD COLUMN source VARCHAR(16) NOT NULL DEFAULT 'offchain';
+ALTER TABLE votes ADD COLUMN tx_id VARCHAR(64);
+ALTER TABLE votes ADD COLUMN event_index INT;
+CREATE UNIQUE INDEX votes_onchain_event_idx ON votes(proposal_id, tx_id, event_index) WHERE tx_id IS NOT NULL;
+
+CREATE TABLE chain_event_cursors (
+  event_type VARCHAR(256) PRIMARY KEY,
+  block_height BIGINT NOT NULL,
+  updated_at TIMESTAMP without time zone default (now() at time zone 'utc')
+);
+
+INSERT INTO feature_flags(name, description) VALUES
+  ('onchain-voting', 'Votes cast on chain with the CASTVoting contract');
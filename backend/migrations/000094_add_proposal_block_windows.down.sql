@@ -0,0 +1,2 @@
+ALTER TABLE proposals DROP COLUMN IF EXISTS end_block;
+ALTER TABLE proposals DROP COLUMN IF EXISTS start_block;
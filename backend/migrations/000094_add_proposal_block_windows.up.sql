@@ -0,0 +1,2 @@
+ALTER TABLE proposals ADD COLUMN start_block BIGINT;
+ALTER TABLE proposals ADD COLUMN end_block BIGINT;
@@ -0,0 +1,3 @@
+ALTER TABLE homepage_sections DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE communities DROP COLUMN IF EXISTS tenant_id;
+DROP TABLE IF EXISTS tenants;
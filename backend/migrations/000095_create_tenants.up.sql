@@ -0,0 +1,20 @@
+CREATE TABLE tenants (
+  id SERIAL PRIMARY KEY,
+  slug VARCHAR(64) NOT NULL UNIQUE,
+  name VARCHAR(128) NOT NULL,
+  hostnames TEXT[] NOT NULL DEFAULT '{}',
+  branding JSONB NOT NULL DEFAULT '{}',
+  admin_addrs VARCHAR(18)[] NOT NULL DEFAULT '{}',
+  created_at TIMESTAMP without time zone default (now() at time zone 'utc'),
+  updated_at TIMESTAMP without time zone default (now() at time zone 'utc')
+);
+
+-- everything created before tenancy belongs to the default tenant
+INSERT INTO tenants(id, slug, name) VALUES (1, 'default', 'CAST');
+SELECT setval('tenants_id_seq', 1);
+
+ALTER TABLE communities ADD COLUMN tenant_id INT NOT NULL DEFAULT 1 REFERENCES tenants(id);
+ALTER TABLE homepage_sections ADD COLUMN tenant_id INT NOT NULL DEFAULT 1 REFERENCES tenants(id);
+
+CREATE INDEX communities_tenant_id_idx ON communities(tenant_id);
+CREATE INDEX homepage_sections_tenant_id_idx ON homepage_sections(tenant_id);
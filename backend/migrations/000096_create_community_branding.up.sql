@@ -0,0 +1,8 @@
+CREATE TABLE community_branding (
+  community_id INT PRIMARY KEY REFERENCES communities(id) ON DELETE CASCADE,
+  custom_domain VARCHAR(253) UNIQUE,
+  logos JSONB NOT NULL DEFAULT '{}',
+  theme JSONB NOT NULL DEFAULT '{}',
+  social_links JSONB NOT NULL DEFAULT '{}',
+  updated_at TIMESTAMP without time zone default (now() at time zone 'utc')
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS vote_reputation;
+DROP TABLE IF EXISTS reputation_scores;
+DROP TABLE IF EXISTS reputation_settings;
@@ -0,0 +1,28 @@
+CREATE TABLE reputation_settings (
+  community_id INT PRIMARY KEY REFERENCES communities(id) ON DELETE CASCADE,
+  participation_weight FLOAT8 NOT NULL,
+  authorship_weight FLOAT8 NOT NULL,
+  tenure_weight FLOAT8 NOT NULL,
+  authorship_target INT NOT NULL,
+  tenure_days INT NOT NULL,
+  updated_at TIMESTAMP without time zone default (now() at time zone 'utc')
+);
+
+CREATE TABLE reputation_scores (
+  community_id INT NOT NULL REFERENCES communities(id) ON DELETE CASCADE,
+  addr VARCHAR(18) NOT NULL,
+  participation FLOAT8 NOT NULL,
+  authorship FLOAT8 NOT NULL,
+  tenure FLOAT8 NOT NULL,
+  score FLOAT8 NOT NULL,
+  computed_at TIMESTAMP without time zone default (now() at time zone 'utc'),
+  PRIMARY KEY (community_id, addr)
+);
+
+CREATE TABLE vote_reputation (
+  proposal_id INT NOT NULL REFERENCES proposals(id) ON DELETE CASCADE,
+  addr VARCHAR(18) NOT NULL,
+  score FLOAT8 NOT NULL,
+  created_at TIMESTAMP without time zone default (now() at time zone 'utc'),
+  PRIMARY KEY (proposal_id, addr)
+);
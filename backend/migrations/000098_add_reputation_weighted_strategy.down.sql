@@ -0,0 +1 @@
+DELETE FROM voting_strategies WHERE key ='reputation-weighted';
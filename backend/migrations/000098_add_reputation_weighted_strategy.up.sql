@@ -0,0 +1,7 @@
+BEGIN;
+ALTER TYPE strategies ADD VALUE IF NOT EXISTS 'reputation-weighted';
+END TRANSACTION;
+COMMIT;
+
+INSERT INTO voting_strategies (key, name, description)
+VALUES ('reputation-weighted', 'Reputation-Weighted', 'A vote weighs the reputation the voter has in the community when they vote, from 0 to 100.');
@@ -0,0 +1,2 @@
+ALTER TABLE communities DROP COLUMN IF EXISTS required_verifications;
+DROP TABLE IF EXISTS address_verifications;
@@ -0,0 +1,16 @@
+CREATE TABLE address_verifications (
+  addr VARCHAR(18) NOT NULL,
+  provider VARCHAR(64) NOT NULL,
+  external_id VARCHAR(128) NOT NULL,
+  status VARCHAR(16) NOT NULL,
+  score FLOAT8,
+  verified_by VARCHAR(18),
+  verified_at TIMESTAMP without time zone,
+  expires_at TIMESTAMP without time zone,
+  updated_at TIMESTAMP without time zone default (now() at time zone 'utc'),
+  PRIMARY KEY (addr, provider),
+  -- one identity can't verify several addresses
+  UNIQUE (provider, external_id)
+);
+
+ALTER TABLE communities ADD COLUMN required_verifications TEXT[];
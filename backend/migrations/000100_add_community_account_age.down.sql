@@ -0,0 +1,2 @@
+ALTER TABLE communities DROP COLUMN IF EXISTS min_account_age;
+ALTER TABLE communities DROP COLUMN IF EXISTS account_created_before_block;
@@ -0,0 +1,2 @@
+ALTER TABLE communities ADD COLUMN account_created_before_block BIGINT;
+ALTER TABLE communities ADD COLUMN min_account_age INT;
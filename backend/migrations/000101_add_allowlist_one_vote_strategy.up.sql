@@ -0,0 +1,7 @@
+BEGIN;
+ALTER TYPE strategies ADD VALUE IF NOT EXISTS 'allowlist-one-vote';
+END TRANSACTION;
+COMMIT;
+
+INSERT INTO voting_strategies (key, name, description)
+VALUES ('allowlist-one-vote', 'Allowlist One Vote', 'Each address on a community list has one vote, the list as it was when the proposal started.');
@@ -0,0 +1,2 @@
+ALTER TABLE votes DROP COLUMN IF EXISTS allocation;
+ALTER TABLE proposals DROP COLUMN IF EXISTS allow_split_votes;
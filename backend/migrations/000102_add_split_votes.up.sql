@@ -0,0 +1,2 @@
+ALTER TABLE proposals ADD COLUMN allow_split_votes BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE votes ADD COLUMN allocation JSONB;
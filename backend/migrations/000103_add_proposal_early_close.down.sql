@@ -0,0 +1,2 @@
+ALTER TABLE proposal_results DROP COLUMN IF EXISTS early_close;
+ALTER TABLE proposals DROP COLUMN IF EXISTS allow_early_close;
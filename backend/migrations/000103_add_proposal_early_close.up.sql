@@ -0,0 +1,2 @@
+ALTER TABLE proposals ADD COLUMN allow_early_close BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE proposal_results ADD COLUMN early_close JSONB;
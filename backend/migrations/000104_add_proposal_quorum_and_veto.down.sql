@@ -0,0 +1,2 @@
+ALTER TABLE proposals DROP COLUMN IF EXISTS veto_threshold;
+ALTER TABLE proposals DROP COLUMN IF EXISTS quorum;
@@ -0,0 +1,2 @@
+ALTER TABLE proposals ADD COLUMN quorum DOUBLE PRECISION;
+ALTER TABLE proposals ADD COLUMN veto_threshold DOUBLE PRECISION;
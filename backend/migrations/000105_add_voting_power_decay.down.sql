@@ -0,0 +1,3 @@
+ALTER TABLE votes DROP COLUMN IF EXISTS weight_multiplier;
+ALTER TABLE communities DROP COLUMN IF EXISTS decay_rate;
+ALTER TABLE communities DROP COLUMN IF EXISTS decay_window;
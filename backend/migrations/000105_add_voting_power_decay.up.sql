@@ -0,0 +1,3 @@
+ALTER TABLE communities ADD COLUMN decay_window INT;
+ALTER TABLE communities ADD COLUMN decay_rate DOUBLE PRECISION;
+ALTER TABLE votes ADD COLUMN weight_multiplier DOUBLE PRECISION;
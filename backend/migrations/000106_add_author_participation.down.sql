@@ -0,0 +1,2 @@
+ALTER TABLE communities DROP COLUMN IF EXISTS author_vote_window;
+ALTER TABLE communities DROP COLUMN IF EXISTS author_min_votes;
@@ -0,0 +1,2 @@
+ALTER TABLE communities ADD COLUMN author_min_votes INT;
+ALTER TABLE communities ADD COLUMN author_vote_window INT;
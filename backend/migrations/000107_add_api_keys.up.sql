@@ -0,0 +1,20 @@
+CREATE TABLE api_keys (
+  id BIGSERIAL primary key,
+  community_id INT not null references communities(id) ON DELETE CASCADE,
+  name VARCHAR(100) not null,
+  rate_limit INT,
+  request_count BIGINT not null default 0,
+  last_used_at TIMESTAMP without time zone,
+  created_by VARCHAR(18) not null,
+  revoked_at TIMESTAMP without time zone,
+  created_at TIMESTAMP without time zone default (now() at time zone 'utc')
+);
+
+CREATE INDEX api_keys_community_id_idx ON api_keys(community_id);
+
+CREATE TABLE api_key_usage (
+  api_key_id BIGINT not null references api_keys(id) ON DELETE CASCADE,
+  day DATE not null,
+  requests BIGINT not null default 0,
+  primary key (api_key_id, day)
+);
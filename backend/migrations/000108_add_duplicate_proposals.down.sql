@@ -0,0 +1,2 @@
+ALTER TABLE communities DROP COLUMN IF EXISTS duplicate_threshold;
+ALTER TABLE communities DROP COLUMN IF EXISTS duplicate_proposals;
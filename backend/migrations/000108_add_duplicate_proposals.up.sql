@@ -0,0 +1,2 @@
+ALTER TABLE communities ADD COLUMN duplicate_proposals TEXT;
+ALTER TABLE communities ADD COLUMN duplicate_threshold DOUBLE PRECISION;
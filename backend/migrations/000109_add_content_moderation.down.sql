@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS moderation_decisions;
+DROP TABLE IF EXISTS moderation_rules;
+ALTER TABLE communities DROP COLUMN IF EXISTS moderation_hold_score;
@@ -0,0 +1,25 @@
+ALTER TABLE communities ADD COLUMN moderation_hold_score DOUBLE PRECISION;
+
+CREATE TABLE moderation_rules (
+  id BIGSERIAL primary key,
+  community_id INT not null references communities(id) ON DELETE CASCADE,
+  pattern VARCHAR(100) not null,
+  weight DOUBLE PRECISION not null,
+  created_by VARCHAR(18) not null,
+  created_at TIMESTAMP without time zone default (now() at time zone 'utc'),
+  UNIQUE (community_id, pattern)
+);
+
+CREATE TABLE moderation_decisions (
+  id BIGSERIAL primary key,
+  community_id INT not null references communities(id) ON DELETE CASCADE,
+  content_type VARCHAR(32) not null,
+  content_id BIGINT not null,
+  author_addr VARCHAR(18) not null,
+  score DOUBLE PRECISION not null,
+  labels TEXT[] not null default '{}',
+  action VARCHAR(16) not null,
+  created_at TIMESTAMP without time zone default (now() at time zone 'utc')
+);
+
+CREATE INDEX moderation_decisions_community_id_idx ON moderation_decisions(community_id, created_at DESC);
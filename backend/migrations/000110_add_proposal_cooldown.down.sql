@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS proposals_community_creator_idx;
+ALTER TABLE communities DROP COLUMN IF EXISTS proposal_cooldown_window;
+ALTER TABLE communities DROP COLUMN IF EXISTS proposal_cooldown_limit;
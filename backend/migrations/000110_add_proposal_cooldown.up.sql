@@ -0,0 +1,4 @@
+ALTER TABLE communities ADD COLUMN proposal_cooldown_limit INT;
+ALTER TABLE communities ADD COLUMN proposal_cooldown_window INT;
+
+CREATE INDEX proposals_community_creator_idx ON proposals(community_id, creator_addr, created_at DESC);
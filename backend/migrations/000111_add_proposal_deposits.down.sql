@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS proposals_deposit_status_idx;
+DROP INDEX IF EXISTS proposals_deposit_tx_id_idx;
+
+ALTER TABLE proposals DROP COLUMN IF EXISTS deposit_settlement_tx_id;
+ALTER TABLE proposals DROP COLUMN IF EXISTS deposit_status;
+ALTER TABLE proposals DROP COLUMN IF EXISTS deposit_amount;
+ALTER TABLE proposals DROP COLUMN IF EXISTS deposit_tx_id;
+
+ALTER TABLE communities DROP COLUMN IF EXISTS deposit_slash_addr;
+ALTER TABLE communities DROP COLUMN IF EXISTS deposit_escrow_addr;
+ALTER TABLE communities DROP COLUMN IF EXISTS deposit_amount;
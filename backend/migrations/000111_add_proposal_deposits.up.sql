@@ -0,0 +1,11 @@
+ALTER TABLE communities ADD COLUMN deposit_amount DOUBLE PRECISION;
+ALTER TABLE communities ADD COLUMN deposit_escrow_addr VARCHAR(18);
+ALTER TABLE communities ADD COLUMN deposit_slash_addr VARCHAR(18);
+
+ALTER TABLE proposals ADD COLUMN deposit_tx_id VARCHAR(64);
+ALTER TABLE proposals ADD COLUMN deposit_amount DOUBLE PRECISION;
+ALTER TABLE proposals ADD COLUMN deposit_status VARCHAR(16);
+ALTER TABLE proposals ADD COLUMN deposit_settlement_tx_id VARCHAR(64);
+
+CREATE UNIQUE INDEX proposals_deposit_tx_id_idx ON proposals(deposit_tx_id);
+CREATE INDEX proposals_deposit_status_idx ON proposals(community_id, deposit_status) WHERE deposit_status IS NOT NULL;
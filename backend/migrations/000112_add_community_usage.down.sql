@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS community_quotas;
+DROP TABLE IF EXISTS community_usage;
@@ -0,0 +1,17 @@
+CREATE TABLE community_usage (
+  community_id INT not null references communities(id) ON DELETE CASCADE,
+  metric VARCHAR(32) not null,
+  period DATE not null,
+  amount BIGINT not null default 0,
+  warned_at TIMESTAMP without time zone,
+  PRIMARY KEY (community_id, metric, period)
+);
+
+CREATE TABLE community_quotas (
+  community_id INT not null references communities(id) ON DELETE CASCADE,
+  metric VARCHAR(32) not null,
+  quota BIGINT not null,
+  updated_by VARCHAR(18) not null,
+  updated_at TIMESTAMP without time zone default (now() at time zone 'utc'),
+  PRIMARY KEY (community_id, metric)
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS billing_events;
+DROP TABLE IF EXISTS community_plans;
+DROP TABLE IF EXISTS plans;
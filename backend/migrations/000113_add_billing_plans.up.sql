@@ -0,0 +1,28 @@
+CREATE TABLE plans (
+  name VARCHAR(32) primary key,
+  display_name VARCHAR(64) not null,
+  max_members INT,
+  max_active_proposals INT,
+  max_custom_strategies INT,
+  created_at TIMESTAMP without time zone default (now() at time zone 'utc')
+);
+
+INSERT INTO plans(name, display_name, max_members, max_active_proposals, max_custom_strategies) VALUES
+  ('free', 'Free', 250, 3, 0),
+  ('pro', 'Pro', NULL, NULL, NULL);
+
+CREATE TABLE community_plans (
+  community_id INT primary key references communities(id) ON DELETE CASCADE,
+  plan VARCHAR(32) not null references plans(name),
+  expires_at TIMESTAMP without time zone,
+  customer_id VARCHAR(255),
+  event_at TIMESTAMP without time zone not null,
+  updated_at TIMESTAMP without time zone default (now() at time zone 'utc')
+);
+
+CREATE TABLE billing_events (
+  id VARCHAR(255) primary key,
+  event_type VARCHAR(64) not null,
+  community_id INT,
+  received_at TIMESTAMP without time zone default (now() at time zone 'utc')
+);
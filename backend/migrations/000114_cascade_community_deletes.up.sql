@@ -0,0 +1,15 @@
+-- tables from before communities could be deleted go with their community
+ALTER TABLE proposals DROP CONSTRAINT proposals_community_id_fkey,
+  ADD CONSTRAINT proposals_community_id_fkey FOREIGN KEY (community_id) REFERENCES communities(id) ON DELETE CASCADE;
+ALTER TABLE votes DROP CONSTRAINT votes_proposal_id_fkey,
+  ADD CONSTRAINT votes_proposal_id_fkey FOREIGN KEY (proposal_id) REFERENCES proposals(id) ON DELETE CASCADE;
+ALTER TABLE proposal_results DROP CONSTRAINT proposal_results_proposal_id_fkey,
+  ADD CONSTRAINT proposal_results_proposal_id_fkey FOREIGN KEY (proposal_id) REFERENCES proposals(id) ON DELETE CASCADE;
+ALTER TABLE community_users DROP CONSTRAINT community_users_community_id_fkey,
+  ADD CONSTRAINT community_users_community_id_fkey FOREIGN KEY (community_id) REFERENCES communities(id) ON DELETE CASCADE;
+ALTER TABLE lists DROP CONSTRAINT lists_community_id_fkey,
+  ADD CONSTRAINT lists_community_id_fkey FOREIGN KEY (community_id) REFERENCES communities(id) ON DELETE CASCADE;
+ALTER TABLE community_users_achievements DROP CONSTRAINT community_users_achievements_community_id_fkey,
+  ADD CONSTRAINT community_users_achievements_community_id_fkey FOREIGN KEY (community_id) REFERENCES communities(id) ON DELETE CASCADE;
+
+CREATE INDEX communities_delete_after_idx ON communities(delete_after) WHERE delete_after IS NOT NULL;
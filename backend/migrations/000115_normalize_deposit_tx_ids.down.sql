@@ -0,0 +1 @@
+-- transaction IDs are left in lower case
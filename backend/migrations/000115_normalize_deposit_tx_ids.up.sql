@@ -0,0 +1,4 @@
+-- transaction IDs are kept in lower case without 0x, so one transaction
+-- can't be told apart from itself
+UPDATE proposals SET deposit_tx_id = lower(deposit_tx_id) WHERE deposit_tx_id IS NOT NULL;
+UPDATE proposals SET deposit_settlement_tx_id = lower(deposit_settlement_tx_id) WHERE deposit_settlement_tx_id IS NOT NULL;
@@ -0,0 +1,8 @@
+// Package migrations embeds the SQL migrations so the server binary can
+// apply and check them without the migrate CLI.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
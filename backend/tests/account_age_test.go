@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountAge(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	clearTable("votes")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+
+	t.Run("Should refuse votes of accounts created after the block", func(t *testing.T) {
+		A.DB.Conn.Exec(A.DB.Context,
+			"UPDATE communities SET account_created_before_block = 1 WHERE id = $1", communityId)
+		proposalId := otu.AddActiveProposals(communityId, 1)[0]
+
+		response := otu.CreateVoteAPI(proposalId, otu.GenerateValidVotePayload("user2", proposalId, "a"))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+		var e errorResponse
+		json.Unmarshal(response.Body.Bytes(), &e)
+		assert.Equal(t, "ERR_1028", e.ErrorCode)
+	})
+
+	t.Run("Should refuse votes of accounts younger than the minimum age", func(t *testing.T) {
+		// the proposal started a month ago, before the emulator did
+		A.DB.Conn.Exec(A.DB.Context,
+			"UPDATE communities SET account_created_before_block = NULL, min_account_age = 3600 WHERE id = $1", communityId)
+		proposalId := otu.AddActiveProposals(communityId, 1)[0]
+
+		response := otu.CreateVoteAPI(proposalId, otu.GenerateValidVotePayload("user2", proposalId, "a"))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		A.DB.Conn.Exec(A.DB.Context, "UPDATE communities SET min_account_age = NULL WHERE id = $1", communityId)
+		response = otu.CreateVoteAPI(proposalId, otu.GenerateValidVotePayload("user2", proposalId, "a"))
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+	})
+
+	t.Run("Minimum ages cannot be negative", func(t *testing.T) {
+		negative := -1
+		assert.Error(t, models.AccountAge{Min_account_age: &negative}.Validate())
+	})
+}
+
+func TestEstimateBlockHeight(t *testing.T) {
+	now := time.Now()
+	head := &flow.BlockHeader{Height: 1000, Timestamp: now}
+
+	assert.Equal(t, uint64(990), shared.EstimateBlockHeight(now.Add(-10*time.Second), head, time.Second))
+	assert.Equal(t, uint64(1000), shared.EstimateBlockHeight(now.Add(time.Hour), head, time.Second))
+	assert.Equal(t, uint64(0), shared.EstimateBlockHeight(now.Add(-time.Hour), head, time.Second))
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/tests/test_utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountActivity(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+
+	addr := otu.AddressOf("user1")
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	proposalId := otu.AddProposals(communityId, 1)[0]
+	A.DB.Conn.Exec(A.DB.Context, "UPDATE proposals SET creator_addr = $1 WHERE id = $2", addr, proposalId)
+
+	getActivity := func(activityType string) test_utils.PaginatedResponseWithActivity {
+		response := otu.GetAccountActivityAPI(addr, activityType)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var p test_utils.PaginatedResponseWithActivity
+		json.Unmarshal(response.Body.Bytes(), &p)
+		return p
+	}
+
+	t.Run("Should list what the address did, latest first", func(t *testing.T) {
+		p := getActivity("")
+		assert.Equal(t, 2, p.TotalRecords)
+		assert.Equal(t, models.ActivityProposal, p.Data[0].Type)
+		assert.Equal(t, proposalId, *p.Data[0].Proposal_id)
+		assert.Equal(t, models.ActivityJoin, p.Data[1].Type)
+		assert.Equal(t, communityId, p.Data[1].Community_id)
+	})
+
+	t.Run("Should filter by type", func(t *testing.T) {
+		p := getActivity("join,vote")
+		assert.Equal(t, 1, p.TotalRecords)
+		assert.Equal(t, models.ActivityJoin, p.Data[0].Type)
+	})
+
+	t.Run("Should reject unknown types", func(t *testing.T) {
+		response := otu.GetAccountActivityAPI(addr, "delegation")
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlatformAdmin(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("platform_admins")
+	clearTable("platform_blocklist")
+	clearTable("job_failures")
+
+	assert.Nil(t, models.SeedPlatformAdmins(A.DB, []string{otu.AddressOf("user1")}))
+	assert.Nil(t, A.ReloadAddressLists())
+	defer func() {
+		clearTable("platform_admins")
+		clearTable("platform_blocklist")
+		A.ReloadAddressLists()
+	}()
+
+	communityId := otu.AddCommunities(1, "dao")[0]
+	adminToken := otu.Login("user1")
+
+	t.Run("Admin reads should require an admin session", func(t *testing.T) {
+		response := otu.GetPlatformStatsAPI("")
+		CheckResponseCode(t, http.StatusUnauthorized, response.Code)
+
+		response = otu.GetPlatformStatsAPI(otu.Login("user2"))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.GetPlatformStatsAPI(adminToken)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var stats models.PlatformStats
+		json.Unmarshal(response.Body.Bytes(), &stats)
+		assert.Equal(t, 1, stats.Communities)
+	})
+
+	t.Run("Admins should feature communities", func(t *testing.T) {
+		response := otu.AdminCommunityAPI(communityId, "feature", otu.GenerateTimestampSignaturePayload("user2"))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.AdminCommunityAPI(communityId, "feature", otu.GenerateTimestampSignaturePayload("user1"))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		response = otu.GetAdminCommunitiesAPI(models.CommunityFilterFeatured, adminToken)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var body struct {
+			Data []models.Community `json:"data"`
+		}
+		json.Unmarshal(response.Body.Bytes(), &body)
+		assert.Equal(t, 1, len(body.Data))
+
+		response = otu.GetAdminCommunitiesAPI("unknown", adminToken)
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("Suspended communities should stay archived until unsuspended", func(t *testing.T) {
+		response := otu.AdminCommunityAPI(communityId, "suspend", otu.GenerateSuspendCommunityPayload("user1", "spam"))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var c models.Community
+		json.Unmarshal(response.Body.Bytes(), &c)
+		assert.True(t, c.Is_archived)
+		assert.Equal(t, "spam", *c.Suspension_reason)
+
+		response = otu.UnarchiveCommunityAPI(communityId, "user1")
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.AdminCommunityAPI(communityId, "unsuspend", otu.GenerateTimestampSignaturePayload("user1"))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		c = models.Community{}
+		json.Unmarshal(response.Body.Bytes(), &c)
+		assert.False(t, c.Is_archived)
+		assert.Nil(t, c.Suspended_at)
+	})
+
+	t.Run("Blocked addresses should not create communities", func(t *testing.T) {
+		addr := otu.AddressOf("user2")
+		response := otu.UpdateAddressListAPI("POST", "blocklist", otu.GenerateAddressListPayload("user1", []string{addr}))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		response = otu.CreateCommunityAPI(otu.GenerateCommunityPayload("user2", otu.GenerateCommunityStruct("user2", "dao")))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.GetAddressListAPI("blocklist", adminToken)
+		var body struct {
+			Data []models.ListedAddress `json:"data"`
+		}
+		json.Unmarshal(response.Body.Bytes(), &body)
+		assert.Equal(t, 1, len(body.Data))
+		assert.Equal(t, otu.AddressOf("user1"), *body.Data[0].Added_by)
+
+		response = otu.UpdateAddressListAPI("DELETE", "blocklist", otu.GenerateAddressListPayload("user1", []string{addr}))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		response = otu.CreateCommunityAPI(otu.GenerateCommunityPayload("user2", otu.GenerateCommunityStruct("user2", "dao")))
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+	})
+
+	t.Run("Admins added and removed should take effect without a restart", func(t *testing.T) {
+		addr := otu.AddressOf("user2")
+		userToken := otu.Login("user2")
+
+		response := otu.UpdateAddressListAPI("POST", "allowlist", otu.GenerateAddressListPayload("user1", []string{addr}))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		response = otu.GetPlatformStatsAPI(userToken)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		response = otu.UpdateAddressListAPI("DELETE", "allowlist", otu.GenerateAddressListPayload("user2", []string{addr}))
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+
+		response = otu.UpdateAddressListAPI("DELETE", "allowlist", otu.GenerateAddressListPayload("user1", []string{addr}))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		response = otu.GetPlatformStatsAPI(userToken)
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("Failed job runs should be listed", func(t *testing.T) {
+		assert.Nil(t, models.RecordJobFailure(A.DB, "pins", errors.New("ipfs unavailable")))
+
+		response := otu.GetFailedJobsAPI("pins", adminToken)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var body struct {
+			Data []models.FailedJob `json:"data"`
+		}
+		json.Unmarshal(response.Body.Bytes(), &body)
+		assert.Equal(t, 1, len(body.Data))
+		assert.Equal(t, "ipfs unavailable", body.Data[0].Error)
+	})
+
+	t.Run("Configuration should be shown to admins with secrets masked", func(t *testing.T) {
+		response := otu.GetConfigAPI(otu.Login("user2"))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.GetConfigAPI(adminToken)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var config shared.Config
+		json.Unmarshal(response.Body.Bytes(), &config)
+		assert.Equal(t, A.Config.Flow_env, config.Flow_env)
+		assert.Equal(t, A.Config.Max_file_size, config.Max_file_size)
+		if A.Config.Db_password != "" {
+			assert.Equal(t, "********", config.Db_password)
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowlistOneVoteStrategy(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("lists")
+	clearTable("proposals")
+	clearTable("votes")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+
+	listType := "allow"
+	list := models.List{
+		Community_id: communityId,
+		Addresses:    []string{otu.AddressOf("user1")},
+		List_type:    &listType,
+	}
+	assert.NoError(t, list.CreateList(A.DB))
+	list.Addresses = append(list.Addresses, otu.AddressOf("user2"))
+	assert.NoError(t, list.UpdateList(A.DB))
+
+	name := "allowlist-one-vote"
+	strategy := models.Strategy{Name: &name}
+	strategy.List_id = &list.ID
+
+	addProposal := func(listVersions map[int]int) int {
+		p := otu.GenerateProposalStruct("account", communityId)
+		p.Strategy = &name
+		p.Strategy_config = &strategy
+		p.Start_time = time.Now().UTC().AddDate(0, -1, 0)
+		p.List_versions = listVersions
+		assert.NoError(t, p.CreateProposal(A.DB))
+		return p.ID
+	}
+
+	t.Run("Only addresses on the bound list version should vote", func(t *testing.T) {
+		proposalId := addProposal(map[int]int{list.ID: 1})
+
+		response := otu.CreateVoteAPI(proposalId, otu.GenerateValidVotePayload("user1", proposalId, "a"))
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+
+		response = otu.CreateVoteAPI(proposalId, otu.GenerateValidVotePayload("user2", proposalId, "a"))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+		var e errorResponse
+		json.Unmarshal(response.Body.Bytes(), &e)
+		assert.Equal(t, "ERR_1029", e.ErrorCode)
+
+		var results models.ProposalResults
+		response = otu.GetProposalResultsAPI(proposalId)
+		json.Unmarshal(response.Body.Bytes(), &results)
+		assert.Equal(t, 1, results.Results["a"])
+	})
+
+	t.Run("Unbound proposals should use the list as it was when they started", func(t *testing.T) {
+		proposalId := addProposal(nil)
+
+		// the list was made after the proposal started
+		response := otu.CreateVoteAPI(proposalId, otu.GenerateValidVotePayload("user1", proposalId, "a"))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+	})
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiKeys(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	clearTable("votes")
+
+	A.AdminAllowlist.Set([]string{otu.AddressOf("user1")})
+	defer A.AdminAllowlist.Set(nil)
+	adminToken := otu.Login("user1")
+
+	communities := otu.AddCommunitiesWithUsers(2, "user1")
+	communityId, otherId := communities[0], communities[1]
+
+	name := "one-address-one-vote"
+	p := otu.GenerateProposalStruct("account", communityId)
+	p.Strategy = &name
+	p.Strategy_config = &models.Strategy{Name: &name}
+	assert.NoError(t, p.CreateProposal(A.DB))
+	response := otu.CreateVoteAPI(p.ID, otu.GenerateValidVotePayload("user2", p.ID, "a"))
+	CheckResponseCode(t, http.StatusCreated, response.Code)
+
+	limit := 3
+	create := func(signer string, communityId int) *models.ApiKeyWithToken {
+		payload := models.CreateApiKeyPayload{
+			Community_id:              communityId,
+			Name:                      "analytics",
+			Rate_limit:                &limit,
+			TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+		}
+		response := otu.CreateApiKeyAPI(&payload)
+		if response.Code != http.StatusCreated {
+			return nil
+		}
+		var key models.ApiKeyWithToken
+		json.Unmarshal(response.Body.Bytes(), &key)
+		return &key
+	}
+
+	t.Run("Only platform admins should create API keys", func(t *testing.T) {
+		assert.Nil(t, create("user2", communityId))
+	})
+
+	t.Run("API keys should read their community's votes", func(t *testing.T) {
+		key := create("user1", communityId)
+		assert.NotNil(t, key)
+
+		path := fmt.Sprintf("/partner/communities/%d/proposals/%d/votes", communityId, p.ID)
+		response := otu.PartnerGetAPI(path, key.Token)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var votes []models.VoteWithBalance
+		scanner := bufio.NewScanner(bytes.NewReader(response.Body.Bytes()))
+		for scanner.Scan() {
+			var v models.VoteWithBalance
+			assert.NoError(t, json.Unmarshal(scanner.Bytes(), &v))
+			votes = append(votes, v)
+		}
+		assert.Equal(t, 1, len(votes))
+		assert.Equal(t, otu.AddressOf("user2"), votes[0].Addr)
+
+		response = otu.PartnerGetAPI(fmt.Sprintf("/partner/communities/%d/proposals", otherId), key.Token)
+		CheckResponseCode(t, http.StatusUnauthorized, response.Code)
+		var e errorResponse
+		json.Unmarshal(response.Body.Bytes(), &e)
+		assert.Equal(t, "ERR_1031", e.ErrorCode)
+
+		response = otu.PartnerGetAPI(path, "")
+		CheckResponseCode(t, http.StatusUnauthorized, response.Code)
+	})
+
+	t.Run("API keys should be rate limited and counted", func(t *testing.T) {
+		key := create("user1", communityId)
+		path := fmt.Sprintf("/partner/communities/%d", communityId)
+		for i := 0; i < limit; i++ {
+			response := otu.PartnerGetAPI(path, key.Token)
+			CheckResponseCode(t, http.StatusOK, response.Code)
+		}
+		response := otu.PartnerGetAPI(path, key.Token)
+		CheckResponseCode(t, http.StatusTooManyRequests, response.Code)
+		assert.NotEmpty(t, response.Header().Get("Retry-After"))
+
+		response = otu.GetApiKeysAPI(communityId, adminToken)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var body struct {
+			Data []models.ApiKey `json:"data"`
+		}
+		json.Unmarshal(response.Body.Bytes(), &body)
+		for _, k := range body.Data {
+			if k.ID == key.ID {
+				assert.Equal(t, int64(limit), k.Request_count)
+			}
+		}
+
+		response = otu.GetApiKeyUsageAPI(key.ID, adminToken)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var usage []models.ApiKeyUsage
+		json.Unmarshal(response.Body.Bytes(), &usage)
+		assert.Equal(t, 1, len(usage))
+		assert.Equal(t, int64(limit), usage[0].Requests)
+	})
+
+	t.Run("Revoked API keys should be refused", func(t *testing.T) {
+		key := create("user1", communityId)
+		revoke := otu.GenerateTimestampSignaturePayload("user1")
+		response := otu.RevokeApiKeyAPI(key.ID, &revoke)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		revoke = otu.GenerateTimestampSignaturePayload("user1")
+		response = otu.RevokeApiKeyAPI(key.ID, &revoke)
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+
+		revoke = otu.GenerateTimestampSignaturePayload("user1")
+		response = otu.RevokeApiKeyAPI(key.ID+1000, &revoke)
+		CheckResponseCode(t, http.StatusNotFound, response.Code)
+
+		response = otu.PartnerGetAPI(fmt.Sprintf("/partner/communities/%d", communityId), key.Token)
+		CheckResponseCode(t, http.StatusUnauthorized, response.Code)
+	})
+}
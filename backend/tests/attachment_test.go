@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProposalAttachments(t *testing.T) {
+	clearTable("uploads")
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	clearTable("ipfs_pins")
+
+	storage, err := shared.NewLocalStorage(t.TempDir(), "https://cast.test/files/", A.TokenSigner)
+	assert.NoError(t, err)
+	defaultStorage := A.Storage
+	A.Storage = storage
+	defer func() { A.Storage = defaultStorage }()
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	otherCommunityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+
+	upload := func(content string, payload *models.UploadPayload) models.Upload {
+		response := otu.UploadFileWithPayloadAPI("budget.pdf", "application/pdf", []byte(content), payload)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var u models.Upload
+		json.Unmarshal(response.Body.Bytes(), &u)
+		return u
+	}
+	budget := upload("%PDF-1.4 budget", nil)
+	minutes := upload("%PDF-1.4 minutes", otu.GeneratePrivateUploadPayload("user1", communityId))
+	foreign := upload("%PDF-1.4 foreign", otu.GeneratePrivateUploadPayload("user1", otherCommunityId))
+
+	var p models.Proposal
+	t.Run("Proposals should be created with attachments", func(t *testing.T) {
+		proposalStruct := otu.GenerateProposalStruct("user1", communityId)
+		proposalStruct.Attachments = []*models.ProposalAttachment{
+			{Upload_id: budget.ID, Name: "Budget"},
+			{Upload_id: minutes.ID, Name: "Minutes"},
+		}
+		response := otu.CreateProposalAPI(otu.GenerateProposalPayload("user1", proposalStruct))
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+		json.Unmarshal(response.Body.Bytes(), &p)
+
+		response = otu.GetProposalAttachmentsAPI(p.ID)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var body struct {
+			Data         []models.ProposalAttachment `json:"data"`
+			TotalRecords int                         `json:"totalRecords"`
+		}
+		json.Unmarshal(response.Body.Bytes(), &body)
+		assert.Equal(t, 2, body.TotalRecords)
+		assert.Equal(t, "Budget", body.Data[0].Name)
+		assert.Equal(t, budget.Url, *body.Data[0].Url)
+		assert.Equal(t, "Minutes", body.Data[1].Name)
+		assert.True(t, body.Data[1].Private)
+		assert.Nil(t, body.Data[1].Url)
+	})
+
+	t.Run("Public attachments should be pinned with the proposal", func(t *testing.T) {
+		otu.ProcessPins()
+
+		response := otu.GetProposalAttachmentsAPI(p.ID)
+		var body struct {
+			Data []models.ProposalAttachment `json:"data"`
+		}
+		json.Unmarshal(response.Body.Bytes(), &body)
+		assert.NotNil(t, body.Data[0].Cid)
+		assert.Nil(t, body.Data[1].Cid)
+		assert.Equal(t, minutes.Content_hash, body.Data[1].Content_hash)
+
+		pinned := models.Proposal{ID: p.ID}
+		assert.NoError(t, pinned.GetProposalById(A.DB))
+		assert.NotNil(t, pinned.Cid)
+	})
+
+	t.Run("Private files of other communities should not be attached", func(t *testing.T) {
+		proposalStruct := otu.GenerateProposalStruct("user1", communityId)
+		proposalStruct.Attachments = []*models.ProposalAttachment{{Upload_id: foreign.ID, Name: "Foreign"}}
+		response := otu.CreateProposalAPI(otu.GenerateProposalPayload("user1", proposalStruct))
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("Attachments should be limited in number", func(t *testing.T) {
+		proposalStruct := otu.GenerateProposalStruct("user1", communityId)
+		for i := 0; i < 11; i++ {
+			proposalStruct.Attachments = append(proposalStruct.Attachments,
+				&models.ProposalAttachment{Upload_id: budget.ID, Name: "Budget"})
+		}
+		response := otu.CreateProposalAPI(otu.GenerateProposalPayload("user1", proposalStruct))
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogin(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+
+	t.Run("Logging in should issue a session for the signing address", func(t *testing.T) {
+		response := otu.LoginAPI(otu.GenerateLoginPayload("user1"))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var session models.Session
+		json.Unmarshal(response.Body.Bytes(), &session)
+		assert.Equal(t, otu.AddressOf("user1"), session.Addr)
+		assert.NotEmpty(t, session.Token)
+		assert.True(t, session.Expires_at.After(time.Now()))
+	})
+
+	t.Run("A login signature should only be used once", func(t *testing.T) {
+		payload := otu.GenerateLoginPayload("user1")
+		response := otu.LoginAPI(payload)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		response = otu.LoginAPI(payload)
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("Notifications should only be readable with a session for their address", func(t *testing.T) {
+		addr := otu.AddressOf("user1")
+
+		response := otu.GetNotificationsAPI(addr, "")
+		CheckResponseCode(t, http.StatusUnauthorized, response.Code)
+
+		response = otu.GetNotificationsAPI(addr, otu.Login("user2"))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.GetNotificationsAPI(addr, otu.Login("user1"))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Join requests should only be readable by member managers", func(t *testing.T) {
+		communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+
+		response := otu.GetJoinRequestsAPI(communityId, "")
+		CheckResponseCode(t, http.StatusUnauthorized, response.Code)
+
+		response = otu.GetJoinRequestsAPI(communityId, "not-a-token")
+		CheckResponseCode(t, http.StatusUnauthorized, response.Code)
+
+		response = otu.GetJoinRequestsAPI(communityId, otu.Login("user2"))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.GetJoinRequestsAPI(communityId, otu.Login("user1"))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Invites should only be readable by member managers", func(t *testing.T) {
+		communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+
+		response := otu.GetInvitesAPI(communityId, "")
+		CheckResponseCode(t, http.StatusUnauthorized, response.Code)
+
+		response = otu.GetInvitesAPI(communityId, otu.Login("user2"))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.GetInvitesAPI(communityId, otu.Login("user1"))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Feeds should only be readable with a session", func(t *testing.T) {
+		addr := otu.AddressOf("user1")
+
+		response := otu.GetMyFeedAPI(addr, "")
+		CheckResponseCode(t, http.StatusUnauthorized, response.Code)
+
+		response = otu.GetMyFeedAPI(addr, otu.Login("user1"))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+	})
+}
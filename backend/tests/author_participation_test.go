@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorParticipation(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	clearTable("votes")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	_, err := A.DB.Conn.Exec(A.DB.Context,
+		`UPDATE communities SET author_min_votes = 1, author_vote_window = 2 WHERE id = $1`, communityId)
+	assert.NoError(t, err)
+
+	now := time.Now().UTC()
+	var ended []int
+	for i := 1; i <= 3; i++ {
+		p := otu.GenerateProposalStruct("account", communityId)
+		p.Start_time = now.AddDate(0, -i, -7)
+		p.End_time = now.AddDate(0, -i, 0)
+		assert.NoError(t, p.CreateProposal(A.DB))
+		ended = append(ended, p.ID)
+	}
+
+	eligibility := func() models.ProposalEligibility {
+		response := otu.GetProposalEligibilityAPI(communityId, otu.AddressOf("user1"))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var e models.ProposalEligibility
+		json.Unmarshal(response.Body.Bytes(), &e)
+		return e
+	}
+
+	t.Run("Authors who didn't vote recently should not propose", func(t *testing.T) {
+		// the oldest proposal is out of the window
+		vote := models.Vote{
+			Proposal_id:          ended[2],
+			Addr:                 otu.AddressOf("user1"),
+			Choice:               "a",
+			Composite_signatures: &[]shared.CompositeSignature{},
+		}
+		assert.NoError(t, vote.CreateVote(A.DB))
+
+		e := eligibility()
+		assert.False(t, e.Eligible)
+		if assert.NotNil(t, e.Participation) {
+			assert.Equal(t, 2, e.Participation.Ended)
+			assert.Equal(t, 0, e.Participation.Voted)
+		}
+
+		proposal := otu.GenerateProposalStruct("user1", communityId)
+		response := otu.CreateProposalAPI(otu.GenerateProposalPayload("user1", proposal))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+		var errResponse errorResponse
+		json.Unmarshal(response.Body.Bytes(), &errResponse)
+		assert.Equal(t, "ERR_1030", errResponse.ErrorCode)
+	})
+
+	t.Run("Authors who voted recently should propose", func(t *testing.T) {
+		vote := models.Vote{
+			Proposal_id:          ended[0],
+			Addr:                 otu.AddressOf("user1"),
+			Choice:               "a",
+			Composite_signatures: &[]shared.CompositeSignature{},
+		}
+		assert.NoError(t, vote.CreateVote(A.DB))
+
+		assert.True(t, eligibility().Eligible)
+	})
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/tests/test_utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommunityBans(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("community_bans")
+	clearTable("proposals")
+	clearTable("votes")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	proposalId := otu.AddActiveProposals(communityId, 1)[0]
+	addr := otu.AddressOf("user2")
+	models.GrantAuthorRolesToAddress(A.DB, communityId, addr)
+
+	checkBanned := func(t *testing.T, response *httptest.ResponseRecorder) {
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+		var e errorResponse
+		json.Unmarshal(response.Body.Bytes(), &e)
+		assert.Equal(t, "ERR_1014", e.ErrorCode)
+	}
+
+	t.Run("Only member managers should ban, and never admins", func(t *testing.T) {
+		response := otu.BanAddressAPI(communityId, otu.GenerateBanPayload("user3", addr, "spam"))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.BanAddressAPI(communityId, otu.GenerateBanPayload("user1", otu.AddressOf("user1"), "spam"))
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+
+		response = otu.BanAddressAPI(communityId, otu.GenerateBanPayload("user1", addr, "spam"))
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+	})
+
+	t.Run("Banned addresses should lose their roles", func(t *testing.T) {
+		assert.NotNil(t, models.EnsureRoleForCommunity(A.DB, addr, communityId, "author"))
+	})
+
+	t.Run("Banned addresses should not vote", func(t *testing.T) {
+		response := otu.CreateVoteAPI(proposalId, otu.GenerateValidVotePayload("user2", proposalId, "a"))
+		checkBanned(t, response)
+	})
+
+	t.Run("Banned addresses should not propose", func(t *testing.T) {
+		proposal := otu.GenerateProposalPayload("user2", otu.GenerateProposalStruct("user2", communityId))
+		response := otu.CreateProposalAPI(proposal)
+		checkBanned(t, response)
+	})
+
+	t.Run("Banned addresses should not join", func(t *testing.T) {
+		member := otu.GenerateCommunityUserStruct("user2", "member")
+		member.Community_id = communityId
+		response := otu.CreateCommunityUserAPI(communityId, otu.GenerateCommunityUserPayload("user2", member))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("Bans should be listed and exported", func(t *testing.T) {
+		response := otu.GetCommunityBansAPI(communityId)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var page test_utils.PaginatedResponseWithBans
+		json.Unmarshal(response.Body.Bytes(), &page)
+		assert.Equal(t, 1, page.TotalRecords)
+		assert.Equal(t, addr, page.Data[0].Addr)
+		assert.Equal(t, "spam", *page.Data[0].Reason)
+		assert.Equal(t, otu.AddressOf("user1"), page.Data[0].Banned_by)
+
+		response = otu.ExportCommunityBansAPI(communityId)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, "text/csv", response.Header().Get("Content-Type"))
+		assert.Contains(t, response.Body.String(), addr+",spam,"+otu.AddressOf("user1"))
+	})
+
+	t.Run("Unbanned addresses should vote again", func(t *testing.T) {
+		response := otu.UnbanAddressAPI(communityId, otu.GenerateBanPayload("user3", addr, ""))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.UnbanAddressAPI(communityId, otu.GenerateBanPayload("user1", addr, ""))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		response = otu.CreateVoteAPI(proposalId, otu.GenerateValidVotePayload("user2", proposalId, "a"))
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+	})
+}
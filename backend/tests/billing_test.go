@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBilling(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	clearTable("community_plans")
+	clearTable("billing_events")
+
+	A.Config.Billing_enabled = true
+	A.Config.Billing_webhook_secret = "whsec_test"
+	defer func() {
+		A.Config.Billing_enabled = false
+		A.Config.Billing_webhook_secret = ""
+	}()
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	otu.AddActiveProposals(communityId, 3)
+	createProposal := func() int {
+		proposal := otu.GenerateProposalStruct("user1", communityId)
+		return otu.CreateProposalAPI(otu.GenerateProposalPayload("user1", proposal)).Code
+	}
+	getBilling := func() models.CommunityBilling {
+		response := otu.GetCommunityBillingAPI(communityId, otu.Login("user1"))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var billing models.CommunityBilling
+		json.Unmarshal(response.Body.Bytes(), &billing)
+		return billing
+	}
+	upgrade := otu.GenerateEntitlementEvent("evt_upgrade", models.EntitlementUpdated, communityId, "pro")
+
+	t.Run("Should list the plans", func(t *testing.T) {
+		response := otu.GetPlansAPI()
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var plans []models.Plan
+		json.Unmarshal(response.Body.Bytes(), &plans)
+		assert.Equal(t, 2, len(plans))
+		assert.Equal(t, models.FreePlan, plans[0].Name)
+	})
+
+	t.Run("Should hold communities without a plan to the free plan", func(t *testing.T) {
+		proposal := otu.GenerateProposalStruct("user1", communityId)
+		response := otu.CreateProposalAPI(otu.GenerateProposalPayload("user1", proposal))
+		CheckResponseCode(t, http.StatusPaymentRequired, response.Code)
+		var e errorResponse
+		json.Unmarshal(response.Body.Bytes(), &e)
+		assert.Equal(t, "ERR_1041", e.ErrorCode)
+
+		billing := getBilling()
+		assert.Equal(t, models.FreePlan, billing.Plan.Name)
+		assert.Equal(t, 3, billing.Usage.Active_proposals)
+		assert.True(t, billing.Enforced)
+
+		response = otu.GetCommunityBillingAPI(communityId, otu.Login("user2"))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("Should refuse webhooks that are not signed with the secret", func(t *testing.T) {
+		response := otu.BillingWebhookAPI(upgrade, "whsec_other")
+		CheckResponseCode(t, http.StatusUnauthorized, response.Code)
+		assert.Equal(t, models.FreePlan, getBilling().Plan.Name)
+	})
+
+	t.Run("Should lift the limits once the community upgrades", func(t *testing.T) {
+		response := otu.BillingWebhookAPI(upgrade, "whsec_test")
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, "pro", getBilling().Plan.Name)
+
+		CheckResponseCode(t, http.StatusCreated, createProposal())
+	})
+
+	t.Run("Should apply a webhook once and go back to free when revoked", func(t *testing.T) {
+		revoke := otu.GenerateEntitlementEvent("evt_revoke", models.EntitlementRevoked, communityId, "")
+		response := otu.BillingWebhookAPI(revoke, "whsec_test")
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, models.FreePlan, getBilling().Plan.Name)
+
+		// a redelivered upgrade doesn't undo the revocation
+		response = otu.BillingWebhookAPI(upgrade, "whsec_test")
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, models.FreePlan, getBilling().Plan.Name)
+
+		CheckResponseCode(t, http.StatusPaymentRequired, createProposal())
+	})
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyLimits(t *testing.T) {
+	clearTable("communities")
+
+	post := func(contentType string, body []byte, chunked bool) errorResponse {
+		req, _ := http.NewRequest("POST", "/communities", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", contentType)
+		if chunked {
+			req.ContentLength = -1
+		}
+		response := otu.ExecuteRequest(req)
+		var e errorResponse
+		json.Unmarshal(response.Body.Bytes(), &e)
+		e.StatusCode = response.Code
+		return e
+	}
+	huge := []byte(`{"name":"` + strings.Repeat("a", int(A.Config.Max_body_size)) + `"}`)
+
+	t.Run("Should refuse bodies that aren't JSON", func(t *testing.T) {
+		e := post("application/x-www-form-urlencoded", []byte("name=dao"), false)
+		assert.Equal(t, http.StatusUnsupportedMediaType, e.StatusCode)
+		assert.Equal(t, "ERR_1034", e.ErrorCode)
+	})
+
+	t.Run("Should refuse bodies larger than the limit", func(t *testing.T) {
+		e := post("application/json", huge, false)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, e.StatusCode)
+		assert.Equal(t, "ERR_1033", e.ErrorCode)
+	})
+
+	t.Run("Should stop reading bodies sent without a length at the limit", func(t *testing.T) {
+		e := post("application/json; charset=utf-8", huge, true)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, e.StatusCode)
+		assert.Equal(t, "ERR_1033", e.ErrorCode)
+	})
+}
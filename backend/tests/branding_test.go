@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommunityBranding(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("community_branding")
+
+	ids := otu.AddCommunitiesWithUsers(2, "user1")
+	domain := "Vote.Example.org"
+	theme := map[string]string{"primary": "#112233", "background": "#fff"}
+	logos := map[string]string{"dark": "https://example.org/dark.png"}
+
+	t.Run("Community admins should set the branding", func(t *testing.T) {
+		payload := &models.CommunityBrandingPayload{
+			TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload("user2"),
+			Custom_domain:             &domain,
+			Theme:                     &theme,
+		}
+		response := otu.SetCommunityBrandingAPI(ids[0], payload)
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		invalid := map[string]string{"primary": "blue"}
+		payload = &models.CommunityBrandingPayload{
+			TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload("user1"),
+			Theme:                     &invalid,
+		}
+		response = otu.SetCommunityBrandingAPI(ids[0], payload)
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+
+		payload = &models.CommunityBrandingPayload{
+			TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload("user1"),
+			Custom_domain:             &domain,
+			Theme:                     &theme,
+			Logos:                     &logos,
+		}
+		response = otu.SetCommunityBrandingAPI(ids[0], payload)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Branding should be read by community and by domain", func(t *testing.T) {
+		response := otu.GetCommunityBrandingAPI(ids[0])
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var b models.CommunityBranding
+		json.Unmarshal(response.Body.Bytes(), &b)
+		assert.Equal(t, "vote.example.org", *b.Custom_domain)
+		assert.Equal(t, "#112233", b.Theme["primary"])
+		assert.Equal(t, "https://example.org/dark.png", b.Logos["dark"])
+
+		response = otu.GetBrandingForHostAPI("vote.example.org:443")
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		b = models.CommunityBranding{}
+		json.Unmarshal(response.Body.Bytes(), &b)
+		assert.Equal(t, ids[0], b.Community_id)
+
+		response = otu.GetBrandingForHostAPI("unknown.example.org")
+		CheckResponseCode(t, http.StatusNotFound, response.Code)
+	})
+
+	t.Run("A domain should serve only one community", func(t *testing.T) {
+		response := otu.SetCommunityBrandingAPI(ids[1], &models.CommunityBrandingPayload{
+			TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload("user1"),
+			Custom_domain:             &domain,
+		})
+		CheckResponseCode(t, http.StatusConflict, response.Code)
+	})
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConditionalReads(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	proposalId := otu.AddProposals(communityId, 1)[0]
+
+	get := func(path string, headers map[string]string) *http.Response {
+		req, _ := http.NewRequest("GET", path, nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		return otu.ExecuteRequest(req).Result()
+	}
+
+	for _, path := range []string{
+		fmt.Sprintf("/communities/%d", communityId),
+		fmt.Sprintf("/proposals/%d", proposalId),
+		fmt.Sprintf("/proposals/%d/results", proposalId),
+	} {
+		t.Run("Should revalidate "+path, func(t *testing.T) {
+			response := get(path, nil)
+			CheckResponseCode(t, http.StatusOK, response.StatusCode)
+			etag := response.Header.Get("ETag")
+			assert.NotEmpty(t, etag)
+			assert.NotEmpty(t, response.Header.Get("Cache-Control"))
+
+			response = get(path, map[string]string{"If-None-Match": etag})
+			CheckResponseCode(t, http.StatusNotModified, response.StatusCode)
+
+			response = get(path, map[string]string{"If-None-Match": `"0-0"`})
+			CheckResponseCode(t, http.StatusOK, response.StatusCode)
+		})
+	}
+
+	t.Run("Should honor If-Modified-Since for communities", func(t *testing.T) {
+		path := fmt.Sprintf("/communities/%d", communityId)
+		lastModified := get(path, nil).Header.Get("Last-Modified")
+		assert.NotEmpty(t, lastModified)
+
+		response := get(path, map[string]string{"If-Modified-Since": lastModified})
+		CheckResponseCode(t, http.StatusNotModified, response.StatusCode)
+
+		response = get(path, map[string]string{"If-Modified-Since": "Mon, 01 Jan 2001 00:00:00 GMT"})
+		CheckResponseCode(t, http.StatusOK, response.StatusCode)
+	})
+}
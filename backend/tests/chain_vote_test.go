@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChainVotes(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("community_feature_flags")
+	clearTable("chain_event_cursors")
+	clearTable("proposals")
+	clearTable("votes")
+
+	os.Setenv("CAST_VOTING_CONTRACT_ADDR", "0xf8d6e0586b0a20c7")
+	os.Setenv("CAST_VOTING_START_HEIGHT", "0")
+	defer os.Unsetenv("CAST_VOTING_CONTRACT_ADDR")
+	defer os.Unsetenv("CAST_VOTING_START_HEIGHT")
+
+	A.AdminAllowlist.Set([]string{otu.AddressOf("user1")})
+	defer A.AdminAllowlist.Set(nil)
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	proposalId := otu.AddActiveProposals(communityId, 1)[0]
+
+	t.Run("Votes cast on chain are ignored unless the community turned them on", func(t *testing.T) {
+		otu.CastVoteOnchainAs("user2", proposalId, "a")
+		assert.Nil(t, A.IngestChainVotes())
+
+		response := otu.GetVoteForProposalByAccountNameAPI(proposalId, "user2")
+		assert.NotEqual(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Votes cast on chain are tallied with signed votes", func(t *testing.T) {
+		enabled := true
+		response := otu.SetCommunityFeatureAPI("user1", models.FlagOnchainVoting, communityId, &enabled)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		otu.CastVoteOnchainAs("user3", proposalId, "b")
+		assert.Nil(t, A.IngestChainVotes())
+
+		response = otu.GetVoteForProposalByAccountNameAPI(proposalId, "user3")
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var vote models.VoteWithBalance
+		json.Unmarshal(response.Body.Bytes(), &vote)
+		assert.Equal(t, "b", vote.Choice)
+		assert.Equal(t, models.VoteOnchain, vote.Source)
+		assert.NotNil(t, vote.Tx_id)
+	})
+
+	t.Run("Invalid choices are ignored", func(t *testing.T) {
+		otu.CastVoteOnchainAs("user4", proposalId, "not a choice")
+		assert.Nil(t, A.IngestChainVotes())
+
+		response := otu.GetVoteForProposalByAccountNameAPI(proposalId, "user4")
+		assert.NotEqual(t, http.StatusOK, response.Code)
+	})
+}
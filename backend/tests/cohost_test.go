@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProposalCohosts(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	clearTable("proposal_cohosts")
+
+	communityIds := otu.AddCommunitiesWithUsers(2, "user1")
+	hostId, partnerId := communityIds[0], communityIds[1]
+	proposalId := otu.AddProposals(hostId, 1)[0]
+	strategy := "token-weighted-default"
+
+	t.Run("Should only let the partner's admins co-host", func(t *testing.T) {
+		payload := otu.GenerateProposalCohostPayload("user2", partnerId, strategy)
+		response := otu.AddProposalCohostAPI(proposalId, payload)
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("Should reject strategies the partner doesn't have", func(t *testing.T) {
+		payload := otu.GenerateProposalCohostPayload("user1", partnerId, "no-such-strategy")
+		response := otu.AddProposalCohostAPI(proposalId, payload)
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("Should co-host a proposal", func(t *testing.T) {
+		payload := otu.GenerateProposalCohostPayload("user1", partnerId, strategy)
+		response := otu.AddProposalCohostAPI(proposalId, payload)
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+
+		var cohost models.ProposalCohost
+		json.Unmarshal(response.Body.Bytes(), &cohost)
+		assert.Equal(t, partnerId, cohost.Community_id)
+
+		payload = otu.GenerateProposalCohostPayload("user1", partnerId, strategy)
+		response = otu.AddProposalCohostAPI(proposalId, payload)
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("Should list co-hosted proposals for the partner", func(t *testing.T) {
+		response := otu.GetProposalsForCommunityAPI(partnerId)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var body shared.PaginatedResponse
+		json.Unmarshal(response.Body.Bytes(), &body)
+		assert.Equal(t, 1, body.TotalRecords)
+	})
+
+	t.Run("Should report results for each host and combined", func(t *testing.T) {
+		response := otu.GetCohostedResultsAPI(proposalId)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var results models.CohostedResults
+		json.Unmarshal(response.Body.Bytes(), &results)
+		assert.Len(t, results.Communities, 2)
+		assert.Equal(t, hostId, results.Communities[0].Community_id)
+		assert.Equal(t, partnerId, results.Communities[1].Community_id)
+		assert.NotNil(t, results.Combined)
+	})
+
+	t.Run("Should end a co-hosting", func(t *testing.T) {
+		payload := otu.GenerateProposalCohostPayload("user1", partnerId, strategy)
+		response := otu.RemoveProposalCohostAPI(proposalId, payload)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		response = otu.GetProposalsForCommunityAPI(partnerId)
+		var body shared.PaginatedResponse
+		json.Unmarshal(response.Body.Bytes(), &body)
+		assert.Equal(t, 0, body.TotalRecords)
+	})
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurgeDeletedCommunities(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	clearTable("votes")
+	clearTable("nfts")
+	clearTable("ipfs_pins")
+	clearTable("billing_events")
+
+	communityIds := otu.AddCommunitiesWithUsers(2, "user1")
+	deleted, kept := communityIds[0], communityIds[1]
+	for _, communityId := range communityIds {
+		proposalId := otu.AddActiveProposals(communityId, 1)[0]
+		otu.AddVotes(proposalId, 2)
+		admin, banned := otu.AddressOf("user1"), otu.AddressOf("user2")
+		for _, insert := range []struct {
+			sql  string
+			args []interface{}
+		}{
+			{`INSERT INTO community_users_achievements(addr, achievement_type, community_id) VALUES($1, 'streak', $2)`,
+				[]interface{}{admin, communityId}},
+			{`INSERT INTO community_bans(community_id, addr, banned_by) VALUES($1, $2, $3)`,
+				[]interface{}{communityId, banned, admin}},
+			{`INSERT INTO notifications(addr, notification_type, community_id, proposal_id) VALUES($1, 'new_proposal', $2, $3)`,
+				[]interface{}{admin, communityId, proposalId}},
+			{`INSERT INTO nfts(uuid, owner_addr, id, proposal_id) VALUES(gen_random_uuid(), $1, 1, $2)`,
+				[]interface{}{admin, proposalId}},
+			{`INSERT INTO ipfs_pins(record_type, record_id) VALUES('proposal', $1)`,
+				[]interface{}{proposalId}},
+			{`INSERT INTO billing_events(id, event_type, community_id) VALUES($1, 'entitlement.updated', $2)`,
+				[]interface{}{fmt.Sprintf("evt_%d", communityId), communityId}},
+		} {
+			_, err := A.DB.Conn.Exec(A.DB.Context, insert.sql, insert.args...)
+			assert.NoError(t, err, insert.sql)
+		}
+	}
+
+	// counts the rows of every table keyed to the community or its proposals
+	leftBehind := func(communityId int) map[string]int {
+		rows, err := A.DB.Conn.Query(A.DB.Context,
+			`
+			SELECT c.table_name, c.column_name FROM information_schema.columns c
+			JOIN information_schema.tables t USING (table_schema, table_name)
+			WHERE c.table_schema = 'public' AND t.table_type = 'BASE TABLE'
+				AND c.column_name IN ('community_id', 'proposal_id')
+			`)
+		assert.NoError(t, err)
+		columns := map[string]string{}
+		for rows.Next() {
+			var table, column string
+			rows.Scan(&table, &column)
+			columns[table] = column
+		}
+		rows.Close()
+
+		counts := map[string]int{}
+		for table, column := range columns {
+			sql := `SELECT COUNT(*) FROM ` + table + ` WHERE community_id = $1`
+			if column == "proposal_id" {
+				sql = `SELECT COUNT(*) FROM ` + table + ` WHERE proposal_id IN (SELECT id FROM proposals WHERE community_id = $1)`
+			}
+			var count int
+			assert.NoError(t, A.DB.Conn.QueryRow(A.DB.Context, sql, communityId).Scan(&count), table)
+			if count > 0 {
+				counts[table] = count
+			}
+		}
+		return counts
+	}
+
+	t.Run("Should keep communities until their grace period has passed", func(t *testing.T) {
+		A.DB.Conn.Exec(A.DB.Context,
+			`UPDATE communities SET is_archived = 'true', delete_after = (now() at time zone 'utc') + interval '1 day' WHERE id = $1`,
+			deleted)
+		assert.NoError(t, A.PurgeDeletedCommunities())
+		assert.NotEmpty(t, leftBehind(deleted))
+	})
+
+	t.Run("Should leave nothing of a deleted community behind", func(t *testing.T) {
+		A.DB.Conn.Exec(A.DB.Context,
+			`UPDATE communities SET delete_after = (now() at time zone 'utc') - interval '1 hour' WHERE id = $1`,
+			deleted)
+		assert.NoError(t, A.PurgeDeletedCommunities())
+
+		assert.Empty(t, leftBehind(deleted))
+		var pins int
+		A.DB.Conn.QueryRow(A.DB.Context, `SELECT COUNT(*) FROM ipfs_pins`).Scan(&pins)
+		assert.Equal(t, 1, pins)
+
+		// replayed webhooks are still recognised once the community is gone
+		var events int
+		A.DB.Conn.QueryRow(A.DB.Context, `SELECT COUNT(*) FROM billing_events WHERE id = $1`,
+			fmt.Sprintf("evt_%d", deleted)).Scan(&events)
+		assert.Equal(t, 1, events)
+
+		assert.NotEmpty(t, leftBehind(kept)["votes"])
+		assert.NotEmpty(t, leftBehind(kept)["billing_events"])
+	})
+}
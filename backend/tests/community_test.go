@@ -2,8 +2,12 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/DapperCollectives/CAST/backend/main/models"
 	"github.com/DapperCollectives/CAST/backend/main/shared"
@@ -65,6 +69,34 @@ func TestCreateCommunity(t *testing.T) {
 	assert.NotNil(t, community.ID)
 }
 
+func TestCreateCommunityRollsBack(t *testing.T) {
+	// Prep
+	clearTable("communities")
+	clearTable("community_users")
+
+	communityStruct := otu.GenerateCommunityStruct("account", "dao")
+
+	// Fail after the community and its roles are written
+	err := A.DB.WithTx(func(tx *shared.Database) error {
+		if err := communityStruct.CreateCommunity(tx); err != nil {
+			return err
+		}
+		if err := models.GrantRolesToCommunityCreator(tx, communityStruct.Creator_addr, communityStruct.ID); err != nil {
+			return err
+		}
+		return errors.New("rollback")
+	})
+	assert.EqualError(t, err, "rollback")
+
+	// Validate nothing was stored
+	response := otu.GetCommunityAPI(communityStruct.ID)
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+
+	roles, err := models.GetAllRolesForUserInCommunity(A.DB, communityStruct.Creator_addr, communityStruct.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, roles)
+}
+
 func TestCreateCommunityFailStrategy(t *testing.T) {
 	// Prep
 	clearTable("communities")
@@ -89,6 +121,17 @@ func TestCreateCommunityFailThreshold(t *testing.T) {
 
 	response := otu.CreateCommunityAPI(communityPayload)
 	checkResponseCode(t, http.StatusBadRequest, response.Code)
+
+	var e struct {
+		Fields []struct {
+			Field string `json:"field"`
+			Rule  string `json:"rule"`
+		} `json:"fields"`
+	}
+	json.Unmarshal(response.Body.Bytes(), &e)
+	assert.Len(t, e.Fields, 1)
+	assert.Equal(t, "proposalThreshold", e.Fields[0].Field)
+	assert.Equal(t, "min", e.Fields[0].Rule)
 }
 
 func TestCreateCommunityNilStrategy(t *testing.T) {
@@ -484,3 +527,275 @@ func TestUpdateCommunity(t *testing.T) {
 	assert.Equal(t, *utils.UpdatedCommunity.Discord_url, *updatedCommunity.Discord_url)
 	assert.Equal(t, *utils.UpdatedCommunity.Instagram_url, *updatedCommunity.Instagram_url)
 }
+
+func TestUpdateCommunityConcurrency(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+
+	communityStruct := otu.GenerateCommunityStruct("account", "dao")
+	response := otu.CreateCommunityAPI(otu.GenerateCommunityPayload("account", communityStruct))
+	checkResponseCode(t, http.StatusCreated, response.Code)
+	var community models.Community
+	json.Unmarshal(response.Body.Bytes(), &community)
+	communityId := community.ID
+
+	etag := otu.GetCommunityAPI(communityId).Header().Get("ETag")
+	assert.True(t, strings.HasPrefix(etag, `"1-`))
+
+	t.Run("Updates without If-Match are rejected", func(t *testing.T) {
+		payload := otu.GenerateCommunityPayload("account", &utils.UpdatedCommunity)
+		response := otu.UpdateCommunityWithETagAPI(communityId, "", payload)
+		checkResponseCode(t, http.StatusPreconditionRequired, response.Code)
+	})
+
+	t.Run("The first update of a version wins", func(t *testing.T) {
+		payload := otu.GenerateCommunityPayload("account", &utils.UpdatedCommunity)
+		response := otu.UpdateCommunityWithETagAPI(communityId, etag, payload)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, `"2"`, response.Header().Get("ETag"))
+
+		var c models.Community
+		json.Unmarshal(response.Body.Bytes(), &c)
+		assert.Equal(t, 2, c.Version)
+	})
+
+	t.Run("A later update of the same version is a conflict", func(t *testing.T) {
+		payload := otu.GenerateCommunityPayload("account", &utils.UpdatedCommunity)
+		response := otu.UpdateCommunityWithETagAPI(communityId, etag, payload)
+		checkResponseCode(t, http.StatusConflict, response.Code)
+	})
+}
+
+func TestStrategyFreeze(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	proposalId := otu.AddActiveProposals(communityId, 1)[0]
+	var boundId int
+
+	// the community's only strategy replaced by another one
+	update := otu.GenerateCommunityStruct("user1", "dao")
+	replaced := (*update.Strategies)[0]
+	staked := "staked-token-weighted-default"
+	replaced.Name = &staked
+	update.Strategies = &[]models.Strategy{replaced}
+
+	t.Run("Strategies of active proposals can't be changed", func(t *testing.T) {
+		response := otu.UpdateCommunityAPI(communityId, otu.GenerateCommunityPayload("user1", update))
+		checkResponseCode(t, http.StatusConflict, response.Code)
+
+		var e errorResponse
+		json.Unmarshal(response.Body.Bytes(), &e)
+		assert.Equal(t, "ERR_1022", e.ErrorCode)
+	})
+
+	t.Run("Proposals are bound to the strategy they were created with", func(t *testing.T) {
+		payload := otu.GenerateProposalPayload("user1", otu.GenerateProposalStruct("user1", communityId))
+		response := otu.CreateProposalAPI(payload)
+		checkResponseCode(t, http.StatusCreated, response.Code)
+
+		var p models.Proposal
+		json.Unmarshal(response.Body.Bytes(), &p)
+		assert.NotNil(t, p.Strategy_config)
+		assert.Equal(t, *p.Strategy, *p.Strategy_config.Name)
+		boundId = p.ID
+	})
+
+	t.Run("Strategies can be changed once proposals closed", func(t *testing.T) {
+		otu.UpdateProposalEndTime(proposalId, time.Now().UTC().AddDate(0, 0, -1))
+		otu.UpdateProposalEndTime(boundId, time.Now().UTC().AddDate(0, 0, -1))
+
+		response := otu.UpdateCommunityAPI(communityId, otu.GenerateCommunityPayload("user1", update))
+		checkResponseCode(t, http.StatusOK, response.Code)
+	})
+}
+
+func TestStrategyVersions(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("community_strategy_versions")
+	clearTable("proposals")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+
+	t.Run("Proposals record the strategy version they were created under", func(t *testing.T) {
+		payload := otu.GenerateProposalPayload("user1", otu.GenerateProposalStruct("user1", communityId))
+		response := otu.CreateProposalAPI(payload)
+		checkResponseCode(t, http.StatusCreated, response.Code)
+
+		var p models.Proposal
+		json.Unmarshal(response.Body.Bytes(), &p)
+		assert.Equal(t, 1, *p.Strategy_version)
+	})
+
+	t.Run("Should list the proposals an intended change affects", func(t *testing.T) {
+		response := otu.PreviewStrategyChangeAPI(communityId, "user2", []models.Strategy{})
+		checkResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.PreviewStrategyChangeAPI(communityId, "user1", []models.Strategy{})
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		var impact models.StrategyChangeImpact
+		json.Unmarshal(response.Body.Bytes(), &impact)
+		assert.Equal(t, 1, impact.Version)
+		assert.Equal(t, []string{"token-weighted-default"}, impact.Changed)
+		assert.Len(t, impact.Proposals, 1)
+	})
+
+	t.Run("Each edit of strategies creates a version", func(t *testing.T) {
+		response := otu.UpdateCommunityAPI(communityId, otu.GenerateCommunityPayload("user1", &utils.UpdatedCommunity))
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		// unchanged strategies are not a new version
+		response = otu.UpdateCommunityAPI(communityId, otu.GenerateCommunityPayload("user1", &utils.UpdatedCommunity))
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		response = otu.GetStrategyVersionsAPI(communityId)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		var versions []models.StrategyVersion
+		json.Unmarshal(response.Body.Bytes(), &versions)
+		assert.Len(t, versions, 2)
+		assert.Equal(t, 2, versions[0].Version)
+		assert.Len(t, *versions[0].Strategies, 2)
+	})
+}
+
+func TestSubCommunities(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("community_strategy_versions")
+	clearTable("lists")
+
+	parentId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	updated := utils.UpdatedCommunity
+	response := otu.UpdateCommunityAPI(parentId, otu.GenerateCommunityPayload("user1", &updated))
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	response = otu.CreateListAPI(otu.GenerateBlockListPayload("user1", otu.GenerateBlockListStruct(parentId)))
+	checkResponseCode(t, http.StatusCreated, response.Code)
+	var parentList models.List
+	json.Unmarshal(response.Body.Bytes(), &parentList)
+
+	createChild := func(signer string) *httptest.ResponseRecorder {
+		child := otu.GenerateCommunityStruct(signer, "dao")
+		child.Parent_id = &parentId
+		payload := otu.GenerateCommunityPayload(signer, child)
+		// left unset to inherit the parent's
+		payload.Strategies = nil
+		return otu.CreateCommunityAPI(payload)
+	}
+
+	childLists := func(childId int) []models.List {
+		response := otu.GetListsForCommunityAPI(childId)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		var lists []models.List
+		json.Unmarshal(response.Body.Bytes(), &lists)
+		return lists
+	}
+
+	var child models.Community
+
+	t.Run("Only admins of the parent should create children", func(t *testing.T) {
+		response := createChild("user2")
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+
+		response = createChild("user1")
+		checkResponseCode(t, http.StatusCreated, response.Code)
+		json.Unmarshal(response.Body.Bytes(), &child)
+		assert.Equal(t, parentId, *child.Parent_id)
+	})
+
+	t.Run("Children should inherit the parent's strategies and lists", func(t *testing.T) {
+		assert.Equal(t, *updated.Strategies, *child.Strategies)
+
+		lists := childLists(child.ID)
+		assert.Len(t, lists, 1)
+		assert.Equal(t, parentList.Addresses, lists[0].Addresses)
+		assert.Equal(t, *parentList.List_type, *lists[0].List_type)
+	})
+
+	t.Run("Should list children and the hierarchy", func(t *testing.T) {
+		response := otu.GetChildCommunitiesAPI(parentId)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		var page test_utils.PaginatedResponseWithCommunity
+		json.Unmarshal(response.Body.Bytes(), &page)
+		assert.Equal(t, 1, page.TotalRecords)
+		assert.Equal(t, child.ID, page.Data[0].ID)
+
+		response = otu.GetCommunityHierarchyAPI(child.ID)
+		checkResponseCode(t, http.StatusOK, response.Code)
+		var hierarchy models.CommunityHierarchy
+		json.Unmarshal(response.Body.Bytes(), &hierarchy)
+		assert.Equal(t, child.ID, hierarchy.Community.ID)
+		assert.Len(t, hierarchy.Ancestors, 1)
+		assert.Equal(t, parentId, hierarchy.Ancestors[0].ID)
+
+		response = otu.GetCommunityHierarchyAPI(parentId)
+		json.Unmarshal(response.Body.Bytes(), &hierarchy)
+		assert.Empty(t, hierarchy.Ancestors)
+		assert.Len(t, hierarchy.Descendants, 1)
+	})
+
+	t.Run("Admins of the parent should manage its children", func(t *testing.T) {
+		// a child the parent admin has no role in
+		other := otu.GenerateCommunityStruct("user2", "dao")
+		other.Parent_id = &parentId
+		assert.Nil(t, otu.CreateCommunityDB(other))
+		models.GrantRolesToCommunityCreator(A.DB, other.Creator_addr, other.ID)
+
+		response := otu.UpdateCommunityAPI(other.ID, otu.GenerateCommunityPayload("user3", &utils.UpdatedCommunity))
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+
+		response = otu.UpdateCommunityAPI(other.ID, otu.GenerateCommunityPayload("user1", &utils.UpdatedCommunity))
+		checkResponseCode(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Lists should be copied, and synced from the parent on request", func(t *testing.T) {
+		response := otu.AddAddressesToListAPI(parentList.ID, otu.GenerateUpdateListPayload(parentList.ID, parentId, "user1"))
+		checkResponseCode(t, http.StatusCreated, response.Code)
+		assert.Equal(t, parentList.Addresses, childLists(child.ID)[0].Addresses)
+
+		response = otu.SyncCommunityListsAPI(child.ID, "user3")
+		checkResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.SyncCommunityListsAPI(child.ID, "user1")
+		checkResponseCode(t, http.StatusOK, response.Code)
+		var synced []models.List
+		json.Unmarshal(response.Body.Bytes(), &synced)
+		assert.Len(t, synced, 1)
+
+		lists := childLists(child.ID)
+		assert.Len(t, lists, 1)
+		assert.Contains(t, lists[0].Addresses, "0x04")
+		assert.Contains(t, lists[0].Addresses, "0x05")
+
+		response = otu.SyncCommunityListsAPI(parentId, "user1")
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+}
+
+func TestGetCommunityAnalytics(t *testing.T) {
+	resetTables()
+
+	communityId := otu.AddCommunities(1, "dao")[0]
+	numProposals := 2
+	numUsers := 3
+	otu.GenerateVotes(communityId, numProposals, numUsers)
+	otu.ComputeAnalytics()
+
+	response := otu.GetCommunityAnalyticsAPI(communityId)
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var analytics models.CommunityAnalytics
+	json.Unmarshal(response.Body.Bytes(), &analytics)
+
+	assert.Equal(t, numProposals, len(analytics.Proposals))
+	for _, p := range analytics.Proposals {
+		assert.Equal(t, numUsers, p.Unique_voters)
+	}
+	assert.NotEmpty(t, analytics.Participation)
+	assert.Equal(t, numUsers*numProposals, analytics.Participation[len(analytics.Participation)-1].Votes)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigValidation(t *testing.T) {
+	t.Run("The running configuration should be valid", func(t *testing.T) {
+		assert.NoError(t, A.Config.Validate())
+	})
+
+	t.Run("Should list every invalid setting", func(t *testing.T) {
+		c := A.Config
+		c.Flow_env = "devnet"
+		c.Max_file_size = 0
+		c.Admin_addrs = "0x01cf0e2f2f715450 not-an-address"
+		c.Cors_origins = "https://app.example.com/path"
+		c.Cors_admin_origins = "*"
+		c.Usage_soft_limit = 1.5
+
+		err := c.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "FLOW_ENV must be emulator, testnet or mainnet")
+		assert.Contains(t, err.Error(), "MAX_FILE_SIZE must be a positive number of bytes.")
+		assert.Contains(t, err.Error(), `ADMIN_ADDRS has "not-an-address"`)
+		assert.Contains(t, err.Error(), `CORS_ORIGINS has "https://app.example.com/path"`)
+		assert.Contains(t, err.Error(), "CORS_ADMIN_ORIGINS must list origins")
+		assert.Contains(t, err.Error(), "USAGE_SOFT_LIMIT must be above 0 and at most 1.")
+	})
+
+	t.Run("Should mask secrets", func(t *testing.T) {
+		c := shared.Config{}
+		c.Ipfs_secret = "secret"
+		assert.Equal(t, "********", c.Redacted().Ipfs_secret)
+		assert.Equal(t, "", c.Redacted().Ipfs_key)
+		assert.Equal(t, "secret", c.Ipfs_secret)
+	})
+}
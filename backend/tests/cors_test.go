@@ -0,0 +1,99 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/middleware"
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCors(t *testing.T) {
+	c := shared.Config{}
+	c.Cors_origins = "https://app.example.com https://partner.example.org"
+	c.Cors_admin_origins = "https://admin.example.com"
+	handler := middleware.UseCors(c)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := func(method, path, origin string, preflight bool) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Origin", origin)
+		if preflight {
+			req.Header.Set("Access-Control-Request-Method", "POST")
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Should allow listed origins on the public API", func(t *testing.T) {
+		response := request("GET", "/communities/1", "https://partner.example.org", false)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, "https://partner.example.org", response.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "Origin", response.Header().Get("Vary"))
+
+		response = request("GET", "/communities/1", "https://evil.example.net", false)
+		assert.Empty(t, response.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Should keep the admin API to its own origins", func(t *testing.T) {
+		response := request("GET", "/admin/api-keys", "https://app.example.com", false)
+		assert.Empty(t, response.Header().Get("Access-Control-Allow-Origin"))
+
+		response = request("GET", "/admin/api-keys", "https://admin.example.com", false)
+		assert.Equal(t, "https://admin.example.com", response.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Should open embeds to every origin", func(t *testing.T) {
+		response := request("GET", "/embed/proposals/1", "https://blog.example.net", false)
+		assert.Equal(t, "*", response.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Should answer preflights without reaching the handler", func(t *testing.T) {
+		response := request("OPTIONS", "/communities", "https://app.example.com", true)
+		CheckResponseCode(t, http.StatusNoContent, response.Code)
+		assert.Contains(t, response.Header().Get("Access-Control-Allow-Headers"), "Authorization")
+		assert.Contains(t, response.Header().Get("Access-Control-Allow-Headers"), middleware.TenantHeader)
+
+		response = request("OPTIONS", "/communities", "https://evil.example.net", true)
+		CheckResponseCode(t, http.StatusNoContent, response.Code)
+		assert.Empty(t, response.Header().Get("Access-Control-Allow-Headers"))
+	})
+}
+
+func TestCorsPreflightThroughRouter(t *testing.T) {
+	preflight := func(path, origin string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("OPTIONS", path, nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", "GET")
+		req.Header.Set("Access-Control-Request-Headers", "Authorization")
+		return executeRequest(req)
+	}
+
+	t.Run("Should answer preflights of routes without OPTIONS", func(t *testing.T) {
+		response := preflight("/me/feed", "https://app.example.com")
+		CheckResponseCode(t, http.StatusNoContent, response.Code)
+
+		response = preflight("/embed/proposals/1/widget", "https://blog.example.net")
+		CheckResponseCode(t, http.StatusNoContent, response.Code)
+		assert.Equal(t, "*", response.Header().Get("Access-Control-Allow-Origin"))
+		assert.Contains(t, response.Header().Get("Access-Control-Allow-Headers"), "Authorization")
+	})
+
+	t.Run("Should still refuse other methods a route doesn't take", func(t *testing.T) {
+		req, _ := http.NewRequest("DELETE", "/me/feed", nil)
+		CheckResponseCode(t, http.StatusMethodNotAllowed, executeRequest(req).Code)
+	})
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	t.Run("Should send security headers with every response", func(t *testing.T) {
+		response := otu.GetCommunityAPI(420)
+		assert.Equal(t, "nosniff", response.Header().Get("X-Content-Type-Options"))
+		assert.Equal(t, "DENY", response.Header().Get("X-Frame-Options"))
+		assert.Equal(t, middleware.ContentSecurityPolicy, response.Header().Get("Content-Security-Policy"))
+	})
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDuplicateProposals(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	setPolicy := func(policy string) {
+		_, err := A.DB.Conn.Exec(A.DB.Context,
+			`UPDATE communities SET duplicate_proposals = $2 WHERE id = $1`, communityId, policy)
+		assert.NoError(t, err)
+	}
+
+	original := otu.GenerateProposalStruct("user1", communityId)
+	response := otu.CreateProposalAPI(otu.GenerateProposalPayload("user1", original))
+	CheckResponseCode(t, http.StatusCreated, response.Code)
+	var first models.Proposal
+	json.Unmarshal(response.Body.Bytes(), &first)
+
+	t.Run("Should list recent proposals a draft looks like", func(t *testing.T) {
+		response := otu.GetDuplicateProposalsAPI(communityId, first.Name, *first.Body)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var duplicates []models.DuplicateProposal
+		json.Unmarshal(response.Body.Bytes(), &duplicates)
+		if assert.Equal(t, 1, len(duplicates)) {
+			assert.Equal(t, first.ID, duplicates[0].ID)
+			assert.InDelta(t, 1, duplicates[0].Similarity, 0.001)
+		}
+
+		response = otu.GetDuplicateProposalsAPI(communityId, "Something else entirely", "")
+		json.Unmarshal(response.Body.Bytes(), &duplicates)
+		assert.Equal(t, 0, len(duplicates))
+	})
+
+	t.Run("Should warn of duplicates when the community warns", func(t *testing.T) {
+		setPolicy(models.DuplicatesWarn)
+		response := otu.CreateProposalAPI(otu.GenerateProposalPayload("user1", otu.GenerateProposalStruct("user1", communityId)))
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+		var p models.Proposal
+		json.Unmarshal(response.Body.Bytes(), &p)
+		if assert.Equal(t, 1, len(p.Duplicates)) {
+			assert.Equal(t, first.ID, p.Duplicates[0].ID)
+		}
+	})
+
+	t.Run("Should refuse duplicates when the community blocks them", func(t *testing.T) {
+		setPolicy(models.DuplicatesBlock)
+		response := otu.CreateProposalAPI(otu.GenerateProposalPayload("user1", otu.GenerateProposalStruct("user1", communityId)))
+		CheckResponseCode(t, http.StatusConflict, response.Code)
+		var e struct {
+			ErrorCode  string                     `json:"errorCode"`
+			Duplicates []models.DuplicateProposal `json:"duplicates"`
+		}
+		json.Unmarshal(response.Body.Bytes(), &e)
+		assert.Equal(t, "ERR_1036", e.ErrorCode)
+		assert.Equal(t, 2, len(e.Duplicates))
+	})
+}
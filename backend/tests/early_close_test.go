@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEarlyClose(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("lists")
+	clearTable("proposals")
+	clearTable("proposal_results")
+	clearTable("votes")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+
+	listType := "allow"
+	list := models.List{
+		Community_id: communityId,
+		Addresses:    []string{otu.AddressOf("user1"), otu.AddressOf("user2"), otu.AddressOf("user3")},
+		List_type:    &listType,
+	}
+	assert.NoError(t, list.CreateList(A.DB))
+
+	name := "allowlist-one-vote"
+	strategy := models.Strategy{Name: &name}
+	strategy.List_id = &list.ID
+
+	p := otu.GenerateProposalStruct("account", communityId)
+	p.Strategy = &name
+	p.Strategy_config = &strategy
+	p.Start_time = time.Now().UTC().AddDate(0, -1, 0)
+	p.List_versions = map[int]int{list.ID: 1}
+	p.Allow_early_close = true
+	assert.NoError(t, p.CreateProposal(A.DB))
+	scheduledEnd := p.End_time
+
+	closed := func() bool {
+		proposal := models.Proposal{ID: p.ID}
+		assert.NoError(t, proposal.GetProposalById(A.DB))
+		return *proposal.Status == models.ProposalClosed
+	}
+
+	t.Run("Should stay open while the outcome can change", func(t *testing.T) {
+		response := otu.CreateVoteAPI(p.ID, otu.GenerateValidVotePayload("user1", p.ID, "a"))
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+
+		otu.CloseProposals()
+		assert.False(t, closed())
+	})
+
+	t.Run("Should close once the leading choice can't be overtaken", func(t *testing.T) {
+		response := otu.CreateVoteAPI(p.ID, otu.GenerateValidVotePayload("user2", p.ID, "a"))
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+
+		otu.CloseProposals()
+		assert.True(t, closed())
+
+		var results models.ProposalResults
+		response = otu.GetProposalResultsAPI(p.ID)
+		json.Unmarshal(response.Body.Bytes(), &results)
+		assert.Equal(t, 2, results.Results["a"])
+		if assert.NotNil(t, results.Early_close) {
+			assert.Equal(t, models.EarlyCloseDecisive, results.Early_close.Rule)
+			assert.Equal(t, "a", results.Early_close.Leading_choice)
+			assert.Equal(t, 1.0, results.Early_close.Remaining_weight)
+			assert.WithinDuration(t, scheduledEnd, results.Early_close.Scheduled_end, time.Second)
+		}
+
+		response = otu.CreateVoteAPI(p.ID, otu.GenerateValidVotePayload("user3", p.ID, "b"))
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+}
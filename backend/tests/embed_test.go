@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProposalEmbeds(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	clearTable("proposal_results")
+	clearTable("votes")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	p := otu.GenerateProposalStruct("account", communityId)
+	assert.NoError(t, p.CreateProposal(A.DB))
+	link := fmt.Sprintf("%s/community/%d/proposal/%d", A.PublicAppURL, communityId, p.ID)
+
+	t.Run("Should describe a proposal to any site", func(t *testing.T) {
+		response := otu.GetProposalEmbedAPI(p.ID)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, "*", response.Header().Get("Access-Control-Allow-Origin"))
+		assert.NotEmpty(t, response.Header().Get("ETag"))
+
+		var embed models.ProposalEmbed
+		json.Unmarshal(response.Body.Bytes(), &embed)
+		assert.Equal(t, p.Name, embed.Name)
+		assert.Equal(t, communityId, embed.Community.ID)
+		assert.Equal(t, link, embed.Url)
+		assert.Equal(t, len(p.Choices), len(embed.Results))
+	})
+
+	t.Run("Should answer oEmbed requests for proposal links", func(t *testing.T) {
+		response := otu.GetOEmbedAPI(link, "")
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var o models.OEmbed
+		json.Unmarshal(response.Body.Bytes(), &o)
+		assert.Equal(t, "rich", o.Type)
+		assert.Contains(t, o.Html, fmt.Sprintf("/embed/proposals/%d/widget", p.ID))
+		assert.NotEmpty(t, o.Thumbnail_url)
+
+		response = otu.GetOEmbedAPI(link, "maxwidth=300")
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		o = models.OEmbed{}
+		json.Unmarshal(response.Body.Bytes(), &o)
+		assert.Equal(t, 300, o.Width)
+		assert.Empty(t, o.Thumbnail_url)
+
+		response = otu.GetOEmbedAPI(link, "format=xml")
+		CheckResponseCode(t, http.StatusNotImplemented, response.Code)
+
+		response = otu.GetOEmbedAPI(fmt.Sprintf("%s/community/%d", A.PublicAppURL, communityId), "")
+		CheckResponseCode(t, http.StatusNotFound, response.Code)
+	})
+}
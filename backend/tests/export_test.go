@@ -0,0 +1,88 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommunityExport(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	clearTable("community_exports")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	otherCommunityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	otu.AddActiveProposals(communityId, 1)
+
+	var export models.ExportWithToken
+
+	t.Run("Only admins should request an export", func(t *testing.T) {
+		response := otu.CreateCommunityExportAPI(communityId, "user2")
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.CreateCommunityExportAPI(communityId, "user1")
+		CheckResponseCode(t, http.StatusAccepted, response.Code)
+		json.Unmarshal(response.Body.Bytes(), &export)
+		assert.Equal(t, models.ExportPending, export.Status)
+		assert.Equal(t, otu.AddressOf("user1"), export.Requested_by)
+		assert.NotEmpty(t, export.Token)
+	})
+
+	t.Run("The export should only be readable with its token", func(t *testing.T) {
+		response := otu.GetCommunityExportAPI(communityId, export.ID, "")
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.GetCommunityExportAPI(otherCommunityId, export.ID, export.Token)
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.DownloadCommunityExportAPI(communityId, export.ID+1, export.Token)
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("Should complete in the background", func(t *testing.T) {
+		otu.ProcessExports()
+
+		assert.Eventually(t, func() bool {
+			response := otu.GetCommunityExportAPI(communityId, export.ID, export.Token)
+			var e models.CommunityExport
+			json.Unmarshal(response.Body.Bytes(), &e)
+			return e.Status == models.ExportCompleted && e.Size > 0
+		}, 10*time.Second, 100*time.Millisecond)
+	})
+
+	t.Run("Should download the archive", func(t *testing.T) {
+		response := otu.DownloadCommunityExportAPI(communityId, export.ID, export.Token)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, "application/zip", response.Header().Get("Content-Type"))
+
+		body := response.Body.Bytes()
+		zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+		assert.NoError(t, err)
+
+		names := []string{}
+		for _, f := range zr.File {
+			names = append(names, f.Name)
+		}
+		assert.Equal(t, []string{
+			"community.json", "members.csv", "lists.csv", "proposals.csv", "votes.csv", "results.csv",
+		}, names)
+
+		f, _ := zr.Open("community.json")
+		content, _ := io.ReadAll(f)
+		var archive models.CommunityArchive
+		json.Unmarshal(content, &archive)
+		assert.Equal(t, models.CommunityArchiveVersion, archive.Version)
+		assert.Equal(t, communityId, archive.Community.ID)
+		assert.Len(t, archive.Proposals, 1)
+		assert.NotEmpty(t, archive.Members)
+	})
+}
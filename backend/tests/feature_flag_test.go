@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeatureFlags(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("community_feature_flags")
+
+	A.AdminAllowlist.Set([]string{otu.AddressOf("user1")})
+	defer A.AdminAllowlist.Set(nil)
+	defer A.DB.Conn.Exec(A.DB.Context, `UPDATE feature_flags SET rollout_percent = 0`)
+
+	communityId := otu.AddCommunities(1, "dao")[0]
+
+	getFeatures := func() map[string]bool {
+		response := otu.GetCommunityFeaturesAPI(communityId)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var features map[string]bool
+		json.Unmarshal(response.Body.Bytes(), &features)
+		return features
+	}
+
+	t.Run("Rollout buckets should be stable and grow with the percentage", func(t *testing.T) {
+		for id := 1; id <= 200; id++ {
+			bucket := models.RolloutBucket(models.FlagComments, id)
+			assert.Equal(t, bucket, models.RolloutBucket(models.FlagComments, id))
+			assert.True(t, bucket >= 0 && bucket < 100)
+
+			f := models.FeatureFlag{Name: models.FlagComments, Communities: map[int]bool{}}
+			f.Rollout_percent = bucket
+			assert.False(t, f.Enabled(id))
+			f.Rollout_percent = bucket + 1
+			assert.True(t, f.Enabled(id))
+		}
+	})
+
+	t.Run("Flags should follow the platform rollout", func(t *testing.T) {
+		assert.False(t, getFeatures()[models.FlagComments])
+
+		response := otu.SetFeatureRolloutAPI("user1", models.FlagComments, 100)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		assert.True(t, getFeatures()[models.FlagComments])
+
+		response = otu.SetFeatureRolloutAPI("user1", models.FlagComments, 101)
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+		response = otu.SetFeatureRolloutAPI("user1", "unknown", 50)
+		CheckResponseCode(t, http.StatusNotFound, response.Code)
+	})
+
+	t.Run("Community settings should win over the rollout", func(t *testing.T) {
+		disabled := false
+		response := otu.SetCommunityFeatureAPI("user1", models.FlagComments, communityId, &disabled)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		assert.False(t, getFeatures()[models.FlagComments])
+
+		response = otu.SetCommunityFeatureAPI("user1", models.FlagComments, communityId, nil)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		assert.True(t, getFeatures()[models.FlagComments])
+
+		enabled := true
+		response = otu.SetCommunityFeatureAPI("user1", models.FlagDelegation, communityId, &enabled)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		assert.True(t, getFeatures()[models.FlagDelegation])
+		assert.False(t, getFeatures()[models.FlagShieldedVoting])
+	})
+
+	t.Run("Only platform admins should change flags", func(t *testing.T) {
+		response := otu.SetFeatureRolloutAPI("user2", models.FlagShieldedVoting, 100)
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.GetFeatureFlagsAPI(otu.Login("user2"))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.GetFeatureFlagsAPI(otu.Login("user1"))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var flags []models.FeatureFlag
+		json.Unmarshal(response.Body.Bytes(), &flags)
+		assert.Equal(t, 3, len(flags))
+	})
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/tests/test_utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommunityFeed(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("community_events")
+	clearTable("proposals")
+	clearTable("votes")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+
+	feed := func(count int) test_utils.PaginatedResponseWithEvents {
+		response := otu.GetCommunityFeedAPI(communityId, count)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var page test_utils.PaginatedResponseWithEvents
+		json.Unmarshal(response.Body.Bytes(), &page)
+		return page
+	}
+
+	var proposal models.Proposal
+
+	t.Run("Created proposals should be in the feed", func(t *testing.T) {
+		response := otu.CreateProposalAPI(otu.GenerateProposalPayload("user1", otu.GenerateProposalStruct("user1", communityId)))
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+		json.Unmarshal(response.Body.Bytes(), &proposal)
+
+		event := feed(10).Data[0]
+		assert.Equal(t, models.EventProposalCreated, event.Event_type)
+		assert.Equal(t, proposal.ID, *event.Proposal_id)
+	})
+
+	t.Run("New members should be in the feed", func(t *testing.T) {
+		member := otu.GenerateCommunityUserStruct("user2", "member")
+		member.Community_id = communityId
+		response := otu.CreateCommunityUserAPI(communityId, otu.GenerateCommunityUserPayload("user2", member))
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+
+		event := feed(10).Data[0]
+		assert.Equal(t, models.EventRoleGranted, event.Event_type)
+		assert.Equal(t, otu.AddressOf("user2"), *event.Actor_addr)
+	})
+
+	t.Run("Vote milestones should be in the feed once", func(t *testing.T) {
+		// open the proposal for voting
+		A.DB.Conn.Exec(A.DB.Context,
+			`UPDATE proposals SET start_time = (now() at time zone 'utc') - interval '1 hour' WHERE id = $1`, proposal.ID)
+		for i := 1; i < models.VOTE_MILESTONES[0]; i++ {
+			A.DB.Conn.Exec(A.DB.Context, `
+				INSERT INTO votes(proposal_id, addr, choice, composite_signatures, message)
+				VALUES($1, $2, $3, $4, $5)
+			`, proposal.ID, fmt.Sprintf("0x%016x", i), "a", "[]", "__msg__")
+		}
+		response := otu.CreateVoteAPI(proposal.ID, otu.GenerateValidVotePayload("user2", proposal.ID, "a"))
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+
+		event := feed(10).Data[0]
+		assert.Equal(t, models.EventVoteMilestone, event.Event_type)
+		assert.Equal(t, models.VOTE_MILESTONES[0], *event.Milestone)
+
+		response = otu.CreateVoteAPI(proposal.ID, otu.GenerateValidVotePayload("user3", proposal.ID, "a"))
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+		assert.Equal(t, 3, feed(10).TotalRecords)
+	})
+
+	t.Run("Closed proposals should be in the feed", func(t *testing.T) {
+		response := otu.UpdateProposalAPI(proposal.ID, otu.GenerateCancelProposalStruct("user1", proposal.ID))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		event := feed(10).Data[0]
+		assert.Equal(t, models.EventProposalClosed, event.Event_type)
+		assert.Equal(t, proposal.ID, *event.Proposal_id)
+	})
+
+	t.Run("Should page through the feed newest first", func(t *testing.T) {
+		page := feed(2)
+		assert.Equal(t, 4, page.TotalRecords)
+		assert.Len(t, page.Data, 2)
+		assert.Equal(t, models.EventProposalClosed, page.Data[0].Event_type)
+		assert.Equal(t, models.EventVoteMilestone, page.Data[1].Event_type)
+	})
+}
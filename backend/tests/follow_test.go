@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/tests/test_utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFollows(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("community_events")
+	clearTable("proposals")
+	clearTable("follows")
+
+	followedCommunity := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	otherCommunity := otu.AddCommunitiesWithUsers(1, "user4")[0]
+	models.GrantAuthorRolesToAddress(A.DB, otherCommunity, otu.AddressOf("user2"))
+
+	follower := otu.AddressOf("user3")
+	followedAddr := otu.AddressOf("user2")
+	missingCommunity := otherCommunity + 1000
+
+	follows := func() test_utils.PaginatedResponseWithFollows {
+		response := otu.GetFollowsAPI(follower)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var page test_utils.PaginatedResponseWithFollows
+		json.Unmarshal(response.Body.Bytes(), &page)
+		return page
+	}
+
+	feed := func() test_utils.PaginatedResponseWithEvents {
+		response := otu.GetMyFeedAPI(follower, otu.Login("user3"))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var page test_utils.PaginatedResponseWithEvents
+		json.Unmarshal(response.Body.Bytes(), &page)
+		return page
+	}
+
+	propose := func(signer string, communityId int) {
+		payload := otu.GenerateProposalPayload(signer, otu.GenerateProposalStruct(signer, communityId))
+		response := otu.CreateProposalAPI(payload)
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+	}
+
+	t.Run("Should follow exactly one community or address", func(t *testing.T) {
+		response := otu.SetFollowAPI(follower, otu.GenerateFollowPayload("user3", nil, nil), true)
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+
+		response = otu.SetFollowAPI(follower, otu.GenerateFollowPayload("user3", &followedCommunity, &followedAddr), true)
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+
+		response = otu.SetFollowAPI(follower, otu.GenerateFollowPayload("user3", nil, &follower), true)
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+
+		response = otu.SetFollowAPI(follower, otu.GenerateFollowPayload("user3", &missingCommunity, nil), true)
+		CheckResponseCode(t, http.StatusNotFound, response.Code)
+	})
+
+	t.Run("Only the address should change its follows", func(t *testing.T) {
+		response := otu.SetFollowAPI(follower, otu.GenerateFollowPayload("user2", &followedCommunity, nil), true)
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.SetFollowAPI(follower, otu.GenerateFollowPayload("user3", &followedCommunity, nil), true)
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+		var f models.Follow
+		json.Unmarshal(response.Body.Bytes(), &f)
+		assert.Equal(t, follower, f.Follower_addr)
+		assert.Equal(t, followedCommunity, *f.Community_id)
+
+		response = otu.SetFollowAPI(follower, otu.GenerateFollowPayload("user3", nil, &followedAddr), true)
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+
+		// following twice is a no-op
+		response = otu.SetFollowAPI(follower, otu.GenerateFollowPayload("user3", &followedCommunity, nil), true)
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+		assert.Equal(t, 2, follows().TotalRecords)
+	})
+
+	t.Run("The feed should need a session", func(t *testing.T) {
+		response := otu.GetMyFeedAPI(follower, "")
+		CheckResponseCode(t, http.StatusUnauthorized, response.Code)
+	})
+
+	t.Run("The feed should merge followed communities and addresses", func(t *testing.T) {
+		propose("user1", followedCommunity)
+		propose("user2", otherCommunity)
+		propose("user4", otherCommunity)
+
+		page := feed()
+		assert.Equal(t, 2, page.TotalRecords)
+		for _, e := range page.Data {
+			assert.Equal(t, models.EventProposalCreated, e.Event_type)
+			assert.True(t, e.Community_id == followedCommunity || *e.Actor_addr == followedAddr)
+		}
+		// newest first
+		assert.Equal(t, otherCommunity, page.Data[0].Community_id)
+	})
+
+	t.Run("Unfollowed activity should leave the feed", func(t *testing.T) {
+		response := otu.SetFollowAPI(follower, otu.GenerateFollowPayload("user3", nil, &followedAddr), false)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		assert.Equal(t, 1, follows().TotalRecords)
+		page := feed()
+		assert.Equal(t, 1, page.TotalRecords)
+		assert.Equal(t, followedCommunity, page.Data[0].Community_id)
+	})
+}
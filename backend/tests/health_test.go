@@ -0,0 +1,35 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type dependencyHealth struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+}
+
+type healthReport struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]dependencyHealth `json:"dependencies"`
+}
+
+func TestHealth(t *testing.T) {
+	t.Run("Should report the status of each dependency", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/health", nil)
+		response := otu.ExecuteRequest(req)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var report healthReport
+		json.Unmarshal(response.Body.Bytes(), &report)
+		assert.NotEqual(t, "down", report.Status)
+		assert.Equal(t, "ok", report.Dependencies["database"].Status)
+		assert.Equal(t, "ok", report.Dependencies["flow"].Status)
+		assert.Contains(t, report.Dependencies, "ipfs")
+		assert.Contains(t, report.Dependencies, "jobs")
+	})
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/tests/test_utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHomepageCuration(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+
+	A.AdminAllowlist.Set([]string{otu.AddressOf("user1")})
+	defer A.AdminAllowlist.Set(nil)
+
+	ids := otu.AddCommunities(3, "dao")
+	otu.MakeFeaturedCommunity(ids[0])
+	otu.MakeFeaturedCommunity(ids[1])
+
+	homepageIds := func() []int {
+		response := otu.GetCommunitiesForHomepageAPI()
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var p test_utils.PaginatedResponseWithCommunity
+		json.Unmarshal(response.Body.Bytes(), &p)
+		communityIds := []int{}
+		for _, c := range p.Data {
+			communityIds = append(communityIds, c.ID)
+		}
+		return communityIds
+	}
+
+	t.Run("Pinned communities should lead in homepage order", func(t *testing.T) {
+		response := otu.AdminCommunityAPI(ids[2], "pin", otu.GenerateTimestampSignaturePayload("user1"))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		response = otu.SetHomepageOrderAPI("user1", []int{ids[1], ids[0]})
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, []int{ids[2], ids[1], ids[0]}, homepageIds())
+
+		response = otu.AdminCommunityAPI(ids[2], "unpin", otu.GenerateTimestampSignaturePayload("user1"))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, []int{ids[1], ids[0]}, homepageIds())
+	})
+
+	t.Run("Curated sections should be assembled on the homepage", func(t *testing.T) {
+		title, kind, category := "DAOs", models.SectionCategory, "dao"
+		response := otu.HomepageSectionAPI("POST", 0, &models.HomepageSectionPayload{
+			TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload("user1"),
+			Title:                     &title,
+			Kind:                      &kind,
+		})
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+
+		order := 10
+		response = otu.HomepageSectionAPI("POST", 0, &models.HomepageSectionPayload{
+			TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload("user1"),
+			Title:                     &title,
+			Kind:                      &kind,
+			Category:                  &category,
+			Display_order:             &order,
+		})
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+		var section models.HomepageSection
+		json.Unmarshal(response.Body.Bytes(), &section)
+
+		response = otu.GetHomepageAPI()
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var sections []models.HomepageSection
+		json.Unmarshal(response.Body.Bytes(), &sections)
+		last := sections[len(sections)-1]
+		assert.Equal(t, section.ID, last.ID)
+		assert.Equal(t, 3, len(last.Communities))
+		assert.Equal(t, models.SectionFeatured, sections[0].Kind)
+		assert.Equal(t, ids[1], sections[0].Communities[0].ID)
+
+		response = otu.HomepageSectionAPI("DELETE", section.ID, &models.HomepageSectionPayload{
+			TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload("user1"),
+		})
+		CheckResponseCode(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Only platform admins should curate the homepage", func(t *testing.T) {
+		response := otu.SetHomepageOrderAPI("user2", []int{ids[0]})
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.AdminCommunityAPI(ids[0], "pin", otu.GenerateTimestampSignaturePayload("user2"))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+	})
+}
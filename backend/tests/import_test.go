@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/tests/test_utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommunityImport(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	clearTable("votes")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	proposalId := otu.AddActiveProposals(communityId, 1)[0]
+	response := otu.CreateVoteAPI(proposalId, otu.GenerateValidVotePayload("user2", proposalId, "a"))
+	CheckResponseCode(t, http.StatusCreated, response.Code)
+
+	// every case gets its own copy of the exported archive to tamper with
+	archive := func(tamper func(a *models.CommunityArchive)) []byte {
+		a, err := models.GetCommunityArchive(A.DB, communityId)
+		assert.NoError(t, err)
+		if tamper != nil {
+			tamper(&a)
+		}
+		data, _ := json.Marshal(a)
+		return data
+	}
+
+	t.Run("Only an admin of the exported community should import it", func(t *testing.T) {
+		response := otu.ImportCommunityAPI(otu.GenerateImportPayload("user2"), archive(nil))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("Tampered archives should be rejected", func(t *testing.T) {
+		invalidCid := "not-a-cid"
+		tampered := []func(a *models.CommunityArchive){
+			// the vote message no longer matches the choice
+			func(a *models.CommunityArchive) { a.Votes[0].Choice = "b" },
+			// the vote was signed by someone else
+			func(a *models.CommunityArchive) { a.Votes[0].Addr = otu.AddressOf("user3") },
+			// the vote was signed for another proposal
+			func(a *models.CommunityArchive) { a.Votes[0].Proposal_id = proposalId + 1000 },
+			func(a *models.CommunityArchive) { a.Proposals[0].Cid = &invalidCid },
+		}
+		for _, tamper := range tampered {
+			response := otu.ImportCommunityAPI(otu.GenerateImportPayload("user1"), archive(tamper))
+			CheckResponseCode(t, http.StatusBadRequest, response.Code)
+		}
+	})
+
+	t.Run("Should import a valid archive once per signature", func(t *testing.T) {
+		payload := otu.GenerateImportPayload("user1")
+		response := otu.ImportCommunityAPI(payload, archive(nil))
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+
+		var c models.Community
+		json.Unmarshal(response.Body.Bytes(), &c)
+		assert.NotEqual(t, communityId, c.ID)
+
+		response = otu.GetProposalsForCommunityAPI(c.ID)
+		var p test_utils.PaginatedResponseWithProposals
+		json.Unmarshal(response.Body.Bytes(), &p)
+		assert.Len(t, p.Data, 1)
+
+		response = otu.ImportCommunityAPI(payload, archive(nil))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+	})
+}
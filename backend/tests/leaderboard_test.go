@@ -140,7 +140,7 @@ func TestGetLeaderboardWithWinningVote(t *testing.T) {
 
 	proposalId := otu.GenerateWinningVoteAchievement(communityId, "token-weighted-default")
 	otu.UpdateProposalEndTime(proposalId, time.Now().UTC())
-	otu.GetProposalResultsAPI(proposalId)
+	otu.CloseProposals()
 
 	response := otu.GetCommunityLeaderboardAPI(communityId)
 	checkResponseCode(t, http.StatusOK, response.Code)
@@ -171,6 +171,57 @@ func TestGetLeaderboardWithWinningVote(t *testing.T) {
 	assert.Equal(t, expectedLosers, receivedLosers)
 }
 
+func TestGetLeaderboardWithWindowAndMetric(t *testing.T) {
+	resetTables()
+
+	communityId := otu.AddCommunities(1, "dao")[0]
+	numProposals := 2
+	numUsers := 3
+	otu.GenerateVotes(communityId, numProposals, numUsers)
+	otu.RefreshLeaderboards()
+
+	response := otu.GetCommunityLeaderboardAPIWithMetric(communityId, "30d", "votes")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var p test_utils.PaginatedResponseWithLeaderboardUser
+	json.Unmarshal(response.Body.Bytes(), &p)
+
+	assert.Equal(t, numUsers, len(p.Data.Users))
+	assert.Equal(t, numProposals, p.Data.Users[0].Score)
+	assert.Equal(t, "votes", p.Data.Metric)
+
+	response = otu.GetCommunityLeaderboardAPIWithMetric(communityId, "7d", "votes")
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+}
+
+func TestGetUserAchievements(t *testing.T) {
+	resetTables()
+
+	communityId := otu.AddCommunities(1, "dao")[0]
+	proposalId := otu.GenerateWinningVoteAchievement(communityId, "token-weighted-default")
+	otu.UpdateProposalEndTime(proposalId, time.Now().UTC())
+
+	// closing and computing again must not award anything twice
+	otu.CloseProposals()
+	otu.CloseProposals()
+	otu.ComputeAchievements()
+
+	winner := otu.GenerateValidVotePayload("user2", proposalId, "a").Addr
+	response := otu.GetUserAchievementsAPI(winner, communityId)
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var p test_utils.PaginatedResponseWithAchievements
+	json.Unmarshal(response.Body.Bytes(), &p)
+
+	types := map[string]int{}
+	for _, a := range p.Data {
+		types[a.Achievement_type] += 1
+	}
+
+	assert.Equal(t, 1, types[models.AchievementFirstVote])
+	assert.Equal(t, 1, types[models.AchievementWinningVote])
+}
+
 func TestGetLeaderboardWithCancelledProposal(t *testing.T) {
 	authorName := "user1"
 
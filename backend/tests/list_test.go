@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/DapperCollectives/CAST/backend/tests/test_utils"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -59,7 +62,13 @@ func TestCreateList(t *testing.T) {
 		var list models.List
 		json.Unmarshal(response.Body.Bytes(), &list)
 
-		assert.NotNil(t, list.Cid)
+		// lists are pinned to IPFS in the background
+		assert.Nil(t, list.Cid)
+		otu.ProcessPins()
+		pinned := models.List{ID: list.ID}
+		assert.Nil(t, pinned.GetListById(A.DB))
+		assert.NotNil(t, pinned.Cid)
+
 		addressLength := len(list.Addresses)
 		assert.Equal(t, len(listStruct.Addresses), addressLength)
 		assert.Equal(t, *listStruct.List_type, *list.List_type)
@@ -158,3 +167,167 @@ func TestUpdateList(t *testing.T) {
 	})
 
 }
+
+func TestListCSV(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	clearTable("lists")
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	listId := otu.AddLists(communityId, 1)[0]
+
+	t.Run("Uploading a CSV should add valid addresses and report the rest", func(t *testing.T) {
+		payload := otu.GenerateUpdateListPayload(listId, communityId, "user1")
+		csv := "address,name\n0x04,alice\n0X05,bob\n0x04,carol\nnot-an-address,dave\n0x01,erin\n"
+		response := otu.UploadListCSVAPI(listId, payload, csv)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		var report models.ListCSVReport
+		json.Unmarshal(response.Body.Bytes(), &report)
+		assert.Equal(t, []string{"0x04", "0x05"}, report.Added)
+		assert.Equal(t, []models.ListCSVRow{{Line: 5, Value: "not-an-address"}}, report.Malformed)
+		assert.Equal(t, 2, len(report.Duplicates))
+
+		response = otu.GetListByIdAPI(listId)
+		var list models.List
+		json.Unmarshal(response.Body.Bytes(), &list)
+		assert.Equal(t, 5, len(list.Addresses))
+	})
+
+	t.Run("Uploading a CSV without permission should be forbidden", func(t *testing.T) {
+		payload := otu.GenerateUpdateListPayload(listId, communityId, "user2")
+		response := otu.UploadListCSVAPI(listId, payload, "0x06\n")
+		checkResponseCode(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("Downloading a list should return its addresses as CSV", func(t *testing.T) {
+		response := otu.DownloadListCSVAPI(listId)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		lines := strings.Split(strings.TrimSpace(response.Body.String()), "\n")
+		assert.Equal(t, "addr", lines[0])
+		assert.Equal(t, 6, len(lines))
+	})
+}
+
+func TestDynamicList(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	clearTable("lists")
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+
+	name := "FlowToken"
+	addr := "0x0ae53cb6e3f42a79"
+	path := "flowTokenBalance"
+	listType := "allow"
+
+	t.Run("Should reject a dynamic list with an invalid rule", func(t *testing.T) {
+		list := otu.GenerateBlockListStruct(communityId)
+		list.List_type = &listType
+		list.Rule = &models.ListRule{Type: "unknown"}
+		response := otu.CreateListAPI(otu.GenerateBlockListPayload("user1", list))
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("Should create a dynamic list and refuse manual edits", func(t *testing.T) {
+		list := otu.GenerateBlockListStruct(communityId)
+		list.List_type = &listType
+		list.Rule = &models.ListRule{
+			Type:       models.ListRuleFTBalance,
+			Contract:   shared.Contract{Name: &name, Addr: &addr, Public_path: &path},
+			Min_amount: 10,
+		}
+		response := otu.CreateListAPI(otu.GenerateBlockListPayload("user1", list))
+		checkResponseCode(t, http.StatusCreated, response.Code)
+
+		var created models.List
+		json.Unmarshal(response.Body.Bytes(), &created)
+		assert.Equal(t, 0, len(created.Addresses))
+		assert.Equal(t, models.ListRuleFTBalance, created.Rule.Type)
+
+		payload := otu.GenerateUpdateListPayload(created.ID, communityId, "user1")
+		response = otu.AddAddressesToListAPI(created.ID, payload)
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+}
+
+func TestListVersions(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	clearTable("lists")
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	listId := otu.AddLists(communityId, 1)[0]
+
+	payload := otu.GenerateUpdateListPayload(listId, communityId, "user1")
+	response := otu.AddAddressesToListAPI(listId, payload)
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	t.Run("Every modification should create a list version", func(t *testing.T) {
+		response := otu.GetListVersionsAPI(listId)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		var body test_utils.PaginatedResponseWithListVersions
+		json.Unmarshal(response.Body.Bytes(), &body)
+		assert.Equal(t, 2, body.TotalRecords)
+		assert.Equal(t, 2, body.Data[0].Version)
+		assert.Equal(t, 5, len(body.Data[0].Addresses))
+	})
+
+	t.Run("Earlier versions should keep their contents", func(t *testing.T) {
+		response := otu.GetListVersionAPI(listId, 1)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		var v models.ListVersion
+		json.Unmarshal(response.Body.Bytes(), &v)
+		assert.Equal(t, []string{"0x01", "0x02", "0x03"}, v.Addresses)
+
+		response = otu.GetListVersionAPI(listId, 3)
+		checkResponseCode(t, http.StatusNotFound, response.Code)
+	})
+}
+
+func TestCombineLists(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	clearTable("lists")
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	listIds := otu.AddLists(communityId, 2)
+
+	// second list holds 0x01 - 0x05
+	payload := otu.GenerateUpdateListPayload(listIds[1], communityId, "user1")
+	response := otu.AddAddressesToListAPI(listIds[1], payload)
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	cases := []struct {
+		operation string
+		left      int
+		right     int
+		expected  []string
+	}{
+		{models.ListUnion, listIds[0], listIds[1], []string{"0x01", "0x02", "0x03", "0x04", "0x05"}},
+		{models.ListIntersection, listIds[0], listIds[1], []string{"0x01", "0x02", "0x03"}},
+		{models.ListDifference, listIds[1], listIds[0], []string{"0x04", "0x05"}},
+	}
+
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("Should compute the %s of two lists into a new list", c.operation), func(t *testing.T) {
+			payload := otu.GenerateListSetOperationPayload(c.operation, c.left, c.right, "user1")
+			response := otu.CombineListsAPI(communityId, payload)
+			checkResponseCode(t, http.StatusCreated, response.Code)
+
+			var list models.List
+			json.Unmarshal(response.Body.Bytes(), &list)
+			assert.Equal(t, c.expected, list.Addresses)
+			assert.NotContains(t, listIds, list.ID)
+		})
+	}
+
+	t.Run("Should reject an unknown operation", func(t *testing.T) {
+		payload := otu.GenerateListSetOperationPayload("xor", listIds[0], listIds[1], "user1")
+		response := otu.CombineListsAPI(communityId, payload)
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+}
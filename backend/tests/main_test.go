@@ -161,7 +161,7 @@ func TestMain(m *testing.M) {
 		customScriptsMap[script.Key] = script
 	}
 
-	adapter := shared.NewFlowClient(os.Getenv("FLOW_ENV"), customScriptsMap)
+	adapter := shared.NewFlowClient(os.Getenv("FLOW_ENV"), A.Config.FlowNodeConfig, customScriptsMap)
 
 	// Setup overflow test utils struct
 	otu = &utils.OverflowTestUtils{T: nil, A: &A, O: O, Adapter: adapter}
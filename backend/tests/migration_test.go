@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/migrations"
+	"github.com/golang-migrate/migrate/v4"
+	pgxmigrate "github.com/golang-migrate/migrate/v4/database/pgx"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v4/stdlib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrations(t *testing.T) {
+	src, err := iofs.New(migrations.FS, ".")
+	assert.NoError(t, err)
+	driver, err := pgxmigrate.WithInstance(stdlib.OpenDB(*A.DB.Pool.Config().ConnConfig), &pgxmigrate.Config{})
+	assert.NoError(t, err)
+	m, err := migrate.NewWithInstance("iofs", src, "pgx", driver)
+	assert.NoError(t, err)
+	defer m.Close()
+
+	latest, _, err := m.Version()
+	assert.NoError(t, err)
+
+	// leave the schema where the rest of the suite expects it
+	defer func() {
+		if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			t.Fatalf("restoring the schema: %v", err)
+		}
+		// pooled connections cache statements planned against the dropped schema
+		for _, c := range A.DB.Pool.AcquireAllIdle(A.DB.Context) {
+			c.Conn().Close(A.DB.Context)
+			c.Release()
+		}
+	}()
+
+	t.Run("Every migration should apply down and up again", func(t *testing.T) {
+		assert.NoError(t, m.Down())
+		_, _, err := m.Version()
+		assert.ErrorIs(t, err, migrate.ErrNilVersion)
+
+		assert.NoError(t, m.Up())
+		version, dirty, err := m.Version()
+		assert.NoError(t, err)
+		assert.False(t, dirty)
+		assert.Equal(t, latest, version)
+	})
+
+	t.Run("Startup should refuse a stale schema", func(t *testing.T) {
+		assert.NoError(t, m.Steps(-1))
+
+		err := A.CheckSchemaVersion()
+		assert.ErrorContains(t, err, "run migrate up")
+
+		A.Config.Db_auto_migrate = true
+		defer func() { A.Config.Db_auto_migrate = false }()
+		assert.NoError(t, A.CheckSchemaVersion())
+
+		version, _, err := m.Version()
+		assert.NoError(t, err)
+		assert.Equal(t, latest, version)
+	})
+
+	t.Run("Startup should refuse a dirty schema", func(t *testing.T) {
+		assert.NoError(t, driver.SetVersion(int(latest), true))
+		defer m.Force(int(latest))
+
+		assert.ErrorContains(t, A.CheckSchemaVersion(), "dirty")
+	})
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/DapperCollectives/CAST/backend/tests/test_utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentModeration(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	clearTable("moderation_rules")
+	clearTable("moderation_decisions")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	models.GrantAuthorRolesToAddress(A.DB, communityId, otu.AddressOf("user2"))
+	_, err := A.DB.Conn.Exec(A.DB.Context,
+		`INSERT INTO moderation_rules(community_id, pattern, weight, created_by) VALUES($1, 'casino', 1, $2)`,
+		communityId, test_utils.UserOneAddr)
+	assert.NoError(t, err)
+
+	createProposal := func(signer, name string) models.Proposal {
+		p := otu.GenerateProposalStruct(signer, communityId)
+		p.Name = name
+		response := otu.CreateProposalAPI(otu.GenerateProposalPayload(signer, p))
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+		var created models.Proposal
+		json.Unmarshal(response.Body.Bytes(), &created)
+		return created
+	}
+
+	t.Run("Should hold proposals that match the community's rules", func(t *testing.T) {
+		p := createProposal("user2", "Free Casino bonus for voters")
+		assert.True(t, p.IsAwaitingReview())
+
+		p = createProposal("user2", "Fund the community garden")
+		assert.False(t, p.IsAwaitingReview())
+	})
+
+	t.Run("Should not hold proposals of moderators", func(t *testing.T) {
+		p := createProposal("user1", "Casino night for the community")
+		assert.False(t, p.IsAwaitingReview())
+	})
+
+	t.Run("Should hold proposals the classifier scores high", func(t *testing.T) {
+		classifier := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var content shared.ModerationContent
+			json.NewDecoder(r.Body).Decode(&content)
+			assert.Equal(t, models.ModerationProposal, content.Kind)
+			json.NewEncoder(w).Encode(shared.ModerationScore{Score: 0.9, Labels: []string{"spam"}})
+		}))
+		defer classifier.Close()
+		A.Classifier = shared.NewHttpClassifier(classifier.URL, "")
+		defer func() { A.Classifier = nil }()
+
+		p := createProposal("user2", "Click here for a prize")
+		assert.True(t, p.IsAwaitingReview())
+	})
+
+	t.Run("Should let proposals through when the classifier is down", func(t *testing.T) {
+		A.Classifier = shared.NewHttpClassifier("http://127.0.0.1:1", "")
+		defer func() { A.Classifier = nil }()
+
+		p := createProposal("user2", "Plant more trees")
+		assert.False(t, p.IsAwaitingReview())
+	})
+
+	t.Run("Should log decisions for moderators only", func(t *testing.T) {
+		response := otu.GetModerationDecisionsAPI(communityId, "hold", otu.Login("user2"))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.GetModerationDecisionsAPI(communityId, "hold", otu.Login("user1"))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var page struct {
+			Data         []models.ModerationDecision `json:"data"`
+			TotalRecords int                         `json:"totalRecords"`
+		}
+		json.Unmarshal(response.Body.Bytes(), &page)
+		assert.Equal(t, 2, page.TotalRecords)
+		if assert.Equal(t, 2, len(page.Data)) {
+			assert.Equal(t, []string{"spam"}, page.Data[0].Labels)
+			assert.Equal(t, []string{"keyword:casino"}, page.Data[1].Labels)
+		}
+
+		response = otu.GetModerationDecisionsAPI(communityId, "", otu.Login("user1"))
+		json.Unmarshal(response.Body.Bytes(), &page)
+		assert.Equal(t, 5, page.TotalRecords)
+		assert.Contains(t, page.Data[0].Labels, "classifier-unavailable")
+	})
+
+	t.Run("Should manage rules with a moderator's signature", func(t *testing.T) {
+		payload := otu.GenerateModerationRulePayload("user1", "Airdrop", 0.5)
+		response := otu.CreateModerationRuleAPI(communityId, payload)
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+		var rule models.ModerationRule
+		json.Unmarshal(response.Body.Bytes(), &rule)
+		assert.Equal(t, "airdrop", rule.Pattern)
+
+		response = otu.CreateModerationRuleAPI(communityId, otu.GenerateModerationRulePayload("user2", "giveaway", 0.5))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.GetModerationRulesAPI(communityId, otu.Login("user1"))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var rules []models.ModerationRule
+		json.Unmarshal(response.Body.Bytes(), &rules)
+		assert.Equal(t, 2, len(rules))
+
+		deletion := otu.GenerateTimestampSignaturePayload("user1")
+		response = otu.DeleteModerationRuleAPI(communityId, rule.ID, &deletion)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		response = otu.DeleteModerationRuleAPI(communityId, rule.ID, &deletion)
+		CheckResponseCode(t, http.StatusNotFound, response.Code)
+	})
+}
+
+func TestKeywordRules(t *testing.T) {
+	rules := models.KeywordRules{
+		{Pattern: "casino", Weight: 0.6},
+		{Pattern: "free money", Weight: 0.6},
+	}
+	score := func(body string) shared.ModerationScore {
+		s, err := rules.Score(shared.ModerationContent{Body: body})
+		assert.NoError(t, err)
+		return s
+	}
+
+	assert.Equal(t, 0.0, score("New casinos open").Score)
+	assert.Equal(t, 0.6, score("<p>CASINO</p>").Score)
+	assert.Equal(t, 1.0, score("Casino! Free money!").Score)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/tests/test_utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotifications(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("community_events")
+	clearTable("proposals")
+	clearTable("follows")
+	clearTable("notifications")
+	clearTable("notification_preferences")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	member, optedOut := otu.AddressOf("user2"), otu.AddressOf("user3")
+	for _, addr := range []string{member, optedOut} {
+		u := models.CommunityUser{Community_id: communityId, Addr: addr, User_type: "member"}
+		assert.NoError(t, u.CreateCommunityUser(A.DB))
+	}
+
+	inbox := func(addr, signer string, unread bool) test_utils.PaginatedResponseWithNotifications {
+		response := otu.GetNotificationsAPI(addr, otu.Login(signer))
+		if unread {
+			response = otu.GetUnreadNotificationsAPI(addr, otu.Login(signer))
+		}
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var page test_utils.PaginatedResponseWithNotifications
+		json.Unmarshal(response.Body.Bytes(), &page)
+		return page
+	}
+
+	t.Run("Every notification type should be enabled by default", func(t *testing.T) {
+		response := otu.GetNotificationPreferencesAPI(optedOut)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var preferences []models.NotificationPreference
+		json.Unmarshal(response.Body.Bytes(), &preferences)
+		assert.Len(t, preferences, len(models.NOTIFICATION_TYPES))
+		for _, p := range preferences {
+			assert.True(t, p.Enabled)
+		}
+	})
+
+	t.Run("Only the address should change its preferences", func(t *testing.T) {
+		off := []models.NotificationPreference{{Notification_type: models.NotificationProposalOpened}}
+
+		response := otu.UpdateNotificationPreferencesAPI(optedOut, "user2", off)
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		unknown := []models.NotificationPreference{{Notification_type: "gossip"}}
+		response = otu.UpdateNotificationPreferencesAPI(optedOut, "user3", unknown)
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+
+		response = otu.UpdateNotificationPreferencesAPI(optedOut, "user3", off)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var preferences []models.NotificationPreference
+		json.Unmarshal(response.Body.Bytes(), &preferences)
+		for _, p := range preferences {
+			assert.Equal(t, p.Notification_type != models.NotificationProposalOpened, p.Enabled)
+		}
+	})
+
+	t.Run("Members should be notified of new proposals unless opted out", func(t *testing.T) {
+		response := otu.CreateProposalAPI(otu.GenerateProposalPayload("user1", otu.GenerateProposalStruct("user1", communityId)))
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+		var p models.Proposal
+		json.Unmarshal(response.Body.Bytes(), &p)
+
+		page := inbox(member, "user2", true)
+		assert.Equal(t, 1, page.TotalRecords)
+		assert.Equal(t, models.NotificationProposalOpened, page.Data[0].Notification_type)
+		assert.Equal(t, p.ID, *page.Data[0].Proposal_id)
+
+		assert.Equal(t, 0, inbox(optedOut, "user3", false).TotalRecords)
+		// the author is not notified of its own proposal
+		assert.Equal(t, 0, inbox(otu.AddressOf("user1"), "user1", false).TotalRecords)
+	})
+
+	t.Run("Only the address should mark its inbox read", func(t *testing.T) {
+		ids := []int{inbox(member, "user2", true).Data[0].ID}
+
+		response := otu.MarkNotificationsReadAPI(member, "user3", ids)
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.MarkNotificationsReadAPI(member, "user2", ids)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var marked map[string]int
+		json.Unmarshal(response.Body.Bytes(), &marked)
+		assert.Equal(t, 1, marked["updated"])
+
+		assert.Equal(t, 0, inbox(member, "user2", true).TotalRecords)
+		page := inbox(member, "user2", false)
+		assert.Equal(t, 1, page.TotalRecords)
+		assert.NotNil(t, page.Data[0].Read_at)
+	})
+}
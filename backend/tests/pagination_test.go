@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type pageLinks struct {
+	Data    []interface{} `json:"data"`
+	Next    int           `json:"next"`
+	Prev    int           `json:"prev"`
+	NextUrl *string       `json:"nextUrl"`
+	PrevUrl *string       `json:"prevUrl"`
+}
+
+func TestPaginationLinks(t *testing.T) {
+	clearTable("communities")
+	otu.AddCommunities(3, "dao")
+
+	getPage := func(path string) (pageLinks, http.Header) {
+		req, _ := http.NewRequest("GET", path, nil)
+		response := otu.ExecuteRequest(req)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var page pageLinks
+		json.Unmarshal(response.Body.Bytes(), &page)
+		return page, response.Header()
+	}
+
+	t.Run("Pages should link the pages around them", func(t *testing.T) {
+		page, header := getPage("/communities?start=1&count=1&sort=")
+		assert.Equal(t, 2, page.Next)
+		assert.Equal(t, 0, page.Prev)
+		assert.True(t, strings.HasSuffix(*page.NextUrl, "/communities?count=1&sort=&start=2"))
+		assert.True(t, strings.HasSuffix(*page.PrevUrl, "/communities?count=1&sort=&start=0"))
+		assert.Equal(t,
+			fmt.Sprintf(`<%s>; rel="next", <%s>; rel="prev"`, *page.NextUrl, *page.PrevUrl),
+			header.Get("Link"),
+		)
+	})
+
+	t.Run("The last page should have no next link", func(t *testing.T) {
+		page, header := getPage("/communities?start=2&count=1")
+		assert.Equal(t, -1, page.Next)
+		assert.Nil(t, page.NextUrl)
+		assert.NotContains(t, header.Get("Link"), `rel="next"`)
+	})
+
+	t.Run("Empty pages should have an empty list", func(t *testing.T) {
+		page, _ := getPage("/communities?start=5")
+		assert.NotNil(t, page.Data)
+		assert.Equal(t, 0, len(page.Data))
+		assert.Equal(t, -1, page.Next)
+	})
+}
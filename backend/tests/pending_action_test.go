@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	utils "github.com/DapperCollectives/CAST/backend/tests/test_utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPendingActions(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("community_pending_actions")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	models.GrantAdminRolesToAddress(otu.A.DB, communityId, otu.ResolveUser(2))
+
+	t.Run("Should apply actions of single approval communities right away", func(t *testing.T) {
+		payload := otu.GeneratePendingActionPayload("user1", models.ActionSetApprovalsRequired,
+			models.SetApprovalsRequiredAction{Required: 2})
+		response := otu.ProposeCommunityActionAPI(communityId, payload)
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+
+		var action models.PendingAction
+		json.Unmarshal(response.Body.Bytes(), &action)
+		assert.Equal(t, models.ActionApplied, action.Status)
+
+		var c models.Community
+		json.Unmarshal(otu.GetCommunityAPI(communityId).Body.Bytes(), &c)
+		assert.Equal(t, 2, c.Admin_approvals_required)
+	})
+
+	t.Run("Should not require more approvals than there are admins", func(t *testing.T) {
+		payload := otu.GeneratePendingActionPayload("user1", models.ActionSetApprovalsRequired,
+			models.SetApprovalsRequiredAction{Required: 3})
+		response := otu.ProposeCommunityActionAPI(communityId, payload)
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+
+		var action models.PendingAction
+		json.Unmarshal(response.Body.Bytes(), &action)
+
+		response = otu.ResolveCommunityActionAPI(communityId, action.ID, "approve", otu.GeneratePendingActionApproval("user2"))
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("Should reject direct strategy updates", func(t *testing.T) {
+		payload := otu.GenerateCommunityPayload("user1", otu.GenerateCommunityStruct("user1", "dao"))
+		response := otu.UpdateCommunityAPI(communityId, payload)
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		var e errorResponse
+		json.Unmarshal(response.Body.Bytes(), &e)
+		assert.Equal(t, "ERR_1021", e.ErrorCode)
+	})
+
+	t.Run("Should only let admins approve actions", func(t *testing.T) {
+		payload := otu.GeneratePendingActionPayload("user1", models.ActionArchive, nil)
+		response := otu.ProposeCommunityActionAPI(communityId, payload)
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+
+		var action models.PendingAction
+		json.Unmarshal(response.Body.Bytes(), &action)
+		assert.Equal(t, models.ActionPending, action.Status)
+
+		response = otu.ResolveCommunityActionAPI(communityId, action.ID, "approve", otu.GeneratePendingActionApproval("user3"))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.ResolveCommunityActionAPI(communityId, action.ID, "approve", otu.GeneratePendingActionApproval("user1"))
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+
+		response = otu.ResolveCommunityActionAPI(communityId, action.ID, "cancel", otu.GeneratePendingActionApproval("user2"))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Should apply actions once enough admins approved", func(t *testing.T) {
+		payload := otu.GeneratePendingActionPayload("user1", models.ActionArchive, nil)
+		response := otu.ProposeCommunityActionAPI(communityId, payload)
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+
+		var action models.PendingAction
+		json.Unmarshal(response.Body.Bytes(), &action)
+
+		response = otu.ResolveCommunityActionAPI(communityId, action.ID, "approve", otu.GeneratePendingActionApproval("user2"))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		json.Unmarshal(response.Body.Bytes(), &action)
+		assert.Equal(t, models.ActionApplied, action.Status)
+		assert.Len(t, action.Approvals, 2)
+
+		var c models.Community
+		json.Unmarshal(otu.GetCommunityAPI(communityId).Body.Bytes(), &c)
+		assert.True(t, c.Is_archived)
+
+		response = otu.GetCommunityActionsAPI(communityId, models.ActionPending)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var pending []models.PendingAction
+		json.Unmarshal(response.Body.Bytes(), &pending)
+		assert.Len(t, pending, 1)
+	})
+}
+
+func TestPendingStrategyAndMemberActions(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("community_pending_actions")
+	clearTable("community_strategy_versions")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	models.GrantAdminRolesToAddress(otu.A.DB, communityId, otu.ResolveUser(2))
+	models.GrantAdminRolesToAddress(otu.A.DB, communityId, otu.ResolveUser(3))
+
+	payload := otu.GeneratePendingActionPayload("user1", models.ActionSetApprovalsRequired,
+		models.SetApprovalsRequiredAction{Required: 2})
+	response := otu.ProposeCommunityActionAPI(communityId, payload)
+	CheckResponseCode(t, http.StatusCreated, response.Code)
+
+	t.Run("Should apply approved strategy updates", func(t *testing.T) {
+		payload := otu.GeneratePendingActionPayload("user1", models.ActionUpdateStrategies,
+			models.UpdateStrategiesAction{Strategies: *utils.UpdatedCommunity.Strategies})
+		response := otu.ProposeCommunityActionAPI(communityId, payload)
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+
+		var action models.PendingAction
+		json.Unmarshal(response.Body.Bytes(), &action)
+		assert.Equal(t, models.ActionPending, action.Status)
+
+		response = otu.ResolveCommunityActionAPI(communityId, action.ID, "approve", otu.GeneratePendingActionApproval("user2"))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		json.Unmarshal(response.Body.Bytes(), &action)
+		assert.Equal(t, models.ActionApplied, action.Status)
+
+		var c models.Community
+		json.Unmarshal(otu.GetCommunityAPI(communityId).Body.Bytes(), &c)
+		assert.Len(t, *c.Strategies, 2)
+	})
+
+	t.Run("Should apply approved member purges", func(t *testing.T) {
+		payload := otu.GeneratePendingActionPayload("user1", models.ActionPurgeMembers,
+			models.PurgeMembersAction{Addresses: []string{otu.ResolveUser(3)}})
+		response := otu.ProposeCommunityActionAPI(communityId, payload)
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+
+		var action models.PendingAction
+		json.Unmarshal(response.Body.Bytes(), &action)
+
+		response = otu.ResolveCommunityActionAPI(communityId, action.ID, "approve", otu.GeneratePendingActionApproval("user2"))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		json.Unmarshal(response.Body.Bytes(), &action)
+		assert.Equal(t, models.ActionApplied, action.Status)
+
+		admins, _ := models.CountCommunityAdmins(otu.A.DB, communityId)
+		assert.Equal(t, 2, admins)
+	})
+
+	t.Run("Should roll back purges leaving fewer admins than approvals required", func(t *testing.T) {
+		payload := otu.GeneratePendingActionPayload("user1", models.ActionPurgeMembers,
+			models.PurgeMembersAction{Addresses: []string{otu.ResolveUser(2)}})
+		response := otu.ProposeCommunityActionAPI(communityId, payload)
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+
+		var action models.PendingAction
+		json.Unmarshal(response.Body.Bytes(), &action)
+
+		response = otu.ResolveCommunityActionAPI(communityId, action.ID, "approve", otu.GeneratePendingActionApproval("user2"))
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+
+		admins, _ := models.CountCommunityAdmins(otu.A.DB, communityId)
+		assert.Equal(t, 2, admins)
+
+		response = otu.GetCommunityActionsAPI(communityId, models.ActionPending)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var pending []models.PendingAction
+		json.Unmarshal(response.Body.Bytes(), &pending)
+		assert.Len(t, pending, 1)
+	})
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPermissionMatrix(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	models.GrantAuthorRolesToAddress(A.DB, communityId, otu.AddressOf("user2"))
+
+	permissionsOf := func(signer string) []string {
+		response := otu.GetUserPermissionsAPI(communityId, otu.AddressOf(signer))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var permissions []string
+		json.Unmarshal(response.Body.Bytes(), &permissions)
+		return permissions
+	}
+
+	t.Run("Should list the permissions and those of the built-in roles", func(t *testing.T) {
+		response := otu.GetPermissionsAPI()
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var matrix struct {
+			Permissions  []string            `json:"permissions"`
+			DefaultRoles map[string][]string `json:"defaultRoles"`
+		}
+		json.Unmarshal(response.Body.Bytes(), &matrix)
+		assert.Equal(t, models.PERMISSIONS, matrix.Permissions)
+		assert.Equal(t, models.DEFAULT_ROLE_PERMISSIONS["author"], matrix.DefaultRoles["author"])
+	})
+
+	t.Run("Built-in roles should resolve to their permissions", func(t *testing.T) {
+		assert.Equal(t, models.PERMISSIONS, permissionsOf("user1"))
+		assert.Equal(t, []string{models.PermCreateProposal, models.PermCancelProposal}, permissionsOf("user2"))
+		assert.Empty(t, permissionsOf("user3"))
+	})
+}
+
+func TestCustomRoles(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("community_roles")
+	clearTable("lists")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	addr := otu.AddressOf("user3")
+	var role models.CommunityRole
+
+	createList := func() int {
+		payload := otu.GenerateBlockListPayload("user3", otu.GenerateBlockListStruct(communityId))
+		return otu.CreateListAPI(payload).Code
+	}
+
+	t.Run("Only admins should create roles", func(t *testing.T) {
+		permissions := []string{models.PermManageLists}
+
+		response := otu.SaveCommunityRoleAPI(communityId, 0, "user2", "list-keeper", permissions)
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.SaveCommunityRoleAPI(communityId, 0, "user1", "admin", permissions)
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+
+		response = otu.SaveCommunityRoleAPI(communityId, 0, "user1", "list-keeper", []string{"rule_the_world"})
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+
+		response = otu.SaveCommunityRoleAPI(communityId, 0, "user1", "list-keeper", permissions)
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+		json.Unmarshal(response.Body.Bytes(), &role)
+		assert.Equal(t, permissions, role.Permissions)
+
+		response = otu.GetCommunityRolesAPI(communityId)
+		var roles []models.CommunityRole
+		json.Unmarshal(response.Body.Bytes(), &roles)
+		assert.Len(t, roles, 1)
+	})
+
+	t.Run("Members of a role should get its permissions", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, createList())
+
+		response := otu.SetCommunityRoleMemberAPI(communityId, role.ID, "user2", addr, true)
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.SetCommunityRoleMemberAPI(communityId, role.ID, "user1", addr, true)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		response = otu.GetCommunityRoleMembersAPI(communityId, role.ID)
+		var members []string
+		json.Unmarshal(response.Body.Bytes(), &members)
+		assert.Equal(t, []string{addr}, members)
+
+		assert.Equal(t, http.StatusCreated, createList())
+	})
+
+	t.Run("Changes to a role should apply to its members", func(t *testing.T) {
+		permissions := []string{models.PermManageLists, models.PermManageMembers}
+		response := otu.SaveCommunityRoleAPI(communityId, role.ID, "user1", "list-keeper", permissions)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		response = otu.GetUserPermissionsAPI(communityId, addr)
+		var granted []string
+		json.Unmarshal(response.Body.Bytes(), &granted)
+		assert.Equal(t, permissions, granted)
+	})
+
+	t.Run("Unassigned or deleted roles should grant nothing", func(t *testing.T) {
+		response := otu.SetCommunityRoleMemberAPI(communityId, role.ID, "user1", addr, false)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		lists := otu.GetListsForCommunityAPI(communityId)
+		var created []models.List
+		json.Unmarshal(lists.Body.Bytes(), &created)
+		payload := otu.GenerateUpdateListPayload(created[0].ID, communityId, "user3")
+		response = otu.AddAddressesToListAPI(created[0].ID, payload)
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		otu.SetCommunityRoleMemberAPI(communityId, role.ID, "user1", addr, true)
+		response = otu.DeleteCommunityRoleAPI(communityId, role.ID, "user2")
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.DeleteCommunityRoleAPI(communityId, role.ID, "user1")
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		response = otu.GetUserPermissionsAPI(communityId, addr)
+		var granted []string
+		json.Unmarshal(response.Body.Bytes(), &granted)
+		assert.Empty(t, granted)
+	})
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPins(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("ipfs_pins")
+
+	A.AdminAllowlist.Set([]string{otu.AddressOf("user1")})
+	defer A.AdminAllowlist.Set(nil)
+
+	response := otu.CreateCommunityAPI(otu.GenerateCommunityPayload("user1", otu.GenerateCommunityStruct("user1", "dao")))
+	CheckResponseCode(t, http.StatusCreated, response.Code)
+	var c models.Community
+	json.Unmarshal(response.Body.Bytes(), &c)
+
+	t.Run("Records should be listed until they are pinned", func(t *testing.T) {
+		response := otu.ReconcilePinsAPI(otu.GeneratePinReconcilePayload("user1", false))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var body struct {
+			Data []models.UnpinnedRecord `json:"data"`
+		}
+		json.Unmarshal(response.Body.Bytes(), &body)
+		assert.Equal(t, 1, len(body.Data))
+		assert.Equal(t, models.PinCommunity, body.Data[0].Record_type)
+		assert.Equal(t, c.ID, body.Data[0].Record_id)
+		assert.Equal(t, models.PinPending, *body.Data[0].Status)
+
+		otu.ProcessPins()
+
+		response = otu.ReconcilePinsAPI(otu.GeneratePinReconcilePayload("user1", false))
+		json.Unmarshal(response.Body.Bytes(), &body)
+		assert.Equal(t, 0, len(body.Data))
+	})
+
+	t.Run("Failed pins should be retried later", func(t *testing.T) {
+		pin, err := models.GetPin(A.DB, models.PinCommunity, c.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, models.PinPinned, pin.Status)
+
+		assert.Nil(t, models.QueuePin(A.DB, models.PinCommunity, c.ID))
+		pin, _ = models.GetPin(A.DB, models.PinCommunity, c.ID)
+		assert.Nil(t, pin.MarkFailed(A.DB, errors.New("ipfs unavailable")))
+
+		pin, _ = models.GetPin(A.DB, models.PinCommunity, c.ID)
+		assert.Equal(t, models.PinFailed, pin.Status)
+		assert.Equal(t, 1, pin.Attempts)
+		assert.True(t, pin.Next_attempt_at.After(pin.Requested_at))
+	})
+
+	t.Run("Only platform admins should reconcile pins", func(t *testing.T) {
+		response := otu.ReconcilePinsAPI(otu.GeneratePinReconcilePayload("user2", false))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+	})
+}
+
+func TestVerifyCid(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+
+	t.Run("CIDs should be computed the way IPFS adds files", func(t *testing.T) {
+		empty, _ := shared.ComputeCid([]byte(""))
+		assert.Equal(t, "QmbFMke1KXqnYyBBWxB74N4c5SBnJMVAiMNRcGu6x1AwQH", empty)
+		hello, _ := shared.ComputeCid([]byte("hello world\n"))
+		assert.Equal(t, "QmT78zSuBmuS4z925WZfrqQ1qHaJ56DQaTfyMUF7F8ff5o", hello)
+	})
+
+	communityId := otu.AddCommunities(1, "dao")[0]
+	proposalId := otu.AddProposals(communityId, 1)[0]
+
+	t.Run("A proposal without a CID should not verify", func(t *testing.T) {
+		response := otu.VerifyProposalAPI(proposalId)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var v models.CidVerification
+		json.Unmarshal(response.Body.Bytes(), &v)
+		assert.False(t, v.Verified)
+		assert.NotNil(t, v.Expected_cid)
+	})
+
+	t.Run("A proposal stored under the CID of its content should match it", func(t *testing.T) {
+		response := otu.VerifyProposalAPI(proposalId)
+		var v models.CidVerification
+		json.Unmarshal(response.Body.Bytes(), &v)
+
+		p := models.Proposal{ID: proposalId}
+		assert.Nil(t, p.SetCid(A.DB, *v.Expected_cid))
+
+		response = otu.VerifyProposalAPI(proposalId)
+		json.Unmarshal(response.Body.Bytes(), &v)
+		assert.True(t, v.Cid_matches)
+		// pinned content can't be fetched while IPFS is overridden
+		assert.False(t, v.Fetched)
+		assert.False(t, v.Verified)
+	})
+}
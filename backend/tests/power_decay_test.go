@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVotingPowerDecay(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	clearTable("proposal_results")
+	clearTable("votes")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	_, err := A.DB.Conn.Exec(A.DB.Context,
+		`UPDATE communities SET decay_window = 1, decay_rate = 0.5 WHERE id = $1`, communityId)
+	assert.NoError(t, err)
+
+	name := "one-address-one-vote"
+	addProposal := func(start, end time.Time) int {
+		p := otu.GenerateProposalStruct("account", communityId)
+		p.Strategy = &name
+		p.Strategy_config = &models.Strategy{Name: &name}
+		p.Start_time = start
+		p.End_time = end
+		assert.NoError(t, p.CreateProposal(A.DB))
+		return p.ID
+	}
+
+	now := time.Now().UTC()
+	endedId := addProposal(now.AddDate(0, -2, 0), now.AddDate(0, -1, 0))
+	ended := models.Vote{
+		Proposal_id:          endedId,
+		Addr:                 otu.AddressOf("user1"),
+		Choice:               "a",
+		Composite_signatures: &[]shared.CompositeSignature{},
+	}
+	assert.NoError(t, ended.CreateVote(A.DB))
+
+	proposalId := addProposal(now.AddDate(0, 0, -7), now.AddDate(0, 0, 7))
+
+	t.Run("Should decay the weight of members who didn't vote recently", func(t *testing.T) {
+		response := otu.CreateVoteAPI(proposalId, otu.GenerateValidVotePayload("user1", proposalId, "a"))
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+		response = otu.CreateVoteAPI(proposalId, otu.GenerateValidVotePayload("user2", proposalId, "b"))
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+
+		var results models.ProposalResults
+		response = otu.GetProposalResultsAPI(proposalId)
+		json.Unmarshal(response.Body.Bytes(), &results)
+		assert.InDelta(t, 1.0, results.Results_float["a"], 1e-9)
+		assert.InDelta(t, 0.5, results.Results_float["b"], 1e-9)
+
+		var vote models.VoteWithBalance
+		response = otu.GetVoteForProposalByAccountNameAPI(proposalId, "user2")
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		json.Unmarshal(response.Body.Bytes(), &vote)
+		assert.Equal(t, 0.5, *vote.Weight_multiplier)
+		assert.Equal(t, 0.5, *vote.Weight)
+	})
+
+	t.Run("Should refuse decay rates above 1", func(t *testing.T) {
+		rate := 1.5
+		assert.Error(t, models.PowerDecay{Decay_rate: &rate}.Validate())
+	})
+}
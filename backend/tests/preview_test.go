@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkPreviews(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	proposalId := otu.AddProposals(communityId, 1)[0]
+
+	t.Run("Should describe a community", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/communities/%d/og", communityId), nil)
+		response := otu.ExecuteRequest(req)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var preview models.LinkPreview
+		json.Unmarshal(response.Body.Bytes(), &preview)
+		assert.Equal(t, "website", preview.Type)
+		assert.True(t, strings.HasSuffix(preview.Url, fmt.Sprintf("/community/%d", communityId)))
+	})
+
+	t.Run("Should describe a proposal with its results", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/proposals/%d/og", proposalId), nil)
+		response := otu.ExecuteRequest(req)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var preview models.LinkPreview
+		json.Unmarshal(response.Body.Bytes(), &preview)
+		assert.Equal(t, "summary_large_image", preview.Twitter_card)
+		assert.Equal(t, 0, *preview.Total_votes)
+		assert.NotEmpty(t, preview.Results)
+		assert.True(t, strings.HasSuffix(*preview.Image, fmt.Sprintf("/proposals/%d/results.png", proposalId)))
+	})
+
+	t.Run("Should serve meta tags for crawlers", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/proposals/%d/og?format=html", proposalId), nil)
+		response := otu.ExecuteRequest(req)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		assert.Contains(t, response.Body.String(), `<meta property="og:title"`)
+	})
+
+	t.Run("Should render the results chart", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/proposals/%d/results.svg", proposalId), nil)
+		response := otu.ExecuteRequest(req)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, "image/svg+xml", response.Header().Get("Content-Type"))
+
+		req, _ = http.NewRequest("GET", "/proposals/420/results.svg", nil)
+		response = otu.ExecuteRequest(req)
+		CheckResponseCode(t, http.StatusNotFound, response.Code)
+	})
+
+	t.Run("Should render the results image once until it is out of date", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/proposals/%d/results.png", proposalId), nil)
+		response := otu.ExecuteRequest(req)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, "image/png", response.Header().Get("Content-Type"))
+
+		image, err := models.GetResultsImage(A.DB, proposalId)
+		assert.Nil(t, err)
+		assert.Equal(t, response.Body.Bytes(), image.Png)
+
+		req, _ = http.NewRequest("GET", fmt.Sprintf("/proposals/%d/results.png", proposalId), nil)
+		otu.ExecuteRequest(req)
+		cached, _ := models.GetResultsImage(A.DB, proposalId)
+		assert.Equal(t, image.Rendered_at, cached.Rendered_at)
+	})
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/tests/test_utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserProfiles(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	clearTable("votes")
+	clearTable("balances")
+	clearTable("user_profiles")
+
+	withProfile, withoutProfile := otu.AddressOf("user1"), otu.AddressOf("user2")
+	displayName, avatar := "Alice", "QmAvatar"
+
+	communityId := otu.AddCommunities(1, "dao")[0]
+	proposalId := otu.AddActiveProposals(communityId, 1)[0]
+
+	t.Run("Addresses without a profile should get an empty one", func(t *testing.T) {
+		response := otu.GetUserProfileAPI(withProfile)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var profile models.UserProfile
+		json.Unmarshal(response.Body.Bytes(), &profile)
+		assert.Nil(t, profile.Display_name)
+		assert.Empty(t, profile.Socials)
+		assert.Equal(t, models.UserStats{}, *profile.Stats)
+	})
+
+	t.Run("Only the address should update its profile", func(t *testing.T) {
+		response := otu.UpdateUserProfileAPI(withProfile, otu.GenerateProfilePayload("user2", displayName, avatar))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.UpdateUserProfileAPI(withProfile, otu.GenerateProfilePayload("user1", strings.Repeat("a", 65), avatar))
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+
+		response = otu.UpdateUserProfileAPI(withProfile, otu.GenerateProfilePayload("user1", displayName, avatar))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var profile models.UserProfile
+		json.Unmarshal(response.Body.Bytes(), &profile)
+		assert.Equal(t, displayName, *profile.Display_name)
+		assert.Equal(t, avatar, *profile.Avatar)
+	})
+
+	for _, signer := range []string{"user1", "user2"} {
+		response := otu.CreateVoteAPI(proposalId, otu.GenerateValidVotePayload(signer, proposalId, "a"))
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+	}
+
+	t.Run("Votes should include the profile of the voter", func(t *testing.T) {
+		response := otu.GetVotesForProposalAPI(proposalId)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var page test_utils.PaginatedResponseWithVotes
+		json.Unmarshal(response.Body.Bytes(), &page)
+		assert.Len(t, page.Data, 2)
+		for _, v := range page.Data {
+			if v.Addr == withoutProfile {
+				assert.Nil(t, v.Profile)
+				continue
+			}
+			assert.Equal(t, displayName, *v.Profile.Display_name)
+			assert.Equal(t, avatar, *v.Profile.Avatar)
+		}
+
+		response = otu.GetVoteForProposalByAddressAPI(proposalId, withProfile)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var vote models.VoteWithBalance
+		json.Unmarshal(response.Body.Bytes(), &vote)
+		assert.Equal(t, displayName, *vote.Profile.Display_name)
+	})
+
+	t.Run("The leaderboard should include the profile of each user", func(t *testing.T) {
+		response := otu.GetCommunityLeaderboardAPIWithCurrentUser(communityId, withProfile)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var p test_utils.PaginatedResponseWithLeaderboardUser
+		json.Unmarshal(response.Body.Bytes(), &p)
+		assert.Len(t, p.Data.Users, 2)
+		for _, u := range p.Data.Users {
+			assert.Equal(t, u.Addr == withProfile, u.Profile != nil)
+		}
+		assert.Equal(t, displayName, *p.Data.CurrentUser.Profile.Display_name)
+	})
+
+	t.Run("Profile stats should count the votes cast", func(t *testing.T) {
+		response := otu.GetUserProfileAPI(withProfile)
+		var profile models.UserProfile
+		json.Unmarshal(response.Body.Bytes(), &profile)
+		assert.Equal(t, 1, profile.Stats.Votes_cast)
+	})
+}
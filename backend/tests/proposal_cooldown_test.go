@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProposalCooldown(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	author := otu.AddressOf("user2")
+	models.GrantAuthorRolesToAddress(A.DB, communityId, author)
+	_, err := A.DB.Conn.Exec(A.DB.Context,
+		`UPDATE communities SET proposal_cooldown_limit = 2, proposal_cooldown_window = 86400 WHERE id = $1`,
+		communityId)
+	assert.NoError(t, err)
+
+	createProposal := func(signer string) *httptest.ResponseRecorder {
+		p := otu.GenerateProposalStruct(signer, communityId)
+		return otu.CreateProposalAPI(otu.GenerateProposalPayload(signer, p))
+	}
+
+	t.Run("Should refuse proposals past the limit until the window passes", func(t *testing.T) {
+		CheckResponseCode(t, http.StatusCreated, createProposal("user2").Code)
+		CheckResponseCode(t, http.StatusCreated, createProposal("user2").Code)
+
+		response := createProposal("user2")
+		CheckResponseCode(t, http.StatusTooManyRequests, response.Code)
+		var e struct {
+			ErrorCode string    `json:"errorCode"`
+			Retry_at  time.Time `json:"retryAt"`
+		}
+		json.Unmarshal(response.Body.Bytes(), &e)
+		assert.Equal(t, "ERR_1037", e.ErrorCode)
+		assert.WithinDuration(t, time.Now().Add(24*time.Hour), e.Retry_at, time.Minute)
+		assert.NotEmpty(t, response.Header().Get("Retry-After"))
+
+		response = otu.GetProposalEligibilityAPI(communityId, author)
+		var eligibility models.ProposalEligibility
+		json.Unmarshal(response.Body.Bytes(), &eligibility)
+		assert.False(t, eligibility.Eligible)
+		if assert.NotNil(t, eligibility.Next_proposal_at) {
+			assert.True(t, eligibility.Next_proposal_at.Equal(e.Retry_at))
+		}
+
+		_, err := A.DB.Conn.Exec(A.DB.Context,
+			`UPDATE proposals SET created_at = created_at - interval '1 day' WHERE creator_addr = $1`, author)
+		assert.NoError(t, err)
+		CheckResponseCode(t, http.StatusCreated, createProposal("user2").Code)
+	})
+
+	t.Run("Should not limit moderators", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			CheckResponseCode(t, http.StatusCreated, createProposal("user1").Code)
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProposalDeposits(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	models.GrantAuthorRolesToAddress(A.DB, communityId, otu.AddressOf("user2"))
+	_, err := A.DB.Conn.Exec(A.DB.Context,
+		`UPDATE communities SET deposit_amount = 1, deposit_escrow_addr = $2 WHERE id = $1`,
+		communityId, otu.AddressOf("user3"))
+	assert.NoError(t, err)
+
+	createProposal := func(txId *string) *httptest.ResponseRecorder {
+		p := otu.GenerateProposalStruct("user2", communityId)
+		p.Deposit_tx_id = txId
+		return otu.CreateProposalAPI(otu.GenerateProposalPayload("user2", p))
+	}
+	errorCode := func(response *httptest.ResponseRecorder) string {
+		var e struct {
+			ErrorCode string `json:"errorCode"`
+		}
+		json.Unmarshal(response.Body.Bytes(), &e)
+		return e.ErrorCode
+	}
+
+	t.Run("Should ask for a deposit", func(t *testing.T) {
+		response := createProposal(nil)
+		CheckResponseCode(t, http.StatusPaymentRequired, response.Code)
+		assert.Equal(t, "ERR_1038", errorCode(response))
+	})
+
+	t.Run("Should refuse deposits that can't be verified", func(t *testing.T) {
+		for _, txId := range []string{"not-a-tx", strings.Repeat("ab", 32)} {
+			response := createProposal(&txId)
+			CheckResponseCode(t, http.StatusBadRequest, response.Code)
+			assert.Equal(t, "ERR_1039", errorCode(response))
+		}
+	})
+
+	t.Run("Should slash the deposit of a cancelled proposal", func(t *testing.T) {
+		_, err := A.DB.Conn.Exec(A.DB.Context,
+			`UPDATE communities SET deposit_amount = NULL WHERE id = $1`, communityId)
+		assert.NoError(t, err)
+		response := createProposal(nil)
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+		var p models.Proposal
+		json.Unmarshal(response.Body.Bytes(), &p)
+		assert.Nil(t, p.Deposit_status)
+
+		// stands in for a deposit verified on chain
+		_, err = A.DB.Conn.Exec(A.DB.Context,
+			`UPDATE proposals SET deposit_tx_id = $2, deposit_amount = 1, deposit_status = 'locked' WHERE id = $1`,
+			p.ID, strings.Repeat("cd", 32))
+		assert.NoError(t, err)
+
+		response = otu.GetProposalDepositsAPI(communityId, models.DepositLocked)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var page struct {
+			Data         []models.Proposal `json:"data"`
+			TotalRecords int               `json:"totalRecords"`
+		}
+		json.Unmarshal(response.Body.Bytes(), &page)
+		assert.Equal(t, 1, page.TotalRecords)
+
+		response = otu.UpdateProposalAPI(p.ID, otu.GenerateCancelProposalStruct("user1", p.ID))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		json.Unmarshal(response.Body.Bytes(), &p)
+		if assert.NotNil(t, p.Deposit_status) {
+			assert.Equal(t, models.DepositSlashDue, *p.Deposit_status)
+		}
+
+		response = otu.GetProposalDepositsAPI(communityId, models.DepositSlashDue)
+		json.Unmarshal(response.Body.Bytes(), &page)
+		assert.Equal(t, 1, page.TotalRecords)
+
+		t.Run("Should settle it only with the escrow's transaction", func(t *testing.T) {
+			response := otu.SettleProposalDepositAPI(p.ID, otu.GenerateDepositSettlementPayload("user2", strings.Repeat("ef", 32)))
+			CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+			// the proposal's own deposit doesn't settle it, however it is written
+			for _, txId := range []string{strings.Repeat("cd", 32), "0x" + strings.Repeat("CD", 32)} {
+				response = otu.SettleProposalDepositAPI(p.ID, otu.GenerateDepositSettlementPayload("user1", txId))
+				CheckResponseCode(t, http.StatusBadRequest, response.Code)
+				assert.Contains(t, response.Body.String(), "already paid")
+			}
+
+			response = otu.SettleProposalDepositAPI(p.ID, otu.GenerateDepositSettlementPayload("user1", strings.Repeat("ef", 32)))
+			CheckResponseCode(t, http.StatusBadRequest, response.Code)
+		})
+	})
+
+	t.Run("Should list deposits by status only", func(t *testing.T) {
+		response := otu.GetProposalDepositsAPI(communityId, "lost")
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProposalOutcome(t *testing.T) {
+	choices := []shared.Choice{
+		{Choice_text: "yes"},
+		{Choice_text: "no"},
+		{Choice_text: "abstain", Choice_type: models.ChoiceAbstain},
+		{Choice_text: "veto", Choice_type: models.ChoiceVeto},
+	}
+	quorum := 10.0
+	p := models.Proposal{Choices: choices, Quorum: &quorum}
+
+	outcome := func(yes, no, abstain, veto float64) models.ProposalOutcome {
+		r := models.ProposalResults{Results_float: map[string]float64{
+			"yes": yes, "no": no, "abstain": abstain, "veto": veto,
+		}}
+		return r.DecideOutcome(p)
+	}
+
+	t.Run("Abstentions should count toward quorum but not the outcome", func(t *testing.T) {
+		o := outcome(3, 2, 5, 0)
+		assert.True(t, o.Quorum_met)
+		assert.Equal(t, 5.0, o.Outcome_weight)
+		if assert.NotNil(t, o.Winning_choice) {
+			assert.Equal(t, "yes", *o.Winning_choice)
+		}
+
+		o = outcome(3, 2, 0, 0)
+		assert.False(t, o.Quorum_met)
+		assert.Nil(t, o.Winning_choice)
+	})
+
+	t.Run("Vetoes past the threshold should invalidate the proposal", func(t *testing.T) {
+		o := outcome(6, 0, 10, 3)
+		assert.False(t, o.Vetoed)
+		assert.Equal(t, models.DefaultVetoThreshold, *o.Veto_threshold)
+
+		o = outcome(6, 0, 10, 4)
+		assert.True(t, o.Vetoed)
+		assert.Equal(t, 40.0, o.Veto_percent)
+		assert.Nil(t, o.Winning_choice)
+	})
+
+	t.Run("Proposals should offer one regular choice and one of each other type", func(t *testing.T) {
+		assert.NoError(t, models.ValidateChoiceTypes(choices))
+		assert.Error(t, models.ValidateChoiceTypes([]shared.Choice{{Choice_text: "abstain", Choice_type: models.ChoiceAbstain}}))
+		assert.Error(t, models.ValidateChoiceTypes(append(choices, shared.Choice{Choice_text: "veto2", Choice_type: models.ChoiceVeto})))
+		assert.Error(t, models.ValidateChoiceTypes([]shared.Choice{{Choice_text: "a", Choice_type: "maybe"}}))
+	})
+}
+
+func TestProposalOutcomeResults(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	clearTable("proposal_results")
+	clearTable("votes")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+
+	name := "one-address-one-vote"
+	quorum := 2.0
+	p := otu.GenerateProposalStruct("account", communityId)
+	p.Strategy = &name
+	p.Strategy_config = &models.Strategy{Name: &name}
+	p.Start_time = time.Now().UTC().AddDate(0, -1, 0)
+	p.Choices = []shared.Choice{{Choice_text: "a"}, {Choice_text: "b"}, {Choice_text: "abstain", Choice_type: models.ChoiceAbstain}}
+	p.Quorum = &quorum
+	assert.NoError(t, p.CreateProposal(A.DB))
+
+	response := otu.CreateVoteAPI(p.ID, otu.GenerateValidVotePayload("user1", p.ID, "abstain"))
+	CheckResponseCode(t, http.StatusCreated, response.Code)
+	response = otu.CreateVoteAPI(p.ID, otu.GenerateValidVotePayload("user2", p.ID, "a"))
+	CheckResponseCode(t, http.StatusCreated, response.Code)
+
+	var results models.ProposalResults
+	response = otu.GetProposalResultsAPI(p.ID)
+	CheckResponseCode(t, http.StatusOK, response.Code)
+	json.Unmarshal(response.Body.Bytes(), &results)
+
+	if assert.NotNil(t, results.Outcome) {
+		assert.True(t, results.Outcome.Quorum_met)
+		assert.Equal(t, 1.0, results.Outcome.Abstain_weight)
+		assert.Equal(t, "a", *results.Outcome.Winning_choice)
+	}
+}
@@ -1,14 +1,17 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"testing"
 	"time"
 
 	"github.com/DapperCollectives/CAST/backend/main/models"
 	"github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/DapperCollectives/CAST/backend/tests/test_utils"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -55,6 +58,60 @@ func TestGetProposal(t *testing.T) {
 	})
 }
 
+func TestFilterProposals(t *testing.T) {
+	clearTable("communities")
+	clearTable("proposals")
+	communityId := otu.AddCommunities(1, "dao")[0]
+
+	pending := otu.AddProposals(communityId, 1)[0]
+	active := otu.AddActiveProposals(communityId, 1)[0]
+	stakedIds, _ := otu.AddProposalsForStrategy(communityId, "staked-token-weighted-default", 1)
+	A.DB.Conn.Exec(A.DB.Context, "UPDATE proposals SET creator_addr = $1 WHERE id = $2", otu.AddressOf("user1"), active)
+
+	filterIds := func(filters url.Values) []int {
+		filters.Set("order", "asc")
+		response := otu.FilterProposalsForCommunityAPI(communityId, filters)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var p test_utils.PaginatedResponseWithProposals
+		json.Unmarshal(response.Body.Bytes(), &p)
+		ids := []int{}
+		for _, proposal := range p.Data {
+			ids = append(ids, proposal.ID)
+		}
+		assert.Equal(t, len(ids), p.TotalRecords)
+		return ids
+	}
+
+	t.Run("Should filter by any of several statuses", func(t *testing.T) {
+		assert.Equal(t, []int{pending}, filterIds(url.Values{"status": {"pending"}}))
+		assert.Equal(t, []int{active, stakedIds[0]}, filterIds(url.Values{"status": {"active,closed"}}))
+	})
+
+	t.Run("Should filter by strategy and author", func(t *testing.T) {
+		assert.Equal(t, stakedIds, filterIds(url.Values{"strategy": {"staked-token-weighted-default"}}))
+		assert.Equal(t, []int{active}, filterIds(url.Values{"author": {otu.AddressOf("user1")}}))
+	})
+
+	t.Run("Should filter by time ranges", func(t *testing.T) {
+		now := time.Now().UTC().Format(time.RFC3339)
+		assert.Equal(t, []int{pending}, filterIds(url.Values{"startAfter": {now}}))
+		assert.Equal(t, []int{active, stakedIds[0]}, filterIds(url.Values{"startBefore": {now}}))
+		later := time.Now().UTC().Add(time.Minute).Format(time.RFC3339)
+		assert.Equal(t, []int{}, filterIds(url.Values{"createdAfter": {later}}))
+	})
+
+	t.Run("Should reject unknown statuses and malformed times", func(t *testing.T) {
+		for _, filters := range []url.Values{
+			{"status": {"active,archived"}},
+			{"endAfter": {"yesterday"}},
+			{"createdAfter": {"2022-02-01T00:00:00Z"}, "createdBefore": {"2022-01-01T00:00:00Z"}},
+		} {
+			response := otu.FilterProposalsForCommunityAPI(communityId, filters)
+			CheckResponseCode(t, http.StatusBadRequest, response.Code)
+		}
+	})
+}
+
 func TestCreateProposal(t *testing.T) {
 	clearTable("communities")
 	clearTable("community_users")
@@ -71,7 +128,10 @@ func TestCreateProposal(t *testing.T) {
 		var p models.Proposal
 		json.Unmarshal(response.Body.Bytes(), &p)
 
-		assert.NotNil(t, p.Cid)
+		otu.ProcessPins()
+		pinned := models.Proposal{ID: p.ID}
+		assert.Nil(t, pinned.GetProposalById(A.DB))
+		assert.NotNil(t, pinned.Cid)
 
 		assert.Equal(t, proposalStruct.Name, p.Name)
 		assert.Equal(t, *proposalStruct.Body, *p.Body)
@@ -80,6 +140,29 @@ func TestCreateProposal(t *testing.T) {
 		assert.Equal(t, *proposalStruct.Strategy, *p.Strategy)
 	})
 
+	t.Run("Should sanitize the body, and render its markdown on request", func(t *testing.T) {
+		proposalStruct := otu.GenerateProposalStruct("user1", communityId)
+		body := "# Title\n\n**bold** <script>alert(1)</script><img src=x onerror=alert(1)>"
+		proposalStruct.Body = &body
+		payload := otu.GenerateProposalPayload("user1", proposalStruct)
+
+		response := otu.CreateProposalAPI(payload)
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+
+		var p models.Proposal
+		json.Unmarshal(response.Body.Bytes(), &p)
+		assert.Equal(t, "# Title\n\n**bold** <img src=\"x\">", *p.Body)
+
+		response = otu.GetProposalFormattedAPI(p.ID, "html")
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var rendered models.Proposal
+		json.Unmarshal(response.Body.Bytes(), &rendered)
+		assert.Equal(t, "<h1>Title</h1>\n<p><strong>bold</strong> <img src=\"x\"></p>\n", *rendered.Body)
+
+		response = otu.GetProposalFormattedAPI(p.ID, "pdf")
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+
 	t.Run("Should throw an error if signature is invalid", func(t *testing.T) {
 		proposalStruct := otu.GenerateProposalStruct("user1", communityId)
 		payload := otu.GenerateProposalPayload("user1", proposalStruct)
@@ -121,6 +204,246 @@ func TestCreateProposal(t *testing.T) {
 	})
 }
 
+func TestProposalEligibility(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("threshold_balances")
+
+	t.Run("Only authors can propose in author only communities", func(t *testing.T) {
+		communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+
+		response := otu.GetProposalEligibilityAPI(communityId, otu.ResolveUser(1))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var eligibility models.ProposalEligibility
+		json.Unmarshal(response.Body.Bytes(), &eligibility)
+		assert.True(t, eligibility.Eligible)
+		assert.True(t, eligibility.Only_authors)
+
+		response = otu.GetProposalEligibilityAPI(communityId, otu.ResolveUser(2))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		eligibility = models.ProposalEligibility{}
+		json.Unmarshal(response.Body.Bytes(), &eligibility)
+		assert.False(t, eligibility.Eligible)
+		assert.NotEmpty(t, eligibility.Reason)
+	})
+
+	t.Run("Balances are checked against the proposal threshold", func(t *testing.T) {
+		communityId := otu.AddCommunitiesWithUsersAndThreshold(1, "user1")[0]
+
+		response := otu.GetProposalEligibilityAPI(communityId, otu.ResolveUser(2))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var eligibility models.ProposalEligibility
+		json.Unmarshal(response.Body.Bytes(), &eligibility)
+		assert.Equal(t, "FlowToken", *eligibility.Token)
+		assert.Equal(t, 0.01, *eligibility.Required)
+		assert.Equal(t, *eligibility.Balance >= 0.01, eligibility.Eligible)
+
+		// a recently read balance is reused
+		response = otu.GetProposalEligibilityAPI(communityId, otu.ResolveUser(2))
+		var cached models.ProposalEligibility
+		json.Unmarshal(response.Body.Bytes(), &cached)
+		assert.Equal(t, *eligibility.Block_height, *cached.Block_height)
+	})
+}
+
+func TestProposalWindow(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+
+	// proposals run between an hour and a week, start 10 minutes out at the
+	// earliest and are given in UTC
+	_, err := A.DB.Conn.Exec(A.DB.Context, `
+		UPDATE communities
+		SET min_proposal_duration = 3600, max_proposal_duration = 604800,
+			min_proposal_lead_time = 600, proposal_time_zones = '{UTC}'
+		WHERE id = $1
+	`, communityId)
+	assert.NoError(t, err)
+
+	start := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	newYork, _ := time.LoadLocation("America/New_York")
+
+	cases := []struct {
+		name    string
+		start   time.Time
+		end     time.Time
+		code    int
+		details string
+	}{
+		{"Should reject a proposal shorter than the minimum", start, start.Add(30 * time.Minute),
+			http.StatusBadRequest, "Proposals must run for at least 1h0m0s."},
+		{"Should reject a proposal longer than the maximum", start, start.Add(8 * 24 * time.Hour),
+			http.StatusBadRequest, "Proposals cannot run for more than 168h0m0s."},
+		{"Should reject a proposal starting too soon", time.Now().UTC().Add(time.Minute), start.Add(time.Hour),
+			http.StatusBadRequest, "Proposals must start at least 10m0s after they are created."},
+		{"Should reject times outside the allowed time zones", start.In(newYork), start.Add(24 * time.Hour),
+			http.StatusBadRequest, "Proposal times must be given in one of these time zones: UTC."},
+		{"Should accept a proposal inside the window", start, start.Add(24 * time.Hour),
+			http.StatusCreated, ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			proposalStruct := otu.GenerateProposalStruct("user1", communityId)
+			proposalStruct.Start_time = c.start
+			proposalStruct.End_time = c.end
+			payload := otu.GenerateProposalPayload("user1", proposalStruct)
+
+			response := otu.CreateProposalAPI(payload)
+			CheckResponseCode(t, c.code, response.Code)
+
+			if c.details != "" {
+				var e errorResponse
+				json.Unmarshal(response.Body.Bytes(), &e)
+				assert.Equal(t, c.details, e.Details)
+			}
+		})
+	}
+}
+
+func TestProposalBlockWindow(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	t.Setenv("FLOW_BLOCK_INTERVAL", "1s")
+
+	head, err := A.FlowAdapter.GetLatestSealedHeader()
+	assert.NoError(t, err)
+
+	t.Run("Should reject an end block that is already sealed", func(t *testing.T) {
+		proposalStruct := otu.GenerateProposalStruct("user1", communityId)
+		endBlock := head.Height
+		proposalStruct.End_block = &endBlock
+		payload := otu.GenerateProposalPayload("user1", proposalStruct)
+
+		response := otu.CreateProposalAPI(payload)
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("Should estimate the times of a block window", func(t *testing.T) {
+		proposalStruct := otu.GenerateProposalStruct("user1", communityId)
+		startBlock, endBlock := head.Height+600, head.Height+3600
+		proposalStruct.Start_block = &startBlock
+		proposalStruct.End_block = &endBlock
+		payload := otu.GenerateProposalPayload("user1", proposalStruct)
+
+		response := otu.CreateProposalAPI(payload)
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+
+		var p models.Proposal
+		json.Unmarshal(response.Body.Bytes(), &p)
+		assert.Equal(t, startBlock, *p.Start_block)
+		assert.Equal(t, endBlock, *p.End_block)
+		assert.True(t, p.Start_time.After(time.Now()))
+		assert.WithinDuration(t, p.Start_time.Add(50*time.Minute), p.End_time, time.Minute)
+	})
+}
+
+func TestProposalSnapshot(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+
+	head, err := A.FlowAdapter.GetLatestSealedHeader()
+	assert.NoError(t, err)
+
+	t.Run("Should snapshot the latest sealed block when none is given", func(t *testing.T) {
+		payload := otu.GenerateProposalPayload("user1", otu.GenerateProposalStruct("user1", communityId))
+
+		response := otu.CreateProposalAPI(payload)
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+
+		var p models.Proposal
+		json.Unmarshal(response.Body.Bytes(), &p)
+		assert.GreaterOrEqual(t, *p.Block_height, head.Height)
+	})
+
+	t.Run("Should keep a sealed snapshot block given by the author", func(t *testing.T) {
+		payload := otu.GenerateProposalPayload("user1", otu.GenerateProposalStruct("user1", communityId))
+		snapshot := head.Height - 1
+		payload.Block_height = &snapshot
+
+		response := otu.CreateProposalAPI(payload)
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+
+		var p models.Proposal
+		json.Unmarshal(response.Body.Bytes(), &p)
+		assert.Equal(t, snapshot, *p.Block_height)
+	})
+
+	t.Run("Should reject a snapshot block that is not sealed yet", func(t *testing.T) {
+		payload := otu.GenerateProposalPayload("user1", otu.GenerateProposalStruct("user1", communityId))
+		snapshot := head.Height + 1000
+		payload.Block_height = &snapshot
+
+		response := otu.CreateProposalAPI(payload)
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("Should reject a snapshot block beyond the retention of the access node", func(t *testing.T) {
+		retention := A.FlowAdapter.Retention
+		A.FlowAdapter.Retention = shared.ScriptRetention{Blocks: 1}
+		defer func() { A.FlowAdapter.Retention = retention }()
+
+		payload := otu.GenerateProposalPayload("user1", otu.GenerateProposalStruct("user1", communityId))
+		snapshot := head.Height - 2
+		payload.Block_height = &snapshot
+
+		response := otu.CreateProposalAPI(payload)
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+}
+
+func TestProposalTimesInUTC(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+
+	t.Run("Should store times given with an offset as the same instant in UTC", func(t *testing.T) {
+		tokyo := time.FixedZone("UTC+9", 9*60*60)
+		proposalStruct := otu.GenerateProposalStruct("user1", communityId)
+		proposalStruct.Start_time = time.Now().Add(24 * time.Hour).In(tokyo).Truncate(time.Second)
+		proposalStruct.End_time = proposalStruct.Start_time.Add(48 * time.Hour)
+		payload := otu.GenerateProposalPayload("user1", proposalStruct)
+
+		response := otu.CreateProposalAPI(payload)
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+		var created models.Proposal
+		json.Unmarshal(response.Body.Bytes(), &created)
+
+		response = otu.GetProposalByIdAPI(communityId, created.ID)
+		var p models.Proposal
+		json.Unmarshal(response.Body.Bytes(), &p)
+
+		assert.True(t, proposalStruct.Start_time.Equal(p.Start_time))
+		assert.True(t, proposalStruct.End_time.Equal(p.End_time))
+		_, offset := p.Start_time.Zone()
+		assert.Equal(t, 0, offset)
+	})
+
+	t.Run("Should reject times without an offset", func(t *testing.T) {
+		proposalStruct := otu.GenerateProposalStruct("user1", communityId)
+		payload := otu.GenerateProposalPayload("user1", proposalStruct)
+		body, _ := json.Marshal(payload)
+		body = bytes.Replace(body, []byte(payload.Start_time.Format(time.RFC3339Nano)),
+			[]byte(payload.Start_time.Format("2006-01-02T15:04:05")), 1)
+
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/communities/%d/proposals", communityId), bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		response := executeRequest(req)
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+
+		var e errorResponse
+		json.Unmarshal(response.Body.Bytes(), &e)
+		assert.Contains(t, e.Details, "use ISO-8601 with an offset")
+	})
+}
+
 func TestUpdateProposal(t *testing.T) {
 	clearTable("communities")
 	clearTable("community_users")
@@ -255,3 +578,148 @@ func TestCreateManyProposals(t *testing.T) {
 	})
 
 }
+
+func TestGetProposalAnalytics(t *testing.T) {
+	resetTables()
+
+	communityId := otu.AddCommunities(1, "dao")[0]
+	proposalId := otu.AddActiveProposals(communityId, 1)[0]
+	numUsers := 3
+	for i := 1; i <= numUsers; i++ {
+		otu.CreateVoteAPI(proposalId, otu.GenerateValidVotePayload(fmt.Sprintf("user%d", i), proposalId, "a"))
+	}
+
+	response := otu.GetProposalAnalyticsAPI(proposalId)
+	CheckResponseCode(t, http.StatusOK, response.Code)
+
+	var analytics models.ProposalAnalytics
+	json.Unmarshal(response.Body.Bytes(), &analytics)
+
+	assert.Equal(t, numUsers, analytics.Unique_voters)
+	assert.NotEmpty(t, analytics.Hourly)
+	assert.Equal(t, numUsers, analytics.Hourly[len(analytics.Hourly)-1].Cumulative_votes)
+}
+
+func TestCloseProposals(t *testing.T) {
+	resetTables()
+
+	communityId := otu.AddCommunities(1, "dao")[0]
+	proposalId := otu.AddActiveProposals(communityId, 1)[0]
+	for i := 1; i <= 3; i++ {
+		otu.CreateVoteAPI(proposalId, otu.GenerateValidVotePayload(fmt.Sprintf("user%d", i), proposalId, "a"))
+	}
+	otu.UpdateProposalEndTime(proposalId, time.Now().UTC())
+	otu.CloseProposals()
+	otu.ProcessPins()
+
+	var closed models.Proposal
+	response := otu.GetProposalByIdAPI(communityId, proposalId)
+	CheckResponseCode(t, http.StatusOK, response.Code)
+	json.Unmarshal(response.Body.Bytes(), &closed)
+
+	t.Run("Ended proposals should be closed with final results", func(t *testing.T) {
+		assert.Equal(t, models.ProposalClosed, *closed.Status)
+		assert.NotNil(t, closed.Closed_at)
+
+		response := otu.GetProposalResultsAPI(proposalId)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var results models.ProposalResults
+		json.Unmarshal(response.Body.Bytes(), &results)
+		assert.NotNil(t, results.Cid)
+		assert.Contains(t, results.Results, "a")
+	})
+
+	t.Run("Proposals should only be closed once", func(t *testing.T) {
+		otu.CloseProposals()
+
+		var p models.Proposal
+		response := otu.GetProposalByIdAPI(communityId, proposalId)
+		json.Unmarshal(response.Body.Bytes(), &p)
+		assert.Equal(t, closed.Closed_at, p.Closed_at)
+	})
+}
+
+func TestProposalBatch(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+
+	communityIds := otu.AddCommunitiesWithUsers(2, "user1")
+	first := otu.AddProposals(communityIds[0], 1)[0]
+	second := otu.AddProposals(communityIds[1], 1)[0]
+
+	t.Run("Should return proposals in the order asked, with their communities", func(t *testing.T) {
+		response := otu.GetProposalBatchAPI([]int{second, 420, first, second})
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var batch []models.ProposalWithCommunity
+		json.Unmarshal(response.Body.Bytes(), &batch)
+		assert.Equal(t, 2, len(batch))
+		assert.Equal(t, second, batch[0].ID)
+		assert.Equal(t, communityIds[1], batch[0].Community.ID)
+		assert.Equal(t, first, batch[1].ID)
+		assert.NotNil(t, batch[1].Strategy_info)
+	})
+
+	t.Run("Should reject empty batches", func(t *testing.T) {
+		response := otu.GetProposalBatchAPI([]int{})
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+}
+
+func TestProposalExecution(t *testing.T) {
+	resetTables()
+	clearTable("proposal_execution_payloads")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	cadence := "transaction(amount: UFix64) { execute {} }"
+	arguments := json.RawMessage(`[{"type": "UFix64", "value": "10.0"}]`)
+
+	t.Run("Should reject payloads for choices the proposal doesn't offer", func(t *testing.T) {
+		proposalStruct := otu.GenerateProposalStruct("user1", communityId)
+		proposalStruct.Execution_payload = &models.ExecutionPayload{Choice: "c", Cadence: cadence, Arguments: arguments}
+		response := otu.CreateProposalAPI(otu.GenerateProposalPayload("user1", proposalStruct))
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("Should pin the payload with the proposal", func(t *testing.T) {
+		proposalStruct := otu.GenerateProposalStruct("user1", communityId)
+		proposalStruct.Execution_payload = &models.ExecutionPayload{Choice: "a", Cadence: cadence, Arguments: arguments}
+		response := otu.CreateProposalAPI(otu.GenerateProposalPayload("user1", proposalStruct))
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+
+		var p models.Proposal
+		json.Unmarshal(response.Body.Bytes(), &p)
+
+		response = otu.GetProposalByIdAPI(communityId, p.ID)
+		var fetched models.Proposal
+		json.Unmarshal(response.Body.Bytes(), &fetched)
+		assert.Equal(t, cadence, fetched.Execution_payload.Cadence)
+
+		response = otu.GetProposalExecutionAPI(p.ID)
+		CheckResponseCode(t, http.StatusConflict, response.Code)
+	})
+
+	t.Run("Should expose the payload of passed proposals with proof", func(t *testing.T) {
+		proposalId := otu.AddActiveProposals(communityId, 1)[0]
+		payload := models.ExecutionPayload{Proposal_id: proposalId, Choice: "a", Cadence: cadence, Arguments: arguments}
+		assert.Nil(t, payload.CreateExecutionPayload(A.DB))
+
+		otu.CreateVoteAPI(proposalId, otu.GenerateValidVotePayload("user1", proposalId, "a"))
+		otu.UpdateProposalEndTime(proposalId, time.Now().UTC())
+		otu.CloseProposals()
+		otu.ProcessPins()
+
+		response := otu.GetProposalExecutionAPI(proposalId)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var receipt shared.SignedReceipt
+		json.Unmarshal(response.Body.Bytes(), &receipt)
+		assert.Nil(t, shared.VerifyReceipt(&receipt, otu.ReceiptPublicKey()))
+
+		record := receipt.Payload.(map[string]interface{})
+		assert.Equal(t, cadence, record["cadence"])
+		assert.NotEmpty(t, record["resultsCid"])
+	})
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var errReplayedSignature = errorResponse{
+	StatusCode: http.StatusForbidden,
+	ErrorCode:  "ERR_1017",
+	Message:    "Signature Already Used",
+	Details:    "This signed request was already made, sign it again to retry.",
+}
+
+func TestReplayProtection(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	clearTable("votes")
+	clearTable("used_signatures")
+
+	t.Run("A proposal signature should only be used once", func(t *testing.T) {
+		communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+		payload := otu.GenerateProposalPayload("user1", otu.GenerateProposalStruct("user1", communityId))
+
+		response := otu.CreateProposalAPI(payload)
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+
+		response = otu.CreateProposalAPI(payload)
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		var e errorResponse
+		json.Unmarshal(response.Body.Bytes(), &e)
+		assert.Equal(t, errReplayedSignature, e)
+	})
+
+	t.Run("A community signature should not be replayed on another mutation", func(t *testing.T) {
+		payload := otu.GenerateCommunityPayload("account", otu.GenerateCommunityStruct("account", "dao"))
+		response := otu.CreateCommunityAPI(payload)
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+
+		var c models.Community
+		json.Unmarshal(response.Body.Bytes(), &c)
+		etag := otu.GetCommunityAPI(c.ID).Header().Get("ETag")
+
+		// an update signed with the same timestamp as the creation
+		update := otu.GenerateCommunityPayload("account", otu.GenerateCommunityStruct("account", "dao"))
+		update.Timestamp = payload.Timestamp
+		update.Composite_signatures = payload.Composite_signatures
+		response = otu.UpdateCommunityWithETagAPI(c.ID, etag, update)
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("A vote signature should only be used once", func(t *testing.T) {
+		communityId := otu.AddCommunities(1, "dao")[0]
+		proposalId := otu.AddActiveProposals(communityId, 1)[0]
+		vote := otu.GenerateValidVotePayload("user1", proposalId, "a")
+
+		response := otu.CreateVoteAPI(proposalId, vote)
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+
+		// deleting the vote leaves its signature spent
+		_, err := A.DB.Conn.Exec(A.DB.Context, `DELETE FROM votes WHERE proposal_id = $1`, proposalId)
+		assert.NoError(t, err)
+
+		response = otu.CreateVoteAPI(proposalId, vote)
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("A membership signature should only be used once", func(t *testing.T) {
+		communityId := otu.AddCommunities(1, "dao")[0]
+		user := otu.GenerateCommunityUserStruct("user1", "member")
+		user.Community_id = communityId
+		payload := otu.GenerateCommunityUserPayload("user1", user)
+
+		response := otu.CreateCommunityUserAPI(communityId, payload)
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+
+		// leaving the community leaves the signature spent
+		assert.NoError(t, user.Remove(A.DB))
+
+		response = otu.CreateCommunityUserAPI(communityId, payload)
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("A future dated signature should not outlive its used record", func(t *testing.T) {
+		communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+		payload := otu.GenerateProposalPayload("user1", otu.GenerateProposalStruct("user1", communityId))
+		future := time.Now().Add(48 * time.Hour)
+		payload.Timestamp = fmt.Sprint(future.UnixNano() / int64(time.Millisecond))
+		payload.Composite_signatures = otu.GenerateCompositeSignatures("user1", payload.Timestamp)
+
+		response := otu.CreateProposalAPI(payload)
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		// purging used signatures must not make it acceptable either
+		_, err := A.DB.Conn.Exec(A.DB.Context, `UPDATE used_signatures SET used_at = used_at - interval '1 day'`)
+		assert.NoError(t, err)
+		assert.NoError(t, A.PurgeUsedSignatures())
+
+		response = otu.CreateProposalAPI(payload)
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("A turned down request should not spend its signature", func(t *testing.T) {
+		communityId := otu.AddCommunities(1, "dao")[0]
+		user := otu.GenerateCommunityUserStruct("user1", "member")
+		user.Community_id = communityId
+		payload := otu.GenerateCommunityUserPayload("user1", user)
+
+		// the address is a member already, which is checked after the signature
+		assert.NoError(t, user.CreateCommunityUser(A.DB))
+		response := otu.CreateCommunityUserAPI(communityId, payload)
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+
+		assert.NoError(t, user.Remove(A.DB))
+		response = otu.CreateCommunityUserAPI(communityId, payload)
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+	})
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/tests/test_utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReputation(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+
+	addr := otu.AddressOf("user1")
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	proposalId := otu.AddProposals(communityId, 1)[0]
+	A.DB.Conn.Exec(A.DB.Context, "UPDATE proposals SET creator_addr = $1 WHERE id = $2", addr, proposalId)
+
+	getScore := func() models.ReputationScore {
+		response := otu.GetUserReputationAPI(communityId, addr)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var score models.ReputationScore
+		json.Unmarshal(response.Body.Bytes(), &score)
+		return score
+	}
+
+	t.Run("Should score members by the default settings", func(t *testing.T) {
+		assert.Equal(t, 0.0, getScore().Score)
+
+		assert.NoError(t, A.ComputeReputation())
+		score := getScore()
+		assert.Equal(t, 0.2, score.Authorship)
+		assert.Equal(t, 0.0, score.Participation)
+		assert.InDelta(t, 6.0, score.Score, 0.1)
+	})
+
+	t.Run("Admins should change how reputation is scored", func(t *testing.T) {
+		zero, one := 0.0, 1.0
+		target := 1
+		payload := models.ReputationSettingsPayload{
+			TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload("user1"),
+			Participation_weight:      &zero,
+			Authorship_weight:         &one,
+			Tenure_weight:             &zero,
+			Authorship_target:         &target,
+		}
+		response := otu.SetReputationSettingsAPI(communityId, &payload)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		assert.NoError(t, A.ComputeReputation())
+		assert.Equal(t, 100.0, getScore().Score)
+
+		payload.TimestampSignaturePayload = otu.GenerateTimestampSignaturePayload("user2")
+		response = otu.SetReputationSettingsAPI(communityId, &payload)
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		payload.TimestampSignaturePayload = otu.GenerateTimestampSignaturePayload("user1")
+		payload.Authorship_weight = &zero
+		response = otu.SetReputationSettingsAPI(communityId, &payload)
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("User listings should show and sort by reputation", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/communities/"+strconv.Itoa(communityId)+"/users?sort=-reputation", nil)
+		response := otu.ExecuteRequest(req)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var p test_utils.PaginatedResponseWithUserType
+		json.Unmarshal(response.Body.Bytes(), &p)
+		assert.Equal(t, addr, p.Data[0].Addr)
+		assert.Equal(t, 100.0, *p.Data[0].Reputation)
+	})
+}
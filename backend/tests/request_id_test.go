@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errInvalidPayload = errorResponse{
+	StatusCode: http.StatusBadRequest,
+	ErrorCode:  "ERR_1024",
+	Message:    "Invalid Payload",
+	Details:    "The request body could not be read, check it is JSON of the expected shape.",
+}
+
+func TestRequestID(t *testing.T) {
+	clearTable("communities")
+
+	t.Run("Should echo the request id sent and return it with errors", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/communities", bytes.NewBufferString("not json"))
+		req.Header.Set("X-Request-ID", "test-request-1")
+		response := otu.ExecuteRequest(req)
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+		assert.Equal(t, "test-request-1", response.Header().Get("X-Request-ID"))
+
+		var e errorResponse
+		json.Unmarshal(response.Body.Bytes(), &e)
+		assert.Equal(t, errInvalidPayload, e)
+
+		var body map[string]string
+		json.Unmarshal(response.Body.Bytes(), &body)
+		assert.Equal(t, "test-request-1", body["requestId"])
+	})
+
+	t.Run("Should generate a request id when none is sent", func(t *testing.T) {
+		response := otu.GetCommunityAPI(420)
+		id := response.Header().Get("X-Request-ID")
+		assert.NotEmpty(t, id)
+
+		var body map[string]string
+		json.Unmarshal(response.Body.Bytes(), &body)
+		assert.Equal(t, id, body["requestId"])
+	})
+
+	t.Run("Should replace request ids that are unsafe to log", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/communities/420", nil)
+		req.Header.Set("X-Request-ID", "bad id\nforged log line")
+		response := otu.ExecuteRequest(req)
+		assert.NotEqual(t, "bad id\nforged log line", response.Header().Get("X-Request-ID"))
+	})
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/tests/test_utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProposalReviewVisibility(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	models.GrantAuthorRolesToAddress(A.DB, communityId, otu.AddressOf("user2"))
+	_, err := A.DB.Conn.Exec(A.DB.Context,
+		`UPDATE communities SET require_proposal_review = 'true' WHERE id = $1`, communityId)
+	assert.NoError(t, err)
+
+	response := otu.CreateProposalAPI(otu.GenerateProposalPayload("user2", otu.GenerateProposalStruct("user2", communityId)))
+	CheckResponseCode(t, http.StatusCreated, response.Code)
+	var held models.Proposal
+	json.Unmarshal(response.Body.Bytes(), &held)
+	assert.True(t, held.IsAwaitingReview())
+
+	t.Run("Should show the review queue to moderators only", func(t *testing.T) {
+		response := otu.GetProposalReviewQueueAPI(communityId, "")
+		CheckResponseCode(t, http.StatusUnauthorized, response.Code)
+
+		response = otu.GetProposalReviewQueueAPI(communityId, otu.Login("user3"))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.GetProposalReviewQueueAPI(communityId, otu.Login("user1"))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var page test_utils.PaginatedResponseWithProposals
+		json.Unmarshal(response.Body.Bytes(), &page)
+		assert.Equal(t, 1, page.TotalRecords)
+	})
+
+	t.Run("Should filter by withheld statuses for moderators only", func(t *testing.T) {
+		filters := url.Values{"status": {"pending_review,rejected"}}
+		response := otu.FilterProposalsAsAPI(communityId, filters, "")
+		CheckResponseCode(t, http.StatusUnauthorized, response.Code)
+
+		response = otu.FilterProposalsAsAPI(communityId, filters, otu.Login("user2"))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.FilterProposalsAsAPI(communityId, filters, otu.Login("user1"))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var page test_utils.PaginatedResponseWithProposals
+		json.Unmarshal(response.Body.Bytes(), &page)
+		assert.Equal(t, 1, page.TotalRecords)
+	})
+
+	t.Run("Should show a withheld proposal to its author and moderators only", func(t *testing.T) {
+		response := otu.GetProposalByIdAPI(communityId, held.ID)
+		CheckResponseCode(t, http.StatusNotFound, response.Code)
+
+		response = otu.GetProposalAsAPI(communityId, held.ID, otu.Login("user3"))
+		CheckResponseCode(t, http.StatusNotFound, response.Code)
+
+		for _, viewer := range []string{"user1", "user2"} {
+			response = otu.GetProposalAsAPI(communityId, held.ID, otu.Login(viewer))
+			CheckResponseCode(t, http.StatusOK, response.Code)
+			assert.Equal(t, "private, no-cache", response.Header().Get("Cache-Control"))
+		}
+	})
+}
+
+func TestProposalReview(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	clearTable("notifications")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	author := otu.AddressOf("user2")
+	models.GrantAuthorRolesToAddress(A.DB, communityId, author)
+	A.DB.Conn.Exec(A.DB.Context,
+		`UPDATE communities SET require_proposal_review = 'true' WHERE id = $1`, communityId)
+
+	propose := func() models.Proposal {
+		response := otu.CreateProposalAPI(otu.GenerateProposalPayload("user2", otu.GenerateProposalStruct("user2", communityId)))
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+		var p models.Proposal
+		json.Unmarshal(response.Body.Bytes(), &p)
+		return p
+	}
+	approved, rejected := propose(), propose()
+
+	t.Run("Only moderators should review proposals", func(t *testing.T) {
+		response := otu.ReviewProposalAPI(approved.ID, "user3", true, "")
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.ReviewProposalAPI(approved.ID, "user2", true, "")
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("Approved proposals should be published", func(t *testing.T) {
+		response := otu.ReviewProposalAPI(approved.ID, "user1", true, "")
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var p models.Proposal
+		json.Unmarshal(response.Body.Bytes(), &p)
+		assert.Equal(t, models.ProposalPublished, *p.Status)
+		assert.Equal(t, otu.AddressOf("user1"), *p.Reviewed_by)
+
+		response = otu.GetProposalByIdAPI(communityId, approved.ID)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Rejections should need a reason", func(t *testing.T) {
+		response := otu.ReviewProposalAPI(rejected.ID, "user1", false, "")
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+
+		response = otu.ReviewProposalAPI(rejected.ID, "user1", false, "off topic")
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var p models.Proposal
+		json.Unmarshal(response.Body.Bytes(), &p)
+		assert.Equal(t, models.ProposalRejected, *p.Status)
+		assert.Equal(t, "off topic", *p.Review_reason)
+
+		response = otu.GetProposalByIdAPI(communityId, rejected.ID)
+		CheckResponseCode(t, http.StatusNotFound, response.Code)
+	})
+
+	t.Run("Reviewed proposals should not be reviewed again", func(t *testing.T) {
+		response := otu.ReviewProposalAPI(rejected.ID, "user1", true, "")
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("Authors should be notified of the review", func(t *testing.T) {
+		response := otu.GetNotificationsAPI(author, otu.Login("user2"))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var page test_utils.PaginatedResponseWithNotifications
+		json.Unmarshal(response.Body.Bytes(), &page)
+		statuses := map[int]interface{}{}
+		for _, n := range page.Data {
+			if n.Notification_type == models.NotificationProposalReviewed {
+				statuses[*n.Proposal_id] = n.Data["status"]
+				if *n.Proposal_id == rejected.ID {
+					assert.Equal(t, "off topic", n.Data["reason"])
+				}
+			}
+		}
+		assert.Equal(t, map[int]interface{}{
+			approved.ID: models.ProposalPublished,
+			rejected.ID: models.ProposalRejected,
+		}, statuses)
+	})
+}
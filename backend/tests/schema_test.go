@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPayloadSchemas(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+
+	t.Run("Should publish the schema of strict payloads", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/schemas", nil)
+		response := otu.ExecuteRequest(req)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var list []struct {
+			Name string `json:"name"`
+		}
+		json.Unmarshal(response.Body.Bytes(), &list)
+		assert.Contains(t, list, struct {
+			Name string `json:"name"`
+		}{"vote"})
+
+		req, _ = http.NewRequest("GET", "/schemas/vote", nil)
+		response = otu.ExecuteRequest(req)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var schema struct {
+			Type                 string                     `json:"type"`
+			Required             []string                   `json:"required"`
+			Properties           map[string]json.RawMessage `json:"properties"`
+			AdditionalProperties bool                       `json:"additionalProperties"`
+		}
+		json.Unmarshal(response.Body.Bytes(), &schema)
+		assert.Equal(t, "object", schema.Type)
+		assert.Contains(t, schema.Required, "addr")
+		assert.Contains(t, schema.Properties, "compositeSignatures")
+		assert.False(t, schema.AdditionalProperties)
+
+		req, _ = http.NewRequest("GET", "/schemas/nope", nil)
+		response = otu.ExecuteRequest(req)
+		CheckResponseCode(t, http.StatusNotFound, response.Code)
+	})
+
+	post := func(body map[string]interface{}) errorResponse {
+		b, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", "/communities", bytes.NewBuffer(b))
+		req.Header.Set("Content-Type", "application/json")
+		response := otu.ExecuteRequest(req)
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+		var e errorResponse
+		json.Unmarshal(response.Body.Bytes(), &e)
+		return e
+	}
+	community := func() map[string]interface{} {
+		b, _ := json.Marshal(otu.GenerateCommunityPayload("user1", otu.GenerateCommunityStruct("user1", "dao")))
+		var body map[string]interface{}
+		json.Unmarshal(b, &body)
+		return body
+	}
+
+	t.Run("Should refuse fields the payload doesn't declare", func(t *testing.T) {
+		body := community()
+		body["proposalTreshold"] = "10"
+		e := post(body)
+		assert.Equal(t, "ERR_1024", e.ErrorCode)
+		assert.Equal(t, "proposalTreshold is not a field of this payload.", e.Details)
+	})
+
+	t.Run("Should name fields of the wrong type", func(t *testing.T) {
+		body := community()
+		body["name"] = 42
+		e := post(body)
+		assert.Equal(t, "name must be a string, not number.", e.Details)
+	})
+
+	t.Run("Should refuse payloads missing required fields", func(t *testing.T) {
+		body := community()
+		delete(body, "category")
+		e := post(body)
+		assert.Equal(t, "category is required.", e.Details)
+	})
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func withScheme(sigs *[]shared.CompositeSignature, scheme string) *[]shared.CompositeSignature {
+	schemed := make([]shared.CompositeSignature, len(*sigs))
+	for i, sig := range *sigs {
+		sig.Scheme = &scheme
+		schemed[i] = sig
+	}
+	return &schemed
+}
+
+func TestSignatureVerifiers(t *testing.T) {
+	message := "1654000000000"
+	hexMessage := hex.EncodeToString([]byte(message))
+	addr := otu.AddressOf("user1")
+	sigs := otu.GenerateCompositeSignatures("user1", message)
+
+	t.Run("Both Flow schemes should accept a valid user signature", func(t *testing.T) {
+		for _, scheme := range []string{shared.SchemeFlowScript, shared.SchemeFlowKeys} {
+			err := A.SignatureVerifiers.Verify(addr, hexMessage, withScheme(sigs, scheme), "USER")
+			assert.NoError(t, err, scheme)
+		}
+	})
+
+	t.Run("Account key verification should reject a signature over another message", func(t *testing.T) {
+		other := hex.EncodeToString([]byte("1654000000001"))
+		err := A.SignatureVerifiers.Verify(addr, other, withScheme(sigs, shared.SchemeFlowKeys), "USER")
+		assert.ErrorIs(t, err, shared.ErrInvalidSignature)
+	})
+
+	t.Run("Account key verification should reject signatures by another address", func(t *testing.T) {
+		v := shared.AccountKeyVerifier{Accounts: A.FlowAdapter}
+		err := v.Verify(otu.AddressOf("user2"), hexMessage, *sigs, "USER")
+		assert.Error(t, err)
+	})
+
+	t.Run("Account key verification should add up the weight of distinct keys", func(t *testing.T) {
+		p256 := generateAccountKey(t, 0, crypto.ECDSA_P256, crypto.SHA3_256)
+		secp256k1 := generateAccountKey(t, 1, crypto.ECDSA_secp256k1, crypto.SHA2_256)
+		accounts := staticAccounts{&flow.Account{
+			Address: flow.HexToAddress(addr),
+			Keys:    []*flow.AccountKey{p256.key, secp256k1.key},
+		}}
+		v := shared.AccountKeyVerifier{Accounts: accounts}
+
+		both := []shared.CompositeSignature{p256.sign(t, addr, message), secp256k1.sign(t, addr, message)}
+		assert.NoError(t, v.Verify(addr, hexMessage, both, "USER"))
+
+		// a key only carries its weight once, however often it signs
+		repeated := []shared.CompositeSignature{p256.sign(t, addr, message), p256.sign(t, addr, message)}
+		assert.ErrorIs(t, v.Verify(addr, hexMessage, repeated, "USER"), shared.ErrInvalidSignature)
+	})
+
+	t.Run("Unknown schemes should be rejected", func(t *testing.T) {
+		err := A.SignatureVerifiers.Verify(addr, hexMessage, withScheme(sigs, "unknown"), "USER")
+		assert.True(t, errors.Is(err, shared.ErrUnknownSignatureScheme))
+	})
+
+	t.Run("Signatures of one request should not mix schemes", func(t *testing.T) {
+		mixed := append(*withScheme(sigs, shared.SchemeFlowKeys), *withScheme(sigs, shared.SchemeFlowScript)...)
+		err := A.SignatureVerifiers.Verify(addr, hexMessage, &mixed, "USER")
+		assert.Error(t, err)
+	})
+}
+
+type staticAccounts struct {
+	account *flow.Account
+}
+
+func (s staticAccounts) GetAccount(address string) (*flow.Account, error) {
+	return s.account, nil
+}
+
+func (s staticAccounts) GetAccountAtBlockHeight(address string, blockHeight uint64) (*flow.Account, error) {
+	return s.account, nil
+}
+
+type testAccountKey struct {
+	key    *flow.AccountKey
+	signer crypto.Signer
+}
+
+// generateAccountKey makes a key with half the weight an account needs.
+func generateAccountKey(
+	t *testing.T,
+	index int,
+	sigAlgo crypto.SignatureAlgorithm,
+	hashAlgo crypto.HashAlgorithm,
+) testAccountKey {
+	seed := make([]byte, crypto.MinSeedLength)
+	_, err := rand.Read(seed)
+	assert.NoError(t, err)
+	privateKey, err := crypto.GeneratePrivateKey(sigAlgo, seed)
+	assert.NoError(t, err)
+	signer, err := crypto.NewInMemorySigner(privateKey, hashAlgo)
+	assert.NoError(t, err)
+
+	return testAccountKey{
+		key: &flow.AccountKey{
+			Index:     index,
+			PublicKey: privateKey.PublicKey(),
+			SigAlgo:   sigAlgo,
+			HashAlgo:  hashAlgo,
+			Weight:    flow.AccountKeyWeightThreshold / 2,
+		},
+		signer: signer,
+	}
+}
+
+func (k testAccountKey) sign(t *testing.T, addr, message string) shared.CompositeSignature {
+	tag := flow.UserDomainTag
+	sig, err := k.signer.Sign(append(tag[:], []byte(message)...))
+	assert.NoError(t, err)
+	return shared.CompositeSignature{Addr: addr, Key_id: uint(k.key.Index), Signature: hex.EncodeToString(sig)}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/tests/test_utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSorting(t *testing.T) {
+	clearTable("communities")
+	social := otu.AddCommunities(2, "social")
+	dao := otu.AddCommunities(1, "dao")
+
+	communityIds := func(sort string) []int {
+		req, _ := http.NewRequest("GET", "/communities?sort="+sort, nil)
+		response := otu.ExecuteRequest(req)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var p test_utils.PaginatedResponseWithCommunity
+		json.Unmarshal(response.Body.Bytes(), &p)
+		ids := []int{}
+		for _, c := range p.Data {
+			ids = append(ids, c.ID)
+		}
+		return ids
+	}
+
+	t.Run("Should sort by each field in turn", func(t *testing.T) {
+		assert.Equal(t, []int{dao[0], social[1], social[0]}, communityIds("-id"))
+		assert.Equal(t, []int{dao[0], social[1], social[0]}, communityIds("category,-id"))
+		assert.Equal(t, []int{social[0], social[1], dao[0]}, communityIds("-category,id"))
+	})
+
+	t.Run("Should only sort by whitelisted fields", func(t *testing.T) {
+		for _, path := range []string{
+			"/communities?sort=creator_addr",
+			"/communities?sort=id,-id",
+			"/communities/" + strconv.Itoa(dao[0]) + "/proposals?sort=-name;DROP",
+			"/communities/" + strconv.Itoa(dao[0]) + "/users?sort=created_at",
+		} {
+			req, _ := http.NewRequest("GET", path, nil)
+			response := otu.ExecuteRequest(req)
+			CheckResponseCode(t, http.StatusBadRequest, response.Code)
+			var e errorResponse
+			json.Unmarshal(response.Body.Bytes(), &e)
+			assert.Contains(t, e.Details, "Cannot sort by")
+		}
+	})
+
+	t.Run("Should still take the order of proposals", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/communities/"+strconv.Itoa(dao[0])+"/proposals?order=asc;DROP", nil)
+		CheckResponseCode(t, http.StatusOK, otu.ExecuteRequest(req).Code)
+	})
+}
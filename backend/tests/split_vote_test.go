@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitVotes(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	clearTable("proposal_results")
+	clearTable("votes")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+
+	addProposal := func(allowSplitVotes bool) int {
+		name := "one-address-one-vote"
+		p := otu.GenerateProposalStruct("account", communityId)
+		p.Strategy = &name
+		p.Strategy_config = &models.Strategy{Name: &name}
+		p.Start_time = time.Now().UTC().AddDate(0, -1, 0)
+		p.Allow_split_votes = allowSplitVotes
+		assert.NoError(t, p.CreateProposal(A.DB))
+		return p.ID
+	}
+
+	t.Run("Should split the weight of a vote across choices", func(t *testing.T) {
+		proposalId := addProposal(true)
+
+		payload := otu.GenerateValidSplitVotePayload("user1", proposalId, map[string]float64{"a": 60, "b": 40})
+		response := otu.CreateVoteAPI(proposalId, payload)
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+
+		var vote models.Vote
+		response = otu.GetVoteForProposalByAccountNameAPI(proposalId, "user1")
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		json.Unmarshal(response.Body.Bytes(), &vote)
+		assert.Equal(t, "a", vote.Choice)
+		assert.Equal(t, map[string]float64{"a": 60, "b": 40}, vote.Allocation)
+
+		var results models.ProposalResults
+		response = otu.GetProposalResultsAPI(proposalId)
+		json.Unmarshal(response.Body.Bytes(), &results)
+		assert.InDelta(t, 0.6, results.Results_float["a"], 1e-9)
+		assert.InDelta(t, 0.4, results.Results_float["b"], 1e-9)
+		assert.Equal(t, 1, results.Results["a"])
+		assert.Equal(t, 0, results.Results["b"])
+	})
+
+	t.Run("Should require percents to add up to 100", func(t *testing.T) {
+		proposalId := addProposal(true)
+
+		payload := otu.GenerateValidSplitVotePayload("user2", proposalId, map[string]float64{"a": 50, "b": 40})
+		response := otu.CreateVoteAPI(proposalId, payload)
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+		var e errorResponse
+		json.Unmarshal(response.Body.Bytes(), &e)
+		assert.Contains(t, e.Details, "add up to 100")
+	})
+
+	t.Run("Should refuse split votes on proposals that don't allow them", func(t *testing.T) {
+		proposalId := addProposal(false)
+
+		payload := otu.GenerateValidSplitVotePayload("user2", proposalId, map[string]float64{"a": 50, "b": 50})
+		response := otu.CreateVoteAPI(proposalId, payload)
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+}
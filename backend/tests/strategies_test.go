@@ -20,6 +20,7 @@ type Strategy interface {
 	InitStrategy(f *shared.FlowAdapter, db *shared.Database)
 	FetchBalance(b *models.Balance, p *models.Proposal) (*models.Balance, error)
 	RequiresSnapshot() bool
+	EstimateWeight(addr string, strategy *models.Strategy, blockHeight uint64) (float64, error)
 }
 
 var strategyMap = map[string]Strategy{
@@ -148,7 +149,7 @@ func TestBalanceOfNFTsStrategy(t *testing.T) {
 		strategyName := "balance-of-nfts"
 
 		s := strategyMap[strategyName]
-		s.InitStrategy(otu.A.FlowAdapter, otu.A.DB, otu.A.SnapshotClient)
+		s.InitStrategy(otu.A.FlowAdapter, otu.A.DB)
 		proposalWithChoices := models.NewProposalResults(proposalId, choices)
 		_results, err := s.TallyVotes(votes, proposalWithChoices, proposals[0])
 		if err != nil {
@@ -385,3 +386,126 @@ func TestStakedTokenWeightedDefaultStrategy(t *testing.T) {
 // 		}
 // 	})
 // }
+
+func TestDryRunTally(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	addresses := []string{"0x01", "0x02", "0x03"}
+
+	t.Run("Admin should get the weight distribution of a draft strategy", func(t *testing.T) {
+		payload := otu.GenerateDryRunTallyPayload("one-address-one-vote", addresses, "user1")
+		response := otu.DryRunTallyAPI(communityId, payload)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		var tally models.DryRunTally
+		json.Unmarshal(response.Body.Bytes(), &tally)
+		assert.Equal(t, 3, tally.Eligible)
+		assert.Equal(t, 3.0, tally.Total_weight)
+		assert.Equal(t, 1.0, tally.Median_weight)
+		assert.Equal(t, 0.0, tally.Concentration.Gini)
+	})
+
+	t.Run("Non admins should not be able to run a dry run", func(t *testing.T) {
+		payload := otu.GenerateDryRunTallyPayload("one-address-one-vote", addresses, "user2")
+		response := otu.DryRunTallyAPI(communityId, payload)
+		checkResponseCode(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("Unknown strategies should be rejected", func(t *testing.T) {
+		payload := otu.GenerateDryRunTallyPayload("not-a-strategy", addresses, "user1")
+		response := otu.DryRunTallyAPI(communityId, payload)
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+}
+
+func TestWeightCache(t *testing.T) {
+	clearTable("weight_cache")
+
+	name := "token-weighted-default"
+	contractName := "FlowToken"
+	contractAddr := "0x0ae53cb6e3f42a79"
+	strategy := models.Strategy{Name: &name}
+	strategy.Contract.Name = &contractName
+	strategy.Contract.Addr = &contractAddr
+
+	s := &strategies.TokenWeightedDefault{}
+	s.InitStrategy(A.FlowAdapter, A.DB)
+
+	t.Run("Cached balances should be used instead of reading Flow", func(t *testing.T) {
+		cached := models.CachedWeight{
+			Addr:                    "0x01cf0e2f2f715450",
+			Strategy:                models.WeightCacheKey(&strategy),
+			Block_height:            1,
+			Primary_account_balance: 250 * 100000000,
+		}
+		assert.Nil(t, cached.Save(A.DB))
+
+		weight, err := s.EstimateWeight(cached.Addr, &strategy, cached.Block_height)
+		assert.Nil(t, err)
+		assert.Equal(t, 250.0, weight)
+	})
+
+	t.Run("Different strategies should not share entries", func(t *testing.T) {
+		other := "staked-token-weighted-default"
+		otherStrategy := strategy
+		otherStrategy.Name = &other
+
+		w, err := models.GetCachedWeight(A.DB, "0x01cf0e2f2f715450", models.WeightCacheKey(&otherStrategy), 1)
+		assert.Nil(t, err)
+		assert.Nil(t, w)
+	})
+}
+
+func TestStreamingTally(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	clearTable("votes")
+	clearTable("balances")
+
+	strategyName := "token-weighted-default"
+	communityId := otu.AddCommunities(1, "dao")[0]
+	proposalIds, proposals := otu.AddProposalsForStrategy(communityId, strategyName, 1)
+	proposalId := proposalIds[0]
+	votes := otu.GenerateListOfVotes(proposalId, 10)
+	if err := otu.AddDummyVotesAndBalances(votes); err != nil {
+		t.Errorf("Error adding votes and balances: %s", err)
+	}
+
+	_, err := A.DB.Conn.Exec(A.DB.Context,
+		`UPDATE proposals SET end_time = now() - interval '1 hour' WHERE id = $1`,
+		proposalId,
+	)
+	assert.Nil(t, err)
+
+	s := strategyMap[strategyName]
+	expected, _ := s.TallyVotes(votes, models.NewProposalResults(proposalId, proposals[0].Choices), proposals[0])
+
+	t.Run("An interrupted tally of an ended proposal resumes where it stopped", func(t *testing.T) {
+		// the first half was counted before the tally stopped
+		partial, _ := s.TallyVotes(votes[:5], models.NewProposalResults(proposalId, proposals[0].Choices), proposals[0])
+		progress := models.TallyProgress{
+			Proposal_id:   proposalId,
+			Last_vote_id:  5,
+			Votes_counted: 5,
+			Results:       partial.Results,
+			Results_float: partial.Results_float,
+		}
+		assert.Nil(t, progress.Save(A.DB))
+
+		response := otu.GetProposalResultsAPI(proposalId)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var results models.ProposalResults
+		json.Unmarshal(response.Body.Bytes(), &results)
+		assert.Equal(t, expected.Results, results.Results)
+		assert.InDelta(t, expected.Results_float["a"], results.Results_float["a"], 1e-9)
+		assert.InDelta(t, expected.Results_float["b"], results.Results_float["b"], 1e-9)
+
+		saved, err := models.GetTallyProgress(A.DB, proposalId)
+		assert.Nil(t, err)
+		assert.Equal(t, 10, saved.Last_vote_id)
+	})
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProposalFeeds(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	clearTable("proposal_results")
+	clearTable("votes")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+
+	name := "one-address-one-vote"
+	now := time.Now().UTC()
+	closed := otu.GenerateProposalStruct("account", communityId)
+	closed.Name = "Closed proposal"
+	closed.Strategy = &name
+	closed.Strategy_config = &models.Strategy{Name: &name}
+	closed.Start_time = now.AddDate(0, 0, -7)
+	closed.End_time = now.Add(-time.Hour)
+	assert.NoError(t, closed.CreateProposal(A.DB))
+	vote := models.Vote{
+		Proposal_id:          closed.ID,
+		Addr:                 otu.AddressOf("user1"),
+		Choice:               "a",
+		Composite_signatures: &[]shared.CompositeSignature{},
+	}
+	assert.NoError(t, vote.CreateVote(A.DB))
+
+	open := otu.GenerateProposalStruct("account", communityId)
+	open.Name = "Open proposal"
+	assert.NoError(t, open.CreateProposal(A.DB))
+
+	t.Run("Should list a community's proposals as RSS", func(t *testing.T) {
+		response := otu.GetProposalFeedAPI(fmt.Sprintf("/communities/%d/proposals.rss", communityId))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		assert.Contains(t, response.Header().Get("Content-Type"), "application/rss+xml")
+
+		var feed models.RssFeed
+		assert.NoError(t, xml.Unmarshal(response.Body.Bytes(), &feed))
+		if assert.Equal(t, 2, len(feed.Channel.Items)) {
+			assert.Equal(t, "Open proposal", feed.Channel.Items[0].Title)
+			assert.Equal(t, "Closed proposal", feed.Channel.Items[1].Title)
+			assert.Contains(t, feed.Channel.Items[1].Description, "Results: a 100.0% (1 votes)")
+			assert.NotContains(t, feed.Channel.Items[0].Description, "Results:")
+		}
+	})
+
+	t.Run("Should list every community's proposals as Atom", func(t *testing.T) {
+		response := otu.GetProposalFeedAPI("/proposals.atom")
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var feed models.AtomFeed
+		assert.NoError(t, xml.Unmarshal(response.Body.Bytes(), &feed))
+		assert.Equal(t, 2, len(feed.Entries))
+	})
+}
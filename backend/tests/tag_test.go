@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/tests/test_utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProposalTags(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("community_tags")
+	clearTable("proposals")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	tagIds := map[string]int{}
+
+	propose := func(tags ...string) *httptest.ResponseRecorder {
+		proposal := otu.GenerateProposalStruct("user1", communityId)
+		proposal.Tags = tags
+		return otu.CreateProposalAPI(otu.GenerateProposalPayload("user1", proposal))
+	}
+
+	proposalIds := func(tags string) []int {
+		response := otu.FilterProposalsAsAPI(communityId, url.Values{"tags": {tags}}, "")
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var page test_utils.PaginatedResponseWithProposals
+		json.Unmarshal(response.Body.Bytes(), &page)
+		ids := []int{}
+		for _, p := range page.Data {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+
+	t.Run("Only admins should add tags to the vocabulary", func(t *testing.T) {
+		response := otu.CreateCommunityTagAPI(communityId, otu.GenerateCommunityTagPayload("user2", "treasury"))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		for _, name := range []string{" Treasury ", "governance"} {
+			response = otu.CreateCommunityTagAPI(communityId, otu.GenerateCommunityTagPayload("user1", name))
+			CheckResponseCode(t, http.StatusCreated, response.Code)
+			var tag models.CommunityTag
+			json.Unmarshal(response.Body.Bytes(), &tag)
+			tagIds[tag.Name] = tag.ID
+		}
+
+		response = otu.CreateCommunityTagAPI(communityId, otu.GenerateCommunityTagPayload("user1", "treasury"))
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+
+		response = otu.GetCommunityTagsAPI(communityId)
+		var tags []models.CommunityTag
+		json.Unmarshal(response.Body.Bytes(), &tags)
+		assert.Len(t, tags, 2)
+		assert.Equal(t, "governance", tags[0].Name)
+		assert.Equal(t, "treasury", tags[1].Name)
+	})
+
+	var ids []int
+
+	t.Run("Proposals should only carry tags of the vocabulary", func(t *testing.T) {
+		response := propose("memes")
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+
+		for _, tags := range [][]string{{"treasury"}, {"treasury", "governance"}, {}} {
+			response = propose(tags...)
+			CheckResponseCode(t, http.StatusCreated, response.Code)
+			var p models.Proposal
+			json.Unmarshal(response.Body.Bytes(), &p)
+			assert.ElementsMatch(t, tags, p.Tags)
+			ids = append(ids, p.ID)
+		}
+	})
+
+	t.Run("Should filter proposals by every requested tag", func(t *testing.T) {
+		assert.ElementsMatch(t, []int{ids[0], ids[1]}, proposalIds("treasury"))
+		assert.Equal(t, []int{ids[1]}, proposalIds("treasury,governance"))
+	})
+
+	t.Run("Search should count the tags of the results", func(t *testing.T) {
+		response := otu.GetSearchCommunitiesAPI([]string{}, "test", nil)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var p test_utils.PaginatedResponseSearch
+		json.Unmarshal(response.Body.Bytes(), &p)
+		assert.Equal(t, []test_utils.SearchFilter{
+			{Text: "treasury", Amount: 2},
+			{Text: "governance", Amount: 1},
+		}, p.Tags)
+	})
+
+	t.Run("Deleted tags should be removed from proposals", func(t *testing.T) {
+		response := otu.DeleteCommunityTagAPI(communityId, tagIds["governance"], otu.GenerateCommunityTagPayload("user2", "governance"))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.DeleteCommunityTagAPI(communityId, tagIds["governance"], otu.GenerateCommunityTagPayload("user1", "governance"))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		assert.Empty(t, proposalIds("governance"))
+		response = otu.GetProposalByIdAPI(communityId, ids[1])
+		var p models.Proposal
+		json.Unmarshal(response.Body.Bytes(), &p)
+		assert.Equal(t, []string{"treasury"}, p.Tags)
+	})
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/tests/test_utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var errUnknownTenant = errorResponse{
+	StatusCode: http.StatusNotFound,
+	ErrorCode:  "ERR_1026",
+	Message:    "Unknown Tenant",
+	Details:    "The requested tenant does not exist.",
+}
+
+func clearTenants() {
+	A.DB.Conn.Exec(A.DB.Context, "DELETE FROM homepage_sections WHERE tenant_id <> $1", models.DefaultTenantID)
+	A.DB.Conn.Exec(A.DB.Context, "DELETE FROM tenants WHERE id <> $1", models.DefaultTenantID)
+	A.ReloadTenants()
+}
+
+func TestTenants(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTenants()
+	defer clearTenants()
+
+	A.Config.Multi_tenant = true
+	defer func() { A.Config.Multi_tenant = false }()
+	A.AdminAllowlist.Set([]string{otu.AddressOf("user1")})
+	defer A.AdminAllowlist.Set(nil)
+
+	slug, name := "acme", "Acme"
+	hostnames := []string{"vote.acme.xyz"}
+	admins := []string{otu.AddressOf("user2")}
+	branding := map[string]string{"primaryColor": "#ff3300"}
+	response := otu.TenantAPI("POST", 0, &models.TenantPayload{
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload("user1"),
+		Slug:                      &slug,
+		Name:                      &name,
+		Hostnames:                 &hostnames,
+		Branding:                  &branding,
+		Admin_addrs:               &admins,
+	})
+	CheckResponseCode(t, http.StatusCreated, response.Code)
+	var tenant models.Tenant
+	json.Unmarshal(response.Body.Bytes(), &tenant)
+
+	getTenant := func(req *http.Request) models.TenantBranding {
+		response := otu.ExecuteRequest(req)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var b models.TenantBranding
+		json.Unmarshal(response.Body.Bytes(), &b)
+		return b
+	}
+
+	t.Run("Should resolve the tenant by header, then hostname", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/tenant", nil)
+		req.Header.Set("X-Tenant-ID", "acme")
+		b := getTenant(req)
+		assert.Equal(t, "acme", b.Slug)
+		assert.Equal(t, "#ff3300", b.Branding["primaryColor"])
+
+		req, _ = http.NewRequest("GET", "/tenant", nil)
+		req.Header.Set("X-Tenant-ID", strconv.Itoa(tenant.ID))
+		assert.Equal(t, "acme", getTenant(req).Slug)
+
+		req, _ = http.NewRequest("GET", "/tenant", nil)
+		req.Host = "VOTE.acme.xyz:443"
+		assert.Equal(t, "acme", getTenant(req).Slug)
+
+		req, _ = http.NewRequest("GET", "/tenant", nil)
+		assert.Equal(t, "default", getTenant(req).Slug)
+
+		req, _ = http.NewRequest("GET", "/tenant", nil)
+		req.Header.Set("X-Tenant-ID", "nope")
+		response := otu.ExecuteRequest(req)
+		CheckResponseCode(t, http.StatusNotFound, response.Code)
+		var e errorResponse
+		json.Unmarshal(response.Body.Bytes(), &e)
+		assert.Equal(t, errUnknownTenant, e)
+	})
+
+	t.Run("Should ignore tenants when not multi-tenant", func(t *testing.T) {
+		A.Config.Multi_tenant = false
+		defer func() { A.Config.Multi_tenant = true }()
+
+		req, _ := http.NewRequest("GET", "/tenant", nil)
+		req.Header.Set("X-Tenant-ID", "acme")
+		assert.Equal(t, "default", getTenant(req).Slug)
+	})
+
+	t.Run("Should not take a slug or hostname of another tenant", func(t *testing.T) {
+		other := "other"
+		response := otu.TenantAPI("POST", 0, &models.TenantPayload{
+			TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload("user1"),
+			Slug:                      &other,
+			Name:                      &name,
+			Hostnames:                 &hostnames,
+		})
+		CheckResponseCode(t, http.StatusConflict, response.Code)
+
+		response = otu.TenantAPI("PATCH", tenant.ID, &models.TenantPayload{
+			TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload("user2"),
+			Name:                      &other,
+		})
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+	})
+
+	ids := otu.AddCommunities(2, "dao")
+	A.DB.Conn.Exec(A.DB.Context, "UPDATE communities SET tenant_id = $1 WHERE id = $2", tenant.ID, ids[1])
+
+	t.Run("Should list and show only the tenant's communities", func(t *testing.T) {
+		communityIds := func(tenant string) []int {
+			req, _ := http.NewRequest("GET", "/communities", nil)
+			response := otu.ExecuteTenantRequest(req, tenant)
+			CheckResponseCode(t, http.StatusOK, response.Code)
+			var p test_utils.PaginatedResponseWithCommunity
+			json.Unmarshal(response.Body.Bytes(), &p)
+			ids := []int{}
+			for _, c := range p.Data {
+				ids = append(ids, c.ID)
+			}
+			return ids
+		}
+		assert.Equal(t, []int{ids[0]}, communityIds(""))
+		assert.Equal(t, []int{ids[1]}, communityIds("acme"))
+
+		req, _ := http.NewRequest("GET", "/communities/"+strconv.Itoa(ids[0]), nil)
+		CheckResponseCode(t, http.StatusNotFound, otu.ExecuteTenantRequest(req, "acme").Code)
+	})
+
+	t.Run("Should not serve sub-resources of another tenant's communities", func(t *testing.T) {
+		proposalId := otu.AddActiveProposals(ids[0], 1)[0]
+		paths := []string{
+			fmt.Sprintf("/communities/%d/proposals", ids[0]),
+			fmt.Sprintf("/communities/%d/proposals/%d", ids[0], proposalId),
+			fmt.Sprintf("/communities/%d/leaderboard", ids[0]),
+			fmt.Sprintf("/proposals/%d", proposalId),
+			fmt.Sprintf("/proposals/%d/votes", proposalId),
+			fmt.Sprintf("/proposals/%d/results", proposalId),
+		}
+		for _, path := range paths {
+			req, _ := http.NewRequest("GET", path, nil)
+			CheckResponseCode(t, http.StatusNotFound, otu.ExecuteTenantRequest(req, "acme").Code)
+
+			req, _ = http.NewRequest("GET", path, nil)
+			CheckResponseCode(t, http.StatusOK, otu.ExecuteTenantRequest(req, "").Code)
+		}
+	})
+
+	t.Run("Tenant admins should curate only their homepage", func(t *testing.T) {
+		response := otu.SetTenantHomepageOrderAPI("acme", "user2", []int{ids[1]})
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		response = otu.SetTenantHomepageOrderAPI("", "user2", []int{ids[0]})
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		payload, _ := json.Marshal(otu.GenerateTimestampSignaturePayload("user1"))
+		req, _ := http.NewRequest("POST", "/admin/communities/"+strconv.Itoa(ids[0])+"/pin", bytes.NewBuffer(payload))
+		CheckResponseCode(t, http.StatusNotFound, otu.ExecuteTenantRequest(req, "acme").Code)
+	})
+}
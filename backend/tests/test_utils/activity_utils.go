@@ -0,0 +1,35 @@
+package test_utils
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+)
+
+type PaginatedResponseWithActivity struct {
+	Data         []models.Activity `json:"data"`
+	Start        int               `json:"start"`
+	Count        int               `json:"count"`
+	TotalRecords int               `json:"totalRecords"`
+	Next         int               `json:"next"`
+}
+
+type PaginatedResponseWithEvents struct {
+	Data         []models.CommunityEvent `json:"data"`
+	Start        int                     `json:"start"`
+	Count        int                     `json:"count"`
+	TotalRecords int                     `json:"totalRecords"`
+	Next         int                     `json:"next"`
+}
+
+func (otu *OverflowTestUtils) GetAccountActivityAPI(addr, activityType string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/accounts/"+addr+"/activity?type="+activityType, nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetCommunityFeedAPI(communityId, count int) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/communities/%d/feed?count=%d", communityId, count), nil)
+	return otu.ExecuteRequest(req)
+}
@@ -0,0 +1,205 @@
+package test_utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+)
+
+func (otu *OverflowTestUtils) GenerateSuspendCommunityPayload(signer, reason string) *models.SuspendCommunityPayload {
+	return &models.SuspendCommunityPayload{
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+		Reason:                    reason,
+	}
+}
+
+func (otu *OverflowTestUtils) GenerateAddressListPayload(signer string, addresses []string) *models.AddressListPayload {
+	return &models.AddressListPayload{
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+		Addresses:                 addresses,
+	}
+}
+
+func (otu *OverflowTestUtils) GetAdminCommunitiesAPI(filter, token string) *httptest.ResponseRecorder {
+	return otu.adminGet("/admin/communities?filter="+filter, token)
+}
+
+// AdminCommunityAPI calls one of the feature, unfeature, suspend and
+// unsuspend actions on a community.
+func (otu *OverflowTestUtils) AdminCommunityAPI(communityId int, action string, payload interface{}) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", "/admin/communities/"+strconv.Itoa(communityId)+"/"+action, bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) UnarchiveCommunityAPI(communityId int, signer string) *httptest.ResponseRecorder {
+	payload := models.ArchiveCommunityRequestPayload{TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer)}
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", "/communities/"+strconv.Itoa(communityId)+"/unarchive", bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+// GetAddressListAPI reads the "allowlist" or "blocklist".
+func (otu *OverflowTestUtils) GetAddressListAPI(list, token string) *httptest.ResponseRecorder {
+	return otu.adminGet("/admin/"+list, token)
+}
+
+// UpdateAddressListAPI adds to the "allowlist" or "blocklist" with POST and
+// removes from it with DELETE.
+func (otu *OverflowTestUtils) UpdateAddressListAPI(method, list string, payload *models.AddressListPayload) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest(method, "/admin/"+list, bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetFailedJobsAPI(job, token string) *httptest.ResponseRecorder {
+	return otu.adminGet("/admin/jobs/failed?job="+job, token)
+}
+
+func (otu *OverflowTestUtils) GetPlatformStatsAPI(token string) *httptest.ResponseRecorder {
+	return otu.adminGet("/admin/stats", token)
+}
+
+func (otu *OverflowTestUtils) GetConfigAPI(token string) *httptest.ResponseRecorder {
+	return otu.adminGet("/admin/config", token)
+}
+
+func (otu *OverflowTestUtils) CreateApiKeyAPI(payload *models.CreateApiKeyPayload) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", "/admin/api-keys", bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetApiKeysAPI(communityId int, token string) *httptest.ResponseRecorder {
+	return otu.adminGet("/admin/api-keys?communityId="+strconv.Itoa(communityId), token)
+}
+
+func (otu *OverflowTestUtils) GetApiKeyUsageAPI(id int, token string) *httptest.ResponseRecorder {
+	return otu.adminGet("/admin/api-keys/"+strconv.Itoa(id)+"/usage", token)
+}
+
+func (otu *OverflowTestUtils) RevokeApiKeyAPI(id int, payload *shared.TimestampSignaturePayload) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("DELETE", "/admin/api-keys/"+strconv.Itoa(id), bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+// PartnerGetAPI reads a /partner path with an API key.
+func (otu *OverflowTestUtils) PartnerGetAPI(path, key string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", path, nil)
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) adminGet(path, token string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetFeatureFlagsAPI(token string) *httptest.ResponseRecorder {
+	return otu.adminGet("/admin/features", token)
+}
+
+func (otu *OverflowTestUtils) SetFeatureRolloutAPI(signer, name string, percent int) *httptest.ResponseRecorder {
+	payload := models.FeatureRolloutPayload{
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+		Rollout_percent:           &percent,
+	}
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("PUT", "/admin/features/"+name, bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+// SetCommunityFeatureAPI turns the flag on or off for the community, or
+// returns it to the rollout when enabled is nil.
+func (otu *OverflowTestUtils) SetCommunityFeatureAPI(signer, name string, communityId int, enabled *bool) *httptest.ResponseRecorder {
+	method := "PUT"
+	if enabled == nil {
+		method = "DELETE"
+	}
+	payload := models.CommunityFeaturePayload{
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+		Enabled:                   enabled,
+	}
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest(method, "/admin/features/"+name+"/communities/"+strconv.Itoa(communityId), bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetCommunityFeaturesAPI(communityId int) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/communities/"+strconv.Itoa(communityId)+"/features", nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetHomepageAPI() *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/homepage", nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) SetHomepageOrderAPI(signer string, communityIds []int) *httptest.ResponseRecorder {
+	return otu.SetTenantHomepageOrderAPI("", signer, communityIds)
+}
+
+// SetTenantHomepageOrderAPI orders the homepage of the tenant, or of the
+// default tenant when tenant is empty.
+func (otu *OverflowTestUtils) SetTenantHomepageOrderAPI(tenant, signer string, communityIds []int) *httptest.ResponseRecorder {
+	payload := models.HomepageOrderPayload{
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+		Community_ids:             communityIds,
+	}
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("PUT", "/admin/homepage/order", bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteTenantRequest(req, tenant)
+}
+
+// HomepageSectionAPI creates a section with POST, and updates or deletes
+// the section with PATCH and DELETE.
+func (otu *OverflowTestUtils) HomepageSectionAPI(method string, sectionId int, payload *models.HomepageSectionPayload) *httptest.ResponseRecorder {
+	url := "/admin/homepage/sections"
+	if sectionId != 0 {
+		url += "/" + strconv.Itoa(sectionId)
+	}
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest(method, url, bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+// TenantAPI creates a tenant with POST, and updates the tenant with PATCH.
+func (otu *OverflowTestUtils) TenantAPI(method string, tenantId int, payload *models.TenantPayload) *httptest.ResponseRecorder {
+	url := "/admin/tenants"
+	if tenantId != 0 {
+		url += "/" + strconv.Itoa(tenantId)
+	}
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest(method, url, bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+// ExecuteTenantRequest sends the request on behalf of the tenant, named by
+// slug or id.
+func (otu *OverflowTestUtils) ExecuteTenantRequest(req *http.Request, tenant string) *httptest.ResponseRecorder {
+	if tenant != "" {
+		req.Header.Set("X-Tenant-ID", tenant)
+	}
+	return otu.ExecuteRequest(req)
+}
@@ -0,0 +1,62 @@
+package test_utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+)
+
+func (otu *OverflowTestUtils) GenerateLoginPayload(signer string) *models.LoginPayload {
+	return &models.LoginPayload{TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer)}
+}
+
+func (otu *OverflowTestUtils) LoginAPI(payload *models.LoginPayload) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", "/auth/login", bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+// Login returns a session token for the account.
+func (otu *OverflowTestUtils) Login(signer string) string {
+	var session models.Session
+	response := otu.LoginAPI(otu.GenerateLoginPayload(signer))
+	json.Unmarshal(response.Body.Bytes(), &session)
+	return session.Token
+}
+
+func (otu *OverflowTestUtils) GetNotificationsAPI(addr, token string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/users/"+addr+"/notifications", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetJoinRequestsAPI(communityId int, token string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/communities/"+strconv.Itoa(communityId)+"/join-requests", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetInvitesAPI(communityId int, token string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/communities/"+strconv.Itoa(communityId)+"/invites", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetMyFeedAPI(addr, token string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/me/feed?addr="+addr, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return otu.ExecuteRequest(req)
+}
@@ -0,0 +1,53 @@
+package test_utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+)
+
+type PaginatedResponseWithBans struct {
+	Data         []models.CommunityBan `json:"data"`
+	Start        int                   `json:"start"`
+	Count        int                   `json:"count"`
+	TotalRecords int                   `json:"totalRecords"`
+	Next         int                   `json:"next"`
+}
+
+func (otu *OverflowTestUtils) GenerateBanPayload(signer, addr, reason string) *models.CommunityBanPayload {
+	payload := models.CommunityBanPayload{
+		Addr:                      addr,
+		Reason:                    &reason,
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+	}
+	return &payload
+}
+
+func (otu *OverflowTestUtils) BanAddressAPI(communityId int, payload *models.CommunityBanPayload) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/communities/%d/bans", communityId), bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) UnbanAddressAPI(communityId int, payload *models.CommunityBanPayload) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	path := fmt.Sprintf("/communities/%d/bans/%s", communityId, payload.Addr)
+	req, _ := http.NewRequest("DELETE", path, bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetCommunityBansAPI(communityId int) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/communities/%d/bans", communityId), nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) ExportCommunityBansAPI(communityId int) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/communities/%d/bans/export", communityId), nil)
+	return otu.ExecuteRequest(req)
+}
@@ -0,0 +1,41 @@
+package test_utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+)
+
+func (otu *OverflowTestUtils) GenerateEntitlementEvent(id, eventType string, communityId int, plan string) models.EntitlementEvent {
+	return models.EntitlementEvent{
+		ID:         id,
+		Type:       eventType,
+		Created_at: time.Now().UTC(),
+		Data:       models.EntitlementData{Community_id: communityId, Plan: plan},
+	}
+}
+
+// BillingWebhookAPI delivers an event signed with secret, as the payment
+// processor would.
+func (otu *OverflowTestUtils) BillingWebhookAPI(event models.EntitlementEvent, secret string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(event)
+	req, _ := http.NewRequest("POST", "/billing/webhooks", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Billing-Signature", shared.SignBillingWebhook(secret, body, time.Now()))
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetPlansAPI() *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/plans", nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetCommunityBillingAPI(communityId int, token string) *httptest.ResponseRecorder {
+	return otu.adminGet(fmt.Sprintf("/communities/%d/billing", communityId), token)
+}
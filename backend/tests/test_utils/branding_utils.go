@@ -0,0 +1,31 @@
+package test_utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+)
+
+func (otu *OverflowTestUtils) GetCommunityBrandingAPI(communityId int) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/communities/"+strconv.Itoa(communityId)+"/branding", nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) SetCommunityBrandingAPI(communityId int, payload *models.CommunityBrandingPayload) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("PUT", "/communities/"+strconv.Itoa(communityId)+"/branding", bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+// GetBrandingForHostAPI resolves the branding of the community served
+// from host, as a request sent to it.
+func (otu *OverflowTestUtils) GetBrandingForHostAPI(host string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/branding", nil)
+	req.Host = host
+	return otu.ExecuteRequest(req)
+}
@@ -55,6 +55,14 @@ type PaginatedResponseWithLeaderboardUser struct {
 	Next         int                       `json:"next"`
 }
 
+type PaginatedResponseWithAchievements struct {
+	Data         []models.Achievement `json:"data"`
+	Start        int                  `json:"start"`
+	Count        int                  `json:"count"`
+	TotalRecords int                  `json:"totalRecords"`
+	Next         int                  `json:"next"`
+}
+
 type SearchFilter struct {
 	Text string 	`json:"text"`
 	Amount int 		`json:"amount"`
@@ -63,6 +71,7 @@ type SearchFilter struct {
 type PaginatedResponseSearch struct {
 	Filters []SearchFilter 	`json:"filters"`
 	Results PaginatedResponseWithCommunity   	`json:"results"`
+	Tags    []SearchFilter `json:"tags"`
 }
 
 var (
@@ -312,10 +321,23 @@ func (otu *OverflowTestUtils) CreateCommunityDB(community *models.Community) err
 	return community.CreateCommunity(otu.A.DB)
 }
 
+// UpdateCommunityAPI updates the current version of the community.
 func (otu *OverflowTestUtils) UpdateCommunityAPI(id int, payload *models.Community) *httptest.ResponseRecorder {
+	etag := otu.GetCommunityAPI(id).Header().Get("ETag")
+	return otu.UpdateCommunityWithETagAPI(id, etag, payload)
+}
+
+func (otu *OverflowTestUtils) UpdateCommunityWithETagAPI(
+	id int,
+	etag string,
+	payload *models.Community,
+) *httptest.ResponseRecorder {
 	json, _ := json.Marshal(payload)
 	req, _ := http.NewRequest("PATCH", "/communities/"+strconv.Itoa(id), bytes.NewBuffer(json))
 	req.Header.Set("Content-Type", "application/json")
+	if etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
 
 	response := otu.ExecuteRequest(req)
 	return response
@@ -327,6 +349,16 @@ func (otu *OverflowTestUtils) GetCommunityAPI(id int) *httptest.ResponseRecorder
 	return response
 }
 
+func (otu *OverflowTestUtils) GetChildCommunitiesAPI(id int) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/communities/"+strconv.Itoa(id)+"/children", nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetCommunityHierarchyAPI(id int) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/communities/"+strconv.Itoa(id)+"/hierarchy", nil)
+	return otu.ExecuteRequest(req)
+}
+
 func (otu *OverflowTestUtils) GetCommunitiesForHomepageAPI() *httptest.ResponseRecorder {
 	req, _ := http.NewRequest("GET", "/communities-for-homepage", nil)
 	response := otu.ExecuteRequest(req)
@@ -377,6 +409,12 @@ func (otu *OverflowTestUtils) GetSearchCommunitiesAPI(filters []string, text str
 	return response
 }
 
+func (otu *OverflowTestUtils) GetCommunityAnalyticsAPI(id int) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/communities/"+strconv.Itoa(id)+"/analytics", nil)
+	response := otu.ExecuteRequest(req)
+	return response
+}
+
 func (otu *OverflowTestUtils) GetCommunityLeaderboardAPI(id int) *httptest.ResponseRecorder {
 	req, _ := http.NewRequest("GET", "/communities/"+strconv.Itoa(id)+"/leaderboard", nil)
 	response := otu.ExecuteRequest(req)
@@ -389,6 +427,12 @@ func (otu *OverflowTestUtils) GetCommunityLeaderboardAPIWithCurrentUser(id int,
 	return response
 }
 
+func (otu *OverflowTestUtils) GetCommunityLeaderboardAPIWithMetric(id int, window, metric string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/communities/"+strconv.Itoa(id)+"/leaderboard?window="+window+"&metric="+metric, nil)
+	response := otu.ExecuteRequest(req)
+	return response
+}
+
 func (otu *OverflowTestUtils) GetCommunityLeaderboardAPIWithPaging(id, start, count int) *httptest.ResponseRecorder {
 	req, _ := http.NewRequest("GET", "/communities/"+strconv.Itoa(id)+"/leaderboard?start="+strconv.Itoa(start)+"&count="+strconv.Itoa(count), nil)
 	response := otu.ExecuteRequest(req)
@@ -412,3 +456,148 @@ func (otu *OverflowTestUtils) GetCommunityActiveStrategies(id int) *httptest.Res
 	response := otu.ExecuteRequest(req)
 	return response
 }
+
+func (otu *OverflowTestUtils) GenerateTreasuryPayload(signer, name, addr string) *models.TreasuryPayload {
+	return &models.TreasuryPayload{
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+		Treasury: models.Treasury{
+			Name:          name,
+			Addr:          addr,
+			Contract_name: flowContractName,
+			Contract_addr: flowContractAddr,
+			Public_path:   flowPublicPath,
+		},
+	}
+}
+
+func (otu *OverflowTestUtils) CreateTreasuryAPI(communityId int, payload *models.TreasuryPayload) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", "/communities/"+strconv.Itoa(communityId)+"/treasuries", bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) DeleteTreasuryAPI(communityId, treasuryId int, payload *models.TreasuryPayload) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	path := fmt.Sprintf("/communities/%d/treasuries/%d", communityId, treasuryId)
+	req, _ := http.NewRequest("DELETE", path, bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetTreasuriesAPI(communityId int) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/communities/"+strconv.Itoa(communityId)+"/treasuries", nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GenerateCommunityTokenPayload(signer, balancePath string) *models.CommunityTokenPayload {
+	return &models.CommunityTokenPayload{
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+		CommunityToken: models.CommunityToken{
+			Contract_name: flowContractName,
+			Contract_addr: flowContractAddr,
+			Vault_path:    "flowTokenVault",
+			Receiver_path: "flowTokenReceiver",
+			Balance_path:  balancePath,
+			Decimals:      8,
+		},
+	}
+}
+
+func (otu *OverflowTestUtils) RegisterCommunityTokenAPI(communityId int, payload *models.CommunityTokenPayload) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", "/communities/"+strconv.Itoa(communityId)+"/tokens", bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) DeleteCommunityTokenAPI(communityId, tokenId int, payload *models.CommunityTokenPayload) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	path := fmt.Sprintf("/communities/%d/tokens/%d", communityId, tokenId)
+	req, _ := http.NewRequest("DELETE", path, bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetCommunityTokensAPI(communityId int) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/communities/"+strconv.Itoa(communityId)+"/tokens", nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetTokensAPI(communityId int) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/tokens?communityId="+strconv.Itoa(communityId), nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GeneratePendingActionPayload(signer, action string, payload interface{}) *models.PendingActionPayload {
+	raw, _ := json.Marshal(payload)
+	return &models.PendingActionPayload{
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+		Action:                    action,
+		Payload:                   raw,
+	}
+}
+
+func (otu *OverflowTestUtils) GeneratePendingActionApproval(signer string) *models.PendingActionApproval {
+	return &models.PendingActionApproval{
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+	}
+}
+
+func (otu *OverflowTestUtils) ProposeCommunityActionAPI(communityId int, payload *models.PendingActionPayload) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", "/communities/"+strconv.Itoa(communityId)+"/actions", bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+// ResolveCommunityActionAPI approves or cancels the action.
+func (otu *OverflowTestUtils) ResolveCommunityActionAPI(
+	communityId, actionId int,
+	resolution string,
+	payload *models.PendingActionApproval,
+) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	path := fmt.Sprintf("/communities/%d/actions/%d/%s", communityId, actionId, resolution)
+	req, _ := http.NewRequest("POST", path, bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetCommunityActionsAPI(communityId int, status string) *httptest.ResponseRecorder {
+	path := fmt.Sprintf("/communities/%d/actions?status=%s", communityId, status)
+	req, _ := http.NewRequest("GET", path, nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetStrategyVersionsAPI(communityId int) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/communities/%d/strategies/versions", communityId), nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) PreviewStrategyChangeAPI(
+	communityId int,
+	signer string,
+	strategies []models.Strategy,
+) *httptest.ResponseRecorder {
+	payload := models.StrategyChangePayload{
+		Strategies:                strategies,
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+	}
+	json, _ := json.Marshal(payload)
+	path := fmt.Sprintf("/communities/%d/strategies/impact", communityId)
+	req, _ := http.NewRequest("POST", path, bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetProposalEligibilityAPI(communityId int, addr string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/communities/%d/proposal-eligibility/%s", communityId, addr), nil)
+	return otu.ExecuteRequest(req)
+}
+
+// GetCommunityTriggerAPI polls a trigger, query is the raw query string.
+func (otu *OverflowTestUtils) GetCommunityTriggerAPI(communityId int, trigger, query string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/communities/"+strconv.Itoa(communityId)+"/triggers/"+trigger+"?"+query, nil)
+	return otu.ExecuteRequest(req)
+}
@@ -0,0 +1,35 @@
+package test_utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+)
+
+func (otu *OverflowTestUtils) GenerateDepositSettlementPayload(signer, txId string) *models.DepositSettlementPayload {
+	return &models.DepositSettlementPayload{
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+		Tx_id:                     txId,
+	}
+}
+
+func (otu *OverflowTestUtils) SettleProposalDepositAPI(
+	proposalId int,
+	payload *models.DepositSettlementPayload,
+) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/proposals/%d/deposit/settlement", proposalId), bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetProposalDepositsAPI(communityId int, status string) *httptest.ResponseRecorder {
+	query := url.Values{"status": {status}}
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/communities/%d/deposits?%s", communityId, query.Encode()), nil)
+	return otu.ExecuteRequest(req)
+}
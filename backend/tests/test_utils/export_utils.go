@@ -0,0 +1,61 @@
+package test_utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/rs/zerolog/log"
+)
+
+func (otu *OverflowTestUtils) CreateCommunityExportAPI(communityId int, signer string) *httptest.ResponseRecorder {
+	payload := models.CreateExportPayload{
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+	}
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/communities/%d/exports", communityId), bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetCommunityExportAPI(communityId, exportId int, token string) *httptest.ResponseRecorder {
+	url := fmt.Sprintf("/communities/%d/exports/%d?token=%s", communityId, exportId, token)
+	req, _ := http.NewRequest("GET", url, nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) DownloadCommunityExportAPI(communityId, exportId int, token string) *httptest.ResponseRecorder {
+	url := fmt.Sprintf("/communities/%d/exports/%d/download?token=%s", communityId, exportId, token)
+	req, _ := http.NewRequest("GET", url, nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GenerateImportPayload(signer string) *models.ImportCommunityPayload {
+	return &models.ImportCommunityPayload{
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+	}
+}
+
+func (otu *OverflowTestUtils) ImportCommunityAPI(payload *models.ImportCommunityPayload, archive []byte) *httptest.ResponseRecorder {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	payloadJSON, _ := json.Marshal(payload)
+	writer.WriteField("payload", string(payloadJSON))
+	part, _ := writer.CreateFormFile("archive", "community.json")
+	part.Write(archive)
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", "/communities/import", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) ProcessExports() {
+	if err := otu.A.ProcessExports(); err != nil {
+		log.Error().Err(err).Msg("Process exports err.")
+	}
+}
@@ -273,6 +273,30 @@ func (otu *OverflowTestUtils) UpdateProposalEndTime(pId int, endTime time.Time)
 	}
 }
 
+func (otu *OverflowTestUtils) CloseProposals() {
+	if err := otu.A.CloseProposals(); err != nil {
+		log.Error().Err(err).Msg("Close proposals err.")
+	}
+}
+
+func (otu *OverflowTestUtils) ComputeAchievements() {
+	if err := otu.A.ComputeAchievements(); err != nil {
+		log.Error().Err(err).Msg("Compute achievements err.")
+	}
+}
+
+func (otu *OverflowTestUtils) RefreshLeaderboards() {
+	if err := otu.A.RefreshLeaderboards(); err != nil {
+		log.Error().Err(err).Msg("Refresh leaderboards err.")
+	}
+}
+
+func (otu *OverflowTestUtils) ComputeAnalytics() {
+	if err := otu.A.ComputeAnalytics(); err != nil {
+		log.Error().Err(err).Msg("Compute analytics err.")
+	}
+}
+
 func (otu *OverflowTestUtils) AddLists(cId int, count int) []int {
 	if count < 1 {
 		count = 1
@@ -0,0 +1,45 @@
+package test_utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+)
+
+type PaginatedResponseWithFollows struct {
+	Data         []models.Follow `json:"data"`
+	Start        int             `json:"start"`
+	Count        int             `json:"count"`
+	TotalRecords int             `json:"totalRecords"`
+	Next         int             `json:"next"`
+}
+
+func (otu *OverflowTestUtils) GenerateFollowPayload(signer string, communityId *int, followedAddr *string) *models.FollowPayload {
+	return &models.FollowPayload{
+		Community_id:              communityId,
+		Followed_addr:             followedAddr,
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+	}
+}
+
+func (otu *OverflowTestUtils) GetFollowsAPI(addr string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/users/"+addr+"/follows", nil)
+	return otu.ExecuteRequest(req)
+}
+
+// SetFollowAPI follows the community or address in the payload, or unfollows
+// it.
+func (otu *OverflowTestUtils) SetFollowAPI(addr string, payload *models.FollowPayload, follow bool) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+
+	method := "POST"
+	if !follow {
+		method = "DELETE"
+	}
+	req, _ := http.NewRequest(method, "/users/"+addr+"/follows", bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
@@ -1,6 +1,8 @@
 package test_utils
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"strconv"
 )
 
@@ -91,6 +93,12 @@ func (otu *OverflowTestUtils) GenerateWinningVoteAchievement(communityId int, st
 	return proposalId
 }
 
+func (otu *OverflowTestUtils) GetUserAchievementsAPI(addr string, communityId int) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/users/"+addr+"/achievements?communityId="+strconv.Itoa(communityId), nil)
+	response := otu.ExecuteRequest(req)
+	return response
+}
+
 func max(s []int) int {
 	var m int
 	for i, v := range s {
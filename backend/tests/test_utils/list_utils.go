@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
@@ -16,6 +17,14 @@ import (
 // Lists
 /////////
 
+type PaginatedResponseWithListVersions struct {
+	Data         []models.ListVersion `json:"data"`
+	Start        int                  `json:"start"`
+	Count        int                  `json:"count"`
+	TotalRecords int                  `json:"totalRecords"`
+	Next         int                  `json:"next"`
+}
+
 var DefaultListType = "block"
 var DefaultListAddresses = []string{"0x01", "0x02", "0x03"}
 var DefaultListStruct = models.List{
@@ -57,6 +66,53 @@ func (otu *OverflowTestUtils) RemoveAddressesFromListAPI(listId int, payload *mo
 	return otu.ExecuteRequest(req)
 }
 
+func (otu *OverflowTestUtils) UploadListCSVAPI(listId int, payload *models.ListPayload, csv string) *httptest.ResponseRecorder {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	payloadJSON, _ := json.Marshal(payload)
+	writer.WriteField("payload", string(payloadJSON))
+	part, _ := writer.CreateFormFile("file", "list.csv")
+	part.Write([]byte(csv))
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", "/lists/"+strconv.Itoa(listId)+"/csv", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) DownloadListCSVAPI(listId int) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/lists/"+strconv.Itoa(listId)+"/csv", nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetListVersionsAPI(listId int) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/lists/"+strconv.Itoa(listId)+"/versions", nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetListVersionAPI(listId, version int) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/lists/"+strconv.Itoa(listId)+"/versions/"+strconv.Itoa(version), nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) CombineListsAPI(communityId int, payload *models.ListSetOperationPayload) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", "/communities/"+strconv.Itoa(communityId)+"/lists/combine", bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+// SyncCommunityListsAPI copies the lists of the parent community again.
+func (otu *OverflowTestUtils) SyncCommunityListsAPI(communityId int, signer string) *httptest.ResponseRecorder {
+	payload := models.CommunityRoleDeletePayload{
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+	}
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", "/communities/"+strconv.Itoa(communityId)+"/lists/sync", bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
 func (otu *OverflowTestUtils) GenerateBlockListStruct(communityId int) *models.List {
 	list := DefaultListStruct
 	list.Community_id = communityId
@@ -93,3 +149,19 @@ func (otu *OverflowTestUtils) GenerateUpdateListPayload(listId, communityId int,
 
 	return &payload
 }
+
+func (otu *OverflowTestUtils) GenerateListSetOperationPayload(operation string, leftId, rightId int, signer string) *models.ListSetOperationPayload {
+	var timestamp = fmt.Sprint(time.Now().UnixNano() / int64(time.Millisecond))
+
+	payload := models.ListSetOperationPayload{
+		Operation:     operation,
+		Left_list_id:  leftId,
+		Right_list_id: rightId,
+	}
+	payload.Composite_signatures = otu.GenerateCompositeSignatures(signer, timestamp)
+	payload.Timestamp = timestamp
+	account, _ := otu.O.State.Accounts().ByName(fmt.Sprintf("emulator-%s", signer))
+	payload.Signing_addr = fmt.Sprintf("0x%s", account.Address().String())
+
+	return &payload
+}
@@ -0,0 +1,79 @@
+package test_utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+)
+
+func (otu *OverflowTestUtils) GenerateModerationRulePayload(signer, pattern string, weight float64) *models.ModerationRulePayload {
+	return &models.ModerationRulePayload{
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+		Pattern:                   pattern,
+		Weight:                    weight,
+	}
+}
+
+func (otu *OverflowTestUtils) CreateModerationRuleAPI(communityId int, payload *models.ModerationRulePayload) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/communities/%d/moderation/rules", communityId), bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) DeleteModerationRuleAPI(
+	communityId, ruleId int,
+	payload *shared.TimestampSignaturePayload,
+) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	path := fmt.Sprintf("/communities/%d/moderation/rules/%d", communityId, ruleId)
+	req, _ := http.NewRequest("DELETE", path, bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetModerationRulesAPI(communityId int, token string) *httptest.ResponseRecorder {
+	return otu.adminGet(fmt.Sprintf("/communities/%d/moderation/rules", communityId), token)
+}
+
+func (otu *OverflowTestUtils) GetModerationDecisionsAPI(communityId int, action, token string) *httptest.ResponseRecorder {
+	query := url.Values{"action": {action}}
+	return otu.adminGet(fmt.Sprintf("/communities/%d/moderation/decisions?%s", communityId, query.Encode()), token)
+}
+
+func (otu *OverflowTestUtils) GetProposalReviewQueueAPI(communityId int, token string) *httptest.ResponseRecorder {
+	return otu.adminGet(fmt.Sprintf("/communities/%d/proposals/review-queue", communityId), token)
+}
+
+func (otu *OverflowTestUtils) GetProposalAsAPI(communityId, proposalId int, token string) *httptest.ResponseRecorder {
+	return otu.adminGet(fmt.Sprintf("/communities/%d/proposals/%d", communityId, proposalId), token)
+}
+
+func (otu *OverflowTestUtils) FilterProposalsAsAPI(communityId int, filters url.Values, token string) *httptest.ResponseRecorder {
+	return otu.adminGet(fmt.Sprintf("/communities/%d/proposals?%s", communityId, filters.Encode()), token)
+}
+
+// ReviewProposalAPI approves or rejects a proposal held for review.
+func (otu *OverflowTestUtils) ReviewProposalAPI(proposalId int, signer string, approve bool, reason string) *httptest.ResponseRecorder {
+	payload := models.ReviewProposalRequestPayload{
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+	}
+	if reason != "" {
+		payload.Reason = &reason
+	}
+	json, _ := json.Marshal(payload)
+
+	action := "reject"
+	if approve {
+		action = "approve"
+	}
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/proposals/%d/%s", proposalId, action), bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
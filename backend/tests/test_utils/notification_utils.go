@@ -0,0 +1,54 @@
+package test_utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+)
+
+type PaginatedResponseWithNotifications struct {
+	Data         []models.Notification `json:"data"`
+	Start        int                   `json:"start"`
+	Count        int                   `json:"count"`
+	TotalRecords int                   `json:"totalRecords"`
+	Next         int                   `json:"next"`
+}
+
+func (otu *OverflowTestUtils) GetUnreadNotificationsAPI(addr, token string) *httptest.ResponseRecorder {
+	return otu.adminGet("/users/"+addr+"/notifications?unread=true", token)
+}
+
+// MarkNotificationsReadAPI marks the notifications read, or the whole inbox
+// when ids is empty.
+func (otu *OverflowTestUtils) MarkNotificationsReadAPI(addr, signer string, ids []int) *httptest.ResponseRecorder {
+	payload := models.MarkNotificationsReadPayload{
+		Ids:                       ids,
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+	}
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", "/users/"+addr+"/notifications/read", bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetNotificationPreferencesAPI(addr string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/users/"+addr+"/notification-preferences", nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) UpdateNotificationPreferencesAPI(
+	addr, signer string,
+	preferences []models.NotificationPreference,
+) *httptest.ResponseRecorder {
+	payload := models.NotificationPreferencesPayload{
+		Preferences:               preferences,
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+	}
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("PUT", "/users/"+addr+"/notification-preferences", bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
@@ -0,0 +1,84 @@
+package test_utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+)
+
+func (otu *OverflowTestUtils) GetPermissionsAPI() *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/permissions", nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetCommunityRolesAPI(communityId int) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/communities/%d/roles", communityId), nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetCommunityRoleMembersAPI(communityId, roleId int) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/communities/%d/roles/%d/members", communityId, roleId), nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetUserPermissionsAPI(communityId int, addr string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/communities/%d/users/%s/permissions", communityId, addr), nil)
+	return otu.ExecuteRequest(req)
+}
+
+// SaveCommunityRoleAPI creates the role, or updates it when roleId is set.
+func (otu *OverflowTestUtils) SaveCommunityRoleAPI(
+	communityId, roleId int,
+	signer, name string,
+	permissions []string,
+) *httptest.ResponseRecorder {
+	payload := models.CommunityRolePayload{
+		Name:                      name,
+		Permissions:               permissions,
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+	}
+	json, _ := json.Marshal(payload)
+
+	method, path := "POST", fmt.Sprintf("/communities/%d/roles", communityId)
+	if roleId != 0 {
+		method, path = "PUT", fmt.Sprintf("%s/%d", path, roleId)
+	}
+	req, _ := http.NewRequest(method, path, bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) DeleteCommunityRoleAPI(communityId, roleId int, signer string) *httptest.ResponseRecorder {
+	payload := models.CommunityRoleDeletePayload{
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+	}
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/communities/%d/roles/%d", communityId, roleId), bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+// SetCommunityRoleMemberAPI assigns the role to the address, or unassigns it.
+func (otu *OverflowTestUtils) SetCommunityRoleMemberAPI(
+	communityId, roleId int,
+	signer, addr string,
+	assign bool,
+) *httptest.ResponseRecorder {
+	payload := models.CommunityRoleMemberPayload{
+		Addr:                      addr,
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+	}
+	json, _ := json.Marshal(payload)
+
+	method := "POST"
+	if !assign {
+		method = "DELETE"
+	}
+	req, _ := http.NewRequest(method, fmt.Sprintf("/communities/%d/roles/%d/members", communityId, roleId), bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
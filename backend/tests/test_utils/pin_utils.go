@@ -0,0 +1,48 @@
+package test_utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/rs/zerolog/log"
+)
+
+func (otu *OverflowTestUtils) ProcessPins() {
+	if err := otu.A.ProcessPins(); err != nil {
+		log.Error().Err(err).Msg("Process pins err.")
+	}
+}
+
+func (otu *OverflowTestUtils) AddressOf(signer string) string {
+	account, _ := otu.O.State.Accounts().ByName(fmt.Sprintf("emulator-%s", signer))
+	return fmt.Sprintf("0x%s", account.Address().String())
+}
+
+func (otu *OverflowTestUtils) GeneratePinReconcilePayload(signer string, requeue bool) *models.PinReconcilePayload {
+	timestamp := fmt.Sprint(time.Now().UnixNano() / int64(time.Millisecond))
+
+	payload := models.PinReconcilePayload{Requeue: requeue}
+	payload.Composite_signatures = otu.GenerateCompositeSignatures(signer, timestamp)
+	payload.Timestamp = timestamp
+	payload.Signing_addr = otu.AddressOf(signer)
+
+	return &payload
+}
+
+func (otu *OverflowTestUtils) ReconcilePinsAPI(payload *models.PinReconcilePayload) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", "/admin/pins/reconcile", bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) VerifyProposalAPI(proposalId int) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/proposals/"+strconv.Itoa(proposalId)+"/verify", nil)
+	return otu.ExecuteRequest(req)
+}
@@ -0,0 +1,25 @@
+package test_utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+)
+
+func (otu *OverflowTestUtils) GenerateProfilePayload(signer, displayName, avatar string) *models.UpdateProfilePayload {
+	return &models.UpdateProfilePayload{
+		Display_name:              &displayName,
+		Avatar:                    &avatar,
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+	}
+}
+
+func (otu *OverflowTestUtils) UpdateUserProfileAPI(addr string, payload *models.UpdateProfilePayload) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("PUT", "/users/"+addr+"/profile", bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strconv"
 	"time"
 
@@ -18,7 +19,7 @@ import (
 //////////////
 
 var (
-	proposalBody                = "<html>something</html>"
+	proposalBody                = "<p>something</p>"
 	published                   = "published"
 	tokenWeightedDefault        = "token-weighted-default"
 	blockHeight          uint64 = 1
@@ -43,6 +44,19 @@ func (otu *OverflowTestUtils) GetProposalsForCommunityAPI(communityId int) *http
 	return response
 }
 
+type PaginatedResponseWithProposals struct {
+	Data         []models.Proposal `json:"data"`
+	Start        int               `json:"start"`
+	Count        int               `json:"count"`
+	TotalRecords int               `json:"totalRecords"`
+	Next         int               `json:"next"`
+}
+
+func (otu *OverflowTestUtils) FilterProposalsForCommunityAPI(communityId int, filters url.Values) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/communities/"+strconv.Itoa(communityId)+"/proposals?"+filters.Encode(), nil)
+	return otu.ExecuteRequest(req)
+}
+
 func (otu *OverflowTestUtils) GetProposalByIdAPI(communityId int, proposalId int) *httptest.ResponseRecorder {
 	req, _ := http.NewRequest("GET", "/communities/"+strconv.Itoa(communityId)+"/proposals/"+strconv.Itoa(proposalId), nil)
 	response := otu.ExecuteRequest(req)
@@ -60,13 +74,27 @@ func (otu *OverflowTestUtils) CreateProposalAPI(proposal *models.Proposal) *http
 	return otu.ExecuteRequest(req)
 }
 
+// UpdateProposalAPI updates the current version of the proposal.
 func (otu *OverflowTestUtils) UpdateProposalAPI(
 	proposalId int,
 	payload *models.UpdateProposalRequestPayload,
+) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/proposals/"+strconv.Itoa(proposalId), nil)
+	etag := otu.ExecuteRequest(req).Header().Get("ETag")
+	return otu.UpdateProposalWithETagAPI(proposalId, etag, payload)
+}
+
+func (otu *OverflowTestUtils) UpdateProposalWithETagAPI(
+	proposalId int,
+	etag string,
+	payload *models.UpdateProposalRequestPayload,
 ) *httptest.ResponseRecorder {
 	json, _ := json.Marshal(payload)
 	req, _ := http.NewRequest("PUT", "/proposals/"+strconv.Itoa(proposalId), bytes.NewBuffer(json))
 	req.Header.Set("Content-Type", "application/json")
+	if etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
 	return otu.ExecuteRequest(req)
 }
 
@@ -75,6 +103,11 @@ func (otu *OverflowTestUtils) GetProposalResultsAPI(proposalId int) *httptest.Re
 	return otu.ExecuteRequest(req)
 }
 
+func (otu *OverflowTestUtils) GetProposalAnalyticsAPI(proposalId int) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/proposals/"+strconv.Itoa(proposalId)+"/analytics", nil)
+	return otu.ExecuteRequest(req)
+}
+
 func (otu *OverflowTestUtils) GenerateProposalStruct(signer string, communityId int) *models.Proposal {
 	// deep copy
 	proposal := DefaultProposalStruct
@@ -123,6 +156,8 @@ func (otu *OverflowTestUtils) GenerateProposalPayload(signer string, proposal *m
 
 	proposal.Timestamp = timestamp
 	proposal.Composite_signatures = compositeSignatures
+	// like clients, leave the snapshot to the server
+	proposal.Block_height = nil
 
 	return proposal
 }
@@ -184,3 +219,77 @@ func (otu *OverflowTestUtils) GenerateProposalPayload(signer string, proposal *m
 // 	jsonStr, _ := json.Marshal(updateProposalPayload)
 // 	return []byte(jsonStr)
 // }
+
+func (otu *OverflowTestUtils) GetProposalAttachmentsAPI(proposalId int) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/proposals/"+strconv.Itoa(proposalId)+"/attachments", nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetProposalFormattedAPI(proposalId int, format string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/proposals/"+strconv.Itoa(proposalId)+"?format="+format, nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetProposalBatchAPI(ids []int) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(models.ProposalBatchPayload{Ids: ids})
+	req, _ := http.NewRequest("POST", "/proposals/batch", bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GenerateProposalCohostPayload(signer string, communityId int, strategy string) *models.ProposalCohostPayload {
+	return &models.ProposalCohostPayload{
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+		Community_id:              communityId,
+		Strategy:                  strategy,
+	}
+}
+
+func (otu *OverflowTestUtils) AddProposalCohostAPI(proposalId int, payload *models.ProposalCohostPayload) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", "/proposals/"+strconv.Itoa(proposalId)+"/cohosts", bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) RemoveProposalCohostAPI(proposalId int, payload *models.ProposalCohostPayload) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	url := fmt.Sprintf("/proposals/%d/cohosts/%d", proposalId, payload.Community_id)
+	req, _ := http.NewRequest("DELETE", url, bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetCohostedResultsAPI(proposalId int) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/proposals/"+strconv.Itoa(proposalId)+"/results/communities", nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetProposalExecutionAPI(proposalId int) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/proposals/"+strconv.Itoa(proposalId)+"/execution", nil)
+	return otu.ExecuteRequest(req)
+}
+
+// GetProposalFeedAPI reads a proposal feed, such as /proposals.rss.
+func (otu *OverflowTestUtils) GetProposalFeedAPI(path string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", path, nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetProposalEmbedAPI(proposalId int) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/embed/proposals/"+strconv.Itoa(proposalId), nil)
+	return otu.ExecuteRequest(req)
+}
+
+// GetOEmbedAPI asks for the oEmbed of a link, query adds to the url.
+func (otu *OverflowTestUtils) GetOEmbedAPI(link, query string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/oembed?url="+url.QueryEscape(link)+"&"+query, nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetDuplicateProposalsAPI(communityId int, name, body string) *httptest.ResponseRecorder {
+	query := url.Values{"name": {name}, "body": {body}}
+	path := fmt.Sprintf("/communities/%d/proposals/duplicates?%s", communityId, query.Encode())
+	req, _ := http.NewRequest("GET", path, nil)
+	return otu.ExecuteRequest(req)
+}
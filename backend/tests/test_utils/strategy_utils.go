@@ -1,9 +1,14 @@
 package test_utils
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"strconv"
+	"time"
 
 	"github.com/DapperCollectives/CAST/backend/main/models"
 	"github.com/DapperCollectives/CAST/backend/main/shared"
@@ -246,3 +251,27 @@ func (otu *OverflowTestUtils) TransferNFT(signer, recipient string, id uint64) {
 			UInt64(id)).
 		RunPrintEventsFull()
 }
+
+func (otu *OverflowTestUtils) DryRunTallyAPI(communityId int, payload *models.DryRunTallyPayload) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", "/communities/"+strconv.Itoa(communityId)+"/strategies/dry-run", bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GenerateDryRunTallyPayload(strategy string, addresses []string, signer string) *models.DryRunTallyPayload {
+	var timestamp = fmt.Sprint(time.Now().UnixNano() / int64(time.Millisecond))
+	blockHeight := uint64(1)
+
+	payload := models.DryRunTallyPayload{
+		Strategy:     models.Strategy{Name: &strategy},
+		Addresses:    addresses,
+		Block_height: &blockHeight,
+	}
+	payload.Composite_signatures = otu.GenerateCompositeSignatures(signer, timestamp)
+	payload.Timestamp = timestamp
+	account, _ := otu.O.State.Accounts().ByName(fmt.Sprintf("emulator-%s", signer))
+	payload.Signing_addr = fmt.Sprintf("0x%s", account.Address().String())
+
+	return &payload
+}
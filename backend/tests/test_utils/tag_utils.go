@@ -0,0 +1,40 @@
+package test_utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+)
+
+func (otu *OverflowTestUtils) GenerateCommunityTagPayload(signer, name string) *models.CommunityTagPayload {
+	return &models.CommunityTagPayload{
+		Name:                      name,
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+	}
+}
+
+func (otu *OverflowTestUtils) CreateCommunityTagAPI(communityId int, payload *models.CommunityTagPayload) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/communities/%d/tags", communityId), bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) DeleteCommunityTagAPI(
+	communityId, tagId int,
+	payload *models.CommunityTagPayload,
+) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/communities/%d/tags/%d", communityId, tagId), bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetCommunityTagsAPI(communityId int) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/communities/%d/tags", communityId), nil)
+	return otu.ExecuteRequest(req)
+}
@@ -0,0 +1,34 @@
+package test_utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+)
+
+func (otu *OverflowTestUtils) GenerateTranslationPayload(signer string, name, body *string) *models.TranslationPayload {
+	return &models.TranslationPayload{
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+		Name:                      name,
+		Body:                      body,
+	}
+}
+
+// TranslationAPI sets the translation at path, such as
+// "/communities/1/translations/es", with PUT or deletes it with DELETE.
+func (otu *OverflowTestUtils) TranslationAPI(method, path string, payload *models.TranslationPayload) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest(method, path, bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+// GetLocalizedAPI reads a community or proposal in the given languages.
+func (otu *OverflowTestUtils) GetLocalizedAPI(path, acceptLanguage string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", path, nil)
+	req.Header.Set("Accept-Language", acceptLanguage)
+	return otu.ExecuteRequest(req)
+}
@@ -0,0 +1,24 @@
+package test_utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/rs/zerolog/log"
+)
+
+func (otu *OverflowTestUtils) ComputeTrending() {
+	if err := otu.A.ComputeTrending(); err != nil {
+		log.Error().Err(err).Msg("Compute trending err.")
+	}
+}
+
+func (otu *OverflowTestUtils) GetTrendingCommunitiesAPI() *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/communities?sort=trending", nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetTrendingProposalsAPI() *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/proposals/trending", nil)
+	return otu.ExecuteRequest(req)
+}
@@ -0,0 +1,79 @@
+package test_utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strconv"
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+)
+
+func (otu *OverflowTestUtils) UploadFileAPI(name, contentType string, content []byte) *httptest.ResponseRecorder {
+	return otu.UploadFileWithPayloadAPI(name, contentType, content, nil)
+}
+
+func (otu *OverflowTestUtils) UploadFileWithPayloadAPI(
+	name, contentType string,
+	content []byte,
+	payload *models.UploadPayload,
+) *httptest.ResponseRecorder {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	if payload != nil {
+		payloadJSON, _ := json.Marshal(payload)
+		writer.WriteField("payload", string(payloadJSON))
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, name))
+	header.Set("Content-Type", contentType)
+	part, _ := writer.CreatePart(header)
+	part.Write(content)
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", "/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetFileAPI(key string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/files/"+key, nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetFileURL(url string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", url, nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GenerateTimestampSignaturePayload(signer string) shared.TimestampSignaturePayload {
+	timestamp := fmt.Sprint(time.Now().UnixNano() / int64(time.Millisecond))
+
+	var payload shared.TimestampSignaturePayload
+	payload.Composite_signatures = otu.GenerateCompositeSignatures(signer, timestamp)
+	payload.Timestamp = timestamp
+	payload.Signing_addr = otu.AddressOf(signer)
+
+	return payload
+}
+
+func (otu *OverflowTestUtils) GeneratePrivateUploadPayload(signer string, communityId int) *models.UploadPayload {
+	return &models.UploadPayload{
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+		Community_id:              &communityId,
+		Private:                   true,
+	}
+}
+
+func (otu *OverflowTestUtils) SignUploadURLAPI(id int, payload shared.TimestampSignaturePayload) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", "/uploads/"+strconv.Itoa(id)+"/signed-url", bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
@@ -0,0 +1,29 @@
+package test_utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+)
+
+func (otu *OverflowTestUtils) GenerateCommunityQuotasPayload(signer string, quotas map[string]*int64) *models.CommunityQuotasPayload {
+	return &models.CommunityQuotasPayload{
+		TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(signer),
+		Quotas:                    quotas,
+	}
+}
+
+func (otu *OverflowTestUtils) SetCommunityQuotasAPI(communityId int, payload *models.CommunityQuotasPayload) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/admin/communities/%d/quotas", communityId), bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetCommunityUsageAPI(communityId int, token string) *httptest.ResponseRecorder {
+	return otu.adminGet(fmt.Sprintf("/communities/%d/usage", communityId), token)
+}
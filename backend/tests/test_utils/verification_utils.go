@@ -0,0 +1,49 @@
+package test_utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+)
+
+// StubVerifier vouches for the external ids it was given.
+type StubVerifier map[string]bool
+
+func (v StubVerifier) Verify(externalId string) (shared.IdentityCheck, error) {
+	return shared.IdentityCheck{Verified: v[externalId]}, nil
+}
+
+func (otu *OverflowTestUtils) GetAddressVerificationsAPI(addr string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/users/"+addr+"/verifications", nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) GetUserProfileAPI(addr string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/users/"+addr+"/profile", nil)
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) VerifyAddressAPI(addr string, payload *models.VerifyAddressPayload) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", "/users/"+addr+"/verifications", bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) AttestAddressAPI(addr string, payload *models.AttestAddressPayload) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", "/admin/verifications/"+addr, bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
+
+func (otu *OverflowTestUtils) RevokeVerificationAPI(addr, provider string, payload *shared.TimestampSignaturePayload) *httptest.ResponseRecorder {
+	json, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("DELETE", "/admin/verifications/"+addr+"/"+provider, bytes.NewBuffer(json))
+	req.Header.Set("Content-Type", "application/json")
+	return otu.ExecuteRequest(req)
+}
@@ -2,6 +2,7 @@ package test_utils
 
 import (
 	"bytes"
+	"crypto/ed25519"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -76,3 +77,48 @@ func (otu *OverflowTestUtils) GenerateValidVotePayload(accountName string, propo
 
 	return &vote
 }
+
+// GenerateValidSplitVotePayload signs the JSON of the allocation in place
+// of a choice.
+func (otu *OverflowTestUtils) GenerateValidSplitVotePayload(accountName string, proposalId int, allocation map[string]float64) *models.Vote {
+	allocationJSON, _ := json.Marshal(allocation)
+	vote := otu.GenerateValidVotePayload(accountName, proposalId, string(allocationJSON))
+	vote.Choice = ""
+	vote.Allocation = allocation
+	return vote
+}
+
+func (otu *OverflowTestUtils) GetVoteReceiptForProposalByAccountNameAPI(proposalId int, accountName string) *httptest.ResponseRecorder {
+	account, _ := otu.O.State.Accounts().ByName(fmt.Sprintf("emulator-%s", accountName))
+	addr := fmt.Sprintf("0x%s", account.Address().String())
+	url := fmt.Sprintf("/proposals/%s/votes/%s/receipt", strconv.Itoa(proposalId), addr)
+	req, _ := http.NewRequest("GET", url, nil)
+	return otu.ExecuteRequest(req)
+}
+
+// ReceiptPublicKey fetches the key receipts are published to be signed with.
+func (otu *OverflowTestUtils) ReceiptPublicKey() ed25519.PublicKey {
+	req, _ := http.NewRequest("GET", "/receipts/public-key", nil)
+	var body struct {
+		PublicKey string `json:"publicKey"`
+	}
+	json.Unmarshal(otu.ExecuteRequest(req).Body.Bytes(), &body)
+	key, _ := hex.DecodeString(body.PublicKey)
+	return key
+}
+
+func (otu *OverflowTestUtils) VerifyVoteAPI(proposalId int, accountName string) *httptest.ResponseRecorder {
+	addr := otu.AddressOf(accountName)
+	req, _ := http.NewRequest("GET", "/proposals/"+strconv.Itoa(proposalId)+"/votes/"+addr+"/verify", nil)
+	return otu.ExecuteRequest(req)
+}
+
+// CastVoteOnchainAs casts the account's vote with the CASTVoting contract.
+func (otu *OverflowTestUtils) CastVoteOnchainAs(accountName string, proposalId int, choice string) {
+	otu.O.TransactionFromFile("cast_vote_onchain").
+		SignProposeAndPayAs(accountName).
+		Args(otu.O.Arguments().
+			UInt64(uint64(proposalId)).
+			String(choice)).
+		RunPrintEventsFull()
+}
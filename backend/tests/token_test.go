@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommunityTokens(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("community_tokens")
+	clearTable("proposals")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	var token models.CommunityToken
+
+	t.Run("Should only let admins register tokens", func(t *testing.T) {
+		payload := otu.GenerateCommunityTokenPayload("user2", "flowTokenBalance")
+		response := otu.RegisterCommunityTokenAPI(communityId, payload)
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("Should reject tokens whose paths don't resolve", func(t *testing.T) {
+		payload := otu.GenerateCommunityTokenPayload("user1", "notABalance")
+		response := otu.RegisterCommunityTokenAPI(communityId, payload)
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("Should reject paths that are not identifiers", func(t *testing.T) {
+		payload := otu.GenerateCommunityTokenPayload("user1", "flowTokenBalance)")
+		response := otu.RegisterCommunityTokenAPI(communityId, payload)
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("Should register a token", func(t *testing.T) {
+		payload := otu.GenerateCommunityTokenPayload("user1", "flowTokenBalance")
+		response := otu.RegisterCommunityTokenAPI(communityId, payload)
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+		json.Unmarshal(response.Body.Bytes(), &token)
+		assert.Equal(t, 8, token.Decimals)
+
+		response = otu.GetCommunityTokensAPI(communityId)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var tokens []models.CommunityToken
+		json.Unmarshal(response.Body.Bytes(), &tokens)
+		assert.Len(t, tokens, 1)
+	})
+
+	t.Run("Should reject strategies using unregistered tokens", func(t *testing.T) {
+		update := otu.GenerateCommunityStruct("user1", "dao")
+		strategy := (*update.Strategies)[0]
+		name, addr := "ExampleToken", "0xf8d6e0586b0a20c7"
+		strategy.Contract.Name = &name
+		strategy.Addr = &addr
+		update.Strategies = &[]models.Strategy{strategy}
+
+		response := otu.UpdateCommunityAPI(communityId, otu.GenerateCommunityPayload("user1", update))
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("Should list token metadata", func(t *testing.T) {
+		response := otu.GetTokensAPI(communityId)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var tokens []shared.TokenMetadata
+		json.Unmarshal(response.Body.Bytes(), &tokens)
+		assert.Len(t, tokens, 1)
+		assert.Equal(t, "FLOW", tokens[0].Symbol)
+		assert.Equal(t, 8, tokens[0].Decimals)
+		assert.Equal(t, shared.TokenSourceList, tokens[0].Source)
+	})
+
+	t.Run("Should describe the tokens of a community's strategies", func(t *testing.T) {
+		response := otu.GetCommunityAPI(communityId)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var c models.Community
+		json.Unmarshal(response.Body.Bytes(), &c)
+		assert.Len(t, c.Tokens, 1)
+		assert.Equal(t, "FlowToken", c.Tokens[0].Contract_name)
+		assert.Equal(t, "FLOW", c.Tokens[0].Symbol)
+	})
+
+	t.Run("Should remove a token", func(t *testing.T) {
+		payload := otu.GenerateCommunityTokenPayload("user1", "flowTokenBalance")
+		response := otu.DeleteCommunityTokenAPI(communityId, token.ID, payload)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		response = otu.GetCommunityTokensAPI(communityId)
+		var tokens []models.CommunityToken
+		json.Unmarshal(response.Body.Bytes(), &tokens)
+		assert.Len(t, tokens, 0)
+	})
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslations(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	proposalId := otu.AddProposals(communityId, 1)[0]
+	communityPath := fmt.Sprintf("/communities/%d", communityId)
+	proposalPath := fmt.Sprintf("/proposals/%d", proposalId)
+
+	name, body := "Comunidad", "Una comunidad de prueba"
+	title := "Propuesta"
+
+	t.Run("Community admins should translate their community", func(t *testing.T) {
+		response := otu.TranslationAPI("PUT", communityPath+"/translations/es", otu.GenerateTranslationPayload("user2", &name, &body))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.TranslationAPI("PUT", communityPath+"/translations/1", otu.GenerateTranslationPayload("user1", &name, &body))
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+
+		response = otu.TranslationAPI("PUT", communityPath+"/translations/es", otu.GenerateTranslationPayload("user1", &name, &body))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Reads should return the best matching translation", func(t *testing.T) {
+		response := otu.GetLocalizedAPI(communityPath, "es-MX, en;q=0.5")
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, "es", response.Header().Get("Content-Language"))
+		var c models.Community
+		json.Unmarshal(response.Body.Bytes(), &c)
+		assert.Equal(t, name, c.Name)
+		assert.Equal(t, body, *c.Body)
+
+		response = otu.GetLocalizedAPI(communityPath, "fr")
+		assert.Equal(t, "", response.Header().Get("Content-Language"))
+		c = models.Community{}
+		json.Unmarshal(response.Body.Bytes(), &c)
+		assert.NotEqual(t, name, c.Name)
+	})
+
+	t.Run("Moderators should translate proposals, keeping untranslated fields", func(t *testing.T) {
+		response := otu.TranslationAPI("PUT", proposalPath+"/translations/es", otu.GenerateTranslationPayload("user2", &title, nil))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.TranslationAPI("PUT", proposalPath+"/translations/es", otu.GenerateTranslationPayload("user1", &title, nil))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		original := models.Proposal{ID: proposalId}
+		original.GetProposalById(A.DB)
+
+		response = otu.GetLocalizedAPI(proposalPath, "es")
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var p models.Proposal
+		json.Unmarshal(response.Body.Bytes(), &p)
+		assert.Equal(t, title, p.Name)
+		assert.Equal(t, *original.Body, *p.Body)
+	})
+
+	t.Run("Translations should be listed and deleted", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", communityPath+"/translations", nil)
+		response := otu.ExecuteRequest(req)
+		var translations []models.Translation
+		json.Unmarshal(response.Body.Bytes(), &translations)
+		assert.Equal(t, 1, len(translations))
+		assert.Equal(t, "es", translations[0].Locale)
+
+		response = otu.TranslationAPI("DELETE", communityPath+"/translations/es", otu.GenerateTranslationPayload("user1", nil, nil))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		response = otu.GetLocalizedAPI(communityPath, "es")
+		assert.Equal(t, "", response.Header().Get("Content-Language"))
+	})
+}
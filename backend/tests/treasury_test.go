@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTreasuries(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("community_treasuries")
+	clearTable("proposals")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	var treasury models.Treasury
+
+	t.Run("Should only let admins register treasuries", func(t *testing.T) {
+		payload := otu.GenerateTreasuryPayload("user2", "Main", otu.ResolveUser(1))
+		response := otu.CreateTreasuryAPI(communityId, payload)
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("Should register a treasury", func(t *testing.T) {
+		payload := otu.GenerateTreasuryPayload("user1", "Main", otu.ResolveUser(1))
+		response := otu.CreateTreasuryAPI(communityId, payload)
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+		json.Unmarshal(response.Body.Bytes(), &treasury)
+
+		response = otu.GetTreasuriesAPI(communityId)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var treasuries []models.Treasury
+		json.Unmarshal(response.Body.Bytes(), &treasuries)
+		assert.Len(t, treasuries, 1)
+	})
+
+	t.Run("Should snapshot treasuries into a proposal", func(t *testing.T) {
+		proposalStruct := otu.GenerateProposalStruct("user1", communityId)
+		proposalStruct.Snapshot_treasury = true
+		payload := otu.GenerateProposalPayload("user1", proposalStruct)
+
+		response := otu.CreateProposalAPI(payload)
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+
+		var p models.Proposal
+		json.Unmarshal(response.Body.Bytes(), &p)
+
+		response = otu.GetProposalByIdAPI(communityId, p.ID)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var fetched models.Proposal
+		json.Unmarshal(response.Body.Bytes(), &fetched)
+		assert.Len(t, fetched.Treasury_snapshot, 1)
+		assert.Equal(t, "Main", fetched.Treasury_snapshot[0].Name)
+		assert.Equal(t, *p.Block_height, fetched.Treasury_snapshot[0].Block_height)
+	})
+
+	t.Run("Should remove a treasury", func(t *testing.T) {
+		payload := otu.GenerateTreasuryPayload("user1", "Main", otu.ResolveUser(1))
+		response := otu.DeleteTreasuryAPI(communityId, treasury.ID, payload)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		proposalStruct := otu.GenerateProposalStruct("user1", communityId)
+		proposalStruct.Snapshot_treasury = true
+		response = otu.CreateProposalAPI(otu.GenerateProposalPayload("user1", proposalStruct))
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+}
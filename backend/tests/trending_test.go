@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/tests/test_utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrending(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	clearTable("votes")
+
+	communityIds := otu.AddCommunities(3, "dao")
+	otu.AddProposals(communityIds[0], 1)
+	proposalId := otu.AddProposals(communityIds[1], 1)[0]
+	otu.AddVotes(proposalId, 5)
+
+	otu.ComputeTrending()
+
+	t.Run("Communities should sort by recent activity", func(t *testing.T) {
+		response := otu.GetTrendingCommunitiesAPI()
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var p test_utils.PaginatedResponseWithCommunity
+		json.Unmarshal(response.Body.Bytes(), &p)
+		assert.Equal(t, 3, len(p.Data))
+		assert.Equal(t, communityIds[1], p.Data[0].ID)
+		assert.Equal(t, communityIds[0], p.Data[1].ID)
+		assert.Greater(t, *p.Data[0].Trending_score, *p.Data[1].Trending_score)
+		assert.Nil(t, p.Data[2].Trending_score)
+
+		req, _ := http.NewRequest("GET", "/communities?sort=unknown", nil)
+		response = otu.ExecuteRequest(req)
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("Only proposals with recent votes should trend", func(t *testing.T) {
+		response := otu.GetTrendingProposalsAPI()
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var body struct {
+			Data         []models.Proposal `json:"data"`
+			TotalRecords int               `json:"totalRecords"`
+		}
+		json.Unmarshal(response.Body.Bytes(), &body)
+		assert.Equal(t, 1, body.TotalRecords)
+		assert.Equal(t, proposalId, body.Data[0].ID)
+		// votes cast just now count fully
+		assert.InDelta(t, 5.0, *body.Data[0].Trending_score, 0.01)
+	})
+}
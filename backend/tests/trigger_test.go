@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPollingTriggers(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	clearTable("proposal_results")
+	clearTable("votes")
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+
+	var ids []int
+	for i := 0; i < 2; i++ {
+		proposal := otu.GenerateProposalStruct("user1", communityId)
+		response := otu.CreateProposalAPI(otu.GenerateProposalPayload("user1", proposal))
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+		var p models.Proposal
+		json.Unmarshal(response.Body.Bytes(), &p)
+		ids = append(ids, p.ID)
+	}
+
+	poll := func(trigger, query string) []models.TriggerItem {
+		response := otu.GetCommunityTriggerAPI(communityId, trigger, query)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var items []models.TriggerItem
+		json.Unmarshal(response.Body.Bytes(), &items)
+		return items
+	}
+
+	t.Run("Should list new proposals newest first", func(t *testing.T) {
+		items := poll(models.TriggerNewProposal, "")
+		if assert.Equal(t, 2, len(items)) {
+			assert.Equal(t, fmt.Sprintf("proposal-%d-created", ids[1]), items[0].ID)
+			assert.Equal(t, fmt.Sprintf("proposal-%d-created", ids[0]), items[1].ID)
+			assert.Greater(t, items[0].Cursor, items[1].Cursor)
+		}
+
+		assert.Equal(t, 1, len(poll(models.TriggerNewProposal, fmt.Sprintf("since=%d", items[1].Cursor))))
+		assert.Equal(t, 0, len(poll(models.TriggerNewProposal, fmt.Sprintf("since=%d", items[0].Cursor))))
+		assert.Equal(t, 0, len(poll(models.TriggerNewProposal, "author="+otu.AddressOf("user2"))))
+	})
+
+	t.Run("Should list closed proposals with their outcome", func(t *testing.T) {
+		assert.Equal(t, 0, len(poll(models.TriggerProposalClosed, "")))
+
+		response := otu.CreateVoteAPI(ids[0], otu.GenerateValidVotePayload("user1", ids[0], "a"))
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+		_, err := A.DB.Conn.Exec(A.DB.Context,
+			`UPDATE proposals SET end_time = (now() at time zone 'utc') - interval '1 minute' WHERE id = $1`, ids[0])
+		assert.NoError(t, err)
+		otu.CloseProposals()
+
+		items := poll(models.TriggerProposalClosed, "")
+		if assert.Equal(t, 1, len(items)) {
+			assert.Equal(t, fmt.Sprintf("proposal-%d-closed", ids[0]), items[0].ID)
+			if assert.NotNil(t, items[0].Winning_choice) {
+				assert.Equal(t, "a", *items[0].Winning_choice)
+			}
+		}
+	})
+
+	t.Run("Should refuse unknown triggers", func(t *testing.T) {
+		response := otu.GetCommunityTriggerAPI(communityId, "new-vote", "")
+		CheckResponseCode(t, http.StatusNotFound, response.Code)
+	})
+}
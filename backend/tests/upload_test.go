@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpload(t *testing.T) {
+	clearTable("uploads")
+
+	storage, err := shared.NewLocalStorage(t.TempDir(), "https://cast.test/files/", A.TokenSigner)
+	assert.NoError(t, err)
+	defaultStorage := A.Storage
+	A.Storage = storage
+	defer func() { A.Storage = defaultStorage }()
+
+	content := []byte("\x89PNG\r\n\x1a\nnot really a png")
+
+	var upload models.Upload
+	t.Run("Uploads should be stored under their content key", func(t *testing.T) {
+		response := otu.UploadFileAPI("logo.png", "image/png", content)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		json.Unmarshal(response.Body.Bytes(), &upload)
+
+		assert.Equal(t, shared.ContentKey(content, "image/png"), upload.Key)
+		assert.Equal(t, "https://cast.test/files/"+upload.Key, upload.Url)
+		assert.Equal(t, shared.StorageLocal, upload.Driver)
+		assert.Equal(t, int64(len(content)), upload.Size)
+	})
+
+	t.Run("Repeated uploads should be deduplicated", func(t *testing.T) {
+		response := otu.UploadFileAPI("copy-of-logo.png", "image/png", content)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var again models.Upload
+		json.Unmarshal(response.Body.Bytes(), &again)
+		assert.Equal(t, upload.ID, again.ID)
+		assert.Equal(t, upload.Url, again.Url)
+	})
+
+	t.Run("Stored files should be served by key", func(t *testing.T) {
+		response := otu.GetFileAPI(upload.Key)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, "image/png", response.Header().Get("Content-Type"))
+		assert.Equal(t, content, response.Body.Bytes())
+
+		response = otu.GetFileAPI("missing.png")
+		CheckResponseCode(t, http.StatusNotFound, response.Code)
+	})
+
+	t.Run("Disallowed file types should be rejected", func(t *testing.T) {
+		response := otu.UploadFileAPI("notes.txt", "text/plain", []byte("hello"))
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+	})
+}
+
+func TestUploadScanning(t *testing.T) {
+	clearTable("uploads")
+
+	storage, err := shared.NewLocalStorage(t.TempDir(), "https://cast.test/files/", A.TokenSigner)
+	assert.NoError(t, err)
+	defaultStorage := A.Storage
+	A.Storage = storage
+	defer func() { A.Storage = defaultStorage }()
+
+	// stands in for an external scanning API that flags the EICAR test string
+	scans := 0
+	scanAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scans++
+		content, _ := io.ReadAll(r.Body)
+		result := shared.ScanResult{Clean: true}
+		if bytes.Contains(content, []byte("EICAR-STANDARD-ANTIVIRUS-TEST-FILE")) {
+			result = shared.ScanResult{Clean: false, Signature: "Eicar-Test-Signature"}
+		}
+		json.NewEncoder(w).Encode(result)
+	}))
+	defer scanAPI.Close()
+
+	A.Scanner = shared.NewHttpScanner(scanAPI.URL, "")
+	defer func() { A.Scanner = nil }()
+
+	t.Run("Clean uploads should be stored", func(t *testing.T) {
+		response := otu.UploadFileAPI("logo.png", "image/png", []byte("\x89PNG\r\n\x1a\nclean"))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var upload models.Upload
+		json.Unmarshal(response.Body.Bytes(), &upload)
+		assert.Equal(t, models.UploadClean, upload.Status)
+		assert.NotNil(t, upload.Scanned_at)
+	})
+
+	infected := []byte(`X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`)
+
+	t.Run("Infected uploads should be quarantined", func(t *testing.T) {
+		response := otu.UploadFileAPI("payload.png", "image/png", infected)
+		CheckResponseCode(t, http.StatusUnprocessableEntity, response.Code)
+
+		upload, err := models.GetUploadByHash(A.DB, shared.StorageLocal, shared.ContentKey(infected, "image/png")[:64], false, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, models.UploadQuarantined, upload.Status)
+		assert.Equal(t, "Eicar-Test-Signature", *upload.Scan_result)
+
+		_, err = storage.Get(upload.Key)
+		assert.ErrorIs(t, err, shared.ErrObjectNotFound)
+
+		response = otu.GetFileAPI(upload.Key)
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("Quarantined content should be rejected without a rescan", func(t *testing.T) {
+		before := scans
+		response := otu.UploadFileAPI("again.png", "image/png", infected)
+		CheckResponseCode(t, http.StatusUnprocessableEntity, response.Code)
+		assert.Equal(t, before, scans)
+	})
+
+	t.Run("Uploads should fail when the scanner is unavailable", func(t *testing.T) {
+		A.Scanner = shared.NewHttpScanner("http://127.0.0.1:1", "")
+		response := otu.UploadFileAPI("other.png", "image/png", []byte("\x89PNG\r\n\x1a\nother"))
+		CheckResponseCode(t, http.StatusServiceUnavailable, response.Code)
+	})
+}
+
+func TestPrivateUpload(t *testing.T) {
+	clearTable("uploads")
+	clearTable("communities")
+	clearTable("community_users")
+
+	storage, err := shared.NewLocalStorage(t.TempDir(), "/files/", A.TokenSigner)
+	assert.NoError(t, err)
+	defaultStorage := A.Storage
+	A.Storage = storage
+	defer func() { A.Storage = defaultStorage }()
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	content := []byte("\x89PNG\r\n\x1a\nbudget")
+
+	var upload models.Upload
+	t.Run("Authors should be able to upload private files", func(t *testing.T) {
+		response := otu.UploadFileWithPayloadAPI("budget.png", "image/png", content, otu.GeneratePrivateUploadPayload("user1", communityId))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		json.Unmarshal(response.Body.Bytes(), &upload)
+
+		assert.True(t, upload.Private)
+		assert.Equal(t, communityId, *upload.Community_id)
+		assert.Equal(t, shared.PrivateKeyPrefix+shared.ContentKey(content, "image/png"), upload.Key)
+	})
+
+	t.Run("Non authors should not upload private files", func(t *testing.T) {
+		response := otu.UploadFileWithPayloadAPI("budget.png", "image/png", content, otu.GeneratePrivateUploadPayload("user2", communityId))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("Private files should not be served without a signed URL", func(t *testing.T) {
+		response := otu.GetFileAPI(upload.Key)
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		response = otu.GetFileURL(upload.Url + "?token=forged")
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("Members should get a signed URL that serves the file", func(t *testing.T) {
+		response := otu.SignUploadURLAPI(upload.ID, otu.GenerateTimestampSignaturePayload("user1"))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var signed models.SignedURL
+		json.Unmarshal(response.Body.Bytes(), &signed)
+		assert.True(t, signed.Expires_at.After(time.Now()))
+
+		response = otu.GetFileURL(signed.Url)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		assert.Equal(t, content, response.Body.Bytes())
+	})
+
+	t.Run("Non members should not get a signed URL", func(t *testing.T) {
+		response := otu.SignUploadURLAPI(upload.ID, otu.GenerateTimestampSignaturePayload("user2"))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+	})
+}
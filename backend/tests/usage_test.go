@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommunityUsage(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	clearTable("votes")
+	clearTable("notifications")
+	clearTable("ipfs_pins")
+	clearTable("community_usage")
+	clearTable("community_quotas")
+
+	A.AdminAllowlist.Set([]string{otu.AddressOf("user1")})
+	defer A.AdminAllowlist.Set(nil)
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	proposalId := otu.AddActiveProposals(communityId, 1)[0]
+	quota := func(q int64) *int64 { return &q }
+	getUsage := func() models.CommunityUsage {
+		response := otu.GetCommunityUsageAPI(communityId, otu.Login("user1"))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var usage models.CommunityUsage
+		json.Unmarshal(response.Body.Bytes(), &usage)
+		return usage
+	}
+	metric := func(usage models.CommunityUsage, name string) models.UsageMetric {
+		for _, m := range usage.Metrics {
+			if m.Metric == name {
+				return m
+			}
+		}
+		return models.UsageMetric{}
+	}
+
+	t.Run("Only platform admins should set quotas", func(t *testing.T) {
+		quotas := map[string]*int64{models.UsageVotes: quota(2)}
+		response := otu.SetCommunityQuotasAPI(communityId, otu.GenerateCommunityQuotasPayload("user2", quotas))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		quotas = map[string]*int64{"gas": quota(2)}
+		response = otu.SetCommunityQuotasAPI(communityId, otu.GenerateCommunityQuotasPayload("user1", quotas))
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+
+		quotas = map[string]*int64{models.UsageVotes: quota(2), models.UsagePins: quota(1)}
+		response = otu.SetCommunityQuotasAPI(communityId, otu.GenerateCommunityQuotasPayload("user1", quotas))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Should refuse votes past the quota until the month is over", func(t *testing.T) {
+		for i := 1; i <= 2; i++ {
+			signer := fmt.Sprintf("user%d", i)
+			response := otu.CreateVoteAPI(proposalId, otu.GenerateValidVotePayload(signer, proposalId, "a"))
+			CheckResponseCode(t, http.StatusCreated, response.Code)
+		}
+
+		response := otu.CreateVoteAPI(proposalId, otu.GenerateValidVotePayload("user3", proposalId, "a"))
+		CheckResponseCode(t, http.StatusTooManyRequests, response.Code)
+		var e struct {
+			ErrorCode string    `json:"errorCode"`
+			Retry_at  time.Time `json:"retryAt"`
+		}
+		json.Unmarshal(response.Body.Bytes(), &e)
+		assert.Equal(t, "ERR_1040", e.ErrorCode)
+		assert.True(t, e.Retry_at.Equal(models.UsagePeriod(time.Now()).AddDate(0, 1, 0)))
+	})
+
+	t.Run("Should show admins their usage and warn them once", func(t *testing.T) {
+		response := otu.GetCommunityUsageAPI(communityId, otu.Login("user2"))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+
+		votes := metric(getUsage(), models.UsageVotes)
+		assert.Equal(t, int64(2), votes.Used)
+		if assert.NotNil(t, votes.Quota) {
+			assert.Equal(t, int64(2), *votes.Quota)
+		}
+		assert.Equal(t, models.UsageBlocked, votes.Status)
+		assert.Nil(t, metric(getUsage(), models.UsageStorage).Quota)
+
+		var warnings int
+		err := A.DB.Conn.QueryRow(A.DB.Context,
+			`SELECT COUNT(*) FROM notifications WHERE notification_type = $1 AND addr = $2`,
+			models.NotificationUsageWarning, otu.AddressOf("user1")).Scan(&warnings)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, warnings)
+	})
+
+	t.Run("Should defer pins past the quota", func(t *testing.T) {
+		assert.NoError(t, models.QueuePin(A.DB, models.PinCommunity, communityId))
+		otu.ProcessPins()
+		assert.Equal(t, int64(1), metric(getUsage(), models.UsagePins).Used)
+
+		assert.NoError(t, models.QueuePin(A.DB, models.PinCommunity, communityId))
+		otu.ProcessPins()
+		pin, err := models.GetPin(A.DB, models.PinCommunity, communityId)
+		assert.NoError(t, err)
+		assert.Equal(t, models.PinPending, pin.Status)
+		assert.Equal(t, 0, pin.Attempts)
+		assert.True(t, pin.Next_attempt_at.After(time.Now()))
+	})
+
+	t.Run("Should go back to the platform's quota", func(t *testing.T) {
+		quotas := map[string]*int64{models.UsageVotes: nil}
+		response := otu.SetCommunityQuotasAPI(communityId, otu.GenerateCommunityQuotasPayload("user1", quotas))
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		response = otu.CreateVoteAPI(proposalId, otu.GenerateValidVotePayload("user3", proposalId, "a"))
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+		assert.Equal(t, int64(3), metric(getUsage(), models.UsageVotes).Used)
+	})
+}
+
+func TestUsageMetric(t *testing.T) {
+	assert.Equal(t, models.UsageOk, models.NewUsageMetric(models.UsageVotes, 100, 0, 0.8).Status)
+	assert.Equal(t, models.UsageOk, models.NewUsageMetric(models.UsageVotes, 7, 10, 0.8).Status)
+	assert.Equal(t, models.UsageWarning, models.NewUsageMetric(models.UsageVotes, 8, 10, 0.8).Status)
+	assert.Equal(t, models.UsageBlocked, models.NewUsageMetric(models.UsageVotes, 10, 10, 0.8).Status)
+}
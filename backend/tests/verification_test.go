@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/DapperCollectives/CAST/backend/main/models"
+	"github.com/DapperCollectives/CAST/backend/main/shared"
+	"github.com/DapperCollectives/CAST/backend/tests/test_utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentityVerification(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	clearTable("votes")
+	clearTable("address_verifications")
+
+	user1, user2 := otu.AddressOf("user1"), otu.AddressOf("user2")
+	verifiers := A.IdentityVerifiers
+	A.IdentityVerifiers = map[string]shared.IdentityVerifier{
+		shared.IdentityBrightId: test_utils.StubVerifier{user1: true},
+	}
+	defer func() { A.IdentityVerifiers = verifiers }()
+	A.AdminAllowlist.Set([]string{user1})
+	defer A.AdminAllowlist.Set(nil)
+
+	verify := func(account string) *models.AddressVerification {
+		payload := models.VerifyAddressPayload{
+			Provider:                  shared.IdentityBrightId,
+			TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload(account),
+		}
+		response := otu.VerifyAddressAPI(otu.AddressOf(account), &payload)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		var v models.AddressVerification
+		json.Unmarshal(response.Body.Bytes(), &v)
+		return &v
+	}
+
+	t.Run("Should record what the provider says of the address", func(t *testing.T) {
+		assert.Equal(t, models.VerificationVerified, verify("user1").Status)
+		assert.Equal(t, models.VerificationUnverified, verify("user2").Status)
+
+		var profile models.UserProfile
+		response := otu.GetUserProfileAPI(user1)
+		json.Unmarshal(response.Body.Bytes(), &profile)
+		assert.Equal(t, []string{shared.IdentityBrightId}, profile.Verified_by)
+	})
+
+	t.Run("Should only verify with configured providers, signed by the address", func(t *testing.T) {
+		payload := models.VerifyAddressPayload{
+			Provider:                  shared.IdentityGitcoinPassport,
+			TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload("user1"),
+		}
+		response := otu.VerifyAddressAPI(user1, &payload)
+		CheckResponseCode(t, http.StatusBadRequest, response.Code)
+
+		payload.Provider = shared.IdentityBrightId
+		payload.TimestampSignaturePayload = otu.GenerateTimestampSignaturePayload("user2")
+		response = otu.VerifyAddressAPI(user1, &payload)
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+	})
+
+	communityId := otu.AddCommunitiesWithUsers(1, "user1")[0]
+	A.DB.Conn.Exec(A.DB.Context,
+		"UPDATE communities SET required_verifications = '{brightid,attestation}' WHERE id = $1", communityId)
+
+	t.Run("Communities should only take votes of verified addresses", func(t *testing.T) {
+		proposalId := otu.AddActiveProposals(communityId, 1)[0]
+
+		response := otu.CreateVoteAPI(proposalId, otu.GenerateValidVotePayload("user2", proposalId, "a"))
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+		var e errorResponse
+		json.Unmarshal(response.Body.Bytes(), &e)
+		assert.Equal(t, "ERR_1027", e.ErrorCode)
+
+		response = otu.CreateVoteAPI(proposalId, otu.GenerateValidVotePayload("user1", proposalId, "a"))
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+	})
+
+	t.Run("Platform admins should attest and revoke verifications", func(t *testing.T) {
+		payload := models.AttestAddressPayload{
+			TimestampSignaturePayload: otu.GenerateTimestampSignaturePayload("user1"),
+		}
+		response := otu.AttestAddressAPI(user2, &payload)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		proposalId := otu.AddActiveProposals(communityId, 1)[0]
+		response = otu.CreateVoteAPI(proposalId, otu.GenerateValidVotePayload("user2", proposalId, "a"))
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+
+		revoke := otu.GenerateTimestampSignaturePayload("user1")
+		response = otu.RevokeVerificationAPI(user2, shared.IdentityAttestation, &revoke)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var verifications []models.AddressVerification
+		response = otu.GetAddressVerificationsAPI(user2)
+		json.Unmarshal(response.Body.Bytes(), &verifications)
+		for _, v := range verifications {
+			assert.NotEqual(t, models.VerificationVerified, v.Status)
+		}
+
+		payload.TimestampSignaturePayload = otu.GenerateTimestampSignaturePayload("user2")
+		response = otu.AttestAddressAPI(user2, &payload)
+		CheckResponseCode(t, http.StatusForbidden, response.Code)
+	})
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 	"testing"
@@ -101,6 +102,39 @@ func TestGetVotes(t *testing.T) {
 	})
 }
 
+func TestGetVoteReceipt(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	clearTable("votes")
+	communityId := otu.AddCommunities(1, "dao")[0]
+	proposalId := otu.AddProposals(communityId, 1)[0]
+
+	t.Run("Should return a verifiable receipt for an existing vote", func(t *testing.T) {
+		otu.AddVotes(1, 1)
+		response := otu.GetVoteReceiptForProposalByAccountNameAPI(proposalId, "user1")
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		var receipt shared.SignedReceipt
+		json.Unmarshal(response.Body.Bytes(), &receipt)
+
+		assert.Nil(t, shared.VerifyReceipt(&receipt, otu.ReceiptPublicKey()))
+		assert.Equal(t, otu.A.ReceiptSigner.PublicKeyHex(), receipt.PublicKey)
+	})
+
+	t.Run("Should not verify a receipt signed with another key", func(t *testing.T) {
+		forger, _ := shared.NewReceiptSigner("")
+		receipt, _ := forger.Sign(models.VoteReceipt{Proposal_id: proposalId, Choice: "a"})
+		assert.NotNil(t, shared.VerifyReceipt(receipt, otu.ReceiptPublicKey()))
+	})
+
+	t.Run("Should fail for an address that has not voted", func(t *testing.T) {
+		clearTable("votes")
+		response := otu.GetVoteReceiptForProposalByAccountNameAPI(proposalId, "user1")
+		checkResponseCode(t, http.StatusNotFound, response.Code)
+	})
+}
+
 func TestCreateVote(t *testing.T) {
 
 	t.Run("should successfully create a vote", func(t *testing.T) {
@@ -129,3 +163,66 @@ func TestCreateVote(t *testing.T) {
 		assert.Equal(t, 1, createdVote.ID)
 	})
 }
+
+func TestResultsUpdatedOnVote(t *testing.T) {
+	resetTables()
+
+	communityId := otu.AddCommunities(1, "dao")[0]
+	proposalId := otu.AddActiveProposals(communityId, 1)[0]
+
+	// the first read stores the results
+	response := otu.GetProposalResultsAPI(proposalId)
+	CheckResponseCode(t, http.StatusOK, response.Code)
+
+	for i := 1; i <= 2; i++ {
+		response := otu.CreateVoteAPI(proposalId, otu.GenerateValidVotePayload(fmt.Sprintf("user%d", i), proposalId, "a"))
+		CheckResponseCode(t, http.StatusCreated, response.Code)
+	}
+
+	t.Run("Votes should be added to the stored results", func(t *testing.T) {
+		var incremental models.ProposalResults
+		response := otu.GetProposalResultsAPI(proposalId)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		json.Unmarshal(response.Body.Bytes(), &incremental)
+
+		_, err := A.DB.Conn.Exec(A.DB.Context, `DELETE FROM proposal_results WHERE proposal_id = $1`, proposalId)
+		assert.Nil(t, err)
+
+		var recounted models.ProposalResults
+		response = otu.GetProposalResultsAPI(proposalId)
+		CheckResponseCode(t, http.StatusOK, response.Code)
+		json.Unmarshal(response.Body.Bytes(), &recounted)
+
+		assert.Equal(t, recounted.Results, incremental.Results)
+		assert.InDelta(t, recounted.Results_float["a"], incremental.Results_float["a"], 1e-9)
+	})
+}
+
+func TestVerifyVoteSignature(t *testing.T) {
+	clearTable("communities")
+	clearTable("community_users")
+	clearTable("proposals")
+	clearTable("votes")
+	communityId := otu.AddCommunities(1, "dao")[0]
+	proposalId := otu.AddActiveProposals(communityId, 1)[0]
+
+	response := otu.CreateVoteAPI(proposalId, otu.GenerateValidVotePayload("user1", proposalId, "a"))
+	CheckResponseCode(t, http.StatusCreated, response.Code)
+
+	t.Run("Votes should record the block height their signature was checked at", func(t *testing.T) {
+		vote := models.Vote{Proposal_id: proposalId, Addr: otu.AddressOf("user1")}
+		assert.Nil(t, vote.GetVote(A.DB))
+		assert.NotNil(t, vote.Signature_block_height)
+	})
+
+	t.Run("Vote signatures should verify against the keys at that height", func(t *testing.T) {
+		response := otu.VerifyVoteAPI(proposalId, "user1")
+		CheckResponseCode(t, http.StatusOK, response.Code)
+
+		var v models.VoteVerification
+		json.Unmarshal(response.Body.Bytes(), &v)
+		assert.NotNil(t, v.Signature)
+		assert.True(t, v.Signature.Valid)
+		assert.NotZero(t, v.Signature.Block_height)
+	})
+}
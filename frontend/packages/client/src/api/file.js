@@ -11,7 +11,7 @@ export const uploadFileApiReq = async ({ image }) => {
   };
   const response = await fetch(url, fetchOptions);
   const upload = await checkResponse(response);
-  // complete url on IPFS
-  const fileUrl = `${IPFS_GETWAY}/${upload.cid}`;
+  // uploads pinned to IPFS are linked through our own gateway
+  const fileUrl = upload.cid ? `${IPFS_GETWAY}/${upload.cid}` : upload.url;
   return { ...upload, fileUrl };
 };